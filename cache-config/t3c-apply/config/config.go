@@ -106,6 +106,16 @@ type Cfg struct {
 	MaxMindLocation string
 	TsHome          string
 	TsConfigDir     string
+	// RollbackDisable is whether to not restore the previous config files if ATS fails to reload or restart,
+	// or is unhealthy afterward.
+	RollbackDisable bool
+	// HealthCheckURL is the URL of the local ATS astats or stats_over_http endpoint to check after ATS is
+	// reloaded or restarted. If empty, only the trafficserver service status is checked.
+	HealthCheckURL      string
+	HealthCheckWaitTime time.Duration
+	// Bundle is the verified config bundle to generate and apply config from, instead of requesting data from Traffic Ops.
+	// If nil, data is requested from Traffic Ops.
+	Bundle *t3cutil.ConfigBundle
 }
 
 type UseGitFlag string
@@ -235,6 +245,12 @@ func GetCfg() (Cfg, error) {
 	defaultEnableH2 := getopt.BoolLong("default-client-enable-h2", '2', "Whether to enable HTTP/2 on Delivery Services by default, if they have no explicit Parameter. This is irrelevant if ATS records.config is not serving H2. If omitted, H2 is disabled.")
 	defaultClientTLSVersions := getopt.StringLong("default-client-tls-versions", 'V', "", "Comma-delimited list of default TLS versions for Delivery Services with no Parameter, e.g. --default-tls-versions='1.1,1.2,1.3'. If omitted, all versions are enabled.")
 	maxmindLocationPtr := getopt.StringLong("maxmind-location", 'M', "", "URL of a maxmind gzipped database file, to be installed into the trafficserver etc directory.")
+	rollbackDisablePtr := getopt.BoolLong("rollback-disable", 'k', "[false | true] do not restore the previous config files and reload if ATS fails to reload or restart, or is unhealthy afterward, default is false")
+	healthCheckURLPtr := getopt.StringLong("health-check-url", 'e', "", "URL of the local ATS astats or stats_over_http endpoint to check after reloading or restarting ATS, e.g. http://127.0.0.1/_astats?application=system. If omitted, only the trafficserver service status is checked")
+	healthCheckWaitTimePtr := getopt.IntLong("health-check-wait-time", 'w', 10, "[seconds] wait [seconds] after reloading or restarting ATS before checking its health, default is 10")
+	configBundlePtr := getopt.StringLong("config-bundle", 'B', "", "Signed config bundle file from 't3c-request --get-data=config-bundle' to generate and apply config from, with no access to Traffic Ops. Requires --config-bundle-public-key")
+	configBundlePublicKeyPtr := getopt.StringLong("config-bundle-public-key", 'K', "", "PEM public key file to verify the --config-bundle signature with")
+	configBundleMaxAgePtr := getopt.IntLong("config-bundle-max-age", 'G', 0, "[seconds] reject a --config-bundle created more than [seconds] ago, default is 0, no maximum age")
 	verbosePtr := getopt.CounterLong("verbose", 'v', `Log verbosity. Logging is output to stderr. By default, errors are logged. To log warnings, pass '-v'. To log info, pass '-vv'. To omit error logging, see '-s'`)
 	silentPtr := getopt.BoolLong("silent", 's', `Silent. Errors are not logged, and the 'verbose' flag is ignored. If a fatal error occurs, the return code will be non-zero but no text will be output to stderr`)
 
@@ -296,6 +312,8 @@ func GetCfg() (Cfg, error) {
 	dnsLocalBind := *dnsLocalBindPtr
 	help := *helpPtr
 	maxmindLocation := *maxmindLocationPtr
+	healthCheckURL := strings.TrimSpace(*healthCheckURLPtr)
+	healthCheckWaitTime := time.Second * time.Duration(*healthCheckWaitTimePtr)
 
 	if help {
 		Usage()
@@ -351,24 +369,36 @@ func GetCfg() (Cfg, error) {
 	}
 
 	usageStr := "basic usage: t3c-apply --traffic-ops-url=myurl --traffic-ops-user=myuser --traffic-ops-password=mypass --cache-host-name=my-cache"
-	if strings.TrimSpace(toURL) == "" {
-		return Cfg{}, errors.New("Missing required argument --traffic-ops-url or TO_URL environment variable. " + usageStr)
-	}
-	if strings.TrimSpace(toUser) == "" {
-		return Cfg{}, errors.New("Missing required argument --traffic-ops-user or TO_USER environment variable. " + usageStr)
-	}
-	if strings.TrimSpace(toPass) == "" {
-		return Cfg{}, errors.New("Missing required argument --traffic-ops-password or TO_PASS environment variable. " + usageStr)
+	configBundlePath := strings.TrimSpace(*configBundlePtr)
+	if configBundlePath == "" { // Traffic Ops isn't used with a config bundle, so its arguments aren't required.
+		if strings.TrimSpace(toURL) == "" {
+			return Cfg{}, errors.New("Missing required argument --traffic-ops-url or TO_URL environment variable. " + usageStr)
+		}
+		if strings.TrimSpace(toUser) == "" {
+			return Cfg{}, errors.New("Missing required argument --traffic-ops-user or TO_USER environment variable. " + usageStr)
+		}
+		if strings.TrimSpace(toPass) == "" {
+			return Cfg{}, errors.New("Missing required argument --traffic-ops-password or TO_PASS environment variable. " + usageStr)
+		}
+
+		toURLParsed, err := url.Parse(toURL)
+		if err != nil {
+			return Cfg{}, errors.New("parsing Traffic Ops URL from " + urlSourceStr + " '" + toURL + "': " + err.Error())
+		} else if err = validateURL(toURLParsed); err != nil {
+			return Cfg{}, errors.New("invalid Traffic Ops URL from " + urlSourceStr + " '" + toURL + "': " + err.Error())
+		}
 	}
 	if strings.TrimSpace(cacheHostName) == "" {
 		return Cfg{}, errors.New("Missing required argument --cache-host-name. " + usageStr)
 	}
 
-	toURLParsed, err := url.Parse(toURL)
-	if err != nil {
-		return Cfg{}, errors.New("parsing Traffic Ops URL from " + urlSourceStr + " '" + toURL + "': " + err.Error())
-	} else if err = validateURL(toURLParsed); err != nil {
-		return Cfg{}, errors.New("invalid Traffic Ops URL from " + urlSourceStr + " '" + toURL + "': " + err.Error())
+	if healthCheckURL != "" {
+		healthCheckURLParsed, err := url.Parse(healthCheckURL)
+		if err != nil {
+			return Cfg{}, errors.New("parsing health check URL '" + healthCheckURL + "': " + err.Error())
+		} else if err = validateURL(healthCheckURLParsed); err != nil {
+			return Cfg{}, errors.New("invalid health check URL '" + healthCheckURL + "': " + err.Error())
+		}
 	}
 
 	svcManagement := getOSSvcManagement()
@@ -406,17 +436,55 @@ func GetCfg() (Cfg, error) {
 		MaxMindLocation:             maxmindLocation,
 		TsHome:                      TSHome,
 		TsConfigDir:                 TSConfigDir,
+		RollbackDisable:             *rollbackDisablePtr,
+		HealthCheckURL:              healthCheckURL,
+		HealthCheckWaitTime:         healthCheckWaitTime,
 	}
 
 	if err = log.InitCfg(cfg); err != nil {
 		return Cfg{}, errors.New("Initializing loggers: " + err.Error() + "\n")
 	}
 
+	// load the config bundle after initializing the loggers, because we want to log how long it takes
+	if configBundlePath != "" {
+		if cfg.Bundle, err = loadConfigBundle(configBundlePath, *configBundlePublicKeyPtr, cacheHostName, time.Second*time.Duration(*configBundleMaxAgePtr)); err != nil {
+			return Cfg{}, errors.New("loading config bundle: " + err.Error())
+		}
+	}
+
 	printConfig(cfg)
 
 	return cfg, nil
 }
 
+// loadConfigBundle reads the config bundle file, verifies its signature with the public key file, and verifies it's for the given cache,
+// no older than maxAge, and not older than the last config bundle applied.
+func loadConfigBundle(path string, publicKeyPath string, cacheHostName string, maxAge time.Duration) (*t3cutil.ConfigBundle, error) {
+	if strings.TrimSpace(publicKeyPath) == "" {
+		return nil, errors.New("missing required argument --config-bundle-public-key, config bundles must be verified")
+	}
+	publicKey, err := t3cutil.LoadVerifyingKey(publicKeyPath)
+	if err != nil {
+		return nil, errors.New("loading public key: " + err.Error())
+	}
+	bundle, err := t3cutil.ReadConfigBundle(path, publicKey)
+	if err != nil {
+		return nil, err
+	}
+	if bundle.CacheHostName != cacheHostName {
+		return nil, errors.New("config bundle is for cache '" + bundle.CacheHostName + "', not '" + cacheHostName + "'")
+	}
+	lastApplied, err := t3cutil.ReadLastAppliedBundle(t3cutil.LastAppliedBundlePath)
+	if err != nil {
+		return nil, errors.New("reading the last applied config bundle: " + err.Error())
+	}
+	if err := t3cutil.CheckConfigBundleAge(bundle, maxAge, lastApplied, time.Now()); err != nil {
+		return nil, err
+	}
+	log.Infof("Using config bundle '%s' for '%s' created %s, Traffic Ops will not be used\n", path, bundle.CacheHostName, bundle.Created.Format(time.RFC3339))
+	return bundle, nil
+}
+
 func validateURL(u *url.URL) error {
 	if u == nil {
 		return errors.New("nil url")
@@ -479,6 +547,10 @@ func printConfig(cfg Cfg) {
 	log.Debugf("WaitForParents: %v\n", cfg.WaitForParents)
 	log.Debugf("YumOptions: %s\n", cfg.YumOptions)
 	log.Debugf("MaxmindLocation: %s\n", cfg.MaxMindLocation)
+	log.Debugf("RollbackDisable: %t\n", cfg.RollbackDisable)
+	log.Debugf("HealthCheckURL: %s\n", cfg.HealthCheckURL)
+	log.Debugf("HealthCheckWaitTime: %d\n", cfg.HealthCheckWaitTime)
+	log.Debugf("ConfigBundle: %t\n", cfg.Bundle != nil)
 }
 
 func Usage() {
@@ -43,6 +43,7 @@ const (
 	ServicesError     = 138
 	SyncDSError       = 139
 	UserCheckError    = 140
+	RolledBack        = 141
 )
 
 func runSysctl(cfg config.Cfg) {
@@ -88,6 +89,11 @@ func main() {
 
 	trops := torequest.NewTrafficOpsReq(cfg)
 
+	if cfg.UseGit == config.UseGitYes || cfg.UseGit == config.UseGitAuto {
+		// snapshot the config before anything is changed, so it can be rolled back if ATS fails with the new config
+		trops.SnapshotGitConfig()
+	}
+
 	// if doing os checks, insure there is a 'systemctl' or 'service' and 'chkconfig' commands.
 	if !cfg.SkipOSCheck && cfg.SvcManagement == config.Unknown {
 		log.Errorln("OS checks are enabled and unable to find any know service management tools.")
@@ -183,8 +189,15 @@ func main() {
 	// check for maxmind db updates
 	CheckMaxmindUpdate(cfg)
 
-	if err := trops.StartServices(&syncdsUpdate); err != nil {
+	err = trops.StartServices(&syncdsUpdate)
+	if err == nil {
+		err = trops.CheckHealth()
+	}
+	if err != nil {
 		log.Errorln("failed to start services: " + err.Error())
+		if trops.RollbackEnabled() {
+			RollbackAndExit(trops, &syncdsUpdate, cfg)
+		}
 		GitCommitAndExit(ServicesError, cfg)
 	}
 
@@ -218,6 +231,12 @@ func main() {
 		log.Infoln("Traffic Ops has been updated.")
 	}
 
+	if cfg.Bundle != nil {
+		if err := t3cutil.WriteLastAppliedBundle(t3cutil.LastAppliedBundlePath, cfg.Bundle); err != nil {
+			log.Errorln("recording the applied config bundle, older bundles may be applied after it: " + err.Error())
+		}
+	}
+
 	GitCommitAndExit(Success, cfg)
 }
 
@@ -236,6 +255,21 @@ func GitCommitAndExit(exitCode int, cfg config.Cfg) {
 	os.Exit(exitCode)
 }
 
+// RollbackAndExit restores the previous config files and reloads ATS, reports the rollback to Traffic Ops,
+// and then git commits and exits with the RolledBack code, or the ServicesError code if the rollback failed
+// and ATS may still be unhealthy.
+func RollbackAndExit(trops *torequest.TrafficOpsReq, syncdsUpdate *torequest.UpdateStatus, cfg config.Cfg) {
+	exitCode := RolledBack
+	if err := trops.Rollback(syncdsUpdate); err != nil {
+		log.Errorln("failed to roll back config: " + err.Error())
+		exitCode = ServicesError
+	}
+	if _, err := trops.UpdateTrafficOps(syncdsUpdate); err != nil {
+		log.Errorf("failed to update Traffic Ops: %s\n", err.Error())
+	}
+	GitCommitAndExit(exitCode, cfg)
+}
+
 // CheckMaxmindUpdate will (if a url is set) check for a db on disk.
 // If it exists, issue an IMS to determine if it needs to update the db.
 // If no file or if an update is needed to be done it is downloaded and unpacked.
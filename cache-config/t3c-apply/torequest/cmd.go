@@ -190,9 +190,27 @@ func getUpdateStatus(cfg config.Cfg) (*tc.ServerUpdateStatus, error) {
 	if err := requestJSON(cfg, "update-status", &status); err != nil {
 		return nil, errors.New("requesting json: " + err.Error())
 	}
+	if cfg.Bundle != nil {
+		status = bundleUpdateStatus(status, cfg.RunMode)
+	}
 	return &status, nil
 }
 
+// bundleUpdateStatus returns the update status to apply a config bundle with.
+// The bundle is the config the operator chose to apply, so its update or revalidation is always pending,
+// and parents are never pending, because they can't be coordinated with without Traffic Ops.
+// Applying the same bundle again is harmless, because only changed files are applied.
+func bundleUpdateStatus(status tc.ServerUpdateStatus, mode t3cutil.Mode) tc.ServerUpdateStatus {
+	if mode == t3cutil.ModeRevalidate {
+		status.RevalPending = true
+	} else {
+		status.UpdatePending = true
+	}
+	status.ParentPending = false
+	status.ParentRevalPending = false
+	return status
+}
+
 func getSystemInfo(cfg config.Cfg) (map[string]interface{}, error) {
 	result := map[string]interface{}{}
 	if err := requestJSON(cfg, "system-info", &result); err != nil {
@@ -211,7 +229,12 @@ func getPackages(cfg config.Cfg) ([]Package, error) {
 
 // sendUpdate updates the given cache's queue update and reval status in Traffic Ops.
 // Note the statuses are the value to be set, not whether to set the value.
-func sendUpdate(cfg config.Cfg, updateStatus bool, revalStatus bool) error {
+// If configRolledBack, the cache is also reported to have rolled back the config it applied, after the statuses are set.
+func sendUpdate(cfg config.Cfg, updateStatus bool, revalStatus bool, configRolledBack bool) error {
+	if cfg.Bundle != nil {
+		log.Infof("Applied config bundle, not updating Traffic Ops update status %t reval status %t config rolled back %t\n", updateStatus, revalStatus, configRolledBack)
+		return nil
+	}
 	args := []string{
 		"--traffic-ops-timeout-milliseconds=" + strconv.FormatInt(int64(cfg.TOTimeoutMS), 10),
 		"--traffic-ops-user=" + cfg.TOUser,
@@ -222,6 +245,9 @@ func sendUpdate(cfg config.Cfg, updateStatus bool, revalStatus bool) error {
 		"--set-update-status=" + strconv.FormatBool(updateStatus),
 		"--set-reval-status=" + strconv.FormatBool(revalStatus),
 	}
+	if configRolledBack {
+		args = append(args, "--set-config-rolled-back")
+	}
 
 	if cfg.LogLocationErr == log.LogLocationNull {
 		args = append(args, "-s")
@@ -366,7 +392,11 @@ func requestJSON(cfg config.Cfg, command string, obj interface{}) error {
 }
 
 // request calls t3c-request with the given command, and returns the stdout bytes.
+// If there's a config bundle, the command's data from the bundle is returned instead.
 func request(cfg config.Cfg, command string) ([]byte, error) {
+	if cfg.Bundle != nil {
+		return requestBundle(cfg.Bundle, command)
+	}
 	args := []string{
 		"--traffic-ops-insecure=" + strconv.FormatBool(cfg.TOInsecure),
 		"--traffic-ops-timeout-milliseconds=" + strconv.FormatInt(int64(cfg.TOTimeoutMS), 10),
@@ -406,6 +436,10 @@ func request(cfg config.Cfg, command string) ([]byte, error) {
 // requestConfig calls t3c-request and returns the stdout bytes.
 // It also caches the config in /var/lib/trafficcontrol-cache-config and uses the cache to issue IMS requests.
 func requestConfig(cfg config.Cfg) ([]byte, error) {
+	if cfg.Bundle != nil {
+		return requestBundle(cfg.Bundle, "config")
+	}
+
 	// TODO support /opt
 
 	cacheBts := ([]byte)(nil)
@@ -472,6 +506,15 @@ func requestConfig(cfg config.Cfg) ([]byte, error) {
 	return stdOut, nil
 }
 
+// requestBundle returns the data of the given t3c-request command from the config bundle.
+func requestBundle(bundle *t3cutil.ConfigBundle, command string) ([]byte, error) {
+	data, ok := bundle.Data[command]
+	if !ok {
+		return nil, errors.New("config bundle has no '" + command + "' data")
+	}
+	return data, nil
+}
+
 // outToErr returns stderr if logLocation is stdout, otherwise returns logLocation unchanged.
 // This is a helper to avoid logging to stdout for commands whose output is on stdout.
 func outToErr(logLocation string) string {
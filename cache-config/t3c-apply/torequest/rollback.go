@@ -0,0 +1,294 @@
+package torequest
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/apache/trafficcontrol/cache-config/t3c-apply/config"
+	"github.com/apache/trafficcontrol/cache-config/t3c-apply/util"
+	"github.com/apache/trafficcontrol/cache-config/t3cutil"
+	"github.com/apache/trafficcontrol/lib/go-log"
+	tcutil "github.com/apache/trafficcontrol/lib/go-util"
+)
+
+const (
+	// healthCheckRetries is the number of times ATS health is checked after a reload or restart, before it's considered unhealthy.
+	healthCheckRetries       = 3
+	healthCheckRetryInterval = 5 * time.Second
+	healthCheckTimeout       = 5 * time.Second
+)
+
+// SnapshotGitConfig records the current git commit of the ATS config directory,
+// so config files in it can be restored from git if ATS fails with the new config.
+// It must be called after any existing changes are committed, and before any config files are replaced.
+func (r *TrafficOpsReq) SnapshotGitConfig() {
+	head, err := util.GetGitHead(config.TSConfigDir)
+	if err != nil {
+		log.Warnln("getting git HEAD of config dir '" + config.TSConfigDir + "', rollback will use backup files: " + err.Error())
+		return
+	}
+	r.gitSnapshot = head
+	log.Infoln("config dir '" + config.TSConfigDir + "' snapshot is git commit " + head)
+}
+
+// RollbackEnabled returns whether config changes were applied which will be health checked, and rolled back on failure.
+func (r *TrafficOpsReq) RollbackEnabled() bool {
+	if r.Cfg.RollbackDisable || len(r.changedFiles) == 0 {
+		return false
+	}
+	switch r.Cfg.RunMode {
+	case t3cutil.ModeBadAss, t3cutil.ModeSyncDS, t3cutil.ModeRevalidate:
+		return true
+	}
+	return false
+}
+
+// backupDir returns the directory config files are backed up to before they're replaced.
+// It's directly under the TmpBase, so it's removed with the other old temp directories.
+func (r *TrafficOpsReq) backupDir() string {
+	return filepath.Join(config.TmpBase, "backup_"+r.unixTimeStr)
+}
+
+// backupCfgFile copies the existing file at the config file's path to the backup directory,
+// so it can be restored if ATS fails with the new file.
+// If no file exists at the path, nothing is backed up, and the config file's CfgBackup is empty.
+func (r *TrafficOpsReq) backupCfgFile(cfg *ConfigFile) error {
+	cfg.CfgBackup = ""
+	if exists, _ := util.FileExists(cfg.Path); !exists {
+		return nil
+	}
+	data, err := ioutil.ReadFile(cfg.Path)
+	if err != nil {
+		return errors.New("reading '" + cfg.Path + "': " + err.Error())
+	}
+	backupPath := filepath.Join(r.backupDir(), cfg.Path)
+	if err := os.MkdirAll(filepath.Dir(backupPath), 0755); err != nil {
+		return errors.New("creating backup directory for '" + backupPath + "': " + err.Error())
+	}
+	if _, err := util.WriteFileWithOwner(backupPath, data, nil, nil, 0644); err != nil {
+		return errors.New("writing backup '" + backupPath + "': " + err.Error())
+	}
+	cfg.CfgBackup = backupPath
+	log.Infof("Backed up '%s' to '%s'\n", cfg.Path, backupPath)
+	return nil
+}
+
+// CheckHealth checks that ATS is healthy after config changes were applied and it was reloaded or restarted.
+// ATS is healthy if the trafficserver service is running and, if a health check URL is configured,
+// the local astats or stats_over_http endpoint returns stats.
+// Returns nil without checking if no changes will be rolled back, per RollbackEnabled.
+func (r *TrafficOpsReq) CheckHealth() error {
+	if !r.RollbackEnabled() {
+		return nil
+	}
+	log.Infof("Waiting %v to check ATS health\n", r.Cfg.HealthCheckWaitTime)
+	time.Sleep(r.Cfg.HealthCheckWaitTime)
+
+	err := error(nil)
+	for i := 0; i < healthCheckRetries; i++ {
+		if i > 0 {
+			time.Sleep(healthCheckRetryInterval)
+		}
+		if err = checkATSHealth(r.Cfg.HealthCheckURL); err == nil {
+			log.Infoln("ATS is healthy")
+			return nil
+		}
+		log.Warnf("ATS health check %d of %d failed: %s\n", i+1, healthCheckRetries, err)
+	}
+	return errors.New("ATS is unhealthy: " + err.Error())
+}
+
+func checkATSHealth(healthCheckURL string) error {
+	svcStatus, _, err := util.GetServiceStatus("trafficserver")
+	if err != nil {
+		return errors.New("getting trafficserver service status: " + err.Error())
+	} else if svcStatus != util.SvcRunning {
+		return errors.New("trafficserver is not running")
+	}
+	if healthCheckURL == "" {
+		return nil
+	}
+
+	client := http.Client{Timeout: healthCheckTimeout}
+	resp, err := client.Get(healthCheckURL)
+	if err != nil {
+		return errors.New("requesting stats from '" + healthCheckURL + "': " + err.Error())
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.New("reading stats from '" + healthCheckURL + "': " + err.Error())
+	}
+	return checkStatsResponse(resp.StatusCode, body)
+}
+
+// checkStatsResponse returns an error if the given response from an astats or stats_over_http endpoint isn't a successful JSON stats object.
+func checkStatsResponse(code int, body []byte) error {
+	if code != http.StatusOK {
+		return fmt.Errorf("stats returned status %d", code)
+	}
+	stats := map[string]interface{}{}
+	if err := json.Unmarshal(body, &stats); err != nil {
+		return errors.New("stats returned malformed JSON: " + err.Error())
+	}
+	if len(stats) == 0 {
+		return errors.New("stats returned no stats")
+	}
+	return nil
+}
+
+// Rollback restores the config files changed by this run to their previous state, and reloads or restarts ATS to apply them.
+// If git is used, the failed config is committed before it's restored, so it's kept in the config dir history.
+// The syncdsUpdate is set to UpdateTropsRolledBack, so the rollback is reported to Traffic Ops by UpdateTrafficOps.
+func (r *TrafficOpsReq) Rollback(syncdsUpdate *UpdateStatus) error {
+	*syncdsUpdate = UpdateTropsRolledBack
+	log.Errorf("Rolling back %d changed config files\n", len(r.changedFiles))
+
+	if r.gitSnapshot != "" {
+		if err := util.MakeGitCommitAll(config.TSConfigDir, util.GitChangeIsSelf, r.Cfg.RunMode, false); err != nil {
+			log.Errorln("git committing failed config, dir '" + config.TSConfigDir + "': " + err.Error())
+		}
+	}
+
+	if err := r.restoreCfgFiles(); err != nil {
+		return errors.New("restoring config files: " + err.Error())
+	}
+	if r.RemapConfigReload {
+		// ATS only re-reads remap plugin config files when remap.config changes, and restoring them doesn't change it
+		r.touchRemapConfig()
+	}
+	if err := r.reloadAfterRollback(); err != nil {
+		return errors.New("reloading ATS with the restored config files: " + err.Error())
+	}
+	if err := r.CheckHealth(); err != nil {
+		return errors.New("after restoring config files, manual intervention is required: " + err.Error())
+	}
+	log.Errorln("Config files were rolled back, and ATS is healthy with the previous config")
+	return nil
+}
+
+// restoreCfgFiles restores the config files changed by this run.
+// Files which didn't exist before are removed. Files in the ATS config directory are restored from the git snapshot if there is one,
+// and any other files, or any which fail to restore from git, are restored from their backups.
+func (r *TrafficOpsReq) restoreCfgFiles() error {
+	errs := []error{}
+	gitFiles := []string{}
+	gitCfgs := []*ConfigFile{}
+	backupCfgs := []*ConfigFile{}
+	for _, cfg := range r.configFiles {
+		if !cfg.ChangeApplied {
+			continue
+		}
+		if cfg.CfgBackup == "" {
+			log.Infof("Removing '%s', which didn't exist before\n", cfg.Path)
+			if err := os.Remove(cfg.Path); err != nil && !os.IsNotExist(err) {
+				errs = append(errs, errors.New("removing '"+cfg.Path+"': "+err.Error()))
+			}
+			continue
+		}
+		if r.gitSnapshot != "" {
+			if rel, err := filepath.Rel(config.TSConfigDir, cfg.Path); err == nil && !strings.HasPrefix(rel, "..") {
+				gitFiles = append(gitFiles, rel)
+				gitCfgs = append(gitCfgs, cfg)
+				continue
+			}
+		}
+		backupCfgs = append(backupCfgs, cfg)
+	}
+
+	if err := util.GitCheckoutFiles(config.TSConfigDir, r.gitSnapshot, gitFiles); err != nil {
+		log.Errorln("restoring config files from git, restoring from backups instead: " + err.Error())
+		backupCfgs = append(backupCfgs, gitCfgs...)
+	} else if len(gitFiles) > 0 {
+		log.Infof("Restored %s from git commit %s\n", strings.Join(gitFiles, ", "), r.gitSnapshot)
+	}
+
+	for _, cfg := range backupCfgs {
+		if err := restoreCfgFileBackup(cfg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return tcutil.JoinErrs(errs)
+}
+
+// restoreCfgFileBackup restores the config file from its backup.
+// Like replaceCfgFile, it writes a temp file and moves it, because moving is atomic but writing is not.
+func restoreCfgFileBackup(cfg *ConfigFile) error {
+	data, err := ioutil.ReadFile(cfg.CfgBackup)
+	if err != nil {
+		return errors.New("reading backup '" + cfg.CfgBackup + "': " + err.Error())
+	}
+	tmpFileName := cfg.Path + configFileTempSuffix
+	if _, err := util.WriteFileWithOwner(tmpFileName, data, &cfg.Uid, &cfg.Gid, 0644); err != nil {
+		return errors.New("writing temp config file '" + tmpFileName + "': " + err.Error())
+	}
+	if err := os.Rename(tmpFileName, cfg.Path); err != nil {
+		return errors.New("moving temp '" + tmpFileName + "' to real '" + cfg.Path + "': " + err.Error())
+	}
+	log.Infof("Restored '%s' from backup '%s'\n", cfg.Path, cfg.CfgBackup)
+	return nil
+}
+
+// touchRemapConfig updates the modification time of remap.config, so ATS reloads it and the remap plugin config files it references.
+func (r *TrafficOpsReq) touchRemapConfig() {
+	cfg, ok := r.GetConfigFile("remap.config")
+	if !ok {
+		return
+	}
+	if _, rc, err := util.ExecCommand("/usr/bin/touch", cfg.Path); err != nil {
+		log.Errorf("failed to update the restored remap.config for reloading: %s\n", err.Error())
+	} else if rc == 0 {
+		log.Infoln("updated the restored remap.config for reloading.")
+	}
+}
+
+// reloadAfterRollback reloads ATS with the restored config files.
+// If trafficserver isn't running, it's started, and in badass mode it's restarted, like StartServices.
+func (r *TrafficOpsReq) reloadAfterRollback() error {
+	svcStatus, _, err := util.GetServiceStatus("trafficserver")
+	if err != nil {
+		return errors.New("getting trafficserver service status: " + err.Error())
+	}
+	if svcStatus != util.SvcRunning || r.Cfg.RunMode == t3cutil.ModeBadAss {
+		startStr := "restart"
+		if svcStatus != util.SvcRunning {
+			startStr = "start"
+		}
+		if _, err := util.ServiceStart("trafficserver", startStr); err != nil {
+			return errors.New("failed to " + startStr + " trafficserver: " + err.Error())
+		}
+		log.Infoln("trafficserver has been " + startStr + "ed with the restored config")
+		return nil
+	}
+	if _, _, err := util.ExecCommand(config.TSHome+config.TrafficCtl, "config", "reload"); err != nil {
+		return errors.New("'traffic_ctl config reload' failed, check ATS logs: " + err.Error())
+	}
+	log.Infoln("ATS 'traffic_ctl config reload' with the restored config was successful")
+	return nil
+}
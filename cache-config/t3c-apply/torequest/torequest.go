@@ -46,6 +46,15 @@ const (
 	UpdateTropsNeeded     UpdateStatus = 1
 	UpdateTropsSuccessful UpdateStatus = 2
 	UpdateTropsFailed     UpdateStatus = 3
+	UpdateTropsRolledBack UpdateStatus = 4
+)
+
+const (
+	recordsConfigFileName = "records.config"
+	recordsYAMLFileName   = "records.yaml"
+
+	// recordsYAMLMinATSMajorVersion is the first major version of ATS which uses records.yaml instead of records.config.
+	recordsYAMLMinATSMajorVersion = 10
 )
 
 type Package struct {
@@ -70,6 +79,7 @@ type TrafficOpsReq struct {
 	TrafficServerRestart bool   // a trafficserver restart is required
 	RemapConfigReload    bool   // remap.config should be reloaded
 	unixTimeStr          string // unix time string at program startup.
+	gitSnapshot          string // git commit of the config dir before any changes were applied, empty if git isn't used.
 }
 
 type ConfigFile struct {
@@ -102,6 +112,8 @@ func (u UpdateStatus) String() string {
 		result = "UpdateTropsSuccessful"
 	case 3:
 		result = "UpdateTropsFailed"
+	case 4:
+		result = "UpdateTropsRolledBack"
 	}
 	return result
 }
@@ -471,6 +483,10 @@ func (r *TrafficOpsReq) replaceCfgFile(cfg *ConfigFile) error {
 		return nil
 	}
 
+	if err := r.backupCfgFile(cfg); err != nil {
+		return errors.New("Failed to back up config file '" + cfg.Path + "': " + err.Error())
+	}
+
 	tmpFileName := cfg.Path + configFileTempSuffix
 	log.Infof("Writing temp file '%s'\n", tmpFileName)
 
@@ -493,6 +509,7 @@ func (r *TrafficOpsReq) replaceCfgFile(cfg *ConfigFile) error {
 	r.RemapConfigReload = r.RemapConfigReload ||
 		cfg.RemapPluginConfig ||
 		cfg.Name == "remap.config" ||
+		cfg.Name == "strategies.yaml" || // ATS loads strategies.yaml with remap.config
 		strings.HasPrefix(cfg.Name, "bg_fetch") ||
 		strings.HasPrefix(cfg.Name, "hdr_rw_") ||
 		strings.HasPrefix(cfg.Name, "regex_remap_") ||
@@ -505,7 +522,8 @@ func (r *TrafficOpsReq) replaceCfgFile(cfg *ConfigFile) error {
 		strings.HasSuffix(cfg.Dir, "trafficserver") ||
 		r.RemapConfigReload ||
 		cfg.Name == "ssl_multicert.config" ||
-		cfg.Name == "records.config" ||
+		cfg.Name == recordsConfigFileName ||
+		cfg.Name == recordsYAMLFileName ||
 		(strings.HasSuffix(cfg.Dir, "ssl") && strings.HasSuffix(cfg.Name, ".cer")) ||
 		(strings.HasSuffix(cfg.Dir, "ssl") && strings.HasSuffix(cfg.Name, ".key"))
 
@@ -703,9 +721,82 @@ func (r *TrafficOpsReq) GetConfigFileList() error {
 			Perm: 0644,
 		}
 	}
+	r.selectRecordsFile()
 	return nil
 }
 
+// selectRecordsFile removes whichever of records.config and records.yaml the installed ATS doesn't use from the config files, so only the one ATS reads is written.
+// If the ATS version can't be determined, both are left as generated.
+func (r *TrafficOpsReq) selectRecordsFile() {
+	_, hasRecordsConfig := r.configFiles[recordsConfigFileName]
+	_, hasRecordsYAML := r.configFiles[recordsYAMLFileName]
+	if !hasRecordsConfig || !hasRecordsYAML {
+		return
+	}
+
+	atsMajorVer := r.atsMajorVersion()
+	if atsMajorVer == 0 {
+		log.Warnln("both " + recordsConfigFileName + " and " + recordsYAMLFileName + " were generated, but the trafficserver version could not be determined, writing both!")
+		return
+	}
+
+	unused := recordsConfigFileName
+	if atsMajorVer < recordsYAMLMinATSMajorVersion {
+		unused = recordsYAMLFileName
+	}
+	log.Infof("trafficserver major version is %v, not writing %v\n", atsMajorVer, unused)
+	delete(r.configFiles, unused)
+}
+
+// atsMajorVersion returns the major version of the installed trafficserver package.
+// If trafficserver isn't installed, it returns the major version of the trafficserver package Traffic Ops says to install.
+// Returns 0 if neither is known.
+func (r *TrafficOpsReq) atsMajorVersion() int {
+	if r.IsPackageInstalled("trafficserver") {
+		for pkg, installed := range r.pkgs {
+			if !installed {
+				continue
+			}
+			if majorVer, ok := packageMajorVersion("trafficserver", pkg); ok {
+				return majorVer
+			}
+		}
+	}
+
+	pkgs, err := getPackages(r.Cfg)
+	if err != nil {
+		log.Errorln("getting packages to determine the trafficserver version: " + err.Error())
+		return 0
+	}
+	for _, pkg := range pkgs {
+		if pkg.Name != "trafficserver" {
+			continue
+		}
+		if majorVer, ok := packageMajorVersion("trafficserver", pkg.Name+"-"+pkg.Version); ok {
+			return majorVer
+		}
+	}
+	return 0
+}
+
+// packageMajorVersion returns the major version of the given package and whether it was found, from an rpm package string like 'trafficserver-9.1.2-42.el7.x86_64'.
+// Returns false if the package string isn't the named package, e.g. 'trafficserver-devel-9.1.2'.
+func packageMajorVersion(name string, pkg string) (int, bool) {
+	if !strings.HasPrefix(pkg, name+"-") {
+		return 0, false
+	}
+	ver := strings.TrimPrefix(pkg, name+"-")
+	dotPos := strings.IndexAny(ver, ".-")
+	if dotPos == -1 {
+		dotPos = len(ver)
+	}
+	majorVer, err := strconv.Atoi(ver[:dotPos])
+	if err != nil || majorVer <= 0 {
+		return 0, false
+	}
+	return majorVer, true
+}
+
 // GetHeaderComment looks up the tm.toolname parameter from traffic ops.
 func (r *TrafficOpsReq) GetHeaderComment() string {
 	result, err := getSystemInfo(r.Cfg)
@@ -738,6 +829,10 @@ func (r *TrafficOpsReq) CheckRevalidateState(sleepOverride bool) (UpdateStatus,
 			log.Errorln("Update URL: Instant invalidate is not enabled.  Separated revalidation requires upgrading to Traffic Ops version 2.2 and enabling this feature.")
 			return UpdateTropsNotNeeded, nil
 		}
+		if serverStatus.RevalPending && serverStatus.ConfigRolledBack {
+			log.Errorln("Traffic Ops is signaling that a revalidation is waiting to be applied, but it was already applied and rolled back. Not applying it again until revalidations are queued again.")
+			return UpdateTropsNotNeeded, nil
+		}
 		if serverStatus.RevalPending == true {
 			log.Errorln("Traffic Ops is signaling that a revalidation is waiting to be applied.")
 			updateStatus = UpdateTropsNeeded
@@ -784,6 +879,11 @@ func (r *TrafficOpsReq) CheckSyncDSState() (UpdateStatus, error) {
 			return updateStatus, err
 		}
 
+		if serverStatus.UpdatePending && serverStatus.ConfigRolledBack && r.Cfg.RunMode != t3cutil.ModeBadAss {
+			log.Errorln("Traffic Ops is signaling that an update is waiting to be applied, but it was already applied and rolled back. Not applying it again until updates are queued again, or in badass mode.")
+			return UpdateTropsNotNeeded, nil
+		}
+
 		if serverStatus.UpdatePending {
 			if r.Cfg.Dispersion > 0 {
 				log.Infof("Sleeping for %ds (dispersion) before proceeding with updates.\n\n", (randDispSec / time.Second))
@@ -1181,6 +1281,8 @@ func (r *TrafficOpsReq) UpdateTrafficOps(syncdsUpdate *UpdateStatus) (bool, erro
 	} else if *syncdsUpdate == UpdateTropsFailed {
 		log.Errorln("Traffic Ops requires an update but, applying the update locally failed.  Traffic Ops is not being updated.")
 		return true, nil
+	} else if *syncdsUpdate == UpdateTropsRolledBack {
+		return r.reportRollback(serverStatus)
 	} else if *syncdsUpdate == UpdateTropsSuccessful {
 		updateResult = true
 		log.Errorln("Traffic Ops requires an update and it was applied successfully.  Clearing update state in Traffic Ops.")
@@ -1199,15 +1301,15 @@ func (r *TrafficOpsReq) UpdateTrafficOps(syncdsUpdate *UpdateStatus) (bool, erro
 		fallthrough
 	case t3cutil.ModeSyncDS:
 		if serverStatus.RevalPending {
-			err = sendUpdate(r.Cfg, false, true)
+			err = sendUpdate(r.Cfg, false, true, false)
 		} else {
-			err = sendUpdate(r.Cfg, false, false)
+			err = sendUpdate(r.Cfg, false, false, false)
 		}
 	case t3cutil.ModeRevalidate:
 		if serverStatus.UpdatePending {
-			err = sendUpdate(r.Cfg, true, false)
+			err = sendUpdate(r.Cfg, true, false, false)
 		} else {
-			err = sendUpdate(r.Cfg, false, false)
+			err = sendUpdate(r.Cfg, false, false, false)
 		}
 	}
 	if err != nil {
@@ -1217,3 +1319,21 @@ func (r *TrafficOpsReq) UpdateTrafficOps(syncdsUpdate *UpdateStatus) (bool, erro
 	log.Errorln("Traffic Ops has been updated.")
 	return true, nil
 }
+
+// reportRollback reports to Traffic Ops that the update was rolled back, by leaving the update or revalidation which failed pending, and setting the server's config rolled back status.
+// This keeps Traffic Ops from treating the server as updated, e.g. a staged rollout waiting for it will halt,
+// and keeps syncds and revalidate runs from applying the same update again, until updates are queued again.
+func (r *TrafficOpsReq) reportRollback(serverStatus *tc.ServerUpdateStatus) (bool, error) {
+	updatePending := serverStatus.UpdatePending
+	revalPending := serverStatus.RevalPending
+	if r.Cfg.RunMode == t3cutil.ModeRevalidate {
+		revalPending = true
+	} else {
+		updatePending = true
+	}
+	log.Errorf("Traffic Ops requires an update but, applying the update locally failed and was rolled back.  Setting update pending %t reval pending %t config rolled back in Traffic Ops.\n", updatePending, revalPending)
+	if err := sendUpdate(r.Cfg, updatePending, revalPending, true); err != nil {
+		return false, errors.New("Traffic Ops rollback update failed: " + err.Error())
+	}
+	return true, nil
+}
@@ -20,10 +20,16 @@ package torequest
  */
 
 import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/apache/trafficcontrol/cache-config/t3c-apply/config"
 	"github.com/apache/trafficcontrol/cache-config/t3cutil"
+	"github.com/apache/trafficcontrol/lib/go-tc"
 )
 
 var testCfg config.Cfg = config.Cfg{
@@ -141,3 +147,160 @@ func TestGetConfigFile(t *testing.T) {
 		t.Errorf("GetConfigFile('remap.config') failed, expected 'remap.config' got '" + cfg.Name + "'.")
 	}
 }
+
+func TestPackageMajorVersion(t *testing.T) {
+	type testCase struct {
+		Pkg           string
+		ExpectedVer   int
+		ExpectedFound bool
+	}
+	testCases := []testCase{
+		{"trafficserver-9.1.2-42.el7.x86_64", 9, true},
+		{"trafficserver-10.0.0-1.el8.x86_64", 10, true},
+		{"trafficserver-10", 10, true},
+		{"trafficserver-devel-9.1.2-42.el7.x86_64", 0, false},
+		{"trafficserver", 0, false},
+		{"astats_over_http-1.0", 0, false},
+	}
+	for _, tc := range testCases {
+		ver, found := packageMajorVersion("trafficserver", tc.Pkg)
+		if ver != tc.ExpectedVer || found != tc.ExpectedFound {
+			t.Errorf("packageMajorVersion('%v') expected %v %v, actual %v %v", tc.Pkg, tc.ExpectedVer, tc.ExpectedFound, ver, found)
+		}
+	}
+}
+
+func TestSelectRecordsFile(t *testing.T) {
+	trops := NewTrafficOpsReq(testCfg)
+	trops.pkgs["trafficserver-10.0.0-1.el8.x86_64"] = true
+	trops.configFiles[recordsConfigFileName] = &ConfigFile{Name: recordsConfigFileName}
+	trops.configFiles[recordsYAMLFileName] = &ConfigFile{Name: recordsYAMLFileName}
+
+	trops.selectRecordsFile()
+	if _, ok := trops.GetConfigFile(recordsConfigFileName); ok {
+		t.Errorf("selectRecordsFile() with ATS 10 expected no records.config, actual records.config")
+	}
+	if _, ok := trops.GetConfigFile(recordsYAMLFileName); !ok {
+		t.Errorf("selectRecordsFile() with ATS 10 expected records.yaml, actual none")
+	}
+
+	trops = NewTrafficOpsReq(testCfg)
+	trops.pkgs["trafficserver-9.1.2-42.el7.x86_64"] = true
+	trops.configFiles[recordsConfigFileName] = &ConfigFile{Name: recordsConfigFileName}
+	trops.configFiles[recordsYAMLFileName] = &ConfigFile{Name: recordsYAMLFileName}
+
+	trops.selectRecordsFile()
+	if _, ok := trops.GetConfigFile(recordsConfigFileName); !ok {
+		t.Errorf("selectRecordsFile() with ATS 9 expected records.config, actual none")
+	}
+	if _, ok := trops.GetConfigFile(recordsYAMLFileName); ok {
+		t.Errorf("selectRecordsFile() with ATS 9 expected no records.yaml, actual records.yaml")
+	}
+}
+
+func TestCheckStatsResponse(t *testing.T) {
+	if err := checkStatsResponse(http.StatusOK, []byte(`{"ats": {"proxy.process.http.current_client_connections": 3}}`)); err != nil {
+		t.Errorf("checkStatsResponse() with stats expected nil error, actual: %v", err)
+	}
+	if err := checkStatsResponse(http.StatusServiceUnavailable, []byte(`{"ats": {}}`)); err == nil {
+		t.Errorf("checkStatsResponse() with status 503 expected error, actual nil")
+	}
+	if err := checkStatsResponse(http.StatusOK, []byte(`<html>not stats</html>`)); err == nil {
+		t.Errorf("checkStatsResponse() with malformed JSON expected error, actual nil")
+	}
+	if err := checkStatsResponse(http.StatusOK, []byte(`{}`)); err == nil {
+		t.Errorf("checkStatsResponse() with no stats expected error, actual nil")
+	}
+}
+
+func TestRestoreCfgFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "t3c-apply-rollback")
+	if err != nil {
+		t.Fatalf("creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(path string, body string) {
+		if err := ioutil.WriteFile(path, []byte(body), 0644); err != nil {
+			t.Fatalf("writing '%s': %v", path, err)
+		}
+	}
+
+	remapPath := filepath.Join(dir, "remap.config")
+	remapBackupPath := filepath.Join(dir, "remap.config.backup")
+	newPath := filepath.Join(dir, "new.config")
+	unchangedPath := filepath.Join(dir, "unchanged.config")
+	write(remapPath, "new remap")
+	write(remapBackupPath, "old remap")
+	write(newPath, "new file")
+	write(unchangedPath, "unchanged")
+
+	trops := NewTrafficOpsReq(testCfg)
+	trops.configFiles["remap.config"] = &ConfigFile{Name: "remap.config", Path: remapPath, CfgBackup: remapBackupPath, ChangeApplied: true, Uid: os.Getuid(), Gid: os.Getgid()}
+	trops.configFiles["new.config"] = &ConfigFile{Name: "new.config", Path: newPath, ChangeApplied: true}
+	trops.configFiles["unchanged.config"] = &ConfigFile{Name: "unchanged.config", Path: unchangedPath, CfgBackup: remapBackupPath}
+
+	if err := trops.restoreCfgFiles(); err != nil {
+		t.Fatalf("restoreCfgFiles() expected nil error, actual: %v", err)
+	}
+
+	if body, err := ioutil.ReadFile(remapPath); err != nil {
+		t.Errorf("restoreCfgFiles() expected remap.config to exist, actual: %v", err)
+	} else if string(body) != "old remap" {
+		t.Errorf("restoreCfgFiles() expected remap.config restored from backup 'old remap', actual '%s'", string(body))
+	}
+	if _, err := os.Stat(newPath); !os.IsNotExist(err) {
+		t.Errorf("restoreCfgFiles() expected new.config which didn't exist before to be removed, actual: %v", err)
+	}
+	if body, err := ioutil.ReadFile(unchangedPath); err != nil || string(body) != "unchanged" {
+		t.Errorf("restoreCfgFiles() expected unchanged.config not to be restored, actual '%s' %v", string(body), err)
+	}
+}
+
+func TestBundleUpdateStatus(t *testing.T) {
+	status := tc.ServerUpdateStatus{UpdatePending: false, RevalPending: false, ParentPending: true, ParentRevalPending: true}
+
+	syncStatus := bundleUpdateStatus(status, t3cutil.ModeSyncDS)
+	if !syncStatus.UpdatePending || syncStatus.RevalPending || syncStatus.ParentPending || syncStatus.ParentRevalPending {
+		t.Errorf("bundleUpdateStatus() in syncds mode expected update pending and no reval or parents pending, actual %+v", syncStatus)
+	}
+
+	revalStatus := bundleUpdateStatus(status, t3cutil.ModeRevalidate)
+	if revalStatus.UpdatePending || !revalStatus.RevalPending || revalStatus.ParentPending || revalStatus.ParentRevalPending {
+		t.Errorf("bundleUpdateStatus() in revalidate mode expected reval pending and no update or parents pending, actual %+v", revalStatus)
+	}
+}
+
+func TestRequestBundle(t *testing.T) {
+	cfg := testCfg
+	cfg.Bundle = &t3cutil.ConfigBundle{
+		CacheHostName: cfg.CacheHostName,
+		Data: map[string]json.RawMessage{
+			"update-status": json.RawMessage(`{"upd_pending":false,"reval_pending":true,"parent_pending":true}`),
+			"statuses":      json.RawMessage(`[{"name":"REPORTED"},{"name":"ONLINE"}]`),
+		},
+	}
+
+	status, err := getUpdateStatus(cfg)
+	if err != nil {
+		t.Fatalf("getUpdateStatus() from a bundle expected nil error, actual: %v", err)
+	}
+	if !status.RevalPending || status.ParentPending {
+		t.Errorf("getUpdateStatus() from a bundle in revalidate mode expected reval pending and no parents pending, actual %+v", *status)
+	}
+
+	statuses, err := getStatuses(cfg)
+	if err != nil {
+		t.Fatalf("getStatuses() from a bundle expected nil error, actual: %v", err)
+	}
+	if len(statuses) != 2 || statuses[0] != "REPORTED" || statuses[1] != "ONLINE" {
+		t.Errorf("getStatuses() from a bundle expected [REPORTED ONLINE], actual %v", statuses)
+	}
+
+	if _, err := getPackages(cfg); err == nil {
+		t.Errorf("getPackages() from a bundle without packages expected error, actual nil")
+	}
+	if err := sendUpdate(cfg, false, false, false); err != nil {
+		t.Errorf("sendUpdate() with a bundle expected nil error without updating Traffic Ops, actual: %v", err)
+	}
+}
@@ -185,3 +185,27 @@ func makeGitCommitMsg(now time.Time, self bool, mode t3cutil.Mode, success bool)
 	const sep = " "
 	return strings.Join([]string{appStr, selfStr, modeStr, successStr, timeStr}, sep)
 }
+
+// GetGitHead returns the commit hash of HEAD in atsConfigDir.
+func GetGitHead(atsConfigDir string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "HEAD")
+	cmd.Dir = atsConfigDir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git rev-parse error: in config dir '%v' returned err %v msg '%v'", atsConfigDir, err, string(output))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// GitCheckoutFiles restores the given files, which must be paths relative to atsConfigDir, to their state at the given commit.
+func GitCheckoutFiles(atsConfigDir string, commit string, files []string) error {
+	if len(files) == 0 {
+		return nil
+	}
+	cmd := exec.Command("git", append([]string{"checkout", commit, "--"}, files...)...)
+	cmd.Dir = atsConfigDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git checkout error: in config dir '%v' returned err %v msg '%v'", atsConfigDir, err, string(output))
+	}
+	return nil
+}
@@ -196,6 +196,18 @@ func MakeRecordsDotConfig(toData *t3cutil.ConfigData, fileName string, hdrCommen
 	)
 }
 
+func MakeRecordsDotYAML(toData *t3cutil.ConfigData, fileName string, hdrCommentTxt string, cfg config.Cfg) (atscfg.Cfg, error) {
+	return atscfg.MakeRecordsDotYAML(
+		toData.Server,
+		toData.ServerParams,
+		atscfg.RecordsYAMLOpts{
+			ReleaseViaStr:           cfg.ViaRelease,
+			DNSLocalBindServiceAddr: cfg.SetDNSLocalBind,
+			HdrComment:              hdrCommentTxt,
+		},
+	)
+}
+
 func MakeRegexRevalidateDotConfig(toData *t3cutil.ConfigData, fileName string, hdrCommentTxt string, cfg config.Cfg) (atscfg.Cfg, error) {
 	return atscfg.MakeRegexRevalidateDotConfig(toData.Server, toData.DeliveryServices, toData.GlobalParams, toData.Jobs, hdrCommentTxt)
 }
@@ -225,6 +237,26 @@ func MakeStorageDotConfig(toData *t3cutil.ConfigData, fileName string, hdrCommen
 	return atscfg.MakeStorageDotConfig(toData.Server, toData.ServerParams, hdrCommentTxt)
 }
 
+func MakeStrategiesDotYAML(toData *t3cutil.ConfigData, fileName string, hdrCommentTxt string, cfg config.Cfg) (atscfg.Cfg, error) {
+	return atscfg.MakeStrategiesDotYAML(
+		toData.DeliveryServices,
+		toData.Server,
+		toData.Servers,
+		toData.Topologies,
+		toData.ServerParams,
+		toData.ParentConfigParams,
+		toData.ServerCapabilities,
+		toData.DSRequiredCapabilities,
+		toData.CacheGroups,
+		toData.DeliveryServiceServers,
+		toData.CDN,
+		atscfg.StrategiesDotYAMLOpts{
+			HdrComment:      hdrCommentTxt,
+			VerboseComments: cfg.ParentComments,
+		},
+	)
+}
+
 func MakeSysCtlDotConf(toData *t3cutil.ConfigData, fileName string, hdrCommentTxt string, cfg config.Cfg) (atscfg.Cfg, error) {
 	return atscfg.MakeSysCtlDotConf(toData.Server, toData.ServerParams, hdrCommentTxt)
 }
@@ -63,7 +63,7 @@ func Usage() {
 func InitConfig() (Cfg, error) {
 	dispersionPtr := getopt.IntLong("login-dispersion", 'l', 0, "[seconds] wait a random number of seconds between 0 and [seconds] before login to traffic ops, default 0")
 	cacheHostNamePtr := getopt.StringLong("cache-host-name", 'H', "", "Host name of the cache to generate config for. Must be the server host name in Traffic Ops, not a URL, and not the FQDN")
-	getDataPtr := getopt.StringLong("get-data", 'D', "system-info", "non-config-file Traffic Ops Data to get. Valid values are update-status, packages, chkconfig, system-info, and statuses")
+	getDataPtr := getopt.StringLong("get-data", 'D', "system-info", "non-config-file Traffic Ops Data to get. Valid values are update-status, packages, chkconfig, system-info, statuses, config, and config-bundle")
 	toInsecurePtr := getopt.BoolLong("traffic-ops-insecure", 'I', "[true | false] ignore certificate errors from Traffic Ops")
 	toTimeoutMSPtr := getopt.IntLong("traffic-ops-timeout-milliseconds", 't', 30000, "Timeout in milli-seconds for Traffic Ops requests, default is 30000")
 	toURLPtr := getopt.StringLong("traffic-ops-url", 'u', "", "Traffic Ops URL. Must be the full URL, including the scheme. Required. May also be set with     the environment variable TO_URL")
@@ -72,6 +72,7 @@ func InitConfig() (Cfg, error) {
 	disableProxyPtr := getopt.BoolLong("traffic-ops-disable-proxy", 'p', "[true | false] whether to not use any configure Traffic Ops proxy parameter. Only used if get-data is config")
 	toPassPtr := getopt.StringLong("traffic-ops-password", 'P', "", "Traffic Ops password. Required. May also be set with the environment variable TO_PASS    ")
 	oldCfgPtr := getopt.StringLong("old-config", 'c', "", "Old config from a previous config request. Optional. May be a file path, or 'stdin' to read from stdin. Used to make conditional requests.")
+	bundleSigningKeyPtr := getopt.StringLong("bundle-signing-key", 'k', "", "PEM private key file to sign config bundles with. Required if get-data is config-bundle. May be Ed25519, RSA, or ECDSA")
 	helpPtr := getopt.BoolLong("help", 'h', "Print usage information and exit")
 	versionPtr := getopt.BoolLong("version", 'V', "Print the app version")
 	verbosePtr := getopt.CounterLong("verbose", 'v', `Log verbosity. Logging is output to stderr. By default, errors are logged. To log warnings, pass '-v'. To log info, pass '-vv'. To omit error logging, see '-s'`)
@@ -167,6 +168,17 @@ func InitConfig() (Cfg, error) {
 		return Cfg{}, errors.New("initializing loggers: " + err.Error())
 	}
 
+	if *getDataPtr == t3cutil.ConfigBundleGetData {
+		if strings.TrimSpace(*bundleSigningKeyPtr) == "" {
+			return Cfg{}, errors.New("get-data " + t3cutil.ConfigBundleGetData + " requires a --bundle-signing-key")
+		}
+		signingKey, err := t3cutil.LoadSigningKey(*bundleSigningKeyPtr)
+		if err != nil {
+			return Cfg{}, errors.New("loading bundle signing key: " + err.Error())
+		}
+		cfg.BundleSigningKey = signingKey
+	}
+
 	// load old config after initializing the loggers, because we want to log how long it takes
 	oldCfg, err := LoadOldCfg(*oldCfgPtr)
 	if err != nil {
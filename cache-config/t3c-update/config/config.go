@@ -47,6 +47,7 @@ type Cfg struct {
 	GetData          string
 	UpdatePending    bool
 	RevalPending     bool
+	ConfigRolledBack bool
 	t3cutil.TCCfg
 }
 
@@ -70,6 +71,7 @@ func InitConfig() (Cfg, error) {
 	var revalPendingPtr bool
 	getopt.FlagLong(&updatePendingPtr, "set-update-status", 'q', "[true | false] sets the servers update status").Mandatory()
 	getopt.FlagLong(&revalPendingPtr, "set-reval-status", 'a', "[true | false] sets the servers revalidate status").Mandatory()
+	configRolledBackPtr := getopt.BoolLong("set-config-rolled-back", 'r', "Report that the server rolled back the update or revalidation it applied, after setting its update and revalidate statuses")
 	toInsecurePtr := getopt.BoolLong("traffic-ops-insecure", 'I', "[true | false] ignore certificate errors from Traffic Ops")
 	toTimeoutMSPtr := getopt.IntLong("traffic-ops-timeout-milliseconds", 't', 30000, "Timeout in milli-seconds for Traffic Ops requests, default is 30000")
 	toURLPtr := getopt.StringLong("traffic-ops-url", 'u', "", "Traffic Ops URL. Must be the full URL, including the scheme. Required. May also be set with     the environment variable TO_URL")
@@ -153,6 +155,7 @@ func InitConfig() (Cfg, error) {
 		LoginDispersion:  dispersion,
 		UpdatePending:    updatePendingPtr,
 		RevalPending:     revalPendingPtr,
+		ConfigRolledBack: *configRolledBackPtr,
 		TCCfg: t3cutil.TCCfg{
 			CacheHostName: cacheHostName,
 			GetData:       "update-status",
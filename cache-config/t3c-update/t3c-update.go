@@ -56,6 +56,13 @@ func main() {
 		os.Exit(3)
 	}
 
+	if cfg.ConfigRolledBack {
+		if err := t3cutil.SetConfigRolledBack(*tccfg, cfg.TCCfg.CacheHostName, true); err != nil {
+			log.Errorf("%s, %s\n", err, cfg.TCCfg.CacheHostName)
+			os.Exit(3)
+		}
+	}
+
 	cur_status, err := t3cutil.GetServerUpdateStatus(*tccfg)
 	if err != nil {
 		log.Errorf("%s, %s\n", err, cfg.TCCfg.CacheHostName)
@@ -0,0 +1,260 @@
+package t3cutil
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-log"
+)
+
+// ConfigBundleGetData is the get-data request for a signed config bundle.
+const ConfigBundleGetData = `config-bundle`
+
+// ConfigBundleGetDatas are the get-data requests whose data is included in a config bundle.
+var ConfigBundleGetDatas = []string{`config`, `update-status`, `packages`, `chkconfig`, `system-info`, `statuses`}
+
+// ConfigBundle is all the Traffic Ops data needed to generate and apply config for a server,
+// so it can be applied with no network access to Traffic Ops.
+type ConfigBundle struct {
+	CacheHostName string    `json:"cache_host_name"`
+	Created       time.Time `json:"created"`
+
+	// Data is the output of each get-data request in ConfigBundleGetDatas, by request name.
+	Data map[string]json.RawMessage `json:"data"`
+}
+
+// SignedConfigBundle is a serialized ConfigBundle and its signature.
+//
+// The bundle is kept serialized, because the signature is of its exact bytes.
+// Ed25519, RSA, and ECDSA keys are supported. RSA signatures are PKCS #1 v1.5 and ECDSA signatures are ASN.1, both of the SHA-256 digest.
+type SignedConfigBundle struct {
+	Bundle    []byte `json:"bundle"`
+	Signature []byte `json:"signature"`
+}
+
+// WriteConfigBundle writes a signed config bundle of all the data in ConfigBundleGetDatas to output.
+// The cfg.BundleSigningKey must not be nil.
+func WriteConfigBundle(cfg TCCfg, output io.Writer) error {
+	if cfg.BundleSigningKey == nil {
+		return errors.New("a signing key is required for a config bundle")
+	}
+
+	bundle := ConfigBundle{
+		CacheHostName: cfg.CacheHostName,
+		Created:       time.Now(),
+		Data:          map[string]json.RawMessage{},
+	}
+	dataFuncs := GetDataFuncs()
+	for _, getData := range ConfigBundleGetDatas {
+		buf := &bytes.Buffer{}
+		if err := dataFuncs[getData](cfg, buf); err != nil {
+			return errors.New("getting " + getData + " data: " + err.Error())
+		}
+		bundle.Data[getData] = json.RawMessage(bytes.TrimSpace(buf.Bytes()))
+	}
+
+	signed, err := SignConfigBundle(bundle, cfg.BundleSigningKey)
+	if err != nil {
+		return errors.New("signing config bundle: " + err.Error())
+	}
+	if err := json.NewEncoder(output).Encode(signed); err != nil {
+		return errors.New("encoding config bundle: " + err.Error())
+	}
+	return nil
+}
+
+// SignConfigBundle serializes and signs the bundle with the given key.
+func SignConfigBundle(bundle ConfigBundle, key crypto.Signer) (SignedConfigBundle, error) {
+	bundleBts, err := json.Marshal(bundle)
+	if err != nil {
+		return SignedConfigBundle{}, errors.New("marshalling bundle: " + err.Error())
+	}
+
+	sig := []byte(nil)
+	switch key.Public().(type) {
+	case ed25519.PublicKey:
+		sig, err = key.Sign(rand.Reader, bundleBts, crypto.Hash(0))
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+		sum := sha256.Sum256(bundleBts)
+		sig, err = key.Sign(rand.Reader, sum[:], crypto.SHA256)
+	default:
+		return SignedConfigBundle{}, errors.New("unsupported signing key type, must be Ed25519, RSA, or ECDSA")
+	}
+	if err != nil {
+		return SignedConfigBundle{}, errors.New("signing: " + err.Error())
+	}
+	return SignedConfigBundle{Bundle: bundleBts, Signature: sig}, nil
+}
+
+// VerifyConfigBundle verifies the bundle's signature with the given public key, and returns the deserialized bundle.
+// The bundle is only deserialized if the signature is valid.
+func VerifyConfigBundle(signed SignedConfigBundle, key crypto.PublicKey) (*ConfigBundle, error) {
+	valid := false
+	switch key := key.(type) {
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(key, signed.Bundle, signed.Signature)
+	case *rsa.PublicKey:
+		sum := sha256.Sum256(signed.Bundle)
+		valid = rsa.VerifyPKCS1v15(key, crypto.SHA256, sum[:], signed.Signature) == nil
+	case *ecdsa.PublicKey:
+		sum := sha256.Sum256(signed.Bundle)
+		valid = ecdsa.VerifyASN1(key, sum[:], signed.Signature)
+	default:
+		return nil, errors.New("unsupported public key type, must be Ed25519, RSA, or ECDSA")
+	}
+	if !valid {
+		return nil, errors.New("invalid signature")
+	}
+
+	bundle := &ConfigBundle{}
+	if err := json.Unmarshal(signed.Bundle, bundle); err != nil {
+		return nil, errors.New("decoding bundle: " + err.Error())
+	}
+	return bundle, nil
+}
+
+// ReadConfigBundle reads the signed config bundle at the given path, verifies its signature with the given public key, and returns the bundle.
+func ReadConfigBundle(path string, key crypto.PublicKey) (*ConfigBundle, error) {
+	defer func(start time.Time) { log.Infof("reading config bundle took %v\n", time.Since(start)) }(time.Now())
+	bts, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.New("reading config bundle file '" + path + "': " + err.Error())
+	}
+	signed := SignedConfigBundle{}
+	if err := json.Unmarshal(bts, &signed); err != nil {
+		return nil, errors.New("decoding config bundle file '" + path + "': " + err.Error())
+	}
+	bundle, err := VerifyConfigBundle(signed, key)
+	if err != nil {
+		return nil, errors.New("verifying config bundle file '" + path + "': " + err.Error())
+	}
+	return bundle, nil
+}
+
+// CheckConfigBundleAge returns an error if the bundle was created more than maxAge before now, or before the last applied bundle was created.
+// A maxAge of 0 is no maximum, and a zero lastApplied is no last applied bundle. Re-applying the last applied bundle is allowed.
+func CheckConfigBundleAge(bundle *ConfigBundle, maxAge time.Duration, lastApplied time.Time, now time.Time) error {
+	if maxAge > 0 && now.Sub(bundle.Created) > maxAge {
+		return errors.New("config bundle was created " + bundle.Created.Format(time.RFC3339) + ", more than the maximum age " + maxAge.String() + " ago")
+	}
+	if bundle.Created.Before(lastApplied) {
+		return errors.New("config bundle was created " + bundle.Created.Format(time.RFC3339) + ", before the last applied config bundle, created " + lastApplied.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// ReadLastAppliedBundle returns the creation time of the last applied config bundle, recorded in the file at the given path.
+// If the file doesn't exist, no bundle has been applied, and the zero time is returned.
+func ReadLastAppliedBundle(path string) (time.Time, error) {
+	bts, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return time.Time{}, nil
+	} else if err != nil {
+		return time.Time{}, errors.New("reading '" + path + "': " + err.Error())
+	}
+	created, err := time.Parse(time.RFC3339Nano, string(bytes.TrimSpace(bts)))
+	if err != nil {
+		return time.Time{}, errors.New("parsing '" + path + "': " + err.Error())
+	}
+	return created, nil
+}
+
+// WriteLastAppliedBundle records the bundle as the last applied config bundle, in the file at the given path.
+// The file is written to a temp file and moved, so a failed write never leaves a truncated record.
+func WriteLastAppliedBundle(path string, bundle *ConfigBundle) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.New("creating directory for '" + path + "': " + err.Error())
+	}
+	tmpPath := path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, []byte(bundle.Created.Format(time.RFC3339Nano)+"\n"), 0644); err != nil {
+		return errors.New("writing '" + tmpPath + "': " + err.Error())
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return errors.New("moving '" + tmpPath + "' to '" + path + "': " + err.Error())
+	}
+	return nil
+}
+
+// LoadSigningKey loads the PEM private key file at the given path, to sign config bundles.
+// The key may be PKCS #8, or PKCS #1 RSA, or SEC 1 ECDSA.
+func LoadSigningKey(path string) (crypto.Signer, error) {
+	block, err := readPEMFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key := interface{}(nil)
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, errors.New("parsing private key file '" + path + "': " + err.Error())
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("private key file '" + path + "' is not a signing key")
+	}
+	return signer, nil
+}
+
+// LoadVerifyingKey loads the PEM PKIX public key file at the given path, to verify config bundles.
+func LoadVerifyingKey(path string) (crypto.PublicKey, error) {
+	block, err := readPEMFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, errors.New("parsing public key file '" + path + "': " + err.Error())
+	}
+	return key, nil
+}
+
+func readPEMFile(path string) (*pem.Block, error) {
+	bts, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.New("reading key file '" + path + "': " + err.Error())
+	}
+	block, _ := pem.Decode(bts)
+	if block == nil {
+		return nil, errors.New("key file '" + path + "' is not PEM encoded")
+	}
+	return block, nil
+}
@@ -0,0 +1,125 @@
+package t3cutil
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSignVerifyConfigBundle(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generating ed25519 key: %v", err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating rsa key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating ecdsa key: %v", err)
+	}
+
+	bundle := ConfigBundle{
+		CacheHostName: "edge-01",
+		Created:       time.Date(2021, 7, 13, 0, 0, 0, 0, time.UTC),
+		Data: map[string]json.RawMessage{
+			`update-status`: json.RawMessage(`{"upd_pending":true}`),
+		},
+	}
+
+	for name, key := range map[string]crypto.Signer{"ed25519": edKey, "rsa": rsaKey, "ecdsa": ecKey} {
+		signed, err := SignConfigBundle(bundle, key)
+		if err != nil {
+			t.Fatalf("SignConfigBundle %v expected nil error, actual: %v", name, err)
+		}
+
+		verified, err := VerifyConfigBundle(signed, key.Public())
+		if err != nil {
+			t.Fatalf("VerifyConfigBundle %v expected nil error, actual: %v", name, err)
+		}
+		if verified.CacheHostName != bundle.CacheHostName || !verified.Created.Equal(bundle.Created) || string(verified.Data[`update-status`]) != `{"upd_pending":true}` {
+			t.Errorf("VerifyConfigBundle %v expected bundle %+v, actual %+v", name, bundle, *verified)
+		}
+
+		tampered := signed
+		tampered.Bundle = []byte(string(signed.Bundle[:len(signed.Bundle)-1]) + " }")
+		if _, err := VerifyConfigBundle(tampered, key.Public()); err == nil {
+			t.Errorf("VerifyConfigBundle %v with a modified bundle expected error, actual nil", name)
+		}
+	}
+
+	signed, err := SignConfigBundle(bundle, edKey)
+	if err != nil {
+		t.Fatalf("SignConfigBundle expected nil error, actual: %v", err)
+	}
+	if _, err := VerifyConfigBundle(signed, rsaKey.Public()); err == nil {
+		t.Errorf("VerifyConfigBundle with the wrong key expected error, actual nil")
+	}
+}
+
+func TestCheckConfigBundleAge(t *testing.T) {
+	now := time.Date(2021, 7, 13, 12, 0, 0, 0, time.UTC)
+	bundle := &ConfigBundle{CacheHostName: "edge-01", Created: now.Add(-2 * time.Hour)}
+
+	if err := CheckConfigBundleAge(bundle, 0, time.Time{}, now); err != nil {
+		t.Errorf("CheckConfigBundleAge with no max age or last applied bundle expected nil error, actual: %v", err)
+	}
+	if err := CheckConfigBundleAge(bundle, 3*time.Hour, bundle.Created, now); err != nil {
+		t.Errorf("CheckConfigBundleAge of the last applied bundle within the max age expected nil error, actual: %v", err)
+	}
+	if err := CheckConfigBundleAge(bundle, time.Hour, time.Time{}, now); err == nil {
+		t.Error("CheckConfigBundleAge of a bundle older than the max age expected error, actual: nil")
+	}
+	if err := CheckConfigBundleAge(bundle, 0, now.Add(-time.Hour), now); err == nil {
+		t.Error("CheckConfigBundleAge of a bundle older than the last applied bundle expected error, actual: nil")
+	}
+}
+
+func TestLastAppliedBundle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "t3c-bundle")
+	if err != nil {
+		t.Fatalf("creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "lib", "config-bundle-last-applied")
+
+	if lastApplied, err := ReadLastAppliedBundle(path); err != nil || !lastApplied.IsZero() {
+		t.Errorf("ReadLastAppliedBundle with no file expected zero time and nil error, actual: %v, %v", lastApplied, err)
+	}
+	bundle := &ConfigBundle{CacheHostName: "edge-01", Created: time.Date(2021, 7, 13, 0, 0, 0, 123, time.UTC)}
+	if err := WriteLastAppliedBundle(path, bundle); err != nil {
+		t.Fatalf("WriteLastAppliedBundle expected nil error, actual: %v", err)
+	}
+	if lastApplied, err := ReadLastAppliedBundle(path); err != nil || !lastApplied.Equal(bundle.Created) {
+		t.Errorf("ReadLastAppliedBundle expected %v, actual: %v, %v", bundle.Created, lastApplied, err)
+	}
+}
@@ -25,6 +25,10 @@ import (
 
 const ApplyCachePath = `/var/lib/trafficcontrol-cache-config/config-data.json`
 
+// LastAppliedBundlePath is the file the creation time of the last config bundle t3c-apply applied is recorded in,
+// so older bundles aren't applied after it.
+const LastAppliedBundlePath = `/var/lib/trafficcontrol-cache-config/config-bundle-last-applied`
+
 // ServiceNeeds represents whether we need to reload or restart Traffic Server,
 // as returned by t3c-check-reload.
 //
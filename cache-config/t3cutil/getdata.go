@@ -20,6 +20,7 @@ package t3cutil
  */
 
 import (
+	"crypto"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -57,6 +58,9 @@ type TCCfg struct {
 
 	// OldCfg is the previously fetched ConfigData, for 'config' requests. May be nil.
 	OldCfg *ConfigData
+
+	// BundleSigningKey is the key to sign config bundles with. This is only used by WriteConfigBundle.
+	BundleSigningKey crypto.Signer
 }
 
 func GetDataFuncs() map[string]func(TCCfg, io.Writer) error {
@@ -67,6 +71,7 @@ func GetDataFuncs() map[string]func(TCCfg, io.Writer) error {
 		`system-info`:   WriteSystemInfo,
 		`statuses`:      WriteStatuses,
 		`config`:        WriteConfig,
+		`config-bundle`: WriteConfigBundle,
 	}
 }
 
@@ -224,6 +229,16 @@ func SetUpdateStatus(cfg TCCfg, serverName string, queue bool, revalPending bool
 	return nil
 }
 
+// SetConfigRolledBack sets whether serverName rolled back the last update or revalidation it applied in Traffic Ops.
+// Setting the update or reval status clears it, so it must be set after them.
+func SetConfigRolledBack(cfg TCCfg, serverName string, rolledBack bool) error {
+	reqInf, err := cfg.TOClient.C.SetServerConfigRolledBack(serverName, rolledBack)
+	if err != nil {
+		return errors.New("setting config rolled back (Traffic Ops '" + torequtil.MaybeIPStr(reqInf.RemoteAddr) + "'): " + err.Error())
+	}
+	return nil
+}
+
 // setUpdateStatusLegacy sets the queue and reval status of serverName in Traffic Ops,
 // using the legacy pre-2.0 /update endpoint.
 func setUpdateStatusLegacy(cfg TCCfg, serverName tc.CacheName, queue bool, revalPending bool) error {
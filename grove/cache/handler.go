@@ -15,14 +15,17 @@ package cache
 */
 
 import (
+	"context"
 	"net/http"
 	"os"
 	"strconv"
+	"sync"
 	"sync/atomic"
 	"time"
 	"unsafe"
 
 	"github.com/apache/trafficcontrol/grove/cachedata"
+	"github.com/apache/trafficcontrol/grove/cacheobj"
 	"github.com/apache/trafficcontrol/grove/plugin"
 
 	"github.com/apache/trafficcontrol/grove/remap"
@@ -70,6 +73,9 @@ type Handler struct {
 	httpsConns      *web.ConnMap
 	interfaceName   string
 	requestID       uint64 // Atomic - DO NOT access or modify without atomic operations
+	// revalidating is the set of cache keys being revalidated in the background, so stale hits don't each start another revalidation.
+	revalidating  map[string]struct{}
+	revalidatingM sync.Mutex
 	// keyThrottlers     Throttlers
 	// nocacheThrottlers Throttlers
 }
@@ -134,6 +140,7 @@ func NewHandler(
 		httpConns:       httpConns,
 		httpsConns:      httpsConns,
 		interfaceName:   interfaceName,
+		revalidating:    map[string]struct{}{},
 		// keyThrottlers:     NewThrottlers(keyLimit),
 		// nocacheThrottlers: NewThrottlers(nocacheLimit),
 	}
@@ -226,6 +233,11 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	beforeCacheLookUpData := plugin.BeforeCacheLookUpData{Req: r, DefaultCacheKey: remappingProducer.CacheKey(), CacheKeyOverrideFunc: remappingProducer.OverrideCacheKey}
 	h.plugins.OnBeforeCacheLookup(remappingProducer.PluginCfg(), pluginContext, beforeCacheLookUpData)
 
+	if remappingProducer.SliceBlockBytes() > 0 && (r.Method == http.MethodGet || r.Method == http.MethodHead) {
+		h.serveSlices(r, reqHeader, responder, remappingProducer, reqCacheControl, reqTime, connectionClose, pluginContext, reqID)
+		return
+	}
+
 	cacheKey := remappingProducer.CacheKey()
 	retrier := NewRetrier(h, reqHeader, reqTime, reqCacheControl, remappingProducer, reqID)
 
@@ -272,6 +284,7 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		h.plugins.OnBeforeParentRequest(remappingProducer.PluginCfg(), pluginContext, beforeParentRequestData)
 	}
 
+	staleWarning := ""
 	switch canReuseStored {
 	case rfc.ReuseCan:
 		log.Debugf("cache.Handler.ServeHTTP: '%v' cache hit! (reqid %v)\n", cacheKey, reqID)
@@ -292,18 +305,35 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	case rfc.ReuseMustRevalidateCanStale:
+		if remappingProducer.StaleWhileRevalidate() && canStaleWhileRevalidate(cacheObj, canReuseStored) {
+			log.Debugf("cache.Handler.ServeHTTP: '%v' stale while revalidate (reqid %v)\n", cacheKey, reqID)
+			h.revalidate(r, cacheObj, remappingProducer, reqCacheControl, reqID)
+			h.addRemapStat(r.Host, stat.StatsRemap.AddStaleWhileRevalidate)
+			staleWarning = WarningResponseIsStale
+			break
+		}
 		log.Debugf("cache.Handler.ServeHTTP: '%v' must revalidate (but allowed stale) (reqid %v)\n", cacheKey, reqID)
 		oldCacheObj := cacheObj
 		cacheObj, reqHost, err = retrier.Get(r, cacheObj)
 		if err != nil {
 			log.Errorf("retrying get error - serving stale as allowed: %v (reqid %v)\n", err, reqID)
 			cacheObj = oldCacheObj
+			staleWarning = WarningRevalidationFailed
+		} else if remappingProducer.StaleIfError() && isRevalidationError(cacheObj, err) && canStaleIfError(reqCacheControl, oldCacheObj, canReuseStored) {
+			log.Debugf("cache.Handler.ServeHTTP: '%v' revalidating got %v - serving stale if error (reqid %v)\n", cacheKey, cacheObj.Code, reqID)
+			cacheObj = oldCacheObj
+			staleWarning = WarningRevalidationFailed
+			h.addRemapStat(r.Host, stat.StatsRemap.AddStaleIfError)
 		}
 	}
 	log.Debugf("cache.Handler.ServeHTTP: '%v' responding with %v (reqid %v)\n", cacheKey, cacheObj.Code, reqID)
 
 	// create new pointers, so plugins don't modify the cacheObj
 	codePtr, hdrsPtr, bodyPtr := cacheObj.Code, cacheObj.RespHeaders, cacheObj.Body
+	if staleWarning != "" {
+		hdrsPtr = web.CopyHeader(hdrsPtr) // must copy, because the cacheObj headers may be concurrently read by other goroutines
+		hdrsPtr.Add("Warning", staleWarning)
+	}
 	responder.SetResponse(&codePtr, &hdrsPtr, &bodyPtr, connectionClose)
 	responder.OriginReqSuccess = true
 	responder.Reuse = canReuseStored
@@ -317,3 +347,44 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.plugins.OnBeforeRespond(remappingProducer.PluginCfg(), pluginContext, beforeRespData)
 	responder.Do()
 }
+
+// revalidate revalidates the given stale cached object in the background, for stale-while-revalidate. If the object's key is already being revalidated, this does nothing.
+func (h *Handler) revalidate(r *http.Request, cacheObj *cacheobj.CacheObj, remappingProducer *remap.RemappingProducer, reqCacheControl rfc.CacheControlMap, reqID uint64) {
+	cacheKey := remappingProducer.CacheKey()
+	h.revalidatingM.Lock()
+	if _, ok := h.revalidating[cacheKey]; ok {
+		h.revalidatingM.Unlock()
+		return
+	}
+	h.revalidating[cacheKey] = struct{}{}
+	h.revalidatingM.Unlock()
+
+	// The request must be copied, because it isn't valid after the client is responded to, and the producer must be copied, because retries modify it.
+	bgReq := r.Clone(context.Background())
+	retrier := NewRetrier(h, web.CopyHeader(r.Header), time.Now(), reqCacheControl, remappingProducer.Copy(), reqID)
+	h.addRemapStat(r.Host, stat.StatsRemap.AddBackgroundRevalidation)
+
+	go func() {
+		defer func() {
+			h.revalidatingM.Lock()
+			delete(h.revalidating, cacheKey)
+			h.revalidatingM.Unlock()
+		}()
+		obj, _, err := retrier.Get(bgReq, cacheObj)
+		if isRevalidationError(obj, err) {
+			code := 0
+			if obj != nil {
+				code = obj.Code
+			}
+			log.Errorf("background revalidation of '%v' failed: code %v error %v (reqid %v)\n", cacheKey, code, err, reqID)
+			h.addRemapStat(bgReq.Host, stat.StatsRemap.AddBackgroundRevalidationFailure)
+		}
+	}()
+}
+
+// addRemapStat calls the given add func with the remap stats of the given request FQDN, if any exist.
+func (h *Handler) addRemapStat(fqdn string, add func(stat.StatsRemap)) {
+	if remapStats, ok := h.stats.Remap().Stats(fqdn); ok {
+		add(remapStats)
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/apache/trafficcontrol/grove/cacheobj"
 	"github.com/apache/trafficcontrol/grove/icache"
 	"github.com/apache/trafficcontrol/grove/remap"
+	"github.com/apache/trafficcontrol/grove/stat"
 	"github.com/apache/trafficcontrol/grove/thread"
 	"github.com/apache/trafficcontrol/grove/web"
 
@@ -59,10 +60,30 @@ func (r *Retrier) Get(req *http.Request, obj *cacheobj.CacheObj) (*cacheobj.Cach
 		canReuse := func(cacheObj *cacheobj.CacheObj) bool {
 			return cacheobj.CanReuse(r.ReqHdr, r.ReqCacheControl, cacheObj, r.H.strictRFC, true)
 		}
+		// With strict collapsing, waiters always use the author's response, rather than making their own requests.
+		canUseCollapsed := canReuse
+		collapse := r.RemappingProducer.CollapseRequests()
+		if collapse {
+			canUseCollapsed = func(*cacheobj.CacheObj) bool { return true }
+		}
+		collapsedCached := false
 		getAndCache := func() *cacheobj.CacheObj {
+			if collapse && obj == nil {
+				// A concurrent request may have fetched and cached the object after our cache miss, but before we became the author.
+				if cached, ok := remapping.Cache.Peek(remapping.CacheKey); ok && canReuse(cached) {
+					collapsedCached = true
+					return cached
+				}
+			}
 			return GetAndCache(remapping.Request, remapping.ProxyURL, remapping.CacheKey, remapping.Name, remapping.Request.Header, r.ReqTime, r.H.strictRFC, remapping.Cache, r.H.ruleThrottlers[remapping.Name], obj, remapping.Timeout, retryFailures, remapping.RetryNum, remapping.RetryCodes, remapping.Transport, r.ReqID)
 		}
-		gotObj, getReqID := r.H.getter.Get(remapping.CacheKey, getAndCache, canReuse, r.ReqID)
+		gotObj, getReqID := r.H.getter.Get(remapping.CacheKey, getAndCache, canUseCollapsed, r.ReqID)
+		if getReqID != r.ReqID || collapsedCached {
+			r.H.addRemapStat(req.Host, stat.StatsRemap.AddCollapsedRequest)
+		} else if latency := gotObj.ReqRespTime.Sub(gotObj.ReqTime); latency >= 0 {
+			// only the request which actually made the parent request observes its latency
+			r.H.addRemapStat(req.Host, func(s stat.StatsRemap) { s.ObserveParentLatency(latency) })
+		}
 
 		req := remapping.Request
 		log.Debugf("Retrier.Get Y URI %v %v %v remapping.CacheKey %v rule %v parent %v code %v headers %+v len(body) %v getterid %v (reqid %v)\n", req.URL.Scheme, req.URL.Host, req.URL.EscapedPath(), remapping.CacheKey, remapping.Name, remapping.ProxyURL, gotObj.Code, gotObj.RespHeaders, len(gotObj.Body), getReqID, r.ReqID)
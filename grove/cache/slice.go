@@ -0,0 +1,354 @@
+package cache
+
+/*
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/trafficcontrol/grove/cacheobj"
+	"github.com/apache/trafficcontrol/grove/plugin"
+	"github.com/apache/trafficcontrol/grove/remap"
+	"github.com/apache/trafficcontrol/grove/web"
+
+	"github.com/apache/trafficcontrol/lib/go-log"
+	"github.com/apache/trafficcontrol/lib/go-rfc"
+)
+
+// SliceBlockKeyParam is the query parameter added to the cache key of an object to make the cache key of one of its slice blocks.
+const SliceBlockKeyParam = "grove_slice_block"
+
+// sliceBlockKey returns the cache key of the block with the given index, of the object with the given cache key. The block size is included, so changing it never serves blocks of the old size.
+func sliceBlockKey(cacheKey string, blockBytes int64, index int64) string {
+	sep := "?"
+	if strings.Contains(cacheKey, "?") {
+		sep = "&"
+	}
+	return cacheKey + sep + SliceBlockKeyParam + "=" + strconv.FormatInt(blockBytes, 10) + "-" + strconv.FormatInt(index, 10)
+}
+
+// sliceRange is a single byte range requested by a client. A negative Start is a suffix range of the last End bytes, and a negative End is an open-ended range.
+type sliceRange struct {
+	Start int64
+	End   int64
+}
+
+// parseSliceRange parses the value of a Range header with a single byte range. Like ATS's slice plugin, multiple ranges are not supported, and they and invalid ranges return false, in which case the full object should be served.
+func parseSliceRange(hdr string) (sliceRange, bool) {
+	const prefix = "bytes="
+	if !strings.HasPrefix(hdr, prefix) {
+		return sliceRange{}, false
+	}
+	spec := strings.TrimSpace(hdr[len(prefix):])
+	if strings.Contains(spec, ",") {
+		return sliceRange{}, false
+	}
+	dash := strings.Index(spec, "-")
+	if dash == -1 {
+		return sliceRange{}, false
+	}
+	startStr, endStr := strings.TrimSpace(spec[:dash]), strings.TrimSpace(spec[dash+1:])
+	if startStr == "" {
+		suffix, err := strconv.ParseInt(endStr, 10, 64)
+		if err != nil || suffix <= 0 {
+			return sliceRange{}, false
+		}
+		return sliceRange{Start: -1, End: suffix}, true
+	}
+	start, err := strconv.ParseInt(startStr, 10, 64)
+	if err != nil || start < 0 {
+		return sliceRange{}, false
+	}
+	if endStr == "" {
+		return sliceRange{Start: start, End: -1}, true
+	}
+	end, err := strconv.ParseInt(endStr, 10, 64)
+	if err != nil || end < start {
+		return sliceRange{}, false
+	}
+	return sliceRange{Start: start, End: end}, true
+}
+
+// resolve returns the first and last bytes of the range, in an object of the given total size, and false if the range isn't satisfiable.
+func (r sliceRange) resolve(total int64) (int64, int64, bool) {
+	start, end := r.Start, r.End
+	if start < 0 {
+		if end > total {
+			end = total
+		}
+		start, end = total-end, total-1
+	} else if end < 0 || end >= total {
+		end = total - 1
+	}
+	if start >= total {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+// parseContentRange parses the value of a Content-Range header of a byte range, returning the first byte, last byte, and total size, and false if it's invalid or the total size is unknown.
+func parseContentRange(hdr string) (int64, int64, int64, bool) {
+	const prefix = "bytes "
+	if !strings.HasPrefix(hdr, prefix) {
+		return 0, 0, 0, false
+	}
+	spec := hdr[len(prefix):]
+	slash := strings.Index(spec, "/")
+	dash := strings.Index(spec, "-")
+	if slash == -1 || dash == -1 || dash > slash {
+		return 0, 0, 0, false
+	}
+	start, err := strconv.ParseInt(spec[:dash], 10, 64)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	end, err := strconv.ParseInt(spec[dash+1:slash], 10, 64)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	total, err := strconv.ParseInt(spec[slash+1:], 10, 64)
+	if err != nil || start > end || end >= total {
+		return 0, 0, 0, false
+	}
+	return start, end, total, true
+}
+
+// sameSliceObject returns whether the given block is of the same version of the object as the reference block, per their ETag, or Last-Modified if the reference has no ETag.
+func sameSliceObject(ref *cacheobj.CacheObj, block *cacheobj.CacheObj) bool {
+	if etag := ref.RespHeaders.Get("ETag"); etag != "" {
+		return block.RespHeaders.Get("ETag") == etag
+	}
+	return block.RespHeaders.Get("Last-Modified") == ref.RespHeaders.Get("Last-Modified")
+}
+
+// validSliceBlock returns whether the given object is the block with the given index, of an object of the given total size, from the same version of the object as the reference block.
+func validSliceBlock(ref *cacheobj.CacheObj, block *cacheobj.CacheObj, blockBytes int64, index int64, total int64) bool {
+	if block.Code != http.StatusPartialContent {
+		return false
+	}
+	start, end, blockTotal, ok := parseContentRange(block.RespHeaders.Get("Content-Range"))
+	if !ok || blockTotal != total || start != index*blockBytes {
+		return false
+	}
+	expectedLen := blockBytes
+	if remaining := total - start; remaining < expectedLen {
+		expectedLen = remaining
+	}
+	if end-start+1 != expectedLen || int64(len(block.Body)) != expectedLen {
+		return false
+	}
+	return sameSliceObject(ref, block)
+}
+
+// slicer fetches the blocks of an object, for a single client request to a remap rule with slicing enabled.
+type slicer struct {
+	h          *Handler
+	req        *http.Request
+	producer   *remap.RemappingProducer
+	reqCC      rfc.CacheControlMap
+	reqTime    time.Time
+	reqID      uint64
+	blockBytes int64
+	// allHits is whether every block fetched so far was a cache hit.
+	allHits bool
+}
+
+// getBlock returns the block with the given index, from the cache if it can be reused, otherwise from the parent. If refetch is true, the cache isn't used, and the block is always fetched from the parent.
+func (s *slicer) getBlock(index int64, refetch bool) (*cacheobj.CacheObj, error) {
+	key := sliceBlockKey(s.producer.CacheKey(), s.blockBytes, index)
+	revalidateObj := (*cacheobj.CacheObj)(nil)
+	if !refetch {
+		if obj, ok := s.producer.Cache().Get(key); ok {
+			switch rfc.CanReuseStored(s.req.Header, obj.RespHeaders, s.reqCC, obj.RespCacheControl, obj.ReqHeaders, obj.ReqRespTime, obj.RespRespTime, s.h.strictRFC) {
+			case rfc.ReuseCan:
+				return obj, nil
+			case rfc.ReuseMustRevalidate, rfc.ReuseMustRevalidateCanStale:
+				revalidateObj = obj
+			}
+		}
+	}
+	s.allHits = false
+
+	// Blocks are always requested with GET, because they're shared with GET requests.
+	blockReq := s.req.Clone(s.req.Context())
+	blockReq.Method = http.MethodGet
+	blockReq.Header.Set("Range", "bytes="+strconv.FormatInt(index*s.blockBytes, 10)+"-"+strconv.FormatInt((index+1)*s.blockBytes-1, 10))
+	producer := s.producer.Copy()
+	producer.OverrideCacheKey(key)
+
+	obj, _, err := NewRetrier(s.h, blockReq.Header, s.reqTime, s.reqCC, producer, s.reqID).Get(blockReq, revalidateObj)
+	if err != nil {
+		return nil, err
+	}
+	if obj == nil {
+		return nil, errors.New("no object returned")
+	}
+	return obj, nil
+}
+
+// getValidBlock returns the block with the given index, of an object of the given total size, which must be from the same version of the object as the reference block. If the block doesn't match, it's refetched from the parent. If it still doesn't match, the object changed at the parent, so the reference block is also refetched, for later requests, and an error is returned.
+func (s *slicer) getValidBlock(ref *cacheobj.CacheObj, index int64, total int64) (*cacheobj.CacheObj, error) {
+	block, err := s.getBlock(index, false)
+	if err != nil {
+		return nil, err
+	}
+	if validSliceBlock(ref, block, s.blockBytes, index, total) {
+		return block, nil
+	}
+	log.Infof("slice block %v of '%v' doesn't match the reference block, refetching (reqid %v)\n", index, s.producer.CacheKey(), s.reqID)
+	if block, err = s.getBlock(index, true); err != nil {
+		return nil, err
+	}
+	if validSliceBlock(ref, block, s.blockBytes, index, total) {
+		return block, nil
+	}
+	if _, err := s.getBlock(0, true); err != nil {
+		log.Errorf("refetching slice reference block of '%v': %v (reqid %v)\n", s.producer.CacheKey(), err, s.reqID)
+	}
+	return nil, errors.New("block " + strconv.FormatInt(index, 10) + " code " + strconv.Itoa(block.Code) + " doesn't match the reference block")
+}
+
+// writeRange writes the bytes from start to end, inclusive, of an object of the given total size, to w. If whole is true, the reference is the whole object, and no blocks are fetched. Returns the bytes written.
+func (s *slicer) writeRange(w http.ResponseWriter, ref *cacheobj.CacheObj, whole bool, start int64, end int64, total int64) (uint64, error) {
+	if whole {
+		n, err := w.Write(ref.Body[start : end+1])
+		return uint64(n), err
+	}
+	written := uint64(0)
+	for index := start / s.blockBytes; index <= end/s.blockBytes; index++ {
+		block := ref
+		if index != 0 {
+			err := error(nil)
+			if block, err = s.getValidBlock(ref, index, total); err != nil {
+				return written, errors.New("getting slice block: " + err.Error())
+			}
+		}
+		blockStart := index * s.blockBytes
+		from, to := int64(0), int64(len(block.Body))
+		if start > blockStart {
+			from = start - blockStart
+		}
+		if end+1-blockStart < to {
+			to = end + 1 - blockStart
+		}
+		n, err := w.Write(block.Body[from:to])
+		written += uint64(n)
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// serveSlices responds to the request with slices of the object, which are fetched and cached as separate blocks, of the remap rule's block size. It behaves like the ATS slice plugin: block 0 is the reference block, whose ETag or Last-Modified every other block must match, and client requests for a single byte range are served from only the blocks containing it.
+// The response body is written as each block is fetched, so BeforeRespond plugins may modify the code and headers, but not the body.
+func (h *Handler) serveSlices(
+	r *http.Request,
+	reqHeader http.Header,
+	responder *Responder,
+	remappingProducer *remap.RemappingProducer,
+	reqCacheControl rfc.CacheControlMap,
+	reqTime time.Time,
+	connectionClose bool,
+	pluginContext map[string]*interface{},
+	reqID uint64,
+) {
+	beforeParentRequestData := plugin.BeforeParentRequestData{Req: r, RemapRule: remappingProducer.Name()}
+	h.plugins.OnBeforeParentRequest(remappingProducer.PluginCfg(), pluginContext, beforeParentRequestData)
+
+	s := &slicer{h: h, req: r, producer: remappingProducer, reqCC: reqCacheControl, reqTime: reqTime, reqID: reqID, blockBytes: remappingProducer.SliceBlockBytes(), allHits: true}
+	ref, err := s.getBlock(0, false)
+	if err != nil {
+		log.Errorf("getting slice reference block of '%v': %v (reqid %v)\n", remappingProducer.CacheKey(), err, reqID)
+		responder.OriginConnectFailed = true
+		responder.Do()
+		return
+	}
+	responder.OriginReqSuccess = true
+	responder.OriginCode = ref.OriginCode
+	responder.ProxyStr = ref.ProxyURL
+
+	total := int64(0)
+	whole := false
+	switch ref.Code {
+	case http.StatusPartialContent:
+		start, _, refTotal, ok := parseContentRange(ref.RespHeaders.Get("Content-Range"))
+		if !ok || start != 0 || !validSliceBlock(ref, ref, s.blockBytes, 0, refTotal) {
+			log.Errorf("slice reference block of '%v' has invalid Content-Range '%v' (reqid %v)\n", remappingProducer.CacheKey(), ref.RespHeaders.Get("Content-Range"), reqID)
+			*responder.ResponseCode = http.StatusBadGateway
+			responder.Do()
+			return
+		}
+		total = refTotal
+	case http.StatusOK:
+		// the parent doesn't support ranges, or ignored it, so the reference block is the whole object
+		total = int64(len(ref.Body))
+		whole = true
+	default:
+		// errors are served to the client as-is
+		codePtr, hdrsPtr, bodyPtr := ref.Code, ref.RespHeaders, ref.Body
+		responder.SetResponse(&codePtr, &hdrsPtr, &bodyPtr, connectionClose)
+		beforeRespData := plugin.BeforeRespondData{Req: r, CacheObj: ref, Code: &codePtr, Hdr: &hdrsPtr, Body: &bodyPtr, RemapRule: remappingProducer.Name()}
+		h.plugins.OnBeforeRespond(remappingProducer.PluginCfg(), pluginContext, beforeRespData)
+		responder.Do()
+		return
+	}
+
+	code := http.StatusOK
+	start, end := int64(0), total-1
+	hdrs := web.CopyHeader(ref.RespHeaders)
+	hdrs.Del("Content-Range")
+	if rng, ok := parseSliceRange(reqHeader.Get("Range")); ok {
+		if start, end, ok = rng.resolve(total); !ok {
+			log.Debugf("slice range '%v' of '%v' not satisfiable (reqid %v)\n", reqHeader.Get("Range"), remappingProducer.CacheKey(), reqID)
+			hdrs = http.Header{}
+			hdrs.Set("Content-Range", "bytes */"+strconv.FormatInt(total, 10))
+			code := http.StatusRequestedRangeNotSatisfiable
+			body := []byte(http.StatusText(code))
+			responder.SetResponse(&code, &hdrs, &body, connectionClose)
+			responder.Do()
+			return
+		}
+		code = http.StatusPartialContent
+		hdrs.Set("Content-Range", "bytes "+strconv.FormatInt(start, 10)+"-"+strconv.FormatInt(end, 10)+"/"+strconv.FormatInt(total, 10))
+	}
+	hdrs.Set("Content-Length", strconv.FormatInt(end-start+1, 10))
+
+	body := []byte(nil) // the body is written by the responder, as blocks are fetched
+	beforeRespData := plugin.BeforeRespondData{Req: r, CacheObj: ref, Code: &code, Hdr: &hdrs, Body: &body, RemapRule: remappingProducer.Name()}
+	h.plugins.OnBeforeRespond(remappingProducer.PluginCfg(), pluginContext, beforeRespData)
+
+	responder.ResponseCode = &code
+	responder.F = func() (uint64, error) {
+		dH := responder.W.Header()
+		web.CopyHeaderTo(hdrs, &dH)
+		if connectionClose {
+			dH.Add("Connection", "close")
+		}
+		responder.W.WriteHeader(code)
+		if r.Method == http.MethodHead || total == 0 {
+			return 0, nil
+		}
+		written, err := s.writeRange(responder.W, ref, whole, start, end, total)
+		if s.allHits {
+			responder.Reuse = rfc.ReuseCan
+		}
+		return written, err
+	}
+	responder.Do()
+}
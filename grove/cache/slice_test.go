@@ -0,0 +1,216 @@
+package cache
+
+/*
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/apache/trafficcontrol/grove/remapdata"
+)
+
+func TestParseSliceRange(t *testing.T) {
+	tests := []struct {
+		hdr           string
+		ok            bool
+		start, end    int64
+		satisfiable   bool
+		resolvedStart int64
+		resolvedEnd   int64
+	}{
+		{"bytes=0-99", true, 0, 99, true, 0, 99},
+		{"bytes=100-", true, 100, -1, true, 100, 999},
+		{"bytes=-100", true, -1, 100, true, 900, 999},
+		{"bytes=-2000", true, -1, 2000, true, 0, 999},
+		{"bytes=500-5000", true, 500, 5000, true, 500, 999},
+		{"bytes=1000-", true, 1000, -1, false, 0, 0},
+		{"bytes=0-1,5-10", false, 0, 0, false, 0, 0},
+		{"bytes=10-5", false, 0, 0, false, 0, 0},
+		{"bytes=-0", false, 0, 0, false, 0, 0},
+		{"items=0-99", false, 0, 0, false, 0, 0},
+		{"", false, 0, 0, false, 0, 0},
+	}
+	for _, test := range tests {
+		rng, ok := parseSliceRange(test.hdr)
+		if ok != test.ok {
+			t.Errorf("range '%s': expected ok %v, actual: %v", test.hdr, test.ok, ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if rng.Start != test.start || rng.End != test.end {
+			t.Errorf("range '%s': expected %v-%v, actual: %v-%v", test.hdr, test.start, test.end, rng.Start, rng.End)
+		}
+		start, end, satisfiable := rng.resolve(1000)
+		if satisfiable != test.satisfiable {
+			t.Errorf("range '%s': expected satisfiable %v, actual: %v", test.hdr, test.satisfiable, satisfiable)
+		} else if satisfiable && (start != test.resolvedStart || end != test.resolvedEnd) {
+			t.Errorf("range '%s': expected resolved %v-%v, actual: %v-%v", test.hdr, test.resolvedStart, test.resolvedEnd, start, end)
+		}
+	}
+}
+
+// sliceOrigin is a test origin which serves a single object, supporting ranges, and counts the requests it gets.
+type sliceOrigin struct {
+	m        sync.Mutex
+	body     []byte
+	modTime  time.Time
+	requests int
+}
+
+func (o *sliceOrigin) set(body []byte, modTime time.Time) {
+	o.m.Lock()
+	defer o.m.Unlock()
+	o.body, o.modTime = body, modTime
+}
+
+func (o *sliceOrigin) count() int {
+	o.m.Lock()
+	defer o.m.Unlock()
+	return o.requests
+}
+
+func (o *sliceOrigin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	o.m.Lock()
+	o.requests++
+	body, modTime := o.body, o.modTime
+	o.m.Unlock()
+	w.Header().Set("Cache-Control", "max-age=60")
+	http.ServeContent(w, r, "obj", modTime, bytes.NewReader(body))
+}
+
+func makeSliceBody(size int, seed byte) []byte {
+	body := make([]byte, size)
+	for i := range body {
+		body[i] = byte(i%251) + seed
+	}
+	return body
+}
+
+func newSliceTestHandler(t *testing.T, originURL string) *Handler {
+	return newTestHandler(t, originURL, `"slice_block_bytes": 262144,`)
+}
+
+func sliceRequest(h *Handler, rangeHdr string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/obj", nil)
+	r.Host = "grove.test"
+	if rangeHdr != "" {
+		r.Header.Set("Range", rangeHdr)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestServeSlices(t *testing.T) {
+	const blockBytes = remapdata.MinSliceBlockBytes
+	bodyA := makeSliceBody(blockBytes*2+1000, 0)
+	origin := &sliceOrigin{}
+	origin.set(bodyA, time.Now().Add(-time.Hour).Truncate(time.Second))
+	srv := httptest.NewServer(origin)
+	defer srv.Close()
+	h := newSliceTestHandler(t, srv.URL)
+
+	w := sliceRequest(h, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("full request: expected code %v, actual: %v", http.StatusOK, w.Code)
+	}
+	if !bytes.Equal(w.Body.Bytes(), bodyA) {
+		t.Errorf("full request: expected body of %v bytes, actual: %v bytes", len(bodyA), w.Body.Len())
+	}
+	if origin.count() != 3 {
+		t.Errorf("full request: expected 3 block requests, actual: %v", origin.count())
+	}
+
+	w = sliceRequest(h, "bytes=262000-524400")
+	if w.Code != http.StatusPartialContent {
+		t.Fatalf("range request: expected code %v, actual: %v", http.StatusPartialContent, w.Code)
+	}
+	if !bytes.Equal(w.Body.Bytes(), bodyA[262000:524401]) {
+		t.Errorf("range request: expected body of %v bytes, actual: %v bytes", 524401-262000, w.Body.Len())
+	}
+	if expected := "bytes 262000-524400/525288"; w.Header().Get("Content-Range") != expected {
+		t.Errorf("range request: expected Content-Range '%v', actual: '%v'", expected, w.Header().Get("Content-Range"))
+	}
+	if origin.count() != 3 {
+		t.Errorf("range request: expected cached blocks, actual origin requests: %v", origin.count())
+	}
+
+	w = sliceRequest(h, "bytes=-100")
+	if w.Code != http.StatusPartialContent || !bytes.Equal(w.Body.Bytes(), bodyA[len(bodyA)-100:]) {
+		t.Errorf("suffix range request: expected last 100 bytes, actual code %v body of %v bytes", w.Code, w.Body.Len())
+	}
+
+	w = sliceRequest(h, "bytes=600000-")
+	if w.Code != http.StatusRequestedRangeNotSatisfiable {
+		t.Errorf("unsatisfiable range request: expected code %v, actual: %v", http.StatusRequestedRangeNotSatisfiable, w.Code)
+	}
+	if expected := "bytes */525288"; w.Header().Get("Content-Range") != expected {
+		t.Errorf("unsatisfiable range request: expected Content-Range '%v', actual: '%v'", expected, w.Header().Get("Content-Range"))
+	}
+}
+
+func TestServeSlicesMismatch(t *testing.T) {
+	const blockBytes = remapdata.MinSliceBlockBytes
+	bodyA := makeSliceBody(blockBytes*2+1000, 0)
+	bodyB := makeSliceBody(blockBytes*2+1000, 1)
+	origin := &sliceOrigin{}
+	origin.set(bodyA, time.Now().Add(-time.Hour).Truncate(time.Second))
+	srv := httptest.NewServer(origin)
+	defer srv.Close()
+	h := newSliceTestHandler(t, srv.URL)
+
+	// cache only the reference block of the old object
+	if w := sliceRequest(h, "bytes=0-99"); w.Code != http.StatusPartialContent {
+		t.Fatalf("expected code %v, actual: %v", http.StatusPartialContent, w.Code)
+	}
+
+	origin.set(bodyB, time.Now().Truncate(time.Second))
+	w := sliceRequest(h, "bytes=524288-")
+	if w.Body.Len() != 0 {
+		t.Errorf("expected a block mismatching the reference to abort the response, actual body: %v bytes", w.Body.Len())
+	}
+
+	// the reference block was refetched, so the object is consistent again
+	w = sliceRequest(h, "")
+	if w.Code != http.StatusOK || !bytes.Equal(w.Body.Bytes(), bodyB) {
+		t.Errorf("expected the new object after the reference block was refetched, actual code %v body of %v bytes", w.Code, w.Body.Len())
+	}
+}
+
+func TestServeSlicesWholeObject(t *testing.T) {
+	body := []byte(strings.Repeat("foo", 100))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write(body) // ignore ranges
+	}))
+	defer srv.Close()
+	h := newSliceTestHandler(t, srv.URL)
+
+	w := sliceRequest(h, "bytes=3-5")
+	if w.Code != http.StatusPartialContent || w.Body.String() != "foo" {
+		t.Errorf("expected a range of the whole object, actual code %v body '%v'", w.Code, w.Body.String())
+	}
+	w = sliceRequest(h, "")
+	if w.Code != http.StatusOK || !bytes.Equal(w.Body.Bytes(), body) {
+		t.Errorf("expected the whole object, actual code %v body of %v bytes", w.Code, w.Body.Len())
+	}
+}
@@ -0,0 +1,95 @@
+package cache
+
+/*
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/apache/trafficcontrol/grove/cacheobj"
+
+	"github.com/apache/trafficcontrol/lib/go-rfc"
+)
+
+// RFC5861 Cache-Control extensions
+const (
+	CacheControlStaleWhileRevalidate = "stale-while-revalidate"
+	CacheControlStaleIfError         = "stale-if-error"
+)
+
+// Warnings added to stale responses, per RFC7234§5.5.
+const (
+	WarningResponseIsStale    = `110 - "Response is Stale"`
+	WarningRevalidationFailed = `111 - "Revalidation Failed"`
+)
+
+// staleFor returns how long the given object has been stale, that is, how far its current age exceeds its freshness lifetime. It is negative if the object is fresh.
+func staleFor(obj *cacheobj.CacheObj) time.Duration {
+	return -rfc.FreshFor(obj.RespHeaders, obj.RespCacheControl, obj.ReqRespTime, obj.RespRespTime)
+}
+
+// staleWindow returns the delta-seconds value of the given directive in the Cache-Control, and whether it exists and is valid.
+func staleWindow(cc rfc.CacheControlMap, directive string) (time.Duration, bool) {
+	val, ok := cc[directive]
+	if !ok {
+		return 0, false
+	}
+	seconds, err := strconv.ParseUint(val, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// canStaleWhileRevalidate returns whether the given stale object may be served while it's revalidated in the background, per RFC5861§3.
+// The reuse must be ReuseMustRevalidateCanStale, because objects which must be revalidated, e.g. via must-revalidate, may never be served stale.
+func canStaleWhileRevalidate(obj *cacheobj.CacheObj, reuse rfc.Reuse) bool {
+	if reuse != rfc.ReuseMustRevalidateCanStale {
+		return false
+	}
+	window, ok := staleWindow(obj.RespCacheControl, CacheControlStaleWhileRevalidate)
+	if !ok {
+		return false
+	}
+	stale := staleFor(obj)
+	return stale >= 0 && stale <= window
+}
+
+// canStaleIfError returns whether the given stale object may be served because revalidating it failed, per RFC5861§4. The stale-if-error directive may be in either the client request or the cached response.
+func canStaleIfError(reqCC rfc.CacheControlMap, obj *cacheobj.CacheObj, reuse rfc.Reuse) bool {
+	if reuse != rfc.ReuseMustRevalidateCanStale {
+		return false
+	}
+	stale := staleFor(obj)
+	for _, cc := range []rfc.CacheControlMap{reqCC, obj.RespCacheControl} {
+		if window, ok := staleWindow(cc, CacheControlStaleIfError); ok && stale <= window {
+			return true
+		}
+	}
+	return false
+}
+
+// isRevalidationError returns whether the result of revalidating an object is an error, for which RFC5861§4 permits serving stale.
+func isRevalidationError(obj *cacheobj.CacheObj, err error) bool {
+	if err != nil || obj == nil {
+		return true
+	}
+	switch obj.Code {
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
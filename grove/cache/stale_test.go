@@ -0,0 +1,349 @@
+package cache
+
+/*
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/apache/trafficcontrol/grove/cacheobj"
+	"github.com/apache/trafficcontrol/grove/icache"
+	"github.com/apache/trafficcontrol/grove/memcache"
+	"github.com/apache/trafficcontrol/grove/plugin"
+	"github.com/apache/trafficcontrol/grove/remap"
+	"github.com/apache/trafficcontrol/grove/stat"
+	"github.com/apache/trafficcontrol/grove/thread"
+	"github.com/apache/trafficcontrol/grove/web"
+
+	"github.com/apache/trafficcontrol/lib/go-rfc"
+)
+
+// newTestHandler makes a Handler with a single remap rule from http://grove.test to the given origin, with the given additional rule options.
+func newTestHandler(t *testing.T, originURL string, ruleOptions string) *Handler {
+	rules := `{
+	"parent_selection": "consistent-hash",
+	"retry_codes": [],
+	"retry_num": 0,
+	"timeout_ms": 5000,
+	"rules": [{
+		"name": "test",
+		"from": "http://grove.test",
+		"query-string": {"cache": true, "remap": true},
+		` + ruleOptions + `
+		"to": [{"url": "` + originURL + `"}]
+	}]
+}`
+	f, err := ioutil.TempFile("", "grove-test")
+	if err != nil {
+		t.Fatalf("creating remap file: %v", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(rules); err != nil {
+		t.Fatalf("writing remap file: %v", err)
+	}
+	f.Close()
+
+	plugins := plugin.Get(nil)
+	caches := map[string]icache.Cache{"": memcache.New(100 * 1024 * 1024)}
+	remapper, err := remap.LoadRemapper(f.Name(), plugins.LoadFuncs(), caches, remap.NewRemappingTransport(5*time.Second, 5*time.Second, 10, 5*time.Second))
+	if err != nil {
+		t.Fatalf("loading remap rules: %v", err)
+	}
+	conns := web.NewConnMap()
+	stats := stat.New(remapper.Rules(), caches, 0, conns, conns, "test")
+	return NewHandler(remapper, 0, stats, "http", "80", conns, false, false, plugins, map[string]*interface{}{}, conns, conns, "lo")
+}
+
+// makeStaleObj makes a cache object with the given Cache-Control, received the given duration ago.
+func makeStaleObj(cacheControl string, age time.Duration) *cacheobj.CacheObj {
+	respTime := time.Now().Add(-age)
+	hdr := http.Header{}
+	hdr.Set("Cache-Control", cacheControl)
+	hdr.Set("Date", respTime.UTC().Format(http.TimeFormat))
+	return cacheobj.New(http.Header{}, []byte("foo"), http.StatusOK, http.StatusOK, "", hdr, respTime, respTime, respTime, respTime)
+}
+
+func TestCanStaleWhileRevalidate(t *testing.T) {
+	tests := []struct {
+		name         string
+		cacheControl string
+		age          time.Duration
+		reuse        rfc.Reuse
+		expected     bool
+	}{
+		{"within window", "max-age=10, stale-while-revalidate=60", 30 * time.Second, rfc.ReuseMustRevalidateCanStale, true},
+		{"past window", "max-age=10, stale-while-revalidate=60", 120 * time.Second, rfc.ReuseMustRevalidateCanStale, false},
+		{"fresh", "max-age=100, stale-while-revalidate=60", 30 * time.Second, rfc.ReuseMustRevalidateCanStale, false},
+		{"no directive", "max-age=10", 30 * time.Second, rfc.ReuseMustRevalidateCanStale, false},
+		{"invalid directive", "max-age=10, stale-while-revalidate=foo", 30 * time.Second, rfc.ReuseMustRevalidateCanStale, false},
+		{"must revalidate", "max-age=10, must-revalidate, stale-while-revalidate=60", 30 * time.Second, rfc.ReuseMustRevalidate, false},
+	}
+	for _, test := range tests {
+		if actual := canStaleWhileRevalidate(makeStaleObj(test.cacheControl, test.age), test.reuse); actual != test.expected {
+			t.Errorf("%s: expected canStaleWhileRevalidate %v, actual: %v", test.name, test.expected, actual)
+		}
+	}
+}
+
+func TestCanStaleIfError(t *testing.T) {
+	tests := []struct {
+		name         string
+		reqCC        string
+		cacheControl string
+		age          time.Duration
+		reuse        rfc.Reuse
+		expected     bool
+	}{
+		{"response within window", "", "max-age=10, stale-if-error=60", 30 * time.Second, rfc.ReuseMustRevalidateCanStale, true},
+		{"response past window", "", "max-age=10, stale-if-error=60", 120 * time.Second, rfc.ReuseMustRevalidateCanStale, false},
+		{"request within window", "stale-if-error=300", "max-age=10", 120 * time.Second, rfc.ReuseMustRevalidateCanStale, true},
+		{"request past window", "stale-if-error=60", "max-age=10", 120 * time.Second, rfc.ReuseMustRevalidateCanStale, false},
+		{"no directive", "", "max-age=10", 30 * time.Second, rfc.ReuseMustRevalidateCanStale, false},
+		{"must revalidate", "", "max-age=10, must-revalidate, stale-if-error=60", 30 * time.Second, rfc.ReuseMustRevalidate, false},
+	}
+	for _, test := range tests {
+		reqHdr := http.Header{}
+		reqHdr.Set("Cache-Control", test.reqCC)
+		reqCC := rfc.ParseCacheControl(reqHdr)
+		if actual := canStaleIfError(reqCC, makeStaleObj(test.cacheControl, test.age), test.reuse); actual != test.expected {
+			t.Errorf("%s: expected canStaleIfError %v, actual: %v", test.name, test.expected, actual)
+		}
+	}
+}
+
+func TestIsRevalidationError(t *testing.T) {
+	for code, expected := range map[int]bool{
+		http.StatusOK:                  false,
+		http.StatusNotModified:         false,
+		http.StatusNotFound:            false,
+		http.StatusInternalServerError: true,
+		http.StatusBadGateway:          true,
+		http.StatusServiceUnavailable:  true,
+		http.StatusGatewayTimeout:      true,
+	} {
+		if actual := isRevalidationError(&cacheobj.CacheObj{Code: code}, nil); actual != expected {
+			t.Errorf("code %v: expected isRevalidationError %v, actual: %v", code, expected, actual)
+		}
+	}
+	if !isRevalidationError(&cacheobj.CacheObj{Code: http.StatusOK}, errors.New("foo")) {
+		t.Errorf("expected an error to be a revalidation error")
+	}
+	if !isRevalidationError(nil, nil) {
+		t.Errorf("expected a nil object to be a revalidation error")
+	}
+}
+
+// versionOrigin serves a body naming the number of requests it has received, with the given code and Cache-Control.
+type versionOrigin struct {
+	m            sync.Mutex
+	requests     int
+	code         int
+	cacheControl string
+}
+
+func (o *versionOrigin) set(code int, cacheControl string) {
+	o.m.Lock()
+	defer o.m.Unlock()
+	o.code, o.cacheControl = code, cacheControl
+}
+
+func (o *versionOrigin) count() int {
+	o.m.Lock()
+	defer o.m.Unlock()
+	return o.requests
+}
+
+func (o *versionOrigin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	o.m.Lock()
+	o.requests++
+	requests, code, cacheControl := o.requests, o.code, o.cacheControl
+	o.m.Unlock()
+	w.Header().Set("Cache-Control", cacheControl)
+	w.WriteHeader(code)
+	fmt.Fprintf(w, "v%d", requests)
+}
+
+func testRequest(h *Handler) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/obj", nil)
+	r.Host = "grove.test"
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func testRemapStats(t *testing.T, h *Handler) stat.StatsRemap {
+	stats, ok := h.stats.Remap().Stats("grove.test")
+	if !ok {
+		t.Fatalf("expected remap stats for grove.test")
+	}
+	return stats
+}
+
+// waitFor waits up to a second for cond to become true, returning whether it did.
+func waitFor(cond func() bool) bool {
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if cond() {
+			return true
+		}
+	}
+	return cond()
+}
+
+func TestServeStaleWhileRevalidate(t *testing.T) {
+	origin := &versionOrigin{}
+	origin.set(http.StatusOK, "max-age=0, stale-while-revalidate=60")
+	srv := httptest.NewServer(origin)
+	defer srv.Close()
+	h := newTestHandler(t, srv.URL, `"stale_while_revalidate": true,`)
+
+	if w := testRequest(h); w.Code != http.StatusOK || w.Body.String() != "v1" {
+		t.Fatalf("cache miss: expected code %v body 'v1', actual code %v body '%v'", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	w := testRequest(h)
+	if w.Code != http.StatusOK || w.Body.String() != "v1" {
+		t.Errorf("stale request: expected the stale object, actual code %v body '%v'", w.Code, w.Body.String())
+	}
+	if warning := w.Header().Get("Warning"); warning != WarningResponseIsStale {
+		t.Errorf("stale request: expected Warning '%v', actual: '%v'", WarningResponseIsStale, warning)
+	}
+	revalidated := waitFor(func() bool {
+		h.revalidatingM.Lock()
+		defer h.revalidatingM.Unlock()
+		return origin.count() == 2 && len(h.revalidating) == 0
+	})
+	if !revalidated {
+		t.Fatalf("stale request: expected a background revalidation, actual origin requests: %v", origin.count())
+	}
+
+	if w := testRequest(h); w.Body.String() != "v2" {
+		t.Errorf("request after revalidation: expected the revalidated object 'v2', actual: '%v'", w.Body.String())
+	}
+	stats := testRemapStats(t, h)
+	if stats.StaleWhileRevalidate() != 2 || stats.BackgroundRevalidations() != 2 || stats.BackgroundRevalidationFailures() != 0 {
+		t.Errorf("expected 2 stale-while-revalidate responses and background revalidations, actual: %v %v, failures %v", stats.StaleWhileRevalidate(), stats.BackgroundRevalidations(), stats.BackgroundRevalidationFailures())
+	}
+}
+
+func TestServeStaleWhileRevalidateDisabled(t *testing.T) {
+	origin := &versionOrigin{}
+	origin.set(http.StatusOK, "max-age=0, stale-while-revalidate=60")
+	srv := httptest.NewServer(origin)
+	defer srv.Close()
+	h := newTestHandler(t, srv.URL, ``)
+
+	testRequest(h)
+	if w := testRequest(h); w.Body.String() != "v2" || w.Header().Get("Warning") != "" {
+		t.Errorf("expected the rule to revalidate before responding, actual body '%v' Warning '%v'", w.Body.String(), w.Header().Get("Warning"))
+	}
+}
+
+func TestServeStaleIfError(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		origin := &versionOrigin{}
+		origin.set(http.StatusOK, "max-age=0, stale-if-error=60")
+		srv := httptest.NewServer(origin)
+		h := newTestHandler(t, srv.URL, fmt.Sprintf(`"stale_if_error": %v,`, enabled))
+
+		testRequest(h)
+		origin.set(http.StatusServiceUnavailable, "")
+		w := testRequest(h)
+		srv.Close()
+
+		if !enabled {
+			if w.Code != http.StatusServiceUnavailable {
+				t.Errorf("disabled: expected the error code %v, actual: %v", http.StatusServiceUnavailable, w.Code)
+			}
+			continue
+		}
+		if w.Code != http.StatusOK || w.Body.String() != "v1" {
+			t.Errorf("expected the stale object, actual code %v body '%v'", w.Code, w.Body.String())
+		}
+		if warning := w.Header().Get("Warning"); warning != WarningRevalidationFailed {
+			t.Errorf("expected Warning '%v', actual: '%v'", WarningRevalidationFailed, warning)
+		}
+		if stats := testRemapStats(t, h); stats.StaleIfError() != 1 {
+			t.Errorf("expected 1 stale-if-error response, actual: %v", stats.StaleIfError())
+		}
+	}
+}
+
+// countingGetter is a Getter which counts the requests which have called Get.
+type countingGetter struct {
+	thread.Getter
+	gets int32
+}
+
+func (g *countingGetter) Get(key string, actualGet func() *cacheobj.CacheObj, canUse func(*cacheobj.CacheObj) bool, reqID uint64) (*cacheobj.CacheObj, uint64) {
+	atomic.AddInt32(&g.gets, 1)
+	return g.Getter.Get(key, actualGet, canUse, reqID)
+}
+
+func TestServeCollapsedRequests(t *testing.T) {
+	const numRequests = 5
+	for _, collapse := range []bool{true, false} {
+		release := make(chan struct{})
+		origin := &versionOrigin{}
+		origin.set(http.StatusOK, "max-age=0")
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+			origin.ServeHTTP(w, r)
+		}))
+		h := newTestHandler(t, srv.URL, fmt.Sprintf(`"collapse_requests": %v,`, collapse))
+		getter := &countingGetter{Getter: h.getter}
+		h.getter = getter
+
+		wg := sync.WaitGroup{}
+		codes := make(chan int, numRequests)
+		for i := 0; i < numRequests; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				codes <- testRequest(h).Code
+			}()
+		}
+		if !waitFor(func() bool { return atomic.LoadInt32(&getter.gets) == numRequests }) {
+			t.Fatalf("expected %v concurrent requests, actual: %v", numRequests, atomic.LoadInt32(&getter.gets))
+		}
+		time.Sleep(50 * time.Millisecond) // let the waiters wait on the author's request
+		close(release)
+		wg.Wait()
+		srv.Close()
+		close(codes)
+		for code := range codes {
+			if code != http.StatusOK {
+				t.Errorf("collapse %v: expected code %v, actual: %v", collapse, http.StatusOK, code)
+			}
+		}
+
+		stats := testRemapStats(t, h)
+		if collapse {
+			if origin.count() != 1 || stats.CollapsedRequests() != numRequests-1 {
+				t.Errorf("strict collapsing: expected 1 origin request and %v collapsed requests, actual: %v and %v", numRequests-1, origin.count(), stats.CollapsedRequests())
+			}
+		} else if origin.count() != numRequests {
+			// the response isn't reusable, so without strict collapsing each waiter makes its own request
+			t.Errorf("non-strict collapsing: expected %v origin requests, actual: %v", numRequests, origin.count())
+		}
+	}
+}
@@ -61,8 +61,19 @@ type Config struct {
 	CacheFiles           map[string][]CacheFile `json:"cache_files"`
 	// FileMemBytes is the amount of memory to use as an LRU in front of each name in CacheFiles, that is, each named group of files. E.g. if there are 10 files, the amount of memory used will be 10*FileMemBytes+CacheSizeBytes.
 	FileMemBytes int `json:"file_mem_bytes"`
+	// DiskCacheEngine is the storage engine used for the files in CacheFiles, either DiskCacheEngineBolt or DiskCacheEngineLog.
+	DiskCacheEngine string `json:"disk_cache_engine"`
+	// DiskCacheIndexIntervalMS is how often the log disk cache engine persists the index of each file. Objects added since the index was last persisted are recovered from the log itself, so this only bounds how much of the log must be replayed on restart.
+	DiskCacheIndexIntervalMS int `json:"disk_cache_index_interval_ms"`
 }
 
+const (
+	// DiskCacheEngineBolt stores each cache file in a bolt key-value database, with an in-memory LRU index.
+	DiskCacheEngineBolt = "bolt"
+	// DiskCacheEngineLog stores each cache file as a circular log, with a persisted index.
+	DiskCacheEngineLog = "log"
+)
+
 type CacheFile struct {
 	Path  string `json:"path"`
 	Bytes uint64 `json:"size_bytes"`
@@ -88,27 +99,29 @@ const MSPerSec = 1000
 
 // DefaultConfig is the default configuration for the application, if no configuration file is given, or if a given config setting doesn't exist in the config file.
 var DefaultConfig = Config{
-	RFCCompliant:           true,
-	Port:                   80,
-	DisableHTTP2:           false,
-	HTTPSPort:              443,
-	CacheSizeBytes:         bytesPerGibibyte,
-	RemapRulesFile:         "remap.config",
-	ConcurrentRuleRequests: 100000,
-	ConnectionClose:        false,
-	LogLocationError:       log.LogLocationStderr,
-	LogLocationWarning:     log.LogLocationStdout,
-	LogLocationInfo:        log.LogLocationNull,
-	LogLocationDebug:       log.LogLocationNull,
-	LogLocationEvent:       log.LogLocationStdout,
-	ReqTimeoutMS:           30 * MSPerSec,
-	ReqKeepAliveMS:         30 * MSPerSec,
-	ReqMaxIdleConns:        100,
-	ReqIdleConnTimeoutMS:   90 * MSPerSec,
-	ServerIdleTimeoutMS:    10 * MSPerSec,
-	ServerWriteTimeoutMS:   3 * MSPerSec,
-	ServerReadTimeoutMS:    3 * MSPerSec,
-	FileMemBytes:           bytesPerMebibyte * 100,
+	RFCCompliant:             true,
+	Port:                     80,
+	DisableHTTP2:             false,
+	HTTPSPort:                443,
+	CacheSizeBytes:           bytesPerGibibyte,
+	RemapRulesFile:           "remap.config",
+	ConcurrentRuleRequests:   100000,
+	ConnectionClose:          false,
+	LogLocationError:         log.LogLocationStderr,
+	LogLocationWarning:       log.LogLocationStdout,
+	LogLocationInfo:          log.LogLocationNull,
+	LogLocationDebug:         log.LogLocationNull,
+	LogLocationEvent:         log.LogLocationStdout,
+	ReqTimeoutMS:             30 * MSPerSec,
+	ReqKeepAliveMS:           30 * MSPerSec,
+	ReqMaxIdleConns:          100,
+	ReqIdleConnTimeoutMS:     90 * MSPerSec,
+	ServerIdleTimeoutMS:      10 * MSPerSec,
+	ServerWriteTimeoutMS:     3 * MSPerSec,
+	ServerReadTimeoutMS:      3 * MSPerSec,
+	FileMemBytes:             bytesPerMebibyte * 100,
+	DiskCacheEngine:          DiskCacheEngineBolt,
+	DiskCacheIndexIntervalMS: 10 * MSPerSec,
 }
 
 // LoadConfig loads the given config file. If an empty string is passed, the default config is returned.
@@ -40,6 +40,7 @@ import (
 	"github.com/apache/trafficcontrol/grove/config"
 	"github.com/apache/trafficcontrol/grove/diskcache"
 	"github.com/apache/trafficcontrol/grove/icache"
+	"github.com/apache/trafficcontrol/grove/logcache"
 	"github.com/apache/trafficcontrol/grove/memcache"
 	"github.com/apache/trafficcontrol/grove/plugin"
 	"github.com/apache/trafficcontrol/grove/remap"
@@ -81,7 +82,7 @@ func main() {
 	}
 	log.Init(eventW, errW, warnW, infoW, debugW)
 
-	caches, err := createCaches(cfg.CacheFiles, uint64(cfg.FileMemBytes), uint64(cfg.CacheSizeBytes))
+	caches, logCaches, err := createCaches(cfg.CacheFiles, uint64(cfg.FileMemBytes), uint64(cfg.CacheSizeBytes), cfg.DiskCacheEngine, time.Duration(cfg.DiskCacheIndexIntervalMS)*time.Millisecond)
 	if err != nil {
 		log.Errorln("starting service: creating caches: " + err.Error())
 		os.Exit(1)
@@ -186,10 +187,20 @@ func main() {
 			log.Init(eventW, errW, warnW, infoW, debugW)
 		}
 
-		// TODO add cache file reloading
+		// TODO add cache file reloading for the bolt disk cache engine. The log engine supports changing the files of existing named groups.
 		// The problem is, the disk db needs file locks, so there's no way to close and create new files without making all requests cache miss in the meantime.
 		// Thus, the file paths must be kept, diffed, only removed paths' dbs closed, only new paths opened, and dbs for existing paths passed into the new caches object.
-		if cachesChanged(oldCfg, cfg) {
+		if logCacheFilesReloadable(oldCfg, cfg) {
+			for name, files := range cfg.CacheFiles {
+				if reflect.DeepEqual(files, oldCfg.CacheFiles[name]) {
+					continue
+				}
+				log.Infoln("reloading config: changing the files of cache '" + name + "'")
+				if err := logCaches[name].SetFiles(files); err != nil {
+					log.Errorln("reloading config: changing the files of cache '" + name + "': " + err.Error())
+				}
+			}
+		} else if cachesChanged(oldCfg, cfg) {
 			log.Warnln("reloading config: caches changed in new config! Dynamic cache reloading is not supported! Old cache files and sizes will be used, and new cache config will NOT be loaded! Restart service to apply cache changes!")
 		}
 
@@ -293,9 +304,45 @@ func main() {
 	if *pprof {
 		profile()
 	}
+
+	// The log caches persist their indexes when closed, so they don't have to be rebuilt by scanning their files on the next start.
+	go signalShutdown(func() {
+		log.Infoln("shutting down")
+		shutdownServer(httpServer, "http")
+		if httpsServer != nil {
+			shutdownServer(httpsServer, "https")
+		}
+		for name, logCache := range logCaches {
+			log.Infoln("closing cache '" + name + "'")
+			logCache.Close()
+		}
+		os.Exit(0)
+	}, unix.SIGTERM, unix.SIGINT)
 	signalReloader(unix.SIGHUP, reloadConfig)
 }
 
+// signalShutdown calls f when any of the given signals is received. It returns after calling f, which should exit.
+func signalShutdown(f func(), sigs ...os.Signal) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, sigs...)
+	<-c
+	f()
+}
+
+// shutdownServer gracefully shuts down the server, and forcefully closes it if its connections don't close within the ShutdownTimeout.
+func shutdownServer(server *http.Server, protocol string) {
+	ctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		if err == context.DeadlineExceeded {
+			log.Errorf("closing %s server: connections didn't close gracefully in %v, forcefully closing.\n", protocol, ShutdownTimeout)
+			server.Close()
+		} else {
+			log.Errorf("closing %s server: %v\n", protocol, err)
+		}
+	}
+}
+
 func profile() {
 	go func() {
 		count := 0
@@ -380,20 +427,49 @@ func loadCerts(rules []remapdata.RemapRule) ([]tls.Certificate, error) {
 	return certs, nil
 }
 
-// createCaches creates the caches specified in the config. The nameFiles is the map of names to groups of files, nameMemBytes is the amount of memory to use for each named group, and memCacheBytes is the amount of memory to use for the default memory cache.
-func createCaches(nameFiles map[string][]config.CacheFile, nameMemBytes uint64, memCacheBytes uint64) (map[string]icache.Cache, error) {
+// createCaches creates the caches specified in the config. The nameFiles is the map of names to groups of files, nameMemBytes is the amount of memory to use for each named group, and memCacheBytes is the amount of memory to use for the default memory cache. The engine is the disk cache engine to store the files with, and indexInterval is how often the log engine persists its indexes.
+// If the engine is the log engine, the disk caches of each named group are also returned, so their files can be changed when the config is reloaded.
+func createCaches(nameFiles map[string][]config.CacheFile, nameMemBytes uint64, memCacheBytes uint64, engine string, indexInterval time.Duration) (map[string]icache.Cache, map[string]*logcache.MultiLogCache, error) {
 	caches := map[string]icache.Cache{}
 	caches[""] = memcache.New(memCacheBytes) // default empty names to the mem cache
+	logCaches := map[string]*logcache.MultiLogCache{}
 
 	for name, files := range nameFiles {
-		multiDiskCache, err := diskcache.NewMulti(files)
-		if err != nil {
-			return nil, errors.New("creating cache '" + name + "': " + err.Error())
+		switch engine {
+		case config.DiskCacheEngineLog:
+			multiLogCache, err := logcache.NewMulti(files, indexInterval)
+			if err != nil {
+				return nil, nil, errors.New("creating cache '" + name + "': " + err.Error())
+			}
+			logCaches[name] = multiLogCache
+			caches[name] = tiercache.New(memcache.New(nameMemBytes), multiLogCache)
+		case config.DiskCacheEngineBolt, "":
+			multiDiskCache, err := diskcache.NewMulti(files)
+			if err != nil {
+				return nil, nil, errors.New("creating cache '" + name + "': " + err.Error())
+			}
+			caches[name] = tiercache.New(memcache.New(nameMemBytes), multiDiskCache)
+		default:
+			return nil, nil, errors.New("creating cache '" + name + "': unknown disk cache engine '" + engine + "'")
 		}
-		caches[name] = tiercache.New(memcache.New(nameMemBytes), multiDiskCache)
 	}
 
-	return caches, nil
+	return caches, logCaches, nil
+}
+
+// logCacheFilesReloadable returns whether the cache changes between the given configs can be applied without a restart. This is the case if the log disk cache engine is used, and the only changes are to the files of existing named groups.
+func logCacheFilesReloadable(oldCfg, newCfg config.Config) bool {
+	if oldCfg.DiskCacheEngine != config.DiskCacheEngineLog || newCfg.DiskCacheEngine != config.DiskCacheEngineLog ||
+		oldCfg.FileMemBytes != newCfg.FileMemBytes || oldCfg.CacheSizeBytes != newCfg.CacheSizeBytes ||
+		len(oldCfg.CacheFiles) != len(newCfg.CacheFiles) {
+		return false
+	}
+	for name := range newCfg.CacheFiles {
+		if _, ok := oldCfg.CacheFiles[name]; !ok {
+			return false
+		}
+	}
+	return true
 }
 
 func cachesChanged(oldCfg, newCfg config.Config) bool {
@@ -0,0 +1,535 @@
+package logcache
+
+/*
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+import (
+	"bytes"
+	"container/list"
+	"encoding/gob"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/apache/trafficcontrol/grove/cacheobj"
+
+	"github.com/apache/trafficcontrol/lib/go-log"
+)
+
+// LogCache is a log-structured disk cache in a single file. The file is used as a circular log: objects are
+// always appended at the write head, and when the head reaches the end of the file it wraps around to the
+// start, evicting the oldest objects as they are overwritten. Eviction is therefore first-in-first-out rather
+// than least-recently-used, in exchange for purely sequential writes and no fragmentation.
+//
+// The index of objects in the log is kept in memory, and periodically persisted to a separate index file.
+// When the cache is opened, the persisted index is loaded and the records written to the log after it was
+// persisted are replayed, so objects survive restarts and crashes. Every record is checksummed, so records
+// which were only partially written before a crash are never served.
+type LogCache struct {
+	path     string
+	file     *os.File
+	capacity uint64
+
+	// writeM serializes writes to the log, so that records are written in sequence order.
+	writeM sync.Mutex
+	// persistM serializes persisting the index.
+	persistM sync.Mutex
+
+	// m guards all of the following.
+	m         sync.RWMutex
+	head      uint64
+	pass      uint64
+	nextSeq   uint64
+	index     map[string]*entry
+	queue     *list.List // of *entry, in write order
+	sizeBytes uint64
+	// indexHead and indexPass are the head and pass of the persisted index, where recovery starts replaying
+	// the log. The log must never be overwritten there, or the records after it couldn't be replayed.
+	indexHead uint64
+	indexPass uint64
+
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// entry is the location of a record in the log.
+type entry struct {
+	key    string
+	offset uint64
+	length uint64
+	seq    uint64
+	// pass is the number of times the log had wrapped when the record was written.
+	pass uint64
+	elem *list.Element
+}
+
+// indexSnapshot is the persisted form of the index.
+type indexSnapshot struct {
+	Capacity uint64
+	Head     uint64
+	Pass     uint64
+	NextSeq  uint64
+	Entries  []snapshotEntry // in write order
+}
+
+type snapshotEntry struct {
+	Key    string
+	Offset uint64
+	Length uint64
+	Seq    uint64
+	Pass   uint64
+}
+
+// IndexSuffix is appended to the path of a cache file to get the path of its persisted index.
+const IndexSuffix = ".idx"
+
+// New opens the log cache in the file at the given path, creating it if it doesn't exist, and recovering the
+// objects it contains if it does. The index is persisted every indexInterval, and when the cache is closed.
+// If indexInterval is not positive, the index is only persisted when the cache is closed.
+func New(path string, capacity uint64, indexInterval time.Duration) (*LogCache, error) {
+	if capacity < recordHeaderLen*2 {
+		return nil, errors.New("cache file size too small")
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, errors.New("opening cache file '" + path + "': " + err.Error())
+	}
+	c := &LogCache{
+		path:     path,
+		file:     file,
+		capacity: capacity,
+		nextSeq:  1,
+		index:    map[string]*entry{},
+		queue:    list.New(),
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	if err := c.recover(); err != nil {
+		file.Close()
+		return nil, errors.New("recovering cache file '" + path + "': " + err.Error())
+	}
+	go c.persistIndexPeriodically(indexInterval)
+	return c, nil
+}
+
+// recover sizes the cache file to the cache's capacity, loads the persisted index, and replays the log
+// records written after it.
+func (c *LogCache) recover() error {
+	info, err := c.file.Stat()
+	if err != nil {
+		return errors.New("getting file info: " + err.Error())
+	}
+	if uint64(info.Size()) < c.capacity {
+		if err := c.file.Truncate(int64(c.capacity)); err != nil {
+			return errors.New("sizing file: " + err.Error())
+		}
+	}
+
+	snapshot, err := c.loadIndex()
+	if err != nil {
+		log.Warnln("LogCache: cache file '" + c.path + "' has no usable index, starting empty: " + err.Error())
+		return nil
+	}
+
+	c.head = snapshot.Head
+	c.pass = snapshot.Pass
+	c.nextSeq = snapshot.NextSeq
+	c.indexHead = snapshot.Head
+	c.indexPass = snapshot.Pass
+	for _, se := range snapshot.Entries {
+		c.addEntry(&entry{key: se.Key, offset: se.Offset, length: se.Length, seq: se.Seq, pass: se.Pass})
+	}
+
+	replayed := c.replay(snapshot.Capacity)
+
+	if snapshot.Capacity > c.capacity {
+		// the file was made smaller: drop the objects that no longer fit.
+		for e := c.queue.Front(); e != nil; {
+			next := e.Next()
+			if ent := e.Value.(*entry); ent.offset+ent.length > c.capacity {
+				c.removeEntry(ent)
+			}
+			e = next
+		}
+		if c.head+recordHeaderLen > c.capacity {
+			c.wrap()
+		}
+		if err := c.file.Truncate(int64(c.capacity)); err != nil {
+			return errors.New("resizing file: " + err.Error())
+		}
+	}
+
+	log.Infof("LogCache: recovered cache file '%s': %d objects (%d bytes), replayed %d records\n", c.path, len(c.index), c.sizeBytes, replayed)
+	return nil
+}
+
+// replay applies the records written to the log after the index was persisted, stopping at the first record
+// which isn't the next in sequence or wasn't completely written. The given logCapacity is the capacity of the
+// log when the index was persisted. It returns the number of records replayed.
+func (c *LogCache) replay(logCapacity uint64) int {
+	replayed := 0
+	header := make([]byte, recordHeaderLen)
+	for {
+		if c.head+recordHeaderLen > logCapacity {
+			if c.head+recordHeaderLen > c.capacity {
+				return replayed
+			}
+			// the head wrapped without room for a wrap record
+			c.wrap()
+			continue
+		}
+		if _, err := c.file.ReadAt(header, int64(c.head)); err != nil {
+			return replayed
+		}
+		h := decodeHeader(header)
+		if h.seq != c.nextSeq {
+			return replayed
+		}
+		if h.magic == wrapMagic {
+			c.nextSeq++
+			c.wrap()
+			replayed++
+			continue
+		}
+		if h.magic != recordMagic || c.head+h.recordLen() > logCapacity || c.head+h.recordLen() > c.capacity {
+			return replayed
+		}
+		buf := make([]byte, h.recordLen())
+		if _, err := c.file.ReadAt(buf, int64(c.head)); err != nil {
+			return replayed
+		}
+		_, key, _, err := decodeRecord(buf)
+		if err != nil {
+			return replayed
+		}
+		c.evict(c.head, h.recordLen())
+		c.addEntry(&entry{key: key, offset: c.head, length: h.recordLen(), seq: h.seq, pass: c.pass})
+		c.head += h.recordLen()
+		c.nextSeq++
+		replayed++
+	}
+}
+
+// loadIndex reads the persisted index.
+func (c *LogCache) loadIndex() (indexSnapshot, error) {
+	snapshot := indexSnapshot{}
+	indexBytes, err := ioutil.ReadFile(c.path + IndexSuffix)
+	if err != nil {
+		return snapshot, errors.New("reading index: " + err.Error())
+	}
+	if err := gob.NewDecoder(bytes.NewReader(indexBytes)).Decode(&snapshot); err != nil {
+		return snapshot, errors.New("decoding index: " + err.Error())
+	}
+	if snapshot.NextSeq == 0 || snapshot.Head > snapshot.Capacity {
+		return snapshot, errors.New("index is invalid")
+	}
+	return snapshot, nil
+}
+
+// PersistIndex writes the index to disk, after syncing the log, so that the objects in the cache can be
+// recovered after a restart.
+func (c *LogCache) PersistIndex() error {
+	c.persistM.Lock()
+	defer c.persistM.Unlock()
+
+	c.m.RLock()
+	snapshot := indexSnapshot{
+		Capacity: c.capacity,
+		Head:     c.head,
+		Pass:     c.pass,
+		NextSeq:  c.nextSeq,
+		Entries:  make([]snapshotEntry, 0, len(c.index)),
+	}
+	for e := c.queue.Front(); e != nil; e = e.Next() {
+		ent := e.Value.(*entry)
+		snapshot.Entries = append(snapshot.Entries, snapshotEntry{Key: ent.key, Offset: ent.offset, Length: ent.length, Seq: ent.seq, Pass: ent.pass})
+	}
+	c.m.RUnlock()
+
+	if err := c.file.Sync(); err != nil {
+		return errors.New("syncing cache file: " + err.Error())
+	}
+
+	buf := bytes.Buffer{}
+	if err := gob.NewEncoder(&buf).Encode(snapshot); err != nil {
+		return errors.New("encoding index: " + err.Error())
+	}
+	tmpPath := c.path + IndexSuffix + ".tmp"
+	if err := writeFileSync(tmpPath, buf.Bytes()); err != nil {
+		return errors.New("writing index: " + err.Error())
+	}
+	if err := os.Rename(tmpPath, c.path+IndexSuffix); err != nil {
+		return errors.New("renaming index: " + err.Error())
+	}
+
+	c.m.Lock()
+	c.indexHead = snapshot.Head
+	c.indexPass = snapshot.Pass
+	c.m.Unlock()
+	return nil
+}
+
+// overwritesIndexHead returns whether writing length bytes at offset in the given pass would overwrite the log
+// at the head of the persisted index. The caller must hold the lock of c.m.
+func (c *LogCache) overwritesIndexHead(offset uint64, length uint64, pass uint64) bool {
+	return pass > c.indexPass+1 || (pass == c.indexPass+1 && offset+length > c.indexHead)
+}
+
+func writeFileSync(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func (c *LogCache) persistIndexPeriodically(interval time.Duration) {
+	defer close(c.stopped)
+	if interval <= 0 {
+		<-c.stop
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			if err := c.PersistIndex(); err != nil {
+				log.Errorln("LogCache: persisting index of '" + c.path + "': " + err.Error())
+			}
+		}
+	}
+}
+
+// addEntry adds the given entry to the index, replacing any existing entry for its key. The caller must hold
+// the write lock of c.m.
+func (c *LogCache) addEntry(ent *entry) {
+	if old, ok := c.index[ent.key]; ok {
+		c.removeEntry(old)
+	}
+	ent.elem = c.queue.PushBack(ent)
+	c.index[ent.key] = ent
+	c.sizeBytes += ent.length
+}
+
+// removeEntry removes the given entry from the index. The caller must hold the write lock of c.m.
+func (c *LogCache) removeEntry(ent *entry) {
+	c.queue.Remove(ent.elem)
+	if c.index[ent.key] == ent {
+		delete(c.index, ent.key)
+	}
+	c.sizeBytes -= ent.length
+}
+
+// evict removes the entries of all records which will be overwritten by writing length bytes at offset,
+// returning whether any were removed. The caller must hold the write lock of c.m.
+//
+// The queue holds the records of the previous pass over the log which haven't been overwritten yet, ordered
+// by offset, followed by the records of the current pass. So the records to evict are always at the front.
+func (c *LogCache) evict(offset uint64, length uint64) bool {
+	evicted := false
+	for e := c.queue.Front(); e != nil; e = c.queue.Front() {
+		ent := e.Value.(*entry)
+		if ent.pass >= c.pass || ent.offset >= offset+length {
+			break
+		}
+		c.removeEntry(ent)
+		evicted = true
+	}
+	return evicted
+}
+
+// wrap moves the write head to the start of the log, evicting the remaining records of the previous pass,
+// which lie between the head and the end of the log. The caller must hold the write lock of c.m.
+func (c *LogCache) wrap() bool {
+	evicted := c.evict(c.head, c.capacity)
+	c.head = 0
+	c.pass++
+	return evicted
+}
+
+// Add takes a key and value to add. Returns whether an eviction occurred.
+func (c *LogCache) Add(key string, val *cacheobj.CacheObj) bool {
+	log.Debugf("LogCache Add CALLED key '%+v' size '%+v'\n", key, val.Size)
+	buf := bytes.Buffer{}
+	if err := gob.NewEncoder(&buf).Encode(val); err != nil {
+		log.Errorln("LogCache.Add encoding cache object: " + err.Error())
+		return false
+	}
+	valBytes := buf.Bytes()
+	recordLen := uint64(recordHeaderLen + len(key) + len(valBytes))
+	if recordLen > c.capacity {
+		log.Warnf("LogCache.Add '%s': object of %d bytes is larger than the cache file '%s', not caching\n", key, recordLen, c.path)
+		return false
+	}
+
+	c.writeM.Lock()
+	defer c.writeM.Unlock()
+
+	c.m.Lock()
+	evicted := false
+	persistIndex := false
+	wrapRecord := []byte(nil)
+	wrapOffset := c.head
+	if c.head+recordLen > c.capacity {
+		if c.head+recordHeaderLen <= c.capacity {
+			evicted = c.evict(wrapOffset, recordHeaderLen)
+			persistIndex = c.overwritesIndexHead(wrapOffset, recordHeaderLen, c.pass)
+			wrapRecord = encodeWrap(c.nextSeq)
+			c.nextSeq++
+		}
+		evicted = c.wrap() || evicted
+	}
+	evicted = c.evict(c.head, recordLen) || evicted
+	persistIndex = persistIndex || c.overwritesIndexHead(c.head, recordLen, c.pass)
+	// The head isn't advanced past the record until it's written, so that the index persisted in the meantime
+	// replays it.
+	ent := &entry{key: key, offset: c.head, length: recordLen, seq: c.nextSeq, pass: c.pass}
+	c.m.Unlock()
+
+	if persistIndex {
+		// The log is about to be overwritten where it would be replayed from, so the index must be persisted
+		// first. This happens at most once per pass over the log, unless the index is persisted periodically.
+		if err := c.PersistIndex(); err != nil {
+			log.Errorln("LogCache.Add persisting index of '" + c.path + "': " + err.Error())
+		}
+	}
+
+	if wrapRecord != nil {
+		if _, err := c.file.WriteAt(wrapRecord, int64(wrapOffset)); err != nil {
+			log.Errorln("LogCache.Add writing wrap record to '" + c.path + "': " + err.Error())
+		}
+	}
+	if _, err := c.file.WriteAt(encodeRecord(ent.seq, key, valBytes), int64(ent.offset)); err != nil {
+		log.Errorln("LogCache.Add writing '" + key + "' to '" + c.path + "': " + err.Error())
+		return evicted
+	}
+
+	c.m.Lock()
+	c.head += recordLen
+	c.nextSeq++
+	c.addEntry(ent)
+	c.m.Unlock()
+
+	log.Debugf("LogCache Add SUCCESS key '%+v' size '%+v' recordLen '%+v'\n", key, val.Size, recordLen)
+	return evicted
+}
+
+// Get takes a key, and returns its value, and whether it was found, and updates the hitcount.
+// Note the LogCache evicts in first-in-first-out order, so getting an object doesn't change when it's evicted.
+func (c *LogCache) Get(key string) (*cacheobj.CacheObj, bool) {
+	val, found := c.Peek(key)
+	if !found {
+		return nil, false
+	}
+	val.HitCount++
+	return val, true
+}
+
+// Peek takes a key, and returns its value, and whether it was found, without changing the hitcount.
+func (c *LogCache) Peek(key string) (*cacheobj.CacheObj, bool) {
+	c.m.RLock()
+	ent, ok := c.index[key]
+	var offset, length, seq uint64
+	if ok {
+		offset, length, seq = ent.offset, ent.length, ent.seq
+	}
+	c.m.RUnlock()
+	if !ok {
+		log.Debugln("LogCache.Peek key '" + key + "' CACHE MISS")
+		return nil, false
+	}
+
+	buf := make([]byte, length)
+	if _, err := c.file.ReadAt(buf, int64(offset)); err != nil && err != io.EOF {
+		log.Errorln("LogCache.Peek reading '" + key + "' from '" + c.path + "': " + err.Error())
+		return nil, false
+	}
+	// The record may have been overwritten while it was read, or only partially written before a crash,
+	// which the checksum and sequence number catch.
+	h, recordKey, valBytes, err := decodeRecord(buf)
+	if err == nil && (h.seq != seq || recordKey != key) {
+		err = errors.New("record was overwritten")
+	}
+	if err != nil {
+		log.Warnln("LogCache.Peek '" + key + "' from '" + c.path + "' is invalid, removing: " + err.Error())
+		c.m.Lock()
+		if cur, ok := c.index[key]; ok && cur == ent {
+			c.removeEntry(ent)
+		}
+		c.m.Unlock()
+		return nil, false
+	}
+
+	val := cacheobj.CacheObj{}
+	if err := gob.NewDecoder(bytes.NewReader(valBytes)).Decode(&val); err != nil {
+		log.Errorln("LogCache.Peek decoding '" + key + "' from cache: " + err.Error())
+		return nil, false
+	}
+	log.Debugln("LogCache.Peek key '" + key + "' CACHE HIT")
+	return &val, true
+}
+
+// Size returns the number of bytes of the log used by the objects in the cache.
+func (c *LogCache) Size() uint64 {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	return c.sizeBytes
+}
+
+// Capacity returns the size of the cache file.
+func (c *LogCache) Capacity() uint64 {
+	return c.capacity
+}
+
+// Keys returns the keys of the objects in the cache, oldest first.
+func (c *LogCache) Keys() []string {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	keys := make([]string, 0, len(c.index))
+	for e := c.queue.Front(); e != nil; e = e.Next() {
+		keys = append(keys, e.Value.(*entry).key)
+	}
+	return keys
+}
+
+// Path returns the path of the cache file.
+func (c *LogCache) Path() string {
+	return c.path
+}
+
+// Close persists the index and closes the cache file.
+func (c *LogCache) Close() {
+	close(c.stop)
+	<-c.stopped
+	c.writeM.Lock()
+	defer c.writeM.Unlock()
+	if err := c.PersistIndex(); err != nil {
+		log.Errorln("LogCache: persisting index of '" + c.path + "' on close: " + err.Error())
+	}
+	c.file.Close()
+}
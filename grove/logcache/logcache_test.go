@@ -0,0 +1,261 @@
+package logcache
+
+/*
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apache/trafficcontrol/grove/cacheobj"
+	"github.com/apache/trafficcontrol/grove/diskcache"
+)
+
+func makeTestObj(key string, bodyLen int) *cacheobj.CacheObj {
+	body := bytes.Repeat([]byte(key), bodyLen/len(key)+1)[:bodyLen]
+	return cacheobj.New(nil, body, 200, 200, "http://origin.invalid/"+key, nil, time.Time{}, time.Time{}, time.Time{}, time.Time{})
+}
+
+func tempDir(t testing.TB) string {
+	dir, err := ioutil.TempDir("", "logcache")
+	if err != nil {
+		t.Fatalf("creating temp dir: %s", err.Error())
+	}
+	return dir
+}
+
+func expectHit(t *testing.T, c *LogCache, key string, bodyLen int) {
+	t.Helper()
+	obj, ok := c.Get(key)
+	if !ok {
+		t.Errorf("expected key '%s' to be cached, actual: miss", key)
+		return
+	}
+	if !bytes.Equal(obj.Body, makeTestObj(key, bodyLen).Body) {
+		t.Errorf("expected key '%s' body of %d bytes, actual: %d bytes", key, bodyLen, len(obj.Body))
+	}
+}
+
+func TestLogCacheAddGet(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	c, err := New(filepath.Join(dir, "cache"), 1024*1024, 0)
+	if err != nil {
+		t.Fatalf("creating cache: %s", err.Error())
+	}
+	defer c.Close()
+
+	for i := 0; i < 10; i++ {
+		c.Add(fmt.Sprintf("key%d", i), makeTestObj(fmt.Sprintf("key%d", i), 1000))
+	}
+	c.Add("key0", makeTestObj("key0", 2000))
+	expectHit(t, c, "key0", 2000)
+	for i := 1; i < 10; i++ {
+		expectHit(t, c, fmt.Sprintf("key%d", i), 1000)
+	}
+	if _, ok := c.Get("nonexistent"); ok {
+		t.Error("expected nonexistent key to miss, actual: hit")
+	}
+	if len(c.Keys()) != 10 {
+		t.Errorf("expected 10 keys, actual: %d", len(c.Keys()))
+	}
+}
+
+func TestLogCacheWrap(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	capacity := uint64(64 * 1024)
+	path := filepath.Join(dir, "cache")
+	c, err := New(path, capacity, 0)
+	if err != nil {
+		t.Fatalf("creating cache: %s", err.Error())
+	}
+	for i := 0; i < 200; i++ {
+		key := fmt.Sprintf("key%d", i)
+		c.Add(key, makeTestObj(key, 1000))
+		if c.Size() > c.Capacity() {
+			t.Fatalf("expected size <= capacity %d, actual: %d", c.Capacity(), c.Size())
+		}
+	}
+	if _, ok := c.Get("key0"); ok {
+		t.Error("expected the oldest object to be evicted, actual: hit")
+	}
+	expectHit(t, c, "key199", 1000)
+	keys := c.Keys()
+	c.Close()
+
+	c, err = New(path, capacity, 0)
+	if err != nil {
+		t.Fatalf("reopening cache: %s", err.Error())
+	}
+	defer c.Close()
+	if len(c.Keys()) != len(keys) {
+		t.Errorf("expected %d keys after reopening, actual: %d", len(keys), len(c.Keys()))
+	}
+	for _, key := range keys {
+		expectHit(t, c, key, 1000)
+	}
+}
+
+func TestLogCacheRecoverAfterCrash(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	capacity := uint64(64 * 1024)
+	path := filepath.Join(dir, "cache")
+	c, err := New(path, capacity, 0)
+	if err != nil {
+		t.Fatalf("creating cache: %s", err.Error())
+	}
+	for i := 0; i < 30; i++ {
+		key := fmt.Sprintf("key%d", i)
+		c.Add(key, makeTestObj(key, 1000))
+	}
+	if err := c.PersistIndex(); err != nil {
+		t.Fatalf("persisting index: %s", err.Error())
+	}
+	// these wrap around the log and overwrite objects in the persisted index
+	for i := 30; i < 80; i++ {
+		key := fmt.Sprintf("key%d", i)
+		c.Add(key, makeTestObj(key, 1000))
+	}
+	expected := c.Keys()
+
+	// simulate a torn write after the last record
+	c.m.RLock()
+	head := c.head
+	c.m.RUnlock()
+	torn := encodeRecord(c.nextSeq, "torn", makeTestObj("torn", 1000).Body)
+	if _, err := c.file.WriteAt(torn[:len(torn)/2], int64(head)); err != nil {
+		t.Fatalf("writing torn record: %s", err.Error())
+	}
+	// crash, without closing the cache and persisting the index
+	c.file.Close()
+
+	c, err = New(path, capacity, 0)
+	if err != nil {
+		t.Fatalf("reopening cache: %s", err.Error())
+	}
+	defer c.Close()
+	if len(c.Keys()) != len(expected) {
+		t.Errorf("expected %d keys after crash, actual: %d", len(expected), len(c.Keys()))
+	}
+	for _, key := range expected {
+		expectHit(t, c, key, 1000)
+	}
+	if _, ok := c.Get("key0"); ok {
+		t.Error("expected overwritten object to be evicted after crash, actual: hit")
+	}
+	if _, ok := c.Get("torn"); ok {
+		t.Error("expected torn object to be ignored, actual: hit")
+	}
+}
+
+func TestLogCacheCorruptRecord(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	c, err := New(filepath.Join(dir, "cache"), 64*1024, 0)
+	if err != nil {
+		t.Fatalf("creating cache: %s", err.Error())
+	}
+	defer c.Close()
+	c.Add("key", makeTestObj("key", 1000))
+	if _, err := c.file.WriteAt([]byte("corrupt"), recordHeaderLen+100); err != nil {
+		t.Fatalf("corrupting record: %s", err.Error())
+	}
+	if _, ok := c.Get("key"); ok {
+		t.Error("expected corrupt object to miss, actual: hit")
+	}
+	if len(c.Keys()) != 0 {
+		t.Errorf("expected corrupt object to be removed, actual keys: %v", c.Keys())
+	}
+}
+
+func BenchmarkLogCacheAdd(b *testing.B) {
+	dir := tempDir(b)
+	defer os.RemoveAll(dir)
+	c, err := New(filepath.Join(dir, "cache"), 256*1024*1024, 0)
+	if err != nil {
+		b.Fatalf("creating cache: %s", err.Error())
+	}
+	defer c.Close()
+	benchmarkAdd(b, c.Add)
+}
+
+func BenchmarkBoltDiskCacheAdd(b *testing.B) {
+	dir := tempDir(b)
+	defer os.RemoveAll(dir)
+	c, err := diskcache.New(filepath.Join(dir, "cache"), 256*1024*1024)
+	if err != nil {
+		b.Fatalf("creating cache: %s", err.Error())
+	}
+	defer c.Close()
+	benchmarkAdd(b, c.Add)
+}
+
+func BenchmarkLogCacheGet(b *testing.B) {
+	dir := tempDir(b)
+	defer os.RemoveAll(dir)
+	c, err := New(filepath.Join(dir, "cache"), 256*1024*1024, 0)
+	if err != nil {
+		b.Fatalf("creating cache: %s", err.Error())
+	}
+	defer c.Close()
+	benchmarkGet(b, c.Add, c.Get)
+}
+
+func BenchmarkBoltDiskCacheGet(b *testing.B) {
+	dir := tempDir(b)
+	defer os.RemoveAll(dir)
+	c, err := diskcache.New(filepath.Join(dir, "cache"), 256*1024*1024)
+	if err != nil {
+		b.Fatalf("creating cache: %s", err.Error())
+	}
+	defer c.Close()
+	benchmarkGet(b, c.Add, c.Get)
+}
+
+const benchmarkObjBytes = 16 * 1024
+const benchmarkKeys = 1000
+
+func benchmarkAdd(b *testing.B, add func(string, *cacheobj.CacheObj) bool) {
+	obj := makeTestObj("benchmark", benchmarkObjBytes)
+	b.SetBytes(benchmarkObjBytes)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		add(fmt.Sprintf("key%d", i%benchmarkKeys), obj)
+	}
+}
+
+func benchmarkGet(b *testing.B, add func(string, *cacheobj.CacheObj) bool, get func(string) (*cacheobj.CacheObj, bool)) {
+	obj := makeTestObj("benchmark", benchmarkObjBytes)
+	for i := 0; i < benchmarkKeys; i++ {
+		add(fmt.Sprintf("key%d", i), obj)
+	}
+	b.SetBytes(benchmarkObjBytes)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, ok := get(fmt.Sprintf("key%d", i%benchmarkKeys)); !ok {
+			b.Fatalf("expected key%d to be cached", i%benchmarkKeys)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package logcache
+
+/*
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/apache/trafficcontrol/grove/cacheobj"
+	"github.com/apache/trafficcontrol/grove/config"
+
+	"github.com/apache/trafficcontrol/lib/go-log"
+	"github.com/apache/trafficcontrol/lib/go-util"
+
+	"github.com/dchest/siphash"
+)
+
+// MultiLogCache is a log cache using multiple files, typically on multiple physical disks.
+//
+// Keys are distributed across the files with weighted rendezvous hashing, where each file is identified by its
+// path and weighted by its size. Therefore, adding or removing a file only moves the keys mapped to that file,
+// and objects in all other files stay cached.
+type MultiLogCache struct {
+	m             sync.RWMutex
+	caches        []*LogCache
+	hashKeys      [][2]uint64
+	indexInterval time.Duration
+}
+
+// NewMulti opens the log caches in the given files.
+func NewMulti(files []config.CacheFile, indexInterval time.Duration) (*MultiLogCache, error) {
+	c := &MultiLogCache{indexInterval: indexInterval}
+	if err := c.SetFiles(files); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// SetFiles changes the files of the cache to the given files. Files whose paths and sizes are already in the
+// cache are kept open, so only the objects in removed files, and the objects whose keys are now mapped to
+// added files, are no longer cached. Files whose sizes changed are reopened, keeping the objects which still
+// fit. If opening any file fails, the other files are still used, and an error is returned.
+//
+// Requests to the cache block while the files are opened, which is typically quick, because opening a file
+// only loads its persisted index.
+func (c *MultiLogCache) SetFiles(files []config.CacheFile) error {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	existing := map[string]*LogCache{}
+	for _, cache := range c.caches {
+		existing[cache.Path()] = cache
+	}
+
+	caches := make([]*LogCache, 0, len(files))
+	errs := []error{}
+	for _, file := range files {
+		if cache, ok := existing[file.Path]; ok {
+			delete(existing, file.Path)
+			if cache.Capacity() == file.Bytes {
+				caches = append(caches, cache)
+				continue
+			}
+			cache.Close()
+		}
+		cache, err := New(file.Path, file.Bytes, c.indexInterval)
+		if err != nil {
+			errs = append(errs, errors.New("creating log cache '"+file.Path+"': "+err.Error()))
+			continue
+		}
+		caches = append(caches, cache)
+	}
+	for path, cache := range existing {
+		log.Infoln("MultiLogCache: closing removed cache file '" + path + "'")
+		cache.Close()
+	}
+
+	c.caches = caches
+	c.hashKeys = make([][2]uint64, len(caches))
+	for i, cache := range caches {
+		c.hashKeys[i] = pathHashKey(cache.Path())
+	}
+	return util.JoinErrs(errs)
+}
+
+// pathHashKey returns the siphash key used to hash keys for the cache file with the given path.
+func pathHashKey(path string) [2]uint64 {
+	k0, k1 := siphash.Hash128(0, 0, []byte(path))
+	return [2]uint64{k0, k1}
+}
+
+// cacheFor returns the cache to which the given key is mapped. The caller must hold the read lock of c.m.
+func (c *MultiLogCache) cacheFor(key string) *LogCache {
+	best := (*LogCache)(nil)
+	bestScore := math.Inf(-1)
+	for i, cache := range c.caches {
+		hash := siphash.Hash(c.hashKeys[i][0], c.hashKeys[i][1], []byte(key))
+		// map the hash to (0,1), and weight it by the file size
+		unit := (float64(hash>>11) + 0.5) / (1 << 53)
+		score := float64(cache.Capacity()) / -math.Log(unit)
+		if score > bestScore {
+			best = cache
+			bestScore = score
+		}
+	}
+	return best
+}
+
+func (c *MultiLogCache) Add(key string, val *cacheobj.CacheObj) bool {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	cache := c.cacheFor(key)
+	if cache == nil {
+		return false
+	}
+	log.Debugf("MultiLogCache.Add key '%+v' size '%+v' mapped to %+v\n", key, val.Size, cache.Path())
+	return cache.Add(key, val)
+}
+
+func (c *MultiLogCache) Get(key string) (*cacheobj.CacheObj, bool) {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	cache := c.cacheFor(key)
+	if cache == nil {
+		return nil, false
+	}
+	return cache.Get(key)
+}
+
+func (c *MultiLogCache) Peek(key string) (*cacheobj.CacheObj, bool) {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	cache := c.cacheFor(key)
+	if cache == nil {
+		return nil, false
+	}
+	return cache.Peek(key)
+}
+
+func (c *MultiLogCache) Size() uint64 {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	sum := uint64(0)
+	for _, cache := range c.caches {
+		sum += cache.Size()
+	}
+	return sum
+}
+
+func (c *MultiLogCache) Close() {
+	c.m.Lock()
+	defer c.m.Unlock()
+	for _, cache := range c.caches {
+		cache.Close()
+	}
+	c.caches = nil
+	c.hashKeys = nil
+}
+
+// Keys returns the keys of the objects in all files. Note this may include keys which are no longer mapped to
+// the file they're in, after files were added.
+func (c *MultiLogCache) Keys() []string {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	arr := make([]string, 0)
+	for _, cache := range c.caches {
+		arr = append(arr, cache.Keys()...)
+	}
+	return arr
+}
+
+func (c *MultiLogCache) Capacity() uint64 {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	sum := uint64(0)
+	for _, cache := range c.caches {
+		sum += cache.Capacity()
+	}
+	return sum
+}
@@ -0,0 +1,120 @@
+package logcache
+
+/*
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/apache/trafficcontrol/grove/config"
+)
+
+func TestMultiLogCacheSetFiles(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	files := []config.CacheFile{}
+	for i := 0; i < 3; i++ {
+		files = append(files, config.CacheFile{Path: filepath.Join(dir, fmt.Sprintf("cache%d", i)), Bytes: 1024 * 1024})
+	}
+	c, err := NewMulti(files, 0)
+	if err != nil {
+		t.Fatalf("creating cache: %s", err.Error())
+	}
+	defer c.Close()
+
+	const numKeys = 300
+	for i := 0; i < numKeys; i++ {
+		key := fmt.Sprintf("key%d", i)
+		c.Add(key, makeTestObj(key, 100))
+	}
+	hits := func() int {
+		n := 0
+		for i := 0; i < numKeys; i++ {
+			if _, ok := c.Get(fmt.Sprintf("key%d", i)); ok {
+				n++
+			}
+		}
+		return n
+	}
+	if n := hits(); n != numKeys {
+		t.Fatalf("expected %d hits, actual: %d", numKeys, n)
+	}
+
+	// adding a file should only move about a quarter of the keys
+	files = append(files, config.CacheFile{Path: filepath.Join(dir, "cache3"), Bytes: 1024 * 1024})
+	if err := c.SetFiles(files); err != nil {
+		t.Fatalf("adding file: %s", err.Error())
+	}
+	if n := hits(); n < numKeys/2 || n == numKeys {
+		t.Errorf("expected about 3/4 of %d keys to hit after adding a file, actual: %d", numKeys, n)
+	}
+
+	// removing the added file should restore all the keys which weren't overwritten
+	if err := c.SetFiles(files[:3]); err != nil {
+		t.Fatalf("removing file: %s", err.Error())
+	}
+	if n := hits(); n != numKeys {
+		t.Errorf("expected %d hits after removing the added file, actual: %d", numKeys, n)
+	}
+
+	// removing a file should only lose its keys
+	if err := c.SetFiles(files[1:3]); err != nil {
+		t.Fatalf("removing file: %s", err.Error())
+	}
+	if n := hits(); n < numKeys/2 || n == numKeys {
+		t.Errorf("expected about 2/3 of %d keys to hit after removing a file, actual: %d", numKeys, n)
+	}
+	if c.Capacity() != 2*1024*1024 {
+		t.Errorf("expected capacity of 2 files, actual: %d", c.Capacity())
+	}
+}
+
+func TestMultiLogCacheConcurrent(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	files := []config.CacheFile{
+		{Path: filepath.Join(dir, "cache0"), Bytes: 64 * 1024},
+		{Path: filepath.Join(dir, "cache1"), Bytes: 64 * 1024},
+	}
+	c, err := NewMulti(files, 0)
+	if err != nil {
+		t.Fatalf("creating cache: %s", err.Error())
+	}
+	defer c.Close()
+
+	wg := sync.WaitGroup{}
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				key := fmt.Sprintf("key%d", (i*8+w)%100)
+				c.Add(key, makeTestObj(key, 1000))
+				if obj, ok := c.Get(key); ok && len(obj.Body) != 1000 {
+					t.Errorf("expected key '%s' body of 1000 bytes, actual: %d", key, len(obj.Body))
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	if c.Size() > c.Capacity() {
+		t.Errorf("expected size <= capacity %d, actual: %d", c.Capacity(), c.Size())
+	}
+}
@@ -0,0 +1,113 @@
+package logcache
+
+/*
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+)
+
+// Each object is stored in the log as a record, consisting of a fixed-size header followed by the key and
+// the value:
+//
+//	magic  uint32
+//	seq    uint64 - the sequence number of the record, one greater than the previous record in the log
+//	keyLen uint32
+//	valLen uint32
+//	crc    uint32 - the CRC-32C of the key and value
+//
+// When a record doesn't fit between the write head and the end of the file, a wrap record (a header with
+// wrapMagic and no key or value) is written if there's room for it, and the head moves to the start of the
+// file. Sequence numbers allow the log to be replayed from a persisted index, stopping at the first record
+// which wasn't completely written.
+const (
+	recordMagic     = uint32(0x47525631) // "GRV1"
+	wrapMagic       = uint32(0x47525750) // "GRWP"
+	recordHeaderLen = 4 + 8 + 4 + 4 + 4
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+type recordHeader struct {
+	magic  uint32
+	seq    uint64
+	keyLen uint32
+	valLen uint32
+	crc    uint32
+}
+
+func (h recordHeader) recordLen() uint64 {
+	return recordHeaderLen + uint64(h.keyLen) + uint64(h.valLen)
+}
+
+func encodeHeader(buf []byte, h recordHeader) {
+	binary.BigEndian.PutUint32(buf[0:], h.magic)
+	binary.BigEndian.PutUint64(buf[4:], h.seq)
+	binary.BigEndian.PutUint32(buf[12:], h.keyLen)
+	binary.BigEndian.PutUint32(buf[16:], h.valLen)
+	binary.BigEndian.PutUint32(buf[20:], h.crc)
+}
+
+func decodeHeader(buf []byte) recordHeader {
+	return recordHeader{
+		magic:  binary.BigEndian.Uint32(buf[0:]),
+		seq:    binary.BigEndian.Uint64(buf[4:]),
+		keyLen: binary.BigEndian.Uint32(buf[12:]),
+		valLen: binary.BigEndian.Uint32(buf[16:]),
+		crc:    binary.BigEndian.Uint32(buf[20:]),
+	}
+}
+
+// encodeRecord returns the bytes of the record storing the given key and value.
+func encodeRecord(seq uint64, key string, val []byte) []byte {
+	buf := make([]byte, recordHeaderLen+len(key)+len(val))
+	copy(buf[recordHeaderLen:], key)
+	copy(buf[recordHeaderLen+len(key):], val)
+	encodeHeader(buf, recordHeader{
+		magic:  recordMagic,
+		seq:    seq,
+		keyLen: uint32(len(key)),
+		valLen: uint32(len(val)),
+		crc:    crc32.Checksum(buf[recordHeaderLen:], crcTable),
+	})
+	return buf
+}
+
+// encodeWrap returns the bytes of a wrap record.
+func encodeWrap(seq uint64) []byte {
+	buf := make([]byte, recordHeaderLen)
+	encodeHeader(buf, recordHeader{magic: wrapMagic, seq: seq})
+	return buf
+}
+
+// decodeRecord verifies the given bytes of a whole record, and returns its header, key, and value.
+func decodeRecord(buf []byte) (recordHeader, string, []byte, error) {
+	if len(buf) < recordHeaderLen {
+		return recordHeader{}, "", nil, errors.New("record shorter than its header")
+	}
+	h := decodeHeader(buf)
+	if h.magic != recordMagic {
+		return h, "", nil, errors.New("bad record magic")
+	}
+	if h.recordLen() != uint64(len(buf)) {
+		return h, "", nil, errors.New("record length doesn't match its header")
+	}
+	if crc32.Checksum(buf[recordHeaderLen:], crcTable) != h.crc {
+		return h, "", nil, errors.New("record checksum mismatch")
+	}
+	keyEnd := recordHeaderLen + int(h.keyLen)
+	return h, string(buf[recordHeaderLen:keyEnd]), buf[keyEnd:], nil
+}
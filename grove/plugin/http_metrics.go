@@ -0,0 +1,61 @@
+package plugin
+
+/*
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/apache/trafficcontrol/grove/stat"
+	"github.com/apache/trafficcontrol/grove/web"
+
+	"github.com/apache/trafficcontrol/lib/go-log"
+)
+
+func init() {
+	AddPlugin(10000, Funcs{onRequest: metrics})
+}
+
+const MetricsEndpoint = "/_metrics"
+
+// metrics serves the stats in the OpenMetrics text format, for Prometheus to scrape. Access is allowed and denied by the same rules as the astats endpoint.
+func metrics(icfg interface{}, d OnRequestData) bool {
+	if !strings.HasPrefix(d.R.URL.Path, MetricsEndpoint) {
+		return false
+	}
+
+	w := d.W
+	ip, err := web.GetIP(d.R)
+	if err != nil {
+		code := http.StatusInternalServerError
+		w.WriteHeader(code)
+		w.Write([]byte(http.StatusText(code)))
+		log.Errorln("metrics plugin failed to get IP: " + err.Error())
+		return true
+	}
+	if !d.StatRules.Allowed(ip) {
+		code := http.StatusForbidden
+		w.WriteHeader(code)
+		w.Write([]byte(http.StatusText(code)))
+		log.Debugln("metrics plugin IP " + ip.String() + " FORBIDDEN")
+		return true
+	}
+
+	w.Header().Set("Content-Type", stat.OpenMetricsContentType)
+	if err := stat.WriteOpenMetrics(w, d.Stats); err != nil {
+		log.Errorln("metrics plugin writing metrics: " + err.Error())
+	}
+	return true
+}
@@ -103,8 +103,8 @@ func LoadSystemStats(stats stat.Stats, interfaceName string) stat.StatsSystemJSO
 func LoadRemapStats(stats stat.Stats, httpConns *web.ConnMap, httpsConns *web.ConnMap) map[string]interface{} {
 	statsRemaps := stats.Remap()
 	rules := statsRemaps.Rules()
-	jsonStats := make(map[string]interface{}, len(rules)*8) // remap has 8 members: in, out, 2xx, 3xx, 4xx, 5xx, hits, misses
-	jsonStats["server"] = "6.2.1"                           // emulate a good ATS version
+	jsonStats := make(map[string]interface{}, len(rules)*13) // remap has 13 members: in, out, 2xx, 3xx, 4xx, 5xx, hits, misses, collapsed, stale-while-revalidate, stale-if-error, revalidations, revalidation failures
+	jsonStats["server"] = "6.2.1"                            // emulate a good ATS version
 	for _, rule := range rules {
 		ruleName := rule
 		statsRemap, ok := statsRemaps.Stats(ruleName)
@@ -119,6 +119,11 @@ func LoadRemapStats(stats stat.Stats, httpConns *web.ConnMap, httpsConns *web.Co
 		jsonStats["plugin.remap_stats."+ruleName+".status_5xx"] = statsRemap.Status5xx()
 		jsonStats["plugin.remap_stats."+ruleName+".cache_hits"] = statsRemap.CacheHits()
 		jsonStats["plugin.remap_stats."+ruleName+".cache_misses"] = statsRemap.CacheMisses()
+		jsonStats["plugin.remap_stats."+ruleName+".collapsed_requests"] = statsRemap.CollapsedRequests()
+		jsonStats["plugin.remap_stats."+ruleName+".stale_while_revalidate"] = statsRemap.StaleWhileRevalidate()
+		jsonStats["plugin.remap_stats."+ruleName+".stale_if_error"] = statsRemap.StaleIfError()
+		jsonStats["plugin.remap_stats."+ruleName+".background_revalidations"] = statsRemap.BackgroundRevalidations()
+		jsonStats["plugin.remap_stats."+ruleName+".background_revalidation_failures"] = statsRemap.BackgroundRevalidationFailures()
 	}
 
 	jsonStats["proxy.process.http.current_client_connections"] = httpConns.Len() + httpsConns.Len()
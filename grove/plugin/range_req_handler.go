@@ -149,6 +149,9 @@ func rangeReqHandleBeforeRespond(icfg interface{}, d BeforeRespondData) {
 	if cfg.Mode == "store_ranges" {
 		return // no need to do anything here.
 	}
+	if *d.Code == http.StatusPartialContent {
+		return // the response is already a range, e.g. from a sliced remap rule.
+	}
 
 	// mode != store_ranges
 	multipartBoundaryString := cfg.MultiPartBoundary
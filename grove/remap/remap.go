@@ -126,6 +126,15 @@ func (p *RemappingProducer) Name() string                      { return p.rule.N
 func (p *RemappingProducer) DSCP() int                         { return p.rule.DSCP }
 func (p *RemappingProducer) PluginCfg() map[string]interface{} { return p.rule.Plugins }
 func (p *RemappingProducer) Cache() icache.Cache               { return p.rule.Cache }
+func (p *RemappingProducer) StaleWhileRevalidate() bool        { return p.rule.StaleWhileRevalidate }
+func (p *RemappingProducer) StaleIfError() bool                { return p.rule.StaleIfError }
+func (p *RemappingProducer) CollapseRequests() bool            { return p.rule.CollapseRequests }
+func (p *RemappingProducer) SliceBlockBytes() int64            { return p.rule.SliceBlockBytes }
+
+// Copy returns a new RemappingProducer for the same rule and cache key as p, which has made no requests. This is used to make requests independent of p's retries, such as background revalidations.
+func (p *RemappingProducer) Copy() *RemappingProducer {
+	return &RemappingProducer{rule: p.rule, oldURI: p.oldURI, cacheKey: p.cacheKey}
+}
 func (p *RemappingProducer) FirstFQDN() string {
 	// TODO verify To is not allowed to be constructed with < 1 element
 	return strings.TrimPrefix(strings.TrimPrefix(p.rule.To[0].URL, "http://"), "https://")
@@ -418,6 +427,10 @@ func LoadRemapRules(path string, pluginConfigLoaders map[string]plugin.LoadFunc,
 			rule.RetryNum = remapRules.RetryNum
 		}
 
+		if rule.SliceBlockBytes != 0 && (rule.SliceBlockBytes < remapdata.MinSliceBlockBytes || rule.SliceBlockBytes > remapdata.MaxSliceBlockBytes) {
+			return nil, nil, nil, fmt.Errorf("error parsing rule %v slice block bytes must be between %v and %v: %v", rule.Name, remapdata.MinSliceBlockBytes, remapdata.MaxSliceBlockBytes, rule.SliceBlockBytes)
+		}
+
 		if rule.PluginsShared == nil {
 			rule.PluginsShared = remapRules.PluginsShared
 		}
@@ -98,8 +98,22 @@ type RemapRuleBase struct {
 	RetryNum               *int                       `json:"retry_num"`
 	DSCP                   int                        `json:"dscp"`
 	PluginsShared          map[string]json.RawMessage `json:"plugins_shared"`
+	// StaleWhileRevalidate is whether to honor the stale-while-revalidate Cache-Control directive of RFC5861§3, serving stale objects within the origin's window while they're revalidated in the background.
+	StaleWhileRevalidate bool `json:"stale_while_revalidate"`
+	// StaleIfError is whether to honor the stale-if-error Cache-Control directive of RFC5861§4, in requests and responses, serving stale objects within the window if revalidating them fails.
+	StaleIfError bool `json:"stale_if_error"`
+	// CollapseRequests is whether concurrent requests to the parent for the same cache key are strictly collapsed into a single request, whose response is given to all requestors even if it isn't reusable. If false, requestors make their own requests when the shared response can't be reused.
+	CollapseRequests bool `json:"collapse_requests"`
+	// SliceBlockBytes is the size of the blocks to slice objects into, which are requested and cached separately, so byte ranges of large objects can be served from the cache without fetching the whole object. If this is 0, objects are not sliced.
+	SliceBlockBytes int64 `json:"slice_block_bytes"`
 }
 
+// The limits of RemapRuleBase.SliceBlockBytes, which are the same as those of the ATS slice plugin.
+const (
+	MinSliceBlockBytes = 256 * 1024
+	MaxSliceBlockBytes = 32 * 1024 * 1024
+)
+
 type RemapRule struct {
 	RemapRuleBase
 	Timeout         *time.Duration
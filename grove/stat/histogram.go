@@ -0,0 +1,71 @@
+package stat
+
+/*
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// DefaultLatencyBuckets are the upper bounds, in seconds, of the buckets of latency histograms. They're the same as the Prometheus client defaults.
+var DefaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Histogram is a threadsafe histogram of durations, with fixed buckets.
+type Histogram struct {
+	bounds   []float64
+	counts   []uint64 // counts[i] is the number of observations in bucket i, not cumulative. The last is the +Inf bucket.
+	sumNanos uint64
+}
+
+// HistogramSnapshot is the state of a Histogram at a point in time.
+type HistogramSnapshot struct {
+	// Bounds are the upper bounds of the buckets, in seconds, not including +Inf.
+	Bounds []float64
+	// Counts are the cumulative counts of observations less than or equal to each bound, followed by the count of all observations.
+	Counts []uint64
+	// Sum is the sum of all observations, in seconds.
+	Sum float64
+}
+
+// Count returns the total number of observations.
+func (s HistogramSnapshot) Count() uint64 { return s.Counts[len(s.Counts)-1] }
+
+// NewHistogram returns a new Histogram with the given bucket upper bounds in seconds, which must be sorted ascending.
+func NewHistogram(bounds []float64) *Histogram {
+	return &Histogram{bounds: bounds, counts: make([]uint64, len(bounds)+1)}
+}
+
+// Observe adds the given duration to the histogram.
+func (h *Histogram) Observe(d time.Duration) {
+	seconds := d.Seconds()
+	i := 0
+	for i < len(h.bounds) && seconds > h.bounds[i] {
+		i++
+	}
+	atomic.AddUint64(&h.counts[i], 1)
+	atomic.AddUint64(&h.sumNanos, uint64(d))
+}
+
+// Snapshot returns the current state of the histogram. Because observations aren't locked, a snapshot taken during observations may include an observation's count but not its sum, or vice-versa.
+func (h *Histogram) Snapshot() HistogramSnapshot {
+	s := HistogramSnapshot{Bounds: h.bounds, Counts: make([]uint64, len(h.counts))}
+	cumulative := uint64(0)
+	for i := range h.counts {
+		cumulative += atomic.LoadUint64(&h.counts[i])
+		s.Counts[i] = cumulative
+	}
+	s.Sum = time.Duration(atomic.LoadUint64(&h.sumNanos)).Seconds()
+	return s
+}
@@ -0,0 +1,148 @@
+package stat
+
+/*
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+import (
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// OpenMetricsContentType is the Content-Type of the OpenMetrics text format written by WriteOpenMetrics.
+const OpenMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// metricsWriter builds an OpenMetrics text exposition.
+type metricsWriter struct {
+	b strings.Builder
+}
+
+// family writes the metadata of a metric family.
+func (m *metricsWriter) family(name string, typ string, unit string, help string) {
+	m.b.WriteString("# TYPE " + name + " " + typ + "\n")
+	if unit != "" {
+		m.b.WriteString("# UNIT " + name + " " + unit + "\n")
+	}
+	m.b.WriteString("# HELP " + name + " " + help + "\n")
+}
+
+// sample writes a sample with the given labels, which are name-value pairs.
+func (m *metricsWriter) sample(name string, val string, labels ...string) {
+	m.b.WriteString(name)
+	if len(labels) > 0 {
+		m.b.WriteString("{")
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				m.b.WriteString(",")
+			}
+			m.b.WriteString(labels[i] + `="` + escapeLabelValue(labels[i+1]) + `"`)
+		}
+		m.b.WriteString("}")
+	}
+	m.b.WriteString(" " + val + "\n")
+}
+
+func (m *metricsWriter) uint(name string, val uint64, labels ...string) {
+	m.sample(name, strconv.FormatUint(val, 10), labels...)
+}
+
+func formatFloat(f float64) string { return strconv.FormatFloat(f, 'g', -1, 64) }
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(s string) string { return labelValueEscaper.Replace(s) }
+
+// WriteOpenMetrics writes the stats in the OpenMetrics text format, for Prometheus to scrape. Remap rule stats are labelled with the "remap" FQDN of the rule, which is the same key used by the astats JSON.
+func WriteOpenMetrics(w io.Writer, s Stats) error {
+	m := &metricsWriter{}
+
+	m.family("grove", "info", "", "Information about the Grove server.")
+	m.uint("grove_info", 1, "version", s.System().Version())
+
+	m.family("grove_connections", "gauge", "", "The number of open client connections.")
+	m.uint("grove_connections", s.Connections())
+
+	m.family("grove_config_reloads", "counter", "", "The number of times the config has been reloaded.")
+	m.uint("grove_config_reloads_total", s.System().ConfigReloads())
+
+	cacheNames := s.CacheNames()
+	sort.Strings(cacheNames)
+	m.family("grove_cache_size_bytes", "gauge", "bytes", "The size of the objects in each tier of each cache.")
+	for _, name := range cacheNames {
+		for _, tier := range s.CacheTiers(name) {
+			m.uint("grove_cache_size_bytes", tier.Size, "cache", name, "tier", tier.Tier)
+		}
+	}
+	m.family("grove_cache_capacity_bytes", "gauge", "bytes", "The capacity of each tier of each cache.")
+	for _, name := range cacheNames {
+		for _, tier := range s.CacheTiers(name) {
+			m.uint("grove_cache_capacity_bytes", tier.Capacity, "cache", name, "tier", tier.Tier)
+		}
+	}
+
+	remaps := s.Remap()
+	rules := remaps.Rules()
+	sort.Strings(rules)
+	remapStats := make([]StatsRemap, 0, len(rules))
+	remapNames := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		if st, ok := remaps.Stats(rule); ok {
+			remapStats = append(remapStats, st)
+			remapNames = append(remapNames, rule)
+		}
+	}
+	remapCounter := func(name string, unit string, help string, get func(StatsRemap) uint64) {
+		m.family(name, "counter", unit, help)
+		for i, st := range remapStats {
+			m.uint(name+"_total", get(st), "remap", remapNames[i])
+		}
+	}
+
+	remapCounter("grove_requests", "", "The number of client requests.", func(st StatsRemap) uint64 { return st.CacheHits() + st.CacheMisses() })
+	m.family("grove_responses", "counter", "", "The number of client responses, by status code class.")
+	for i, st := range remapStats {
+		m.uint("grove_responses_total", st.Status2xx(), "remap", remapNames[i], "code_class", "2xx")
+		m.uint("grove_responses_total", st.Status3xx(), "remap", remapNames[i], "code_class", "3xx")
+		m.uint("grove_responses_total", st.Status4xx(), "remap", remapNames[i], "code_class", "4xx")
+		m.uint("grove_responses_total", st.Status5xx(), "remap", remapNames[i], "code_class", "5xx")
+	}
+	remapCounter("grove_in_bytes", "bytes", "The bytes read from clients.", StatsRemap.InBytes)
+	remapCounter("grove_out_bytes", "bytes", "The bytes written to clients.", StatsRemap.OutBytes)
+	remapCounter("grove_cache_hits", "", "The number of client requests served from the cache.", StatsRemap.CacheHits)
+	remapCounter("grove_cache_misses", "", "The number of client requests not served from the cache.", StatsRemap.CacheMisses)
+	m.family("grove_stale_responses", "counter", "", "The number of stale responses served, per RFC5861, by reason.")
+	for i, st := range remapStats {
+		m.uint("grove_stale_responses_total", st.StaleWhileRevalidate(), "remap", remapNames[i], "reason", "stale_while_revalidate")
+		m.uint("grove_stale_responses_total", st.StaleIfError(), "remap", remapNames[i], "reason", "stale_if_error")
+	}
+	remapCounter("grove_collapsed_requests", "", "The number of requests given the response of a concurrent parent request for the same object.", StatsRemap.CollapsedRequests)
+	remapCounter("grove_background_revalidations", "", "The number of stale objects revalidated in the background.", StatsRemap.BackgroundRevalidations)
+	remapCounter("grove_background_revalidation_failures", "", "The number of background revalidations which failed.", StatsRemap.BackgroundRevalidationFailures)
+
+	m.family("grove_parent_request_duration_seconds", "histogram", "seconds", "The time taken by parent requests, until the full response is received.")
+	for i, st := range remapStats {
+		latency := st.ParentLatency()
+		for j, bound := range latency.Bounds {
+			m.uint("grove_parent_request_duration_seconds_bucket", latency.Counts[j], "remap", remapNames[i], "le", formatFloat(bound))
+		}
+		m.uint("grove_parent_request_duration_seconds_bucket", latency.Count(), "remap", remapNames[i], "le", "+Inf")
+		m.sample("grove_parent_request_duration_seconds_sum", formatFloat(latency.Sum), "remap", remapNames[i])
+		m.uint("grove_parent_request_duration_seconds_count", latency.Count(), "remap", remapNames[i])
+	}
+
+	m.b.WriteString("# EOF\n")
+	_, err := io.WriteString(w, m.b.String())
+	return err
+}
@@ -0,0 +1,108 @@
+package stat
+
+/*
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apache/trafficcontrol/grove/icache"
+	"github.com/apache/trafficcontrol/grove/memcache"
+	"github.com/apache/trafficcontrol/grove/remapdata"
+	"github.com/apache/trafficcontrol/grove/tiercache"
+	"github.com/apache/trafficcontrol/grove/web"
+)
+
+func TestHistogram(t *testing.T) {
+	h := NewHistogram([]float64{0.1, 1})
+	h.Observe(50 * time.Millisecond)
+	h.Observe(100 * time.Millisecond)
+	h.Observe(500 * time.Millisecond)
+	h.Observe(5 * time.Second)
+
+	s := h.Snapshot()
+	expected := []uint64{2, 3, 4}
+	for i, count := range expected {
+		if s.Counts[i] != count {
+			t.Errorf("bucket %v: expected cumulative count %v, actual: %v", i, count, s.Counts[i])
+		}
+	}
+	if s.Count() != 4 {
+		t.Errorf("expected count 4, actual: %v", s.Count())
+	}
+	if s.Sum != 5.65 {
+		t.Errorf("expected sum 5.65, actual: %v", s.Sum)
+	}
+}
+
+func TestWriteOpenMetrics(t *testing.T) {
+	rule := remapdata.RemapRule{RemapRuleBase: remapdata.RemapRuleBase{Name: "foo", From: "http://foo.example.net/bar"}}
+	caches := map[string]icache.Cache{
+		"":     memcache.New(1000),
+		"disk": tiercache.New(memcache.New(100), memcache.New(2000)),
+	}
+	s := New([]remapdata.RemapRule{rule}, caches, 1000, web.NewConnMap(), web.NewConnMap(), "1.2.3")
+
+	remapStats, ok := s.Remap().Stats("foo.example.net")
+	if !ok {
+		t.Fatalf("expected remap stats for rule")
+	}
+	remapStats.AddCacheHit()
+	remapStats.AddCacheHit()
+	remapStats.AddCacheMiss()
+	remapStats.AddStatus2xx(3)
+	remapStats.AddOutBytes(1234)
+	remapStats.AddStaleIfError()
+	remapStats.ObserveParentLatency(20 * time.Millisecond)
+
+	buf := &bytes.Buffer{}
+	if err := WriteOpenMetrics(buf, s); err != nil {
+		t.Fatalf("writing metrics: %v", err)
+	}
+	metrics := buf.String()
+
+	for _, expected := range []string{
+		`# TYPE grove_requests counter`,
+		`grove_info{version="1.2.3"} 1`,
+		`grove_requests_total{remap="foo.example.net"} 3`,
+		`grove_responses_total{remap="foo.example.net",code_class="2xx"} 3`,
+		`# UNIT grove_out_bytes bytes`,
+		`grove_out_bytes_total{remap="foo.example.net"} 1234`,
+		`grove_cache_hits_total{remap="foo.example.net"} 2`,
+		`grove_stale_responses_total{remap="foo.example.net",reason="stale_if_error"} 1`,
+		`grove_parent_request_duration_seconds_bucket{remap="foo.example.net",le="0.01"} 0`,
+		`grove_parent_request_duration_seconds_bucket{remap="foo.example.net",le="0.025"} 1`,
+		`grove_parent_request_duration_seconds_bucket{remap="foo.example.net",le="+Inf"} 1`,
+		`grove_parent_request_duration_seconds_count{remap="foo.example.net"} 1`,
+		`grove_cache_capacity_bytes{cache="",tier="memory"} 1000`,
+		`grove_cache_capacity_bytes{cache="disk",tier="memory"} 100`,
+		`grove_cache_capacity_bytes{cache="disk",tier="disk"} 2000`,
+	} {
+		if !strings.Contains(metrics, expected+"\n") {
+			t.Errorf("expected metrics to contain '%v', actual:\n%v", expected, metrics)
+		}
+	}
+	if !strings.HasSuffix(metrics, "# EOF\n") {
+		t.Errorf("expected metrics to end with '# EOF', actual:\n%v", metrics)
+	}
+}
+
+func TestEscapeLabelValue(t *testing.T) {
+	if actual, expected := escapeLabelValue("a\"b\\c\nd"), `a\"b\\c\nd`; actual != expected {
+		t.Errorf("expected '%v', actual: '%v'", expected, actual)
+	}
+}
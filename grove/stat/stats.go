@@ -22,7 +22,9 @@ import (
 
 	"github.com/apache/trafficcontrol/grove/cacheobj"
 	"github.com/apache/trafficcontrol/grove/icache"
+	"github.com/apache/trafficcontrol/grove/memcache"
 	"github.com/apache/trafficcontrol/grove/remapdata"
+	"github.com/apache/trafficcontrol/grove/tiercache"
 	"github.com/apache/trafficcontrol/grove/web"
 
 	"github.com/apache/trafficcontrol/lib/go-log"
@@ -65,8 +67,21 @@ type Stats interface {
 	CacheCapacityByName(string) (uint64, bool)
 	CacheNames() []string
 	CachePeek(string, string) (*cacheobj.CacheObj, bool)
+	CacheTiers(string) []CacheTier
 }
 
+// CacheTier is the occupancy of one tier, memory or disk, of a cache.
+type CacheTier struct {
+	Tier     string
+	Size     uint64
+	Capacity uint64
+}
+
+const (
+	CacheTierMemory = "memory"
+	CacheTierDisk   = "disk"
+)
+
 func New(remapRules []remapdata.RemapRule, caches map[string]icache.Cache, cacheCapacityBytes uint64, httpConns *web.ConnMap, httpsConns *web.ConnMap, version string) Stats {
 	cacheHits := uint64(0)
 	cacheMisses := uint64(0)
@@ -206,6 +221,25 @@ func (s stats) CacheCapacityByName(cName string) (uint64, bool) {
 
 func (s stats) CacheCapacity() uint64 { return s.cacheCapacityBytes }
 
+// CacheTiers returns the occupancy of each tier of the cache with the given name. Tiered caches have a memory tier in front of a disk tier, and the default cache is only in memory.
+func (s stats) CacheTiers(cacheName string) []CacheTier {
+	cache, ok := s.caches[cacheName]
+	if !ok {
+		return nil
+	}
+	makeTier := func(tier string, c icache.Cache) CacheTier {
+		return CacheTier{Tier: tier, Size: c.Size(), Capacity: c.Capacity()}
+	}
+	switch c := cache.(type) {
+	case *tiercache.TierCache:
+		return []CacheTier{makeTier(CacheTierMemory, c.First()), makeTier(CacheTierDisk, c.Second())}
+	case *memcache.MemCache:
+		return []CacheTier{makeTier(CacheTierMemory, c)}
+	default:
+		return []CacheTier{makeTier(CacheTierDisk, c)}
+	}
+}
+
 type StatsRemaps interface {
 	Stats(fqdn string) (StatsRemap, bool)
 	Rules() []string
@@ -229,6 +263,24 @@ type StatsRemap interface {
 	AddCacheHit()
 	CacheMisses() uint64
 	AddCacheMiss()
+
+	// CollapsedRequests is the number of requests which were given the response of a concurrent parent request for the same key, rather than making their own.
+	CollapsedRequests() uint64
+	AddCollapsedRequest()
+	// StaleWhileRevalidate is the number of stale responses served while being revalidated in the background, per RFC5861§3.
+	StaleWhileRevalidate() uint64
+	AddStaleWhileRevalidate()
+	// StaleIfError is the number of stale responses served because revalidating them failed, per RFC5861§4.
+	StaleIfError() uint64
+	AddStaleIfError()
+	BackgroundRevalidations() uint64
+	AddBackgroundRevalidation()
+	BackgroundRevalidationFailures() uint64
+	AddBackgroundRevalidationFailure()
+
+	// ParentLatency is the histogram of the time taken by parent requests, from the request until the full response is received.
+	ParentLatency() HistogramSnapshot
+	ObserveParentLatency(time.Duration)
 }
 
 func getFromFQDN(r remapdata.RemapRule) string {
@@ -261,7 +313,7 @@ func (s statsRemaps) Stats(rule string) (StatsRemap, bool) {
 }
 
 func (s statsRemaps) Rules() []string {
-	rules := make([]string, len(s))
+	rules := make([]string, 0, len(s))
 	for rule := range s {
 		rules = append(rules, rule)
 	}
@@ -269,7 +321,7 @@ func (s statsRemaps) Rules() []string {
 }
 
 func NewStatsRemap() StatsRemap {
-	return &statsRemap{}
+	return &statsRemap{parentLatency: NewHistogram(DefaultLatencyBuckets)}
 }
 
 type statsRemap struct {
@@ -281,6 +333,13 @@ type statsRemap struct {
 	status5xx   uint64
 	cacheHits   uint64
 	cacheMisses uint64
+
+	collapsedRequests              uint64
+	staleWhileRevalidate           uint64
+	staleIfError                   uint64
+	backgroundRevalidations        uint64
+	backgroundRevalidationFailures uint64
+	parentLatency                  *Histogram
 }
 
 func (r *statsRemap) InBytes() uint64       { return atomic.LoadUint64(&r.inBytes) }
@@ -302,6 +361,30 @@ func (r *statsRemap) AddCacheHit()      { atomic.AddUint64(&r.cacheHits, 1) }
 func (r *statsRemap) CacheMisses() uint64 { return atomic.LoadUint64(&r.cacheMisses) }
 func (r *statsRemap) AddCacheMiss()       { atomic.AddUint64(&r.cacheMisses, 1) }
 
+func (r *statsRemap) CollapsedRequests() uint64 { return atomic.LoadUint64(&r.collapsedRequests) }
+func (r *statsRemap) AddCollapsedRequest()      { atomic.AddUint64(&r.collapsedRequests, 1) }
+
+func (r *statsRemap) StaleWhileRevalidate() uint64 { return atomic.LoadUint64(&r.staleWhileRevalidate) }
+func (r *statsRemap) AddStaleWhileRevalidate()     { atomic.AddUint64(&r.staleWhileRevalidate, 1) }
+
+func (r *statsRemap) StaleIfError() uint64 { return atomic.LoadUint64(&r.staleIfError) }
+func (r *statsRemap) AddStaleIfError()     { atomic.AddUint64(&r.staleIfError, 1) }
+
+func (r *statsRemap) BackgroundRevalidations() uint64 {
+	return atomic.LoadUint64(&r.backgroundRevalidations)
+}
+func (r *statsRemap) AddBackgroundRevalidation() { atomic.AddUint64(&r.backgroundRevalidations, 1) }
+
+func (r *statsRemap) BackgroundRevalidationFailures() uint64 {
+	return atomic.LoadUint64(&r.backgroundRevalidationFailures)
+}
+func (r *statsRemap) AddBackgroundRevalidationFailure() {
+	atomic.AddUint64(&r.backgroundRevalidationFailures, 1)
+}
+
+func (r *statsRemap) ParentLatency() HistogramSnapshot           { return r.parentLatency.Snapshot() }
+func (r *statsRemap) ObserveParentLatency(latency time.Duration) { r.parentLatency.Observe(latency) }
+
 func NewStatsSystem(version string) StatsSystem {
 	return &statsSystem{version: version}
 }
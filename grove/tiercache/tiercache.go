@@ -88,3 +88,9 @@ func (c *TierCache) Keys() []string {
 
 // Capacity returns the maximum size in bytes of the cache
 func (c *TierCache) Capacity() uint64 { return c.second.Capacity() }
+
+// First returns the first, smaller and faster, cache.
+func (c *TierCache) First() icache.Cache { return c.first }
+
+// Second returns the second, larger and slower, cache.
+func (c *TierCache) Second() icache.Cache { return c.second }
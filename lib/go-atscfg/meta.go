@@ -415,6 +415,9 @@ type configProfileParams struct {
 }
 
 func requiredFiles(atsMajorVer int) []string {
+	if atsMajorVer >= RecordsDotYAMLMinATSMajorVersion {
+		return requiredFiles10()
+	}
 	if atsMajorVer >= 9 {
 		return requiredFiles9()
 	}
@@ -457,6 +460,24 @@ func requiredFiles9() []string {
 	}
 }
 
+// requiredFiles10 is the list of config files required by ATS 10.
+// Note these are not exhaustive. This is only used to error if these are missing.
+// The presence of these is no guarantee the location Parameters are complete and correct.
+func requiredFiles10() []string {
+	return []string{
+		"cache.config",
+		"hosting.config",
+		"ip_allow.yaml",
+		"parent.config",
+		"plugin.config",
+		"records.yaml",
+		"remap.config",
+		"sni.yaml",
+		"storage.config",
+		"volume.config",
+	}
+}
+
 // ensureConfigFile ensures files contains the given fileName. If so, returns files unmodified.
 // If not, if configDir is empty, returns an error.
 // If not, and configDir is nonempty, creates the given file, configDir location, and returns files.
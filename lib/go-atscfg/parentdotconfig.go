@@ -1024,8 +1024,47 @@ func getTopologyParents(
 		return nil, nil, warnings, errors.New("Server '" + *server.HostName + "' DS " + *ds.XMLID + " topology '" + *ds.Topology + "' cachegroup '" + *server.Cachegroup + "' topology node parent " + strconv.Itoa(svNode.Parents[0]) + " is not in the topology!")
 	}
 
+	parents, secondaryParents, parentWarns := getTopologyParentServers(server, ds, servers, parentConfigParams, parentCG, secondaryParentCG, serverCapabilities, dsRequiredCapabilities, dsOrigins)
+	warnings = append(warnings, parentWarns...)
+
 	parentStrs := []string{}
 	secondaryParentStrs := []string{}
+	for _, sv := range parents {
+		parentStr, err := serverParentStr(&sv.Server, sv.Params)
+		if err != nil {
+			return nil, nil, warnings, errors.New("getting server parent string: " + err.Error())
+		}
+		if parentStr != "" { // will be empty if server is not_a_parent (possibly other reasons)
+			parentStrs = append(parentStrs, parentStr)
+		}
+	}
+	for _, sv := range secondaryParents {
+		parentStr, err := serverParentStr(&sv.Server, sv.Params)
+		if err != nil {
+			return nil, nil, warnings, errors.New("getting server parent string: " + err.Error())
+		}
+		secondaryParentStrs = append(secondaryParentStrs, parentStr)
+	}
+
+	return parentStrs, secondaryParentStrs, warnings, nil
+}
+
+// getTopologyParentServers returns the servers of the given primary and secondary parent CacheGroups which may be parents of the given server for the given Topology DS, sorted by rank, with their parent Parameters, and any warnings.
+// The secondary parent CacheGroup may be empty, if the server's Topology node has no secondary parent.
+func getTopologyParentServers(
+	server *Server,
+	ds *DeliveryService,
+	servers []Server,
+	parentConfigParams []parameterWithProfilesMap, // all params with configFile parent.config
+	parentCG string,
+	secondaryParentCG string,
+	serverCapabilities map[int]map[ServerCapability]struct{},
+	dsRequiredCapabilities map[int]map[ServerCapability]struct{},
+	dsOrigins map[ServerID]struct{}, // for Topology DSes, MSO still needs DeliveryServiceServer assignments.
+) ([]serverWithParams, []serverWithParams, []string) {
+	warnings := []string{}
+	parents := []serverWithParams{}
+	secondaryParents := []serverWithParams{}
 
 	serversWithParams := []serverWithParams{}
 	for _, sv := range servers {
@@ -1070,24 +1109,14 @@ func getTopologyParents(
 			continue
 		}
 		if *sv.Cachegroup == parentCG {
-			parentStr, err := serverParentStr(&sv.Server, sv.Params)
-			if err != nil {
-				return nil, nil, warnings, errors.New("getting server parent string: " + err.Error())
-			}
-			if parentStr != "" { // will be empty if server is not_a_parent (possibly other reasons)
-				parentStrs = append(parentStrs, parentStr)
-			}
+			parents = append(parents, sv)
 		}
 		if *sv.Cachegroup == secondaryParentCG {
-			parentStr, err := serverParentStr(&sv.Server, sv.Params)
-			if err != nil {
-				return nil, nil, warnings, errors.New("getting server parent string: " + err.Error())
-			}
-			secondaryParentStrs = append(secondaryParentStrs, parentStr)
+			secondaryParents = append(secondaryParents, sv)
 		}
 	}
 
-	return parentStrs, secondaryParentStrs, warnings, nil
+	return parents, secondaryParents, warnings
 }
 
 // getOriginURI returns the URL, any warnings, and any error.
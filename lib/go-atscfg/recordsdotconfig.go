@@ -130,17 +130,12 @@ func addRecordsDotConfigViaStr(txt string) (string, []string) {
 	responseViaStr := `proxy.config.http.response_via_str`
 	responseServerStr := `proxy.config.http.response_server_str`
 
-	cmd := "yum info installed trafficserver | grep Release"
-	yumOutput, err := exec.Command("sh", "-c", cmd).Output()
-
+	releaseVer, err := getTrafficServerRelease()
 	if err != nil {
 		warnings = append(warnings, "could not read trafficserver release information from yum! Not setting via strings")
 		return txt, warnings
 	}
 
-	releaseVerSlice := strings.Split(string(yumOutput), " ")
-	releaseVer := releaseVerSlice[len(releaseVerSlice)-1]
-
 	if strings.Contains(txt, requestViaStr) {
 		warnings = append(warnings, "records.config had a proxy.config.http.request_via_str Parameter! Using Parameter, not setting request via string")
 	} else {
@@ -165,6 +160,17 @@ func addRecordsDotConfigViaStr(txt string) (string, []string) {
 	return txt, warnings
 }
 
+// getTrafficServerRelease returns the Release of the installed trafficserver package, and any error.
+func getTrafficServerRelease() (string, error) {
+	cmd := "yum info installed trafficserver | grep Release"
+	yumOutput, err := exec.Command("sh", "-c", cmd).Output()
+	if err != nil {
+		return "", err
+	}
+	releaseVerSlice := strings.Split(string(yumOutput), " ")
+	return releaseVerSlice[len(releaseVerSlice)-1], nil
+}
+
 func addRecordsDotConfigDNSLocal(txt string, server *Server) (string, []string) {
 	warnings := []string{}
 
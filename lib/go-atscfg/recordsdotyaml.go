@@ -0,0 +1,335 @@
+package atscfg
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+)
+
+const RecordsYAMLFileName = "records.yaml"
+const ContentTypeRecordsDotYAML = ContentTypeYAML
+const LineCommentRecordsDotYAML = LineCommentYAML
+
+// RecordsDotYAMLMinATSMajorVersion is the first major version of ATS which uses records.yaml instead of records.config.
+const RecordsDotYAMLMinATSMajorVersion = 10
+
+// RecordsConfigPrefix is the prefix of records which records.yaml omits, because the records.yaml root node represents it.
+const RecordsConfigPrefix = "proxy.config."
+
+// RecordsLocalPrefix is the prefix of local records, which records.yaml nests under 'local'.
+const RecordsLocalPrefix = "proxy.local."
+
+// These are the records.config record types, which may prefix records.config and records.yaml Parameter values.
+const (
+	RecordsTypeInt     = "INT"
+	RecordsTypeFloat   = "FLOAT"
+	RecordsTypeString  = "STRING"
+	RecordsTypeCounter = "COUNTER"
+)
+
+// RecordsYAMLOpts contains settings to configure records.yaml generation options.
+type RecordsYAMLOpts struct {
+	// ReleaseViaStr is whether or not we replace the via and server strings in ATS
+	// responses to be the Release value from the rpm package. See RecordsConfigOpts.ReleaseViaStr.
+	ReleaseViaStr bool
+
+	// DNSLocalBindServiceAddr is whether to set the server's service addresses
+	// as the proxy.config.dns.local_ipv* settings.
+	DNSLocalBindServiceAddr bool
+
+	// HdrComment is the header comment to include at the beginning of the file.
+	// This should be the text desired, without comment syntax (like # or //). The file's comment syntax will be added.
+	// To omit the header comment, pass the empty string.
+	HdrComment string
+}
+
+// MakeRecordsDotYAML creates the records.yaml config file used by ATS 10 and later.
+//
+// Records come from Parameters with the records.yaml ConfigFile, whose Name is the record name, e.g. proxy.config.http.cache.http, and whose Value may be prefixed with a records.config type, e.g. INT 1. Values without a type are written as numbers if they are numeric, and strings otherwise.
+//
+// Parameters with the legacy records.config ConfigFile, e.g. Name CONFIG proxy.config.http.cache.http and Value INT 1, are converted, so existing Profiles work with ATS 10 unchanged. If a record has both, the records.yaml Parameter is used.
+func MakeRecordsDotYAML(
+	server *Server,
+	serverParams []tc.Parameter,
+	opt RecordsYAMLOpts,
+) (Cfg, error) {
+	warnings := []string{}
+	if server.Profile == nil {
+		return Cfg{}, makeErr(warnings, "server profile missing")
+	}
+
+	records := map[string]recordsYAMLValue{} // map[recordName]value
+
+	legacyParams, paramWarns := paramsToMap(filterParams(serverParams, RecordsFileName, "", "", "location"))
+	warnings = append(warnings, paramWarns...)
+	for name, val := range legacyParams {
+		recordName, recordVal, err := parseRecordsParam(trimParamUnderscoreNumSuffix(name), val)
+		if err != nil {
+			warnings = append(warnings, "records.config Parameter '"+name+"' could not be converted to records.yaml, skipping: "+err.Error())
+			continue
+		}
+		records[recordName] = recordVal
+	}
+
+	yamlParams, paramWarns := paramsToMap(filterParams(serverParams, RecordsYAMLFileName, "", "", "location"))
+	warnings = append(warnings, paramWarns...)
+	for name, val := range yamlParams {
+		recordName, recordVal, err := parseRecordsParam(trimParamUnderscoreNumSuffix(name), val)
+		if err != nil {
+			warnings = append(warnings, "records.yaml Parameter '"+name+"' is malformed, skipping: "+err.Error())
+			continue
+		}
+		if _, ok := records[recordName]; ok {
+			warnings = append(warnings, "record '"+recordName+"' had both records.config and records.yaml Parameters, using records.yaml")
+		}
+		records[recordName] = recordVal
+	}
+
+	for name, val := range records {
+		if val.Type == RecordsTypeString && val.Val == "__HOSTNAME__" {
+			val.Val = "__FULL_HOSTNAME__"
+			records[name] = val
+		}
+	}
+
+	overrideWarns := addRecordsDotYAMLOverrides(records, server, opt)
+	warnings = append(warnings, overrideWarns...)
+
+	txt := ""
+	if opt.HdrComment != "" {
+		txt += makeHdrComment(opt.HdrComment)
+	}
+	recordsTxt, treeWarns := makeRecordsYAMLTree(records)
+	warnings = append(warnings, treeWarns...)
+	txt += recordsTxt
+
+	return Cfg{
+		Text:        txt,
+		ContentType: ContentTypeRecordsDotYAML,
+		LineComment: LineCommentRecordsDotYAML,
+		Warnings:    warnings,
+	}, nil
+}
+
+// recordsYAMLValue is the value of a record, with its records.config type, if any.
+type recordsYAMLValue struct {
+	// Type is the records.config type of the record, or the empty string if the Parameter had no type.
+	Type string
+	Val  string
+}
+
+// parseRecordsParam returns the record name and value of a records.config or records.yaml Parameter, and any error.
+//
+// The name may be prefixed with the records.config CONFIG or LOCAL keyword, and the value may be prefixed with a records.config type. So both the legacy Name 'CONFIG proxy.config.x' Value 'INT 1' and the Name 'proxy.config.x' Value '1' return the record proxy.config.x.
+func parseRecordsParam(name string, val string) (string, recordsYAMLValue, error) {
+	nameFields := strings.Fields(name)
+	switch len(nameFields) {
+	case 1:
+		name = nameFields[0]
+	case 2:
+		if nameFields[0] != "CONFIG" && nameFields[0] != "LOCAL" {
+			return "", recordsYAMLValue{}, errors.New("name '" + name + "' has unknown keyword '" + nameFields[0] + "', must be CONFIG or LOCAL")
+		}
+		name = nameFields[1]
+	default:
+		return "", recordsYAMLValue{}, errors.New("name '" + name + "' must be a record name, optionally prefixed with CONFIG or LOCAL")
+	}
+	if !strings.HasPrefix(name, RecordsConfigPrefix) && !strings.HasPrefix(name, RecordsLocalPrefix) {
+		return "", recordsYAMLValue{}, errors.New("name '" + name + "' must start with '" + RecordsConfigPrefix + "' or '" + RecordsLocalPrefix + "'")
+	}
+
+	val = strings.TrimSpace(val)
+	recordVal := recordsYAMLValue{Val: val}
+	if valFields := strings.SplitN(val, " ", 2); len(valFields) == 2 {
+		switch valFields[0] {
+		case RecordsTypeInt, RecordsTypeFloat, RecordsTypeString, RecordsTypeCounter:
+			recordVal.Type = valFields[0]
+			recordVal.Val = strings.TrimSpace(valFields[1])
+		}
+	}
+
+	switch recordVal.Type {
+	case RecordsTypeInt, RecordsTypeCounter:
+		if _, err := strconv.ParseInt(recordVal.Val, 0, 64); err != nil {
+			return "", recordsYAMLValue{}, errors.New("value '" + recordVal.Val + "' of type " + recordVal.Type + " is not an integer")
+		}
+	case RecordsTypeFloat:
+		if _, err := strconv.ParseFloat(recordVal.Val, 64); err != nil {
+			return "", recordsYAMLValue{}, errors.New("value '" + recordVal.Val + "' of type " + recordVal.Type + " is not a number")
+		}
+	}
+	return name, recordVal, nil
+}
+
+// recordYAMLPath returns the records.yaml path of the given record name, relative to the records root node.
+func recordYAMLPath(name string) []string {
+	if strings.HasPrefix(name, RecordsConfigPrefix) {
+		return strings.Split(strings.TrimPrefix(name, RecordsConfigPrefix), ".")
+	}
+	return append([]string{"local"}, strings.Split(strings.TrimPrefix(name, RecordsLocalPrefix), ".")...)
+}
+
+// yamlText returns the records.yaml text of the value.
+func (v recordsYAMLValue) yamlText() string {
+	switch v.Type {
+	case RecordsTypeInt, RecordsTypeCounter:
+		return v.Val
+	case RecordsTypeFloat:
+		if !strings.ContainsAny(v.Val, ".eE") {
+			return v.Val + ".0" // YAML would otherwise parse it as an integer
+		}
+		return v.Val
+	case RecordsTypeString:
+		return strconv.Quote(v.Val)
+	}
+	if _, err := strconv.ParseInt(v.Val, 10, 64); err == nil {
+		return v.Val
+	}
+	if _, err := strconv.ParseFloat(v.Val, 64); err == nil && strings.ContainsAny(v.Val, ".eE") {
+		return v.Val
+	}
+	return strconv.Quote(v.Val)
+}
+
+// recordsYAMLNode is a node of the records.yaml tree. Exactly one of Children and Val is set.
+type recordsYAMLNode struct {
+	Children map[string]*recordsYAMLNode
+	Val      *recordsYAMLValue
+}
+
+// makeRecordsYAMLTree returns the records.yaml text of the given records, nested by their names, and any warnings.
+func makeRecordsYAMLTree(records map[string]recordsYAMLValue) (string, []string) {
+	warnings := []string{}
+
+	names := []string{}
+	for name := range records {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	root := &recordsYAMLNode{Children: map[string]*recordsYAMLNode{}}
+	for _, name := range names {
+		val := records[name]
+		path := recordYAMLPath(name)
+		node := root
+		conflict := false
+		for _, key := range path[:len(path)-1] {
+			child, ok := node.Children[key]
+			if !ok {
+				child = &recordsYAMLNode{Children: map[string]*recordsYAMLNode{}}
+				node.Children[key] = child
+			} else if child.Val != nil {
+				conflict = true
+				break
+			}
+			node = child
+		}
+		leafKey := path[len(path)-1]
+		if existing, ok := node.Children[leafKey]; conflict || (ok && existing.Val == nil) {
+			warnings = append(warnings, "record '"+name+"' conflicts with another record which is both a value and a parent of values in records.yaml, skipping!")
+			continue
+		}
+		node.Children[leafKey] = &recordsYAMLNode{Val: &val}
+	}
+
+	if len(root.Children) == 0 {
+		return "records: {}\n", warnings
+	}
+	return "records:\n" + root.yamlText("  "), warnings
+}
+
+// yamlText returns the YAML text of the node's children, with the given indentation.
+func (n *recordsYAMLNode) yamlText(indent string) string {
+	keys := []string{}
+	for key := range n.Children {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	txt := ""
+	for _, key := range keys {
+		child := n.Children[key]
+		if child.Val != nil {
+			txt += indent + key + ": " + child.Val.yamlText() + "\n"
+			continue
+		}
+		txt += indent + key + ":\n" + child.yamlText(indent+"  ")
+	}
+	return txt
+}
+
+// addRecordsDotYAMLOverrides adds the records which are set from server data rather than Parameters, unless a Parameter already set them, and returns any warnings.
+// These are the same as the records.config overrides; see addRecordsDotConfigOverrides.
+func addRecordsDotYAMLOverrides(records map[string]recordsYAMLValue, server *Server, opt RecordsYAMLOpts) []string {
+	warnings := []string{}
+
+	const outgoingIP = RecordsLocalPrefix + `outgoing_ip_to_bind`
+	v4, v6 := getServiceAddresses(server)
+	if _, ok := records[outgoingIP]; ok {
+		warnings = append(warnings, "records.yaml had a "+outgoingIP+" Parameter! Using Parameter, not setting Outgoing IP from Server")
+	} else if v4 == nil {
+		warnings = append(warnings, "server had no IPv4 service address, cannot set "+outgoingIP+"!")
+	} else {
+		ips := v4.String()
+		if v6 != nil {
+			ips += ` [` + v6.String() + `]`
+		}
+		records[outgoingIP] = recordsYAMLValue{Type: RecordsTypeString, Val: ips}
+	}
+
+	if opt.ReleaseViaStr {
+		releaseVer, err := getTrafficServerRelease()
+		if err != nil {
+			warnings = append(warnings, "could not read trafficserver release information from yum! Not setting via strings")
+		} else {
+			for _, name := range []string{
+				RecordsConfigPrefix + `http.request_via_str`,
+				RecordsConfigPrefix + `http.response_via_str`,
+				RecordsConfigPrefix + `http.response_server_str`,
+			} {
+				if _, ok := records[name]; ok {
+					warnings = append(warnings, "records.yaml had a "+name+" Parameter! Using Parameter, not setting via string")
+					continue
+				}
+				records[name] = recordsYAMLValue{Type: RecordsTypeString, Val: strings.TrimSpace(releaseVer)}
+			}
+		}
+	}
+
+	if opt.DNSLocalBindServiceAddr {
+		if v4 == nil {
+			warnings = append(warnings, "server had no IPv4 Service Address, not setting records.yaml dns v4 local bind addr!")
+		} else {
+			records[RecordsConfigPrefix+`dns.local_ipv4`] = recordsYAMLValue{Type: RecordsTypeString, Val: v4.String()}
+		}
+		if v6 == nil {
+			warnings = append(warnings, "server had no IPv6 Service Address, not setting records.yaml dns v6 local bind addr!")
+		} else {
+			records[RecordsConfigPrefix+`dns.local_ipv6`] = recordsYAMLValue{Type: RecordsTypeString, Val: `[` + v6.String() + `]`}
+		}
+	}
+
+	return warnings
+}
@@ -0,0 +1,148 @@
+package atscfg
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/apache/trafficcontrol/lib/go-util"
+)
+
+func TestMakeRecordsDotYAML(t *testing.T) {
+	hdr := "myHeaderComment"
+
+	paramData := makeParamsFromMap("serverProfile", RecordsFileName, map[string]string{
+		"CONFIG proxy.config.accept_threads":         "INT 1",
+		"CONFIG proxy.config.http.cache.http":        "INT 0",
+		"CONFIG proxy.config.http.server_ports":      "STRING 80 80:ipv6",
+		"CONFIG proxy.config.cache.ram_cache.size":   "INT 34359738368",
+		"CONFIG proxy.config.http.slow.log.thresh":   "FLOAT 2",
+		"CONFIG proxy.config.proxy_name":             "STRING __HOSTNAME__",
+		"CONFIG proxy.config.malformed":              "INT notanint",
+		"location":                                   "/opt/trafficserver/etc/trafficserver",
+		"CONFIG proxy.config.diags.debug.tags__1":    "STRING http|dns",
+		"CONFIG proxy.config.diags.debug.enabled__2": "INT 1",
+	})
+	paramData = append(paramData, makeParamsFromMap("serverProfile", RecordsYAMLFileName, map[string]string{
+		"proxy.config.http.cache.http":             "1",
+		"proxy.config.http.keep_alive_no_activity": "120",
+		"proxy.config.log.hostname":                "my host",
+	})...)
+
+	server := makeTestRemapServer()
+	server.Interfaces = nil
+	ipStr := "192.163.2.99"
+	setIP(server, ipStr+"/30")
+	ip6Str := "2001:db8::9"
+	setIP6(server, ip6Str+"/48")
+	server.Profile = util.StrPtr("serverProfile")
+
+	opt := RecordsYAMLOpts{HdrComment: hdr, DNSLocalBindServiceAddr: true}
+	cfg, err := MakeRecordsDotYAML(server, paramData, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txt := cfg.Text
+
+	testComment(t, txt, hdr)
+
+	expecteds := []string{
+		"records:\n  accept_threads: 1\n  cache:\n    ram_cache:\n      size: 34359738368\n",
+		"\n  http:\n    cache:\n      http: 1\n",
+		"\n    keep_alive_no_activity: 120\n",
+		"\n    server_ports: \"80 80:ipv6\"\n",
+		"\n    slow:\n      log:\n        thresh: 2.0\n",
+		"\n  proxy_name: \"__FULL_HOSTNAME__\"\n",
+		"\n  log:\n    hostname: \"my host\"\n",
+		"\n  diags:\n    debug:\n      enabled: 1\n      tags: \"http|dns\"\n",
+		"\n  local:\n    outgoing_ip_to_bind: \"" + ipStr + " [" + ip6Str + "]\"\n",
+		"\n  dns:\n    local_ipv4: \"" + ipStr + "\"\n    local_ipv6: \"[" + ip6Str + "]\"\n",
+	}
+	for _, expected := range expecteds {
+		if !strings.Contains(txt, expected) {
+			t.Errorf("expected records.yaml to contain '%v', actual: '%v'", expected, txt)
+		}
+	}
+	if strings.Contains(txt, "malformed") {
+		t.Errorf("expected malformed typed Parameter to be omitted, actual: '%v'", txt)
+	}
+	if strings.Contains(txt, "proxy.config") || strings.Contains(txt, "CONFIG") || strings.Contains(txt, "location") {
+		t.Errorf("expected records.config names to be converted, actual: '%v'", txt)
+	}
+}
+
+func TestMakeRecordsDotYAMLConflict(t *testing.T) {
+	paramData := makeParamsFromMap("serverProfile", RecordsYAMLFileName, map[string]string{
+		"proxy.config.http.cache":         "1",
+		"proxy.config.http.cache.http":    "1",
+		"proxy.local.outgoing_ip_to_bind": "192.0.2.1",
+	})
+
+	server := makeTestRemapServer()
+	server.Profile = util.StrPtr("serverProfile")
+
+	cfg, err := MakeRecordsDotYAML(server, paramData, RecordsYAMLOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	txt := cfg.Text
+
+	if !strings.Contains(txt, "records:\n  http:\n    cache: 1\n  local:\n    outgoing_ip_to_bind: \"192.0.2.1\"\n") {
+		t.Errorf("expected the first of conflicting records and the outgoing IP Parameter, actual: '%v'", txt)
+	}
+	if len(cfg.Warnings) == 0 {
+		t.Errorf("expected warnings for conflicting records, actual none")
+	}
+}
+
+func TestParseRecordsParam(t *testing.T) {
+	type testCase struct {
+		Name         string
+		Val          string
+		ExpectedName string
+		ExpectedVal  recordsYAMLValue
+		ExpectErr    bool
+	}
+	testCases := []testCase{
+		{"CONFIG proxy.config.x", "INT 1", "proxy.config.x", recordsYAMLValue{Type: RecordsTypeInt, Val: "1"}, false},
+		{"LOCAL proxy.local.x", "STRING a b", "proxy.local.x", recordsYAMLValue{Type: RecordsTypeString, Val: "a b"}, false},
+		{"proxy.config.x", "1.5", "proxy.config.x", recordsYAMLValue{Val: "1.5"}, false},
+		{"proxy.config.x", "FLOAT abc", "", recordsYAMLValue{}, true},
+		{"FOO proxy.config.x", "1", "", recordsYAMLValue{}, true},
+		{"param0", "val0", "", recordsYAMLValue{}, true},
+	}
+	for _, tc := range testCases {
+		name, val, err := parseRecordsParam(tc.Name, tc.Val)
+		if tc.ExpectErr {
+			if err == nil {
+				t.Errorf("parseRecordsParam('%v', '%v') expected error, actual nil", tc.Name, tc.Val)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseRecordsParam('%v', '%v') expected no error, actual: %v", tc.Name, tc.Val, err)
+			continue
+		}
+		if name != tc.ExpectedName || val != tc.ExpectedVal {
+			t.Errorf("parseRecordsParam('%v', '%v') expected '%v' %+v, actual '%v' %+v", tc.Name, tc.Val, tc.ExpectedName, tc.ExpectedVal, name, val)
+		}
+	}
+}
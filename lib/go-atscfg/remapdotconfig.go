@@ -86,14 +86,15 @@ func MakeRemapDotConfig(
 	}
 
 	nameTopologies := makeTopologyNameMap(topologies)
+	usesStrategies := serverUsesStrategies(serverParams, atsMajorVersion)
 
 	hdr := makeHdrComment(hdrComment)
 	txt := ""
 	typeWarns := []string{}
 	if tc.CacheTypeFromString(server.Type) == tc.CacheTypeMid {
-		txt, typeWarns, err = getServerConfigRemapDotConfigForMid(atsMajorVersion, dsProfilesCacheKeyConfigParams, dses, dsRegexes, hdr, server, nameTopologies, cacheGroups, serverCapabilities, dsRequiredCapabilities)
+		txt, typeWarns, err = getServerConfigRemapDotConfigForMid(atsMajorVersion, dsProfilesCacheKeyConfigParams, dses, dsRegexes, hdr, server, nameTopologies, cacheGroups, serverCapabilities, dsRequiredCapabilities, usesStrategies)
 	} else {
-		txt, typeWarns, err = getServerConfigRemapDotConfigForEdge(cacheURLConfigParams, dsProfilesCacheKeyConfigParams, serverPackageParamData, dses, dsRegexes, atsMajorVersion, hdr, server, nameTopologies, cacheGroups, serverCapabilities, dsRequiredCapabilities, cdnDomain)
+		txt, typeWarns, err = getServerConfigRemapDotConfigForEdge(cacheURLConfigParams, dsProfilesCacheKeyConfigParams, serverPackageParamData, dses, dsRegexes, atsMajorVersion, hdr, server, nameTopologies, cacheGroups, serverCapabilities, dsRequiredCapabilities, cdnDomain, usesStrategies)
 	}
 	warnings = append(warnings, typeWarns...)
 	if err != nil {
@@ -120,6 +121,7 @@ func getServerConfigRemapDotConfigForMid(
 	cacheGroups map[tc.CacheGroupName]tc.CacheGroupNullable,
 	serverCapabilities map[int]map[ServerCapability]struct{},
 	dsRequiredCapabilities map[int]map[ServerCapability]struct{},
+	usesStrategies bool,
 ) (string, []string, error) {
 	warnings := []string{}
 	midRemaps := map[string]string{}
@@ -164,6 +166,13 @@ func getServerConfigRemapDotConfigForMid(
 				return "", warnings, err
 			}
 			midRemap += topoTxt
+			if usesStrategies {
+				strategyTxt, err := makeDSTopologyStrategyTxt(ds, tc.CacheGroupName(*server.Cachegroup), topology, cacheGroups)
+				if err != nil {
+					return "", warnings, err
+				}
+				midRemap += strategyTxt
+			}
 		} else if (ds.MidHeaderRewrite != nil && *ds.MidHeaderRewrite != "") || (ds.MaxOriginConnections != nil && *ds.MaxOriginConnections > 0) || (ds.ServiceCategory != nil && *ds.ServiceCategory != "") {
 			midRemap += ` @plugin=header_rewrite.so @pparam=` + midHeaderRewriteConfigFileName(*ds.XMLID)
 		}
@@ -228,6 +237,7 @@ func getServerConfigRemapDotConfigForEdge(
 	serverCapabilities map[int]map[ServerCapability]struct{},
 	dsRequiredCapabilities map[int]map[ServerCapability]struct{},
 	cdnDomain string,
+	usesStrategies bool,
 ) (string, []string, error) {
 	warnings := []string{}
 	textLines := []string{}
@@ -277,7 +287,7 @@ func getServerConfigRemapDotConfigForEdge(
 					profilecacheKeyConfigParams = profilesCacheKeyConfigParams[*ds.ProfileID]
 				}
 				remapWarns := []string{}
-				remapText, remapWarns, err = buildEdgeRemapLine(cacheURLConfigParams, atsMajorVersion, server, serverPackageParamData, remapText, ds, line.From, line.To, profilecacheKeyConfigParams, cacheGroups, nameTopologies)
+				remapText, remapWarns, err = buildEdgeRemapLine(cacheURLConfigParams, atsMajorVersion, server, serverPackageParamData, remapText, ds, line.From, line.To, profilecacheKeyConfigParams, cacheGroups, nameTopologies, usesStrategies)
 				warnings = append(warnings, remapWarns...)
 				if err != nil {
 					return "", warnings, err
@@ -312,6 +322,7 @@ func buildEdgeRemapLine(
 	cacheKeyConfigParams map[string]string,
 	cacheGroups map[tc.CacheGroupName]tc.CacheGroupNullable,
 	nameTopologies map[TopologyName]tc.Topology,
+	usesStrategies bool,
 ) (string, []string, error) {
 	warnings := []string{}
 	// ds = 'remap' in perl
@@ -329,6 +340,13 @@ func buildEdgeRemapLine(
 			return "", warnings, err
 		}
 		text += topoTxt
+		if usesStrategies {
+			strategyTxt, err := makeDSTopologyStrategyTxt(ds, tc.CacheGroupName(*server.Cachegroup), nameTopologies[TopologyName(*ds.Topology)], cacheGroups)
+			if err != nil {
+				return "", warnings, err
+			}
+			text += strategyTxt
+		}
 	} else if (ds.EdgeHeaderRewrite != nil && *ds.EdgeHeaderRewrite != "") || (ds.ServiceCategory != nil && *ds.ServiceCategory != "") || (ds.MaxOriginConnections != nil && *ds.MaxOriginConnections != 0) {
 		text += ` @plugin=header_rewrite.so @pparam=` + edgeHeaderRewriteConfigFileName(*ds.XMLID)
 	}
@@ -437,6 +455,19 @@ func makeDSTopologyHeaderRewriteTxt(ds DeliveryService, cg tc.CacheGroupName, to
 	return txt, nil
 }
 
+// makeDSTopologyStrategyTxt returns the remap line text referencing the DS's strategies.yaml strategy, for servers which use strategies.yaml, and any error.
+// May be empty, if the server has no parents for the DS in the topology, and so has no strategy.
+func makeDSTopologyStrategyTxt(ds DeliveryService, cg tc.CacheGroupName, topology tc.Topology, cacheGroups map[tc.CacheGroupName]tc.CacheGroupNullable) (string, error) {
+	placement, err := getTopologyPlacement(cg, topology, cacheGroups, &ds)
+	if err != nil {
+		return "", errors.New("getting topology placement: " + err.Error())
+	}
+	if !dsUsesStrategy(&ds, placement) {
+		return "", nil
+	}
+	return ` @strategy=` + StrategyName(*ds.XMLID), nil
+}
+
 type remapLine struct {
 	From string
 	To   string
@@ -8299,3 +8299,116 @@ func TestMakeRemapDotConfigMidNoNoCacheRemapLine(t *testing.T) {
 		t.Errorf("expected remap line for HTTP_NO_CACHE to not exist on Mid server, regardless of Mid Header Rewrite, actual '%v'", txt)
 	}
 }
+
+func TestMakeRemapDotConfigEdgeStrategies(t *testing.T) {
+	hdr := "myHeaderComment"
+
+	server := makeTestRemapServer()
+	server.Type = "EDGE"
+	server.Cachegroup = util.StrPtr("edgeCG")
+
+	ds := DeliveryService{}
+	ds.ID = util.IntPtr(48)
+	dsType := tc.DSType("HTTP_LIVE")
+	ds.Type = &dsType
+	ds.OrgServerFQDN = util.StrPtr("origin.example.test")
+	ds.XMLID = util.StrPtr("mydsname")
+	ds.DSCP = util.IntPtr(0)
+	ds.RoutingName = util.StrPtr("myroutingname")
+	ds.Protocol = util.IntPtr(0)
+	ds.Active = util.BoolPtr(true)
+	ds.Topology = util.StrPtr("t0")
+	dses := []DeliveryService{ds}
+
+	dss := []DeliveryServiceServer{}
+
+	dsRegexes := []tc.DeliveryServiceRegexes{
+		tc.DeliveryServiceRegexes{
+			DSName: *ds.XMLID,
+			Regexes: []tc.DeliveryServiceRegex{
+				tc.DeliveryServiceRegex{
+					Type:      string(tc.DSMatchTypeHostRegex),
+					SetNumber: 0,
+					Pattern:   "myregexpattern",
+				},
+			},
+		},
+	}
+
+	serverParams := []tc.Parameter{
+		tc.Parameter{
+			Name:       "trafficserver",
+			ConfigFile: "package",
+			Value:      "9",
+			Profiles:   []byte(`["global"]`),
+		},
+	}
+
+	cdn := &tc.CDN{
+		DomainName: "cdndomain.example",
+		Name:       "my-cdn-name",
+	}
+
+	topologies := []tc.Topology{
+		tc.Topology{
+			Name: "t0",
+			Nodes: []tc.TopologyNode{
+				tc.TopologyNode{
+					Cachegroup: "edgeCG",
+					Parents:    []int{1},
+				},
+				tc.TopologyNode{
+					Cachegroup: "midCG",
+				},
+			},
+		},
+	}
+
+	eCG := tc.CacheGroupNullable{}
+	eCG.Name = util.StrPtr("edgeCG")
+	eCGType := tc.CacheGroupEdgeTypeName
+	eCG.Type = &eCGType
+
+	mCG := tc.CacheGroupNullable{}
+	mCG.Name = util.StrPtr("midCG")
+	mCGType := tc.CacheGroupMidTypeName
+	mCG.Type = &mCGType
+
+	cgs := []tc.CacheGroupNullable{eCG, mCG}
+	serverCapabilities := map[int]map[ServerCapability]struct{}{}
+	dsRequiredCapabilities := map[int]map[ServerCapability]struct{}{}
+
+	cfg, err := MakeRemapDotConfig(server, dses, dss, dsRegexes, serverParams, cdn, nil, topologies, cgs, serverCapabilities, dsRequiredCapabilities, hdr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(cfg.Text, "@strategy") {
+		t.Errorf("expected no strategy without strategies.yaml location parameter, actual '%v'", cfg.Text)
+	}
+
+	serverParams = append(serverParams, tc.Parameter{
+		Name:       "location",
+		ConfigFile: StrategiesDotYAMLFileName,
+		Value:      "/opt/trafficserver/etc/trafficserver",
+		Profiles:   []byte(`["MyProfile"]`),
+	})
+
+	cfg, err = MakeRemapDotConfig(server, dses, dss, dsRegexes, serverParams, cdn, nil, topologies, cgs, serverCapabilities, dsRequiredCapabilities, hdr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(cfg.Text, "@strategy="+StrategyName(*ds.XMLID)) {
+		t.Errorf("expected strategy with strategies.yaml location parameter, actual '%v'", cfg.Text)
+	}
+
+	mid := makeTestRemapServer()
+	mid.Cachegroup = util.StrPtr("midCG")
+
+	cfg, err = MakeRemapDotConfig(mid, dses, dss, dsRegexes, serverParams, cdn, nil, topologies, cgs, serverCapabilities, dsRequiredCapabilities, hdr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(cfg.Text, "@strategy") {
+		t.Errorf("expected no strategy for last tier, which goes to the origin, actual '%v'", cfg.Text)
+	}
+}
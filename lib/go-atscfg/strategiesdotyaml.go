@@ -0,0 +1,485 @@
+package atscfg
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+)
+
+const StrategiesDotYAMLFileName = "strategies.yaml"
+
+const ContentTypeStrategiesDotYAML = ContentTypeYAML
+const LineCommentStrategiesDotYAML = LineCommentYAML
+
+// StrategiesDotYAMLMinATSMajorVersion is the first major version of ATS which supports strategies.yaml.
+const StrategiesDotYAMLMinATSMajorVersion = 9
+
+// These are Delivery Service Profile Parameters, with the ConfigFile parent.config, used only by strategies.yaml.
+const StrategiesParamIgnoreSelfDetect = "strategies.ignore_self_detect"
+const StrategiesParamHealthCheck = "strategies.health_check"
+
+const StrategiesDefaultHealthCheck = "passive"
+
+// StrategiesDotYAMLOpts contains settings to configure strategies.yaml generation options.
+type StrategiesDotYAMLOpts struct {
+	// VerboseComments is whether to add informative comments to the generated file, about what was generated and why.
+	// Note this does not include the header comment, which is configured separately with HdrComment.
+	// These comments are human-readable and not guaranteed to be consistent between versions. Automating anything based on them is strongly discouraged.
+	VerboseComments bool
+
+	// HdrComment is the header comment to include at the beginning of the file.
+	// This should be the text desired, without comment syntax (like # or //). The file's comment syntax will be added.
+	// To omit the header comment, pass the empty string.
+	HdrComment string
+}
+
+// MakeStrategiesDotYAML creates the strategies.yaml ATS 9+ config file, with the next-hop selection strategy of each Topology Delivery Service for which the server isn't the last tier.
+//
+// It takes the same data as MakeParentDotConfig, and each strategy selects the same parents as the Delivery Service's parent.config line. When strategies.yaml is enabled for a server, by a location Parameter on its Profile, remap.config references these strategies instead of using parent.config. Delivery Services without Topologies always use parent.config.
+func MakeStrategiesDotYAML(
+	dses []DeliveryService,
+	server *Server,
+	servers []Server,
+	topologies []tc.Topology,
+	tcServerParams []tc.Parameter,
+	tcParentConfigParams []tc.Parameter,
+	serverCapabilities map[int]map[ServerCapability]struct{},
+	dsRequiredCapabilities map[int]map[ServerCapability]struct{},
+	cacheGroupArr []tc.CacheGroupNullable,
+	dss []DeliveryServiceServer,
+	cdn *tc.CDN,
+	opt StrategiesDotYAMLOpts,
+) (Cfg, error) {
+	warnings := []string{}
+
+	if server.HostName == nil || *server.HostName == "" {
+		return Cfg{}, makeErr(warnings, "server HostName missing")
+	} else if server.ID == nil {
+		return Cfg{}, makeErr(warnings, "server ID missing")
+	} else if server.CDNName == nil || *server.CDNName == "" {
+		return Cfg{}, makeErr(warnings, "server CDNName missing")
+	} else if server.Cachegroup == nil || *server.Cachegroup == "" {
+		return Cfg{}, makeErr(warnings, "server Cachegroup missing")
+	} else if server.Profile == nil || *server.Profile == "" {
+		return Cfg{}, makeErr(warnings, "server Profile missing")
+	}
+
+	txt := ""
+	if opt.HdrComment != "" {
+		txt += makeHdrComment(opt.HdrComment)
+	}
+
+	atsMajorVer, verWarns := getATSMajorVersion(tcServerParams)
+	warnings = append(warnings, verWarns...)
+	if atsMajorVer < StrategiesDotYAMLMinATSMajorVersion {
+		warnings = append(warnings, "server '"+*server.HostName+"' is ATS "+strconv.Itoa(atsMajorVer)+", but strategies.yaml isn't supported until ATS "+strconv.Itoa(StrategiesDotYAMLMinATSMajorVersion)+"! Generating no strategies, parent.config will be used!")
+		return Cfg{
+			Text:        txt + "strategies: []\n",
+			ContentType: ContentTypeStrategiesDotYAML,
+			LineComment: LineCommentStrategiesDotYAML,
+			Warnings:    warnings,
+		}, nil
+	}
+
+	cacheGroups, err := makeCGMap(cacheGroupArr)
+	if err != nil {
+		return Cfg{}, makeErr(warnings, "making CacheGroup map: "+err.Error())
+	}
+
+	parentConfigParamsWithProfiles, err := tcParamsToParamsWithProfiles(tcParentConfigParams)
+	if err != nil {
+		warnings = append(warnings, "error getting profiles from Traffic Ops Parameters, Parameters will not be considered for generation! : "+err.Error())
+		parentConfigParamsWithProfiles = []parameterWithProfiles{}
+	}
+	parentConfigParams := parameterWithProfilesToMap(parentConfigParamsWithProfiles)
+
+	profileParentConfigParams := map[string]map[string]string{} // map[profileName][paramName]paramVal
+	for _, param := range parentConfigParamsWithProfiles {
+		for _, profile := range param.ProfileNames {
+			if _, ok := profileParentConfigParams[profile]; !ok {
+				profileParentConfigParams[profile] = map[string]string{}
+			}
+			profileParentConfigParams[profile][param.Name] = param.Value
+		}
+	}
+
+	serverParams := map[string]string{}
+	for name, val := range profileParentConfigParams[*server.Profile] {
+		if name == ParentConfigParamQStringHandling ||
+			name == ParentConfigParamAlgorithm ||
+			name == ParentConfigParamQString {
+			serverParams[name] = val
+		}
+	}
+
+	nameTopologies := makeTopologyNameMap(topologies)
+
+	dsOrigins, dsOriginWarns := makeDSOrigins(dss, dses, servers)
+	warnings = append(warnings, dsOriginWarns...)
+
+	sort.Sort(dsesSortByName(dses))
+
+	txt += "strategies:\n"
+	numStrategies := 0
+	for _, ds := range dses {
+		if ds.XMLID == nil || *ds.XMLID == "" {
+			warnings = append(warnings, "got ds with missing XMLID, skipping!")
+			continue
+		} else if ds.ID == nil {
+			warnings = append(warnings, "got ds with missing ID, skipping!")
+			continue
+		} else if ds.Type == nil {
+			warnings = append(warnings, "got ds with missing Type, skipping!")
+			continue
+		}
+		if ds.Topology == nil || *ds.Topology == "" {
+			continue // non-Topology DSes use parent.config
+		}
+		if !ds.Type.IsHTTP() && !ds.Type.IsDNS() {
+			continue // skip ANY_MAP, STEERING, etc
+		}
+		if ds.OrgServerFQDN == nil || *ds.OrgServerFQDN == "" {
+			warnings = append(warnings, "DS '"+*ds.XMLID+"' has no origin server! Skipping!")
+			continue
+		}
+		if !hasRequiredCapabilities(serverCapabilities[*server.ID], dsRequiredCapabilities[*ds.ID]) {
+			continue
+		}
+
+		dsParams, dsParamsWarnings := getParentDSParams(ds, profileParentConfigParams)
+		warnings = append(warnings, dsParamsWarnings...)
+
+		strategyTxt, strategyWarns, err := makeDSStrategy(
+			server,
+			servers,
+			&ds,
+			serverParams,
+			parentConfigParams,
+			profileParentConfigParams,
+			nameTopologies,
+			serverCapabilities,
+			dsRequiredCapabilities,
+			cacheGroups,
+			dsParams,
+			dsOrigins[DeliveryServiceID(*ds.ID)],
+			opt.VerboseComments,
+		)
+		warnings = append(warnings, strategyWarns...)
+		if err != nil {
+			// Unlike parent.config, this must fail generation if one ds is malformed, because remap.config references the strategy of every ds for which dsUsesStrategy is true, and ATS rejects remaps referencing strategies which don't exist.
+			return Cfg{}, makeErr(warnings, err.Error()) // makeDSStrategy includes error context
+		}
+		if strategyTxt != "" { // will be empty with no error if this server isn't in the Topology, or is its last tier
+			txt += strategyTxt
+			numStrategies++
+		}
+	}
+	if numStrategies == 0 {
+		txt = strings.TrimSuffix(txt, "strategies:\n") + "strategies: []\n"
+	}
+
+	return Cfg{
+		Text:        txt,
+		ContentType: ContentTypeStrategiesDotYAML,
+		LineComment: LineCommentStrategiesDotYAML,
+		Warnings:    warnings,
+	}, nil
+}
+
+// StrategyName returns the name of the strategies.yaml strategy of the given Delivery Service.
+func StrategyName(dsName string) string {
+	return "strategy-" + dsName
+}
+
+// serverUsesStrategies returns whether the server with the given Parameters uses strategies.yaml rather than parent.config for Topology Delivery Services, which it does if its Profile has a location Parameter for strategies.yaml and it's a version of ATS which supports it.
+func serverUsesStrategies(serverParams []tc.Parameter, atsMajorVer int) bool {
+	if atsMajorVer < StrategiesDotYAMLMinATSMajorVersion {
+		return false
+	}
+	for _, param := range serverParams {
+		if param.ConfigFile == StrategiesDotYAMLFileName && param.Name == "location" {
+			return true
+		}
+	}
+	return false
+}
+
+// dsUsesStrategy returns whether the given DS, with the given placement of the server in its Topology, has a strategy in the server's strategies.yaml, which is whether the server has parents for it.
+// This must be kept consistent with makeDSStrategy, so remap.config never references a strategy which doesn't exist. MakeStrategiesDotYAML fails if makeDSStrategy fails for any DS, so a strategy this doesn't know can't be generated never results in a remap.config referencing it.
+func dsUsesStrategy(ds *DeliveryService, placement TopologyPlacement) bool {
+	return ds.Topology != nil && *ds.Topology != "" &&
+		ds.Type != nil && (ds.Type.IsHTTP() || ds.Type.IsDNS()) &&
+		ds.OrgServerFQDN != nil && *ds.OrgServerFQDN != "" &&
+		placement.InTopology && !placement.IsLastTier
+}
+
+// strategyHost is a host of a strategies.yaml strategy group.
+type strategyHost struct {
+	Host   string
+	Scheme string
+	Port   int
+	Weight string
+}
+
+// makeDSStrategy returns the strategies.yaml strategy of the given Topology DS, any warnings, and any error.
+// Returns the empty string if the server isn't in the DS's Topology, or is its last tier, and so goes directly to the origin.
+func makeDSStrategy(
+	server *Server,
+	servers []Server,
+	ds *DeliveryService,
+	serverParams map[string]string,
+	parentConfigParams []parameterWithProfilesMap, // all params with configFile parent.config
+	profileParentConfigParams map[string]map[string]string, // map[profileName][paramName]paramVal
+	nameTopologies map[TopologyName]tc.Topology,
+	serverCapabilities map[int]map[ServerCapability]struct{},
+	dsRequiredCapabilities map[int]map[ServerCapability]struct{},
+	cacheGroups map[tc.CacheGroupName]tc.CacheGroupNullable,
+	dsParams parentDSParams,
+	dsOrigins map[ServerID]struct{},
+	verboseComments bool,
+) (string, []string, error) {
+	warnings := []string{}
+
+	orgURI, orgWarns, err := getOriginURI(*ds.OrgServerFQDN)
+	warnings = append(warnings, orgWarns...)
+	if err != nil {
+		return "", warnings, errors.New("DS '" + *ds.XMLID + "' has malformed origin URI: '" + *ds.OrgServerFQDN + "': " + err.Error())
+	}
+
+	topology := nameTopologies[TopologyName(*ds.Topology)]
+	if topology.Name == "" {
+		return "", warnings, errors.New("DS " + *ds.XMLID + " topology '" + *ds.Topology + "' not found in Topologies!")
+	}
+
+	placement, err := getTopologyPlacement(tc.CacheGroupName(*server.Cachegroup), topology, cacheGroups, ds)
+	if err != nil {
+		return "", warnings, errors.New("getting topology placement: " + err.Error())
+	}
+	if !dsUsesStrategy(ds, placement) {
+		return "", warnings, nil // server isn't in the topology, or goes direct to the origin: no error
+	}
+
+	svNode := tc.TopologyNode{}
+	for _, node := range topology.Nodes {
+		if node.Cachegroup == *server.Cachegroup {
+			svNode = node
+			break
+		}
+	}
+	if len(topology.Nodes) <= svNode.Parents[0] {
+		return "", warnings, errors.New("DS " + *ds.XMLID + " topology '" + *ds.Topology + "' node parent " + strconv.Itoa(svNode.Parents[0]) + " greater than number of topology nodes " + strconv.Itoa(len(topology.Nodes)) + ". Cannot create strategy!")
+	}
+	parentCG := topology.Nodes[svNode.Parents[0]].Cachegroup
+	secondaryParentCG := ""
+	if len(svNode.Parents) > 1 && len(topology.Nodes) > svNode.Parents[1] {
+		secondaryParentCG = topology.Nodes[svNode.Parents[1]].Cachegroup
+	}
+
+	// Parents which are caches are always HTTP. Parents which are origins, in the last cache tier, use the origin's scheme.
+	scheme := "http"
+	if placement.IsLastCacheTier {
+		scheme = orgURI.Scheme
+	}
+
+	parents, secondaryParents, parentWarns := getTopologyParentServers(server, ds, servers, parentConfigParams, parentCG, secondaryParentCG, serverCapabilities, dsRequiredCapabilities, dsOrigins)
+	warnings = append(warnings, parentWarns...)
+
+	groups := [][]strategyHost{}
+	for _, parentServers := range [][]serverWithParams{parents, secondaryParents} {
+		group := []strategyHost{}
+		for _, sv := range parentServers {
+			host, hostWarns, err := makeStrategyHost(sv, scheme)
+			warnings = append(warnings, hostWarns...)
+			if err != nil {
+				return "", warnings, errors.New("DS '" + *ds.XMLID + "' getting strategy host: " + err.Error())
+			}
+			if host.Host != "" { // will be empty if server is not_a_parent
+				group = append(group, host)
+			}
+		}
+		if len(group) > 0 {
+			groups = append(groups, group)
+		}
+	}
+
+	goDirect := getTopologyGoDirect(ds, placement.IsLastTier) == "true"
+	parentIsProxy := !placement.IsLastCacheTier
+	if len(groups) == 0 {
+		// Emit the strategy anyway, because remap.config references it: send requests to the origin.
+		warnings = append(warnings, "DS '"+*ds.XMLID+"' topology '"+*ds.Topology+"' has no parents for this server! Sending to the origin. (Does your Topology have a CacheGroup with no servers in it?)")
+		port, _ := strconv.Atoi(orgURI.Port())
+		groups = append(groups, []strategyHost{{Host: orgURI.Hostname(), Scheme: orgURI.Scheme, Port: port, Weight: "1.0"}})
+		scheme = orgURI.Scheme
+		goDirect = true
+		parentIsProxy = false
+	}
+
+	policy, policyWarns := getStrategyPolicy(*ds.XMLID, getTopologyRoundRobin(ds, serverParams, placement.IsLastCacheTier, dsParams.Algorithm))
+	warnings = append(warnings, policyWarns...)
+
+	hashKey := "path"
+	if getTopologyQueryString(ds, serverParams, placement.IsLastCacheTier, dsParams.Algorithm, dsParams.QueryStringHandling) == "consider" {
+		hashKey = "path+query"
+	}
+
+	dsStrategyParams := map[string]string{}
+	if ds.ProfileName != nil {
+		dsStrategyParams = profileParentConfigParams[*ds.ProfileName]
+	}
+	ignoreSelfDetect := false
+	if v, ok := dsStrategyParams[StrategiesParamIgnoreSelfDetect]; ok {
+		if ignoreSelfDetect, err = strconv.ParseBool(strings.TrimSpace(v)); err != nil {
+			warnings = append(warnings, "DS '"+*ds.XMLID+"' had malformed "+StrategiesParamIgnoreSelfDetect+" parameter '"+v+"', not using!")
+		}
+	}
+	healthChecks, healthCheckWarns := getStrategyHealthChecks(*ds.XMLID, dsStrategyParams[StrategiesParamHealthCheck])
+	warnings = append(warnings, healthCheckWarns...)
+
+	txt := ""
+	if verboseComments {
+		txt += LineCommentYAML + " ds '" + *ds.XMLID + "' topology '" + *ds.Topology + "'\n"
+	}
+	txt += "  - strategy: '" + StrategyName(*ds.XMLID) + "'\n"
+	txt += "    policy: " + policy + "\n"
+	txt += "    hash_key: " + hashKey + "\n"
+	txt += "    go_direct: " + strconv.FormatBool(goDirect) + "\n"
+	txt += "    parent_is_proxy: " + strconv.FormatBool(parentIsProxy) + "\n"
+	txt += "    ignore_self_detect: " + strconv.FormatBool(ignoreSelfDetect) + "\n"
+	txt += "    scheme: " + scheme + "\n"
+	txt += "    groups:\n"
+	for _, group := range groups {
+		for i, host := range group {
+			prefix := "        "
+			if i == 0 {
+				prefix = "      - "
+			}
+			txt += prefix + "- host: '" + host.Host + "'\n"
+			txt += "          protocol:\n"
+			txt += "            - scheme: " + host.Scheme + "\n"
+			txt += "              port: " + strconv.Itoa(host.Port) + "\n"
+			txt += "          weight: " + host.Weight + "\n"
+		}
+	}
+	txt += "    failover:\n"
+	if len(groups) > 1 {
+		ringMode := "alternate_ring"
+		if dsParams.TryAllPrimariesBeforeSecondary {
+			ringMode = "exhaust_ring"
+		}
+		txt += "      ring_mode: " + ringMode + "\n"
+	}
+	txt += getStrategyRetryStr(placement.IsLastCacheTier, dsParams.ParentRetry, dsParams.UnavailableServerRetryResponses, dsParams.MaxSimpleRetries, dsParams.MaxUnavailableServerRetries)
+	txt += "      health_check: [" + strings.Join(healthChecks, ", ") + "]\n"
+
+	return txt, warnings, nil
+}
+
+// makeStrategyHost returns the strategy host of the given parent server, any warnings, and any error.
+// Returns a host with an empty Host if the server is not_a_parent.
+func makeStrategyHost(sv serverWithParams, scheme string) (strategyHost, []string, error) {
+	warnings := []string{}
+	if sv.Params.NotAParent {
+		return strategyHost{}, warnings, nil
+	}
+	host := strategyHost{Scheme: scheme, Port: sv.Params.Port, Weight: sv.Params.Weight}
+	if sv.Params.UseIP {
+		ip := getServerIPAddress(&sv.Server)
+		if ip == nil {
+			return strategyHost{}, warnings, errors.New("server params Use IP, but has no valid IPv4 Service Address")
+		}
+		host.Host = ip.String()
+	} else {
+		host.Host = *sv.HostName + "." + *sv.DomainName
+	}
+	if _, err := strconv.ParseFloat(host.Weight, 64); err != nil {
+		warnings = append(warnings, "server '"+*sv.HostName+"' had malformed "+ParentConfigCacheParamWeight+" parameter '"+host.Weight+"', using default!")
+		host.Weight = defaultProfileCache().Weight
+	}
+	return host, warnings, nil
+}
+
+// getStrategyPolicy returns the strategies.yaml policy of the given parent.config round_robin value, and any warnings.
+func getStrategyPolicy(dsName string, roundRobin string) (string, []string) {
+	switch roundRobin {
+	case tc.AlgorithmConsistentHash:
+		return "consistent_hash", nil
+	case "true":
+		return "rr_ip", nil
+	case "strict":
+		return "rr_strict", nil
+	case "false":
+		return "first_live", nil
+	case "latched":
+		return "latched", nil
+	}
+	return "consistent_hash", []string{"DS '" + dsName + "' had unknown parent selection algorithm '" + roundRobin + "', using consistent_hash!"}
+}
+
+// getStrategyHealthChecks returns the health checks of the given comma-delimited Parameter value, and any warnings.
+// If the value is empty, the default passive health check is returned.
+func getStrategyHealthChecks(dsName string, param string) ([]string, []string) {
+	warnings := []string{}
+	healthChecks := []string{}
+	for _, check := range strings.Split(param, ",") {
+		check = strings.TrimSpace(check)
+		if check == "" {
+			continue
+		}
+		if check != "passive" && check != "active" {
+			warnings = append(warnings, "DS '"+dsName+"' had malformed "+StrategiesParamHealthCheck+" parameter value '"+check+"', must be 'passive' or 'active', not using!")
+			continue
+		}
+		healthChecks = append(healthChecks, check)
+	}
+	if len(healthChecks) == 0 {
+		healthChecks = append(healthChecks, StrategiesDefaultHealthCheck)
+	}
+	return healthChecks, warnings
+}
+
+// getStrategyRetryStr returns the failover retry directives, which are the strategies.yaml equivalents of the parent.config parent retry directives; see getParentRetryStr.
+func getStrategyRetryStr(isLastCacheTier bool, parentRetry string, unavailableServerRetryResponses string, maxSimpleRetries string, maxUnavailableServerRetries string) string {
+	if !isLastCacheTier || parentRetry == "" {
+		return ""
+	}
+	if maxSimpleRetries == "" {
+		maxSimpleRetries = ParentConfigDSParamDefaultMaxSimpleRetries
+	}
+	if maxUnavailableServerRetries == "" {
+		maxUnavailableServerRetries = ParentConfigDSParamDefaultMaxUnavailableServerRetries
+	}
+
+	txt := ""
+	if parentRetry == "simple_retry" || parentRetry == "both" {
+		txt += "      max_simple_retries: " + maxSimpleRetries + "\n"
+	}
+	if parentRetry == "unavailable_server_retry" || parentRetry == "both" {
+		txt += "      max_unavailable_retries: " + maxUnavailableServerRetries + "\n"
+		if unavailableServerRetryResponses != "" {
+			txt += "      markdown_codes: [" + strings.Trim(unavailableServerRetryResponses, `"`) + "]\n"
+		}
+	}
+	return txt
+}
@@ -0,0 +1,297 @@
+package atscfg
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/lib/go-util"
+)
+
+func TestMakeStrategiesDotYAMLTopologies(t *testing.T) {
+	opt := StrategiesDotYAMLOpts{HdrComment: "myHeaderComment"}
+
+	ds0, ds1, server, servers, topologies, cgs, dss := makeStrategiesTestData()
+	ds1.ProfileName = util.StrPtr("ds1Profile")
+	dses := []DeliveryService{*ds0, *ds1}
+
+	parentConfigParams := []tc.Parameter{
+		{
+			Name:       ParentConfigParamAlgorithm,
+			ConfigFile: "parent.config",
+			Value:      tc.AlgorithmConsistentHash,
+			Profiles:   []byte(`["serverprofile"]`),
+		},
+		{
+			Name:       ParentConfigParamQStringHandling,
+			ConfigFile: "parent.config",
+			Value:      "consider",
+			Profiles:   []byte(`["serverprofile"]`),
+		},
+		{
+			Name:       ParentConfigParamSecondaryMode,
+			ConfigFile: "parent.config",
+			Value:      "",
+			Profiles:   []byte(`["ds1Profile"]`),
+		},
+		{
+			Name:       StrategiesParamHealthCheck,
+			ConfigFile: "parent.config",
+			Value:      "passive,active",
+			Profiles:   []byte(`["ds1Profile"]`),
+		},
+	}
+
+	serverParams := []tc.Parameter{
+		{
+			Name:       "trafficserver",
+			ConfigFile: "package",
+			Value:      "9",
+			Profiles:   []byte(`["global"]`),
+		},
+	}
+
+	serverCapabilities := map[int]map[ServerCapability]struct{}{}
+	dsRequiredCapabilities := map[int]map[ServerCapability]struct{}{}
+	cdn := &tc.CDN{DomainName: "cdndomain.example", Name: "my-cdn-name"}
+
+	cfg, err := MakeStrategiesDotYAML(dses, server, servers, topologies, serverParams, parentConfigParams, serverCapabilities, dsRequiredCapabilities, cgs, dss, cdn, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txt := cfg.Text
+
+	testComment(t, txt, opt.HdrComment)
+
+	if strings.Contains(txt, "strategy-ds0") {
+		t.Errorf("expected no strategy for non-Topology DS, actual: '%v'", txt)
+	}
+	if !strings.Contains(txt, "- strategy: 'strategy-ds1'") {
+		t.Errorf("expected strategy for Topology DS, actual: '%v'", txt)
+	}
+	if !strings.Contains(txt, "policy: consistent_hash") {
+		t.Errorf("expected consistent_hash policy from parameter, actual: '%v'", txt)
+	}
+	if !strings.Contains(txt, "hash_key: path+query") {
+		t.Errorf("expected path+query hash key from qstring parameter, actual: '%v'", txt)
+	}
+	if !strings.Contains(txt, "parent_is_proxy: true") {
+		t.Errorf("expected parents to be proxies for non-last-tier parents, actual: '%v'", txt)
+	}
+	if !strings.Contains(txt, "      - - host: 'mymid.mydomain.example.net'") {
+		t.Errorf("expected primary parent group with mid, actual: '%v'", txt)
+	}
+	if !strings.Contains(txt, "      - - host: 'mymid1.mydomain.example.net'") {
+		t.Errorf("expected secondary parent group with mid1, actual: '%v'", txt)
+	}
+	if strings.Contains(txt, "host: 'ds1.example.net'") {
+		t.Errorf("expected origin not to be a host when cache parents exist, actual: '%v'", txt)
+	}
+	if !strings.Contains(txt, "ring_mode: exhaust_ring") {
+		t.Errorf("expected exhaust_ring from secondary mode parameter, actual: '%v'", txt)
+	}
+	if !strings.Contains(txt, "health_check: [passive, active]") {
+		t.Errorf("expected health checks from parameter, actual: '%v'", txt)
+	}
+}
+
+func TestMakeStrategiesDotYAMLLastTier(t *testing.T) {
+	opt := StrategiesDotYAMLOpts{HdrComment: "myHeaderComment"}
+
+	ds0, ds1, _, servers, topologies, cgs, dss := makeStrategiesTestData()
+	ds1.OrgServerFQDN = util.StrPtr("https://ds1.example.net")
+	dses := []DeliveryService{*ds0, *ds1}
+
+	serverParams := []tc.Parameter{
+		{
+			Name:       "trafficserver",
+			ConfigFile: "package",
+			Value:      "9",
+			Profiles:   []byte(`["global"]`),
+		},
+	}
+
+	mid := servers[1]
+	cdn := &tc.CDN{DomainName: "cdndomain.example", Name: "my-cdn-name"}
+
+	cfg, err := MakeStrategiesDotYAML(dses, &mid, servers, topologies, serverParams, nil, nil, nil, cgs, dss, cdn, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txt := cfg.Text
+
+	if !strings.Contains(txt, "strategies: []") {
+		t.Errorf("expected no strategies for last tier server which goes to the origin, actual: '%v'", txt)
+	}
+}
+
+func TestMakeStrategiesDotYAMLOldATS(t *testing.T) {
+	opt := StrategiesDotYAMLOpts{HdrComment: "myHeaderComment"}
+
+	ds0, ds1, server, servers, topologies, cgs, dss := makeStrategiesTestData()
+	dses := []DeliveryService{*ds0, *ds1}
+
+	serverParams := []tc.Parameter{
+		{
+			Name:       "trafficserver",
+			ConfigFile: "package",
+			Value:      "8",
+			Profiles:   []byte(`["global"]`),
+		},
+	}
+
+	cdn := &tc.CDN{DomainName: "cdndomain.example", Name: "my-cdn-name"}
+
+	cfg, err := MakeStrategiesDotYAML(dses, server, servers, topologies, serverParams, nil, nil, nil, cgs, dss, cdn, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txt := cfg.Text
+
+	if !strings.Contains(txt, "strategies: []") {
+		t.Errorf("expected no strategies for ATS 8, actual: '%v'", txt)
+	}
+	if len(cfg.Warnings) == 0 {
+		t.Errorf("expected warning for ATS 8, actual none")
+	}
+}
+
+func TestMakeStrategiesDotYAMLMalformedDS(t *testing.T) {
+	opt := StrategiesDotYAMLOpts{HdrComment: "myHeaderComment"}
+
+	ds0, ds1, server, servers, topologies, cgs, dss := makeStrategiesTestData()
+	ds1.Topology = util.StrPtr("nonexistent-topology")
+	dses := []DeliveryService{*ds0, *ds1}
+
+	serverParams := []tc.Parameter{
+		{
+			Name:       "trafficserver",
+			ConfigFile: "package",
+			Value:      "9",
+			Profiles:   []byte(`["global"]`),
+		},
+	}
+
+	cdn := &tc.CDN{DomainName: "cdndomain.example", Name: "my-cdn-name"}
+
+	// remap.config references the strategy of every Topology DS, so generating strategies.yaml without it must fail
+	if _, err := MakeStrategiesDotYAML(dses, server, servers, topologies, serverParams, nil, nil, nil, cgs, dss, cdn, opt); err == nil {
+		t.Error("expected error for DS whose strategy can't be made, actual nil")
+	}
+}
+
+func TestGetStrategyPolicy(t *testing.T) {
+	expecteds := map[string]string{
+		tc.AlgorithmConsistentHash: "consistent_hash",
+		"true":                     "rr_ip",
+		"strict":                   "rr_strict",
+		"false":                    "first_live",
+		"latched":                  "latched",
+		"nonsense":                 "consistent_hash",
+	}
+	for roundRobin, expected := range expecteds {
+		if actual, _ := getStrategyPolicy("ds0", roundRobin); actual != expected {
+			t.Errorf("getStrategyPolicy(%v) expected '%v', actual '%v'", roundRobin, expected, actual)
+		}
+	}
+}
+
+// makeStrategiesTestData returns a non-Topology DS, a Topology DS, an edge server, all servers, topologies, cachegroups, and DSSes.
+func makeStrategiesTestData() (*DeliveryService, *DeliveryService, *Server, []Server, []tc.Topology, []tc.CacheGroupNullable, []DeliveryServiceServer) {
+	ds0 := makeParentDS()
+	ds0.XMLID = util.StrPtr("ds0")
+	ds0Type := tc.DSTypeHTTP
+	ds0.Type = &ds0Type
+	ds0.OrgServerFQDN = util.StrPtr("http://ds0.example.net")
+
+	ds1 := makeParentDS()
+	ds1.ID = util.IntPtr(43)
+	ds1.Topology = util.StrPtr("t0")
+
+	server := makeTestParentServer()
+	server.Cachegroup = util.StrPtr("edgeCG")
+	server.CachegroupID = util.IntPtr(400)
+
+	mid0 := makeTestParentServer()
+	mid0.Cachegroup = util.StrPtr("midCG")
+	mid0.CachegroupID = util.IntPtr(500)
+	mid0.HostName = util.StrPtr("mymid")
+	mid0.ID = util.IntPtr(45)
+	mid0.Type = tc.MidTypePrefix
+	setIP(mid0, "192.168.2.2")
+
+	mid1 := makeTestParentServer()
+	mid1.Cachegroup = util.StrPtr("midCG2")
+	mid1.CachegroupID = util.IntPtr(501)
+	mid1.HostName = util.StrPtr("mymid1")
+	mid1.ID = util.IntPtr(46)
+	mid1.Type = tc.MidTypePrefix
+	setIP(mid1, "192.168.2.3")
+
+	servers := []Server{*server, *mid0, *mid1}
+
+	topologies := []tc.Topology{
+		{
+			Name: "t0",
+			Nodes: []tc.TopologyNode{
+				{
+					Cachegroup: "edgeCG",
+					Parents:    []int{1, 2},
+				},
+				{
+					Cachegroup: "midCG",
+				},
+				{
+					Cachegroup: "midCG2",
+				},
+			},
+		},
+	}
+
+	eCG := &tc.CacheGroupNullable{}
+	eCG.Name = server.Cachegroup
+	eCG.ID = server.CachegroupID
+	eCGType := tc.CacheGroupEdgeTypeName
+	eCG.Type = &eCGType
+
+	mCG := &tc.CacheGroupNullable{}
+	mCG.Name = mid0.Cachegroup
+	mCG.ID = mid0.CachegroupID
+	mCGType := tc.CacheGroupMidTypeName
+	mCG.Type = &mCGType
+
+	mCG2 := &tc.CacheGroupNullable{}
+	mCG2.Name = mid1.Cachegroup
+	mCG2.ID = mid1.CachegroupID
+	mCGType2 := tc.CacheGroupMidTypeName
+	mCG2.Type = &mCGType2
+
+	cgs := []tc.CacheGroupNullable{*eCG, *mCG, *mCG2}
+
+	dss := []DeliveryServiceServer{
+		{
+			Server:          *server.ID,
+			DeliveryService: *ds0.ID,
+		},
+	}
+	return ds0, ds1, server, servers, topologies, cgs, dss
+}
@@ -40,6 +40,8 @@ type IsAvailable struct {
 	IsAvailable   bool `json:"isAvailable"`
 	Ipv4Available bool `json:"ipv4Available"`
 	Ipv6Available bool `json:"ipv6Available"`
+	// Reason is why the cache was made unavailable by something other than its health, such as a Traffic Monitor maintenance window.
+	Reason string `json:"reason,omitempty"`
 }
 
 // NewCRStates creates a new CR states object, initializing pointer members.
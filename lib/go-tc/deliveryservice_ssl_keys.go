@@ -66,6 +66,105 @@ type DeliveryServiceSSLKeysV15 struct {
 	Expiration time.Time `json:"expiration,omitempty"`
 }
 
+// DeliveryServiceSSLKeysVersion is a summary of one stored version of a Delivery
+// Service's SSL keys. It describes the certificate without including any of the
+// key material, so that versions can be listed and compared.
+type DeliveryServiceSSLKeysVersion struct {
+	Version      util.JSONIntStr `json:"version"`
+	Latest       bool            `json:"latest"`
+	AuthType     string          `json:"authType,omitempty"`
+	Hostname     string          `json:"hostname,omitempty"`
+	Subject      string          `json:"subject"`
+	Issuer       string          `json:"issuer"`
+	SANs         []string        `json:"sans"`
+	SerialNumber string          `json:"serialNumber"`
+	Fingerprint  string          `json:"fingerprint"`
+	NotBefore    time.Time       `json:"notBefore"`
+	Expiration   time.Time       `json:"expiration"`
+}
+
+// DeliveryServiceSSLKeysVersionsResponse is the type of a response from Traffic
+// Ops to a request for the stored versions of a Delivery Service's SSL keys.
+type DeliveryServiceSSLKeysVersionsResponse struct {
+	Response []DeliveryServiceSSLKeysVersion `json:"response"`
+	Alerts
+}
+
+// DeliveryServiceSSLKeysVersionsDiff describes the differences between two
+// stored versions of a Delivery Service's SSL keys. Like the version summaries,
+// it never includes any key material.
+type DeliveryServiceSSLKeysVersionsDiff struct {
+	From DeliveryServiceSSLKeysVersion `json:"from"`
+	To   DeliveryServiceSSLKeysVersion `json:"to"`
+	// PrivateKeyChanged is whether the two versions have different private keys.
+	PrivateKeyChanged bool                                  `json:"privateKeyChanged"`
+	Changes           []DeliveryServiceSSLKeysVersionChange `json:"changes"`
+}
+
+// DeliveryServiceSSLKeysVersionChange is a field of the certificate summary
+// that differs between two versions of a Delivery Service's SSL keys.
+type DeliveryServiceSSLKeysVersionChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// DeliveryServiceSSLKeysVersionsDiffResponse is the type of a response from
+// Traffic Ops to a request for the differences between two stored versions of
+// a Delivery Service's SSL keys.
+type DeliveryServiceSSLKeysVersionsDiffResponse struct {
+	Response DeliveryServiceSSLKeysVersionsDiff `json:"response"`
+	Alerts
+}
+
+// DeliveryServiceSSLKeysRollbackRequest is the type of a request to roll a
+// Delivery Service's SSL keys back to a previously stored version.
+type DeliveryServiceSSLKeysRollbackRequest struct {
+	Version *util.JSONIntStr `json:"version"`
+}
+
+// Validate implements the github.com/apache/trafficcontrol/traffic_ops/traffic_ops_golang/api.ParseValidator
+// interface.
+func (r *DeliveryServiceSSLKeysRollbackRequest) Validate(tx *sql.Tx) error {
+	if r.Version == nil {
+		return errors.New("version required")
+	}
+	if *r.Version < 1 {
+		return errors.New("version must be a positive integer")
+	}
+	return nil
+}
+
+// DeliveryServiceSSLKeysRollbackResponse is the type of a response from Traffic
+// Ops to a request to roll back a Delivery Service's SSL keys. The response
+// describes the new latest version, which is a copy of the requested one.
+type DeliveryServiceSSLKeysRollbackResponse struct {
+	Response DeliveryServiceSSLKeysVersion `json:"response"`
+	Alerts
+}
+
+// SSLKeyExpirationInformation describes the expiration of the current SSL
+// certificate of a Delivery Service.
+type SSLKeyExpirationInformation struct {
+	DeliveryService string          `json:"deliveryservice"`
+	CDN             string          `json:"cdn"`
+	Version         util.JSONIntStr `json:"version"`
+	AuthType        string          `json:"authType"`
+	SANs            []string        `json:"sans"`
+	Issuer          string          `json:"issuer"`
+	Expiration      time.Time       `json:"expiration"`
+	// AutoRenewable is whether or not the certificate will be renewed by the
+	// ACME automatic renewal of Traffic Ops.
+	AutoRenewable bool `json:"autoRenewable"`
+}
+
+// SSLKeyExpirationsResponse is the type of a response from Traffic Ops to a
+// request for the expiration information of Delivery Service SSL certificates.
+type SSLKeyExpirationsResponse struct {
+	Response []SSLKeyExpirationInformation `json:"response"`
+	Alerts
+}
+
 type SSLKeyRequestFields struct {
 	BusinessUnit *string `json:"businessUnit,omitempty"`
 	City         *string `json:"city,omitempty"`
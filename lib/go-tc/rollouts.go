@@ -0,0 +1,52 @@
+package tc
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+// RolloutDefaultCanaryPercent is the percent of the targeted cache servers
+// which are canaries, if a rollout request has neither a canary percent nor
+// canary servers.
+const RolloutDefaultCanaryPercent = 10
+
+// RolloutDefaultTimeoutSeconds is the number of seconds a rollout waits for
+// its canaries to apply their updates, if the request has no timeout.
+const RolloutDefaultTimeoutSeconds = 1800
+
+// RolloutDefaultSoakSeconds is the number of seconds a rollout's canaries
+// must stay healthy after applying their updates, before updates are queued
+// on the rest of the servers, if the request has no soak period.
+const RolloutDefaultSoakSeconds = 300
+
+// RolloutRequest encodes the request data for the POST rollouts endpoint,
+// which starts a staged queue-update: updates are queued on the canary
+// servers first, and on the rest of the targeted servers only once all
+// canaries have applied their updates and have stayed healthy in Traffic
+// Monitor for the soak period.
+//
+// The targeted servers are those in the CDN, optionally limited to those in
+// a Cache Group or Topology.
+type RolloutRequest struct {
+	CDNID          int      `json:"cdnId"`
+	CacheGroupID   *int     `json:"cachegroupId"`
+	Topology       *string  `json:"topology"`
+	CanaryPercent  *int     `json:"canaryPercent"`
+	CanaryServers  []string `json:"canaryServers"`
+	TimeoutSeconds *int     `json:"timeoutSeconds"`
+	SoakSeconds    *int     `json:"soakSeconds"`
+}
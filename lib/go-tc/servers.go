@@ -1112,6 +1112,10 @@ type ServerUpdateStatus struct {
 	Status             string `json:"status"`
 	ParentPending      bool   `json:"parent_pending"`
 	ParentRevalPending bool   `json:"parent_reval_pending"`
+	// ConfigRolledBack is whether the server rolled back the last update or
+	// revalidation it applied, because its cache was unhealthy with it. It's
+	// cleared when updates or revalidations are queued or cleared again.
+	ConfigRolledBack bool `json:"config_rolled_back"`
 }
 
 // ServerUpdateStatusResponseV40 is the type of a response from the Traffic
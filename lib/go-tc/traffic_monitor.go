@@ -33,10 +33,17 @@ import (
 const (
 	// ThresholdPrefix is the prefix of all Names of Parameters used to define
 	// monitoring thresholds.
-	ThresholdPrefix   = "health.threshold."
-	StatNameKBPS      = "kbps"
-	StatNameMaxKBPS   = "maxKbps"
-	StatNameBandwidth = "bandwidth"
+	ThresholdPrefix = "health.threshold."
+	// AnomalyThresholdPrefix is the prefix of all Names of Parameters used to
+	// define anomaly thresholds on the values of stats.
+	AnomalyThresholdPrefix = "health.anomaly.threshold."
+	// AnomalyRateThresholdPrefix is the prefix of all Names of Parameters used
+	// to define anomaly thresholds on the per-second rates of change of stats,
+	// for stats which are counters.
+	AnomalyRateThresholdPrefix = "health.anomaly.rate_threshold."
+	StatNameKBPS               = "kbps"
+	StatNameMaxKBPS            = "maxKbps"
+	StatNameBandwidth          = "bandwidth"
 )
 
 // TMConfigResponse is the response to requests made to the
@@ -445,14 +452,47 @@ type TMParameters struct {
 	HealthPollingFormat     string `json:"health.polling.format"`
 	HealthPollingType       string `json:"health.polling.type"`
 	HistoryCount            int    `json:"history.count"`
-	MinFreeKbps             int64
+	// HealthProbePath is the path requested through the cache from each of its
+	// HTTP Delivery Services by Traffic Monitor's synthetic probes. If empty,
+	// the cache isn't probed.
+	HealthProbePath string `json:"health.probe.path"`
+	// HealthProbeStatus is the HTTP status code the synthetic probes must
+	// receive. If 0, 200 is expected.
+	HealthProbeStatus int `json:"health.probe.status"`
+	// HealthProbeBodySHA256 is the hex-encoded SHA-256 digest the bodies of the
+	// synthetic probes' responses must have. If empty, bodies aren't checked.
+	HealthProbeBodySHA256 string `json:"health.probe.body.sha256"`
+	MinFreeKbps           int64
 	// HealthThresholdJSONParameters contains the Parameters contained in the
 	// Thresholds field, formatted as individual string Parameters, rather than as
 	// a JSON object.
 	Thresholds map[string]HealthThreshold `json:"health_threshold,omitempty"`
+	// AnomalyThresholds are the thresholds of the Parameters whose Names begin
+	// with AnomalyThresholdPrefix or AnomalyRateThresholdPrefix, keyed by stat.
+	AnomalyThresholds map[string]AnomalyThreshold `json:"health_anomaly_threshold,omitempty"`
+	// AnomalySamples is the number of polls the baselines of AnomalyThresholds
+	// are learned from. If 0, Traffic Monitor uses its default.
+	AnomalySamples int `json:"health.anomaly.samples"`
+	// AnomalyRelearnPolls is the number of polls in a row a stat must be
+	// anomalous before its anomalous values are learned as its new baseline.
+	// If 0, Traffic Monitor uses AnomalySamples.
+	AnomalyRelearnPolls int `json:"health.anomaly.relearn_polls"`
 	HealthThresholdJSONParameters
 }
 
+// AnomalyThreshold describes how far a stat may deviate above the baseline
+// learned from its recent values before the cache server reporting it is
+// considered unhealthy.
+type AnomalyThreshold struct {
+	// Deviations is the number of standard deviations above the mean of its
+	// baseline that the stat may be.
+	Deviations float64
+	// Rate is whether the stat's per-second rate of change is compared to its
+	// baseline, rather than its value. This is used for counters, e.g. of
+	// error responses.
+	Rate bool
+}
+
 // HealthThresholdJSONParameters contains Parameters whose Thresholds must be met in order for
 // Caches using the Profile containing these Parameters to be marked as Healthy.
 type HealthThresholdJSONParameters struct {
@@ -556,8 +596,60 @@ func (params *TMParameters) UnmarshalJSON(bytes []byte) (err error) {
 		}
 	}
 
+	if vi, ok := raw["health.probe.path"]; ok {
+		if v, ok := vi.(string); !ok {
+			return fmt.Errorf("Unmarshalling TMParameters health.probe.path expected string, got %v", vi)
+		} else {
+			params.HealthProbePath = v
+		}
+	}
+
+	if vi, ok := raw["health.probe.status"]; ok {
+		if v, ok := vi.(float64); !ok {
+			return fmt.Errorf("Unmarshalling TMParameters health.probe.status expected integer, got %v", vi)
+		} else {
+			params.HealthProbeStatus = int(v)
+		}
+	}
+
+	if vi, ok := raw["health.probe.body.sha256"]; ok {
+		if v, ok := vi.(string); !ok {
+			return fmt.Errorf("Unmarshalling TMParameters health.probe.body.sha256 expected string, got %v", vi)
+		} else {
+			params.HealthProbeBodySHA256 = v
+		}
+	}
+
+	if vi, ok := raw["health.anomaly.samples"]; ok {
+		if v, ok := vi.(float64); !ok {
+			return fmt.Errorf("Unmarshalling TMParameters health.anomaly.samples expected integer, got %v", vi)
+		} else {
+			params.AnomalySamples = int(v)
+		}
+	}
+
+	if vi, ok := raw["health.anomaly.relearn_polls"]; ok {
+		if v, ok := vi.(float64); !ok {
+			return fmt.Errorf("Unmarshalling TMParameters health.anomaly.relearn_polls expected integer, got %v", vi)
+		} else {
+			params.AnomalyRelearnPolls = int(v)
+		}
+	}
+
+	params.AnomalyThresholds = map[string]AnomalyThreshold{}
 	params.Thresholds = make(map[string]HealthThreshold, len(raw))
 	for k, v := range raw {
+		if strings.HasPrefix(k, AnomalyThresholdPrefix) || strings.HasPrefix(k, AnomalyRateThresholdPrefix) {
+			threshold := AnomalyThreshold{Rate: strings.HasPrefix(k, AnomalyRateThresholdPrefix)}
+			stat := strings.TrimPrefix(strings.TrimPrefix(k, AnomalyThresholdPrefix), AnomalyRateThresholdPrefix)
+			deviations, err := strconv.ParseFloat(fmt.Sprintf("%v", v), 64)
+			if err != nil || deviations <= 0 {
+				return fmt.Errorf("Unmarshalling TMParameters anomaly threshold parameter value not a positive number: stat '%s' value '%v'", k, v)
+			}
+			threshold.Deviations = deviations
+			params.AnomalyThresholds[stat] = threshold
+			continue
+		}
 		if strings.HasPrefix(k, ThresholdPrefix) {
 			stat := k[len(ThresholdPrefix):]
 			vStr := fmt.Sprintf("%v", v) // allows string or numeric JSON types. TODO check if a type switch is faster.
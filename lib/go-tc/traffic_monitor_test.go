@@ -22,6 +22,7 @@ package tc
 import (
 	"encoding/json"
 	"fmt"
+	"reflect"
 	"testing"
 	"time"
 
@@ -216,6 +217,41 @@ func ExampleTMParameters_UnmarshalJSON() {
 	// # of Thresholds: 2 - foo: <=500.000000, bandwidth: >50.000000
 }
 
+func TestTMParametersUnmarshalAnomalyThresholds(t *testing.T) {
+	const data = `{
+		"health.threshold.loadavg": "25",
+		"health.anomaly.samples": 30,
+		"health.anomaly.relearn_polls": 15,
+		"health.anomaly.threshold.loadavg": "3",
+		"health.anomaly.rate_threshold.proxy.process.http.5xx_responses": 4.5
+	}`
+
+	var params TMParameters
+	if err := json.Unmarshal([]byte(data), &params); err != nil {
+		t.Fatalf("unmarshalling TMParameters with anomaly thresholds - expected: no error, actual: %v", err)
+	}
+	if params.AnomalySamples != 30 {
+		t.Errorf("anomaly samples - expected: 30, actual: %d", params.AnomalySamples)
+	}
+	if params.AnomalyRelearnPolls != 15 {
+		t.Errorf("anomaly relearn polls - expected: 15, actual: %d", params.AnomalyRelearnPolls)
+	}
+	if len(params.Thresholds) != 1 {
+		t.Errorf("number of thresholds - expected: 1, actual: %d", len(params.Thresholds))
+	}
+	expected := map[string]AnomalyThreshold{
+		"loadavg":                          {Deviations: 3},
+		"proxy.process.http.5xx_responses": {Deviations: 4.5, Rate: true},
+	}
+	if !reflect.DeepEqual(expected, params.AnomalyThresholds) {
+		t.Errorf("anomaly thresholds - expected: %+v, actual: %+v", expected, params.AnomalyThresholds)
+	}
+
+	if err := json.Unmarshal([]byte(`{"health.anomaly.threshold.loadavg": "-1"}`), &params); err == nil {
+		t.Error("unmarshalling TMParameters with a negative anomaly threshold - expected: error, actual: nil")
+	}
+}
+
 func ExampleTrafficMonitorConfigMap_Valid() {
 	mc := &TrafficMonitorConfigMap{
 		CacheGroup: map[string]TMCacheGroup{"a": {}},
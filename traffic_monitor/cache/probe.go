@@ -0,0 +1,108 @@
+package cache
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+// probe parses the results of the "probe" poller type, which makes synthetic
+// requests for Delivery Service content through a cache. Probe results carry
+// no system or interface statistics; they are only used to compute the
+// availability of the cache from the "probe." thresholds of its profile.
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/apache/trafficcontrol/traffic_monitor/poller"
+	"github.com/apache/trafficcontrol/traffic_monitor/todata"
+)
+
+// ProbeStatPrefix is the prefix of all the stats produced by the probe decoder.
+const ProbeStatPrefix = "probe."
+
+// These are the names of the aggregate stats produced by the probe decoder,
+// which may be used in "health.threshold.probe.*" Parameters.
+const (
+	ProbeStatCount    = ProbeStatPrefix + "count"
+	ProbeStatFailures = ProbeStatPrefix + "failures"
+	ProbeStatLatency  = ProbeStatPrefix + "latency"
+)
+
+func init() {
+	registerDecoder(poller.PollerTypeProbe, probeParse, probePrecompute)
+}
+
+// probeParse parses the JSON array of poller.ProbeResult objects returned by
+// the probe poller. In addition to the aggregate stats, it returns
+// "probe.<ds>.status", "probe.<ds>.latency", and "probe.<ds>.success" for each
+// probed Delivery Service, and "probe.<ds>.error" for each failed one.
+func probeParse(cacheName string, data io.Reader, _ interface{}) (Statistics, map[string]interface{}, error) {
+	if data == nil {
+		return Statistics{}, nil, errors.New("handler got nil reader")
+	}
+
+	results := []poller.ProbeResult{}
+	if err := json.NewDecoder(data).Decode(&results); err != nil {
+		return Statistics{}, nil, errors.New("decoding probe results: " + err.Error())
+	}
+
+	failures := 0
+	maxLatency := 0.0
+	miscStats := make(map[string]interface{}, len(results)*3+3)
+	for _, result := range results {
+		prefix := ProbeStatPrefix + string(result.DeliveryService) + "."
+		miscStats[prefix+"status"] = float64(result.Status)
+		miscStats[prefix+"latency"] = result.LatencyMS
+		miscStats[prefix+"success"] = result.Success
+		if !result.Success {
+			failures++
+			miscStats[prefix+"error"] = result.Error
+		}
+		if result.LatencyMS > maxLatency {
+			maxLatency = result.LatencyMS
+		}
+	}
+	miscStats[ProbeStatCount] = float64(len(results))
+	miscStats[ProbeStatFailures] = float64(failures)
+	miscStats[ProbeStatLatency] = maxLatency
+
+	return Statistics{Interfaces: map[string]Interface{}}, miscStats, nil
+}
+
+func probePrecompute(cache string, toData todata.TOData, stats Statistics, rawStats map[string]interface{}) PrecomputedData {
+	return PrecomputedData{DeliveryServiceStats: map[string]*DSStat{}}
+}
+
+// ProbeErrors returns the errors of the failed probes in the given stats
+// produced by the probe decoder, as sorted "<ds>: <error>" strings.
+func ProbeErrors(miscStats map[string]interface{}) []string {
+	errs := []string{}
+	for stat, val := range miscStats {
+		if !strings.HasPrefix(stat, ProbeStatPrefix) || !strings.HasSuffix(stat, ".error") {
+			continue
+		}
+		if msg, ok := val.(string); ok {
+			errs = append(errs, strings.TrimSuffix(strings.TrimPrefix(stat, ProbeStatPrefix), ".error")+": "+msg)
+		}
+	}
+	sort.Strings(errs)
+	return errs
+}
@@ -0,0 +1,62 @@
+package cache
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestProbeParse(t *testing.T) {
+	data := `[
+		{"deliveryService": "ds1", "url": "http://ds1.example.test/probe", "status": 200, "latencyMs": 12.5, "success": true},
+		{"deliveryService": "ds2", "url": "http://ds2.example.test/probe", "status": 503, "latencyMs": 40, "success": false, "error": "bad HTTP status: expected 200, actual 503"}
+	]`
+	_, miscStats, err := probeParse("edge", strings.NewReader(data), nil)
+	if err != nil {
+		t.Fatalf("parsing probe results expected: no error, actual: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		ProbeStatCount:      float64(2),
+		ProbeStatFailures:   float64(1),
+		ProbeStatLatency:    float64(40),
+		"probe.ds1.status":  float64(200),
+		"probe.ds1.latency": 12.5,
+		"probe.ds1.success": true,
+		"probe.ds2.status":  float64(503),
+		"probe.ds2.latency": float64(40),
+		"probe.ds2.success": false,
+		"probe.ds2.error":   "bad HTTP status: expected 200, actual 503",
+	}
+	if !reflect.DeepEqual(expected, miscStats) {
+		t.Errorf("probe stats expected: %+v, actual: %+v", expected, miscStats)
+	}
+
+	errs := ProbeErrors(miscStats)
+	if len(errs) != 1 || errs[0] != "ds2: bad HTTP status: expected 200, actual 503" {
+		t.Errorf("probe errors expected: [ds2: bad HTTP status: expected 200, actual 503], actual: %v", errs)
+	}
+
+	if _, _, err := probeParse("edge", strings.NewReader("not json"), nil); err == nil {
+		t.Error("parsing invalid probe results expected: error, actual: nil")
+	}
+}
@@ -0,0 +1,410 @@
+package cache
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+// The "prometheus" Stats format parses the Prometheus text exposition format
+// (and OpenMetrics, which is a superset of it), as served by Grove, the
+// Prometheus node_exporter, and the Prometheus plugins and exporters of
+// other caches.
+//
+// Which metrics hold the loadavg, network interface, connection and Delivery
+// Service stats is configured by the `prometheus_metrics` option of
+// traffic_monitor.cfg. Every sample is also returned as a miscellaneous stat,
+// named as it appears in the exposition with its labels sorted, e.g.
+// `node_load5` or `grove_responses_total{code_class="2xx",remap="a.b.c"}`,
+// so any of them may be used in Traffic Ops thresholds.
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/apache/trafficcontrol/lib/go-log"
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_monitor/config"
+	"github.com/apache/trafficcontrol/traffic_monitor/poller"
+	"github.com/apache/trafficcontrol/traffic_monitor/todata"
+)
+
+func init() {
+	registerDecoder("prometheus", prometheusParse, prometheusPrecompute)
+}
+
+// prometheusConnectionsStat is the name the connections metric is stored
+// under, which is the astats name Traffic Monitor reads connections from.
+const prometheusConnectionsStat = "proxy.process.http.current_client_connections"
+
+// prometheusDSStatPrefix is the prefix of the names the Delivery Service
+// metrics are stored under, which are of the form
+// `prometheus.ds.<label value>.<stat>`.
+const prometheusDSStatPrefix = "prometheus.ds."
+
+// prometheusMaxLineBytes is the longest exposition line which can be parsed.
+const prometheusMaxLineBytes = 1024 * 1024
+
+type prometheusSample struct {
+	Name   string
+	Labels map[string]string
+	Value  float64
+}
+
+func prometheusParse(cacheName string, data io.Reader, pollCTX interface{}) (Statistics, map[string]interface{}, error) {
+	var stats Statistics
+	if data == nil {
+		log.Warnf("Cannot read stats data for cache '%s' - nil data reader", cacheName)
+		return stats, nil, errors.New("handler got nil reader")
+	}
+
+	ctx := pollCTX.(*poller.HTTPPollCtx)
+	metrics := ctx.PrometheusMetrics
+
+	samples, err := prometheusParseText(data)
+	if err != nil {
+		return stats, nil, fmt.Errorf("parsing prometheus stats for cache '%s': %v", cacheName, err)
+	}
+
+	misc := make(map[string]interface{}, len(samples))
+	foundLoadavg := false
+	for _, sample := range samples {
+		misc[sample.key()] = sample.Value
+
+		switch sample.Name {
+		case metrics.Loadavg:
+			stats.Loadavg.One = sample.Value
+			foundLoadavg = true
+		case metrics.InterfaceBytesIn, metrics.InterfaceBytesOut, metrics.InterfaceSpeed:
+			if err := prometheusAddInterfaceSample(&stats, metrics, sample); err != nil {
+				log.Warnf("cache '%s' stat %s: %v", cacheName, sample.key(), err)
+			}
+		case metrics.Connections:
+			misc[prometheusConnectionsStat] = sample.Value
+		case metrics.DeliveryServiceBytesIn:
+			prometheusAddDSSample(misc, metrics, sample, "in_bytes")
+		case metrics.DeliveryServiceBytesOut:
+			prometheusAddDSSample(misc, metrics, sample, "out_bytes")
+		case metrics.DeliveryServiceResponses:
+			class := prometheusStatusClass(sample.Labels[metrics.DeliveryServiceStatusLabel])
+			if class == "" {
+				log.Warnf("cache '%s' stat %s: missing or malformed status label '%s'", cacheName, sample.key(), metrics.DeliveryServiceStatusLabel)
+				continue
+			}
+			prometheusAddDSSample(misc, metrics, sample, "status_"+class)
+		}
+	}
+
+	if !foundLoadavg {
+		return stats, nil, fmt.Errorf("cache '%s' had no loadavg metric '%s'", cacheName, metrics.Loadavg)
+	}
+	if len(stats.Interfaces) < 1 {
+		return stats, nil, fmt.Errorf("cache '%s' had no interfaces", cacheName)
+	}
+	return stats, misc, nil
+}
+
+// prometheusAddInterfaceSample adds the given interface byte or speed sample
+// to the interfaces of stats.
+func prometheusAddInterfaceSample(stats *Statistics, metrics config.PrometheusMetrics, sample prometheusSample) error {
+	name := sample.Labels[metrics.InterfaceLabel]
+	if name == "" {
+		return fmt.Errorf("missing interface label '%s'", metrics.InterfaceLabel)
+	}
+	if sample.Value < 0 || sample.Value > math.MaxInt64 || math.IsNaN(sample.Value) {
+		return fmt.Errorf("value %v out of range", sample.Value)
+	}
+	if stats.Interfaces == nil {
+		stats.Interfaces = map[string]Interface{}
+	}
+	iface := stats.Interfaces[name]
+	switch sample.Name {
+	case metrics.InterfaceBytesIn:
+		iface.BytesIn = uint64(sample.Value)
+	case metrics.InterfaceBytesOut:
+		iface.BytesOut = uint64(sample.Value)
+	case metrics.InterfaceSpeed:
+		iface.Speed = int64(sample.Value * metrics.InterfaceSpeedMultiplier)
+	}
+	stats.Interfaces[name] = iface
+	return nil
+}
+
+// prometheusAddDSSample adds the given Delivery Service sample to misc, as the
+// given stat. Samples of the same Delivery Service and stat are summed, so
+// metrics with further labels (e.g. status codes within a class) are
+// aggregated.
+func prometheusAddDSSample(misc map[string]interface{}, metrics config.PrometheusMetrics, sample prometheusSample, stat string) {
+	ds := sample.Labels[metrics.DeliveryServiceLabel]
+	if ds == "" {
+		return
+	}
+	name := prometheusDSStatPrefix + ds + "." + stat
+	sum, _ := misc[name].(float64)
+	misc[name] = sum + sample.Value
+}
+
+// prometheusStatusClass returns the class of the given status code or status
+// code class label value, e.g. "2xx" for "200" or "2xx"; or the empty string
+// if it isn't one.
+func prometheusStatusClass(status string) string {
+	if len(status) != 3 || status[0] < '1' || status[0] > '5' {
+		return ""
+	}
+	if strings.EqualFold(status[1:], "xx") {
+		return status[:1] + "xx"
+	}
+	if _, err := strconv.Atoi(status); err != nil {
+		return ""
+	}
+	return status[:1] + "xx"
+}
+
+// prometheusParseText parses the samples of a Prometheus text format or
+// OpenMetrics exposition. Comments, including HELP and TYPE metadata, are
+// ignored.
+func prometheusParseText(data io.Reader) ([]prometheusSample, error) {
+	samples := []prometheusSample{}
+	scanner := bufio.NewScanner(data)
+	scanner.Buffer(make([]byte, 0, 64*1024), prometheusMaxLineBytes)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "# EOF" {
+			break
+		}
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		sample, err := prometheusParseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNum, err)
+		}
+		samples = append(samples, sample)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return samples, nil
+}
+
+// prometheusParseLine parses a single sample line, of the form
+// `name{label="value",...} value [timestamp]`.
+func prometheusParseLine(line string) (prometheusSample, error) {
+	sample := prometheusSample{}
+	nameEnd := strings.IndexAny(line, "{ \t")
+	if nameEnd <= 0 {
+		return sample, errors.New("malformed sample '" + line + "'")
+	}
+	sample.Name = line[:nameEnd]
+	rest := line[nameEnd:]
+
+	if rest[0] == '{' {
+		labels, labelsEnd, err := prometheusParseLabels(rest)
+		if err != nil {
+			return sample, fmt.Errorf("metric '%s': %v", sample.Name, err)
+		}
+		sample.Labels = labels
+		rest = rest[labelsEnd:]
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) < 1 || len(fields) > 3 { // OpenMetrics allows a timestamp, then an exemplar
+		return sample, fmt.Errorf("metric '%s': malformed value '%s'", sample.Name, rest)
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return sample, fmt.Errorf("metric '%s': parsing value: %v", sample.Name, err)
+	}
+	sample.Value = value
+	return sample, nil
+}
+
+// prometheusParseLabels parses the label set at the start of s, which must
+// begin with '{'. It returns the labels, and the index in s after the closing
+// '}'.
+func prometheusParseLabels(s string) (map[string]string, int, error) {
+	labels := map[string]string{}
+	i := 1
+	for {
+		for i < len(s) && (s[i] == ' ' || s[i] == ',') {
+			i++
+		}
+		if i >= len(s) {
+			return nil, 0, errors.New("unterminated label set")
+		}
+		if s[i] == '}' {
+			return labels, i + 1, nil
+		}
+
+		eq := strings.IndexByte(s[i:], '=')
+		if eq < 0 {
+			return nil, 0, errors.New("label without value")
+		}
+		name := strings.TrimSpace(s[i : i+eq])
+		i += eq + 1
+		if i >= len(s) || s[i] != '"' {
+			return nil, 0, fmt.Errorf("label '%s' value not quoted", name)
+		}
+		i++
+
+		value := strings.Builder{}
+		for {
+			if i >= len(s) {
+				return nil, 0, fmt.Errorf("label '%s' value unterminated", name)
+			}
+			c := s[i]
+			i++
+			if c == '"' {
+				break
+			}
+			if c == '\\' && i < len(s) {
+				c = s[i]
+				i++
+				if c == 'n' {
+					c = '\n'
+				}
+			}
+			value.WriteByte(c)
+		}
+		labels[name] = value.String()
+	}
+}
+
+// key returns the name of the sample's series, with its labels sorted by
+// name, e.g. `name{a="1",b="2"}`.
+func (s prometheusSample) key() string {
+	if len(s.Labels) == 0 {
+		return s.Name
+	}
+	names := make([]string, 0, len(s.Labels))
+	for name := range s.Labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	key := strings.Builder{}
+	key.WriteString(s.Name)
+	key.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			key.WriteByte(',')
+		}
+		key.WriteString(name)
+		key.WriteString(`="`)
+		key.WriteString(s.Labels[name])
+		key.WriteByte('"')
+	}
+	key.WriteByte('}')
+	return key.String()
+}
+
+func prometheusPrecompute(cacheName string, data todata.TOData, stats Statistics, miscStats map[string]interface{}) PrecomputedData {
+	var precomputed PrecomputedData
+	precomputed.DeliveryServiceStats = make(map[string]*DSStat)
+
+	precomputed.OutBytes = 0
+	precomputed.MaxKbps = 0
+	for _, iface := range stats.Interfaces {
+		precomputed.OutBytes += iface.BytesOut
+		if iface.Speed > precomputed.MaxKbps {
+			precomputed.MaxKbps = iface.Speed
+		}
+	}
+	precomputed.MaxKbps *= 1000
+
+	for stat, value := range miscStats {
+		if !strings.HasPrefix(stat, prometheusDSStatPrefix) {
+			continue
+		}
+		trimmedStat := strings.TrimPrefix(stat, prometheusDSStatPrefix)
+		statSep := strings.LastIndexByte(trimmedStat, '.')
+		if statSep < 1 {
+			err := errors.New("stat has no deliveryservice and name parts")
+			log.Infof("precomputing cache %s stat %s value %v error %v", cacheName, stat, value, err)
+			precomputed.Errors = append(precomputed.Errors, err)
+			continue
+		}
+		ds, ok := prometheusDeliveryService(data, trimmedStat[:statSep])
+		if !ok {
+			err := errors.New("No Delivery Service match for stat")
+			log.Infof("precomputing cache %s stat %s value %v error %v", cacheName, stat, value, err)
+			precomputed.Errors = append(precomputed.Errors, err)
+			continue
+		}
+
+		floatVal, ok := value.(float64)
+		if !ok || floatVal < 0 || floatVal > math.MaxUint64 || math.IsNaN(floatVal) {
+			err := fmt.Errorf("value '%v' is not a valid counter", value)
+			log.Infof("precomputing cache %s stat %s value %v error %v", cacheName, stat, value, err)
+			precomputed.Errors = append(precomputed.Errors, err)
+			continue
+		}
+		parsedStat := uint64(floatVal)
+
+		dsName := string(ds)
+		dsStat, ok := precomputed.DeliveryServiceStats[dsName]
+		if !ok || dsStat == nil {
+			dsStat = new(DSStat)
+		}
+
+		switch trimmedStat[statSep+1:] {
+		case "status_2xx":
+			dsStat.Status2xx += parsedStat
+		case "status_3xx":
+			dsStat.Status3xx += parsedStat
+		case "status_4xx":
+			dsStat.Status4xx += parsedStat
+		case "status_5xx":
+			dsStat.Status5xx += parsedStat
+		case "out_bytes":
+			dsStat.OutBytes += parsedStat
+		case "in_bytes":
+			dsStat.InBytes += parsedStat
+		default:
+			err := fmt.Errorf("Unknown stat '%s'", trimmedStat[statSep+1:])
+			log.Infof("precomputing cache %s stat %s value %v error %v", cacheName, stat, value, err)
+			precomputed.Errors = append(precomputed.Errors, err)
+			continue
+		}
+		precomputed.DeliveryServiceStats[dsName] = dsStat
+	}
+	return precomputed
+}
+
+// prometheusDeliveryService returns the Delivery Service identified by the
+// given Delivery Service label value, which may be either its XMLID or a
+// request FQDN matching one of its regular expressions.
+//
+// Note multiple FQDNs may match the same Delivery Service, in which case
+// their stats are summed by the caller.
+func prometheusDeliveryService(data todata.TOData, label string) (tc.DeliveryServiceName, bool) {
+	if _, ok := data.DeliveryServiceTypes[tc.DeliveryServiceName(label)]; ok {
+		return tc.DeliveryServiceName(label), true
+	}
+	parts := strings.SplitN(label, ".", 3)
+	if len(parts) < 3 {
+		return "", false
+	}
+	return data.DeliveryServiceRegexes.DeliveryService(parts[2], parts[1], parts[0])
+}
@@ -0,0 +1,209 @@
+package cache
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_monitor/config"
+	"github.com/apache/trafficcontrol/traffic_monitor/poller"
+	"github.com/apache/trafficcontrol/traffic_monitor/todata"
+)
+
+func TestPrometheusParse(t *testing.T) {
+	fd, err := os.Open("prometheus.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fd.Close()
+
+	ctx := &poller.HTTPPollCtx{PrometheusMetrics: config.DefaultPrometheusMetrics}
+	stats, misc, err := prometheusParse("test", fd, ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Loadavg.One != 0.21 {
+		t.Errorf("Incorrect one-minute loadavg, expected 0.21, got %v", stats.Loadavg.One)
+	}
+	if len(stats.Interfaces) != 2 {
+		t.Errorf("Expected exactly two interfaces, got %d", len(stats.Interfaces))
+	}
+	iface, ok := stats.Interfaces["eth0"]
+	if !ok {
+		t.Fatal("Didn't find the expected 'eth0' network interface")
+	}
+	if iface.Speed != 10000 {
+		t.Errorf("Incorrect interface speed, expected 10000, got %d", iface.Speed)
+	}
+	if iface.BytesIn != 4363732 {
+		t.Errorf("Incorrect interface bytes in, expected 4363732, got %d", iface.BytesIn)
+	}
+	if iface.BytesOut != 237634637 {
+		t.Errorf("Incorrect interface bytes out, expected 237634637, got %d", iface.BytesOut)
+	}
+
+	if misc["node_load5"] != float64(0.35) {
+		t.Errorf("Expected 0.35 for node_load5, got %v", misc["node_load5"])
+	}
+	if misc[prometheusConnectionsStat] != float64(42) {
+		t.Errorf("Expected 42 for %s, got %v", prometheusConnectionsStat, misc[prometheusConnectionsStat])
+	}
+	key := `grove_responses_total{code_class="2xx",remap="edge.demo1.mycdn.ciab.test"}`
+	if misc[key] != float64(1000) {
+		t.Errorf("Expected 1000 for %s, got %v", key, misc[key])
+	}
+	key = `grove_info{version="0.5 "beta""}`
+	if misc[key] != float64(1) {
+		t.Errorf("Expected 1 for %s, got %v", key, misc[key])
+	}
+	key = prometheusDSStatPrefix + "edge.demo1.mycdn.ciab.test.out_bytes"
+	if misc[key] != float64(1234567890) {
+		t.Errorf("Expected 1234567890 for %s, got %v", key, misc[key])
+	}
+}
+
+func TestPrometheusParseConfiguredMetrics(t *testing.T) {
+	exposition := `
+# TYPE varnish_main_sess_conn counter
+varnish_main_sess_conn 12
+load_one 1.5
+if_rx{iface="bond0"} 100
+if_tx{iface="bond0"} 200
+if_speed_mbps{iface="bond0"} 40000
+ds_out_bytes{ds="demo1"} 300
+ds_responses{ds="demo1",code="200"} 10
+ds_responses{ds="demo1",code="206"} 5
+ds_responses{ds="demo1",code="503"} 1
+`
+	metrics := config.PrometheusMetrics{
+		Loadavg:                    "load_one",
+		InterfaceLabel:             "iface",
+		InterfaceBytesIn:           "if_rx",
+		InterfaceBytesOut:          "if_tx",
+		InterfaceSpeed:             "if_speed_mbps",
+		InterfaceSpeedMultiplier:   1,
+		Connections:                "varnish_main_sess_conn",
+		DeliveryServiceLabel:       "ds",
+		DeliveryServiceBytesOut:    "ds_out_bytes",
+		DeliveryServiceResponses:   "ds_responses",
+		DeliveryServiceStatusLabel: "code",
+	}
+	ctx := &poller.HTTPPollCtx{PrometheusMetrics: metrics}
+	stats, misc, err := prometheusParse("test", strings.NewReader(exposition), ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Loadavg.One != 1.5 {
+		t.Errorf("Incorrect one-minute loadavg, expected 1.5, got %v", stats.Loadavg.One)
+	}
+	if iface := stats.Interfaces["bond0"]; iface.Speed != 40000 || iface.BytesIn != 100 || iface.BytesOut != 200 {
+		t.Errorf("Incorrect interface, expected {40000 200 100}, got %+v", iface)
+	}
+	if misc[prometheusConnectionsStat] != float64(12) {
+		t.Errorf("Expected 12 for %s, got %v", prometheusConnectionsStat, misc[prometheusConnectionsStat])
+	}
+
+	toData := *todata.New()
+	toData.DeliveryServiceTypes[tc.DeliveryServiceName("demo1")] = tc.DSTypeCategoryHTTP
+	precomputed := prometheusPrecompute("test", toData, stats, misc)
+	if len(precomputed.Errors) != 0 {
+		t.Errorf("Expected no precompute errors, got %v", precomputed.Errors)
+	}
+	dsStat, ok := precomputed.DeliveryServiceStats["demo1"]
+	if !ok {
+		t.Fatal("Expected stats for Delivery Service 'demo1'")
+	}
+	if dsStat.Status2xx != 15 {
+		t.Errorf("Expected 15 2xx responses, got %d", dsStat.Status2xx)
+	}
+	if dsStat.Status5xx != 1 {
+		t.Errorf("Expected 1 5xx response, got %d", dsStat.Status5xx)
+	}
+	if dsStat.OutBytes != 300 {
+		t.Errorf("Expected 300 out bytes, got %d", dsStat.OutBytes)
+	}
+	if precomputed.MaxKbps != 40000000 {
+		t.Errorf("Expected max kbps 40000000, got %d", precomputed.MaxKbps)
+	}
+}
+
+func TestPrometheusParseErrors(t *testing.T) {
+	ctx := &poller.HTTPPollCtx{PrometheusMetrics: config.DefaultPrometheusMetrics}
+	expositions := map[string]string{
+		"no loadavg":       `node_network_transmit_bytes_total{device="eth0"} 1`,
+		"no interfaces":    `node_load1 1`,
+		"bad value":        "node_load1 one",
+		"unterminated":     `node_load1{a="b" 1`,
+		"unquoted label":   `node_load1{a=b} 1`,
+		"missing value":    `node_load1`,
+		"too many columns": `node_load1 1 2 3 4`,
+	}
+	for name, exposition := range expositions {
+		if _, _, err := prometheusParse("test", strings.NewReader(exposition), ctx); err == nil {
+			t.Errorf("Expected an error parsing exposition with %s, got nil", name)
+		}
+	}
+}
+
+func TestPrometheusPrecompute(t *testing.T) {
+	fd, err := os.Open("prometheus.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fd.Close()
+
+	ctx := &poller.HTTPPollCtx{PrometheusMetrics: config.DefaultPrometheusMetrics}
+	stats, misc, err := prometheusParse("test", fd, ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	toData := getMockTOData(map[tc.DeliveryServiceName]string{"demo1": "edge.demo1.mycdn.ciab.test"})
+	precomputed := prometheusPrecompute("test", toData, stats, misc)
+
+	if precomputed.OutBytes != 237634637+91234 {
+		t.Errorf("Expected %d out bytes, got %d", 237634637+91234, precomputed.OutBytes)
+	}
+	if precomputed.MaxKbps != 10000000 {
+		t.Errorf("Expected max kbps 10000000, got %d", precomputed.MaxKbps)
+	}
+	// edge.demo2 doesn't match any Delivery Service
+	if len(precomputed.Errors) != 3 {
+		t.Errorf("Expected 3 precompute errors, got %d: %v", len(precomputed.Errors), precomputed.Errors)
+	}
+	if len(precomputed.DeliveryServiceStats) != 1 {
+		t.Fatalf("Expected stats for exactly one Delivery Service, got %d", len(precomputed.DeliveryServiceStats))
+	}
+	expected := DSStat{
+		InBytes:   296727207,
+		OutBytes:  1234567890,
+		Status2xx: 1000,
+		Status3xx: 30,
+		Status4xx: 4,
+		Status5xx: 5,
+	}
+	if dsStat := precomputed.DeliveryServiceStats["demo1"]; dsStat == nil || *dsStat != expected {
+		t.Errorf("Expected Delivery Service stats %+v, got %+v", expected, dsStat)
+	}
+}
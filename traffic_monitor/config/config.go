@@ -47,6 +47,8 @@ const (
 	CRConfigBackupFile = "/opt/traffic_monitor/crconfig.backup"
 	//TmConfigBackupFile is the default file name to store the last tmconfig
 	TMConfigBackupFile = "/opt/traffic_monitor/tmconfig.backup"
+	//MaintenanceFile is the default file name to store the maintenance windows
+	MaintenanceFile = "/opt/traffic_monitor/maintenance.json"
 	//HTTPPollingFormat is the default accept encoding for stats from caches
 	HTTPPollingFormat = "text/json"
 )
@@ -95,39 +97,155 @@ func (t *PollingProtocol) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// PrometheusMetrics holds the names of the Prometheus metrics, and the names
+// of their labels, from which the "prometheus" stats format builds the
+// statistics Traffic Monitor uses for health and Delivery Service stats.
+// Any metric name left empty is not read.
+type PrometheusMetrics struct {
+	// Loadavg is the metric holding the one-minute loadavg.
+	Loadavg string `json:"loadavg"`
+	// InterfaceLabel is the label holding the network interface name of the
+	// interface metrics.
+	InterfaceLabel string `json:"interface_label"`
+	// InterfaceBytesIn is the metric holding the bytes received by each
+	// network interface.
+	InterfaceBytesIn string `json:"interface_bytes_in"`
+	// InterfaceBytesOut is the metric holding the bytes transmitted by each
+	// network interface.
+	InterfaceBytesOut string `json:"interface_bytes_out"`
+	// InterfaceSpeed is the metric holding the speed of each network interface.
+	InterfaceSpeed string `json:"interface_speed"`
+	// InterfaceSpeedMultiplier converts InterfaceSpeed values to megabits per
+	// second, e.g. 0.000008 for a speed in bytes per second.
+	InterfaceSpeedMultiplier float64 `json:"interface_speed_multiplier"`
+	// Connections is the metric holding the number of open client connections.
+	Connections string `json:"connections"`
+	// DeliveryServiceLabel is the label of the Delivery Service metrics holding
+	// the Delivery Service's XMLID, or a request FQDN matching one of its
+	// regular expressions.
+	DeliveryServiceLabel string `json:"delivery_service_label"`
+	// DeliveryServiceBytesIn is the metric holding the bytes received for each
+	// Delivery Service.
+	DeliveryServiceBytesIn string `json:"delivery_service_bytes_in"`
+	// DeliveryServiceBytesOut is the metric holding the bytes transmitted for
+	// each Delivery Service.
+	DeliveryServiceBytesOut string `json:"delivery_service_bytes_out"`
+	// DeliveryServiceResponses is the metric holding the number of responses
+	// for each Delivery Service, by status.
+	DeliveryServiceResponses string `json:"delivery_service_responses"`
+	// DeliveryServiceStatusLabel is the label of DeliveryServiceResponses
+	// holding the response status code, or status code class (e.g. "2xx").
+	DeliveryServiceStatusLabel string `json:"delivery_service_status_label"`
+}
+
+// DefaultPrometheusMetrics are the metric names used by the "prometheus"
+// stats format if none are configured. These are the names exposed by the
+// Prometheus node_exporter and by Grove.
+var DefaultPrometheusMetrics = PrometheusMetrics{
+	Loadavg:                    "node_load1",
+	InterfaceLabel:             "device",
+	InterfaceBytesIn:           "node_network_receive_bytes_total",
+	InterfaceBytesOut:          "node_network_transmit_bytes_total",
+	InterfaceSpeed:             "node_network_speed_bytes",
+	InterfaceSpeedMultiplier:   0.000008,
+	Connections:                "grove_connections",
+	DeliveryServiceLabel:       "remap",
+	DeliveryServiceBytesIn:     "grove_in_bytes_total",
+	DeliveryServiceBytesOut:    "grove_out_bytes_total",
+	DeliveryServiceResponses:   "grove_responses_total",
+	DeliveryServiceStatusLabel: "code_class",
+}
+
+const (
+	// PeerQuorumModeOptimistic considers a cache available if it is
+	// available locally or on any available peer.
+	PeerQuorumModeOptimistic = "optimistic"
+	// PeerQuorumModeWeighted considers a cache available if the weighted
+	// votes of this Traffic Monitor and its available peers reach the
+	// PeerQuorum AvailableThreshold.
+	PeerQuorumModeWeighted = "weighted"
+)
+
+// PeerQuorum configures how the health states of this Traffic Monitor and
+// its peers are combined, and when the peers are considered to have split
+// into disagreeing partitions.
+type PeerQuorum struct {
+	// Mode is PeerQuorumModeOptimistic or PeerQuorumModeWeighted. If empty,
+	// it is PeerQuorumModeOptimistic.
+	Mode string `json:"mode"`
+	// SameCacheGroupWeight is the weight of the votes of this Traffic
+	// Monitor, and of peers in the same Cache Group.
+	SameCacheGroupWeight float64 `json:"same_cachegroup_weight"`
+	// SameRegionWeight is the weight of the votes of peers in a different
+	// Cache Group of the same region, per Regions.
+	SameRegionWeight float64 `json:"same_region_weight"`
+	// Regions maps Cache Group names to the names of the regions they are
+	// in. Peers in Cache Groups which aren't in it are never in the same
+	// region.
+	Regions map[string]string `json:"regions"`
+	// AvailableThreshold is the fraction of the total weight of votes which
+	// must be available for a cache to be available, in weighted mode.
+	AvailableThreshold float64 `json:"available_threshold"`
+	// SplitBrainAgreement is the fraction of caches on which two Traffic
+	// Monitors must agree to be in the same partition.
+	SplitBrainAgreement float64 `json:"split_brain_agreement"`
+}
+
+// DefaultPeerQuorum is the peer quorum configuration used if none is
+// configured, which keeps the historic optimistic behavior.
+var DefaultPeerQuorum = PeerQuorum{
+	Mode:                 PeerQuorumModeOptimistic,
+	SameCacheGroupWeight: 2,
+	SameRegionWeight:     1.5,
+	AvailableThreshold:   0.5,
+	SplitBrainAgreement:  0.9,
+}
+
 // Config is the configuration for the application. It includes myriad data, such as polling intervals and log locations.
 type Config struct {
-	CacheHealthPollingInterval   time.Duration   `json:"-"`
-	CacheStatPollingInterval     time.Duration   `json:"-"`
-	MonitorConfigPollingInterval time.Duration   `json:"-"`
-	HTTPTimeout                  time.Duration   `json:"-"`
-	PeerPollingInterval          time.Duration   `json:"-"`
-	PeerOptimistic               bool            `json:"peer_optimistic"`
-	PeerOptimisticQuorumMin      int             `json:"peer_optimistic_quorum_min"`
-	MaxEvents                    uint64          `json:"max_events"`
-	MaxStatHistory               uint64          `json:"max_stat_history"`
-	MaxHealthHistory             uint64          `json:"max_health_history"`
-	HealthFlushInterval          time.Duration   `json:"-"`
-	StatFlushInterval            time.Duration   `json:"-"`
-	StatBufferInterval           time.Duration   `json:"-"`
-	LogLocationError             string          `json:"log_location_error"`
-	LogLocationWarning           string          `json:"log_location_warning"`
-	LogLocationInfo              string          `json:"log_location_info"`
-	LogLocationDebug             string          `json:"log_location_debug"`
-	LogLocationEvent             string          `json:"log_location_event"`
-	ServeReadTimeout             time.Duration   `json:"-"`
-	ServeWriteTimeout            time.Duration   `json:"-"`
-	HealthToStatRatio            uint64          `json:"health_to_stat_ratio"`
-	StaticFileDir                string          `json:"static_file_dir"`
-	CRConfigHistoryCount         uint64          `json:"crconfig_history_count"`
-	TrafficOpsMinRetryInterval   time.Duration   `json:"-"`
-	TrafficOpsMaxRetryInterval   time.Duration   `json:"-"`
-	CRConfigBackupFile           string          `json:"crconfig_backup_file"`
-	TMConfigBackupFile           string          `json:"tmconfig_backup_file"`
-	TrafficOpsDiskRetryMax       uint64          `json:"-"`
-	CachePollingProtocol         PollingProtocol `json:"cache_polling_protocol"`
-	PeerPollingProtocol          PollingProtocol `json:"peer_polling_protocol"`
-	HTTPPollingFormat            string          `json:"http_polling_format"`
+	CacheHealthPollingInterval   time.Duration     `json:"-"`
+	CacheStatPollingInterval     time.Duration     `json:"-"`
+	CacheProbePollingInterval    time.Duration     `json:"-"`
+	MonitorConfigPollingInterval time.Duration     `json:"-"`
+	HTTPTimeout                  time.Duration     `json:"-"`
+	PeerPollingInterval          time.Duration     `json:"-"`
+	PeerOptimistic               bool              `json:"peer_optimistic"`
+	PeerOptimisticQuorumMin      int               `json:"peer_optimistic_quorum_min"`
+	PeerQuorum                   PeerQuorum        `json:"peer_quorum"`
+	MaxEvents                    uint64            `json:"max_events"`
+	MaxStatHistory               uint64            `json:"max_stat_history"`
+	MaxHealthHistory             uint64            `json:"max_health_history"`
+	HealthFlushInterval          time.Duration     `json:"-"`
+	StatFlushInterval            time.Duration     `json:"-"`
+	StatBufferInterval           time.Duration     `json:"-"`
+	LogLocationError             string            `json:"log_location_error"`
+	LogLocationWarning           string            `json:"log_location_warning"`
+	LogLocationInfo              string            `json:"log_location_info"`
+	LogLocationDebug             string            `json:"log_location_debug"`
+	LogLocationEvent             string            `json:"log_location_event"`
+	ServeReadTimeout             time.Duration     `json:"-"`
+	ServeWriteTimeout            time.Duration     `json:"-"`
+	HealthToStatRatio            uint64            `json:"health_to_stat_ratio"`
+	StaticFileDir                string            `json:"static_file_dir"`
+	CRConfigHistoryCount         uint64            `json:"crconfig_history_count"`
+	TrafficOpsMinRetryInterval   time.Duration     `json:"-"`
+	TrafficOpsMaxRetryInterval   time.Duration     `json:"-"`
+	CRConfigBackupFile           string            `json:"crconfig_backup_file"`
+	TMConfigBackupFile           string            `json:"tmconfig_backup_file"`
+	TrafficOpsDiskRetryMax       uint64            `json:"-"`
+	CachePollingProtocol         PollingProtocol   `json:"cache_polling_protocol"`
+	PeerPollingProtocol          PollingProtocol   `json:"peer_polling_protocol"`
+	HTTPPollingFormat            string            `json:"http_polling_format"`
+	PrometheusMetrics            PrometheusMetrics `json:"prometheus_metrics"`
+	EventStorePath               string            `json:"event_store_path"`
+	EventStoreRetention          time.Duration     `json:"-"`
+	GRPCListener                 string            `json:"grpc_listener"`
+	GRPCCertFile                 string            `json:"grpc_cert_file"`
+	GRPCKeyFile                  string            `json:"grpc_key_file"`
+	MaintenanceFile              string            `json:"maintenance_file"`
+	MaintenanceAPIToken          string            `json:"maintenance_api_token"`
+	// CDNs are the CDNs monitored in addition to the CDN of this Traffic Monitor in Traffic Ops.
+	CDNs []string `json:"cdns"`
 }
 
 func (c Config) ErrorLog() log.LogLocation   { return log.LogLocation(c.LogLocationError) }
@@ -140,11 +258,13 @@ func (c Config) EventLog() log.LogLocation   { return log.LogLocation(c.LogLocat
 var DefaultConfig = Config{
 	CacheHealthPollingInterval:   6 * time.Second,
 	CacheStatPollingInterval:     6 * time.Second,
+	CacheProbePollingInterval:    30 * time.Second,
 	MonitorConfigPollingInterval: 5 * time.Second,
 	HTTPTimeout:                  2 * time.Second,
 	PeerPollingInterval:          5 * time.Second,
 	PeerOptimistic:               true,
 	PeerOptimisticQuorumMin:      0,
+	PeerQuorum:                   DefaultPeerQuorum,
 	MaxEvents:                    200,
 	MaxStatHistory:               5,
 	MaxHealthHistory:             5,
@@ -169,6 +289,9 @@ var DefaultConfig = Config{
 	CachePollingProtocol:         Both,
 	PeerPollingProtocol:          Both,
 	HTTPPollingFormat:            HTTPPollingFormat,
+	PrometheusMetrics:            DefaultPrometheusMetrics,
+	EventStoreRetention:          7 * 24 * time.Hour,
+	MaintenanceFile:              MaintenanceFile,
 }
 
 // MarshalJSON marshals custom millisecond durations. Aliasing inspired by http://choly.ca/post/go-json-marshalling/
@@ -178,6 +301,7 @@ func (c *Config) MarshalJSON() ([]byte, error) {
 	return json.Marshal(&struct {
 		CacheHealthPollingIntervalMs   uint64 `json:"cache_health_polling_interval_ms"`
 		CacheStatPollingIntervalMs     uint64 `json:"cache_stat_polling_interval_ms"`
+		CacheProbePollingIntervalMs    uint64 `json:"cache_probe_polling_interval_ms"`
 		MonitorConfigPollingIntervalMs uint64 `json:"monitor_config_polling_interval_ms"`
 		HTTPTimeoutMS                  uint64 `json:"http_timeout_ms"`
 		PeerPollingIntervalMs          uint64 `json:"peer_polling_interval_ms"`
@@ -192,6 +316,7 @@ func (c *Config) MarshalJSON() ([]byte, error) {
 	}{
 		CacheHealthPollingIntervalMs:   uint64(c.CacheHealthPollingInterval / time.Millisecond),
 		CacheStatPollingIntervalMs:     uint64(c.CacheStatPollingInterval / time.Millisecond),
+		CacheProbePollingIntervalMs:    uint64(c.CacheProbePollingInterval / time.Millisecond),
 		MonitorConfigPollingIntervalMs: uint64(c.MonitorConfigPollingInterval / time.Millisecond),
 		HTTPTimeoutMS:                  uint64(c.HTTPTimeout / time.Millisecond),
 		PeerPollingIntervalMs:          uint64(c.PeerPollingInterval / time.Millisecond),
@@ -210,6 +335,7 @@ func (c *Config) UnmarshalJSON(data []byte) error {
 	aux := &struct {
 		CacheHealthPollingIntervalMs   *uint64 `json:"cache_health_polling_interval_ms"`
 		CacheStatPollingIntervalMs     *uint64 `json:"cache_stat_polling_interval_ms"`
+		CacheProbePollingIntervalMs    *uint64 `json:"cache_probe_polling_interval_ms"`
 		MonitorConfigPollingIntervalMs *uint64 `json:"monitor_config_polling_interval_ms"`
 		HTTPTimeoutMS                  *uint64 `json:"http_timeout_ms"`
 		PeerPollingIntervalMs          *uint64 `json:"peer_polling_interval_ms"`
@@ -226,6 +352,7 @@ func (c *Config) UnmarshalJSON(data []byte) error {
 		CRConfigBackupFile             *string `json:"crconfig_backup_file"`
 		TMConfigBackupFile             *string `json:"tmconfig_backup_file"`
 		HTTPPollingFormat              *string `json:"http_polling_format"`
+		EventStoreRetentionHours       *uint64 `json:"event_store_retention_hours"`
 		*Alias
 	}{
 		Alias: (*Alias)(c),
@@ -241,6 +368,9 @@ func (c *Config) UnmarshalJSON(data []byte) error {
 	if aux.CacheStatPollingIntervalMs != nil {
 		c.CacheStatPollingInterval = time.Duration(*aux.CacheStatPollingIntervalMs) * time.Millisecond
 	}
+	if aux.CacheProbePollingIntervalMs != nil {
+		c.CacheProbePollingInterval = time.Duration(*aux.CacheProbePollingIntervalMs) * time.Millisecond
+	}
 	if aux.MonitorConfigPollingIntervalMs != nil {
 		c.MonitorConfigPollingInterval = time.Duration(*aux.MonitorConfigPollingIntervalMs) * time.Millisecond
 	}
@@ -289,6 +419,12 @@ func (c *Config) UnmarshalJSON(data []byte) error {
 	if aux.HTTPPollingFormat != nil {
 		c.HTTPPollingFormat = *aux.HTTPPollingFormat
 	}
+	if aux.EventStoreRetentionHours != nil {
+		c.EventStoreRetention = time.Duration(*aux.EventStoreRetentionHours) * time.Hour
+	}
+	if c.PeerQuorum.Mode != "" && c.PeerQuorum.Mode != PeerQuorumModeOptimistic && c.PeerQuorum.Mode != PeerQuorumModeWeighted {
+		return errors.New("invalid peer_quorum mode '" + c.PeerQuorum.Mode + "', must be '" + PeerQuorumModeOptimistic + "' or '" + PeerQuorumModeWeighted + "'")
+	}
 	return nil
 }
 
@@ -71,3 +71,39 @@ func TestLoggingConfig(t *testing.T) {
 		t.Errorf("debug log location - expected: %s, actual: %s\n", c.LogLocationDebug, string(c.DebugLog()))
 	}
 }
+
+func TestPrometheusMetricsConfig(t *testing.T) {
+	c, err := LoadBytes([]byte(`{"prometheus_metrics": {"loadavg": "load_one", "interface_speed_multiplier": 1}}`))
+	if err != nil {
+		t.Fatalf("loading config bytes - expected: no error, actual: %v", err)
+	}
+	if c.PrometheusMetrics.Loadavg != "load_one" {
+		t.Errorf("prometheus loadavg metric - expected: load_one, actual: %s\n", c.PrometheusMetrics.Loadavg)
+	}
+	if c.PrometheusMetrics.InterfaceSpeedMultiplier != 1 {
+		t.Errorf("prometheus interface speed multiplier - expected: 1, actual: %v\n", c.PrometheusMetrics.InterfaceSpeedMultiplier)
+	}
+	if c.PrometheusMetrics.InterfaceBytesOut != DefaultPrometheusMetrics.InterfaceBytesOut {
+		t.Errorf("prometheus unset interface bytes out metric - expected: %s, actual: %s\n", DefaultPrometheusMetrics.InterfaceBytesOut, c.PrometheusMetrics.InterfaceBytesOut)
+	}
+}
+
+func TestPeerQuorumConfig(t *testing.T) {
+	c, err := LoadBytes([]byte(`{"peer_quorum": {"mode": "weighted", "regions": {"cg-a": "east"}}}`))
+	if err != nil {
+		t.Fatalf("loading config bytes - expected: no error, actual: %v", err)
+	}
+	if c.PeerQuorum.Mode != PeerQuorumModeWeighted {
+		t.Errorf("peer quorum mode - expected: %s, actual: %s\n", PeerQuorumModeWeighted, c.PeerQuorum.Mode)
+	}
+	if c.PeerQuorum.Regions["cg-a"] != "east" {
+		t.Errorf("peer quorum region of cg-a - expected: east, actual: %s\n", c.PeerQuorum.Regions["cg-a"])
+	}
+	if c.PeerQuorum.SplitBrainAgreement != DefaultPeerQuorum.SplitBrainAgreement {
+		t.Errorf("peer quorum unset split brain agreement - expected: %v, actual: %v\n", DefaultPeerQuorum.SplitBrainAgreement, c.PeerQuorum.SplitBrainAgreement)
+	}
+
+	if _, err := LoadBytes([]byte(`{"peer_quorum": {"mode": "pessimistic"}}`)); err == nil {
+		t.Error("loading config with invalid peer quorum mode - expected: error, actual: nil")
+	}
+}
@@ -71,6 +71,12 @@ type CacheStatus struct {
 	IPv4Available         *bool    `json:"ipv4_available,omitempty"`
 	IPv6Available         *bool    `json:"ipv6_available,omitempty"`
 	CombinedAvailable     *bool    `json:"combined_available,omitempty"`
+	// PeerSplitBrain is whether this Traffic Monitor and its peers have
+	// split into disagreeing partitions, none of which has a majority.
+	PeerSplitBrain *bool `json:"peer_split_brain,omitempty"`
+	// PeerDisputed is whether the partitions of Traffic Monitors disagree on
+	// the availability of this cache.
+	PeerDisputed *bool `json:"peer_disputed,omitempty"`
 
 	Interfaces *map[string]CacheInterfaceStatus `json:"interfaces,omitempty"`
 }
@@ -95,9 +101,10 @@ func srvAPICacheStates(
 	localCacheStatus threadsafe.CacheAvailableStatus,
 	statMaxKbpses threadsafe.CacheKbpses,
 	monitorConfig threadsafe.TrafficMonitorConfigMap,
+	quorumStatus peer.QuorumStatusThreadsafe,
 ) ([]byte, error) {
 	json := jsoniter.ConfigFastest
-	return json.Marshal(createCacheStatuses(toData.Get().ServerTypes, statInfoHistory.Get(), statResultHistory, healthHistory.Get(), lastHealthDurations.Get(), localStates.Get().Caches, lastStats.Get(), localCacheStatus, statMaxKbpses, monitorConfig.Get().TrafficServer))
+	return json.Marshal(createCacheStatuses(toData.Get().ServerTypes, statInfoHistory.Get(), statResultHistory, healthHistory.Get(), lastHealthDurations.Get(), localStates.Get().Caches, lastStats.Get(), localCacheStatus, statMaxKbpses, monitorConfig.Get().TrafficServer, quorumStatus.Get()))
 }
 
 // interfaceStatus returns the status of the given interface, both qualitatively
@@ -132,6 +139,7 @@ func createCacheStatuses(
 	localCacheStatusThreadsafe threadsafe.CacheAvailableStatus,
 	statMaxKbpses threadsafe.CacheKbpses,
 	servers map[string]tc.TrafficServer,
+	quorumStatus peer.QuorumStatus,
 ) map[string]CacheStatus {
 	conns := createCacheConnections(statResultHistory)
 	statii := make(map[string]CacheStatus, len(servers))
@@ -250,6 +258,9 @@ func createCacheStatuses(
 			cacheStatus.ProcessedAvailable = cacheStatus.Available.IPv4 || cacheStatus.Available.IPv6
 		}
 
+		splitBrain := quorumStatus.SplitBrain
+		disputed := quorumStatus.Disputed(tc.CacheName(cacheName))
+
 		statii[cacheName] = CacheStatus{
 			Type:                   &cacheTypeStr,
 			LoadAverage:            &loadAverage,
@@ -266,6 +277,8 @@ func createCacheStatuses(
 			IPv4Available:          &cacheStatus.Available.IPv4,
 			IPv6Available:          &cacheStatus.Available.IPv6,
 			CombinedAvailable:      &cacheStatus.ProcessedAvailable,
+			PeerSplitBrain:         &splitBrain,
+			PeerDisputed:           &disputed,
 			Interfaces:             &interfaceStatuses,
 		}
 	}
@@ -27,6 +27,7 @@ import (
 	"github.com/apache/trafficcontrol/lib/go-util"
 	"github.com/apache/trafficcontrol/traffic_monitor/cache"
 	"github.com/apache/trafficcontrol/traffic_monitor/dsdata"
+	"github.com/apache/trafficcontrol/traffic_monitor/peer"
 	"github.com/apache/trafficcontrol/traffic_monitor/threadsafe"
 )
 
@@ -103,7 +104,8 @@ func TestCreateCacheStatusesForKbps(t *testing.T) {
 		lastStats,
 		localCacheStatusThreadsafe,
 		statMaxKbpses,
-		servers)
+		servers,
+		peer.QuorumStatus{})
 
 	if len(result) != 1 {
 		t.Fatalf("expected only one cache in result, but got %d", len(result))
@@ -154,7 +156,8 @@ func TestCreateCacheStatusesForKbps(t *testing.T) {
 		lastStats,
 		localCacheStatusThreadsafe,
 		statMaxKbpses,
-		servers)
+		servers,
+		peer.QuorumStatus{SplitBrain: true, DisputedCaches: []tc.CacheName{"edgeserver"}})
 
 	if len(result) != 1 {
 		t.Fatalf("expected only one cache in result, but got %d", len(result))
@@ -168,5 +171,11 @@ func TestCreateCacheStatusesForKbps(t *testing.T) {
 		if *status.BandwidthKbps != 800 {
 			t.Errorf("expected BandwidthKbps to be equal to the sum of the values in the interfaces (800), but got %f", *status.BandwidthKbps)
 		}
+		if status.PeerSplitBrain == nil || !*status.PeerSplitBrain {
+			t.Errorf("expected PeerSplitBrain to be true")
+		}
+		if status.PeerDisputed == nil || !*status.PeerDisputed {
+			t.Errorf("expected PeerDisputed to be true")
+		}
 	}
 }
@@ -0,0 +1,167 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package datareq
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-rfc"
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_monitor/peer"
+	"github.com/apache/trafficcontrol/traffic_monitor/poller"
+	"github.com/apache/trafficcontrol/traffic_monitor/threadsafe"
+	"github.com/apache/trafficcontrol/traffic_monitor/todata"
+	"github.com/apache/trafficcontrol/traffic_monitor/towrap"
+	jsoniter "github.com/json-iterator/go"
+)
+
+// cdnPathPrefix is the path prefix of the endpoints of a single monitored CDN, which are followed by the CDN name, and the path of the endpoint, e.g. /cdn/over-the-top/publish/CrStates.
+const cdnPathPrefix = "/cdn/"
+
+// makeCDNHandler returns the handler of the endpoints of each monitored CDN, which serve the same data as the endpoints of the same path without the /cdn/{name} prefix, but only for the caches and Delivery Services of that CDN.
+func makeCDNHandler(
+	opsConfig threadsafe.OpsConfig,
+	extraCDNs []string,
+	toSession towrap.TrafficOpsSessionThreadsafe,
+	toData todata.TODataThreadsafe,
+	localStates peer.CRStatesThreadsafe,
+	combinedStates peer.CRStatesThreadsafe,
+	peerStates peer.CRStatesPeersThreadsafe,
+	quorumStatus peer.QuorumStatusThreadsafe,
+	errorCount threadsafe.Uint,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cdn, endpoint, ok := parseCDNPath(r.URL.Path)
+		if !ok || !isMonitoredCDN(cdn, opsConfig.Get().CdnName, extraCDNs) {
+			http.NotFound(w, r)
+			return
+		}
+		switch endpoint {
+		case "publish/CrStates":
+			WrapParams(func(params url.Values, path string) ([]byte, int) {
+				bytes, statusCode, err := srvCDNTRState(params, tc.CDNName(cdn), toData, localStates, combinedStates, peerStates)
+				return WrapErrStatusCode(errorCount, path, bytes, statusCode, err)
+			}, rfc.ApplicationJSON)(w, r)
+		case "publish/PeerStates":
+			WrapParams(func(params url.Values, path string) ([]byte, int) {
+				return srvCDNPeerStates(params, errorCount, path, tc.CDNName(cdn), toData, peerStates, quorumStatus)
+			}, rfc.ApplicationJSON)(w, r)
+		case "publish/CrConfig":
+			WrapAgeErr(errorCount, func() ([]byte, time.Time, error) {
+				if !toSession.Initialized() {
+					return nil, time.Time{}, errors.New("Unable to connect to Traffic Ops")
+				}
+				return toSession.LastCRConfig(cdn)
+			}, rfc.ApplicationJSON)(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	}
+}
+
+// parseCDNPath returns the CDN name and the endpoint of the given path of a CDN endpoint, without leading or trailing slashes, and false if the path isn't one.
+func parseCDNPath(path string) (string, string, bool) {
+	if !strings.HasPrefix(path, cdnPathPrefix) {
+		return "", "", false
+	}
+	parts := strings.SplitN(strings.TrimSuffix(strings.TrimPrefix(path, cdnPathPrefix), "/"), "/", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+func isMonitoredCDN(cdn string, ownCDN string, extraCDNs []string) bool {
+	for _, monitoredCDN := range poller.MonitoredCDNs(ownCDN, extraCDNs) {
+		if cdn == monitoredCDN {
+			return true
+		}
+	}
+	return false
+}
+
+// srvCDNTRState serves the CrStates of the given CDN, as srvTRState serves those of every monitored CDN. Optimistic quorum is checked against only the peers which monitor the CDN.
+func srvCDNTRState(params url.Values, cdn tc.CDNName, toData todata.TODataThreadsafe, localStates peer.CRStatesThreadsafe, combinedStates peer.CRStatesThreadsafe, peerStates peer.CRStatesPeersThreadsafe) ([]byte, int, error) {
+	if _, raw := params["raw"]; raw {
+		data, err := tc.CRStatesMarshall(filterCRStatesCDN(localStates.Get(), toData.Get(), cdn))
+		return data, http.StatusOK, err
+	}
+	if err := CheckOptimisticQuorumCDN(peerStates, cdn); err != nil {
+		return nil, http.StatusServiceUnavailable, err
+	}
+	data, err := tc.CRStatesMarshall(filterCRStatesCDN(combinedStates.Get(), toData.Get(), cdn))
+	return data, http.StatusOK, err
+}
+
+// filterCRStatesCDN returns the states of the caches and Delivery Services of the given CDN.
+func filterCRStatesCDN(states tc.CRStates, toData todata.TOData, cdn tc.CDNName) tc.CRStates {
+	filtered := tc.NewCRStates()
+	for cacheName, state := range states.Caches {
+		if cacheInCDN(toData, cacheName, cdn) {
+			filtered.Caches[cacheName] = state
+		}
+	}
+	for dsName, state := range states.DeliveryService {
+		if toData.DeliveryServiceCDNs[dsName] == cdn {
+			filtered.DeliveryService[dsName] = state
+		}
+	}
+	return filtered
+}
+
+// srvCDNPeerStates serves the PeerStates of the peers which monitor the given CDN, with only the caches of that CDN, as srvPeerStates serves those of every peer.
+func srvCDNPeerStates(params url.Values, errorCount threadsafe.Uint, path string, cdn tc.CDNName, toData todata.TODataThreadsafe, peerStates peer.CRStatesPeersThreadsafe, quorumStatus peer.QuorumStatusThreadsafe) ([]byte, int) {
+	toDataVal := toData.Get()
+	filter, err := NewPeerStateFilter(path, params, toDataVal.ServerTypes)
+	if err != nil {
+		HandleErr(errorCount, path, err)
+		return []byte(err.Error()), http.StatusBadRequest
+	}
+	apiPeerStates := createAPIPeerStates(peerStates.GetCrstates(), peerStates.GetPeersOnline(), filter, params)
+	for peerName, caches := range apiPeerStates.Peers {
+		if !peerStates.MonitorsCDN(peerName, cdn) {
+			delete(apiPeerStates.Peers, peerName)
+			continue
+		}
+		for cacheName := range caches {
+			if !cacheInCDN(toDataVal, cacheName, cdn) {
+				delete(caches, cacheName)
+			}
+		}
+	}
+	apiPeerStates.Quorum = quorumStatus.Get()
+	json := jsoniter.ConfigFastest
+	bytes, err := json.Marshal(apiPeerStates)
+	return WrapErrCode(errorCount, path, bytes, err)
+}
+
+// cacheInCDN returns whether the given cache is in the given CDN.
+func cacheInCDN(toData todata.TOData, cacheName tc.CacheName, cdn tc.CDNName) bool {
+	for _, cacheCDN := range toData.ServerCDNs[cacheName] {
+		if cacheCDN == cdn {
+			return true
+		}
+	}
+	return false
+}
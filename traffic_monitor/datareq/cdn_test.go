@@ -0,0 +1,77 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package datareq
+
+import (
+	"testing"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_monitor/todata"
+)
+
+func TestParseCDNPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		cdn      string
+		endpoint string
+		ok       bool
+	}{
+		{"/cdn/cdn0/publish/CrStates", "cdn0", "publish/CrStates", true},
+		{"/cdn/cdn0/publish/CrStates/", "cdn0", "publish/CrStates", true},
+		{"/cdn/cdn0", "", "", false},
+		{"/cdn//publish/CrStates", "", "", false},
+		{"/publish/CrStates", "", "", false},
+	}
+	for _, test := range tests {
+		cdn, endpoint, ok := parseCDNPath(test.path)
+		if cdn != test.cdn || endpoint != test.endpoint || ok != test.ok {
+			t.Errorf("%s: expected %q %q %v, actual %q %q %v", test.path, test.cdn, test.endpoint, test.ok, cdn, endpoint, ok)
+		}
+	}
+}
+
+func TestFilterCRStatesCDN(t *testing.T) {
+	states := tc.NewCRStates()
+	states.Caches["edge0"] = tc.IsAvailable{IsAvailable: true}
+	states.Caches["edge1"] = tc.IsAvailable{IsAvailable: true}
+	states.Caches["shared"] = tc.IsAvailable{IsAvailable: false}
+	states.DeliveryService["ds0"] = tc.CRStatesDeliveryService{IsAvailable: true}
+	states.DeliveryService["ds1"] = tc.CRStatesDeliveryService{IsAvailable: true}
+
+	toData := todata.TOData{
+		ServerCDNs: map[tc.CacheName][]tc.CDNName{
+			"edge0":  {"cdn0"},
+			"edge1":  {"cdn1"},
+			"shared": {"cdn0", "cdn1"},
+		},
+		DeliveryServiceCDNs: map[tc.DeliveryServiceName]tc.CDNName{"ds0": "cdn0", "ds1": "cdn1"},
+	}
+
+	filtered := filterCRStatesCDN(states, toData, "cdn1")
+	if len(filtered.Caches) != 2 || !filtered.Caches["edge1"].IsAvailable {
+		t.Errorf("expected cdn1 and shared caches, actual %+v", filtered.Caches)
+	}
+	if _, ok := filtered.Caches["shared"]; !ok {
+		t.Errorf("expected shared cache in cdn1, actual %+v", filtered.Caches)
+	}
+	if len(filtered.DeliveryService) != 1 || !filtered.DeliveryService["ds1"].IsAvailable {
+		t.Errorf("expected only cdn1 Delivery Service, actual %+v", filtered.DeliveryService)
+	}
+}
@@ -42,13 +42,8 @@ func srvTRState(params url.Values, localStates peer.CRStatesThreadsafe, combined
 	// to use the last good state fetched from a Traffic Monitor within the CDN. If the peers are simply unreachable from
 	// this Traffic Monitor, serving 503s until connectivity is restored will cause Traffic Router to ignore this instance
 	// until the health protocol can be relied upon once again.
-	if peerStates.OptimisticQuorumEnabled() {
-		optimisticQuorum, peersAvailable, peerCount, minimum := peerStates.HasOptimisticQuorum()
-		log.Debugf("optimisticQuorum=%v, peerCount=%v, peersAvailable=%v, minimum=%v", optimisticQuorum, peerCount, peersAvailable, minimum)
-
-		if !optimisticQuorum {
-			return nil, http.StatusServiceUnavailable, fmt.Errorf("number of peers available (%d/%d) is less than the minimum number of %d required for optimistic peer quorum", peersAvailable, peerCount, minimum)
-		}
+	if err := CheckOptimisticQuorum(peerStates); err != nil {
+		return nil, http.StatusServiceUnavailable, err
 	}
 
 	data, err := srvTRStateDerived(combinedStates, peerStates)
@@ -56,6 +51,32 @@ func srvTRState(params url.Values, localStates peer.CRStatesThreadsafe, combined
 	return data, http.StatusOK, err
 }
 
+// CheckOptimisticQuorum returns an error if optimistic quorum is enabled and this Traffic Monitor doesn't have it.
+func CheckOptimisticQuorum(peerStates peer.CRStatesPeersThreadsafe) error {
+	if !peerStates.OptimisticQuorumEnabled() {
+		return nil
+	}
+	optimisticQuorum, peersAvailable, peerCount, minimum := peerStates.HasOptimisticQuorum()
+	log.Debugf("optimisticQuorum=%v, peerCount=%v, peersAvailable=%v, minimum=%v", optimisticQuorum, peerCount, peersAvailable, minimum)
+	if !optimisticQuorum {
+		return fmt.Errorf("number of peers available (%d/%d) is less than the minimum number of %d required for optimistic peer quorum", peersAvailable, peerCount, minimum)
+	}
+	return nil
+}
+
+// CheckOptimisticQuorumCDN is CheckOptimisticQuorum for the given monitored CDN, counting only the peers which monitor it.
+func CheckOptimisticQuorumCDN(peerStates peer.CRStatesPeersThreadsafe, cdn tc.CDNName) error {
+	if !peerStates.OptimisticQuorumEnabledCDN(cdn) {
+		return nil
+	}
+	optimisticQuorum, peersAvailable, peerCount, minimum := peerStates.HasOptimisticQuorumCDN(cdn)
+	log.Debugf("cdn=%v, optimisticQuorum=%v, peerCount=%v, peersAvailable=%v, minimum=%v", cdn, optimisticQuorum, peerCount, peersAvailable, minimum)
+	if !optimisticQuorum {
+		return fmt.Errorf("number of peers of CDN %s available (%d/%d) is less than the minimum number of %d required for optimistic peer quorum", cdn, peersAvailable, peerCount, minimum)
+	}
+	return nil
+}
+
 func srvTRStateDerived(combinedStates peer.CRStatesThreadsafe, peerStates peer.CRStatesPeersThreadsafe) ([]byte, error) {
 	return tc.CRStatesMarshall(combinedStates.Get())
 }
@@ -0,0 +1,176 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package datareq
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-log"
+	"github.com/apache/trafficcontrol/traffic_monitor/peer"
+	"github.com/apache/trafficcontrol/traffic_monitor/threadsafe"
+)
+
+const (
+	// crStatesStreamKeepAlive is how often a comment is sent on an idle CrStates stream, to keep proxies from closing it.
+	crStatesStreamKeepAlive = 5 * time.Second
+	// crStatesStreamRetryMS is the reconnection time sent to CrStates stream clients, in milliseconds.
+	crStatesStreamRetryMS = 1000
+	// crStatesStreamMaxMargin is the maximum time before the server write timeout at which a CrStates stream is ended.
+	crStatesStreamMaxMargin = time.Second
+)
+
+// srvTRStateStream serves the CrStates of the given feed as Server-Sent Events, starting with a `snapshot` event of the full CrStates, followed by a `delta` event for each change.
+//
+// Clients reconnecting with the `Last-Event-ID` header, or the `since` query parameter, receive the deltas since that event instead of a snapshot, unless those deltas are no longer kept, in which case they receive a new snapshot.
+//
+// Because the HTTP server's write timeout applies to the whole response, the stream is ended shortly before writeTimeout, and clients are expected to reconnect and resume.
+func srvTRStateStream(w http.ResponseWriter, r *http.Request, errorCount threadsafe.Uint, feed *peer.CRStatesFeed, peerStates peer.CRStatesPeersThreadsafe, writeTimeout time.Duration) {
+	path := r.URL.EscapedPath()
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		HandleErr(errorCount, path, errors.New("response writer does not support flushing, cannot stream"))
+		w.WriteHeader(http.StatusInternalServerError)
+		log.Write(w, []byte(http.StatusText(http.StatusInternalServerError)), path)
+		return
+	}
+
+	if err := CheckOptimisticQuorum(peerStates); err != nil {
+		HandleErr(errorCount, path, err)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		log.Write(w, []byte(http.StatusText(http.StatusServiceUnavailable)), path)
+		return
+	}
+
+	lastID := r.Header.Get("Last-Event-ID")
+	if since := r.URL.Query().Get("since"); since != "" {
+		lastID = since
+	}
+	seq, resume := uint64(0), false
+	if lastID != "" {
+		var err error
+		if seq, err = feed.ParseEventID(lastID); err != nil {
+			log.Infof("%s: sending snapshot, can't resume: %v", path, err)
+		} else {
+			resume = true
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", crStatesStreamRetryMS); err != nil {
+		log.Warnf("%s: writing stream: %v", path, err)
+		return
+	}
+	flusher.Flush()
+
+	var end <-chan time.Time
+	if writeTimeout > 0 {
+		margin := writeTimeout / 10
+		if margin > crStatesStreamMaxMargin {
+			margin = crStatesStreamMaxMargin
+		}
+		endTimer := time.NewTimer(writeTimeout - margin)
+		defer endTimer.Stop()
+		end = endTimer.C
+	}
+	keepAlive := time.NewTicker(crStatesStreamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		// get the channel before reading the feed, so changes between the read and the wait aren't missed.
+		changed := feed.Changed()
+
+		buf := &bytes.Buffer{}
+		deltas, ok := feed.Since(seq)
+		if !resume || !ok {
+			snapshot := feed.Snapshot()
+			data, err := json.Marshal(snapshot)
+			if err != nil {
+				HandleErr(errorCount, path, errors.New("marshalling CrStates snapshot: "+err.Error()))
+				return
+			}
+			writeEvent(buf, "snapshot", feed.EventID(snapshot.Sequence), data)
+			seq, resume = snapshot.Sequence, true
+		} else {
+			for _, delta := range deltas {
+				data, err := json.Marshal(delta)
+				if err != nil {
+					HandleErr(errorCount, path, errors.New("marshalling CrStates delta: "+err.Error()))
+					return
+				}
+				writeEvent(buf, "delta", feed.EventID(delta.Sequence), data)
+				seq = delta.Sequence
+			}
+		}
+		if buf.Len() > 0 {
+			if _, err := w.Write(buf.Bytes()); err != nil {
+				log.Warnf("%s: writing stream: %v", path, err)
+				return
+			}
+			flusher.Flush()
+		}
+
+	wait:
+		for {
+			select {
+			case <-changed:
+				if err := CheckOptimisticQuorum(peerStates); err != nil {
+					HandleErr(errorCount, path, err)
+					buf := &bytes.Buffer{}
+					writeEvent(buf, "error", "", []byte(strconv.Quote(err.Error())))
+					w.Write(buf.Bytes())
+					flusher.Flush()
+					return
+				}
+				break wait
+			case <-keepAlive.C:
+				if _, err := w.Write([]byte(": keepalive\n\n")); err != nil {
+					log.Warnf("%s: writing stream: %v", path, err)
+					return
+				}
+				flusher.Flush()
+			case <-end:
+				return
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}
+}
+
+// writeEvent writes a Server-Sent Event with the given type, ID, and single-line data to buf. If id is empty, no ID is written, so the client's last event ID is unchanged.
+func writeEvent(buf *bytes.Buffer, event string, id string, data []byte) {
+	buf.WriteString("event: " + event + "\n")
+	if id != "" {
+		buf.WriteString("id: " + id + "\n")
+	}
+	buf.WriteString("data: ")
+	buf.Write(data)
+	buf.WriteString("\n\n")
+}
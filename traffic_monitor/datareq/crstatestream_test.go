@@ -0,0 +1,82 @@
+package datareq
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_monitor/peer"
+	"github.com/apache/trafficcontrol/traffic_monitor/threadsafe"
+)
+
+func TestSrvTRStateStream(t *testing.T) {
+	feed := peer.NewCRStatesFeed(peer.DefaultCRStatesFeedHistory)
+	peerStates := peer.NewCRStatesPeersThreadsafe(0)
+	errorCount := threadsafe.NewUint()
+
+	states := tc.NewCRStates()
+	states.Caches["edge0"] = tc.IsAvailable{IsAvailable: true, Ipv4Available: true}
+	feed.Update(states)
+
+	stream := func(lastEventID string) string {
+		r := httptest.NewRequest(http.MethodGet, "/publish/CrStates/stream", nil)
+		if lastEventID != "" {
+			r.Header.Set("Last-Event-ID", lastEventID)
+		}
+		w := httptest.NewRecorder()
+		srvTRStateStream(w, r, errorCount, feed, peerStates, 100*time.Millisecond)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, actual %d", http.StatusOK, w.Code)
+		}
+		if contentType := w.Header().Get("Content-Type"); contentType != "text/event-stream" {
+			t.Errorf("expected content type text/event-stream, actual '%s'", contentType)
+		}
+		return w.Body.String()
+	}
+
+	body := stream("")
+	if !strings.Contains(body, "event: snapshot\nid: "+feed.EventID(1)+"\n") {
+		t.Errorf("expected snapshot event with ID %s, actual body: %s", feed.EventID(1), body)
+	}
+	if !strings.Contains(body, `"edge0"`) {
+		t.Errorf("expected snapshot to contain cache edge0, actual body: %s", body)
+	}
+
+	states.Caches["edge0"] = tc.IsAvailable{}
+	feed.Update(states)
+
+	body = stream(feed.EventID(1))
+	if strings.Contains(body, "event: snapshot") {
+		t.Errorf("expected resumed stream to not contain a snapshot, actual body: %s", body)
+	}
+	if !strings.Contains(body, "event: delta\nid: "+feed.EventID(2)+"\n") {
+		t.Errorf("expected delta event with ID %s, actual body: %s", feed.EventID(2), body)
+	}
+
+	body = stream("not-this-epoch:1")
+	if !strings.Contains(body, "event: snapshot\nid: "+feed.EventID(2)+"\n") {
+		t.Errorf("expected snapshot event for an unknown epoch, actual body: %s", body)
+	}
+}
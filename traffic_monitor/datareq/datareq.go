@@ -33,6 +33,7 @@ import (
 	"github.com/apache/trafficcontrol/lib/go-rfc"
 	"github.com/apache/trafficcontrol/traffic_monitor/config"
 	"github.com/apache/trafficcontrol/traffic_monitor/health"
+	"github.com/apache/trafficcontrol/traffic_monitor/maintenance"
 	"github.com/apache/trafficcontrol/traffic_monitor/peer"
 	"github.com/apache/trafficcontrol/traffic_monitor/threadsafe"
 	"github.com/apache/trafficcontrol/traffic_monitor/todata"
@@ -46,6 +47,8 @@ func MakeDispatchMap(
 	localStates peer.CRStatesThreadsafe,
 	peerStates peer.CRStatesPeersThreadsafe,
 	combinedStates peer.CRStatesThreadsafe,
+	crStatesFeed *peer.CRStatesFeed,
+	quorumStatus peer.QuorumStatusThreadsafe,
 	statInfoHistory threadsafe.ResultInfoHistory,
 	statResultHistory threadsafe.ResultStatHistory,
 	statMaxKbpses threadsafe.CacheKbpses,
@@ -63,6 +66,10 @@ func MakeDispatchMap(
 	lastStats threadsafe.LastStats,
 	unpolledCaches threadsafe.UnpolledCaches,
 	monitorConfig threadsafe.TrafficMonitorConfigMap,
+	maintenanceWindows *maintenance.Windows,
+	maintenanceAPIToken string,
+	extraCDNs []string,
+	writeTimeout time.Duration,
 ) map[string]http.HandlerFunc {
 
 	// wrap composes all universal wrapper functions. Right now, it's only the UnpolledCheck, but there may be others later. For example, security headers.
@@ -78,6 +85,9 @@ func MakeDispatchMap(
 			bytes, statusCode, err := srvTRState(params, localStates, combinedStates, peerStates)
 			return WrapErrStatusCode(errorCount, path, bytes, statusCode, err)
 		}, rfc.ApplicationJSON)),
+		"/publish/CrStates/stream": wrap(func(w http.ResponseWriter, r *http.Request) {
+			srvTRStateStream(w, r, errorCount, crStatesFeed, peerStates, writeTimeout)
+		}),
 		"/publish/CacheStatsNew": wrap(WrapParams(func(params url.Values, path string) ([]byte, int) {
 			return srvCacheStats(params, errorCount, path, toData, statResultHistory, statInfoHistory, monitorConfig, combinedStates, statMaxKbpses)
 		}, rfc.ApplicationJSON)),
@@ -90,8 +100,11 @@ func MakeDispatchMap(
 		"/publish/EventLog": wrap(WrapErr(errorCount, func() ([]byte, error) {
 			return srvEventLog(events)
 		}, rfc.ApplicationJSON)),
+		"/api/events": wrap(WrapParams(func(params url.Values, path string) ([]byte, int) {
+			return srvEvents(params, errorCount, path, toData, events)
+		}, rfc.ApplicationJSON)),
 		"/publish/PeerStates": wrap(WrapParams(func(params url.Values, path string) ([]byte, int) {
-			return srvPeerStates(params, errorCount, path, toData, peerStates)
+			return srvPeerStates(params, errorCount, path, toData, peerStates, quorumStatus)
 		}, rfc.ApplicationJSON)),
 		"/publish/Stats": wrap(WrapErr(errorCount, func() ([]byte, error) {
 			return srvStats(staticAppData, healthPollInterval, lastHealthDurations, fetchCount, healthIteration, errorCount, peerStates)
@@ -118,7 +131,7 @@ func MakeDispatchMap(
 			return srvAPITrafficOpsURI(opsConfig)
 		}, rfc.ContentTypeURIList)),
 		"/api/cache-statuses": wrap(WrapErr(errorCount, func() ([]byte, error) {
-			return srvAPICacheStates(toData, statInfoHistory, statResultHistory, healthHistory, lastHealthDurations, localStates, lastStats, localCacheStatus, statMaxKbpses, monitorConfig)
+			return srvAPICacheStates(toData, statInfoHistory, statResultHistory, healthHistory, lastHealthDurations, localStates, lastStats, localCacheStatus, statMaxKbpses, monitorConfig, quorumStatus)
 		}, rfc.ApplicationJSON)),
 		"/api/bandwidth-kbps": wrap(WrapBytes(func() []byte {
 			return srvAPIBandwidthKbps(toData, lastStats)
@@ -132,6 +145,11 @@ func MakeDispatchMap(
 		"/api/crconfig-history": wrap(WrapErr(errorCount, func() ([]byte, error) {
 			return srvAPICRConfigHist(toSession)
 		}, rfc.ApplicationJSON)),
+		cdnPathPrefix: wrap(makeCDNHandler(opsConfig, extraCDNs, toSession, toData, localStates, combinedStates, peerStates, quorumStatus, errorCount)),
+		// maintenance windows aren't wrapped in the unpolled check, so they can be scheduled, and shared with peers, before all caches are polled.
+		"/api/maintenance": func(w http.ResponseWriter, r *http.Request) {
+			srvMaintenance(w, r, errorCount, maintenanceWindows, toData, maintenanceAPIToken)
+		},
 	}
 	return addTrailingSlashEndpoints(dispatchMap)
 }
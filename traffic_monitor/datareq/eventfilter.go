@@ -0,0 +1,201 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package datareq
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_monitor/health"
+	"github.com/apache/trafficcontrol/traffic_monitor/todata"
+)
+
+// EventFilter fulfills the health.EventFilter interface, for filtering events. See the `NewEventFilter` documentation for details on which query parameters are used to filter.
+type EventFilter struct {
+	caches            map[string]struct{}
+	cachegroups       map[tc.CacheGroupName]struct{}
+	deliveryServices  map[tc.DeliveryServiceName]struct{}
+	dsCaches          map[tc.CacheName]struct{}
+	types             map[string]struct{}
+	available         *bool
+	start             time.Time
+	end               time.Time
+	limit             uint64
+	serverCachegroups map[tc.CacheName]tc.CacheGroupName
+}
+
+// UseEvent returns whether the given event is in the filter.
+func (f *EventFilter) UseEvent(e health.Event) bool {
+	if _, ok := f.caches[e.Hostname]; len(f.caches) != 0 && !ok {
+		return false
+	}
+	if len(f.cachegroups) != 0 {
+		cachegroup := tc.CacheGroupName(e.CacheGroup)
+		if cachegroup == "" {
+			cachegroup = f.serverCachegroups[tc.CacheName(e.Hostname)] // events persisted before cachegroups were recorded
+		}
+		if _, ok := f.cachegroups[cachegroup]; !ok {
+			return false
+		}
+	}
+	if len(f.deliveryServices) != 0 {
+		_, isDS := f.deliveryServices[tc.DeliveryServiceName(e.Name)]
+		_, isDSCache := f.dsCaches[tc.CacheName(e.Hostname)]
+		if !isDS && !isDSCache {
+			return false
+		}
+	}
+	if _, ok := f.types[strings.ToLower(e.Type)]; len(f.types) != 0 && !ok {
+		return false
+	}
+	if f.available != nil && *f.available != e.Available {
+		return false
+	}
+	return true
+}
+
+// Within returns whether the given time is within the filter's time range.
+func (f *EventFilter) Within(t time.Time) bool {
+	return !f.Before(t) && (f.end.IsZero() || !t.After(f.end))
+}
+
+// Before returns whether the given time is before the start of the filter's time range.
+func (f *EventFilter) Before(t time.Time) bool {
+	return !f.start.IsZero() && t.Before(f.start)
+}
+
+// Limit returns the maximum number of events to return, or 0 for no limit.
+func (f *EventFilter) Limit() uint64 {
+	return f.limit
+}
+
+// NewEventFilter takes the HTTP query parameters and creates an EventFilter which fulfills the `health.EventFilter` interface, filtering according to the query parameters passed.
+// Query parameters used are `cache`, `cachegroup`, `deliveryservice`, `type`, `available`, `start`, `end`, and `limit`.
+// The `cache`, `cachegroup`, `deliveryservice`, and `type` parameters are comma-delimited lists; an event matching any member of a list matches that parameter.
+// The `deliveryservice` parameter matches the Delivery Service's own events, and the events of the caches currently assigned to it.
+// The `start` and `end` parameters are either unix epoch seconds, or RFC3339 times.
+// If `limit` is empty or 0, all matching events are returned.
+func NewEventFilter(path string, params url.Values, toData todata.TOData) (*EventFilter, error) {
+	validParams := map[string]struct{}{
+		"cache":           struct{}{},
+		"cachegroup":      struct{}{},
+		"deliveryservice": struct{}{},
+		"type":            struct{}{},
+		"available":       struct{}{},
+		"start":           struct{}{},
+		"end":             struct{}{},
+		"limit":           struct{}{},
+	}
+	for param := range params {
+		if _, ok := validParams[param]; !ok {
+			return nil, fmt.Errorf("invalid query parameter '%v'", param)
+		}
+	}
+
+	filter := &EventFilter{
+		caches:            map[string]struct{}{},
+		cachegroups:       map[tc.CacheGroupName]struct{}{},
+		deliveryServices:  map[tc.DeliveryServiceName]struct{}{},
+		dsCaches:          map[tc.CacheName]struct{}{},
+		types:             map[string]struct{}{},
+		serverCachegroups: toData.ServerCachegroups,
+	}
+
+	for _, cache := range splitEventParam(params, "cache") {
+		filter.caches[cache] = struct{}{}
+	}
+	if pathArgument := getPathArgument(path); pathArgument != "" {
+		filter.caches[pathArgument] = struct{}{}
+	}
+	for _, cachegroup := range splitEventParam(params, "cachegroup") {
+		filter.cachegroups[tc.CacheGroupName(cachegroup)] = struct{}{}
+	}
+	for _, ds := range splitEventParam(params, "deliveryservice") {
+		dsName := tc.DeliveryServiceName(ds)
+		filter.deliveryServices[dsName] = struct{}{}
+		for _, cache := range toData.DeliveryServiceServers[dsName] {
+			filter.dsCaches[cache] = struct{}{}
+		}
+	}
+	for _, eventType := range splitEventParam(params, "type") {
+		filter.types[strings.ToLower(eventType)] = struct{}{}
+	}
+
+	if available := params.Get("available"); available != "" {
+		v, err := strconv.ParseBool(available)
+		if err != nil {
+			return nil, fmt.Errorf("invalid query parameter available '%v' - must be a boolean", available)
+		}
+		filter.available = &v
+	}
+
+	var err error
+	if filter.start, err = parseEventTime(params.Get("start")); err != nil {
+		return nil, fmt.Errorf("invalid query parameter start: %v", err)
+	}
+	if filter.end, err = parseEventTime(params.Get("end")); err != nil {
+		return nil, fmt.Errorf("invalid query parameter end: %v", err)
+	}
+	if !filter.start.IsZero() && !filter.end.IsZero() && filter.end.Before(filter.start) {
+		return nil, fmt.Errorf("invalid query parameters: end is before start")
+	}
+
+	if limit := params.Get("limit"); limit != "" {
+		if filter.limit, err = strconv.ParseUint(limit, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid query parameter limit '%v' - must be a non-negative integer", limit)
+		}
+	}
+
+	return filter, nil
+}
+
+// splitEventParam returns the members of the comma-delimited lists of all the
+// values of the given query parameter.
+func splitEventParam(params url.Values, param string) []string {
+	members := []string{}
+	for _, val := range params[param] {
+		for _, member := range strings.Split(val, ",") {
+			if member = strings.TrimSpace(member); member != "" {
+				members = append(members, member)
+			}
+		}
+	}
+	return members
+}
+
+// parseEventTime parses a time given as unix epoch seconds, or as an RFC3339
+// time. The empty string is the zero time.
+func parseEventTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if unixTime, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(unixTime, 0), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("'%v' must be a unix epoch integer or an RFC3339 time", s)
+	}
+	return t, nil
+}
@@ -0,0 +1,107 @@
+package datareq
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_monitor/health"
+	"github.com/apache/trafficcontrol/traffic_monitor/todata"
+)
+
+func TestEventFilter(t *testing.T) {
+	toData := todata.New()
+	toData.ServerCachegroups["edge-0"] = "cg-east"
+	toData.ServerCachegroups["edge-1"] = "cg-west"
+	toData.DeliveryServiceServers["demo1"] = []tc.CacheName{"edge-1"}
+
+	now := time.Now()
+	events := []health.Event{
+		{Time: health.Time(now), Name: "edge-0", Hostname: "edge-0", Type: "EDGE", CacheGroup: "cg-east"},
+		{Time: health.Time(now), Name: "edge-0", Hostname: "edge-0", Type: "EDGE", Available: true}, // persisted without a cachegroup
+		{Time: health.Time(now), Name: "edge-1", Hostname: "edge-1", Type: "EDGE", CacheGroup: "cg-west"},
+		{Time: health.Time(now), Name: "demo1", Hostname: "demo1", Type: "DELIVERYSERVICE"},
+		{Time: health.Time(now), Name: "tm-1", Hostname: "tm-1", Type: "PEER"},
+	}
+
+	tests := []struct {
+		query    string
+		expected []int
+	}{
+		{"", []int{0, 1, 2, 3, 4}},
+		{"cache=edge-0", []int{0, 1}},
+		{"cache=edge-0,edge-1", []int{0, 1, 2}},
+		{"cachegroup=cg-east", []int{0, 1}},
+		{"deliveryservice=demo1", []int{2, 3}},
+		{"type=peer", []int{4}},
+		{"cache=edge-0&available=true", []int{1}},
+	}
+	for _, test := range tests {
+		params, err := url.ParseQuery(test.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		filter, err := NewEventFilter("/api/events", params, *toData)
+		if err != nil {
+			t.Errorf("query '%s' - expected: no error, actual: %v", test.query, err)
+			continue
+		}
+		matched := []int{}
+		for i, e := range events {
+			if filter.UseEvent(e) {
+				matched = append(matched, i)
+			}
+		}
+		if len(matched) != len(test.expected) {
+			t.Errorf("query '%s' - expected events: %v, actual: %v", test.query, test.expected, matched)
+			continue
+		}
+		for i := range matched {
+			if matched[i] != test.expected[i] {
+				t.Errorf("query '%s' - expected events: %v, actual: %v", test.query, test.expected, matched)
+				break
+			}
+		}
+	}
+}
+
+func TestEventFilterTimeRange(t *testing.T) {
+	params := url.Values{"start": {"1600000000"}, "end": {"2020-09-13T13:00:00Z"}}
+	filter, err := NewEventFilter("/api/events", params, *todata.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !filter.Before(time.Unix(1599999999, 0)) || filter.Before(time.Unix(1600000000, 0)) {
+		t.Errorf("expected times before 1600000000 to be before the filter")
+	}
+	if !filter.Within(time.Unix(1600000000, 0)) || filter.Within(time.Unix(1600002001, 0)) {
+		t.Errorf("expected times from 1600000000 to 2020-09-13T13:00:00Z to be within the filter")
+	}
+
+	for _, query := range []string{"start=yesterday", "limit=-1", "available=maybe", "foo=bar", "start=1600000000&end=1500000000"} {
+		params, _ := url.ParseQuery(query)
+		if _, err := NewEventFilter("/api/events", params, *todata.New()); err == nil {
+			t.Errorf("query '%s' - expected: error, actual: nil", query)
+		}
+	}
+}
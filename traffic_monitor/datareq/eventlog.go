@@ -20,7 +20,12 @@
 package datareq
 
 import (
+	"net/http"
+	"net/url"
+
 	"github.com/apache/trafficcontrol/traffic_monitor/health"
+	"github.com/apache/trafficcontrol/traffic_monitor/threadsafe"
+	"github.com/apache/trafficcontrol/traffic_monitor/todata"
 
 	"github.com/json-iterator/go"
 )
@@ -34,3 +39,18 @@ func srvEventLog(events health.ThreadsafeEvents) ([]byte, error) {
 	json := jsoniter.ConfigFastest
 	return json.Marshal(JSONEvents{Events: events.Get()})
 }
+
+func srvEvents(params url.Values, errorCount threadsafe.Uint, path string, toData todata.TODataThreadsafe, events health.ThreadsafeEvents) ([]byte, int) {
+	filter, err := NewEventFilter(path, params, toData.Get())
+	if err != nil {
+		HandleErr(errorCount, path, err)
+		return []byte(err.Error()), http.StatusBadRequest
+	}
+	matched, err := events.Query(filter)
+	if err != nil {
+		return WrapErrCode(errorCount, path, nil, err)
+	}
+	json := jsoniter.ConfigFastest
+	bytes, err := json.Marshal(JSONEvents{Events: matched})
+	return WrapErrCode(errorCount, path, bytes, err)
+}
@@ -0,0 +1,139 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package datareq
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-log"
+	"github.com/apache/trafficcontrol/lib/go-rfc"
+	"github.com/apache/trafficcontrol/traffic_monitor/maintenance"
+	"github.com/apache/trafficcontrol/traffic_monitor/threadsafe"
+	"github.com/apache/trafficcontrol/traffic_monitor/todata"
+)
+
+// srvMaintenance serves the maintenance windows. GET returns every window, including recently deleted ones, and the caches currently drained. POST creates a window from the request body, and DELETE deletes the window given by the `id` query parameter.
+//
+// Creating and deleting windows requires the given token as a Bearer token in the Authorization header. If the token is empty, windows can't be created or deleted over HTTP.
+func srvMaintenance(w http.ResponseWriter, r *http.Request, errorCount threadsafe.Uint, windows *maintenance.Windows, toData todata.TODataThreadsafe, token string) {
+	path := r.URL.EscapedPath()
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		if code := maintenanceAuth(r, token); code != http.StatusOK {
+			writeMaintenanceErr(w, path, code, errors.New(http.StatusText(code)))
+			return
+		}
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		status := maintenance.Status{
+			Windows: windows.All(),
+			Drained: windows.Drained(time.Now(), toData.Get().ServerCachegroups),
+		}
+		writeMaintenanceJSON(w, r, errorCount, http.StatusOK, status)
+	case http.MethodPost:
+		window := maintenance.Window{}
+		if err := json.NewDecoder(r.Body).Decode(&window); err != nil {
+			writeMaintenanceErr(w, path, http.StatusBadRequest, errors.New("decoding maintenance window: "+err.Error()))
+			return
+		}
+		if window.Start.IsZero() {
+			window.Start = time.Now()
+		}
+		if window.Cache != "" {
+			if _, ok := toData.Get().ServerTypes[window.Cache]; !ok {
+				writeMaintenanceErr(w, path, http.StatusBadRequest, errors.New("cache '"+string(window.Cache)+"' not found"))
+				return
+			}
+		}
+		window, err := windows.Add(window)
+		if window.ID == "" {
+			writeMaintenanceErr(w, path, http.StatusBadRequest, err)
+			return
+		}
+		if err != nil {
+			HandleErr(errorCount, path, err)
+		}
+		log.Infof("maintenance window %s created for cache '%s' cachegroup '%s': %s", window.ID, window.Cache, window.CacheGroup, window.Reason)
+		writeMaintenanceJSON(w, r, errorCount, http.StatusCreated, window)
+	case http.MethodDelete:
+		id := r.URL.Query().Get("id")
+		if id == "" {
+			writeMaintenanceErr(w, path, http.StatusBadRequest, errors.New("missing id parameter"))
+			return
+		}
+		ok, err := windows.Delete(id)
+		if !ok {
+			writeMaintenanceErr(w, path, http.StatusNotFound, errors.New("maintenance window '"+id+"' not found"))
+			return
+		}
+		if err != nil {
+			HandleErr(errorCount, path, err)
+		}
+		log.Infof("maintenance window %s deleted", id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", strings.Join([]string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodDelete}, ", "))
+		writeMaintenanceErr(w, path, http.StatusMethodNotAllowed, errors.New(http.StatusText(http.StatusMethodNotAllowed)))
+	}
+}
+
+// maintenanceAuth returns http.StatusOK if the request has the given Bearer token, else the status code to return.
+func maintenanceAuth(r *http.Request, token string) int {
+	if token == "" {
+		return http.StatusForbidden
+	}
+	const prefix = "Bearer "
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, prefix) || subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, prefix)), []byte(token)) != 1 {
+		return http.StatusUnauthorized
+	}
+	return http.StatusOK
+}
+
+// writeMaintenanceErr writes the given error status code, with the error as the body. Callers must not include internal errors, which must be logged and not returned to clients.
+func writeMaintenanceErr(w http.ResponseWriter, path string, code int, err error) {
+	w.Header().Set(rfc.ContentType, rfc.ContentTypeTextPlain)
+	w.WriteHeader(code)
+	log.Write(w, []byte(err.Error()), path)
+}
+
+func writeMaintenanceJSON(w http.ResponseWriter, r *http.Request, errorCount threadsafe.Uint, code int, obj interface{}) {
+	path := r.URL.EscapedPath()
+	bts, err := json.Marshal(obj)
+	if err != nil {
+		HandleErr(errorCount, path, errors.New("marshalling maintenance response: "+err.Error()))
+		w.WriteHeader(http.StatusInternalServerError)
+		log.Write(w, []byte(http.StatusText(http.StatusInternalServerError)), path)
+		return
+	}
+	w.Header().Set(rfc.ContentType, rfc.ApplicationJSON)
+	w.WriteHeader(code)
+	if r.Method == http.MethodHead {
+		return
+	}
+	log.Write(w, bts, path)
+}
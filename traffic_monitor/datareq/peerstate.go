@@ -32,10 +32,11 @@ import (
 	jsoniter "github.com/json-iterator/go"
 )
 
-// APIPeerStates contains the data to be returned for an API call to get the peer states of a Traffic Monitor. This contains common API data returned by most endpoints, and a map of peers, to caches' states.
+// APIPeerStates contains the data to be returned for an API call to get the peer states of a Traffic Monitor. This contains common API data returned by most endpoints, a map of peers, to caches' states, and the peer quorum status, including whether the peers are split-brained.
 type APIPeerStates struct {
 	tc.CommonAPIData
-	Peers map[tc.TrafficMonitorName]map[tc.CacheName][]CacheState `json:"peers"`
+	Peers  map[tc.TrafficMonitorName]map[tc.CacheName][]CacheState `json:"peers"`
+	Quorum peer.QuorumStatus                                       `json:"quorum"`
 }
 
 // CacheState represents the available state of a cache.
@@ -45,14 +46,16 @@ type CacheState struct {
 	Ipv6Available bool `json:"ipv6Available"`
 }
 
-func srvPeerStates(params url.Values, errorCount threadsafe.Uint, path string, toData todata.TODataThreadsafe, peerStates peer.CRStatesPeersThreadsafe) ([]byte, int) {
+func srvPeerStates(params url.Values, errorCount threadsafe.Uint, path string, toData todata.TODataThreadsafe, peerStates peer.CRStatesPeersThreadsafe, quorumStatus peer.QuorumStatusThreadsafe) ([]byte, int) {
 	filter, err := NewPeerStateFilter(path, params, toData.Get().ServerTypes)
 	if err != nil {
 		HandleErr(errorCount, path, err)
 		return []byte(err.Error()), http.StatusBadRequest
 	}
 	json := jsoniter.ConfigFastest
-	bytes, err := json.Marshal(createAPIPeerStates(peerStates.GetCrstates(), peerStates.GetPeersOnline(), filter, params))
+	apiPeerStates := createAPIPeerStates(peerStates.GetCrstates(), peerStates.GetPeersOnline(), filter, params)
+	apiPeerStates.Quorum = quorumStatus.Get()
+	bytes, err := json.Marshal(apiPeerStates)
 	return WrapErrCode(errorCount, path, bytes, err)
 }
 
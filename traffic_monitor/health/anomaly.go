@@ -0,0 +1,268 @@
+package health
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-log"
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/lib/go-util"
+	"github.com/apache/trafficcontrol/traffic_monitor/cache"
+	"github.com/apache/trafficcontrol/traffic_monitor/threadsafe"
+)
+
+// DefaultAnomalySamples is the number of polls anomaly baselines are learned
+// from, if a cache's profile has no health.anomaly.samples Parameter.
+const DefaultAnomalySamples = 60
+
+// AnomalyMinSamples is the fewest samples a baseline must have before a stat
+// is compared to it, or the profile's number of samples if that's fewer.
+const AnomalyMinSamples = 10
+
+// AnomalyMinDeviationRatio is the smallest standard deviation a baseline is
+// considered to have, as a ratio of its mean. This keeps stats which barely
+// vary from being anomalous at any small change.
+const AnomalyMinDeviationRatio = 0.1
+
+// anomalousSpan is the Span of a baseline sample which was anomalous. Such a
+// sample is kept, so consecutive anomalous polls can be counted, and as the
+// counter value the next rate is computed from, but its value or rate is left
+// out of the baseline, unless the stat stays anomalous long enough to be
+// relearned. Other baseline samples have a Span of 1.
+const anomalousSpan = 0
+
+// EvalAnomalies compares each stat of the given result which has an anomaly
+// threshold in the cache's profile to the baseline learned from its recent
+// values in baselines, and then adds the result's value to the baseline, unless
+// it was anomalous, so that a short anomaly doesn't become the baseline it's
+// judged by. If a stat is anomalous for the profile's number of relearn polls in
+// a row, it's considered to have changed for good, and its anomalous values are
+// learned as its new baseline. It
+// returns a description of why the first stat (by name) which deviated above
+// its baseline by more than its threshold is anomalous, and that stat; or
+// empty strings if none did.
+//
+// Like the ResultStatValHistory it learns from, this MUST NOT be called by
+// multiple goroutines for the same cache.
+func EvalAnomalies(result cache.Result, baselines threadsafe.ResultStatValHistory, mc *tc.TrafficMonitorConfigMap) (string, string) {
+	if result.Error != nil {
+		return "", ""
+	}
+	serverInfo, ok := mc.TrafficServer[result.ID]
+	if !ok {
+		return "", ""
+	}
+	profile, ok := mc.Profile[serverInfo.Profile]
+	if !ok || len(profile.Parameters.AnomalyThresholds) == 0 {
+		return "", ""
+	}
+
+	samples := profile.Parameters.AnomalySamples
+	if samples < 1 {
+		samples = DefaultAnomalySamples
+	}
+	relearnPolls := profile.Parameters.AnomalyRelearnPolls
+	if relearnPolls < 1 {
+		relearnPolls = samples
+	}
+
+	stats := make([]string, 0, len(profile.Parameters.AnomalyThresholds))
+	for stat := range profile.Parameters.AnomalyThresholds {
+		stats = append(stats, stat)
+	}
+	sort.Strings(stats)
+
+	computedStats := cache.ComputedStats()
+	resultInfo := cache.ToInfo(result)
+	why, unavailableStat := "", ""
+	for _, stat := range stats {
+		resultStat := interface{}(nil)
+		if computedStatF, ok := computedStats[stat]; ok {
+			resultStat = computedStatF(resultInfo, serverInfo, profile, dummyCombinedState)
+		} else if resultStat, ok = result.Miscellaneous[stat]; !ok {
+			continue
+		}
+		val, ok := util.ToNumeric(resultStat)
+		if !ok {
+			log.Errorf("health.EvalAnomalies anomaly threshold stat %s was not a number: %v", stat, resultStat)
+			continue
+		}
+
+		threshold := profile.Parameters.AnomalyThresholds[stat]
+		history := baselines.Load(stat)
+		statWhy := evalAnomaly(stat, threshold, val, result.Time, history, samples)
+
+		span := uint64(1)
+		learned := samples - 1
+		if statWhy != "" {
+			if anomalous := consecutiveAnomalous(history); anomalous+1 >= relearnPolls {
+				log.Infof("health.EvalAnomalies cache %s stat %s anomalous for %d polls, learning it as the new baseline", result.ID, stat, anomalous+1)
+				history = relearnAnomalous(history, anomalous)
+				statWhy = ""
+			} else {
+				span = anomalousSpan
+				learned = samples // anomalous samples don't push the baseline out
+			}
+		}
+		if statWhy != "" && why == "" {
+			why, unavailableStat = statWhy, stat
+		}
+		baselines.Store(stat, append([]tc.ResultStatVal{{Val: val, Time: result.Time, Span: span}}, trimBaseline(history, learned)...))
+	}
+	return why, unavailableStat
+}
+
+// consecutiveAnomalous returns the number of anomalous samples at the start of
+// the given history, newest first, which is the number of polls in a row the
+// stat has been anomalous.
+func consecutiveAnomalous(history []tc.ResultStatVal) int {
+	n := 0
+	for n < len(history) && history[n].Span == anomalousSpan {
+		n++
+	}
+	return n
+}
+
+// relearnAnomalous returns a copy of the given history with its first n samples
+// no longer anomalous, so they're learned into the baseline.
+func relearnAnomalous(history []tc.ResultStatVal, n int) []tc.ResultStatVal {
+	relearned := make([]tc.ResultStatVal, len(history))
+	copy(relearned, history)
+	for i := 0; i < n; i++ {
+		relearned[i].Span = 1
+	}
+	return relearned
+}
+
+// trimBaseline returns the start of the given history, newest first, with at
+// most the given number of samples which aren't anomalous. Anomalous samples
+// older than those are also removed.
+func trimBaseline(history []tc.ResultStatVal, learned int) []tc.ResultStatVal {
+	n := 0
+	for i, sample := range history {
+		if sample.Span == anomalousSpan {
+			continue
+		}
+		if n == learned {
+			return history[:i]
+		}
+		n++
+	}
+	return history
+}
+
+// evalAnomaly compares the given value of stat, polled at the given time, to
+// the baseline of its history, newest first. It returns why the value is
+// anomalous, or an empty string if it isn't, or there isn't enough history to
+// tell.
+func evalAnomaly(stat string, threshold tc.AnomalyThreshold, val float64, t time.Time, history []tc.ResultStatVal, samples int) string {
+	baseline := make([]float64, 0, len(history))
+	if !threshold.Rate {
+		for _, sample := range history {
+			if sample.Span == anomalousSpan {
+				continue
+			}
+			if v, ok := util.ToNumeric(sample.Val); ok {
+				baseline = append(baseline, v)
+			}
+		}
+	} else {
+		if len(history) == 0 {
+			return ""
+		}
+		rate, ok := counterRate(history[0], val, t)
+		if !ok {
+			return "" // e.g. the counter was reset
+		}
+		val = rate
+		for i := 0; i+1 < len(history); i++ {
+			if history[i].Span == anomalousSpan {
+				continue
+			}
+			if v, ok := util.ToNumeric(history[i].Val); ok {
+				if rate, ok := counterRate(history[i+1], v, history[i].Time); ok {
+					baseline = append(baseline, rate)
+				}
+			}
+		}
+		samples-- // n values have n-1 rates between them
+	}
+
+	minSamples := AnomalyMinSamples
+	if samples < minSamples {
+		minSamples = samples
+	}
+	if minSamples < 2 {
+		minSamples = 2
+	}
+	if len(baseline) < minSamples {
+		return ""
+	}
+
+	mean, stdDev := meanStdDev(baseline)
+	if minStdDev := AnomalyMinDeviationRatio * math.Abs(mean); stdDev < minStdDev {
+		stdDev = minStdDev
+	}
+	if stdDev == 0 {
+		return "" // a baseline which never varies from 0 can't be deviated from meaningfully
+	}
+	if val <= mean+threshold.Deviations*stdDev {
+		return ""
+	}
+
+	if threshold.Rate {
+		return fmt.Sprintf("%s rate anomalous (%.2f/s > baseline %.2f/s + %g standard deviations of %.2f/s over %d polls)", stat, val, mean, threshold.Deviations, stdDev, len(baseline))
+	}
+	return fmt.Sprintf("%s anomalous (%.2f > baseline %.2f + %g standard deviations of %.2f over %d polls)", stat, val, mean, threshold.Deviations, stdDev, len(baseline))
+}
+
+// counterRate returns the per-second rate of change of a counter from the
+// given previous sample to the value polled at the given time. It returns
+// false if there is no rate, because no time passed or the counter decreased.
+func counterRate(prev tc.ResultStatVal, val float64, t time.Time) (float64, bool) {
+	prevVal, ok := util.ToNumeric(prev.Val)
+	if !ok {
+		return 0, false
+	}
+	secs := t.Sub(prev.Time).Seconds()
+	if secs <= 0 || val < prevVal {
+		return 0, false
+	}
+	return (val - prevVal) / secs, true
+}
+
+// meanStdDev returns the mean and population standard deviation of vals,
+// which must not be empty.
+func meanStdDev(vals []float64) (float64, float64) {
+	sum := 0.0
+	for _, v := range vals {
+		sum += v
+	}
+	mean := sum / float64(len(vals))
+	sqDiffs := 0.0
+	for _, v := range vals {
+		sqDiffs += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(sqDiffs / float64(len(vals)))
+}
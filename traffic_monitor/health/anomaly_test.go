@@ -0,0 +1,205 @@
+package health
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_monitor/cache"
+	"github.com/apache/trafficcontrol/traffic_monitor/threadsafe"
+)
+
+func anomalyTestConfig(cacheName string, thresholds map[string]tc.AnomalyThreshold) tc.TrafficMonitorConfigMap {
+	return tc.TrafficMonitorConfigMap{
+		TrafficServer: map[string]tc.TrafficServer{
+			cacheName: {ServerStatus: string(tc.CacheStatusReported), Profile: "edgeProfile"},
+		},
+		Profile: map[string]tc.TMProfile{
+			"edgeProfile": {Name: "edgeProfile", Parameters: tc.TMParameters{AnomalyThresholds: thresholds, AnomalySamples: 20}},
+		},
+	}
+}
+
+func TestEvalAnomaliesValue(t *testing.T) {
+	cacheName := "edge"
+	mc := anomalyTestConfig(cacheName, map[string]tc.AnomalyThreshold{"errors": {Deviations: 3}})
+	baselines := threadsafe.NewResultStatValHistory()
+	start := time.Now()
+	eval := func(i int, val float64) (string, string) {
+		result := cache.Result{ID: cacheName, Time: start.Add(time.Duration(i) * time.Second), Miscellaneous: map[string]interface{}{"errors": val}}
+		return EvalAnomalies(result, baselines, &mc)
+	}
+
+	if why, _ := eval(0, 100); why != "" {
+		t.Errorf("anomaly without a baseline - expected: none, actual: %s", why)
+	}
+	for i := 1; i < 25; i++ {
+		val := 9.0
+		if i%2 == 0 {
+			val = 11.0
+		}
+		if why, _ := eval(i, val); why != "" && i > 10 {
+			t.Errorf("anomaly within the baseline at poll %d - expected: none, actual: %s", i, why)
+		}
+	}
+	if history := baselines.Load("errors"); len(history) != 20 {
+		t.Errorf("baseline samples - expected: 20, actual: %d", len(history))
+	}
+
+	if why, _ := eval(25, 12); why != "" {
+		t.Errorf("anomaly within 3 standard deviations - expected: none, actual: %s", why)
+	}
+	why, stat := eval(26, 20)
+	if stat != "errors" {
+		t.Errorf("anomalous stat - expected: errors, actual: '%s'", stat)
+	}
+	if !strings.HasPrefix(why, "errors anomalous (20.00 > baseline ") {
+		t.Errorf("anomaly explanation - expected: the exceeded baseline, actual: '%s'", why)
+	}
+
+	// a sustained anomaly isn't learned into the baseline until it's been anomalous for the relearn polls, which default to the samples
+	for i := 27; i < 45; i++ {
+		if why, _ := eval(i, 20); why == "" {
+			t.Fatalf("sustained anomaly at poll %d - expected: anomalous, actual: none", i)
+		}
+	}
+	if why, _ := eval(45, 20); why != "" {
+		t.Errorf("anomaly for the relearn polls - expected: learned as the new baseline, actual: %s", why)
+	}
+	for i := 46; i < 50; i++ {
+		if why, _ := eval(i, 20); why != "" {
+			t.Errorf("relearned baseline at poll %d - expected: no anomaly, actual: %s", i, why)
+		}
+	}
+}
+
+func TestEvalAnomaliesRelearnPolls(t *testing.T) {
+	cacheName := "edge"
+	mc := anomalyTestConfig(cacheName, map[string]tc.AnomalyThreshold{"errors": {Deviations: 3}})
+	profile := mc.Profile["edgeProfile"]
+	profile.Parameters.AnomalyRelearnPolls = 5
+	mc.Profile["edgeProfile"] = profile
+	baselines := threadsafe.NewResultStatValHistory()
+	start := time.Now()
+	eval := func(i int, val float64) (string, string) {
+		result := cache.Result{ID: cacheName, Time: start.Add(time.Duration(i) * time.Second), Miscellaneous: map[string]interface{}{"errors": val}}
+		return EvalAnomalies(result, baselines, &mc)
+	}
+
+	for i := 0; i < 20; i++ {
+		eval(i, 10+float64(i%2))
+	}
+
+	// anomalies which don't last for the relearn polls in a row aren't learned
+	for i := 20; i < 24; i++ {
+		if why, _ := eval(i, 50); why == "" {
+			t.Fatalf("anomaly at poll %d - expected: anomalous, actual: none", i)
+		}
+	}
+	eval(24, 10)
+	if why, _ := eval(25, 50); why == "" {
+		t.Error("anomaly after an interrupted anomaly - expected: anomalous, actual: none")
+	}
+	if history := baselines.Load("errors"); len(history) != 25 || consecutiveAnomalous(history) != 1 {
+		t.Errorf("baseline after interrupted anomaly - expected: 25 samples, 1 anomalous, actual: %d samples, %d anomalous", len(history), consecutiveAnomalous(history))
+	}
+
+	for i := 26; i < 29; i++ {
+		if why, _ := eval(i, 50); why == "" {
+			t.Fatalf("anomaly at poll %d - expected: anomalous, actual: none", i)
+		}
+	}
+	if why, _ := eval(29, 50); why != "" {
+		t.Errorf("anomaly for 5 polls in a row - expected: learned as the new baseline, actual: %s", why)
+	}
+	if history := baselines.Load("errors"); consecutiveAnomalous(history) != 0 {
+		t.Errorf("relearned baseline anomalous samples - expected: 0, actual: %d", consecutiveAnomalous(history))
+	}
+}
+
+func TestEvalAnomaliesRate(t *testing.T) {
+	cacheName := "edge"
+	mc := anomalyTestConfig(cacheName, map[string]tc.AnomalyThreshold{"errors": {Deviations: 3, Rate: true}})
+	baselines := threadsafe.NewResultStatValHistory()
+	start := time.Now()
+	eval := func(i int, val float64) (string, string) {
+		result := cache.Result{ID: cacheName, Time: start.Add(time.Duration(i) * time.Second), Miscellaneous: map[string]interface{}{"errors": val}}
+		return EvalAnomalies(result, baselines, &mc)
+	}
+
+	counter := 0.0
+	for i := 0; i < 30; i++ {
+		counter += 100
+		if i%2 == 0 {
+			counter += 20
+		}
+		if why, _ := eval(i, counter); why != "" {
+			t.Errorf("anomaly within the baseline rate at poll %d - expected: none, actual: %s", i, why)
+		}
+	}
+
+	// a large counter which grows at the usual rate isn't anomalous
+	counter += 110
+	if why, _ := eval(30, counter); why != "" {
+		t.Errorf("anomaly at the baseline rate - expected: none, actual: %s", why)
+	}
+
+	if why, _ := eval(31, 0); why != "" {
+		t.Errorf("anomaly at counter reset - expected: none, actual: %s", why)
+	}
+
+	why, stat := eval(32, 1000)
+	if stat != "errors" {
+		t.Errorf("anomalous stat - expected: errors, actual: '%s'", stat)
+	}
+	if !strings.HasPrefix(why, "errors rate anomalous (1000.00/s > baseline ") {
+		t.Errorf("anomaly explanation - expected: the exceeded baseline rate, actual: '%s'", why)
+	}
+
+	// a sustained anomalous rate isn't learned into the baseline until it's been anomalous for the relearn polls
+	counter = 1000.0
+	for i := 33; i < 51; i++ {
+		counter += 1000
+		if why, _ := eval(i, counter); why == "" {
+			t.Fatalf("sustained anomalous rate at poll %d - expected: anomalous, actual: none", i)
+		}
+	}
+	for i := 51; i < 60; i++ {
+		counter += 1000
+		if why, _ := eval(i, counter); why != "" {
+			t.Errorf("relearned baseline rate at poll %d - expected: no anomaly, actual: %s", i, why)
+		}
+	}
+}
+
+func TestEvalAnomaliesError(t *testing.T) {
+	cacheName := "edge"
+	mc := anomalyTestConfig(cacheName, map[string]tc.AnomalyThreshold{"errors": {Deviations: 3}})
+	baselines := threadsafe.NewResultStatValHistory()
+	result := cache.Result{ID: cacheName, Time: time.Now(), Miscellaneous: map[string]interface{}{"errors": 1}, Error: errors.New("polling failed")}
+	EvalAnomalies(result, baselines, &mc)
+	if history := baselines.Load("errors"); len(history) != 0 {
+		t.Errorf("baseline samples from a failed poll - expected: 0, actual: %d", len(history))
+	}
+}
@@ -209,6 +209,9 @@ func EvalAggregate(result cache.ResultInfo, resultStats *threadsafe.ResultStatVa
 	computedStats := cache.ComputedStats()
 
 	for stat, threshold := range profile.Parameters.Thresholds {
+		if strings.HasPrefix(stat, cache.ProbeStatPrefix) {
+			continue // synthetic probe thresholds are evaluated by EvalProbe
+		}
 		resultStat := interface{}(nil)
 		computedStatF, ok := computedStats[stat]
 		if !ok {
@@ -336,6 +339,12 @@ func CalcAvailability(
 
 		if statResultsVal != nil {
 			aggIsAvailable, aggWhyAvailable, aggUnavailableStat = EvalAggregate(cache.ToInfo(result), &statResultsVal.Stats, &mc)
+
+			// baselines are always learned, but like static thresholds, only caches which aren't ONLINE are judged by them
+			anomalyWhy, anomalyStat := EvalAnomalies(result, statResultsVal.Baselines, &mc)
+			if status := tc.CacheStatusFromString(serverInfo.ServerStatus); anomalyWhy != "" && aggIsAvailable && status != tc.CacheStatusOnline {
+				aggIsAvailable, aggWhyAvailable, aggUnavailableStat = false, eventDesc(status, anomalyWhy), anomalyStat
+			}
 		} else {
 			aggIsAvailable, aggWhyAvailable, aggUnavailableStat = EvalAggregate(cache.ToInfo(result), nil, &mc)
 		}
@@ -346,6 +355,12 @@ func CalcAvailability(
 			availStatus.Available.IPv6 = availStatus.Available.IPv6 && aggIsAvailable
 		}
 
+		// the profile check ignores stale failures of caches which are no longer probed
+		if probeWhy := localCacheStatusThreadsafe.ProbeFailure(result.ID); probeWhy != "" && mc.Profile[serverInfo.Profile].Parameters.HealthProbePath != "" {
+			availStatus.Available = cache.AvailableTuple{}
+			reasons = append(reasons, "probe: "+probeWhy)
+		}
+
 		availStatus.ProcessedAvailable = processAvailableTuple(availStatus.Available, serverInfo)
 
 		if aggWhyAvailable != "" {
@@ -376,6 +391,7 @@ func CalcAvailability(
 				Name:          result.ID,
 				Hostname:      result.ID,
 				Type:          toData.ServerTypes[tc.CacheName(result.ID)].String(),
+				CacheGroup:    string(toData.ServerCachegroups[tc.CacheName(result.ID)]),
 				Available:     availStatus.ProcessedAvailable,
 				IPv4Available: availStatus.Available.IPv4,
 				IPv6Available: availStatus.Available.IPv6,
@@ -444,12 +460,13 @@ func calculateDeliveryServiceState(deliveryServiceServers map[tc.DeliveryService
 			log.Infof("CRConfig does not have delivery service %s, but traffic monitor poller does; skipping\n", deliveryServiceName)
 			continue
 		}
-		deliveryServiceState.DisabledLocations = getDisabledLocations(deliveryServiceName, toData.DeliveryServiceServers[deliveryServiceName], cacheStates, toData.ServerCachegroups)
+		deliveryServiceState.DisabledLocations = GetDisabledLocations(deliveryServiceName, toData.DeliveryServiceServers[deliveryServiceName], cacheStates, toData.ServerCachegroups)
 		states.SetDeliveryService(deliveryServiceName, deliveryServiceState)
 	}
 }
 
-func getDisabledLocations(deliveryService tc.DeliveryServiceName, deliveryServiceServers []tc.CacheName, cacheStates map[tc.CacheName]tc.IsAvailable, serverCacheGroups map[tc.CacheName]tc.CacheGroupName) []tc.CacheGroupName {
+// GetDisabledLocations returns the cachegroups of the given delivery service servers in which none of the servers are available in cacheStates.
+func GetDisabledLocations(deliveryService tc.DeliveryServiceName, deliveryServiceServers []tc.CacheName, cacheStates map[tc.CacheName]tc.IsAvailable, serverCacheGroups map[tc.CacheName]tc.CacheGroupName) []tc.CacheGroupName {
 	disabledLocations := []tc.CacheGroupName{} // it's important this isn't nil, so it serialises to the JSON `[]` instead of `null`
 	dsCacheStates := getDeliveryServiceCacheAvailability(cacheStates, deliveryServiceServers)
 	dsCachegroupsAvailable := getDeliveryServiceCachegroupAvailability(dsCacheStates, serverCacheGroups)
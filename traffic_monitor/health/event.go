@@ -52,6 +52,7 @@ type Event struct {
 	Name          string `json:"name"`
 	Hostname      string `json:"hostname"`
 	Type          string `json:"type"`
+	CacheGroup    string `json:"cachegroup,omitempty"`
 	Available     bool   `json:"isAvailable"`
 	IPv4Available bool   `json:"ipv4Available"`
 	IPv6Available bool   `json:"ipv6Available"`
@@ -63,6 +64,9 @@ type ThreadsafeEvents struct {
 	m         *sync.RWMutex
 	nextIndex *uint64
 	max       uint64
+	store     *EventStore
+	// storeM serializes appends to the store, so events are persisted in index order without holding m during file I/O.
+	storeM *sync.Mutex
 }
 
 func copyEvents(a []Event) []Event {
@@ -74,7 +78,27 @@ func copyEvents(a []Event) []Event {
 // NewEvents creates a new single-writer-multiple-reader Threadsafe object
 func NewThreadsafeEvents(maxEvents uint64) ThreadsafeEvents {
 	i := uint64(0)
-	return ThreadsafeEvents{m: &sync.RWMutex{}, events: &[]Event{}, nextIndex: &i, max: maxEvents}
+	return ThreadsafeEvents{m: &sync.RWMutex{}, events: &[]Event{}, nextIndex: &i, max: maxEvents, storeM: &sync.Mutex{}}
+}
+
+// NewPersistedThreadsafeEvents creates a new single-writer-multiple-reader
+// Threadsafe object, which also persists added events to the given store. The
+// newest events in the store are loaded, so recent events and their indices
+// survive a restart.
+func NewPersistedThreadsafeEvents(maxEvents uint64, store *EventStore) ThreadsafeEvents {
+	events := NewThreadsafeEvents(maxEvents)
+	events.store = store
+
+	latest, err := store.Latest(maxEvents)
+	if err != nil {
+		log.Errorf("loading persisted events: %v", err)
+		return events
+	}
+	if len(latest) > 0 {
+		*events.events = latest
+		*events.nextIndex = latest[0].Index + 1
+	}
+	return events
 }
 
 // Get returns the internal slice of Events for reading. This MUST NOT be modified. If modification is necessary, copy the slice.
@@ -98,5 +122,41 @@ func (o *ThreadsafeEvents) Add(e Event) {
 	// o.m.Lock()
 	*o.events = events
 	*o.nextIndex++
+	if o.store == nil {
+		o.m.Unlock()
+		return
+	}
+	// Take the store lock before releasing m, so concurrent adds persist their events in index order, while readers aren't blocked by the file I/O.
+	o.storeM.Lock()
 	o.m.Unlock()
+	defer o.storeM.Unlock()
+	if err := o.store.Append(e); err != nil {
+		log.Errorf("persisting event: %v", err)
+	}
+}
+
+// Query returns the events matching the given filter, newest first. If events
+// are persisted, all retained events are queried; otherwise, only those
+// returned by Get.
+func (o *ThreadsafeEvents) Query(filter EventFilter) ([]Event, error) {
+	if o.store != nil {
+		return o.store.Query(filter)
+	}
+
+	limit := filter.Limit()
+	events := []Event{}
+	for _, e := range o.Get() {
+		t := time.Time(e.Time)
+		if filter.Before(t) {
+			break
+		}
+		if !filter.Within(t) || !filter.UseEvent(e) {
+			continue
+		}
+		events = append(events, e)
+		if limit != 0 && uint64(len(events)) >= limit {
+			break
+		}
+	}
+	return events, nil
 }
@@ -0,0 +1,266 @@
+package health
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-log"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+const eventStoreFilePrefix = "events-"
+const eventStoreFileSuffix = ".json"
+const eventStoreDayFormat = "20060102"
+
+// eventStoreMaxLineBytes is the longest event which can be read back from an
+// EventStore.
+const eventStoreMaxLineBytes = 1024 * 1024
+
+// EventFilter selects which Events are returned by a query.
+type EventFilter interface {
+	// UseEvent returns whether the given event should be returned.
+	UseEvent(e Event) bool
+	// Within returns whether the given time is within the time range being
+	// queried. Events are queried newest first, so once a query reaches an
+	// event before the range, no older events are considered.
+	Within(t time.Time) bool
+	// Before returns whether the given time is before the start of the time
+	// range being queried.
+	Before(t time.Time) bool
+	// Limit returns the maximum number of events to return, or 0 for no limit.
+	Limit() uint64
+}
+
+// EventStore persists Events to append-only files in a directory, one file
+// per day, with each Event stored as a line of JSON. Files older than the
+// retention are removed as new files are created.
+//
+// EventStore is safe for one writer and multiple readers.
+type EventStore struct {
+	dir       string
+	retention time.Duration
+	m         *sync.Mutex
+	file      *os.File
+	fileDay   string
+}
+
+// NewEventStore creates an EventStore persisting to the given directory,
+// creating it if it doesn't exist, and removing any files older than the
+// given retention. A retention of 0 keeps all events.
+func NewEventStore(dir string, retention time.Duration) (*EventStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.New("creating event store directory: " + err.Error())
+	}
+	s := &EventStore{dir: dir, retention: retention, m: &sync.Mutex{}}
+	s.prune(time.Now())
+	return s, nil
+}
+
+// Append persists the given event, in the file for the current day.
+func (s *EventStore) Append(e Event) error {
+	json := jsoniter.ConfigFastest
+	bts, err := json.Marshal(e)
+	if err != nil {
+		return errors.New("marshalling event: " + err.Error())
+	}
+	bts = append(bts, '\n')
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	now := time.Now()
+	day := now.UTC().Format(eventStoreDayFormat)
+	if s.file == nil || day != s.fileDay {
+		if s.file != nil {
+			if err := s.file.Close(); err != nil {
+				log.Warnf("closing event store file for %s: %v", s.fileDay, err)
+			}
+			s.file = nil
+		}
+		file, err := os.OpenFile(s.dayPath(day), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return errors.New("opening event store file: " + err.Error())
+		}
+		s.file = file
+		s.fileDay = day
+		s.prune(now)
+	}
+
+	if _, err := s.file.Write(bts); err != nil {
+		return errors.New("writing event store file: " + err.Error())
+	}
+	return nil
+}
+
+// Close closes the file currently being appended to. The EventStore may still
+// be appended to afterwards, which will reopen it.
+func (s *EventStore) Close() error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// Query returns the persisted events matching the given filter, newest first.
+//
+// Events are read without blocking Append, so an event being appended
+// concurrently may or may not be returned.
+func (s *EventStore) Query(filter EventFilter) ([]Event, error) {
+	days, err := s.days()
+	if err != nil {
+		return nil, err
+	}
+	cutoff := s.cutoff(time.Now())
+	limit := filter.Limit()
+
+	events := []Event{}
+	for i := len(days) - 1; i >= 0; i-- {
+		dayEvents, err := s.readDay(days[i])
+		if err != nil {
+			return nil, err
+		}
+		for j := len(dayEvents) - 1; j >= 0; j-- {
+			e := dayEvents[j]
+			t := time.Time(e.Time)
+			if !cutoff.IsZero() && t.Before(cutoff) {
+				continue
+			}
+			if !filter.Within(t) || !filter.UseEvent(e) {
+				continue
+			}
+			events = append(events, e)
+			if limit != 0 && uint64(len(events)) >= limit {
+				return events, nil
+			}
+		}
+		if len(dayEvents) > 0 && filter.Before(time.Time(dayEvents[0].Time)) {
+			break // events are appended in the order they occur, so no older day can match.
+		}
+	}
+	return events, nil
+}
+
+// Latest returns the newest n persisted events, newest first.
+func (s *EventStore) Latest(n uint64) ([]Event, error) {
+	return s.Query(latestEventFilter(n))
+}
+
+type latestEventFilter uint64
+
+func (f latestEventFilter) UseEvent(e Event) bool   { return true }
+func (f latestEventFilter) Within(t time.Time) bool { return true }
+func (f latestEventFilter) Before(t time.Time) bool { return false }
+func (f latestEventFilter) Limit() uint64           { return uint64(f) }
+
+// readDay returns the events persisted on the given day, oldest first.
+// Lines which can't be parsed, such as one partially written when Traffic
+// Monitor stopped, are skipped.
+func (s *EventStore) readDay(day string) ([]Event, error) {
+	file, err := os.Open(s.dayPath(day))
+	if os.IsNotExist(err) {
+		return nil, nil // removed by retention after it was listed
+	} else if err != nil {
+		return nil, errors.New("opening event store file: " + err.Error())
+	}
+	defer file.Close()
+
+	json := jsoniter.ConfigFastest
+	events := []Event{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), eventStoreMaxLineBytes)
+	for scanner.Scan() {
+		e := Event{}
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			log.Warnf("event store file %s: skipping malformed event: %v", file.Name(), err)
+			continue
+		}
+		events = append(events, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.New("reading event store file: " + err.Error())
+	}
+	return events, nil
+}
+
+// days returns the days which have event files, oldest first.
+func (s *EventStore) days() ([]string, error) {
+	names, err := filepath.Glob(filepath.Join(s.dir, eventStoreFilePrefix+"*"+eventStoreFileSuffix))
+	if err != nil {
+		return nil, errors.New("listing event store files: " + err.Error())
+	}
+	days := make([]string, 0, len(names))
+	for _, name := range names {
+		day := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(name), eventStoreFilePrefix), eventStoreFileSuffix)
+		if _, err := time.Parse(eventStoreDayFormat, day); err != nil {
+			continue
+		}
+		days = append(days, day)
+	}
+	sort.Strings(days)
+	return days, nil
+}
+
+// prune removes the files of days which ended before the retention.
+func (s *EventStore) prune(now time.Time) {
+	cutoff := s.cutoff(now)
+	if cutoff.IsZero() {
+		return
+	}
+	days, err := s.days()
+	if err != nil {
+		log.Errorf("pruning event store: %v", err)
+		return
+	}
+	for _, day := range days {
+		start, _ := time.Parse(eventStoreDayFormat, day) // days only returns parseable days
+		if !start.AddDate(0, 0, 1).Before(cutoff) {
+			break
+		}
+		if err := os.Remove(s.dayPath(day)); err != nil && !os.IsNotExist(err) {
+			log.Errorf("pruning event store file for %s: %v", day, err)
+		}
+	}
+}
+
+// cutoff returns the time before which events are no longer retained, or the
+// zero time if all events are retained.
+func (s *EventStore) cutoff(now time.Time) time.Time {
+	if s.retention <= 0 {
+		return time.Time{}
+	}
+	return now.Add(-s.retention)
+}
+
+func (s *EventStore) dayPath(day string) string {
+	return filepath.Join(s.dir, eventStoreFilePrefix+day+eventStoreFileSuffix)
+}
@@ -0,0 +1,186 @@
+package health
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testEventFilter struct {
+	name  string
+	start time.Time
+	limit uint64
+}
+
+func (f testEventFilter) UseEvent(e Event) bool   { return f.name == "" || e.Name == f.name }
+func (f testEventFilter) Within(t time.Time) bool { return !f.Before(t) }
+func (f testEventFilter) Before(t time.Time) bool { return !f.start.IsZero() && t.Before(f.start) }
+func (f testEventFilter) Limit() uint64           { return f.limit }
+
+func TestPersistedThreadsafeEvents(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tm-events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := NewEventStore(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := NewPersistedThreadsafeEvents(3, store)
+	now := time.Now()
+	for i, name := range []string{"edge-0", "edge-1", "edge-0", "edge-2", "edge-0"} {
+		events.Add(Event{Time: Time(now.Add(time.Duration(i-5) * time.Hour)), Name: name, Hostname: name, Type: "EDGE"})
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// simulate a restart
+	store, err = NewEventStore(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events = NewPersistedThreadsafeEvents(3, store)
+	if got := events.Get(); len(got) != 3 || got[0].Index != 4 || got[2].Index != 2 {
+		t.Fatalf("expected the newest 3 events with indices 4..2 to be loaded, actual: %+v", got)
+	}
+
+	events.Add(Event{Time: Time(now), Name: "edge-1", Hostname: "edge-1", Type: "EDGE"})
+	if got := events.Get(); got[0].Index != 5 {
+		t.Errorf("expected index 5 after restart, actual: %d", got[0].Index)
+	}
+
+	all, err := events.Query(testEventFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 6 {
+		t.Fatalf("expected 6 persisted events, actual: %d", len(all))
+	}
+	for i, e := range all {
+		if e.Index != uint64(5-i) {
+			t.Errorf("expected events newest first, event %d had index %d", i, e.Index)
+		}
+	}
+
+	edge0, err := events.Query(testEventFilter{name: "edge-0", start: now.Add(-3*time.Hour - time.Minute)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(edge0) != 2 || edge0[0].Index != 4 || edge0[1].Index != 2 {
+		t.Errorf("expected edge-0 events 4 and 2, actual: %+v", edge0)
+	}
+
+	limited, err := events.Query(testEventFilter{limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(limited) != 2 {
+		t.Errorf("expected 2 events with limit 2, actual: %d", len(limited))
+	}
+}
+
+func TestPersistedThreadsafeEventsConcurrentAdds(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tm-events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := NewEventStore(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := NewPersistedThreadsafeEvents(10, store)
+	now := time.Now()
+	wg := sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 25; j++ {
+				events.Add(Event{Time: Time(now), Name: "edge-0", Hostname: "edge-0", Type: "EDGE"})
+			}
+		}()
+	}
+	wg.Wait()
+
+	all, err := events.Query(testEventFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 100 {
+		t.Fatalf("expected 100 persisted events, actual: %d", len(all))
+	}
+	for i, e := range all {
+		if e.Index != uint64(99-i) {
+			t.Fatalf("expected events persisted in index order, event %d had index %d", i, e.Index)
+		}
+	}
+}
+
+func TestEventStoreRetention(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tm-events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	old := time.Now().AddDate(0, 0, -10).UTC().Format(eventStoreDayFormat)
+	oldPath := filepath.Join(dir, eventStoreFilePrefix+old+eventStoreFileSuffix)
+	if err := ioutil.WriteFile(oldPath, []byte(`{"time":1,"index":0,"name":"old"}`+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	recent := time.Now().AddDate(0, 0, -1).UTC().Format(eventStoreDayFormat)
+	recentPath := filepath.Join(dir, eventStoreFilePrefix+recent+eventStoreFileSuffix)
+	recentEvents := `{"time":` + toUnixString(time.Now().Add(-24*time.Hour)) + `,"index":1,"name":"recent"}` + "\n" + `{"time":` // partial write
+	if err := ioutil.WriteFile(recentPath, []byte(recentEvents), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewEventStore(dir, 7*24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	if _, err := os.Stat(oldPath); !os.IsNotExist(err) {
+		t.Errorf("expected the event file older than the retention to be removed, stat error: %v", err)
+	}
+	events, err := store.Latest(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Name != "recent" {
+		t.Errorf("expected only the recent event, skipping the malformed one, actual: %+v", events)
+	}
+}
+
+func toUnixString(t time.Time) string {
+	bts, _ := Time(t).MarshalJSON()
+	return string(bts)
+}
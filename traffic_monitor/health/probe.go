@@ -0,0 +1,135 @@
+package health
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/apache/trafficcontrol/lib/go-log"
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/lib/go-util"
+	"github.com/apache/trafficcontrol/traffic_monitor/cache"
+	"github.com/apache/trafficcontrol/traffic_monitor/peer"
+	"github.com/apache/trafficcontrol/traffic_monitor/threadsafe"
+	"github.com/apache/trafficcontrol/traffic_monitor/todata"
+)
+
+// DefaultProbeThresholds are the thresholds synthetic probe results are
+// evaluated against, when the cache's profile has no "probe." thresholds:
+// every probe must succeed.
+var DefaultProbeThresholds = map[string]tc.HealthThreshold{
+	cache.ProbeStatFailures: {Val: 1, Comparator: "<"},
+}
+
+// EvalProbe evaluates the given synthetic probe result against the "probe."
+// thresholds of the cache's profile, and returns why the cache's probes
+// failed, or an empty string if they passed.
+func EvalProbe(result cache.Result, mc *tc.TrafficMonitorConfigMap) string {
+	if result.Error != nil {
+		return fmt.Sprintf("%v", result.Error)
+	}
+
+	serverInfo, ok := mc.TrafficServer[result.ID]
+	if !ok {
+		log.Errorf("Cache %v missing from from Traffic Ops Monitor Config - not evaluating probes\n", result.ID)
+		return ""
+	}
+	profile, ok := mc.Profile[serverInfo.Profile]
+	if !ok {
+		log.Errorf("Profile '%v' for cache server '%v' missing from monitoring configuration - not evaluating probes", serverInfo.Profile, result.ID)
+		return ""
+	}
+
+	thresholds := map[string]tc.HealthThreshold{}
+	for stat, threshold := range profile.Parameters.Thresholds {
+		if strings.HasPrefix(stat, cache.ProbeStatPrefix) {
+			thresholds[stat] = threshold
+		}
+	}
+	if len(thresholds) == 0 {
+		thresholds = DefaultProbeThresholds
+	}
+
+	stats := make([]string, 0, len(thresholds))
+	for stat := range thresholds {
+		stats = append(stats, stat)
+	}
+	sort.Strings(stats) // so the reason doesn't change from poll to poll when multiple thresholds are exceeded
+
+	reasons := []string{}
+	for _, stat := range stats {
+		resultStat, ok := result.Miscellaneous[stat]
+		if !ok {
+			continue // e.g. a threshold for a Delivery Service this cache doesn't serve
+		}
+		resultStatNum, ok := util.ToNumeric(resultStat)
+		if !ok {
+			log.Errorf("health.EvalProbe threshold stat %s was not a number: %v", stat, resultStat)
+			continue
+		}
+		if threshold := thresholds[stat]; !inThreshold(threshold, resultStatNum) {
+			reasons = append(reasons, exceedsThresholdMsg(stat, threshold, resultStatNum))
+		}
+	}
+	if len(reasons) == 0 {
+		return ""
+	}
+	return strings.Join(append(reasons, cache.ProbeErrors(result.Miscellaneous)...), "; ")
+}
+
+// CalcProbeAvailability evaluates each synthetic probe result, and records
+// why the cache's probes failed for CalcAvailability to take into account.
+// A cache whose probes start failing is immediately marked unavailable in the
+// localStates; the event, and the cache's return to availability once its
+// probes pass again, come from its next health or stat poll. Returns whether
+// any cache was marked unavailable.
+func CalcProbeAvailability(
+	results []cache.Result,
+	mc tc.TrafficMonitorConfigMap,
+	toData todata.TOData,
+	localCacheStatusThreadsafe threadsafe.CacheAvailableStatus,
+	localStates peer.CRStatesThreadsafe,
+) bool {
+	changed := false
+	for _, result := range results {
+		why := EvalProbe(result, &mc)
+		prevWhy := localCacheStatusThreadsafe.ProbeFailure(result.ID)
+		localCacheStatusThreadsafe.SetProbeFailure(result.ID, why)
+		if why == "" {
+			if prevWhy != "" {
+				log.Infof("Probes of %s passed; it will be marked available by its next poll", result.ID)
+			}
+			continue
+		}
+		if prevWhy != "" {
+			continue
+		}
+
+		log.Infof("Changing state for %s now: false because probe: %s", result.ID, why)
+		localStates.SetCache(tc.CacheName(result.ID), tc.IsAvailable{IsAvailable: false})
+		changed = true
+	}
+	if changed {
+		calculateDeliveryServiceState(toData.DeliveryServiceServers, localStates, toData)
+	}
+	return changed
+}
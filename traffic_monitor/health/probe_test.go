@@ -0,0 +1,153 @@
+package health
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_monitor/cache"
+	"github.com/apache/trafficcontrol/traffic_monitor/config"
+	"github.com/apache/trafficcontrol/traffic_monitor/peer"
+	"github.com/apache/trafficcontrol/traffic_monitor/threadsafe"
+	"github.com/apache/trafficcontrol/traffic_monitor/todata"
+)
+
+func TestCalcProbeAvailability(t *testing.T) {
+	cacheName := "edge"
+	mc := tc.TrafficMonitorConfigMap{
+		TrafficServer: map[string]tc.TrafficServer{
+			cacheName: {
+				ServerStatus: string(tc.CacheStatusReported),
+				Profile:      "edgeProfile",
+				Interfaces:   []tc.ServerInterfaceInfo{{Name: "bond0", Monitor: true}},
+			},
+		},
+		Profile: map[string]tc.TMProfile{
+			"edgeProfile": {Name: "edgeProfile", Parameters: tc.TMParameters{HealthProbePath: "/probe"}},
+		},
+	}
+	toData := todata.TOData{
+		ServerTypes:            map[tc.CacheName]tc.CacheType{tc.CacheName(cacheName): tc.CacheTypeEdge},
+		DeliveryServiceServers: map[tc.DeliveryServiceName][]tc.CacheName{"ds1": {tc.CacheName(cacheName)}},
+		ServerCachegroups:      map[tc.CacheName]tc.CacheGroupName{tc.CacheName(cacheName): "edgeCG"},
+	}
+	localCacheStatus := threadsafe.NewCacheAvailableStatus()
+	localStates := peer.NewCRStatesThreadsafe()
+	localStates.AddCache(tc.CacheName(cacheName), tc.IsAvailable{IsAvailable: false})
+	events := NewThreadsafeEvents(200)
+
+	healthResult := cache.Result{
+		ID:            cacheName,
+		Miscellaneous: map[string]interface{}{},
+		Statistics: cache.Statistics{
+			Interfaces: map[string]cache.Interface{"bond0": {Speed: 20000, BytesOut: 1000}},
+		},
+		Time:      time.Now(),
+		Available: true,
+		UsingIPv4: true,
+	}
+	GetVitals(&healthResult, nil, &mc)
+	calcHealth := func() {
+		CalcAvailability([]cache.Result{healthResult}, "health", nil, mc, toData, localCacheStatus, localStates, events, config.IPv4Only)
+	}
+	isAvailable := func() bool {
+		state, _ := localStates.GetCache(tc.CacheName(cacheName))
+		return state.IsAvailable
+	}
+
+	calcHealth() // the first poll of a cache only seeds its status
+	calcHealth()
+	if !isAvailable() {
+		t.Fatalf("cache with passing health poll expected: available, actual: unavailable")
+	}
+
+	failing := cache.Result{ID: cacheName, Miscellaneous: map[string]interface{}{
+		cache.ProbeStatCount:    float64(1),
+		cache.ProbeStatFailures: float64(1),
+		cache.ProbeStatLatency:  float64(5),
+		"probe.ds1.error":       "bad HTTP status: expected 200, actual 503",
+	}}
+	if !CalcProbeAvailability([]cache.Result{failing}, mc, toData, localCacheStatus, localStates) {
+		t.Errorf("failing probe of available cache expected: changed, actual: unchanged")
+	}
+	if isAvailable() {
+		t.Errorf("cache with failing probe expected: unavailable, actual: available")
+	}
+	if why := localCacheStatus.ProbeFailure(cacheName); !strings.Contains(why, "ds1: bad HTTP status") {
+		t.Errorf("probe failure expected: to contain the failed probe's error, actual: '%v'", why)
+	}
+
+	calcHealth()
+	if isAvailable() {
+		t.Errorf("cache with failing probe and passing health poll expected: unavailable, actual: available")
+	}
+	if why := localCacheStatus.Get()[cacheName].Why; !strings.Contains(why, "probe: ") {
+		t.Errorf("cache status why expected: to contain the probe failure, actual: '%v'", why)
+	}
+
+	passing := cache.Result{ID: cacheName, Miscellaneous: map[string]interface{}{
+		cache.ProbeStatCount:    float64(1),
+		cache.ProbeStatFailures: float64(0),
+		cache.ProbeStatLatency:  float64(150),
+	}}
+	if CalcProbeAvailability([]cache.Result{passing}, mc, toData, localCacheStatus, localStates) {
+		t.Errorf("passing probe expected: unchanged, actual: changed")
+	}
+	if why := localCacheStatus.ProbeFailure(cacheName); why != "" {
+		t.Errorf("probe failure after passing probe expected: empty, actual: '%v'", why)
+	}
+	calcHealth()
+	if !isAvailable() {
+		t.Errorf("cache with passing probe and health poll expected: available, actual: unavailable")
+	}
+
+	mc.Profile["edgeProfile"] = tc.TMProfile{Name: "edgeProfile", Parameters: tc.TMParameters{
+		HealthProbePath: "/probe",
+		Thresholds:      map[string]tc.HealthThreshold{"probe.latency": {Val: 100, Comparator: "<"}},
+	}}
+	if why := EvalProbe(passing, &mc); !strings.HasPrefix(why, "probe.latency too high") {
+		t.Errorf("probe over latency threshold expected: 'probe.latency too high' failure, actual: '%v'", why)
+	}
+	if why := EvalProbe(failing, &mc); why != "" {
+		t.Errorf("failing probe with only a latency threshold expected: no failure, actual: '%v'", why)
+	}
+}
+
+func TestClearStaleProbeFailures(t *testing.T) {
+	localCacheStatus := threadsafe.NewCacheAvailableStatus()
+	localCacheStatus.SetProbeFailure("stale", "probe.failures 1 exceeds threshold <1")
+	time.Sleep(time.Millisecond)
+	cutoff := time.Now()
+	localCacheStatus.SetProbeFailure("fresh", "probe.failures 1 exceeds threshold <1")
+
+	cleared := localCacheStatus.ClearStaleProbeFailures(cutoff)
+	if len(cleared) != 1 || cleared[0] != "stale" {
+		t.Errorf("cleared probe failures expected: [stale], actual: %v", cleared)
+	}
+	if why := localCacheStatus.ProbeFailure("stale"); why != "" {
+		t.Errorf("stale probe failure expected: cleared, actual: '%v'", why)
+	}
+	if why := localCacheStatus.ProbeFailure("fresh"); why == "" {
+		t.Errorf("fresh probe failure expected: kept, actual: cleared")
+	}
+}
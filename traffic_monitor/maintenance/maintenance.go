@@ -0,0 +1,290 @@
+// Package maintenance holds the maintenance windows during which Traffic
+// Monitor drains caches, gradually marking them unavailable.
+package maintenance
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+
+	"github.com/google/uuid"
+)
+
+// PruneAge is how long deleted and ended windows are kept, so that peers
+// learn of them, before they're forgotten.
+const PruneAge = 24 * time.Hour
+
+// Window is a maintenance window, during which a cache, or the caches of a
+// cachegroup, are drained.
+//
+// The caches are drained RampPercent at a time, every RampIntervalMS
+// milliseconds, from Start, in order of their names, and are all restored at
+// End, if it's set, or when the window is deleted.
+type Window struct {
+	ID             string            `json:"id"`
+	Cache          tc.CacheName      `json:"cache,omitempty"`
+	CacheGroup     tc.CacheGroupName `json:"cachegroup,omitempty"`
+	Start          time.Time         `json:"start"`
+	End            *time.Time        `json:"end,omitempty"`
+	RampPercent    float64           `json:"rampPercent"`
+	RampIntervalMS uint64            `json:"rampIntervalMs"`
+	Reason         string            `json:"reason"`
+	// LastUpdated is when the window was created or deleted. When the same window differs between peers, the last updated wins.
+	LastUpdated time.Time `json:"lastUpdated"`
+	// Deleted windows are kept for PruneAge, so that their deletion is shared with peers.
+	Deleted bool `json:"deleted,omitempty"`
+}
+
+// Validate returns an error if the window isn't valid.
+func (w Window) Validate() error {
+	if (w.Cache == "") == (w.CacheGroup == "") {
+		return errors.New("exactly one of cache and cachegroup must be given")
+	}
+	if w.Start.IsZero() {
+		return errors.New("start is required")
+	}
+	if w.End != nil && !w.End.After(w.Start) {
+		return errors.New("end must be after start")
+	}
+	if w.RampPercent <= 0 || w.RampPercent > 100 {
+		return errors.New("rampPercent must be greater than 0 and at most 100")
+	}
+	if w.RampPercent < 100 && w.RampIntervalMS == 0 {
+		return errors.New("rampIntervalMs is required if rampPercent is less than 100")
+	}
+	return nil
+}
+
+// active returns whether the window drains caches at the given time.
+func (w Window) active(now time.Time) bool {
+	return !w.Deleted && !now.Before(w.Start) && (w.End == nil || now.Before(*w.End))
+}
+
+// drainCount returns how many of the window's n caches are drained at the
+// given time.
+func (w Window) drainCount(now time.Time, n int) int {
+	if !w.active(now) {
+		return 0
+	}
+	percent := 100.0
+	if w.RampPercent < 100 {
+		steps := float64(now.Sub(w.Start)/(time.Duration(w.RampIntervalMS)*time.Millisecond)) + 1
+		percent = math.Min(100, steps*w.RampPercent)
+	}
+	return int(math.Ceil(percent * float64(n) / 100))
+}
+
+// Windows is the maintenance windows of a Traffic Monitor, which are safe for
+// multiple goroutines, and persisted in a file.
+type Windows struct {
+	windows map[string]Window
+	path    string
+	m       *sync.RWMutex
+}
+
+// Load returns the windows persisted in the file at the given path, which
+// doesn't need to exist. If the path is empty, the windows are not persisted.
+func Load(path string) (*Windows, error) {
+	w := &Windows{windows: map[string]Window{}, path: path, m: &sync.RWMutex{}}
+	if path == "" {
+		return w, nil
+	}
+	bts, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return w, nil
+	} else if err != nil {
+		return w, errors.New("reading maintenance windows: " + err.Error())
+	}
+	windows := []Window{}
+	if err := json.Unmarshal(bts, &windows); err != nil {
+		return w, errors.New("decoding maintenance windows: " + err.Error())
+	}
+	for _, window := range windows {
+		w.windows[window.ID] = window
+	}
+	return w, nil
+}
+
+// save persists the windows. It must be called with the lock held.
+func (w *Windows) save() error {
+	if w.path == "" {
+		return nil
+	}
+	bts, err := json.MarshalIndent(w.all(), "", "\t")
+	if err != nil {
+		return errors.New("encoding maintenance windows: " + err.Error())
+	}
+	// write a temporary file and rename it, so a failed write doesn't lose the windows
+	tmpPath := w.path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, bts, 0644); err != nil {
+		return errors.New("writing maintenance windows: " + err.Error())
+	}
+	if err := os.Rename(tmpPath, w.path); err != nil {
+		return errors.New("replacing maintenance windows file: " + err.Error())
+	}
+	return nil
+}
+
+// all returns every window, including deleted ones, by start time. It must be
+// called with the lock held.
+func (w *Windows) all() []Window {
+	windows := make([]Window, 0, len(w.windows))
+	for _, window := range w.windows {
+		windows = append(windows, window)
+	}
+	sort.Slice(windows, func(i, j int) bool {
+		if !windows[i].Start.Equal(windows[j].Start) {
+			return windows[i].Start.Before(windows[j].Start)
+		}
+		return windows[i].ID < windows[j].ID
+	})
+	return windows
+}
+
+// All returns every window, including deleted ones, by start time.
+func (w *Windows) All() []Window {
+	w.m.RLock()
+	defer w.m.RUnlock()
+	return w.all()
+}
+
+// Add validates and adds a new window, returning it with its ID. The window is
+// still added if it can't be persisted, in which case the error is returned
+// with it.
+func (w *Windows) Add(window Window) (Window, error) {
+	if window.RampPercent == 0 {
+		window.RampPercent = 100
+	}
+	if err := window.Validate(); err != nil {
+		return Window{}, err
+	}
+	window.ID = uuid.New().String()
+	window.LastUpdated = time.Now()
+	window.Deleted = false
+
+	w.m.Lock()
+	defer w.m.Unlock()
+	w.windows[window.ID] = window
+	return window, w.save()
+}
+
+// Delete deletes the window with the given ID, restoring its caches. It
+// returns false if there is no such window. The window is still deleted if
+// this can't be persisted, in which case the error is returned.
+func (w *Windows) Delete(id string) (bool, error) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	window, ok := w.windows[id]
+	if !ok || window.Deleted {
+		return false, nil
+	}
+	window.Deleted = true
+	window.LastUpdated = time.Now()
+	w.windows[id] = window
+	return true, w.save()
+}
+
+// Merge merges the windows of a peer, keeping the last updated of each. It
+// returns whether any window changed.
+func (w *Windows) Merge(peerWindows []Window) (bool, error) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	changed := false
+	for _, peerWindow := range peerWindows {
+		if peerWindow.ID == "" || (!peerWindow.Deleted && peerWindow.Validate() != nil) {
+			continue
+		}
+		if window, ok := w.windows[peerWindow.ID]; ok && !peerWindow.LastUpdated.After(window.LastUpdated) {
+			continue
+		}
+		w.windows[peerWindow.ID] = peerWindow
+		changed = true
+	}
+	if !changed {
+		return false, nil
+	}
+	return true, w.save()
+}
+
+// Prune forgets the windows which were deleted or ended more than PruneAge
+// before the given time.
+func (w *Windows) Prune(now time.Time) error {
+	w.m.Lock()
+	defer w.m.Unlock()
+	pruned := false
+	for id, window := range w.windows {
+		if (window.Deleted && now.Sub(window.LastUpdated) > PruneAge) || (window.End != nil && now.Sub(*window.End) > PruneAge) {
+			delete(w.windows, id)
+			pruned = true
+		}
+	}
+	if !pruned {
+		return nil
+	}
+	return w.save()
+}
+
+// Drained returns the caches drained at the given time, and the reason each is
+// drained. The caches of cachegroups are taken from cacheGroups. If a cache is
+// drained by multiple windows, the reason is that of the one which started
+// first.
+func (w *Windows) Drained(now time.Time, cacheGroups map[tc.CacheName]tc.CacheGroupName) map[tc.CacheName]string {
+	w.m.RLock()
+	windows := w.all()
+	w.m.RUnlock()
+
+	groupCaches := map[tc.CacheGroupName][]tc.CacheName{}
+	for cache, cacheGroup := range cacheGroups {
+		groupCaches[cacheGroup] = append(groupCaches[cacheGroup], cache)
+	}
+	for _, caches := range groupCaches {
+		sort.Slice(caches, func(i, j int) bool { return caches[i] < caches[j] })
+	}
+
+	drained := map[tc.CacheName]string{}
+	for _, window := range windows {
+		caches := []tc.CacheName{window.Cache}
+		if window.CacheGroup != "" {
+			caches = groupCaches[window.CacheGroup]
+		}
+		for _, cache := range caches[:window.drainCount(now, len(caches))] {
+			if _, ok := drained[cache]; !ok {
+				drained[cache] = "maintenance " + window.ID + ": " + window.Reason
+			}
+		}
+	}
+	return drained
+}
+
+// Status is the maintenance windows of a Traffic Monitor, as served to clients
+// and peers, along with the caches they currently drain.
+type Status struct {
+	Windows []Window                `json:"windows"`
+	Drained map[tc.CacheName]string `json:"drained"`
+}
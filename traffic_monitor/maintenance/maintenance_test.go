@@ -0,0 +1,147 @@
+package maintenance
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+)
+
+func TestDrainedRamp(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+	w, err := Load("")
+	if err != nil {
+		t.Fatalf("loading unpersisted windows: %v", err)
+	}
+	window, err := w.Add(Window{CacheGroup: "cg0", Start: start, End: &end, RampPercent: 25, RampIntervalMS: 60000, Reason: "upgrade"})
+	if err != nil {
+		t.Fatalf("adding window: %v", err)
+	}
+	cacheGroups := map[tc.CacheName]tc.CacheGroupName{}
+	for _, cache := range []tc.CacheName{"cache3", "cache1", "cache2", "cache0", "cache4", "cache5", "cache6", "cache7"} {
+		cacheGroups[cache] = "cg0"
+	}
+	cacheGroups["other"] = "cg1"
+
+	tests := []struct {
+		at       time.Duration
+		expected []tc.CacheName
+	}{
+		{-time.Second, nil},
+		{0, []tc.CacheName{"cache0", "cache1"}},
+		{90 * time.Second, []tc.CacheName{"cache0", "cache1", "cache2", "cache3"}},
+		{10 * time.Minute, []tc.CacheName{"cache0", "cache1", "cache2", "cache3", "cache4", "cache5", "cache6", "cache7"}},
+		{time.Hour, nil},
+	}
+	for _, test := range tests {
+		drained := w.Drained(start.Add(test.at), cacheGroups)
+		if len(drained) != len(test.expected) {
+			t.Errorf("at %v expected %d drained, actual %+v", test.at, len(test.expected), drained)
+			continue
+		}
+		for _, cache := range test.expected {
+			if reason := drained[cache]; reason != "maintenance "+window.ID+": upgrade" {
+				t.Errorf("at %v expected %s drained by window, actual reason '%s'", test.at, cache, reason)
+			}
+		}
+	}
+
+	if ok, err := w.Delete(window.ID); !ok || err != nil {
+		t.Fatalf("deleting window: %v %v", ok, err)
+	}
+	if drained := w.Drained(start.Add(time.Minute), cacheGroups); len(drained) != 0 {
+		t.Errorf("expected no drained caches after delete, actual %+v", drained)
+	}
+}
+
+func TestAddInvalid(t *testing.T) {
+	w, _ := Load("")
+	invalid := []Window{
+		{Start: time.Now()},
+		{Cache: "cache0", CacheGroup: "cg0", Start: time.Now()},
+		{Cache: "cache0"},
+		{Cache: "cache0", Start: time.Now(), RampPercent: 150},
+		{Cache: "cache0", Start: time.Now(), RampPercent: 10},
+	}
+	for _, window := range invalid {
+		if _, err := w.Add(window); err == nil {
+			t.Errorf("expected error adding invalid window %+v", window)
+		}
+	}
+	if len(w.All()) != 0 {
+		t.Errorf("expected no windows added, actual %+v", w.All())
+	}
+}
+
+func TestMergePersistPrune(t *testing.T) {
+	dir, err := ioutil.TempDir("", "maintenance")
+	if err != nil {
+		t.Fatalf("creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "maintenance.json")
+
+	w, err := Load(path)
+	if err != nil {
+		t.Fatalf("loading missing file: %v", err)
+	}
+	window, err := w.Add(Window{Cache: "cache0", Start: time.Now(), Reason: "disk"})
+	if err != nil {
+		t.Fatalf("adding window: %v", err)
+	}
+
+	peer, err := Load("")
+	if err != nil {
+		t.Fatalf("loading unpersisted windows: %v", err)
+	}
+	if changed, err := peer.Merge(w.All()); !changed || err != nil {
+		t.Fatalf("expected peer merge to change, actual %v %v", changed, err)
+	}
+	if changed, _ := peer.Merge(w.All()); changed {
+		t.Error("expected merging the same windows again to not change")
+	}
+	if _, err := peer.Delete(window.ID); err != nil {
+		t.Fatalf("deleting window: %v", err)
+	}
+	if changed, err := w.Merge(peer.All()); !changed || err != nil {
+		t.Fatalf("expected merging peer delete to change, actual %v %v", changed, err)
+	}
+
+	reloaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("reloading: %v", err)
+	}
+	if all := reloaded.All(); len(all) != 1 || all[0].ID != window.ID || !all[0].Deleted {
+		t.Fatalf("expected reloaded deleted window %s, actual %+v", window.ID, all)
+	}
+
+	if err := reloaded.Prune(time.Now()); err != nil || len(reloaded.All()) != 1 {
+		t.Errorf("expected recently deleted window not pruned, actual %v %+v", err, reloaded.All())
+	}
+	if err := reloaded.Prune(time.Now().Add(PruneAge + time.Minute)); err != nil || len(reloaded.All()) != 0 {
+		t.Errorf("expected old deleted window pruned, actual %v %+v", err, reloaded.All())
+	}
+}
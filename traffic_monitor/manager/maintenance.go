@@ -0,0 +1,123 @@
+package manager
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-log"
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_monitor/config"
+	"github.com/apache/trafficcontrol/traffic_monitor/maintenance"
+	"github.com/apache/trafficcontrol/traffic_monitor/threadsafe"
+	"github.com/apache/trafficcontrol/traffic_monitor/todata"
+)
+
+// maintenanceCheckInterval is how often the caches drained by maintenance windows are recomputed, so that drains start, ramp, and end on time.
+const maintenanceCheckInterval = time.Second
+
+// StartMaintenanceManager starts the goroutine which combines states whenever the caches drained by maintenance windows change, and which periodically fetches the maintenance windows of online peers, so that windows created on any Traffic Monitor are applied by all of them.
+func StartMaintenanceManager(
+	windows *maintenance.Windows,
+	toData todata.TODataThreadsafe,
+	monitorConfig threadsafe.TrafficMonitorConfigMap,
+	cfg config.Config,
+	staticAppData config.StaticAppData,
+	combineState func(),
+) {
+	client := &http.Client{Timeout: cfg.HTTPTimeout}
+	go func() {
+		checkTicker := time.NewTicker(maintenanceCheckInterval)
+		peerTicker := time.NewTicker(cfg.PeerPollingInterval)
+		drained := map[tc.CacheName]string{}
+		for {
+			select {
+			case <-checkTicker.C:
+			case <-peerTicker.C:
+				syncPeerMaintenance(client, windows, monitorConfig.Get(), staticAppData)
+			}
+			newDrained := windows.Drained(time.Now(), toData.Get().ServerCachegroups)
+			if !drainedEqual(drained, newDrained) {
+				drained = newDrained
+				combineState()
+			}
+		}
+	}()
+}
+
+// syncPeerMaintenance merges the maintenance windows of each online peer, and prunes old windows.
+func syncPeerMaintenance(client *http.Client, windows *maintenance.Windows, monitorConfig tc.TrafficMonitorConfigMap, staticAppData config.StaticAppData) {
+	for _, srv := range monitorConfig.TrafficMonitor {
+		if srv.HostName == staticAppData.Hostname || tc.CacheStatusFromString(srv.ServerStatus) != tc.CacheStatusOnline {
+			continue
+		}
+		url := fmt.Sprintf("http://%s:%d/api/maintenance", srv.IP, srv.Port)
+		peerWindows, err := getPeerMaintenance(client, url, staticAppData.UserAgent)
+		if err != nil {
+			log.Warnf("getting maintenance windows from peer %s: %v", srv.HostName, err)
+			continue
+		}
+		if changed, err := windows.Merge(peerWindows); err != nil {
+			log.Errorf("merging maintenance windows from peer %s: %v", srv.HostName, err)
+		} else if changed {
+			log.Infof("merged maintenance windows from peer %s", srv.HostName)
+		}
+	}
+	if err := windows.Prune(time.Now()); err != nil {
+		log.Errorf("pruning maintenance windows: %v", err)
+	}
+}
+
+func getPeerMaintenance(client *http.Client, url string, userAgent string) ([]maintenance.Window, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, errors.New("creating request: " + err.Error())
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.New("requesting: " + err.Error())
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bad response code %d", resp.StatusCode)
+	}
+	status := maintenance.Status{}
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, errors.New("decoding: " + err.Error())
+	}
+	return status.Windows, nil
+}
+
+func drainedEqual(a map[tc.CacheName]string, b map[tc.CacheName]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for cache, reason := range a {
+		if bReason, ok := b[cache]; !ok || bReason != reason {
+			return false
+		}
+	}
+	return true
+}
@@ -33,8 +33,10 @@ import (
 	"github.com/apache/trafficcontrol/traffic_monitor/config"
 	"github.com/apache/trafficcontrol/traffic_monitor/handler"
 	"github.com/apache/trafficcontrol/traffic_monitor/health"
+	"github.com/apache/trafficcontrol/traffic_monitor/maintenance"
 	"github.com/apache/trafficcontrol/traffic_monitor/peer"
 	"github.com/apache/trafficcontrol/traffic_monitor/poller"
+	"github.com/apache/trafficcontrol/traffic_monitor/srvgrpc"
 	"github.com/apache/trafficcontrol/traffic_monitor/threadsafe"
 	"github.com/apache/trafficcontrol/traffic_monitor/todata"
 	"github.com/apache/trafficcontrol/traffic_monitor/towrap"
@@ -57,16 +59,26 @@ func Start(opsConfigFile string, cfg config.Config, appData config.StaticAppData
 	cacheHealthPoller := poller.NewCache(cfg.CacheHealthPollingInterval, true, cacheHealthHandler, cfg, appData, cfg.CachePollingProtocol)
 	cacheStatHandler := cache.NewPrecomputeHandler(toData)
 	cacheStatPoller := poller.NewCache(cfg.CacheStatPollingInterval, false, cacheStatHandler, cfg, appData, cfg.CachePollingProtocol)
-	monitorConfigPoller := poller.NewMonitorConfig(cfg.MonitorConfigPollingInterval)
+	cacheProbeHandler := cache.NewHandler()
+	cacheProbePoller := poller.NewCache(cfg.CacheProbePollingInterval, false, cacheProbeHandler, cfg, appData, cfg.CachePollingProtocol)
+	monitorConfigPoller := poller.NewMonitorConfig(cfg.MonitorConfigPollingInterval, cfg.CDNs)
 	peerHandler := peer.NewHandler()
 	peerPoller := poller.NewCache(cfg.PeerPollingInterval, false, peerHandler, cfg, appData, cfg.PeerPollingProtocol)
 
 	go monitorConfigPoller.Poll()
 	go cacheHealthPoller.Poll()
 	go cacheStatPoller.Poll()
+	go cacheProbePoller.Poll()
 	go peerPoller.Poll()
 
 	events := health.NewThreadsafeEvents(cfg.MaxEvents)
+	if cfg.EventStorePath != "" {
+		if eventStore, err := health.NewEventStore(cfg.EventStorePath, cfg.EventStoreRetention); err != nil {
+			log.Errorf("opening event store '%s', events will not be persisted: %v", cfg.EventStorePath, err)
+		} else {
+			events = health.NewPersistedThreadsafeEvents(cfg.MaxEvents, eventStore)
+		}
+	}
 
 	cachesChanged := make(chan struct{})
 	peerStates := peer.NewCRStatesPeersThreadsafe(cfg.PeerOptimisticQuorumMin) // each peer's last state is saved in this map
@@ -77,6 +89,7 @@ func Start(opsConfigFile string, cfg config.Config, appData config.StaticAppData
 		peerStates,
 		cacheStatPoller.ConfigChannel,
 		cacheHealthPoller.ConfigChannel,
+		cacheProbePoller.ConfigChannel,
 		peerPoller.ConfigChannel,
 		monitorConfigPoller.IntervalChan,
 		cachesChanged,
@@ -86,7 +99,23 @@ func Start(opsConfigFile string, cfg config.Config, appData config.StaticAppData
 		toData,
 	)
 
-	combinedStates, combineStateFunc := StartStateCombiner(events, peerStates, localStates, toData)
+	maintenanceWindows, err := maintenance.Load(cfg.MaintenanceFile)
+	if err != nil {
+		log.Errorf("loading maintenance windows from '%s', starting without them: %v", cfg.MaintenanceFile, err)
+	}
+
+	crStatesFeed := peer.NewCRStatesFeed(peer.DefaultCRStatesFeedHistory)
+	quorumStatus := peer.NewQuorumStatusThreadsafe()
+	combinedStates, combineStateFunc := StartStateCombiner(events, peerStates, localStates, toData, crStatesFeed, quorumStatus, cfg.PeerQuorum, maintenanceWindows)
+
+	StartMaintenanceManager(
+		maintenanceWindows,
+		toData,
+		monitorConfig,
+		cfg,
+		appData,
+		combineStateFunc,
+	)
 
 	StartPeerManager(
 		peerHandler.ResultChannel,
@@ -121,6 +150,16 @@ func Start(opsConfigFile string, cfg config.Config, appData config.StaticAppData
 		localCacheStatus,
 	)
 
+	StartProbeResultManager(
+		cacheProbeHandler.ResultChan(),
+		cfg.CacheProbePollingInterval,
+		toData,
+		localStates,
+		monitorConfig,
+		localCacheStatus,
+		combineStateFunc,
+	)
+
 	StartOpsConfigManager(
 		opsConfigFile,
 		toSession,
@@ -130,6 +169,8 @@ func Start(opsConfigFile string, cfg config.Config, appData config.StaticAppData
 		localStates,
 		peerStates,
 		combinedStates,
+		crStatesFeed,
+		quorumStatus,
 		statInfoHistory,
 		statResultHistory,
 		statMaxKbpses,
@@ -146,9 +187,31 @@ func Start(opsConfigFile string, cfg config.Config, appData config.StaticAppData
 		localCacheStatus,
 		unpolledCaches,
 		monitorConfig,
+		maintenanceWindows,
 		cfg,
 	)
 
+	if cfg.GRPCListener != "" {
+		grpcServer := srvgrpc.NewServer(
+			localStates,
+			combinedStates,
+			peerStates,
+			crStatesFeed,
+			statInfoHistory,
+			statResultHistory,
+			statMaxKbpses,
+			dsStats,
+			toData,
+			monitorConfig,
+			unpolledCaches,
+			errorCount,
+			cfg.CacheStatPollingInterval,
+		)
+		if _, err := grpcServer.Run(cfg.GRPCListener, cfg.GRPCCertFile, cfg.GRPCKeyFile); err != nil {
+			log.Errorf("starting gRPC server on '%s', gRPC will not be served: %v", cfg.GRPCListener, err)
+		}
+	}
+
 	if err := startMonitorConfigFilePoller(trafficMonitorConfigFileName); err != nil {
 		return fmt.Errorf("starting monitor config file poller: %v", err)
 	}
@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"net/url"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -124,6 +125,7 @@ func StartMonitorConfigManager(
 	peerStates peer.CRStatesPeersThreadsafe,
 	statURLSubscriber chan<- poller.CachePollerConfig,
 	healthURLSubscriber chan<- poller.CachePollerConfig,
+	probeURLSubscriber chan<- poller.CachePollerConfig,
 	peerURLSubscriber chan<- poller.CachePollerConfig,
 	toIntervalSubscriber chan<- time.Duration,
 	cachesChangeSubscriber chan<- struct{},
@@ -139,6 +141,7 @@ func StartMonitorConfigManager(
 		peerStates,
 		statURLSubscriber,
 		healthURLSubscriber,
+		probeURLSubscriber,
 		peerURLSubscriber,
 		toIntervalSubscriber,
 		cachesChangeSubscriber,
@@ -194,6 +197,7 @@ func monitorConfigListen(
 	peerStates peer.CRStatesPeersThreadsafe,
 	statURLSubscriber chan<- poller.CachePollerConfig,
 	healthURLSubscriber chan<- poller.CachePollerConfig,
+	probeURLSubscriber chan<- poller.CachePollerConfig,
 	peerURLSubscriber chan<- poller.CachePollerConfig,
 	toIntervalSubscriber chan<- time.Duration,
 	cachesChangeSubscriber chan<- struct{},
@@ -215,14 +219,14 @@ func monitorConfigListen(
 
 	for pollerMonitorCfg := range monitorConfigPollChan {
 		monitorConfig := pollerMonitorCfg.Cfg
-		cdn := pollerMonitorCfg.CDN
 		monitorConfigTS.Set(monitorConfig)
-		if err := toData.Update(toSession, cdn); err != nil {
+		if err := toData.Update(toSession, pollerMonitorCfg.CDNs...); err != nil {
 			log.Errorln("Updating Traffic Ops Data: " + err.Error())
 		}
 
 		healthURLs := map[string]poller.PollConfig{}
 		statURLs := map[string]poller.PollConfig{}
+		probeURLs := map[string]poller.PollConfig{}
 		peerURLs := map[string]poller.PollConfig{}
 		caches := map[string]string{}
 
@@ -282,10 +286,16 @@ func monitorConfigListen(
 			statURL4 := createServerStatPollURL(pollURL4Str)
 			statURL6 := createServerStatPollURL(pollURL6Str)
 			statURLs[srv.HostName] = poller.PollConfig{URL: statURL4, URLv6: statURL6, Host: srv.FQDN, Timeout: connTimeout, Format: format, PollType: pollType}
+
+			if probes := createServerProbes(srv, monitorConfig.Profile[srv.Profile].Parameters, toData.Get()); len(probes) > 0 {
+				probeURLs[srv.HostName] = poller.PollConfig{URL: pollURL4Str, URLv6: pollURL6Str, Host: srv.FQDN, Timeout: connTimeout, Format: poller.PollerTypeProbe, PollType: poller.PollerTypeProbe, Probes: probes}
+			}
 		}
 
 		peerSet := map[tc.TrafficMonitorName]struct{}{}
+		monitorLocations := map[tc.TrafficMonitorName]tc.CacheGroupName{}
 		for _, srv := range monitorConfig.TrafficMonitor {
+			monitorLocations[tc.TrafficMonitorName(srv.HostName)] = tc.CacheGroupName(srv.Location)
 			if srv.HostName == staticAppData.Hostname {
 				continue
 			}
@@ -301,10 +311,13 @@ func monitorConfigListen(
 
 		statURLSubscriber <- poller.CachePollerConfig{Urls: statURLs, PollingProtocol: cfg.CachePollingProtocol, Interval: intervals.Stat, NoKeepAlive: intervals.StatNoKeepAlive}
 		healthURLSubscriber <- poller.CachePollerConfig{Urls: healthURLs, PollingProtocol: cfg.CachePollingProtocol, Interval: intervals.Health, NoKeepAlive: intervals.HealthNoKeepAlive}
+		probeURLSubscriber <- poller.CachePollerConfig{Urls: probeURLs, PollingProtocol: cfg.CachePollingProtocol, Interval: cfg.CacheProbePollingInterval}
 		peerURLSubscriber <- poller.CachePollerConfig{Urls: peerURLs, PollingProtocol: cfg.PeerPollingProtocol, Interval: intervals.Peer, NoKeepAlive: intervals.PeerNoKeepAlive}
 		toIntervalSubscriber <- intervals.TO
 		peerStates.SetTimeout((intervals.Peer + cfg.HTTPTimeout) * 2)
 		peerStates.SetPeers(peerSet)
+		peerStates.SetLocations(tc.TrafficMonitorName(staticAppData.Hostname), monitorLocations)
+		peerStates.SetCDNs(pollerMonitorCfg.MonitorCDNs)
 
 		for cacheName := range localStates.GetCaches() {
 			if _, exists := monitorConfig.TrafficServer[string(cacheName)]; !exists {
@@ -404,6 +417,44 @@ func insertPorts(pollingURLStr string, srv tc.TrafficServer) string {
 	return pollingURLStr
 }
 
+// createServerProbes returns the synthetic probes to make through srv, one for
+// each HTTP Delivery Service assigned to it, sorted by Delivery Service. If
+// the server's profile has no health.probe.path Parameter, it isn't probed,
+// and nil is returned.
+func createServerProbes(srv tc.TrafficServer, params tc.TMParameters, toData todata.TOData) []poller.Probe {
+	if params.HealthProbePath == "" {
+		return nil
+	}
+	path := params.HealthProbePath
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	probes := []poller.Probe{}
+	for _, ds := range toData.ServerDeliveryServices[tc.CacheName(srv.HostName)] {
+		dsHost, ok := toData.DeliveryServiceHosts[ds]
+		if !ok {
+			continue // not HTTP, or has no single host to request
+		}
+		scheme, port := "http", srv.Port
+		if !dsHost.HTTP {
+			if !dsHost.HTTPS {
+				continue
+			}
+			scheme, port = "https", srv.HTTPSPort
+		}
+		probes = append(probes, poller.Probe{
+			DeliveryService: ds,
+			URL:             scheme + "://" + dsHost.Host + path,
+			Port:            port,
+			ExpectedStatus:  params.HealthProbeStatus,
+			BodySHA256:      params.HealthProbeBodySHA256,
+		})
+	}
+	sort.Slice(probes, func(i, j int) bool { return probes[i].DeliveryService < probes[j].DeliveryService })
+	return probes
+}
+
 // createServerStatPollURL takes the health polling URL string, and modifies it to be the stat poll URL.
 // Note this does not replace template variables with server values, healthPollURLStr must be the health URL for a given server, not a template.
 func createServerStatPollURL(healthPollURLStr string) string {
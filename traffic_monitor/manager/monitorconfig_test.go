@@ -20,9 +20,12 @@ package manager
  */
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_monitor/poller"
+	"github.com/apache/trafficcontrol/traffic_monitor/todata"
 )
 
 func TestCreateServerHealthPollURL(t *testing.T) {
@@ -266,3 +269,27 @@ func TestCreateServerStatPollURL(t *testing.T) {
 		t.Errorf("incorrect IPv6 polling URL; expected: '%s', actual: '%s'", expectedV6, actualV6)
 	}
 }
+
+func TestCreateServerProbes(t *testing.T) {
+	srv := tc.TrafficServer{HostName: "edge", Port: 8080, HTTPSPort: 8443}
+	toData := todata.TOData{
+		ServerDeliveryServices: map[tc.CacheName][]tc.DeliveryServiceName{"edge": {"ds-https", "ds-dns", "ds-http"}},
+		DeliveryServiceHosts: map[tc.DeliveryServiceName]todata.DeliveryServiceHost{
+			"ds-http":  {Host: "cdn.ds-http.example.test", HTTP: true, HTTPS: true},
+			"ds-https": {Host: "cdn.ds-https.example.test", HTTPS: true},
+		},
+	}
+
+	if probes := createServerProbes(srv, tc.TMParameters{}, toData); probes != nil {
+		t.Errorf("probes of server without health.probe.path expected: nil, actual: %+v", probes)
+	}
+
+	params := tc.TMParameters{HealthProbePath: "probe.txt", HealthProbeStatus: 204, HealthProbeBodySHA256: "abc"}
+	expected := []poller.Probe{
+		{DeliveryService: "ds-http", URL: "http://cdn.ds-http.example.test/probe.txt", Port: 8080, ExpectedStatus: 204, BodySHA256: "abc"},
+		{DeliveryService: "ds-https", URL: "https://cdn.ds-https.example.test/probe.txt", Port: 8443, ExpectedStatus: 204, BodySHA256: "abc"},
+	}
+	if actual := createServerProbes(srv, params, toData); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("server probes expected: %+v, actual: %+v", expected, actual)
+	}
+}
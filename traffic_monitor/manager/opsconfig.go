@@ -33,7 +33,9 @@ import (
 	"github.com/apache/trafficcontrol/traffic_monitor/datareq"
 	"github.com/apache/trafficcontrol/traffic_monitor/handler"
 	"github.com/apache/trafficcontrol/traffic_monitor/health"
+	"github.com/apache/trafficcontrol/traffic_monitor/maintenance"
 	"github.com/apache/trafficcontrol/traffic_monitor/peer"
+	"github.com/apache/trafficcontrol/traffic_monitor/poller"
 	"github.com/apache/trafficcontrol/traffic_monitor/srvhttp"
 	"github.com/apache/trafficcontrol/traffic_monitor/threadsafe"
 	"github.com/apache/trafficcontrol/traffic_monitor/todata"
@@ -53,6 +55,8 @@ func StartOpsConfigManager(
 	localStates peer.CRStatesThreadsafe,
 	peerStates peer.CRStatesPeersThreadsafe,
 	combinedStates peer.CRStatesThreadsafe,
+	crStatesFeed *peer.CRStatesFeed,
+	quorumStatus peer.QuorumStatusThreadsafe,
 	statInfoHistory threadsafe.ResultInfoHistory,
 	statResultHistory threadsafe.ResultStatHistory,
 	statMaxKbpses threadsafe.CacheKbpses,
@@ -69,6 +73,7 @@ func StartOpsConfigManager(
 	localCacheStatus threadsafe.CacheAvailableStatus,
 	unpolledCaches threadsafe.UnpolledCaches,
 	monitorConfig threadsafe.TrafficMonitorConfigMap,
+	maintenanceWindows *maintenance.Windows,
 	cfg config.Config,
 ) (threadsafe.OpsConfig, error) {
 
@@ -109,6 +114,8 @@ func StartOpsConfigManager(
 			localStates,
 			peerStates,
 			combinedStates,
+			crStatesFeed,
+			quorumStatus,
 			statInfoHistory,
 			statResultHistory,
 			statMaxKbpses,
@@ -126,6 +133,10 @@ func StartOpsConfigManager(
 			lastStats,
 			unpolledCaches,
 			monitorConfig,
+			maintenanceWindows,
+			cfg.MaintenanceAPIToken,
+			cfg.CDNs,
+			cfg.ServeWriteTimeout,
 		)
 
 		// If the HTTPS Listener is defined in the traffic_ops.cfg file then it creates the HTTPS endpoint and the corresponding HTTP endpoint as a redirect
@@ -171,7 +182,7 @@ func StartOpsConfigManager(
 				log.Errorf("retrying in %v\n", duration)
 				time.Sleep(duration)
 
-				if toSession.BackupFileExists() && (toLoginCount >= cfg.TrafficOpsDiskRetryMax) {
+				if toSession.BackupFileExists(newOpsConfig.CdnName) && (toLoginCount >= cfg.TrafficOpsDiskRetryMax) {
 					log.Errorf("error instantiating Session with traffic_ops, backup disk files exist, creating empty traffic_ops session to read")
 					newOpsConfig.UsingDummyTO = true
 					break
@@ -199,7 +210,7 @@ func StartOpsConfigManager(
 		// Will loop and retry until a good CRConfig is received from traffic_ops
 		backoff.Reset()
 		for {
-			if err := toData.Fetch(toSession, newOpsConfig.CdnName); err != nil {
+			if err := toData.Fetch(toSession, poller.MonitoredCDNs(newOpsConfig.CdnName, cfg.CDNs)...); err != nil {
 				handleErr(fmt.Errorf("Error getting Traffic Ops data: %v\n", err))
 				duration := backoff.BackoffDuration()
 				log.Errorf("retrying in %v\n", duration)
@@ -0,0 +1,83 @@
+package manager
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-log"
+	"github.com/apache/trafficcontrol/traffic_monitor/cache"
+	"github.com/apache/trafficcontrol/traffic_monitor/health"
+	"github.com/apache/trafficcontrol/traffic_monitor/peer"
+	"github.com/apache/trafficcontrol/traffic_monitor/threadsafe"
+	"github.com/apache/trafficcontrol/traffic_monitor/todata"
+)
+
+// ProbeFailureMaxAgeIntervals is the number of probe polling intervals after
+// which a cache's probe failure is cleared if no new probe result for it has
+// been received, e.g. because its probes were removed from the monitoring
+// config.
+const ProbeFailureMaxAgeIntervals = 3
+
+// StartProbeResultManager starts the goroutine which listens for the results
+// of the synthetic probe poller, and records which caches' probes fail in the
+// localCacheStatus.
+func StartProbeResultManager(
+	cacheProbeChan <-chan cache.Result,
+	probeInterval time.Duration,
+	toData todata.TODataThreadsafe,
+	localStates peer.CRStatesThreadsafe,
+	monitorConfig threadsafe.TrafficMonitorConfigMap,
+	localCacheStatus threadsafe.CacheAvailableStatus,
+	combineState func(),
+) {
+	go probeResultManagerListen(cacheProbeChan, probeInterval, toData, localStates, monitorConfig, localCacheStatus, combineState)
+}
+
+func probeResultManagerListen(
+	cacheProbeChan <-chan cache.Result,
+	probeInterval time.Duration,
+	toData todata.TODataThreadsafe,
+	localStates peer.CRStatesThreadsafe,
+	monitorConfig threadsafe.TrafficMonitorConfigMap,
+	localCacheStatus threadsafe.CacheAvailableStatus,
+	combineState func(),
+) {
+	maxAge := probeInterval * ProbeFailureMaxAgeIntervals
+	tick := time.NewTicker(probeInterval)
+	defer tick.Stop()
+	for {
+		select {
+		case result, ok := <-cacheProbeChan:
+			if !ok {
+				return
+			}
+			if health.CalcProbeAvailability([]cache.Result{result}, monitorConfig.Get(), toData.Get(), localCacheStatus, localStates) {
+				combineState()
+			}
+			log.Debugf("poll %v %v finish\n", result.PollID, time.Now())
+			result.PollFinished <- result.PollID
+		case <-tick.C:
+			for _, cacheName := range localCacheStatus.ClearStaleProbeFailures(time.Now().Add(-maxAge)) {
+				log.Infof("Cleared probe failure of %s, which hasn't been probed for %v; it will be marked available by its next poll", cacheName, maxAge)
+			}
+		}
+	}
+}
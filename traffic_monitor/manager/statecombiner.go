@@ -27,13 +27,15 @@ import (
 
 	"github.com/apache/trafficcontrol/lib/go-log"
 	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_monitor/config"
 	"github.com/apache/trafficcontrol/traffic_monitor/health"
+	"github.com/apache/trafficcontrol/traffic_monitor/maintenance"
 	"github.com/apache/trafficcontrol/traffic_monitor/peer"
 	"github.com/apache/trafficcontrol/traffic_monitor/todata"
 )
 
-// StartStateCombiner starts the State Combiner goroutine, and returns the threadsafe CombinedStates, and a func to signal to combine states.
-func StartStateCombiner(events health.ThreadsafeEvents, peerStates peer.CRStatesPeersThreadsafe, localStates peer.CRStatesThreadsafe, toData todata.TODataThreadsafe) (peer.CRStatesThreadsafe, func()) {
+// StartStateCombiner starts the State Combiner goroutine, and returns the threadsafe CombinedStates, and a func to signal to combine states. Each time states are combined, the caches drained by maintenance windows are marked unavailable, the combined states are given to the feed, for streaming to clients, and the peer quorum status is updated.
+func StartStateCombiner(events health.ThreadsafeEvents, peerStates peer.CRStatesPeersThreadsafe, localStates peer.CRStatesThreadsafe, toData todata.TODataThreadsafe, feed *peer.CRStatesFeed, quorumStatus peer.QuorumStatusThreadsafe, quorumCfg config.PeerQuorum, maintenanceWindows *maintenance.Windows) (peer.CRStatesThreadsafe, func()) {
 	combinedStates := peer.NewCRStatesThreadsafe()
 
 	// the chan buffer just reduces the number of goroutines on our infinite buffer hack in combineState(), no real writer will block, since combineState() writes in a goroutine.
@@ -56,9 +58,20 @@ func StartStateCombiner(events health.ThreadsafeEvents, peerStates peer.CRStates
 
 	go func() {
 		overrideMap := map[tc.CacheName]bool{}
+		drainedCaches := map[tc.CacheName]string{}
 		for range combineStateChan {
 			drain(combineStateChan)
-			combineCrStates(events, true, peerStates, localStates.Get(), combinedStates, overrideMap, toData.Get())
+			localCrStates := localStates.Get()
+			weights := peerStates.Weights(quorumCfg)
+			toDataVal := toData.Get()
+			if quorumCfg.Mode == config.PeerQuorumModeWeighted {
+				combineCrStatesWeighted(events, quorumCfg.AvailableThreshold, weights, peerStates, localCrStates, combinedStates, overrideMap, toDataVal)
+			} else {
+				combineCrStates(events, true, peerStates, localCrStates, combinedStates, overrideMap, toDataVal)
+			}
+			applyMaintenance(events, maintenanceWindows.Drained(time.Now(), toDataVal.ServerCachegroups), drainedCaches, combinedStates, toDataVal)
+			updateQuorumStatus(events, quorumCfg, weights, peerStates, localCrStates, quorumStatus)
+			feed.Update(combinedStates.Get())
 		}
 	}()
 
@@ -99,7 +112,11 @@ func combineCacheState(
 			ipv4OnlineOnPeers := make([]string, 0)
 			ipv6OnlineOnPeers := make([]string, 0)
 
+			peerCDNs := peerStates.GetCDNs()
 			for peer, peerCrStates := range peerStates.GetCrstates() {
+				if !monitorsAnyCDN(peerCDNs, peer, toData.ServerCDNs[cacheName]) {
+					continue
+				}
 				if peerStates.GetPeerAvailability(peer) {
 					if peerCrStates.Caches[cacheName].IsAvailable {
 						onlineOnPeers = append(onlineOnPeers, peer.String())
@@ -132,12 +149,154 @@ func combineCacheState(
 	}
 
 	if overrideCondition != "" {
-		events.Add(health.Event{Time: health.Time(time.Now()), Description: fmt.Sprintf("Health protocol override condition %s", overrideCondition), Name: cacheName.String(), Hostname: cacheName.String(), Type: toData.ServerTypes[cacheName].String(), Available: available, IPv4Available: ipv4Available, IPv6Available: ipv6Available})
+		events.Add(health.Event{Time: health.Time(time.Now()), Description: fmt.Sprintf("Health protocol override condition %s", overrideCondition), Name: cacheName.String(), Hostname: cacheName.String(), Type: toData.ServerTypes[cacheName].String(), CacheGroup: string(toData.ServerCachegroups[cacheName]), Available: available, IPv4Available: ipv4Available, IPv6Available: ipv6Available})
 	}
 
 	combinedStates.AddCache(cacheName, tc.IsAvailable{IsAvailable: available, Ipv4Available: ipv4Available, Ipv6Available: ipv6Available})
 }
 
+// combineCacheStateWeighted combines the local and peer states of the given cache by weighted vote. The cache, and each of its IP versions, is available if the weight of the votes for it is at least the given fraction of the total weight. Unavailable peers, peers which don't monitor a CDN of the cache, and peers which don't report the cache, don't vote.
+func combineCacheStateWeighted(
+	cacheName tc.CacheName,
+	localCacheState tc.IsAvailable,
+	events health.ThreadsafeEvents,
+	threshold float64,
+	self tc.TrafficMonitorName,
+	weights map[tc.TrafficMonitorName]float64,
+	peerCrStates map[tc.TrafficMonitorName]tc.CRStates,
+	peerCDNs map[tc.TrafficMonitorName][]tc.CDNName,
+	combinedStates peer.CRStatesThreadsafe,
+	overrideMap map[tc.CacheName]bool,
+	toData todata.TOData,
+) {
+	localAvailable := localCacheState.Ipv4Available || localCacheState.Ipv6Available
+
+	total, availableVotes, ipv4Votes, ipv6Votes := 0.0, 0.0, 0.0, 0.0
+	vote := func(weight float64, available bool, ipv4Available bool, ipv6Available bool) {
+		total += weight
+		if available {
+			availableVotes += weight
+		}
+		if ipv4Available {
+			ipv4Votes += weight
+		}
+		if ipv6Available {
+			ipv6Votes += weight
+		}
+	}
+
+	vote(weights[self], localAvailable, localCacheState.Ipv4Available, localCacheState.Ipv6Available)
+	for peerName, weight := range weights {
+		if peerName == self || !monitorsAnyCDN(peerCDNs, peerName, toData.ServerCDNs[cacheName]) {
+			continue
+		}
+		peerCacheState, ok := peerCrStates[peerName].Caches[cacheName]
+		if !ok {
+			continue
+		}
+		vote(weight, peerCacheState.IsAvailable, peerCacheState.Ipv4Available, peerCacheState.Ipv6Available)
+	}
+
+	available, ipv4Available, ipv6Available := localAvailable, localCacheState.Ipv4Available, localCacheState.Ipv6Available
+	if total > 0 {
+		needed := threshold * total
+		available = availableVotes >= needed
+		ipv4Available = ipv4Votes >= needed
+		ipv6Available = ipv6Votes >= needed
+	}
+
+	overrideCondition := ""
+	overridden := available != localAvailable || ipv4Available != localCacheState.Ipv4Available || ipv6Available != localCacheState.Ipv6Available
+	if override := overrideMap[cacheName]; overridden && !override {
+		overrideCondition = fmt.Sprintf("detected; weighted peer vote %.2f of %.2f available", availableVotes, total)
+		overrideMap[cacheName] = true
+	} else if !overridden && override {
+		overrideCondition = "cleared; weighted peer vote agrees with local health"
+		overrideMap[cacheName] = false
+	}
+
+	if overrideCondition != "" {
+		events.Add(health.Event{Time: health.Time(time.Now()), Description: fmt.Sprintf("Health protocol override condition %s", overrideCondition), Name: cacheName.String(), Hostname: cacheName.String(), Type: toData.ServerTypes[cacheName].String(), CacheGroup: string(toData.ServerCachegroups[cacheName]), Available: available, IPv4Available: ipv4Available, IPv6Available: ipv6Available})
+	}
+
+	combinedStates.AddCache(cacheName, tc.IsAvailable{IsAvailable: available, Ipv4Available: ipv4Available, Ipv6Available: ipv6Available})
+}
+
+// monitorsAnyCDN returns whether the given peer monitors any of the given CDNs of a cache, and so may vote on its health. Peers whose CDNs are unknown, and caches whose CDNs are unknown, are assumed to match.
+func monitorsAnyCDN(peerCDNs map[tc.TrafficMonitorName][]tc.CDNName, peerName tc.TrafficMonitorName, cacheCDNs []tc.CDNName) bool {
+	monitoredCDNs, ok := peerCDNs[peerName]
+	if !ok || len(cacheCDNs) == 0 {
+		return true
+	}
+	for _, monitoredCDN := range monitoredCDNs {
+		for _, cacheCDN := range cacheCDNs {
+			if monitoredCDN == cacheCDN {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// applyMaintenance marks the given drained caches unavailable in the combined states, with the reason they're drained, recomputes the availability of their delivery services, and adds events when caches are drained and restored. The drainedCaches are the caches drained the last time this was called, and are updated.
+func applyMaintenance(events health.ThreadsafeEvents, drained map[tc.CacheName]string, drainedCaches map[tc.CacheName]string, combinedStates peer.CRStatesThreadsafe, toData todata.TOData) {
+	for cacheName, reason := range drained {
+		if _, ok := combinedStates.GetCache(cacheName); !ok {
+			continue
+		}
+		combinedStates.SetCache(cacheName, tc.IsAvailable{IsAvailable: false, Ipv4Available: false, Ipv6Available: false, Reason: reason})
+		if drainedCaches[cacheName] != reason {
+			drainedCaches[cacheName] = reason
+			events.Add(health.Event{Time: health.Time(time.Now()), Description: "Drained by " + reason, Name: cacheName.String(), Hostname: cacheName.String(), Type: toData.ServerTypes[cacheName].String(), CacheGroup: string(toData.ServerCachegroups[cacheName]), Available: false})
+		}
+	}
+	applyMaintenanceDeliveryServices(drained, combinedStates, toData)
+	for cacheName := range drainedCaches {
+		if _, ok := drained[cacheName]; ok {
+			continue
+		}
+		delete(drainedCaches, cacheName)
+		state, ok := combinedStates.GetCache(cacheName)
+		if !ok {
+			continue
+		}
+		events.Add(health.Event{Time: health.Time(time.Now()), Description: "Maintenance drain ended", Name: cacheName.String(), Hostname: cacheName.String(), Type: toData.ServerTypes[cacheName].String(), CacheGroup: string(toData.ServerCachegroups[cacheName]), Available: state.IsAvailable, IPv4Available: state.Ipv4Available, IPv6Available: state.Ipv6Available})
+	}
+}
+
+// applyMaintenanceDeliveryServices marks the delivery services of the given drained caches unavailable if none of their caches are available, and adds the cachegroups with no available caches to their disabled locations.
+// Delivery services of restored caches don't need to be made available here, because they're combined anew from the local and peer states each time states are combined.
+func applyMaintenanceDeliveryServices(drained map[tc.CacheName]string, combinedStates peer.CRStatesThreadsafe, toData todata.TOData) {
+	cacheStates := combinedStates.GetCaches()
+	deliveryServices := map[tc.DeliveryServiceName]struct{}{}
+	for cacheName := range drained {
+		if _, ok := cacheStates[cacheName]; !ok {
+			continue
+		}
+		for _, deliveryServiceName := range toData.ServerDeliveryServices[cacheName] {
+			deliveryServices[deliveryServiceName] = struct{}{}
+		}
+	}
+
+	for deliveryServiceName := range deliveryServices {
+		deliveryService, ok := combinedStates.GetDeliveryService(deliveryServiceName)
+		if !ok {
+			continue
+		}
+		servers := toData.DeliveryServiceServers[deliveryServiceName]
+		cacheAvailable := false
+		for _, server := range servers {
+			if cacheStates[server].IsAvailable {
+				cacheAvailable = true
+				break
+			}
+		}
+		deliveryService.IsAvailable = deliveryService.IsAvailable && cacheAvailable
+		deliveryService.DisabledLocations = union(deliveryService.DisabledLocations, health.GetDisabledLocations(deliveryServiceName, servers, cacheStates, toData.ServerCachegroups))
+		combinedStates.SetDeliveryService(deliveryServiceName, deliveryService)
+	}
+}
+
 func combineDSState(
 	deliveryServiceName tc.DeliveryServiceName,
 	localDeliveryService tc.CRStatesDeliveryService,
@@ -212,6 +371,61 @@ func combineCrStates(events health.ThreadsafeEvents, peerOptimistic bool, peerSt
 	pruneCombinedCaches(combinedStates, localStates)
 }
 
+// combineCrStatesWeighted is combineCrStates for the weighted peer quorum mode, combining cache states by weighted vote. Delivery Services are combined the same as in optimistic mode.
+func combineCrStatesWeighted(events health.ThreadsafeEvents, threshold float64, weights map[tc.TrafficMonitorName]float64, peerStates peer.CRStatesPeersThreadsafe, localStates tc.CRStates, combinedStates peer.CRStatesThreadsafe, overrideMap map[tc.CacheName]bool, toData todata.TOData) {
+	self := peerStates.Self()
+	peerCrStates := peerStates.GetCrstates()
+	peerCDNs := peerStates.GetCDNs()
+	for cacheName, localCacheState := range localStates.Caches {
+		combineCacheStateWeighted(cacheName, localCacheState, events, threshold, self, weights, peerCrStates, peerCDNs, combinedStates, overrideMap, toData)
+	}
+
+	for deliveryServiceName, localDeliveryService := range localStates.DeliveryService {
+		combineDSState(deliveryServiceName, localDeliveryService, peerStates, combinedStates)
+	}
+
+	pruneCombinedDSState(combinedStates, localStates, peerStates)
+	pruneCombinedCaches(combinedStates, localStates)
+}
+
+// updateQuorumStatus compares the local states with those of the voting peers, sets the quorum status, and adds an event when a split-brain is detected or cleared.
+func updateQuorumStatus(events health.ThreadsafeEvents, quorumCfg config.PeerQuorum, weights map[tc.TrafficMonitorName]float64, peerStates peer.CRStatesPeersThreadsafe, localStates tc.CRStates, quorumStatus peer.QuorumStatusThreadsafe) {
+	self := peerStates.Self()
+	states := map[tc.TrafficMonitorName]tc.CRStates{self: localStates}
+	for peerName, peerCrStates := range peerStates.GetCrstates() {
+		if _, ok := weights[peerName]; ok && peerName != self {
+			states[peerName] = peerCrStates
+		}
+	}
+
+	splitBrain, partitions, disputed := peer.DetectSplitBrain(states, weights, quorumCfg.SplitBrainAgreement)
+
+	if splitBrain != quorumStatus.Get().SplitBrain {
+		description := "Peer split-brain cleared"
+		if splitBrain {
+			partitionStrs := make([]string, 0, len(partitions))
+			for _, partition := range partitions {
+				partitionStrs = append(partitionStrs, fmt.Sprintf("%v", partition))
+			}
+			description = fmt.Sprintf("Peer split-brain detected; partitions %s disagree on %d caches", strings.Join(partitionStrs, ", "), len(disputed))
+		}
+		events.Add(health.Event{Time: health.Time(time.Now()), Description: description, Name: self.String(), Hostname: self.String(), Type: "PEER", Available: !splitBrain})
+	}
+
+	mode := quorumCfg.Mode
+	if mode == "" {
+		mode = config.PeerQuorumModeOptimistic
+	}
+	quorumStatus.Set(peer.QuorumStatus{
+		Mode:           mode,
+		Time:           time.Now(),
+		Weights:        weights,
+		SplitBrain:     splitBrain,
+		Partitions:     partitions,
+		DisputedCaches: disputed,
+	})
+}
+
 // CacheNameSlice is a slice of cache names, which fulfills the `sort.Interface` interface.
 type CacheGroupNameSlice []tc.CacheGroupName
 
@@ -219,6 +433,24 @@ func (p CacheGroupNameSlice) Len() int           { return len(p) }
 func (p CacheGroupNameSlice) Less(i, j int) bool { return p[i] < p[j] }
 func (p CacheGroupNameSlice) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
 
+// union returns the cachegroups in either a or b, sorted and without duplicates.
+// Unlike intersection, this doesn't modify a or b.
+func union(a []tc.CacheGroupName, b []tc.CacheGroupName) []tc.CacheGroupName {
+	seen := make(map[tc.CacheGroupName]struct{}, len(a)+len(b))
+	c := make([]tc.CacheGroupName, 0, len(a)+len(b)) // important to initialize, so JSON is `[]` not `null`
+	for _, cgs := range [][]tc.CacheGroupName{a, b} {
+		for _, cg := range cgs {
+			if _, ok := seen[cg]; ok {
+				continue
+			}
+			seen[cg] = struct{}{}
+			c = append(c, cg)
+		}
+	}
+	sort.Sort(CacheGroupNameSlice(c))
+	return c
+}
+
 // intersection returns strings in both a and b.
 // Note this modifies a and b. Specifically, it sorts them. If that isn't acceptable, pass copies of your real data.
 func intersection(a []tc.CacheGroupName, b []tc.CacheGroupName) []tc.CacheGroupName {
@@ -25,6 +25,7 @@ import (
 	"github.com/apache/trafficcontrol/traffic_monitor/peer"
 	"github.com/apache/trafficcontrol/traffic_monitor/todata"
 	"math/rand"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -154,3 +155,164 @@ func TestCombineCacheStateCacheDown(t *testing.T) {
 		t.Fatalf("cache IPv6 is unavailable and should be available")
 	}
 }
+
+func TestCombineCacheStateWeighted(t *testing.T) {
+	cacheName := tc.CacheName("testCache")
+	self := tc.TrafficMonitorName("TestTM-00")
+	up := tc.IsAvailable{IsAvailable: true, Ipv4Available: true, Ipv6Available: true}
+	down := tc.IsAvailable{}
+
+	peerCrStates := map[tc.TrafficMonitorName]tc.CRStates{
+		"TestTM-01": tc.CRStates{Caches: map[tc.CacheName]tc.IsAvailable{cacheName: down}},
+		"TestTM-02": tc.CRStates{Caches: map[tc.CacheName]tc.IsAvailable{cacheName: down}},
+	}
+	toData := todata.TOData{ServerTypes: map[tc.CacheName]tc.CacheType{cacheName: tc.CacheTypeEdge}}
+
+	tests := []struct {
+		name      string
+		weights   map[tc.TrafficMonitorName]float64
+		available bool
+	}{
+		{"local outweighs stale peers", map[tc.TrafficMonitorName]float64{self: 2, "TestTM-01": 0.5, "TestTM-02": 0.5}, true},
+		{"local ties fresh peers", map[tc.TrafficMonitorName]float64{self: 2, "TestTM-01": 1, "TestTM-02": 1}, true},
+		{"local outweighed by nearby peers", map[tc.TrafficMonitorName]float64{self: 2, "TestTM-01": 2, "TestTM-02": 1}, false},
+	}
+	for _, test := range tests {
+		events := health.NewThreadsafeEvents(1)
+		combinedStates := peer.NewCRStatesThreadsafe()
+		overrideMap := map[tc.CacheName]bool{}
+
+		combineCacheStateWeighted(cacheName, up, events, 0.5, self, test.weights, peerCrStates, nil, combinedStates, overrideMap, toData)
+
+		state := combinedStates.Get().Caches[cacheName]
+		if state.IsAvailable != test.available || state.Ipv4Available != test.available || state.Ipv6Available != test.available {
+			t.Errorf("%s: expected available %v, actual %+v", test.name, test.available, state)
+		}
+		if overrideMap[cacheName] == test.available {
+			t.Errorf("%s: expected override %v, actual %v", test.name, !test.available, overrideMap[cacheName])
+		}
+		if overridden := len(events.Get()) > 0; overridden == test.available {
+			t.Errorf("%s: expected override event %v, actual %v", test.name, !test.available, overridden)
+		}
+	}
+}
+
+func TestApplyMaintenance(t *testing.T) {
+	cacheName := tc.CacheName("testCache")
+	up := tc.IsAvailable{IsAvailable: true, Ipv4Available: true, Ipv6Available: true}
+	toData := todata.TOData{ServerTypes: map[tc.CacheName]tc.CacheType{cacheName: tc.CacheTypeEdge}}
+
+	events := health.NewThreadsafeEvents(10)
+	combinedStates := peer.NewCRStatesThreadsafe()
+	combinedStates.AddCache(cacheName, up)
+	drainedCaches := map[tc.CacheName]string{}
+	drained := map[tc.CacheName]string{cacheName: "maintenance 1: upgrade", "unknownCache": "maintenance 1: upgrade"}
+
+	applyMaintenance(events, drained, drainedCaches, combinedStates, toData)
+	state := combinedStates.Get().Caches[cacheName]
+	if state.IsAvailable || state.Ipv4Available || state.Ipv6Available || state.Reason != "maintenance 1: upgrade" {
+		t.Errorf("expected drained cache unavailable with reason, actual %+v", state)
+	}
+	if _, ok := combinedStates.Get().Caches["unknownCache"]; ok {
+		t.Error("expected drained cache not in the combined states to not be added")
+	}
+	if len(events.Get()) != 1 {
+		t.Errorf("expected 1 drain event, actual %d", len(events.Get()))
+	}
+
+	// the combiner resets the cache state before applying maintenance each time
+	combinedStates.AddCache(cacheName, up)
+	applyMaintenance(events, drained, drainedCaches, combinedStates, toData)
+	if len(events.Get()) != 1 {
+		t.Errorf("expected no event for an unchanged drain, actual %d events", len(events.Get()))
+	}
+
+	combinedStates.AddCache(cacheName, up)
+	applyMaintenance(events, map[tc.CacheName]string{}, drainedCaches, combinedStates, toData)
+	if state := combinedStates.Get().Caches[cacheName]; state != up {
+		t.Errorf("expected restored cache state %+v, actual %+v", up, state)
+	}
+	if len(events.Get()) != 2 || len(drainedCaches) != 0 {
+		t.Errorf("expected drain ended event and no drained caches, actual %d events, drained %+v", len(events.Get()), drainedCaches)
+	}
+}
+
+func TestApplyMaintenanceDeliveryServices(t *testing.T) {
+	up := tc.IsAvailable{IsAvailable: true, Ipv4Available: true, Ipv6Available: true}
+	dsName := tc.DeliveryServiceName("testDS")
+	otherDSName := tc.DeliveryServiceName("otherDS")
+	toData := todata.TOData{
+		DeliveryServiceServers: map[tc.DeliveryServiceName][]tc.CacheName{dsName: {"cache-a1", "cache-a2", "cache-b1"}, otherDSName: {"cache-c1"}},
+		ServerDeliveryServices: map[tc.CacheName][]tc.DeliveryServiceName{"cache-a1": {dsName}, "cache-a2": {dsName}, "cache-b1": {dsName}, "cache-c1": {otherDSName}},
+		ServerCachegroups:      map[tc.CacheName]tc.CacheGroupName{"cache-a1": "cg-a", "cache-a2": "cg-a", "cache-b1": "cg-b", "cache-c1": "cg-c"},
+		ServerTypes:            map[tc.CacheName]tc.CacheType{"cache-a1": tc.CacheTypeEdge, "cache-a2": tc.CacheTypeEdge, "cache-b1": tc.CacheTypeEdge, "cache-c1": tc.CacheTypeEdge},
+	}
+	resetStates := func(combinedStates peer.CRStatesThreadsafe) {
+		for cacheName := range toData.ServerCachegroups {
+			combinedStates.AddCache(cacheName, up)
+		}
+		combinedStates.SetDeliveryService(dsName, tc.CRStatesDeliveryService{IsAvailable: true, DisabledLocations: []tc.CacheGroupName{}})
+		combinedStates.SetDeliveryService(otherDSName, tc.CRStatesDeliveryService{IsAvailable: true, DisabledLocations: []tc.CacheGroupName{}})
+	}
+
+	events := health.NewThreadsafeEvents(10)
+	combinedStates := peer.NewCRStatesThreadsafe()
+	drainedCaches := map[tc.CacheName]string{}
+
+	resetStates(combinedStates)
+	applyMaintenance(events, map[tc.CacheName]string{"cache-a1": "maintenance 1: upgrade", "cache-a2": "maintenance 1: upgrade"}, drainedCaches, combinedStates, toData)
+	ds, _ := combinedStates.GetDeliveryService(dsName)
+	if !ds.IsAvailable || !reflect.DeepEqual(ds.DisabledLocations, []tc.CacheGroupName{"cg-a"}) {
+		t.Errorf("expected delivery service available with drained cachegroup cg-a disabled, actual %+v", ds)
+	}
+	if otherDS, _ := combinedStates.GetDeliveryService(otherDSName); !otherDS.IsAvailable || len(otherDS.DisabledLocations) != 0 {
+		t.Errorf("expected delivery service without drained caches unchanged, actual %+v", otherDS)
+	}
+
+	resetStates(combinedStates)
+	applyMaintenance(events, map[tc.CacheName]string{"cache-a1": "maintenance 1: upgrade", "cache-a2": "maintenance 1: upgrade", "cache-b1": "maintenance 2: upgrade"}, drainedCaches, combinedStates, toData)
+	ds, _ = combinedStates.GetDeliveryService(dsName)
+	if ds.IsAvailable || !reflect.DeepEqual(ds.DisabledLocations, []tc.CacheGroupName{"cg-a", "cg-b"}) {
+		t.Errorf("expected delivery service with all caches drained unavailable with cg-a and cg-b disabled, actual %+v", ds)
+	}
+
+	resetStates(combinedStates)
+	applyMaintenance(events, map[tc.CacheName]string{}, drainedCaches, combinedStates, toData)
+	if ds, _ = combinedStates.GetDeliveryService(dsName); !ds.IsAvailable || len(ds.DisabledLocations) != 0 {
+		t.Errorf("expected delivery service of restored caches available without disabled locations, actual %+v", ds)
+	}
+}
+
+func TestUnion(t *testing.T) {
+	a := []tc.CacheGroupName{"cg-b", "cg-a"}
+	b := []tc.CacheGroupName{"cg-c", "cg-b"}
+	if actual := union(a, b); !reflect.DeepEqual(actual, []tc.CacheGroupName{"cg-a", "cg-b", "cg-c"}) {
+		t.Errorf("expected union [cg-a cg-b cg-c], actual %v", actual)
+	}
+	if !reflect.DeepEqual(a, []tc.CacheGroupName{"cg-b", "cg-a"}) {
+		t.Errorf("expected union not to modify its arguments, actual %v", a)
+	}
+	if actual := union(nil, nil); actual == nil || len(actual) != 0 {
+		t.Errorf("expected empty non-nil union, actual %#v", actual)
+	}
+}
+
+func TestMonitorsAnyCDN(t *testing.T) {
+	peerCDNs := map[tc.TrafficMonitorName][]tc.CDNName{"tm-a": {"cdn-a"}, "tm-ab": {"cdn-a", "cdn-b"}}
+	tests := []struct {
+		peer      tc.TrafficMonitorName
+		cacheCDNs []tc.CDNName
+		expected  bool
+	}{
+		{"tm-a", []tc.CDNName{"cdn-a"}, true},
+		{"tm-a", []tc.CDNName{"cdn-b"}, false},
+		{"tm-ab", []tc.CDNName{"cdn-b", "cdn-c"}, true},
+		{"tm-unknown", []tc.CDNName{"cdn-b"}, true},
+		{"tm-a", nil, true},
+	}
+	for _, test := range tests {
+		if actual := monitorsAnyCDN(peerCDNs, test.peer, test.cacheCDNs); actual != test.expected {
+			t.Errorf("%s %v: expected %v, actual %v", test.peer, test.cacheCDNs, test.expected, actual)
+		}
+	}
+}
@@ -124,6 +124,9 @@ type CRStatesPeersThreadsafe struct {
 	peerCount  *int
 	quorumMin  *int
 	timeout    *time.Duration
+	self       *tc.TrafficMonitorName
+	locations  *map[tc.TrafficMonitorName]tc.CacheGroupName
+	cdns       *map[tc.TrafficMonitorName][]tc.CDNName
 	m          *sync.RWMutex
 }
 
@@ -131,9 +134,15 @@ type CRStatesPeersThreadsafe struct {
 func NewCRStatesPeersThreadsafe(quorumMin int) CRStatesPeersThreadsafe {
 	count := 0
 	timeout := time.Hour // default to a large timeout
+	self := tc.TrafficMonitorName("")
+	locations := map[tc.TrafficMonitorName]tc.CacheGroupName{}
+	cdns := map[tc.TrafficMonitorName][]tc.CDNName{}
 	return CRStatesPeersThreadsafe{
 		m:          &sync.RWMutex{},
 		timeout:    &timeout,
+		self:       &self,
+		locations:  &locations,
+		cdns:       &cdns,
 		peerOnline: map[tc.TrafficMonitorName]bool{},
 		crStates:   map[tc.TrafficMonitorName]tc.CRStates{},
 		peerStates: map[tc.TrafficMonitorName]bool{},
@@ -271,6 +280,73 @@ func (t *CRStatesPeersThreadsafe) HasOptimisticQuorum() (bool, int, int, int) {
 	return false, available, *t.peerCount, *t.quorumMin
 }
 
+// HasOptimisticQuorumCDN is HasOptimisticQuorum for the given monitored CDN, counting only the peers which monitor it.
+func (t *CRStatesPeersThreadsafe) HasOptimisticQuorumCDN(cdn tc.CDNName) (bool, int, int, int) {
+	t.m.RLock()
+	defer t.m.RUnlock()
+
+	available, peerCount := 0, 0
+	for peer, online := range t.peerOnline {
+		if !online || !t.monitorsCDN(peer, cdn) {
+			continue
+		}
+		peerCount++
+		if t.peerStates[peer] {
+			available++
+		}
+	}
+	return available >= *t.quorumMin, available, peerCount, *t.quorumMin
+}
+
+// OptimisticQuorumEnabledCDN is OptimisticQuorumEnabled for the given monitored CDN, counting only the peers which monitor it.
+func (t *CRStatesPeersThreadsafe) OptimisticQuorumEnabledCDN(cdn tc.CDNName) bool {
+	t.m.RLock()
+	defer t.m.RUnlock()
+
+	peerCount := 0
+	for peer, online := range t.peerOnline {
+		if online && t.monitorsCDN(peer, cdn) {
+			peerCount++
+		}
+	}
+	return *t.quorumMin > 0 && peerCount > 1
+}
+
+// SetCDNs sets the monitored CDNs of each peer, from the monitoring configs of the CDNs in which they're found.
+func (t *CRStatesPeersThreadsafe) SetCDNs(cdns map[tc.TrafficMonitorName][]tc.CDNName) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	*t.cdns = cdns
+}
+
+// GetCDNs returns the monitored CDNs of each peer, as set by SetCDNs. This MUST NOT be modified.
+func (t *CRStatesPeersThreadsafe) GetCDNs() map[tc.TrafficMonitorName][]tc.CDNName {
+	t.m.RLock()
+	defer t.m.RUnlock()
+	return *t.cdns
+}
+
+// MonitorsCDN returns whether the given peer monitors the given CDN.
+func (t *CRStatesPeersThreadsafe) MonitorsCDN(peer tc.TrafficMonitorName, cdn tc.CDNName) bool {
+	t.m.RLock()
+	defer t.m.RUnlock()
+	return t.monitorsCDN(peer, cdn)
+}
+
+// monitorsCDN returns whether the given peer monitors the given CDN. Peers whose CDNs are unknown are assumed to monitor every CDN, as they did before CDNs were set. Callers must lock t.
+func (t *CRStatesPeersThreadsafe) monitorsCDN(peer tc.TrafficMonitorName, cdn tc.CDNName) bool {
+	peerCDNs, ok := (*t.cdns)[peer]
+	if !ok {
+		return true
+	}
+	for _, peerCDN := range peerCDNs {
+		if peerCDN == cdn {
+			return true
+		}
+	}
+	return false
+}
+
 // OptimisticQuorumEnabled returns true when peer_optimistic_quorum_min is set to a value greater than zero and the number of peers is greater than 1. Optimistic quorum requires a minimum of three Traffic Monitors; every individual monitor requires at least two peers to prevent a split-brain scenario that would be caused by having a single peer. If a single peer was legal (i.e.: two Traffic Monitors), neither peer would know which peer is reachable, and consequently both would serve 503s. This would force all Traffic Routers to use only their last-known state until the peering is restored, despite the fact that one of the two Traffic Monitors could still be reachable. A future enhancement could employ a heuristic to enable two monitors to determine whether they are offline independently by combining peer connectivity state with a calculation around the number of caches that are reachable, which might also include a rate of change in cache health state.
 func (t *CRStatesPeersThreadsafe) OptimisticQuorumEnabled() bool {
 	t.m.RLock()
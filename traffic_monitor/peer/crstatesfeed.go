@@ -0,0 +1,246 @@
+package peer
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+)
+
+// DefaultCRStatesFeedHistory is the default number of deltas a CRStatesFeed
+// keeps for clients resuming a feed.
+const DefaultCRStatesFeedHistory = 1000
+
+// CRStatesSnapshot is the full CRStates at a point in a CRStatesFeed.
+type CRStatesSnapshot struct {
+	Sequence uint64 `json:"sequence"`
+	tc.CRStates
+}
+
+// CRStatesDelta is a change to the CRStates of a CRStatesFeed. Caches and
+// DeliveryServices hold the full new state of each cache and Delivery Service
+// which was added or changed.
+type CRStatesDelta struct {
+	Sequence                uint64                                                `json:"sequence"`
+	Caches                  map[tc.CacheName]tc.IsAvailable                       `json:"caches,omitempty"`
+	DeliveryServices        map[tc.DeliveryServiceName]tc.CRStatesDeliveryService `json:"deliveryServices,omitempty"`
+	RemovedCaches           []tc.CacheName                                        `json:"removedCaches,omitempty"`
+	RemovedDeliveryServices []tc.DeliveryServiceName                              `json:"removedDeliveryServices,omitempty"`
+}
+
+// CRStatesFeed turns successive CRStates into a sequence of numbered deltas,
+// keeping the latest deltas so clients which lost their connection can resume
+// without missing changes. It is safe for one writer and multiple readers.
+//
+// Sequence numbers start from 1 each time Traffic Monitor starts, so each
+// feed also has an epoch, which clients must present along with a sequence to
+// resume from it.
+type CRStatesFeed struct {
+	m       *sync.RWMutex
+	epoch   string
+	seq     uint64
+	states  tc.CRStates
+	history []CRStatesDelta
+	max     int
+	changed chan struct{}
+}
+
+// NewCRStatesFeed creates a new CRStatesFeed, keeping the given number of
+// deltas for resuming clients.
+func NewCRStatesFeed(historyMax int) *CRStatesFeed {
+	return &CRStatesFeed{
+		m:       &sync.RWMutex{},
+		epoch:   strconv.FormatInt(time.Now().UnixNano(), 36),
+		states:  tc.NewCRStates(),
+		max:     historyMax,
+		changed: make(chan struct{}),
+	}
+}
+
+// Update sets the current CRStates of the feed. If they differ from the
+// previous CRStates, a new delta is added, and Changed channels are closed.
+// This MUST NOT be called by multiple goroutines.
+func (f *CRStatesFeed) Update(states tc.CRStates) {
+	delta := diffCRStates(f.states, states)
+	if delta == nil {
+		return
+	}
+
+	f.m.Lock()
+	f.seq++
+	delta.Sequence = f.seq
+	f.states = copyCRStatesDeep(states)
+	f.history = append(f.history, *delta)
+	if len(f.history) > f.max {
+		f.history = f.history[len(f.history)-f.max:]
+	}
+	changed := f.changed
+	f.changed = make(chan struct{})
+	f.m.Unlock()
+
+	close(changed)
+}
+
+// Snapshot returns the current CRStates of the feed.
+func (f *CRStatesFeed) Snapshot() CRStatesSnapshot {
+	f.m.RLock()
+	defer f.m.RUnlock()
+	return CRStatesSnapshot{Sequence: f.seq, CRStates: copyCRStatesDeep(f.states)}
+}
+
+// Since returns the deltas after the given sequence, oldest first. It returns
+// false if the deltas after the sequence are no longer kept, or the sequence
+// isn't one of this feed's, in which case the client must start from a
+// Snapshot.
+func (f *CRStatesFeed) Since(seq uint64) ([]CRStatesDelta, bool) {
+	f.m.RLock()
+	defer f.m.RUnlock()
+	if seq > f.seq {
+		return nil, false
+	}
+	if seq == f.seq {
+		return nil, true
+	}
+	if len(f.history) == 0 || f.history[0].Sequence > seq+1 {
+		return nil, false
+	}
+	deltas := f.history[seq+1-f.history[0].Sequence:]
+	return append([]CRStatesDelta(nil), deltas...), true
+}
+
+// Changed returns a channel which is closed when the feed next changes.
+func (f *CRStatesFeed) Changed() <-chan struct{} {
+	f.m.RLock()
+	defer f.m.RUnlock()
+	return f.changed
+}
+
+// EventID returns the identifier of the given sequence of this feed, of the
+// form `epoch:sequence`.
+func (f *CRStatesFeed) EventID(seq uint64) string {
+	return f.epoch + ":" + strconv.FormatUint(seq, 10)
+}
+
+// ParseEventID returns the sequence of the given identifier returned by
+// EventID. It returns an error if the identifier is malformed, or is from a
+// different epoch, e.g. from before Traffic Monitor restarted.
+func (f *CRStatesFeed) ParseEventID(id string) (uint64, error) {
+	sep := strings.LastIndexByte(id, ':')
+	if sep < 0 {
+		return 0, errors.New("malformed event ID '" + id + "'")
+	}
+	if id[:sep] != f.epoch {
+		return 0, errors.New("event ID '" + id + "' is from a different epoch")
+	}
+	seq, err := strconv.ParseUint(id[sep+1:], 10, 64)
+	if err != nil {
+		return 0, errors.New("malformed event ID '" + id + "' sequence: " + err.Error())
+	}
+	return seq, nil
+}
+
+// diffCRStates returns the changes from a to b, or nil if there are none.
+func diffCRStates(a tc.CRStates, b tc.CRStates) *CRStatesDelta {
+	delta := CRStatesDelta{}
+	changed := false
+
+	for name, cache := range b.Caches {
+		if prev, ok := a.Caches[name]; ok && prev == cache {
+			continue
+		}
+		if delta.Caches == nil {
+			delta.Caches = map[tc.CacheName]tc.IsAvailable{}
+		}
+		delta.Caches[name] = cache
+		changed = true
+	}
+	for name := range a.Caches {
+		if _, ok := b.Caches[name]; !ok {
+			delta.RemovedCaches = append(delta.RemovedCaches, name)
+			changed = true
+		}
+	}
+
+	for name, ds := range b.DeliveryService {
+		if prev, ok := a.DeliveryService[name]; ok && equalCRStatesDeliveryService(prev, ds) {
+			continue
+		}
+		if delta.DeliveryServices == nil {
+			delta.DeliveryServices = map[tc.DeliveryServiceName]tc.CRStatesDeliveryService{}
+		}
+		delta.DeliveryServices[name] = copyCRStatesDeliveryService(ds)
+		changed = true
+	}
+	for name := range a.DeliveryService {
+		if _, ok := b.DeliveryService[name]; !ok {
+			delta.RemovedDeliveryServices = append(delta.RemovedDeliveryServices, name)
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+	sort.Slice(delta.RemovedCaches, func(i, j int) bool { return delta.RemovedCaches[i] < delta.RemovedCaches[j] })
+	sort.Slice(delta.RemovedDeliveryServices, func(i, j int) bool { return delta.RemovedDeliveryServices[i] < delta.RemovedDeliveryServices[j] })
+	return &delta
+}
+
+// equalCRStatesDeliveryService returns whether a and b have the same
+// availability and disabled locations, in any order.
+func equalCRStatesDeliveryService(a tc.CRStatesDeliveryService, b tc.CRStatesDeliveryService) bool {
+	if a.IsAvailable != b.IsAvailable || len(a.DisabledLocations) != len(b.DisabledLocations) {
+		return false
+	}
+	locations := make(map[tc.CacheGroupName]int, len(a.DisabledLocations))
+	for _, location := range a.DisabledLocations {
+		locations[location]++
+	}
+	for _, location := range b.DisabledLocations {
+		if locations[location] == 0 {
+			return false
+		}
+		locations[location]--
+	}
+	return true
+}
+
+// copyCRStatesDeliveryService copies ds, including its DisabledLocations,
+// which the state combiner may sort in place.
+func copyCRStatesDeliveryService(ds tc.CRStatesDeliveryService) tc.CRStatesDeliveryService {
+	locations := make([]tc.CacheGroupName, len(ds.DisabledLocations))
+	copy(locations, ds.DisabledLocations)
+	ds.DisabledLocations = locations
+	return ds
+}
+
+func copyCRStatesDeep(states tc.CRStates) tc.CRStates {
+	c := states.Copy()
+	for name, ds := range c.DeliveryService {
+		c.DeliveryService[name] = copyCRStatesDeliveryService(ds)
+	}
+	return c
+}
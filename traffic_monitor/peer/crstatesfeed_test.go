@@ -0,0 +1,137 @@
+package peer
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+)
+
+func testCRStates(available map[tc.CacheName]bool, dsDisabled map[tc.DeliveryServiceName][]tc.CacheGroupName) tc.CRStates {
+	states := tc.NewCRStates()
+	for name, isAvailable := range available {
+		states.Caches[name] = tc.IsAvailable{IsAvailable: isAvailable, Ipv4Available: isAvailable, Ipv6Available: isAvailable}
+	}
+	for name, disabled := range dsDisabled {
+		states.DeliveryService[name] = tc.CRStatesDeliveryService{IsAvailable: true, DisabledLocations: disabled}
+	}
+	return states
+}
+
+func TestCRStatesFeedDeltas(t *testing.T) {
+	feed := NewCRStatesFeed(DefaultCRStatesFeedHistory)
+
+	changed := feed.Changed()
+	feed.Update(testCRStates(map[tc.CacheName]bool{"edge0": true, "edge1": true}, map[tc.DeliveryServiceName][]tc.CacheGroupName{"ds0": {"cg0", "cg1"}}))
+	select {
+	case <-changed:
+	default:
+		t.Fatal("expected Changed channel to be closed after an update")
+	}
+
+	// an identical update, with disabled locations in a different order, isn't a change
+	changed = feed.Changed()
+	feed.Update(testCRStates(map[tc.CacheName]bool{"edge0": true, "edge1": true}, map[tc.DeliveryServiceName][]tc.CacheGroupName{"ds0": {"cg1", "cg0"}}))
+	select {
+	case <-changed:
+		t.Fatal("expected Changed channel to be open after an update with no changes")
+	default:
+	}
+
+	feed.Update(testCRStates(map[tc.CacheName]bool{"edge0": false, "edge2": true}, map[tc.DeliveryServiceName][]tc.CacheGroupName{}))
+
+	snapshot := feed.Snapshot()
+	if snapshot.Sequence != 2 {
+		t.Fatalf("expected snapshot sequence 2, actual %d", snapshot.Sequence)
+	}
+
+	deltas, ok := feed.Since(0)
+	if !ok {
+		t.Fatal("expected Since(0) to succeed")
+	}
+	if len(deltas) != 2 {
+		t.Fatalf("expected 2 deltas, actual %d", len(deltas))
+	}
+
+	delta := deltas[1]
+	if delta.Sequence != 2 {
+		t.Errorf("expected delta sequence 2, actual %d", delta.Sequence)
+	}
+	expectedCaches := map[tc.CacheName]tc.IsAvailable{
+		"edge0": tc.IsAvailable{},
+		"edge2": tc.IsAvailable{IsAvailable: true, Ipv4Available: true, Ipv6Available: true},
+	}
+	if !reflect.DeepEqual(delta.Caches, expectedCaches) {
+		t.Errorf("expected changed caches %+v, actual %+v", expectedCaches, delta.Caches)
+	}
+	if !reflect.DeepEqual(delta.RemovedCaches, []tc.CacheName{"edge1"}) {
+		t.Errorf("expected removed caches [edge1], actual %+v", delta.RemovedCaches)
+	}
+	if !reflect.DeepEqual(delta.RemovedDeliveryServices, []tc.DeliveryServiceName{"ds0"}) {
+		t.Errorf("expected removed delivery services [ds0], actual %+v", delta.RemovedDeliveryServices)
+	}
+
+	if deltas, ok := feed.Since(2); !ok || len(deltas) != 0 {
+		t.Errorf("expected Since(current) to succeed with no deltas, actual %d deltas ok %v", len(deltas), ok)
+	}
+	if _, ok := feed.Since(3); ok {
+		t.Error("expected Since(future) to fail")
+	}
+}
+
+func TestCRStatesFeedHistory(t *testing.T) {
+	feed := NewCRStatesFeed(2)
+	for i := 0; i < 4; i++ {
+		feed.Update(testCRStates(map[tc.CacheName]bool{"edge0": i%2 == 0}, nil))
+	}
+
+	if _, ok := feed.Since(1); ok {
+		t.Error("expected Since a sequence no longer kept to fail")
+	}
+	deltas, ok := feed.Since(2)
+	if !ok {
+		t.Fatal("expected Since the oldest kept sequence to succeed")
+	}
+	if len(deltas) != 2 || deltas[0].Sequence != 3 || deltas[1].Sequence != 4 {
+		t.Errorf("expected deltas 3 and 4, actual %+v", deltas)
+	}
+}
+
+func TestCRStatesFeedEventID(t *testing.T) {
+	feed := NewCRStatesFeed(DefaultCRStatesFeedHistory)
+	other := NewCRStatesFeed(DefaultCRStatesFeedHistory)
+	other.epoch = feed.epoch + "0"
+
+	seq, err := feed.ParseEventID(feed.EventID(42))
+	if err != nil {
+		t.Fatalf("parsing event ID: %v", err)
+	}
+	if seq != 42 {
+		t.Errorf("expected sequence 42, actual %d", seq)
+	}
+
+	for _, id := range []string{other.EventID(42), "42", feed.EventID(0) + "x"} {
+		if _, err := feed.ParseEventID(id); err == nil {
+			t.Errorf("expected error parsing event ID '%s'", id)
+		}
+	}
+}
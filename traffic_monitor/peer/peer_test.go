@@ -49,3 +49,25 @@ func TestCrStates(t *testing.T) {
 	}
 
 }
+
+func TestHasOptimisticQuorumCDN(t *testing.T) {
+	peerStates := NewCRStatesPeersThreadsafe(2)
+	for _, name := range []tc.TrafficMonitorName{"tm-a0", "tm-a1", "tm-b0"} {
+		peerStates.Set(Result{ID: name, Available: true, PeerStates: tc.NewCRStates()})
+	}
+	peerStates.SetPeers(map[tc.TrafficMonitorName]struct{}{"tm-a0": {}, "tm-a1": {}, "tm-b0": {}})
+	peerStates.SetCDNs(map[tc.TrafficMonitorName][]tc.CDNName{"tm-a0": {"cdn-a"}, "tm-a1": {"cdn-a"}, "tm-b0": {"cdn-b"}})
+
+	if !peerStates.OptimisticQuorumEnabledCDN("cdn-a") {
+		t.Error("expected optimistic quorum enabled for cdn-a with 2 peers")
+	}
+	if quorum, available, count, _ := peerStates.HasOptimisticQuorumCDN("cdn-a"); !quorum || available != 2 || count != 2 {
+		t.Errorf("expected cdn-a quorum with 2/2 peers, actual %v %d/%d", quorum, available, count)
+	}
+	if peerStates.OptimisticQuorumEnabledCDN("cdn-b") {
+		t.Error("expected optimistic quorum disabled for cdn-b with 1 peer")
+	}
+	if quorum, available, count, _ := peerStates.HasOptimisticQuorumCDN("cdn-b"); quorum || available != 1 || count != 1 {
+		t.Errorf("expected no cdn-b quorum with 1/1 peers, actual %v %d/%d", quorum, available, count)
+	}
+}
@@ -0,0 +1,258 @@
+package peer
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_monitor/config"
+)
+
+// QuorumStatus is the result of comparing the health states of this Traffic Monitor and its available peers.
+type QuorumStatus struct {
+	// Mode is the config.PeerQuorum mode used to combine health states.
+	Mode string `json:"mode"`
+	// Time is when the status was computed.
+	Time time.Time `json:"time"`
+	// Weights are the vote weights of this Traffic Monitor and its available peers. Unavailable peers don't vote.
+	Weights map[tc.TrafficMonitorName]float64 `json:"weights"`
+	// SplitBrain is whether the Traffic Monitors have split into disagreeing partitions, none of which has a majority of the weight.
+	SplitBrain bool `json:"splitBrain"`
+	// Partitions are the groups of Traffic Monitors which agree with each other, largest weight first.
+	Partitions [][]tc.TrafficMonitorName `json:"partitions"`
+	// DisputedCaches are the caches whose availability differs between partitions.
+	DisputedCaches []tc.CacheName `json:"disputedCaches"`
+}
+
+// Disputed returns whether the given cache's availability differs between partitions.
+func (s QuorumStatus) Disputed(cache tc.CacheName) bool {
+	i := sort.Search(len(s.DisputedCaches), func(i int) bool { return s.DisputedCaches[i] >= cache })
+	return i < len(s.DisputedCaches) && s.DisputedCaches[i] == cache
+}
+
+// QuorumStatusThreadsafe provides safe access for multiple goroutines to read a QuorumStatus, with a single goroutine writer.
+type QuorumStatusThreadsafe struct {
+	status *QuorumStatus
+	m      *sync.RWMutex
+}
+
+// NewQuorumStatusThreadsafe creates a new QuorumStatusThreadsafe safe for multiple goroutine readers and a single writer.
+func NewQuorumStatusThreadsafe() QuorumStatusThreadsafe {
+	return QuorumStatusThreadsafe{status: &QuorumStatus{}, m: &sync.RWMutex{}}
+}
+
+// Get returns the QuorumStatus. Callers MUST NOT modify it.
+func (t *QuorumStatusThreadsafe) Get() QuorumStatus {
+	t.m.RLock()
+	defer t.m.RUnlock()
+	return *t.status
+}
+
+// Set sets the QuorumStatus. This MUST NOT be called by multiple goroutines.
+func (t *QuorumStatusThreadsafe) Set(status QuorumStatus) {
+	t.m.Lock()
+	*t.status = status
+	t.m.Unlock()
+}
+
+// SetLocations sets the name of this Traffic Monitor, and the Cache Groups of it and its peers, used to weight peer votes.
+func (t *CRStatesPeersThreadsafe) SetLocations(self tc.TrafficMonitorName, locations map[tc.TrafficMonitorName]tc.CacheGroupName) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	*t.self = self
+	*t.locations = locations
+}
+
+// Self returns the name of this Traffic Monitor, as set by SetLocations.
+func (t *CRStatesPeersThreadsafe) Self() tc.TrafficMonitorName {
+	t.m.RLock()
+	defer t.m.RUnlock()
+	return *t.self
+}
+
+// Weights returns the vote weights of this Traffic Monitor and its available peers.
+//
+// A peer's weight is its locality weight - cfg.SameCacheGroupWeight if it's in this Traffic Monitor's Cache Group, cfg.SameRegionWeight if it's in the same region per cfg.Regions, and otherwise 1 - multiplied by its freshness, which falls linearly from 1 when it was just polled to 0 at the peer timeout. This Traffic Monitor's own weight is cfg.SameCacheGroupWeight.
+func (t *CRStatesPeersThreadsafe) Weights(cfg config.PeerQuorum) map[tc.TrafficMonitorName]float64 {
+	t.m.RLock()
+	defer t.m.RUnlock()
+
+	selfLocation := (*t.locations)[*t.self]
+	selfRegion := cfg.Regions[string(selfLocation)]
+
+	weights := map[tc.TrafficMonitorName]float64{*t.self: cfg.SameCacheGroupWeight}
+	now := time.Now()
+	for peer := range t.crStates {
+		if !t.peerStates[peer] || !t.peerOnline[peer] {
+			continue
+		}
+		age := now.Sub(t.peerTimes[peer])
+		if age >= *t.timeout {
+			continue
+		}
+		freshness := 1.0
+		if age > 0 {
+			freshness = 1 - float64(age)/float64(*t.timeout)
+		}
+
+		locality := 1.0
+		location := (*t.locations)[peer]
+		if location != "" && location == selfLocation {
+			locality = cfg.SameCacheGroupWeight
+		} else if region := cfg.Regions[string(location)]; region != "" && region == selfRegion {
+			locality = cfg.SameRegionWeight
+		}
+		weights[peer] = freshness * locality
+	}
+	return weights
+}
+
+// DetectSplitBrain groups the Traffic Monitors with the given weights into partitions, where each Traffic Monitor agrees on the availability of at least the fraction agreement of the caches both report with some other Traffic Monitor in its partition.
+//
+// The Traffic Monitors are split-brained if there is more than one partition, and none has more than half of the total weight. The disputed caches are those for which the weighted majority of some partitions differ.
+func DetectSplitBrain(states map[tc.TrafficMonitorName]tc.CRStates, weights map[tc.TrafficMonitorName]float64, agreement float64) (bool, [][]tc.TrafficMonitorName, []tc.CacheName) {
+	monitors := make([]tc.TrafficMonitorName, 0, len(weights))
+	for monitor := range weights {
+		if _, ok := states[monitor]; ok {
+			monitors = append(monitors, monitor)
+		}
+	}
+	sort.Slice(monitors, func(i, j int) bool { return monitors[i] < monitors[j] })
+
+	// union-find of monitors which agree
+	parents := make([]int, len(monitors))
+	for i := range parents {
+		parents[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parents[i] != i {
+			parents[i] = find(parents[i])
+		}
+		return parents[i]
+	}
+	for i := 0; i < len(monitors); i++ {
+		for j := i + 1; j < len(monitors); j++ {
+			if agrees(states[monitors[i]], states[monitors[j]], agreement) {
+				parents[find(i)] = find(j)
+			}
+		}
+	}
+
+	partitionIndexes := map[int]int{}
+	partitions := [][]tc.TrafficMonitorName{}
+	partitionWeights := []float64{}
+	totalWeight := 0.0
+	for i, monitor := range monitors {
+		root := find(i)
+		pi, ok := partitionIndexes[root]
+		if !ok {
+			pi = len(partitions)
+			partitionIndexes[root] = pi
+			partitions = append(partitions, nil)
+			partitionWeights = append(partitionWeights, 0)
+		}
+		partitions[pi] = append(partitions[pi], monitor)
+		partitionWeights[pi] += weights[monitor]
+		totalWeight += weights[monitor]
+	}
+
+	order := make([]int, len(partitions))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return partitionWeights[order[i]] > partitionWeights[order[j]] })
+	sorted := make([][]tc.TrafficMonitorName, len(partitions))
+	for i, pi := range order {
+		sorted[i] = partitions[pi]
+	}
+
+	if len(partitions) < 2 {
+		return false, sorted, []tc.CacheName{}
+	}
+
+	splitBrain := partitionWeights[order[0]] <= totalWeight/2
+	return splitBrain, sorted, disputedCaches(states, weights, sorted)
+}
+
+// agrees returns whether a and b agree on the availability of at least the fraction agreement of the caches both report. Monitors which report no caches in common agree.
+func agrees(a tc.CRStates, b tc.CRStates, agreement float64) bool {
+	common := 0
+	same := 0
+	for cache, aAvailable := range a.Caches {
+		bAvailable, ok := b.Caches[cache]
+		if !ok {
+			continue
+		}
+		common++
+		if aAvailable.IsAvailable == bAvailable.IsAvailable {
+			same++
+		}
+	}
+	if common == 0 {
+		return true
+	}
+	return float64(same)/float64(common) >= agreement
+}
+
+// disputedCaches returns the caches whose weighted majority availability differs between the given partitions, sorted.
+func disputedCaches(states map[tc.TrafficMonitorName]tc.CRStates, weights map[tc.TrafficMonitorName]float64, partitions [][]tc.TrafficMonitorName) []tc.CacheName {
+	type vote struct{ available, total float64 }
+	votes := make([]map[tc.CacheName]vote, len(partitions))
+	caches := map[tc.CacheName]struct{}{}
+	for i, partition := range partitions {
+		votes[i] = map[tc.CacheName]vote{}
+		for _, monitor := range partition {
+			for cache, available := range states[monitor].Caches {
+				caches[cache] = struct{}{}
+				v := votes[i][cache]
+				v.total += weights[monitor]
+				if available.IsAvailable {
+					v.available += weights[monitor]
+				}
+				votes[i][cache] = v
+			}
+		}
+	}
+
+	disputed := []tc.CacheName{}
+	for cache := range caches {
+		seenAvailable, seenUnavailable := false, false
+		for _, partitionVotes := range votes {
+			v, ok := partitionVotes[cache]
+			if !ok {
+				continue
+			}
+			if v.available*2 >= v.total {
+				seenAvailable = true
+			} else {
+				seenUnavailable = true
+			}
+		}
+		if seenAvailable && seenUnavailable {
+			disputed = append(disputed, cache)
+		}
+	}
+	sort.Slice(disputed, func(i, j int) bool { return disputed[i] < disputed[j] })
+	return disputed
+}
@@ -0,0 +1,133 @@
+package peer
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_monitor/config"
+)
+
+func TestWeights(t *testing.T) {
+	peerStates := NewCRStatesPeersThreadsafe(0)
+	now := time.Now()
+	for _, result := range []Result{
+		{ID: "tm-same-cg", Available: true, Time: now},
+		{ID: "tm-same-region", Available: true, Time: now},
+		{ID: "tm-other", Available: true, Time: now.Add(-5 * time.Second)},
+		{ID: "tm-unavailable", Available: false, Time: now},
+		{ID: "tm-stale", Available: true, Time: now.Add(-time.Minute)},
+	} {
+		peerStates.Set(result)
+	}
+	peerStates.SetPeers(map[tc.TrafficMonitorName]struct{}{"tm-same-cg": {}, "tm-same-region": {}, "tm-other": {}, "tm-unavailable": {}, "tm-stale": {}})
+	peerStates.SetTimeout(10 * time.Second)
+	peerStates.SetLocations("tm-self", map[tc.TrafficMonitorName]tc.CacheGroupName{
+		"tm-self":        "cg-a",
+		"tm-same-cg":     "cg-a",
+		"tm-same-region": "cg-b",
+		"tm-other":       "cg-c",
+	})
+
+	cfg := config.DefaultPeerQuorum
+	cfg.Regions = map[string]string{"cg-a": "east", "cg-b": "east", "cg-c": "west"}
+	weights := peerStates.Weights(cfg)
+
+	if len(weights) != 4 {
+		t.Fatalf("expected weights of self and 3 available fresh peers, actual %+v", weights)
+	}
+	if weights["tm-self"] != cfg.SameCacheGroupWeight {
+		t.Errorf("expected self weight %v, actual %v", cfg.SameCacheGroupWeight, weights["tm-self"])
+	}
+	if w := weights["tm-same-cg"]; w > cfg.SameCacheGroupWeight || w < cfg.SameCacheGroupWeight*0.9 {
+		t.Errorf("expected same cachegroup peer weight about %v, actual %v", cfg.SameCacheGroupWeight, w)
+	}
+	if w := weights["tm-same-region"]; w > cfg.SameRegionWeight || w < cfg.SameRegionWeight*0.9 {
+		t.Errorf("expected same region peer weight about %v, actual %v", cfg.SameRegionWeight, w)
+	}
+	if w := weights["tm-other"]; w > 0.5 || w < 0.4 {
+		t.Errorf("expected peer polled half a timeout ago to have weight about 0.5, actual %v", w)
+	}
+}
+
+func splitBrainStates(available map[tc.TrafficMonitorName][]bool) map[tc.TrafficMonitorName]tc.CRStates {
+	caches := []tc.CacheName{"edge0", "edge1", "edge2", "edge3"}
+	states := map[tc.TrafficMonitorName]tc.CRStates{}
+	for monitor, cacheAvailable := range available {
+		crStates := tc.NewCRStates()
+		for i, isAvailable := range cacheAvailable {
+			crStates.Caches[caches[i]] = tc.IsAvailable{IsAvailable: isAvailable}
+		}
+		states[monitor] = crStates
+	}
+	return states
+}
+
+func TestDetectSplitBrain(t *testing.T) {
+	weights := map[tc.TrafficMonitorName]float64{"tm0": 1, "tm1": 1, "tm2": 1, "tm3": 1}
+
+	states := splitBrainStates(map[tc.TrafficMonitorName][]bool{
+		"tm0": {true, true, true, true},
+		"tm1": {true, true, true, true},
+		"tm2": {true, true, false, false},
+		"tm3": {true, true, false, false},
+	})
+	splitBrain, partitions, disputed := DetectSplitBrain(states, weights, 0.9)
+	if !splitBrain {
+		t.Error("expected two equal disagreeing partitions to be split-brained")
+	}
+	if expected := [][]tc.TrafficMonitorName{{"tm0", "tm1"}, {"tm2", "tm3"}}; !reflect.DeepEqual(partitions, expected) {
+		t.Errorf("expected partitions %v, actual %v", expected, partitions)
+	}
+	if expected := []tc.CacheName{"edge2", "edge3"}; !reflect.DeepEqual(disputed, expected) {
+		t.Errorf("expected disputed caches %v, actual %v", expected, disputed)
+	}
+
+	// a single disagreeing monitor is outvoted, not a split-brain
+	states = splitBrainStates(map[tc.TrafficMonitorName][]bool{
+		"tm0": {true, true, true, true},
+		"tm1": {true, true, true, true},
+		"tm2": {true, true, true, true},
+		"tm3": {false, false, false, false},
+	})
+	splitBrain, partitions, disputed = DetectSplitBrain(states, weights, 0.9)
+	if splitBrain {
+		t.Error("expected a majority partition to not be split-brained")
+	}
+	if len(partitions) != 2 || len(partitions[0]) != 3 {
+		t.Errorf("expected the majority partition first, actual %v", partitions)
+	}
+	if len(disputed) != 4 {
+		t.Errorf("expected 4 disputed caches, actual %v", disputed)
+	}
+
+	// monitors which agree on enough caches are one partition
+	states = splitBrainStates(map[tc.TrafficMonitorName][]bool{
+		"tm0": {true, true, true, true},
+		"tm1": {true, true, true, false},
+	})
+	splitBrain, partitions, _ = DetectSplitBrain(states, weights, 0.75)
+	if splitBrain || len(partitions) != 1 {
+		t.Errorf("expected one partition, actual split-brain %v partitions %v", splitBrain, partitions)
+	}
+}
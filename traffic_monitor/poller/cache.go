@@ -47,6 +47,26 @@ type PollConfig struct {
 	Timeout  time.Duration
 	Format   string
 	PollType string
+	// Probes are the synthetic requests made by the probe poller type. Other
+	// poller types ignore them.
+	Probes []Probe
+}
+
+// Equal returns whether c and o are the same poll configuration. PollConfig
+// isn't comparable with ==, because of its Probes.
+func (c PollConfig) Equal(o PollConfig) bool {
+	if c.URL != o.URL || c.URLv6 != o.URLv6 || c.Host != o.Host || c.Timeout != o.Timeout || c.Format != o.Format || c.PollType != o.PollType {
+		return false
+	}
+	if len(c.Probes) != len(o.Probes) {
+		return false
+	}
+	for i, probe := range c.Probes {
+		if probe != o.Probes[i] {
+			return false
+		}
+	}
+	return true
 }
 
 type CachePollerConfig struct {
@@ -121,6 +141,7 @@ func (p CachePoller) Poll() {
 				Timeout:     info.Timeout,
 				NoKeepAlive: info.NoKeepAlive,
 				PollerID:    info.ID,
+				Probes:      info.Probes,
 			}
 			pollerCtx := interface{}(nil)
 			if pollerObj.Init != nil {
@@ -232,7 +253,7 @@ func diffConfigs(old CachePollerConfig, new CachePollerConfig) ([]string, []Cach
 		newPollCfg, newIdExists := new.Urls[id]
 		if !newIdExists {
 			deletions = append(deletions, id)
-		} else if newPollCfg != oldPollCfg {
+		} else if !newPollCfg.Equal(oldPollCfg) {
 			deletions = append(deletions, id)
 			additions = append(additions, CachePollInfo{
 				Interval:        new.Interval,
@@ -29,9 +29,16 @@ import (
 	"github.com/apache/trafficcontrol/traffic_monitor/towrap" // TODO move to common
 )
 
+// MonitorCfg is the monitoring config of the CDNs monitored by this Traffic Monitor.
 type MonitorCfg struct {
+	// CDN is the CDN of this Traffic Monitor.
 	CDN string
+	// CDNs is every monitored CDN, starting with CDN.
+	CDNs []string
+	// Cfg is the monitoring config of every monitored CDN, merged. Caches, peers, and Delivery Services in multiple CDNs appear once.
 	Cfg tc.TrafficMonitorConfigMap
+	// MonitorCDNs are the CDNs of each Traffic Monitor in Cfg, of those monitored.
+	MonitorCDNs map[tc.TrafficMonitorName][]tc.CDNName
 }
 
 type MonitorConfigPoller struct {
@@ -42,13 +49,16 @@ type MonitorConfigPoller struct {
 	Interval         time.Duration
 	IntervalChan     chan time.Duration
 	OpsConfig        handler.OpsConfig
+	// ExtraCDNs are the CDNs monitored in addition to the CDN of the OpsConfig.
+	ExtraCDNs []string
 }
 
 // Creates and returns a new CachePoller.
 // If tick is false, CachePoller.TickChan() will return nil
-func NewMonitorConfig(interval time.Duration) MonitorConfigPoller {
+func NewMonitorConfig(interval time.Duration, extraCDNs []string) MonitorConfigPoller {
 	return MonitorConfigPoller{
 		Interval:       interval,
+		ExtraCDNs:      extraCDNs,
 		SessionChannel: make(chan towrap.TrafficOpsSessionThreadsafe),
 		// ConfigChannel MUST have a buffer size 1, to make the nonblocking writeConfig work
 		ConfigChannel:    make(chan MonitorCfg, 1),
@@ -82,6 +92,8 @@ func (p MonitorConfigPoller) Poll() {
 		log.Errorf("%s\n", stacktrace())
 		os.Exit(1) // The Monitor can't run without a MonitorConfigPoller
 	}()
+	// lastMonitorConfigs is the last monitoring config successfully fetched for each CDN, used when fetching a CDN's config fails.
+	lastMonitorConfigs := map[string]tc.TrafficMonitorConfigMap{}
 	for {
 		// Every case MUST be asynchronous and non-blocking, to prevent livelocks. If a chan must be written to, it must either be buffered AND remove existing values, or be written to in a goroutine.
 		select {
@@ -108,12 +120,111 @@ func (p MonitorConfigPoller) Poll() {
 				log.Warnln("MonitorConfigPoller: skipping this iteration, Session is nil")
 				continue
 			}
-			monitorConfig, err := p.Session.TrafficMonitorConfigMap(p.OpsConfig.CdnName)
-			if err != nil {
-				log.Errorf("MonitorConfigPoller: %s\n %v\n", err, monitorConfig)
+			cdns := MonitoredCDNs(p.OpsConfig.CdnName, p.ExtraCDNs)
+			monitorCfg, ok := p.getMonitorCfg(cdns, lastMonitorConfigs)
+			if !ok {
 				continue
 			}
-			p.writeConfig(MonitorCfg{CDN: p.OpsConfig.CdnName, Cfg: *monitorConfig})
+			p.writeConfig(monitorCfg)
 		}
 	}
 }
+
+// getMonitorCfg fetches the monitoring config of each of the given CDNs, and returns them merged.
+// A CDN whose config can't be fetched doesn't stop the others from being updated: its last good config in lastMonitorConfigs is used instead, or, if it has none, the CDN is left out.
+// Returns false if the config of this Traffic Monitor's own CDN, the first of cdns, is unavailable, in which case nothing should be written.
+func (p MonitorConfigPoller) getMonitorCfg(cdns []string, lastMonitorConfigs map[string]tc.TrafficMonitorConfigMap) (MonitorCfg, bool) {
+	availableCDNs := make([]string, 0, len(cdns))
+	monitorConfigs := make([]tc.TrafficMonitorConfigMap, 0, len(cdns))
+	for _, cdn := range cdns {
+		monitorConfig, err := p.Session.TrafficMonitorConfigMap(cdn)
+		if err == nil {
+			lastMonitorConfigs[cdn] = *monitorConfig
+		} else if _, ok := lastMonitorConfigs[cdn]; ok {
+			log.Errorf("MonitorConfigPoller: CDN %s: %s; using its last good config\n", cdn, err)
+		} else {
+			log.Errorf("MonitorConfigPoller: CDN %s: %s; no previous config, skipping it\n", cdn, err)
+			continue
+		}
+		availableCDNs = append(availableCDNs, cdn)
+		monitorConfigs = append(monitorConfigs, lastMonitorConfigs[cdn])
+	}
+	if len(availableCDNs) == 0 || availableCDNs[0] != cdns[0] {
+		return MonitorCfg{}, false
+	}
+	return MonitorCfg{CDN: cdns[0], CDNs: availableCDNs, Cfg: MergeMonitorConfigs(monitorConfigs), MonitorCDNs: MonitorConfigCDNs(availableCDNs, monitorConfigs)}, true
+}
+
+// MonitorConfigCDNs returns the CDNs of each Traffic Monitor in the given monitoring configs of the given CDNs, which are in the same order.
+func MonitorConfigCDNs(cdns []string, monitorConfigs []tc.TrafficMonitorConfigMap) map[tc.TrafficMonitorName][]tc.CDNName {
+	monitorCDNs := map[tc.TrafficMonitorName][]tc.CDNName{}
+	for i, monitorConfig := range monitorConfigs {
+		for _, monitor := range monitorConfig.TrafficMonitor {
+			name := tc.TrafficMonitorName(monitor.HostName)
+			monitorCDNs[name] = append(monitorCDNs[name], tc.CDNName(cdns[i]))
+		}
+	}
+	return monitorCDNs
+}
+
+// MonitoredCDNs returns the CDNs to monitor: the given CDN of this Traffic Monitor, followed by the extra CDNs which aren't it, without duplicates.
+func MonitoredCDNs(cdn string, extraCDNs []string) []string {
+	cdns := []string{cdn}
+	seen := map[string]struct{}{cdn: {}}
+	for _, extraCDN := range extraCDNs {
+		if _, ok := seen[extraCDN]; ok || extraCDN == "" {
+			continue
+		}
+		seen[extraCDN] = struct{}{}
+		cdns = append(cdns, extraCDN)
+	}
+	return cdns
+}
+
+// MergeMonitorConfigs merges the monitoring configs of multiple CDNs. Where configs have the same cache, cachegroup, peer, Delivery Service, Profile, or config parameter, that of the first is used, so the config of this Traffic Monitor's own CDN should be first.
+func MergeMonitorConfigs(monitorConfigs []tc.TrafficMonitorConfigMap) tc.TrafficMonitorConfigMap {
+	if len(monitorConfigs) == 1 {
+		return monitorConfigs[0]
+	}
+	merged := tc.TrafficMonitorConfigMap{
+		TrafficServer:   map[string]tc.TrafficServer{},
+		CacheGroup:      map[string]tc.TMCacheGroup{},
+		Config:          map[string]interface{}{},
+		TrafficMonitor:  map[string]tc.TrafficMonitor{},
+		DeliveryService: map[string]tc.TMDeliveryService{},
+		Profile:         map[string]tc.TMProfile{},
+	}
+	for _, monitorConfig := range monitorConfigs {
+		for name, server := range monitorConfig.TrafficServer {
+			if _, ok := merged.TrafficServer[name]; !ok {
+				merged.TrafficServer[name] = server
+			}
+		}
+		for name, cacheGroup := range monitorConfig.CacheGroup {
+			if _, ok := merged.CacheGroup[name]; !ok {
+				merged.CacheGroup[name] = cacheGroup
+			}
+		}
+		for name, val := range monitorConfig.Config {
+			if _, ok := merged.Config[name]; !ok {
+				merged.Config[name] = val
+			}
+		}
+		for name, monitor := range monitorConfig.TrafficMonitor {
+			if _, ok := merged.TrafficMonitor[name]; !ok {
+				merged.TrafficMonitor[name] = monitor
+			}
+		}
+		for name, ds := range monitorConfig.DeliveryService {
+			if _, ok := merged.DeliveryService[name]; !ok {
+				merged.DeliveryService[name] = ds
+			}
+		}
+		for name, profile := range monitorConfig.Profile {
+			if _, ok := merged.Profile[name]; !ok {
+				merged.Profile[name] = profile
+			}
+		}
+	}
+	return merged
+}
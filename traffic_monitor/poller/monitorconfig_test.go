@@ -0,0 +1,60 @@
+package poller
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+)
+
+func TestMonitoredCDNs(t *testing.T) {
+	actual := MonitoredCDNs("cdn0", []string{"cdn1", "cdn0", "", "cdn2", "cdn1"})
+	expected := []string{"cdn0", "cdn1", "cdn2"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, actual %v", expected, actual)
+	}
+}
+
+func TestMergeMonitorConfigs(t *testing.T) {
+	a := tc.TrafficMonitorConfigMap{
+		TrafficServer:  map[string]tc.TrafficServer{"edge0": {HostName: "edge0", ServerStatus: "REPORTED"}},
+		Config:         map[string]interface{}{"peers.polling.interval": 1000.0},
+		TrafficMonitor: map[string]tc.TrafficMonitor{"tm0": {HostName: "tm0"}},
+	}
+	b := tc.TrafficMonitorConfigMap{
+		TrafficServer:   map[string]tc.TrafficServer{"edge0": {HostName: "edge0", ServerStatus: "ADMIN_DOWN"}, "edge1": {HostName: "edge1"}},
+		Config:          map[string]interface{}{"peers.polling.interval": 5000.0},
+		TrafficMonitor:  map[string]tc.TrafficMonitor{"tm1": {HostName: "tm1"}},
+		DeliveryService: map[string]tc.TMDeliveryService{"ds-b": {XMLID: "ds-b"}},
+	}
+
+	merged := MergeMonitorConfigs([]tc.TrafficMonitorConfigMap{a, b})
+	if len(merged.TrafficServer) != 2 || merged.TrafficServer["edge0"].ServerStatus != "REPORTED" {
+		t.Errorf("expected 2 caches, keeping the first shared cache, actual %+v", merged.TrafficServer)
+	}
+	if merged.Config["peers.polling.interval"] != 1000.0 {
+		t.Errorf("expected config of the first CDN, actual %+v", merged.Config)
+	}
+	if len(merged.TrafficMonitor) != 2 || len(merged.DeliveryService) != 1 {
+		t.Errorf("expected peers and Delivery Services of both CDNs, actual %+v %+v", merged.TrafficMonitor, merged.DeliveryService)
+	}
+}
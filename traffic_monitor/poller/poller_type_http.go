@@ -43,9 +43,10 @@ func httpGlobalInit(cfg config.Config, appData config.StaticAppData) interface{}
 		Timeout:   cfg.HTTPTimeout,
 	}
 	return &HTTPPollGlobalCtx{
-		UserAgent:    appData.UserAgent,
-		Client:       sharedClient,
-		FormatAccept: cfg.HTTPPollingFormat,
+		UserAgent:         appData.UserAgent,
+		Client:            sharedClient,
+		FormatAccept:      cfg.HTTPPollingFormat,
+		PrometheusMetrics: cfg.PrometheusMetrics,
 	}
 }
 
@@ -72,33 +73,36 @@ func httpInit(cfg PollerConfig, globalCtxI interface{}) interface{} {
 	}
 
 	return &HTTPPollCtx{
-		Client:       gctx.Client,
-		UserAgent:    gctx.UserAgent,
-		NoKeepAlive:  cfg.NoKeepAlive,
-		URL:          cfg.URL,
-		URLv6:        cfg.URLv6,
-		Host:         cfg.Host,
-		PollerID:     cfg.PollerID,
-		FormatAccept: gctx.FormatAccept,
+		Client:            gctx.Client,
+		UserAgent:         gctx.UserAgent,
+		NoKeepAlive:       cfg.NoKeepAlive,
+		URL:               cfg.URL,
+		URLv6:             cfg.URLv6,
+		Host:              cfg.Host,
+		PollerID:          cfg.PollerID,
+		FormatAccept:      gctx.FormatAccept,
+		PrometheusMetrics: gctx.PrometheusMetrics,
 	}
 }
 
 type HTTPPollGlobalCtx struct {
-	Client       *http.Client
-	UserAgent    string
-	FormatAccept string
+	Client            *http.Client
+	UserAgent         string
+	FormatAccept      string
+	PrometheusMetrics config.PrometheusMetrics
 }
 
 type HTTPPollCtx struct {
-	Client       *http.Client
-	UserAgent    string
-	NoKeepAlive  bool
-	URL          string
-	URLv6        string
-	Host         string
-	PollerID     string
-	HTTPHeader   http.Header
-	FormatAccept string
+	Client            *http.Client
+	UserAgent         string
+	NoKeepAlive       bool
+	URL               string
+	URLv6             string
+	Host              string
+	PollerID          string
+	HTTPHeader        http.Header
+	FormatAccept      string
+	PrometheusMetrics config.PrometheusMetrics
 }
 
 func httpPoll(ctxI interface{}, url string, host string, pollID uint64) ([]byte, time.Time, time.Duration, error) {
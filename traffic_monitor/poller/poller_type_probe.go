@@ -0,0 +1,239 @@
+package poller
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_monitor/config"
+)
+
+// PollerTypeProbe is the poller type which makes synthetic requests for
+// Delivery Service content through a cache, rather than requesting its stats.
+const PollerTypeProbe = "probe"
+
+// ProbeConcurrency is the maximum number of probes made through a single cache
+// at once.
+const ProbeConcurrency = 8
+
+// ProbePollDeadlineTimeouts is the number of probe timeouts after which a probe
+// poll of a cache ends, however many of its probes remain. Probes which
+// haven't finished by then fail.
+const ProbePollDeadlineTimeouts = 2
+
+func init() {
+	AddPollerType(PollerTypeProbe, probeGlobalInit, probeInit, probePoll)
+}
+
+// Probe is a single synthetic request made through a cache to one of the
+// Delivery Services assigned to it.
+type Probe struct {
+	// DeliveryService is the Delivery Service being probed.
+	DeliveryService tc.DeliveryServiceName
+	// URL is the URL requested. Its host is the Delivery Service's host, not
+	// the cache's; the connection is always made to the cache being polled.
+	URL string
+	// Port is the cache port to connect to. If 0, the default port of the
+	// URL's scheme is used.
+	Port int
+	// ExpectedStatus is the HTTP status code the cache must respond with. If
+	// 0, 200 is expected.
+	ExpectedStatus int
+	// BodySHA256 is the hex-encoded SHA-256 digest the response body must
+	// have. If empty, the body isn't checked.
+	BodySHA256 string
+}
+
+// ProbeResult is the result of making a single Probe. The probe poller
+// returns a JSON array of these, which is parsed by the "probe" stats decoder.
+type ProbeResult struct {
+	DeliveryService tc.DeliveryServiceName `json:"deliveryService"`
+	URL             string                 `json:"url"`
+	Status          int                    `json:"status"`
+	LatencyMS       float64                `json:"latencyMs"`
+	Success         bool                   `json:"success"`
+	Error           string                 `json:"error,omitempty"`
+}
+
+type ProbePollGlobalCtx struct {
+	UserAgent string
+	Timeout   time.Duration
+}
+
+type ProbePollCtx struct {
+	UserAgent string
+	Timeout   time.Duration
+	PollerID  string
+	Probes    []Probe
+}
+
+func probeGlobalInit(cfg config.Config, appData config.StaticAppData) interface{} {
+	return &ProbePollGlobalCtx{
+		UserAgent: appData.UserAgent,
+		Timeout:   cfg.HTTPTimeout,
+	}
+}
+
+func probeInit(cfg PollerConfig, globalCtxI interface{}) interface{} {
+	gctx := (globalCtxI).(*ProbePollGlobalCtx)
+	timeout := gctx.Timeout
+	if cfg.Timeout != 0 {
+		timeout = cfg.Timeout
+	}
+	return &ProbePollCtx{
+		UserAgent: gctx.UserAgent,
+		Timeout:   timeout,
+		PollerID:  cfg.PollerID,
+		Probes:    cfg.Probes,
+	}
+}
+
+// probePoll makes each of the poller's probes through the cache addressed by
+// pollURL, up to ProbeConcurrency at once, and returns the JSON-encoded
+// []ProbeResult, in the order of the probes. The failure of an individual
+// probe is reported in its result, not as an error.
+func probePoll(ctxI interface{}, pollURL string, host string, pollID uint64) ([]byte, time.Time, time.Duration, error) {
+	ctx := (ctxI).(*ProbePollCtx)
+	startReq := time.Now()
+	cacheURL, err := url.Parse(pollURL)
+	if err != nil {
+		return nil, time.Now(), 0, fmt.Errorf("id %v url %v parsing: %v", ctx.PollerID, pollURL, err)
+	}
+
+	deadline, cancel := context.WithTimeout(context.Background(), ctx.Timeout*ProbePollDeadlineTimeouts)
+	defer cancel()
+
+	results := make([]ProbeResult, len(ctx.Probes))
+	sem := make(chan struct{}, ProbeConcurrency)
+	wg := sync.WaitGroup{}
+	for i, probe := range ctx.Probes {
+		wg.Add(1)
+		go func(i int, probe Probe) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+				results[i] = ctx.probe(deadline, cacheURL.Hostname(), probe)
+			case <-deadline.Done():
+				results[i] = ProbeResult{DeliveryService: probe.DeliveryService, URL: probe.URL, Error: "not probed: poll deadline exceeded"}
+			}
+		}(i, probe)
+	}
+	wg.Wait()
+
+	bts, err := json.Marshal(results)
+	reqEnd := time.Now()
+	reqTime := reqEnd.Sub(startReq)
+	if err != nil {
+		return nil, reqEnd, reqTime, fmt.Errorf("id %v url %v encoding probe results: %v", ctx.PollerID, pollURL, err)
+	}
+	return bts, reqEnd, reqTime, nil
+}
+
+// probe makes the given Probe's request to the cache at cacheHost, and returns
+// its result. The request is canceled if the deadline context is done first.
+func (ctx *ProbePollCtx) probe(deadline context.Context, cacheHost string, probe Probe) ProbeResult {
+	result := ProbeResult{DeliveryService: probe.DeliveryService, URL: probe.URL}
+
+	req, err := http.NewRequest(http.MethodGet, probe.URL, nil)
+	if err != nil {
+		result.Error = "creating HTTP request: " + err.Error()
+		return result
+	}
+	req = req.WithContext(deadline)
+	req.Header.Set("User-Agent", ctx.UserAgent)
+
+	port := probe.Port
+	if port == 0 {
+		port = 80
+		if req.URL.Scheme == "https" {
+			port = 443
+		}
+	}
+	addr := net.JoinHostPort(cacheHost, strconv.Itoa(port))
+	dialer := &net.Dialer{Timeout: ctx.Timeout}
+
+	// The request URL's host is the Delivery Service, so the Host header and
+	// TLS SNI are what a real client would send; only the dial goes to the cache.
+	client := &http.Client{
+		Timeout: ctx.Timeout,
+		Transport: &http.Transport{
+			DialContext: func(c context.Context, network string, _ string) (net.Conn, error) {
+				return dialer.DialContext(c, network, addr)
+			},
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+			DisableKeepAlives: true,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse // a redirect is a response from the cache like any other
+		},
+	}
+
+	startReq := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		result.LatencyMS = float64(time.Since(startReq)) / float64(time.Millisecond)
+		result.Error = "fetch error: " + err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+
+	bodyHash := sha256.New()
+	_, err = io.Copy(bodyHash, resp.Body)
+	result.LatencyMS = float64(time.Since(startReq)) / float64(time.Millisecond) // note this is the time to transfer the entire body, not just the roundtrip
+	result.Status = resp.StatusCode
+	if err != nil {
+		result.Error = "reading body: " + err.Error()
+		return result
+	}
+
+	expectedStatus := probe.ExpectedStatus
+	if expectedStatus == 0 {
+		expectedStatus = http.StatusOK
+	}
+	if resp.StatusCode != expectedStatus {
+		result.Error = fmt.Sprintf("bad HTTP status: expected %v, actual %v", expectedStatus, resp.StatusCode)
+		return result
+	}
+
+	if probe.BodySHA256 != "" {
+		if bodySHA256 := hex.EncodeToString(bodyHash.Sum(nil)); !strings.EqualFold(bodySHA256, probe.BodySHA256) {
+			result.Error = "body SHA-256 mismatch: expected " + probe.BodySHA256 + ", actual " + bodySHA256
+			return result
+		}
+	}
+
+	result.Success = true
+	return result
+}
@@ -0,0 +1,159 @@
+package poller
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/apache/trafficcontrol/traffic_monitor/config"
+)
+
+func TestProbePoll(t *testing.T) {
+	body := "probe body"
+	bodySum := sha256.Sum256([]byte(body))
+	bodySHA256 := hex.EncodeToString(bodySum[:])
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Host != "ds1.example.test" && r.Host != "ds2.example.test" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	srvURL, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatalf("parsing test server URL: %v", err)
+	}
+	port, err := strconv.Atoi(srvURL.Port())
+	if err != nil {
+		t.Fatalf("parsing test server port: %v", err)
+	}
+
+	ctx := probeInit(PollerConfig{PollerID: "edge", Probes: []Probe{
+		{DeliveryService: "ds1", URL: "http://ds1.example.test/probe", Port: port, BodySHA256: bodySHA256},
+		{DeliveryService: "ds2", URL: "http://ds2.example.test/probe", Port: port, BodySHA256: "00"},
+		{DeliveryService: "ds3", URL: "http://ds3.example.test/probe", Port: port},
+		{DeliveryService: "ds4", URL: "http://ds3.example.test/probe", Port: port, ExpectedStatus: http.StatusNotFound},
+	}}, probeGlobalInit(config.DefaultConfig, config.StaticAppData{UserAgent: "test"}))
+
+	bts, _, _, err := probePoll(ctx, srv.URL+"/_astats", "edge.example.test", 1)
+	if err != nil {
+		t.Fatalf("probe poll expected: no error, actual: %v", err)
+	}
+	results := []ProbeResult{}
+	if err := json.Unmarshal(bts, &results); err != nil {
+		t.Fatalf("decoding probe results: %v", err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("probe results expected: 4, actual: %v", len(results))
+	}
+
+	expected := map[string]bool{"ds1": true, "ds2": false, "ds3": false, "ds4": true}
+	for _, result := range results {
+		if result.Success != expected[string(result.DeliveryService)] {
+			t.Errorf("probe %v success expected: %v, actual: %v (error '%v')", result.DeliveryService, expected[string(result.DeliveryService)], result.Success, result.Error)
+		}
+		if result.Success && result.Error != "" {
+			t.Errorf("probe %v expected: no error, actual: %v", result.DeliveryService, result.Error)
+		}
+		if !result.Success && result.Error == "" {
+			t.Errorf("probe %v expected: error, actual: none", result.DeliveryService)
+		}
+	}
+	if results[2].Status != http.StatusNotFound {
+		t.Errorf("probe ds3 status expected: %v, actual: %v", http.StatusNotFound, results[2].Status)
+	}
+}
+
+func TestProbePollDeadline(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	srvURL, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatalf("parsing test server URL: %v", err)
+	}
+	port, err := strconv.Atoi(srvURL.Port())
+	if err != nil {
+		t.Fatalf("parsing test server port: %v", err)
+	}
+
+	probes := []Probe{}
+	for i := 0; i < ProbeConcurrency*3; i++ {
+		probes = append(probes, Probe{DeliveryService: "ds", URL: "http://ds.example.test/probe", Port: port})
+	}
+	timeout := 100 * time.Millisecond
+	ctx := probeInit(PollerConfig{PollerID: "edge", Timeout: timeout, Probes: probes}, probeGlobalInit(config.DefaultConfig, config.StaticAppData{UserAgent: "test"}))
+
+	start := time.Now()
+	bts, _, _, err := probePoll(ctx, srv.URL+"/_astats", "edge.example.test", 1)
+	if err != nil {
+		t.Fatalf("probe poll expected: no error, actual: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > timeout*(ProbePollDeadlineTimeouts+2) {
+		t.Errorf("probe poll of unresponsive cache expected: to end near the %v deadline, actual: %v", timeout*ProbePollDeadlineTimeouts, elapsed)
+	}
+	results := []ProbeResult{}
+	if err := json.Unmarshal(bts, &results); err != nil {
+		t.Fatalf("decoding probe results: %v", err)
+	}
+	if len(results) != len(probes) {
+		t.Fatalf("probe results expected: %v, actual: %v", len(probes), len(results))
+	}
+	for _, result := range results {
+		if result.Success || result.Error == "" {
+			t.Errorf("probe of unresponsive cache expected: failure with error, actual: %+v", result)
+		}
+	}
+}
+
+func TestPollConfigEqual(t *testing.T) {
+	a := PollConfig{URL: "http://10.0.0.1/_astats", Timeout: time.Second, Probes: []Probe{{DeliveryService: "ds1", URL: "http://ds1.example.test/probe"}}}
+	b := a
+	b.Probes = []Probe{{DeliveryService: "ds1", URL: "http://ds1.example.test/probe"}}
+	if !a.Equal(b) {
+		t.Errorf("poll configs with equal probes expected: equal, actual: not equal")
+	}
+	b.Probes = []Probe{{DeliveryService: "ds1", URL: "http://ds1.example.test/other"}}
+	if a.Equal(b) {
+		t.Errorf("poll configs with different probes expected: not equal, actual: equal")
+	}
+	b.Probes = nil
+	if a.Equal(b) {
+		t.Errorf("poll configs with different numbers of probes expected: not equal, actual: equal")
+	}
+}
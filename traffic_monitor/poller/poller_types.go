@@ -42,6 +42,7 @@ type PollerConfig struct {
 	Timeout     time.Duration
 	NoKeepAlive bool
 	PollerID    string
+	Probes      []Probe
 }
 
 // PollerGlobalInit performs global initialization, and returns a global context object.
@@ -0,0 +1,344 @@
+// Package srvgrpc serves the data of the CrStates, CacheStatsNew and DsStats
+// endpoints over gRPC, as the protobuf messages of the tmpb package, which are
+// far cheaper for clients to decode than the JSON of the HTTP endpoints.
+package srvgrpc
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-log"
+	"github.com/apache/trafficcontrol/traffic_monitor/datareq"
+	"github.com/apache/trafficcontrol/traffic_monitor/peer"
+	"github.com/apache/trafficcontrol/traffic_monitor/srvgrpc/tmpb"
+	"github.com/apache/trafficcontrol/traffic_monitor/threadsafe"
+	"github.com/apache/trafficcontrol/traffic_monitor/todata"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+// MinStreamInterval is the shortest interval at which stats are streamed.
+const MinStreamInterval = time.Second
+
+// Server serves the TrafficMonitor gRPC service.
+type Server struct {
+	tmpb.UnimplementedTrafficMonitorServer
+	localStates       peer.CRStatesThreadsafe
+	combinedStates    peer.CRStatesThreadsafe
+	peerStates        peer.CRStatesPeersThreadsafe
+	crStatesFeed      *peer.CRStatesFeed
+	statInfoHistory   threadsafe.ResultInfoHistory
+	statResultHistory threadsafe.ResultStatHistory
+	statMaxKbpses     threadsafe.CacheKbpses
+	dsStats           threadsafe.DSStatsReader
+	toData            todata.TODataThreadsafe
+	monitorConfig     threadsafe.TrafficMonitorConfigMap
+	unpolledCaches    threadsafe.UnpolledCaches
+	errorCount        threadsafe.Uint
+	statPollInterval  time.Duration
+	// polledAll is set to 1 once every cache has been polled, after which the unpolled caches aren't checked again, like the HTTP endpoints.
+	polledAll *int32
+}
+
+// NewServer returns a Server of the given data. Stats are streamed every
+// statPollInterval, unless clients request otherwise.
+func NewServer(
+	localStates peer.CRStatesThreadsafe,
+	combinedStates peer.CRStatesThreadsafe,
+	peerStates peer.CRStatesPeersThreadsafe,
+	crStatesFeed *peer.CRStatesFeed,
+	statInfoHistory threadsafe.ResultInfoHistory,
+	statResultHistory threadsafe.ResultStatHistory,
+	statMaxKbpses threadsafe.CacheKbpses,
+	dsStats threadsafe.DSStatsReader,
+	toData todata.TODataThreadsafe,
+	monitorConfig threadsafe.TrafficMonitorConfigMap,
+	unpolledCaches threadsafe.UnpolledCaches,
+	errorCount threadsafe.Uint,
+	statPollInterval time.Duration,
+) *Server {
+	return &Server{
+		localStates:       localStates,
+		combinedStates:    combinedStates,
+		peerStates:        peerStates,
+		crStatesFeed:      crStatesFeed,
+		statInfoHistory:   statInfoHistory,
+		statResultHistory: statResultHistory,
+		statMaxKbpses:     statMaxKbpses,
+		dsStats:           dsStats,
+		toData:            toData,
+		monitorConfig:     monitorConfig,
+		unpolledCaches:    unpolledCaches,
+		errorCount:        errorCount,
+		statPollInterval:  statPollInterval,
+		polledAll:         new(int32),
+	}
+}
+
+// Run starts serving the gRPC service at the given addr, over TLS if certFile
+// and keyFile are given, and returns once it's listening. The returned server
+// may be used to stop it.
+func (s *Server) Run(addr string, certFile string, keyFile string) (*grpc.Server, error) {
+	opts := []grpc.ServerOption{
+		grpc.UnaryInterceptor(s.unaryUnpolledCheck),
+		grpc.StreamInterceptor(s.streamUnpolledCheck),
+	}
+	if certFile != "" || keyFile != "" {
+		creds, err := credentials.NewServerTLSFromFile(certFile, keyFile)
+		if err != nil {
+			return nil, errors.New("loading gRPC TLS certificate: " + err.Error())
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	grpcServer := grpc.NewServer(opts...)
+	tmpb.RegisterTrafficMonitorServer(grpcServer, s)
+	go func() {
+		if err := grpcServer.Serve(listener); err != nil {
+			log.Warnf("gRPC server stopped with error: %v", err)
+		} else {
+			log.Infof("gRPC server stopped on %s", addr)
+		}
+	}()
+	log.Infof("gRPC server listening on %s", addr)
+	return grpcServer, nil
+}
+
+// checkUnpolled returns an Unavailable error if Traffic Monitor is still
+// starting, and some caches haven't been polled yet.
+func (s *Server) checkUnpolled(method string) error {
+	if atomic.LoadInt32(s.polledAll) == 1 {
+		return nil
+	}
+	if s.unpolledCaches.Any() {
+		err := fmt.Errorf("service still starting, some caches unpolled: %v", s.unpolledCaches.UnpolledCaches())
+		datareq.HandleErr(s.errorCount, method, err)
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	atomic.StoreInt32(s.polledAll, 1)
+	return nil
+}
+
+func (s *Server) unaryUnpolledCheck(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := s.checkUnpolled(info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (s *Server) streamUnpolledCheck(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := s.checkUnpolled(info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, stream)
+}
+
+// checkQuorum returns an Unavailable error if this Traffic Monitor doesn't have
+// the optimistic peer quorum, in which case /publish/CrStates serves a 503.
+func (s *Server) checkQuorum(method string) error {
+	if err := datareq.CheckOptimisticQuorum(s.peerStates); err != nil {
+		datareq.HandleErr(s.errorCount, method, err)
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	return nil
+}
+
+// GetCrStates returns the CrStates, or this Traffic Monitor's own states if
+// raw is requested.
+func (s *Server) GetCrStates(ctx context.Context, req *tmpb.CrStatesRequest) (*tmpb.CrStates, error) {
+	if req.GetRaw() {
+		return tmpb.NewCrStates(s.localStates.Get(), 0), nil
+	}
+	if err := s.checkQuorum("GetCrStates"); err != nil {
+		return nil, err
+	}
+	return tmpb.NewCrStates(s.combinedStates.Get(), 0), nil
+}
+
+// StreamCrStates sends the CrStates of the change feed, and then sends them
+// again every time they change. The stream is ended with an Unavailable error
+// if this Traffic Monitor loses its optimistic peer quorum.
+func (s *Server) StreamCrStates(req *tmpb.StreamCrStatesRequest, stream tmpb.TrafficMonitor_StreamCrStatesServer) error {
+	sent, seq := false, uint64(0)
+	for {
+		// get the channel before reading the feed, so changes between the read and the wait aren't missed.
+		changed := s.crStatesFeed.Changed()
+		if err := s.checkQuorum("StreamCrStates"); err != nil {
+			return err
+		}
+		if snapshot := s.crStatesFeed.Snapshot(); !sent || snapshot.Sequence != seq {
+			if err := stream.Send(tmpb.NewCrStates(snapshot.CRStates, snapshot.Sequence)); err != nil {
+				return err
+			}
+			sent, seq = true, snapshot.Sequence
+		}
+		select {
+		case <-changed:
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// cacheStatParams returns the /publish/CacheStatsNew query parameters
+// equivalent to the request.
+func cacheStatParams(req *tmpb.CacheStatsRequest) url.Values {
+	params := url.Values{}
+	addHistoryCountParam(params, req.GetHistoryCount(), req.GetAllHistory())
+	addListParam(params, "stats", req.GetStats())
+	addListParam(params, "interfaceStats", req.GetInterfaceStats())
+	if req.GetWildcard() {
+		params.Set("wildcard", "true")
+	}
+	if req.GetType() != "" {
+		params.Set("type", req.GetType())
+	}
+	addListParam(params, "hosts", req.GetHosts())
+	return params
+}
+
+// dsStatParams returns the /publish/DsStats query parameters equivalent to
+// the request.
+func dsStatParams(req *tmpb.DsStatsRequest) url.Values {
+	params := url.Values{}
+	addHistoryCountParam(params, req.GetHistoryCount(), req.GetAllHistory())
+	addListParam(params, "stats", req.GetStats())
+	if req.GetWildcard() {
+		params.Set("wildcard", "true")
+	}
+	if req.GetType() != "" {
+		params.Set("type", req.GetType())
+	}
+	addListParam(params, "deliveryservices", req.GetDeliveryServices())
+	return params
+}
+
+func addHistoryCountParam(params url.Values, historyCount uint64, allHistory bool) {
+	if allHistory {
+		params.Set("hc", "0")
+	} else if historyCount > 0 {
+		params.Set("hc", strconv.FormatUint(historyCount, 10))
+	}
+}
+
+func addListParam(params url.Values, name string, vals []string) {
+	if len(vals) > 0 {
+		params.Set(name, strings.Join(vals, ","))
+	}
+}
+
+// cacheStats returns the stats the request asks for.
+func (s *Server) cacheStats(method string, req *tmpb.CacheStatsRequest) (*tmpb.CacheStats, error) {
+	params := cacheStatParams(req)
+	filter, err := datareq.NewCacheStatFilter("", params, s.toData.Get().ServerTypes)
+	if err != nil {
+		datareq.HandleErr(s.errorCount, method, err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	now := time.Now()
+	stats := threadsafe.GenerateStats(s.statResultHistory, s.statInfoHistory.Get(), s.combinedStates.Get(), s.monitorConfig.Get(), s.statMaxKbpses.Get(), filter, params)
+	return tmpb.NewCacheStats(stats, now), nil
+}
+
+// dsStatsMsg returns the Delivery Service stats the request asks for.
+func (s *Server) dsStatsMsg(method string, req *tmpb.DsStatsRequest) (*tmpb.DsStats, error) {
+	params := dsStatParams(req)
+	filter, err := datareq.NewDSStatFilter("", params, s.toData.Get().DeliveryServiceTypes)
+	if err != nil {
+		datareq.HandleErr(s.errorCount, method, err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	now := time.Now()
+	return tmpb.NewDsStats(s.dsStats.Get().JSON(filter, params), now), nil
+}
+
+// GetCacheStats returns the requested cache stats.
+func (s *Server) GetCacheStats(ctx context.Context, req *tmpb.CacheStatsRequest) (*tmpb.CacheStats, error) {
+	return s.cacheStats("GetCacheStats", req)
+}
+
+// StreamCacheStats sends the requested cache stats every requested interval.
+func (s *Server) StreamCacheStats(req *tmpb.CacheStatsRequest, stream tmpb.TrafficMonitor_StreamCacheStatsServer) error {
+	return s.streamEvery(stream.Context(), req.GetIntervalMs(), func() error {
+		stats, err := s.cacheStats("StreamCacheStats", req)
+		if err != nil {
+			return err
+		}
+		return stream.Send(stats)
+	})
+}
+
+// GetDsStats returns the requested Delivery Service stats.
+func (s *Server) GetDsStats(ctx context.Context, req *tmpb.DsStatsRequest) (*tmpb.DsStats, error) {
+	return s.dsStatsMsg("GetDsStats", req)
+}
+
+// StreamDsStats sends the requested Delivery Service stats every requested
+// interval.
+func (s *Server) StreamDsStats(req *tmpb.DsStatsRequest, stream tmpb.TrafficMonitor_StreamDsStatsServer) error {
+	return s.streamEvery(stream.Context(), req.GetIntervalMs(), func() error {
+		stats, err := s.dsStatsMsg("StreamDsStats", req)
+		if err != nil {
+			return err
+		}
+		return stream.Send(stats)
+	})
+}
+
+// streamEvery calls send immediately, and then every intervalMS milliseconds,
+// or stat poll interval if it's 0, until it returns an error or ctx is done.
+func (s *Server) streamEvery(ctx context.Context, intervalMS uint64, send func() error) error {
+	interval := s.statPollInterval
+	if intervalMS > 0 {
+		interval = time.Duration(intervalMS) * time.Millisecond
+	}
+	if interval < MinStreamInterval {
+		interval = MinStreamInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := send(); err != nil {
+			return err
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
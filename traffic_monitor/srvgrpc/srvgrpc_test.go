@@ -0,0 +1,219 @@
+package srvgrpc
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_monitor/dsdata"
+	"github.com/apache/trafficcontrol/traffic_monitor/peer"
+	"github.com/apache/trafficcontrol/traffic_monitor/srvgrpc/tmpb"
+	"github.com/apache/trafficcontrol/traffic_monitor/threadsafe"
+	"github.com/apache/trafficcontrol/traffic_monitor/tmclient"
+	"github.com/apache/trafficcontrol/traffic_monitor/todata"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type testData struct {
+	localStates    peer.CRStatesThreadsafe
+	combinedStates peer.CRStatesThreadsafe
+	feed           *peer.CRStatesFeed
+	unpolledCaches threadsafe.UnpolledCaches
+}
+
+// startTestServer serves a Server of test data in memory, and returns a client
+// of it, which is closed when the test ends.
+func startTestServer(t *testing.T) (*tmclient.GRPCClient, testData) {
+	data := testData{
+		localStates:    peer.NewCRStatesThreadsafe(),
+		combinedStates: peer.NewCRStatesThreadsafe(),
+		feed:           peer.NewCRStatesFeed(10),
+		unpolledCaches: threadsafe.NewUnpolledCaches(),
+	}
+	data.localStates.AddCache("edge", tc.IsAvailable{IsAvailable: false})
+	data.combinedStates.AddCache("edge", tc.IsAvailable{IsAvailable: true, Ipv4Available: true})
+	data.combinedStates.SetDeliveryService("ds1", tc.CRStatesDeliveryService{IsAvailable: true, DisabledLocations: []tc.CacheGroupName{"cg1"}})
+	data.feed.Update(data.combinedStates.Get())
+
+	statResultHistory := threadsafe.NewResultStatHistory()
+	statResultHistory.LoadOrStore("edge").Stats.Store("proxy.process.http.completed_requests", []tc.ResultStatVal{
+		{Span: 2, Time: time.Unix(100, 0), Val: float64(42)},
+		{Span: 1, Time: time.Unix(90, 0), Val: float64(40)},
+	})
+
+	dsStats := threadsafe.NewDSStats()
+	stats := dsdata.NewStats(1)
+	stats.DeliveryService["ds1"] = &dsdata.Stat{TotalStats: dsdata.StatCacheStats{Status5xx: dsdata.StatInt{Value: 7}}}
+	stats.Time = time.Unix(100, 0)
+	dsStats.Set(*stats)
+
+	s := NewServer(
+		data.localStates,
+		data.combinedStates,
+		peer.NewCRStatesPeersThreadsafe(0),
+		data.feed,
+		threadsafe.NewResultInfoHistory(),
+		statResultHistory,
+		threadsafe.NewCacheKbpses(),
+		&dsStats,
+		todata.NewThreadsafe(),
+		threadsafe.NewTrafficMonitorConfigMap(),
+		data.unpolledCaches,
+		threadsafe.NewUint(),
+		time.Second,
+	)
+
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(s.unaryUnpolledCheck), grpc.StreamInterceptor(s.streamUnpolledCheck))
+	tmpb.RegisterTrafficMonitorServer(grpcServer, s)
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	dial := func(ctx context.Context, addr string) (net.Conn, error) { return listener.Dial() }
+	client, err := tmclient.NewGRPC("bufconn", 5*time.Second, grpc.WithContextDialer(dial), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("creating gRPC client - expected: no error, actual: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client, data
+}
+
+func TestUnpolled(t *testing.T) {
+	client, data := startTestServer(t)
+	if _, err := client.Client().GetCrStates(context.Background(), &tmpb.CrStatesRequest{}); status.Code(err) != codes.Unavailable {
+		t.Errorf("getting CrStates with unpolled caches - expected: Unavailable error, actual: %v", err)
+	}
+	data.unpolledCaches.SetNewCaches(map[tc.CacheName]struct{}{})
+	if _, err := client.CRStates(false); err != nil {
+		t.Errorf("getting CrStates with all caches polled - expected: no error, actual: %v", err)
+	}
+}
+
+func TestCrStates(t *testing.T) {
+	client, data := startTestServer(t)
+	data.unpolledCaches.SetNewCaches(map[tc.CacheName]struct{}{})
+
+	states, err := client.CRStates(false)
+	if err != nil {
+		t.Fatalf("getting CrStates - expected: no error, actual: %v", err)
+	}
+	if cache := states.Caches["edge"]; !cache.IsAvailable || !cache.Ipv4Available || cache.Ipv6Available {
+		t.Errorf("CrStates cache edge - expected: available on IPv4, actual: %+v", cache)
+	}
+	if ds := states.DeliveryService["ds1"]; !ds.IsAvailable || len(ds.DisabledLocations) != 1 || ds.DisabledLocations[0] != "cg1" {
+		t.Errorf("CrStates Delivery Service ds1 - expected: available with disabled location cg1, actual: %+v", ds)
+	}
+
+	if states, err = client.CRStates(true); err != nil {
+		t.Fatalf("getting raw CrStates - expected: no error, actual: %v", err)
+	}
+	if states.Caches["edge"].IsAvailable {
+		t.Error("raw CrStates cache edge - expected: unavailable, actual: available")
+	}
+}
+
+func TestStreamCrStates(t *testing.T) {
+	client, data := startTestServer(t)
+	data.unpolledCaches.SetNewCaches(map[tc.CacheName]struct{}{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	received := []tc.CRStates{}
+	err := client.StreamCRStates(ctx, func(states tc.CRStates, seq uint64) error {
+		received = append(received, states)
+		if len(received) == 1 {
+			data.combinedStates.SetCache("edge", tc.IsAvailable{IsAvailable: false})
+			data.feed.Update(data.combinedStates.Get())
+			return nil
+		}
+		cancel()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("streaming CrStates - expected: no error, actual: %v", err)
+	}
+	if len(received) != 2 {
+		t.Fatalf("streamed CrStates - expected: 2, actual: %d", len(received))
+	}
+	if !received[0].Caches["edge"].IsAvailable || received[1].Caches["edge"].IsAvailable {
+		t.Errorf("streamed CrStates cache edge - expected: available then unavailable, actual: %+v then %+v", received[0].Caches["edge"], received[1].Caches["edge"])
+	}
+}
+
+func TestCacheStats(t *testing.T) {
+	client, data := startTestServer(t)
+	data.unpolledCaches.SetNewCaches(map[tc.CacheName]struct{}{})
+
+	stats, err := client.CacheStatsNew(&tmpb.CacheStatsRequest{AllHistory: true, Stats: []string{"ats.proxy.process.http.completed_requests"}})
+	if err != nil {
+		t.Fatalf("getting cache stats - expected: no error, actual: %v", err)
+	}
+	vals := stats.Caches["edge"].Stats["ats.proxy.process.http.completed_requests"]
+	if len(vals) != 2 {
+		t.Fatalf("cache stat history - expected: 2 values, actual: %+v", vals)
+	}
+	if vals[0].Val != float64(42) || vals[0].Span != 2 || !vals[0].Time.Equal(time.Unix(100, 0)) {
+		t.Errorf("newest cache stat value - expected: 42 with span 2 at %v, actual: %+v", time.Unix(100, 0), vals[0])
+	}
+
+	if stats, err = client.CacheStatsNew(&tmpb.CacheStatsRequest{Stats: []string{"nonexistent"}}); err != nil {
+		t.Fatalf("getting filtered cache stats - expected: no error, actual: %v", err)
+	}
+	if len(stats.Caches["edge"].Stats) != 0 {
+		t.Errorf("filtered cache stats - expected: none, actual: %+v", stats.Caches["edge"].Stats)
+	}
+
+	if _, err := client.Client().GetCacheStats(context.Background(), &tmpb.CacheStatsRequest{Type: "invalid"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("getting cache stats of an invalid type - expected: InvalidArgument error, actual: %v", err)
+	}
+}
+
+func TestDsStats(t *testing.T) {
+	client, data := startTestServer(t)
+	data.unpolledCaches.SetNewCaches(map[tc.CacheName]struct{}{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	streamed := 0
+	err := client.StreamDSStats(ctx, &tmpb.DsStatsRequest{DeliveryServices: []string{"ds1"}, Stats: []string{"status_5xx"}}, func(stats dsdata.StatsOld) error {
+		vals := stats.DeliveryService["ds1"]["total.status_5xx"]
+		if len(vals) != 1 || vals[0].Value != "7" || vals[0].Time != 100000 {
+			t.Errorf("Delivery Service stat - expected: 7 at 100000, actual: %+v", vals)
+		}
+		if streamed++; streamed == 2 {
+			cancel()
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("streaming Delivery Service stats - expected: no error, actual: %v", err)
+	}
+	if streamed != 2 {
+		t.Errorf("streamed Delivery Service stats - expected: 2, actual: %d", streamed)
+	}
+}
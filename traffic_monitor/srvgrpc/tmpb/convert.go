@@ -0,0 +1,232 @@
+package tmpb
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+// This file converts between the protobuf messages and the types the
+// equivalent JSON endpoints serve, so gRPC clients and the server can use
+// the same types as their JSON counterparts.
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/lib/go-util"
+	"github.com/apache/trafficcontrol/traffic_monitor/dsdata"
+	"github.com/apache/trafficcontrol/traffic_monitor/srvhttp"
+)
+
+// NewCrStates creates a CrStates message from the given states, with the given
+// change feed sequence number.
+func NewCrStates(states tc.CRStates, sequence uint64) *CrStates {
+	m := &CrStates{
+		Sequence:         sequence,
+		Caches:           make(map[string]*CacheAvailability, len(states.Caches)),
+		DeliveryServices: make(map[string]*DeliveryServiceAvailability, len(states.DeliveryService)),
+	}
+	for name, avail := range states.Caches {
+		m.Caches[string(name)] = &CacheAvailability{
+			IsAvailable:   avail.IsAvailable,
+			Ipv4Available: avail.Ipv4Available,
+			Ipv6Available: avail.Ipv6Available,
+			Reason:        avail.Reason,
+		}
+	}
+	for name, ds := range states.DeliveryService {
+		disabledLocations := make([]string, 0, len(ds.DisabledLocations))
+		for _, cg := range ds.DisabledLocations {
+			disabledLocations = append(disabledLocations, string(cg))
+		}
+		m.DeliveryServices[string(name)] = &DeliveryServiceAvailability{
+			DisabledLocations: disabledLocations,
+			IsAvailable:       ds.IsAvailable,
+		}
+	}
+	return m
+}
+
+// ToCRStates converts the message to the CrStates served by /publish/CrStates.
+func (m *CrStates) ToCRStates() tc.CRStates {
+	states := tc.CRStates{
+		Caches:          make(map[tc.CacheName]tc.IsAvailable, len(m.GetCaches())),
+		DeliveryService: make(map[tc.DeliveryServiceName]tc.CRStatesDeliveryService, len(m.GetDeliveryServices())),
+	}
+	for name, avail := range m.GetCaches() {
+		states.Caches[tc.CacheName(name)] = tc.IsAvailable{
+			IsAvailable:   avail.GetIsAvailable(),
+			Ipv4Available: avail.GetIpv4Available(),
+			Ipv6Available: avail.GetIpv6Available(),
+			Reason:        avail.GetReason(),
+		}
+	}
+	for name, ds := range m.GetDeliveryServices() {
+		disabledLocations := make([]tc.CacheGroupName, 0, len(ds.GetDisabledLocations()))
+		for _, cg := range ds.GetDisabledLocations() {
+			disabledLocations = append(disabledLocations, tc.CacheGroupName(cg))
+		}
+		states.DeliveryService[tc.DeliveryServiceName(name)] = tc.CRStatesDeliveryService{
+			DisabledLocations: disabledLocations,
+			IsAvailable:       ds.GetIsAvailable(),
+		}
+	}
+	return states
+}
+
+// NewStatValue creates a StatValue message from a stat value. Strings and
+// booleans are kept as they are, other values are converted to numbers if
+// they're numeric, and strings if not.
+func NewStatValue(span uint64, t time.Time, val interface{}) *StatValue {
+	m := &StatValue{Span: span, TimeUnixNano: t.UnixNano()}
+	switch v := val.(type) {
+	case string:
+		m.Value = &StatValue_String_{String_: v}
+	case bool:
+		m.Value = &StatValue_Bool{Bool: v}
+	default:
+		if num, ok := util.ToNumeric(v); ok {
+			m.Value = &StatValue_Number{Number: num}
+		} else {
+			m.Value = &StatValue_String_{String_: fmt.Sprintf("%v", v)}
+		}
+	}
+	return m
+}
+
+// Val returns the value of the stat, which is a float64, string, or bool.
+func (m *StatValue) Val() interface{} {
+	switch v := m.GetValue().(type) {
+	case *StatValue_Number:
+		return v.Number
+	case *StatValue_String_:
+		return v.String_
+	case *StatValue_Bool:
+		return v.Bool
+	}
+	return nil
+}
+
+// Time returns the time the stat was polled.
+func (m *StatValue) Time() time.Time {
+	return time.Unix(0, m.GetTimeUnixNano())
+}
+
+func newStatHistory(vals []tc.ResultStatVal) *StatHistory {
+	history := &StatHistory{Values: make([]*StatValue, 0, len(vals))}
+	for _, val := range vals {
+		history.Values = append(history.Values, NewStatValue(val.Span, val.Time, val.Val))
+	}
+	return history
+}
+
+func (m *StatHistory) toResultStatVals() []tc.ResultStatVal {
+	vals := make([]tc.ResultStatVal, 0, len(m.GetValues()))
+	for _, val := range m.GetValues() {
+		vals = append(vals, tc.ResultStatVal{Span: val.GetSpan(), Time: val.Time(), Val: val.Val()})
+	}
+	return vals
+}
+
+// NewCacheStats creates a CacheStats message from the given stats, generated
+// at the given time.
+func NewCacheStats(stats tc.Stats, t time.Time) *CacheStats {
+	m := &CacheStats{TimeUnixNano: t.UnixNano(), Caches: make(map[string]*ServerStats, len(stats.Caches))}
+	for name, serverStats := range stats.Caches {
+		server := &ServerStats{
+			Interfaces: make(map[string]*InterfaceStats, len(serverStats.Interfaces)),
+			Stats:      make(map[string]*StatHistory, len(serverStats.Stats)),
+		}
+		for inf, infStats := range serverStats.Interfaces {
+			interfaceStats := &InterfaceStats{Stats: make(map[string]*StatHistory, len(infStats))}
+			for stat, vals := range infStats {
+				interfaceStats.Stats[stat] = newStatHistory(vals)
+			}
+			server.Interfaces[inf] = interfaceStats
+		}
+		for stat, vals := range serverStats.Stats {
+			server.Stats[stat] = newStatHistory(vals)
+		}
+		m.Caches[name] = server
+	}
+	return m
+}
+
+// ToStats converts the message to the stats served by /publish/CacheStatsNew.
+func (m *CacheStats) ToStats() tc.Stats {
+	stats := tc.Stats{
+		CommonAPIData: tc.CommonAPIData{DateStr: srvhttp.DateStr(time.Unix(0, m.GetTimeUnixNano()))},
+		Caches:        make(map[string]tc.ServerStats, len(m.GetCaches())),
+	}
+	for name, server := range m.GetCaches() {
+		serverStats := tc.ServerStats{
+			Interfaces: make(map[string]map[string][]tc.ResultStatVal, len(server.GetInterfaces())),
+			Stats:      make(map[string][]tc.ResultStatVal, len(server.GetStats())),
+		}
+		for inf, infStats := range server.GetInterfaces() {
+			serverStats.Interfaces[inf] = make(map[string][]tc.ResultStatVal, len(infStats.GetStats()))
+			for stat, history := range infStats.GetStats() {
+				serverStats.Interfaces[inf][stat] = history.toResultStatVals()
+			}
+		}
+		for stat, history := range server.GetStats() {
+			serverStats.Stats[stat] = history.toResultStatVals()
+		}
+		stats.Caches[name] = serverStats
+	}
+	return stats
+}
+
+// NewDsStats creates a DsStats message from the given stats, generated at the
+// given time.
+func NewDsStats(stats dsdata.StatsOld, t time.Time) *DsStats {
+	m := &DsStats{TimeUnixNano: t.UnixNano(), DeliveryServices: make(map[string]*DeliveryServiceStats, len(stats.DeliveryService))}
+	for name, dsStats := range stats.DeliveryService {
+		ds := &DeliveryServiceStats{Stats: make(map[string]*StatHistory, len(dsStats))}
+		for stat, vals := range dsStats {
+			history := &StatHistory{Values: make([]*StatValue, 0, len(vals))}
+			for _, val := range vals {
+				// the JSON stat times are milliseconds since the epoch
+				history.Values = append(history.Values, NewStatValue(uint64(val.Span), time.Unix(0, val.Time*int64(time.Millisecond)), val.Value))
+			}
+			ds.Stats[string(stat)] = history
+		}
+		m.DeliveryServices[string(name)] = ds
+	}
+	return m
+}
+
+// ToStatsOld converts the message to the stats served by /publish/DsStats.
+func (m *DsStats) ToStatsOld() dsdata.StatsOld {
+	stats := dsdata.StatsOld{
+		CommonAPIData:   tc.CommonAPIData{DateStr: srvhttp.DateStr(time.Unix(0, m.GetTimeUnixNano()))},
+		DeliveryService: make(map[tc.DeliveryServiceName]map[dsdata.StatName][]dsdata.StatOld, len(m.GetDeliveryServices())),
+	}
+	for name, ds := range m.GetDeliveryServices() {
+		dsStats := make(map[dsdata.StatName][]dsdata.StatOld, len(ds.GetStats()))
+		for stat, history := range ds.GetStats() {
+			vals := make([]dsdata.StatOld, 0, len(history.GetValues()))
+			for _, val := range history.GetValues() {
+				vals = append(vals, dsdata.StatOld{Time: val.GetTimeUnixNano() / int64(time.Millisecond), Value: val.Val(), Span: int(val.GetSpan())})
+			}
+			dsStats[dsdata.StatName(stat)] = vals
+		}
+		stats.DeliveryService[tc.DeliveryServiceName(name)] = dsStats
+	}
+	return stats
+}
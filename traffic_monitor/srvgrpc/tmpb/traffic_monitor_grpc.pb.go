@@ -0,0 +1,377 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package tmpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// TrafficMonitorClient is the client API for TrafficMonitor service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type TrafficMonitorClient interface {
+	// GetCrStates returns the same states as /publish/CrStates.
+	GetCrStates(ctx context.Context, in *CrStatesRequest, opts ...grpc.CallOption) (*CrStates, error)
+	// StreamCrStates sends the combined states, and then the complete states
+	// again every time they change.
+	StreamCrStates(ctx context.Context, in *StreamCrStatesRequest, opts ...grpc.CallOption) (TrafficMonitor_StreamCrStatesClient, error)
+	// GetCacheStats returns the same stats as /publish/CacheStatsNew.
+	GetCacheStats(ctx context.Context, in *CacheStatsRequest, opts ...grpc.CallOption) (*CacheStats, error)
+	// StreamCacheStats sends the requested stats every interval.
+	StreamCacheStats(ctx context.Context, in *CacheStatsRequest, opts ...grpc.CallOption) (TrafficMonitor_StreamCacheStatsClient, error)
+	// GetDsStats returns the same stats as /publish/DsStats.
+	GetDsStats(ctx context.Context, in *DsStatsRequest, opts ...grpc.CallOption) (*DsStats, error)
+	// StreamDsStats sends the requested stats every interval.
+	StreamDsStats(ctx context.Context, in *DsStatsRequest, opts ...grpc.CallOption) (TrafficMonitor_StreamDsStatsClient, error)
+}
+
+type trafficMonitorClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTrafficMonitorClient(cc grpc.ClientConnInterface) TrafficMonitorClient {
+	return &trafficMonitorClient{cc}
+}
+
+func (c *trafficMonitorClient) GetCrStates(ctx context.Context, in *CrStatesRequest, opts ...grpc.CallOption) (*CrStates, error) {
+	out := new(CrStates)
+	err := c.cc.Invoke(ctx, "/trafficmonitor.v1.TrafficMonitor/GetCrStates", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trafficMonitorClient) StreamCrStates(ctx context.Context, in *StreamCrStatesRequest, opts ...grpc.CallOption) (TrafficMonitor_StreamCrStatesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &TrafficMonitor_ServiceDesc.Streams[0], "/trafficmonitor.v1.TrafficMonitor/StreamCrStates", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &trafficMonitorStreamCrStatesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type TrafficMonitor_StreamCrStatesClient interface {
+	Recv() (*CrStates, error)
+	grpc.ClientStream
+}
+
+type trafficMonitorStreamCrStatesClient struct {
+	grpc.ClientStream
+}
+
+func (x *trafficMonitorStreamCrStatesClient) Recv() (*CrStates, error) {
+	m := new(CrStates)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *trafficMonitorClient) GetCacheStats(ctx context.Context, in *CacheStatsRequest, opts ...grpc.CallOption) (*CacheStats, error) {
+	out := new(CacheStats)
+	err := c.cc.Invoke(ctx, "/trafficmonitor.v1.TrafficMonitor/GetCacheStats", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trafficMonitorClient) StreamCacheStats(ctx context.Context, in *CacheStatsRequest, opts ...grpc.CallOption) (TrafficMonitor_StreamCacheStatsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &TrafficMonitor_ServiceDesc.Streams[1], "/trafficmonitor.v1.TrafficMonitor/StreamCacheStats", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &trafficMonitorStreamCacheStatsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type TrafficMonitor_StreamCacheStatsClient interface {
+	Recv() (*CacheStats, error)
+	grpc.ClientStream
+}
+
+type trafficMonitorStreamCacheStatsClient struct {
+	grpc.ClientStream
+}
+
+func (x *trafficMonitorStreamCacheStatsClient) Recv() (*CacheStats, error) {
+	m := new(CacheStats)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *trafficMonitorClient) GetDsStats(ctx context.Context, in *DsStatsRequest, opts ...grpc.CallOption) (*DsStats, error) {
+	out := new(DsStats)
+	err := c.cc.Invoke(ctx, "/trafficmonitor.v1.TrafficMonitor/GetDsStats", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trafficMonitorClient) StreamDsStats(ctx context.Context, in *DsStatsRequest, opts ...grpc.CallOption) (TrafficMonitor_StreamDsStatsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &TrafficMonitor_ServiceDesc.Streams[2], "/trafficmonitor.v1.TrafficMonitor/StreamDsStats", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &trafficMonitorStreamDsStatsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type TrafficMonitor_StreamDsStatsClient interface {
+	Recv() (*DsStats, error)
+	grpc.ClientStream
+}
+
+type trafficMonitorStreamDsStatsClient struct {
+	grpc.ClientStream
+}
+
+func (x *trafficMonitorStreamDsStatsClient) Recv() (*DsStats, error) {
+	m := new(DsStats)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// TrafficMonitorServer is the server API for TrafficMonitor service.
+// All implementations must embed UnimplementedTrafficMonitorServer
+// for forward compatibility
+type TrafficMonitorServer interface {
+	// GetCrStates returns the same states as /publish/CrStates.
+	GetCrStates(context.Context, *CrStatesRequest) (*CrStates, error)
+	// StreamCrStates sends the combined states, and then the complete states
+	// again every time they change.
+	StreamCrStates(*StreamCrStatesRequest, TrafficMonitor_StreamCrStatesServer) error
+	// GetCacheStats returns the same stats as /publish/CacheStatsNew.
+	GetCacheStats(context.Context, *CacheStatsRequest) (*CacheStats, error)
+	// StreamCacheStats sends the requested stats every interval.
+	StreamCacheStats(*CacheStatsRequest, TrafficMonitor_StreamCacheStatsServer) error
+	// GetDsStats returns the same stats as /publish/DsStats.
+	GetDsStats(context.Context, *DsStatsRequest) (*DsStats, error)
+	// StreamDsStats sends the requested stats every interval.
+	StreamDsStats(*DsStatsRequest, TrafficMonitor_StreamDsStatsServer) error
+	mustEmbedUnimplementedTrafficMonitorServer()
+}
+
+// UnimplementedTrafficMonitorServer must be embedded to have forward compatible implementations.
+type UnimplementedTrafficMonitorServer struct {
+}
+
+func (UnimplementedTrafficMonitorServer) GetCrStates(context.Context, *CrStatesRequest) (*CrStates, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCrStates not implemented")
+}
+func (UnimplementedTrafficMonitorServer) StreamCrStates(*StreamCrStatesRequest, TrafficMonitor_StreamCrStatesServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamCrStates not implemented")
+}
+func (UnimplementedTrafficMonitorServer) GetCacheStats(context.Context, *CacheStatsRequest) (*CacheStats, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCacheStats not implemented")
+}
+func (UnimplementedTrafficMonitorServer) StreamCacheStats(*CacheStatsRequest, TrafficMonitor_StreamCacheStatsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamCacheStats not implemented")
+}
+func (UnimplementedTrafficMonitorServer) GetDsStats(context.Context, *DsStatsRequest) (*DsStats, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDsStats not implemented")
+}
+func (UnimplementedTrafficMonitorServer) StreamDsStats(*DsStatsRequest, TrafficMonitor_StreamDsStatsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamDsStats not implemented")
+}
+func (UnimplementedTrafficMonitorServer) mustEmbedUnimplementedTrafficMonitorServer() {}
+
+// UnsafeTrafficMonitorServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TrafficMonitorServer will
+// result in compilation errors.
+type UnsafeTrafficMonitorServer interface {
+	mustEmbedUnimplementedTrafficMonitorServer()
+}
+
+func RegisterTrafficMonitorServer(s grpc.ServiceRegistrar, srv TrafficMonitorServer) {
+	s.RegisterService(&TrafficMonitor_ServiceDesc, srv)
+}
+
+func _TrafficMonitor_GetCrStates_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CrStatesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrafficMonitorServer).GetCrStates(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trafficmonitor.v1.TrafficMonitor/GetCrStates",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrafficMonitorServer).GetCrStates(ctx, req.(*CrStatesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrafficMonitor_StreamCrStates_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamCrStatesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TrafficMonitorServer).StreamCrStates(m, &trafficMonitorStreamCrStatesServer{stream})
+}
+
+type TrafficMonitor_StreamCrStatesServer interface {
+	Send(*CrStates) error
+	grpc.ServerStream
+}
+
+type trafficMonitorStreamCrStatesServer struct {
+	grpc.ServerStream
+}
+
+func (x *trafficMonitorStreamCrStatesServer) Send(m *CrStates) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _TrafficMonitor_GetCacheStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CacheStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrafficMonitorServer).GetCacheStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trafficmonitor.v1.TrafficMonitor/GetCacheStats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrafficMonitorServer).GetCacheStats(ctx, req.(*CacheStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrafficMonitor_StreamCacheStats_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(CacheStatsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TrafficMonitorServer).StreamCacheStats(m, &trafficMonitorStreamCacheStatsServer{stream})
+}
+
+type TrafficMonitor_StreamCacheStatsServer interface {
+	Send(*CacheStats) error
+	grpc.ServerStream
+}
+
+type trafficMonitorStreamCacheStatsServer struct {
+	grpc.ServerStream
+}
+
+func (x *trafficMonitorStreamCacheStatsServer) Send(m *CacheStats) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _TrafficMonitor_GetDsStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DsStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrafficMonitorServer).GetDsStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trafficmonitor.v1.TrafficMonitor/GetDsStats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrafficMonitorServer).GetDsStats(ctx, req.(*DsStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrafficMonitor_StreamDsStats_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DsStatsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TrafficMonitorServer).StreamDsStats(m, &trafficMonitorStreamDsStatsServer{stream})
+}
+
+type TrafficMonitor_StreamDsStatsServer interface {
+	Send(*DsStats) error
+	grpc.ServerStream
+}
+
+type trafficMonitorStreamDsStatsServer struct {
+	grpc.ServerStream
+}
+
+func (x *trafficMonitorStreamDsStatsServer) Send(m *DsStats) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// TrafficMonitor_ServiceDesc is the grpc.ServiceDesc for TrafficMonitor service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var TrafficMonitor_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "trafficmonitor.v1.TrafficMonitor",
+	HandlerType: (*TrafficMonitorServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetCrStates",
+			Handler:    _TrafficMonitor_GetCrStates_Handler,
+		},
+		{
+			MethodName: "GetCacheStats",
+			Handler:    _TrafficMonitor_GetCacheStats_Handler,
+		},
+		{
+			MethodName: "GetDsStats",
+			Handler:    _TrafficMonitor_GetDsStats_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamCrStates",
+			Handler:       _TrafficMonitor_StreamCrStates_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamCacheStats",
+			Handler:       _TrafficMonitor_StreamCacheStats_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamDsStats",
+			Handler:       _TrafficMonitor_StreamDsStats_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "traffic_monitor.proto",
+}
@@ -21,22 +21,36 @@ package threadsafe
 
 import (
 	"sync"
+	"time"
 
 	"github.com/apache/trafficcontrol/traffic_monitor/cache"
 )
 
 // CacheAvailableStatus wraps a map of cache available statuses to be safe for
 // multiple reader goroutines and one writer.
+//
+// It also holds why each cache's synthetic probes last failed. These are kept
+// apart from the available statuses, because they're written by the probe
+// result manager, and must survive the health and stat pollers setting the
+// statuses they calculate.
 type CacheAvailableStatus struct {
 	caches *cache.AvailableStatuses
+	probes map[string]probeFailure
 	m      *sync.RWMutex
 }
 
+// probeFailure is why a cache's synthetic probes failed, and when that was
+// last reported.
+type probeFailure struct {
+	why  string
+	time time.Time
+}
+
 // NewCacheAvailableStatus creates and returns a new CacheAvailableStatus,
 // initializing internal pointer values.
 func NewCacheAvailableStatus() CacheAvailableStatus {
 	c := cache.AvailableStatuses(map[string]cache.AvailableStatus{})
-	return CacheAvailableStatus{m: &sync.RWMutex{}, caches: &c}
+	return CacheAvailableStatus{m: &sync.RWMutex{}, caches: &c, probes: map[string]probeFailure{}}
 }
 
 // Get returns the internal map of cache statuses. The returned map MUST NOT be
@@ -54,3 +68,41 @@ func (o *CacheAvailableStatus) Set(v cache.AvailableStatuses) {
 	*o.caches = v
 	o.m.Unlock()
 }
+
+// ProbeFailure returns why the synthetic probes of the given cache last failed
+// their thresholds, or an empty string if they passed or the cache isn't
+// probed.
+func (o *CacheAvailableStatus) ProbeFailure(cacheName string) string {
+	o.m.RLock()
+	defer o.m.RUnlock()
+	return o.probes[cacheName].why
+}
+
+// SetProbeFailure sets why the synthetic probes of the given cache failed their
+// thresholds. An empty why clears the failure. This MUST NOT be called by
+// multiple goroutines.
+func (o *CacheAvailableStatus) SetProbeFailure(cacheName string, why string) {
+	o.m.Lock()
+	if why == "" {
+		delete(o.probes, cacheName)
+	} else {
+		o.probes[cacheName] = probeFailure{why: why, time: time.Now()}
+	}
+	o.m.Unlock()
+}
+
+// ClearStaleProbeFailures clears the probe failures last reported before the
+// given time, e.g. of caches which are no longer probed, and returns the names
+// of their caches. This MUST NOT be called by multiple goroutines.
+func (o *CacheAvailableStatus) ClearStaleProbeFailures(before time.Time) []string {
+	o.m.Lock()
+	defer o.m.Unlock()
+	cleared := []string{}
+	for cacheName, failure := range o.probes {
+		if failure.time.Before(before) {
+			delete(o.probes, cacheName)
+			cleared = append(cleared, cacheName)
+		}
+	}
+	return cleared
+}
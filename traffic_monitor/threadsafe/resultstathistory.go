@@ -308,6 +308,10 @@ type CacheStatHistory struct {
 	// Stats is a historical collection of all of the cache server's generic
 	// (non-interface-dependent) statistics.
 	Stats ResultStatValHistory
+	// Baselines holds the recent values of the cache server's stats which have
+	// anomaly thresholds, one per poll regardless of whether they changed, from
+	// which their baselines are learned.
+	Baselines ResultStatValHistory
 }
 
 // NewCacheStatHistory constructs a new empty CacheStatHistory.
@@ -315,6 +319,7 @@ func NewCacheStatHistory() CacheStatHistory {
 	return CacheStatHistory{
 		Interfaces: make(map[string]ResultStatValHistory),
 		Stats:      NewResultStatValHistory(),
+		Baselines:  NewResultStatValHistory(),
 	}
 }
 
@@ -347,7 +352,9 @@ func newStatEqual(history []tc.ResultStatVal, stat interface{}) (bool, error) {
 	return stat == history[0].Val, nil
 }
 
-func generateStats(
+// GenerateStats returns the stats of the caches in combinedStates which pass the
+// filter, as served by /publish/CacheStatsNew.
+func GenerateStats(
 	statResultHistory ResultStatHistory,
 	statInfo cache.ResultInfoHistory,
 	combinedStates tc.CRStates,
@@ -472,7 +479,7 @@ func StatsMarshall(
 	filter cache.Filter,
 	params url.Values,
 ) ([]byte, error) {
-	stats := generateStats(statResultHistory, statInfo, combinedStates, monitorConfig, statMaxKbpses, filter, params)
+	stats := GenerateStats(statResultHistory, statInfo, combinedStates, monitorConfig, statMaxKbpses, filter, params)
 
 	json := jsoniter.ConfigFastest // TODO make configurable
 	return json.Marshal(stats)
@@ -494,7 +501,7 @@ func LegacyStatsMarshall(
 	params url.Values,
 ) ([]byte, error) {
 
-	stats := generateStats(statResultHistory, statInfo, combinedStates, monitorConfig, statMaxKbpses, filter, params)
+	stats := GenerateStats(statResultHistory, statInfo, combinedStates, monitorConfig, statMaxKbpses, filter, params)
 	skippedCaches, legacyStats := stats.ToLegacy(monitorConfig)
 	if len(skippedCaches) > 0 {
 		log.Warnln(strings.Join(skippedCaches, "\n"))
@@ -0,0 +1,158 @@
+package tmclient
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_monitor/dsdata"
+	"github.com/apache/trafficcontrol/traffic_monitor/srvgrpc/tmpb"
+
+	"google.golang.org/grpc"
+)
+
+// GRPCClient is a client of the Traffic Monitor gRPC service, which serves the
+// same data as the CrStates, CacheStatsNew and DsStats endpoints.
+type GRPCClient struct {
+	conn    *grpc.ClientConn
+	client  tmpb.TrafficMonitorClient
+	timeout time.Duration
+}
+
+// NewGRPC returns a client of the gRPC service at the given address, whose
+// requests time out after the given timeout. Connection options such as
+// grpc.WithInsecure() or grpc.WithTransportCredentials() must be given in
+// opts. The client must be closed when it's no longer used.
+func NewGRPC(addr string, timeout time.Duration, opts ...grpc.DialOption) (*GRPCClient, error) {
+	conn, err := grpc.Dial(addr, opts...)
+	if err != nil {
+		return nil, errors.New("dialing '" + addr + "': " + err.Error())
+	}
+	return &GRPCClient{conn: conn, client: tmpb.NewTrafficMonitorClient(conn), timeout: timeout}, nil
+}
+
+// Close closes the client's connection.
+func (c *GRPCClient) Close() error { return c.conn.Close() }
+
+// Client returns the generated client, whose methods return the protobuf
+// messages themselves, for callers which don't need them converted.
+func (c *GRPCClient) Client() tmpb.TrafficMonitorClient { return c.client }
+
+func (c *GRPCClient) CRStates(raw bool) (tc.CRStates, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	states, err := c.client.GetCrStates(ctx, &tmpb.CrStatesRequest{Raw: raw})
+	if err != nil {
+		return tc.CRStates{}, errors.New("getting CrStates: " + err.Error())
+	}
+	return states.ToCRStates(), nil
+}
+
+func (c *GRPCClient) CacheStatsNew(req *tmpb.CacheStatsRequest) (tc.Stats, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	stats, err := c.client.GetCacheStats(ctx, req)
+	if err != nil {
+		return tc.Stats{}, errors.New("getting cache stats: " + err.Error())
+	}
+	return stats.ToStats(), nil
+}
+
+func (c *GRPCClient) DSStats(req *tmpb.DsStatsRequest) (dsdata.StatsOld, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	stats, err := c.client.GetDsStats(ctx, req)
+	if err != nil {
+		return dsdata.StatsOld{}, errors.New("getting Delivery Service stats: " + err.Error())
+	}
+	return stats.ToStatsOld(), nil
+}
+
+// StreamCRStates calls f with the CrStates and their change feed sequence
+// number, and again every time they change, until ctx is done, f returns an
+// error, or the stream fails. It returns nil if ctx is done, and the error
+// otherwise.
+func (c *GRPCClient) StreamCRStates(ctx context.Context, f func(tc.CRStates, uint64) error) error {
+	stream, err := c.client.StreamCrStates(ctx, &tmpb.StreamCrStatesRequest{})
+	if err != nil {
+		return errors.New("streaming CrStates: " + err.Error())
+	}
+	for {
+		states, err := stream.Recv()
+		if err != nil {
+			return streamErr(ctx, "CrStates", err)
+		}
+		if err := f(states.ToCRStates(), states.GetSequence()); err != nil {
+			return err
+		}
+	}
+}
+
+// StreamCacheStats calls f with the requested cache stats every requested
+// interval, like StreamCRStates.
+func (c *GRPCClient) StreamCacheStats(ctx context.Context, req *tmpb.CacheStatsRequest, f func(tc.Stats) error) error {
+	stream, err := c.client.StreamCacheStats(ctx, req)
+	if err != nil {
+		return errors.New("streaming cache stats: " + err.Error())
+	}
+	for {
+		stats, err := stream.Recv()
+		if err != nil {
+			return streamErr(ctx, "cache stats", err)
+		}
+		if err := f(stats.ToStats()); err != nil {
+			return err
+		}
+	}
+}
+
+// StreamDSStats calls f with the requested Delivery Service stats every
+// requested interval, like StreamCRStates.
+func (c *GRPCClient) StreamDSStats(ctx context.Context, req *tmpb.DsStatsRequest, f func(dsdata.StatsOld) error) error {
+	stream, err := c.client.StreamDsStats(ctx, req)
+	if err != nil {
+		return errors.New("streaming Delivery Service stats: " + err.Error())
+	}
+	for {
+		stats, err := stream.Recv()
+		if err != nil {
+			return streamErr(ctx, "Delivery Service stats", err)
+		}
+		if err := f(stats.ToStatsOld()); err != nil {
+			return err
+		}
+	}
+}
+
+// streamErr returns nil if the stream of the given data ended because ctx is
+// done, and the stream error otherwise.
+func streamErr(ctx context.Context, data string, err error) error {
+	if ctx.Err() != nil {
+		return nil
+	}
+	if err == io.EOF {
+		return errors.New("streaming " + data + ": stream ended by server")
+	}
+	return errors.New("streaming " + data + ": " + err.Error())
+}
@@ -25,6 +25,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -98,6 +99,19 @@ func (c *TMClient) EventLog() (datareq.JSONEvents, error) {
 	return obj, nil
 }
 
+// Events returns the events matching the given query parameters, as documented for the /api/events endpoint.
+func (c *TMClient) Events(params url.Values) (datareq.JSONEvents, error) {
+	path := "/api/events"
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+	obj := datareq.JSONEvents{}
+	if err := c.GetJSON(path, &obj); err != nil {
+		return datareq.JSONEvents{}, err // GetJSON adds context
+	}
+	return obj, nil
+}
+
 func (c *TMClient) CacheStatsNew() (tc.Stats, error) {
 	path := "/publish/CacheStats"
 	obj := tc.Stats{}
@@ -137,6 +151,19 @@ func (c *TMClient) CRStates(raw bool) (tc.CRStates, error) {
 	return obj, nil
 }
 
+// CDNCRStates returns the CRStates of the given CDN, which must be monitored by the Monitor.
+func (c *TMClient) CDNCRStates(cdn tc.CDNName, raw bool) (tc.CRStates, error) {
+	path := "/cdn/" + url.PathEscape(string(cdn)) + "/publish/CrStates"
+	if raw {
+		path += "?raw"
+	}
+	obj := tc.CRStates{}
+	if err := c.GetJSON(path, &obj); err != nil {
+		return tc.CRStates{}, err // GetJSON adds context
+	}
+	return obj, nil
+}
+
 func (c *TMClient) CRConfig() (tc.CRConfig, error) {
 	path := "/publish/CrConfig"
 	obj := tc.CRConfig{}
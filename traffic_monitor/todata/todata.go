@@ -70,25 +70,41 @@ func NewRegexes() Regexes {
 	}
 }
 
+// DeliveryServiceHost is the host name clients request an HTTP Delivery
+// Service's content with, and the schemes the Delivery Service accepts.
+type DeliveryServiceHost struct {
+	Host  string
+	HTTP  bool
+	HTTPS bool
+}
+
 // TOData holds CDN data fetched from Traffic Ops.
 type TOData struct {
+	DeliveryServiceHosts   map[tc.DeliveryServiceName]DeliveryServiceHost
 	DeliveryServiceRegexes Regexes
 	DeliveryServiceServers map[tc.DeliveryServiceName][]tc.CacheName
 	DeliveryServiceTypes   map[tc.DeliveryServiceName]tc.DSTypeCategory
 	ServerCachegroups      map[tc.CacheName]tc.CacheGroupName
 	ServerDeliveryServices map[tc.CacheName][]tc.DeliveryServiceName
 	ServerTypes            map[tc.CacheName]tc.CacheType
+	// ServerCDNs is the monitored CDNs of each cache. Caches may be in multiple CDNs.
+	ServerCDNs map[tc.CacheName][]tc.CDNName
+	// DeliveryServiceCDNs is the monitored CDN of each Delivery Service.
+	DeliveryServiceCDNs map[tc.DeliveryServiceName]tc.CDNName
 }
 
 // New returns a new empty TOData object, initializing pointer members.
 func New() *TOData {
 	return &TOData{
+		DeliveryServiceHosts:   map[tc.DeliveryServiceName]DeliveryServiceHost{},
 		DeliveryServiceServers: map[tc.DeliveryServiceName][]tc.CacheName{},
 		ServerDeliveryServices: map[tc.CacheName][]tc.DeliveryServiceName{},
 		ServerTypes:            map[tc.CacheName]tc.CacheType{},
 		DeliveryServiceTypes:   map[tc.DeliveryServiceName]tc.DSTypeCategory{},
 		DeliveryServiceRegexes: NewRegexes(),
 		ServerCachegroups:      map[tc.CacheName]tc.CacheGroupName{},
+		ServerCDNs:             map[tc.CacheName][]tc.CDNName{},
+		DeliveryServiceCDNs:    map[tc.DeliveryServiceName]tc.CDNName{},
 	}
 }
 
@@ -121,50 +137,78 @@ func (d TODataThreadsafe) set(newTOData TOData) {
 // CRConfig is the CrConfig data needed by TOData. Note this is not all data in the CRConfig.
 // TODO change strings to type?
 type CRConfig struct {
-	ContentServers map[tc.CacheName]struct {
-		DeliveryServices map[tc.DeliveryServiceName][]string `json:"deliveryServices"`
-		CacheGroup       string                              `json:"cacheGroup"`
-		Type             string                              `json:"type"`
-	} `json:"contentServers"`
-	DeliveryServices map[tc.DeliveryServiceName]struct {
-		Topology  tc.TopologyName `json:"topology"`
-		Matchsets []struct {
-			Protocol  string `json:"protocol"`
-			MatchList []struct {
-				Regex string `json:"regex"`
-			} `json:"matchlist"`
-		} `json:"matchsets"`
-	} `json:"deliveryServices"`
-	Topologies map[tc.TopologyName]struct {
-		Nodes []string `json:"nodes"`
-	}
+	ContentServers   map[tc.CacheName]crConfigServer                    `json:"contentServers"`
+	DeliveryServices map[tc.DeliveryServiceName]crConfigDeliveryService `json:"deliveryServices"`
+	Topologies       map[tc.TopologyName]crConfigTopology
+}
+
+// crConfigServer is a CRConfig content server. It's an alias, so CRConfigs may be created with struct literals.
+type crConfigServer = struct {
+	DeliveryServices map[tc.DeliveryServiceName][]string `json:"deliveryServices"`
+	CacheGroup       string                              `json:"cacheGroup"`
+	Type             string                              `json:"type"`
+}
+
+// crConfigDeliveryService is a CRConfig Delivery Service. It's an alias, so CRConfigs may be created with struct literals.
+type crConfigDeliveryService = struct {
+	Domains     []string                            `json:"domains"`
+	Protocol    *tc.CRConfigDeliveryServiceProtocol `json:"protocol"`
+	RoutingName string                              `json:"routingName"`
+	Topology    tc.TopologyName                     `json:"topology"`
+	Matchsets   []struct {
+		Protocol  string `json:"protocol"`
+		MatchList []struct {
+			Regex string `json:"regex"`
+		} `json:"matchlist"`
+	} `json:"matchsets"`
+}
+
+// crConfigTopology is a CRConfig Topology. It's an alias, so CRConfigs may be created with struct literals.
+type crConfigTopology = struct {
+	Nodes []string `json:"nodes"`
 }
 
-// Fetch gets the CRConfig from Traffic Ops, creates the TOData maps, and atomically sets the TOData.
+// Fetch gets the CRConfig of each of the given CDNs from Traffic Ops, creates the TOData maps, and atomically sets the TOData.
 // TODO since the session is threadsafe, each TOData get func below could be put in a goroutine, if performance mattered
-func (d TODataThreadsafe) Fetch(to towrap.TrafficOpsSessionThreadsafe, cdn string) error {
-	if _, err := to.CRConfigRaw(cdn); err != nil {
-		return fmt.Errorf("Error getting CRconfig from Traffic Ops: %v", err)
+func (d TODataThreadsafe) Fetch(to towrap.TrafficOpsSessionThreadsafe, cdns ...string) error {
+	for _, cdn := range cdns {
+		if _, err := to.CRConfigRaw(cdn); err != nil {
+			return fmt.Errorf("Error getting CRconfig for CDN %s from Traffic Ops: %v", cdn, err)
+		}
 	}
-	return d.Update(to, cdn)
+	return d.Update(to, cdns...)
 }
 
-// Update updates the TOData data with the last fetched CDN
-func (d TODataThreadsafe) Update(to towrap.TrafficOpsSessionThreadsafe, cdn string) error {
-	crConfigBytes, _, err := to.LastCRConfig(cdn)
-	if err != nil {
-		return fmt.Errorf("Error getting last CRConfig: %v", err)
+// Update updates the TOData data with the last fetched CRConfig of each of the given CDNs. When multiple CDNs are given, their CRConfigs are merged, and the CDNs of each cache and Delivery Service are recorded.
+func (d TODataThreadsafe) Update(to towrap.TrafficOpsSessionThreadsafe, cdns ...string) error {
+	newTOData := TOData{
+		ServerCDNs:          map[tc.CacheName][]tc.CDNName{},
+		DeliveryServiceCDNs: map[tc.DeliveryServiceName]tc.CDNName{},
 	}
 
-	newTOData := TOData{}
-
 	var crConfig CRConfig
 	json := jsoniter.ConfigFastest
-	err = json.Unmarshal(crConfigBytes, &crConfig)
-	if err != nil {
-		return fmt.Errorf("Error unmarshalling CRconfig: %v", err)
+	for _, cdn := range cdns {
+		crConfigBytes, _, err := to.LastCRConfig(cdn)
+		if err != nil {
+			return fmt.Errorf("Error getting last CRConfig for CDN %s: %v", cdn, err)
+		}
+		var cdnCRConfig CRConfig
+		if err := json.Unmarshal(crConfigBytes, &cdnCRConfig); err != nil {
+			return fmt.Errorf("Error unmarshalling CRconfig for CDN %s: %v", cdn, err)
+		}
+		for server := range cdnCRConfig.ContentServers {
+			newTOData.ServerCDNs[server] = append(newTOData.ServerCDNs[server], tc.CDNName(cdn))
+		}
+		for ds := range cdnCRConfig.DeliveryServices {
+			if _, ok := newTOData.DeliveryServiceCDNs[ds]; !ok {
+				newTOData.DeliveryServiceCDNs[ds] = tc.CDNName(cdn)
+			}
+		}
+		crConfig = mergeCRConfigs(crConfig, cdnCRConfig)
 	}
 
+	var err error
 	newTOData.DeliveryServiceServers, newTOData.ServerDeliveryServices, err = getDeliveryServiceServers(crConfig)
 	if err != nil {
 		return err
@@ -180,6 +224,8 @@ func (d TODataThreadsafe) Update(to towrap.TrafficOpsSessionThreadsafe, cdn stri
 		return fmt.Errorf("Error getting delivery service regexes from Traffic Ops: %v\n", err)
 	}
 
+	newTOData.DeliveryServiceHosts = getDeliveryServiceHosts(crConfig)
+
 	newTOData.ServerCachegroups, err = getServerCachegroups(crConfig)
 	if err != nil {
 		return fmt.Errorf("Error getting server cachegroups from Traffic Ops: %v\n", err)
@@ -194,6 +240,46 @@ func (d TODataThreadsafe) Update(to towrap.TrafficOpsSessionThreadsafe, cdn stri
 	return nil
 }
 
+// mergeCRConfigs merges the CRConfig b into a, and returns a. Servers in both are assigned the Delivery Services of both. Where both have the same Delivery Service or Topology, that of a is kept.
+func mergeCRConfigs(a CRConfig, b CRConfig) CRConfig {
+	if a.ContentServers == nil {
+		a.ContentServers = map[tc.CacheName]crConfigServer{}
+	}
+	if a.DeliveryServices == nil {
+		a.DeliveryServices = map[tc.DeliveryServiceName]crConfigDeliveryService{}
+	}
+	if a.Topologies == nil {
+		a.Topologies = map[tc.TopologyName]crConfigTopology{}
+	}
+	for name, bServer := range b.ContentServers {
+		server, ok := a.ContentServers[name]
+		if !ok {
+			a.ContentServers[name] = bServer
+			continue
+		}
+		dses := make(map[tc.DeliveryServiceName][]string, len(server.DeliveryServices)+len(bServer.DeliveryServices))
+		for ds, fqdns := range bServer.DeliveryServices {
+			dses[ds] = fqdns
+		}
+		for ds, fqdns := range server.DeliveryServices {
+			dses[ds] = fqdns
+		}
+		server.DeliveryServices = dses
+		a.ContentServers[name] = server
+	}
+	for name, ds := range b.DeliveryServices {
+		if _, ok := a.DeliveryServices[name]; !ok {
+			a.DeliveryServices[name] = ds
+		}
+	}
+	for name, topology := range b.Topologies {
+		if _, ok := a.Topologies[name]; !ok {
+			a.Topologies[name] = topology
+		}
+	}
+	return a
+}
+
 // getDeliveryServiceServers gets the servers on each delivery services, for the given CDN, from Traffic Ops.
 func getDeliveryServiceServers(crc CRConfig) (map[tc.DeliveryServiceName][]tc.CacheName, map[tc.CacheName][]tc.DeliveryServiceName, error) {
 	dsServers := map[tc.DeliveryServiceName][]tc.CacheName{}
@@ -284,6 +370,35 @@ func createRegexes(dsToRegex map[tc.DeliveryServiceName][]string) (Regexes, erro
 	return dsRegexes, nil
 }
 
+// getDeliveryServiceHosts gets the host name of each HTTP delivery service whose
+// first host regex is of the form `.*\.foo\..*`, for the given CDN, from Traffic Ops.
+// Delivery services with any other regexes have no single host, and are omitted.
+func getDeliveryServiceHosts(crc CRConfig) map[tc.DeliveryServiceName]DeliveryServiceHost {
+	dsHosts := map[tc.DeliveryServiceName]DeliveryServiceHost{}
+
+	for dsName, dsData := range crc.DeliveryServices {
+		if len(dsData.Matchsets) < 1 || tc.DSTypeCategoryFromString(dsData.Matchsets[0].Protocol) != tc.DSTypeCategoryHTTP {
+			continue
+		}
+		if len(dsData.Matchsets[0].MatchList) < 1 || len(dsData.Domains) < 1 || dsData.RoutingName == "" {
+			continue
+		}
+		regexStr := dsData.Matchsets[0].MatchList[0].Regex
+		if !strings.HasPrefix(regexStr, `.*\.`) || !strings.HasSuffix(regexStr, `\..*`) {
+			continue
+		}
+		host := DeliveryServiceHost{Host: dsData.RoutingName + "." + dsData.Domains[0], HTTP: true}
+		if dsData.Protocol != nil {
+			if dsData.Protocol.AcceptHTTP != nil {
+				host.HTTP = *dsData.Protocol.AcceptHTTP
+			}
+			host.HTTPS = dsData.Protocol.AcceptHTTPS
+		}
+		dsHosts[dsName] = host
+	}
+	return dsHosts
+}
+
 // getServerCachegroups gets the cachegroup of each ATS Edge+Mid Cache server, for the given CDN, from Traffic Ops.
 // Returns a map[server]cachegroup.
 func getServerCachegroups(crc CRConfig) (map[tc.CacheName]tc.CacheGroupName, error) {
@@ -22,6 +22,7 @@ package todata
 import (
 	"github.com/apache/trafficcontrol/lib/go-tc"
 
+	"encoding/json"
 	"reflect"
 	"testing"
 )
@@ -43,8 +44,11 @@ func TestGetDeliveryServiceServersWithTopologyBasedDeliveryService(t *testing.T)
 			},
 		},
 		DeliveryServices: map[tc.DeliveryServiceName]struct {
-			Topology  tc.TopologyName `json:"topology"`
-			Matchsets []struct {
+			Domains     []string                            `json:"domains"`
+			Protocol    *tc.CRConfigDeliveryServiceProtocol `json:"protocol"`
+			RoutingName string                              `json:"routingName"`
+			Topology    tc.TopologyName                     `json:"topology"`
+			Matchsets   []struct {
 				Protocol  string `json:"protocol"`
 				MatchList []struct {
 					Regex string `json:"regex"`
@@ -105,8 +109,11 @@ func TestGetDeliveryServiceServersWithNonTopologyBasedDeliveryService(t *testing
 				Type:       "MID",
 			}},
 		DeliveryServices: map[tc.DeliveryServiceName]struct {
-			Topology  tc.TopologyName `json:"topology"`
-			Matchsets []struct {
+			Domains     []string                            `json:"domains"`
+			Protocol    *tc.CRConfigDeliveryServiceProtocol `json:"protocol"`
+			RoutingName string                              `json:"routingName"`
+			Topology    tc.TopologyName                     `json:"topology"`
+			Matchsets   []struct {
 				Protocol  string `json:"protocol"`
 				MatchList []struct {
 					Regex string `json:"regex"`
@@ -142,3 +149,50 @@ func TestGetDeliveryServiceServersWithNonTopologyBasedDeliveryService(t *testing
 		t.Fatalf("getDeliveryServiceServers with non-topology-based delivery service expected: %+v actual: %+v", expectedNonTopologiesTOData, nonTopologiesTOData)
 	}
 }
+
+func TestGetDeliveryServiceHosts(t *testing.T) {
+	crConfigJSON := `{"deliveryServices": {
+		"demo1": {"routingName": "cdn", "domains": ["demo1.mycdn.ciab.test"], "matchsets": [{"protocol": "HTTP", "matchlist": [{"regex": ".*\\.demo1\\..*"}]}]},
+		"demo2": {"routingName": "cdn", "domains": ["demo2.mycdn.ciab.test"], "protocol": {"acceptHttp": "false", "acceptHttps": "true"}, "matchsets": [{"protocol": "HTTP", "matchlist": [{"regex": ".*\\.demo2\\..*"}]}]},
+		"dns": {"routingName": "edge", "domains": ["dns.mycdn.ciab.test"], "matchsets": [{"protocol": "DNS", "matchlist": [{"regex": ".*\\.dns\\..*"}]}]},
+		"raw": {"routingName": "cdn", "domains": ["rawmycdn.ciab.test"], "matchsets": [{"protocol": "HTTP", "matchlist": [{"regex": "raw.example.test"}]}]}
+	}}`
+	crConfig := CRConfig{}
+	if err := json.Unmarshal([]byte(crConfigJSON), &crConfig); err != nil {
+		t.Fatalf("unmarshalling CRConfig: %v", err)
+	}
+
+	expected := map[tc.DeliveryServiceName]DeliveryServiceHost{
+		"demo1": {Host: "cdn.demo1.mycdn.ciab.test", HTTP: true},
+		"demo2": {Host: "cdn.demo2.mycdn.ciab.test", HTTPS: true},
+	}
+	if actual := getDeliveryServiceHosts(crConfig); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("getDeliveryServiceHosts expected: %+v actual: %+v", expected, actual)
+	}
+}
+
+func TestMergeCRConfigs(t *testing.T) {
+	a := CRConfig{}
+	if err := json.Unmarshal([]byte(`{"contentServers":{"edge0":{"cacheGroup":"cg0","type":"EDGE","deliveryServices":{"ds-a":["a.example.net"]}}},"deliveryServices":{"ds-a":{"routingName":"a"}}}`), &a); err != nil {
+		t.Fatalf("unmarshalling CRConfig a: %v", err)
+	}
+	b := CRConfig{}
+	if err := json.Unmarshal([]byte(`{"contentServers":{"edge0":{"cacheGroup":"cg1","type":"EDGE","deliveryServices":{"ds-b":["b.example.net"]}},"edge1":{"cacheGroup":"cg1","type":"EDGE"}},"deliveryServices":{"ds-a":{"routingName":"other"},"ds-b":{"routingName":"b"}}}`), &b); err != nil {
+		t.Fatalf("unmarshalling CRConfig b: %v", err)
+	}
+
+	merged := mergeCRConfigs(mergeCRConfigs(CRConfig{}, a), b)
+	if len(merged.ContentServers) != 2 {
+		t.Errorf("expected 2 merged servers, actual %+v", merged.ContentServers)
+	}
+	edge0 := merged.ContentServers["edge0"]
+	if edge0.CacheGroup != "cg0" {
+		t.Errorf("expected shared server cachegroup of the first CRConfig 'cg0', actual '%s'", edge0.CacheGroup)
+	}
+	if len(edge0.DeliveryServices) != 2 {
+		t.Errorf("expected shared server to have the Delivery Services of both CRConfigs, actual %+v", edge0.DeliveryServices)
+	}
+	if len(merged.DeliveryServices) != 2 || merged.DeliveryServices["ds-a"].RoutingName != "a" {
+		t.Errorf("expected 2 merged Delivery Services keeping the first ds-a, actual %+v", merged.DeliveryServices)
+	}
+}
@@ -85,15 +85,30 @@ func (c ByteMapCache) Get(key string) ([]byte, time.Time, *tc.CRConfigStats) {
 	}
 }
 
-func (s TrafficOpsSessionThreadsafe) BackupFileExists() bool {
-	if _, err := os.Stat(s.CRConfigBackupFile); !os.IsNotExist(err) {
-		if _, err = os.Stat(s.TMConfigBackupFile); !os.IsNotExist(err) {
+// BackupFileExists returns whether both the CRConfig and monitoring config
+// backup files of the given CDN exist.
+func (s TrafficOpsSessionThreadsafe) BackupFileExists(cdn string) bool {
+	if _, err := os.Stat(s.crConfigBackupFile(cdn)); !os.IsNotExist(err) {
+		if _, err = os.Stat(s.tmConfigBackupFile(cdn)); !os.IsNotExist(err) {
 			return true
 		}
 	}
 	return false
 }
 
+// crConfigBackupFile returns the path of the CRConfig backup file of the given
+// CDN. Each monitored CDN has its own backup file, named by suffixing the
+// configured file with the CDN name.
+func (s TrafficOpsSessionThreadsafe) crConfigBackupFile(cdn string) string {
+	return s.CRConfigBackupFile + "." + cdn
+}
+
+// tmConfigBackupFile returns the path of the monitoring config backup file of
+// the given CDN.
+func (s TrafficOpsSessionThreadsafe) tmConfigBackupFile(cdn string) string {
+	return s.TMConfigBackupFile + "." + cdn
+}
+
 // CRConfigStat represents a set of statistics from a CDN Snapshot requested at
 // a particular time.
 type CRConfigStat struct {
@@ -360,11 +375,11 @@ func (s TrafficOpsSessionThreadsafe) CRConfigRaw(cdn string) ([]byte, error) {
 	}
 
 	if err == nil {
-		ioutil.WriteFile(s.CRConfigBackupFile, data, 0644)
+		ioutil.WriteFile(s.crConfigBackupFile(cdn), data, 0644)
 	} else {
-		if s.BackupFileExists() {
+		if s.BackupFileExists(cdn) {
 			log.Errorln("using backup file for CRConfig snapshot due to error fetching CRConfig snapshot from Traffic Ops: " + err.Error())
-			data, err = ioutil.ReadFile(s.CRConfigBackupFile)
+			data, err = ioutil.ReadFile(s.crConfigBackupFile(cdn))
 			if err != nil {
 				return nil, fmt.Errorf("file Read Error: %v", err)
 			}
@@ -470,12 +485,12 @@ func (s TrafficOpsSessionThreadsafe) trafficMonitorConfigMapRaw(cdn string) (*tc
 
 	if err != nil {
 		// Default error case, no backup file exists
-		if !s.BackupFileExists() {
+		if !s.BackupFileExists(cdn) {
 			return nil, err
 		}
 		log.Errorln("using backup file for monitoring config snapshot due to invalid monitoring config snapshot from Traffic Ops: " + err.Error())
 
-		b, err := ioutil.ReadFile(s.TMConfigBackupFile)
+		b, err := ioutil.ReadFile(s.tmConfigBackupFile(cdn))
 		if err != nil {
 			return nil, errors.New("reading TMConfigBackupFile: " + err.Error())
 		}
@@ -491,7 +506,7 @@ func (s TrafficOpsSessionThreadsafe) trafficMonitorConfigMapRaw(cdn string) (*tc
 	json := jsoniter.ConfigFastest
 	data, err := json.Marshal(*config)
 	if err == nil {
-		ioutil.WriteFile(s.TMConfigBackupFile, data, 0644)
+		ioutil.WriteFile(s.tmConfigBackupFile(cdn), data, 0644)
 	}
 
 	return configMap, err
@@ -0,0 +1,25 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+-- +goose Up
+DELETE FROM stats_summary a
+USING stats_summary b
+WHERE a.cdn_name = b.cdn_name
+AND a.deliveryservice_name = b.deliveryservice_name
+AND a.stat_name = b.stat_name
+AND a.stat_date = b.stat_date
+AND a.id < b.id;
+
+CREATE UNIQUE INDEX stats_summary_cdn_ds_stat_date ON stats_summary (cdn_name, deliveryservice_name, stat_name, stat_date);
+
+-- +goose Down
+DROP INDEX IF EXISTS stats_summary_cdn_ds_stat_date;
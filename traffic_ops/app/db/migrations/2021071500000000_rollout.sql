@@ -0,0 +1,31 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS rollout (
+    async_status_id bigint NOT NULL,
+    cdn_id bigint NOT NULL,
+    canary_servers bigint[] NOT NULL,
+    remaining_servers bigint[] NOT NULL,
+    canary_deadline timestamp with time zone NOT NULL,
+    soak_seconds bigint NOT NULL,
+    owner text NOT NULL,
+    lease_expires timestamp with time zone NOT NULL,
+    last_updated timestamp with time zone DEFAULT now() NOT NULL,
+
+    PRIMARY KEY (async_status_id),
+    CONSTRAINT fk_rollout_async_status FOREIGN KEY (async_status_id) REFERENCES async_status(id) ON DELETE CASCADE,
+    CONSTRAINT fk_rollout_cdn FOREIGN KEY (cdn_id) REFERENCES cdn(id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS rollout;
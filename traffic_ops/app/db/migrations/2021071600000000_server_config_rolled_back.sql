@@ -0,0 +1,40 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+-- +goose Up
+ALTER TABLE server ADD COLUMN config_rolled_back boolean NOT NULL DEFAULT FALSE;
+
+-- +goose StatementBegin
+-- A rolled back config stays rolled back until updates or revalidations are queued or cleared again,
+-- which is every update that sets upd_pending or reval_pending. Rollbacks are reported by setting only config_rolled_back.
+CREATE OR REPLACE FUNCTION public.on_update_pending_clear_config_rolled_back()
+    RETURNS trigger
+    AS $$
+BEGIN
+  NEW.config_rolled_back := FALSE;
+  RETURN NEW;
+END;
+$$
+LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+ALTER FUNCTION public.on_update_pending_clear_config_rolled_back() OWNER TO traffic_ops;
+
+CREATE TRIGGER on_update_pending_clear_config_rolled_back
+BEFORE UPDATE OF upd_pending, reval_pending
+ON server
+FOR EACH ROW EXECUTE PROCEDURE on_update_pending_clear_config_rolled_back();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS on_update_pending_clear_config_rolled_back ON server;
+DROP FUNCTION IF EXISTS public.on_update_pending_clear_config_rolled_back();
+ALTER TABLE server DROP COLUMN config_rolled_back;
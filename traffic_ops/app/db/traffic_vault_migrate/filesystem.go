@@ -0,0 +1,378 @@
+package main
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	util "github.com/apache/trafficcontrol/lib/go-util"
+)
+
+// These must match the layout used by the Traffic Ops "filesystem" Traffic Vault backend.
+const (
+	FS_DATA_KEY_FILE = "data.key"
+	FS_DIR_SSL       = "ssl"
+	FS_DIR_DNSSEC    = "dnssec"
+	FS_DIR_URL_SIG   = "url_sig_keys"
+	FS_DIR_URI_SIG   = "uri_signing_keys"
+	FS_SUFFIX        = ".enc"
+)
+
+// FSConfig represents the configuration options available to the file system backend.
+type FSConfig struct {
+	Directory string `json:"directory"`
+	Key       string `json:"masterKey"`
+	MasterKey []byte
+}
+
+// FSBackend is the file system implementation of TVBackend.
+type FSBackend struct {
+	sslKeys        []SSLKey
+	dnssecKeys     []DNSSecKey
+	uriSigningKeys []URISignKey
+	urlSigKeys     []URLSigKey
+	cfg            FSConfig
+	dataKey        []byte
+}
+
+// String returns a high level overview of the backend and its keys.
+func (fs *FSBackend) String() string {
+	data := fmt.Sprintf("File system %s\n", fs.cfg.Directory)
+	data += fmt.Sprintf("\tSSL Keys: %d\n", len(fs.sslKeys))
+	data += fmt.Sprintf("\tDNSSec Keys: %d\n", len(fs.dnssecKeys))
+	data += fmt.Sprintf("\tURI Signing Keys: %d\n", len(fs.uriSigningKeys))
+	data += fmt.Sprintf("\tURL Sig Keys: %d\n", len(fs.urlSigKeys))
+	return data
+}
+
+// Name returns the name for this backend.
+func (fs *FSBackend) Name() string {
+	return "FS"
+}
+
+// ReadConfigFile takes in a filename and will read it into the backends config.
+func (fs *FSBackend) ReadConfigFile(configFile string) error {
+	var err error
+	if err = UnmarshalConfig(configFile, &fs.cfg); err != nil {
+		return err
+	}
+	if fs.cfg.Directory == "" {
+		return errors.New("FS directory is required")
+	}
+
+	if fs.cfg.MasterKey, err = base64.StdEncoding.DecodeString(fs.cfg.Key); err != nil {
+		return fmt.Errorf("unable to decode FS masterKey '%s': %w", fs.cfg.Key, err)
+	}
+
+	if err = util.ValidateAESKey(fs.cfg.MasterKey); err != nil {
+		return fmt.Errorf("unable to validate FS masterKey '%s'", fs.cfg.Key)
+	}
+	return nil
+}
+
+// Start loads the data key of the backend directory, creating both if they do not exist.
+func (fs *FSBackend) Start() error {
+	if err := os.MkdirAll(fs.cfg.Directory, 0700); err != nil {
+		return fmt.Errorf("unable to create FS directory '%s': %w", fs.cfg.Directory, err)
+	}
+	path := filepath.Join(fs.cfg.Directory, FS_DATA_KEY_FILE)
+	wrapped, err := ioutil.ReadFile(path)
+	if err == nil {
+		if fs.dataKey, err = util.AESDecrypt(wrapped, fs.cfg.MasterKey); err != nil {
+			return fmt.Errorf("unable to unwrap FS data key '%s' with masterKey: %w", path, err)
+		}
+		return util.ValidateAESKey(fs.dataKey)
+	}
+	if !os.IsNotExist(err) {
+		return fmt.Errorf("unable to read FS data key '%s': %w", path, err)
+	}
+
+	fs.dataKey = make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, fs.dataKey); err != nil {
+		return fmt.Errorf("unable to generate FS data key: %w", err)
+	}
+	if wrapped, err = util.AESEncrypt(fs.dataKey, fs.cfg.MasterKey); err != nil {
+		return fmt.Errorf("unable to wrap FS data key: %w", err)
+	}
+	return ioutil.WriteFile(path, wrapped, 0600)
+}
+
+// Close is a no-op for the file system backend.
+func (fs *FSBackend) Close() error {
+	return nil
+}
+
+// Ping checks that the backend directory is accessible.
+func (fs *FSBackend) Ping() error {
+	fi, err := os.Stat(fs.cfg.Directory)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("'%s' is not a directory", fs.cfg.Directory)
+	}
+	return nil
+}
+
+// ValidateKey validates that the keys are valid (in most cases, certain fields are not null).
+func (fs *FSBackend) ValidateKey() []string {
+	errs := []string{}
+	for _, key := range fs.sslKeys {
+		if key.DeliveryService == "" {
+			errs = append(errs, fmt.Sprintf("SSL Key '%s': Delivery Service is blank!", key.Key))
+		}
+		if key.CDN == "" {
+			errs = append(errs, fmt.Sprintf("SSL Key '%s': CDN is blank!", key.Key))
+		}
+		if key.Version == "" {
+			errs = append(errs, fmt.Sprintf("SSL Key '%s': Version is blank!", key.Key))
+		}
+	}
+	for i, key := range fs.dnssecKeys {
+		if key.CDN == "" {
+			errs = append(errs, fmt.Sprintf("DNSSec Key #%d: CDN is blank!", i))
+		}
+	}
+	for i, key := range fs.urlSigKeys {
+		if key.DeliveryService == "" {
+			errs = append(errs, fmt.Sprintf("URL Key #%d: Delivery Service is blank!", i))
+		}
+	}
+	for i, key := range fs.uriSigningKeys {
+		if key.DeliveryService == "" {
+			errs = append(errs, fmt.Sprintf("URI Signing Key #%d: Delivery Service is blank!", i))
+		}
+	}
+	return errs
+}
+
+// Fetch gets all of the keys from the backend directory.
+func (fs *FSBackend) Fetch() error {
+	fs.sslKeys = []SSLKey{}
+	dses, err := fsListDir(filepath.Join(fs.cfg.Directory, FS_DIR_SSL))
+	if err != nil {
+		return fmt.Errorf("FSSSLKey fetch: %w", err)
+	}
+	for _, ds := range dses {
+		versions, err := fsListDir(filepath.Join(fs.cfg.Directory, FS_DIR_SSL, ds))
+		if err != nil {
+			return fmt.Errorf("FSSSLKey fetch '%s': %w", ds, err)
+		}
+		for _, version := range versions {
+			key := tc.DeliveryServiceSSLKeys{}
+			if err := fs.readInto(filepath.Join(FS_DIR_SSL, ds, version+FS_SUFFIX), &key); err != nil {
+				return fmt.Errorf("FSSSLKey fetch: %w", err)
+			}
+			fs.sslKeys = append(fs.sslKeys, SSLKey{DeliveryServiceSSLKeys: key, Version: version})
+		}
+	}
+
+	fs.dnssecKeys = []DNSSecKey{}
+	cdns, err := fsListDir(filepath.Join(fs.cfg.Directory, FS_DIR_DNSSEC))
+	if err != nil {
+		return fmt.Errorf("FSDNSSecKey fetch: %w", err)
+	}
+	for _, cdn := range cdns {
+		key := tc.DNSSECKeysTrafficVault{}
+		if err := fs.readInto(filepath.Join(FS_DIR_DNSSEC, cdn+FS_SUFFIX), &key); err != nil {
+			return fmt.Errorf("FSDNSSecKey fetch: %w", err)
+		}
+		fs.dnssecKeys = append(fs.dnssecKeys, DNSSecKey{CDN: cdn, DNSSECKeysTrafficVault: key})
+	}
+
+	fs.urlSigKeys = []URLSigKey{}
+	dses, err = fsListDir(filepath.Join(fs.cfg.Directory, FS_DIR_URL_SIG))
+	if err != nil {
+		return fmt.Errorf("FSURLSigKey fetch: %w", err)
+	}
+	for _, ds := range dses {
+		key := tc.URLSigKeys{}
+		if err := fs.readInto(filepath.Join(FS_DIR_URL_SIG, ds+FS_SUFFIX), &key); err != nil {
+			return fmt.Errorf("FSURLSigKey fetch: %w", err)
+		}
+		fs.urlSigKeys = append(fs.urlSigKeys, URLSigKey{DeliveryService: ds, URLSigKeys: key})
+	}
+
+	fs.uriSigningKeys = []URISignKey{}
+	dses, err = fsListDir(filepath.Join(fs.cfg.Directory, FS_DIR_URI_SIG))
+	if err != nil {
+		return fmt.Errorf("FSURISignKey fetch: %w", err)
+	}
+	for _, ds := range dses {
+		key := map[string]tc.URISignerKeyset{}
+		if err := fs.readInto(filepath.Join(FS_DIR_URI_SIG, ds+FS_SUFFIX), &key); err != nil {
+			return fmt.Errorf("FSURISignKey fetch: %w", err)
+		}
+		fs.uriSigningKeys = append(fs.uriSigningKeys, URISignKey{DeliveryService: ds, Keys: key})
+	}
+	return nil
+}
+
+// Insert takes the current keys and writes them into the backend directory.
+func (fs *FSBackend) Insert() error {
+	for _, key := range fs.sslKeys {
+		if err := fs.writeFrom(filepath.Join(FS_DIR_SSL, key.DeliveryService, key.Version+FS_SUFFIX), key.DeliveryServiceSSLKeys); err != nil {
+			return fmt.Errorf("FSSSLKey insertKeys '%s': %w", key.DeliveryService, err)
+		}
+	}
+	for _, key := range fs.dnssecKeys {
+		if err := fs.writeFrom(filepath.Join(FS_DIR_DNSSEC, key.CDN+FS_SUFFIX), key.DNSSECKeysTrafficVault); err != nil {
+			return fmt.Errorf("FSDNSSecKey insertKeys '%s': %w", key.CDN, err)
+		}
+	}
+	for _, key := range fs.urlSigKeys {
+		if err := fs.writeFrom(filepath.Join(FS_DIR_URL_SIG, key.DeliveryService+FS_SUFFIX), key.URLSigKeys); err != nil {
+			return fmt.Errorf("FSURLSigKey insertKeys '%s': %w", key.DeliveryService, err)
+		}
+	}
+	for _, key := range fs.uriSigningKeys {
+		if err := fs.writeFrom(filepath.Join(FS_DIR_URI_SIG, key.DeliveryService+FS_SUFFIX), key.Keys); err != nil {
+			return fmt.Errorf("FSURISignKey insertKeys '%s': %w", key.DeliveryService, err)
+		}
+	}
+	return nil
+}
+
+// GetSSLKeys converts the backends internal key representation into the common representation (SSLKey).
+func (fs *FSBackend) GetSSLKeys() ([]SSLKey, error) {
+	return fs.sslKeys, nil
+}
+
+// SetSSLKeys takes in keys and converts the data into the backends internal format.
+func (fs *FSBackend) SetSSLKeys(keys []SSLKey) error {
+	for _, key := range keys {
+		if err := fsValidateName(key.DeliveryService); err != nil {
+			return err
+		}
+		if err := fsValidateName(key.Version); err != nil {
+			return err
+		}
+	}
+	fs.sslKeys = keys
+	return nil
+}
+
+// GetDNSSecKeys converts the backends internal key representation into the common representation (DNSSecKey).
+func (fs *FSBackend) GetDNSSecKeys() ([]DNSSecKey, error) {
+	return fs.dnssecKeys, nil
+}
+
+// SetDNSSecKeys takes in keys and converts the data into the backends internal format.
+func (fs *FSBackend) SetDNSSecKeys(keys []DNSSecKey) error {
+	for _, key := range keys {
+		if err := fsValidateName(key.CDN); err != nil {
+			return err
+		}
+	}
+	fs.dnssecKeys = keys
+	return nil
+}
+
+// GetURISignKeys converts the backends internal key representation into the common representation (URISignKey).
+func (fs *FSBackend) GetURISignKeys() ([]URISignKey, error) {
+	return fs.uriSigningKeys, nil
+}
+
+// SetURISignKeys takes in keys and converts the data into the backends internal format.
+func (fs *FSBackend) SetURISignKeys(keys []URISignKey) error {
+	for _, key := range keys {
+		if err := fsValidateName(key.DeliveryService); err != nil {
+			return err
+		}
+	}
+	fs.uriSigningKeys = keys
+	return nil
+}
+
+// GetURLSigKeys converts the backends internal key representation into the common representation (URLSigKey).
+func (fs *FSBackend) GetURLSigKeys() ([]URLSigKey, error) {
+	return fs.urlSigKeys, nil
+}
+
+// SetURLSigKeys takes in keys and converts the data into the backends internal format.
+func (fs *FSBackend) SetURLSigKeys(keys []URLSigKey) error {
+	for _, key := range keys {
+		if err := fsValidateName(key.DeliveryService); err != nil {
+			return err
+		}
+	}
+	fs.urlSigKeys = keys
+	return nil
+}
+
+func (fs *FSBackend) readInto(relPath string, value interface{}) error {
+	path := filepath.Join(fs.cfg.Directory, relPath)
+	encData, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("unable to read '%s': %w", path, err)
+	}
+	if err := decryptInto(fs.dataKey, encData, value); err != nil {
+		return fmt.Errorf("unable to decrypt '%s': %w", path, err)
+	}
+	return nil
+}
+func (fs *FSBackend) writeFrom(relPath string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("unable to marshal keys: %w", err)
+	}
+	encData, err := encrypt(data, fs.dataKey)
+	if err != nil {
+		return fmt.Errorf("encrypt error: %w", err)
+	}
+	path := filepath.Join(fs.cfg.Directory, relPath)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, encData, 0600)
+}
+func fsListDir(dir string) ([]string, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	names := []string{}
+	for _, info := range infos {
+		if strings.HasPrefix(info.Name(), ".") {
+			continue
+		}
+		names = append(names, strings.TrimSuffix(info.Name(), FS_SUFFIX))
+	}
+	return names, nil
+}
+func fsValidateName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, "/\\\x00") {
+		return fmt.Errorf("'%s' cannot be used as a file name", name)
+	}
+	return nil
+}
@@ -44,7 +44,13 @@ type PGConfig struct {
 	SSLMode  string `json:"sslmode"`
 	Database string `json:"database"`
 	Key      string `json:"aesKey"`
-	AESKey   []byte
+	// KeyID is the ID of aesKey in the Traffic Ops keyring, recorded on every row written.
+	KeyID string `json:"aesKeyId"`
+	// PreviousKeys holds the other base64-encoded keys of the Traffic Ops keyring, by ID, which are used to
+	// decrypt rows that are not encrypted with aesKey.
+	PreviousKeys    map[string]string `json:"previousAesKeys"`
+	AESKey          []byte
+	PreviousAESKeys map[string][]byte
 }
 
 // PGBackend is the Postgres implementation of TVBackend.
@@ -86,9 +92,32 @@ func (pg *PGBackend) ReadConfigFile(configFile string) error {
 	if err = util.ValidateAESKey(pg.cfg.AESKey); err != nil {
 		return fmt.Errorf("unable to validate PG AESKey '%s'", pg.cfg.Key)
 	}
+
+	pg.cfg.PreviousAESKeys = make(map[string][]byte, len(pg.cfg.PreviousKeys))
+	for id, key := range pg.cfg.PreviousKeys {
+		if id == pg.cfg.KeyID {
+			return fmt.Errorf("PG previous AES key ID '%s' is the same as aesKeyId", id)
+		}
+		if pg.cfg.PreviousAESKeys[id], err = base64.StdEncoding.DecodeString(key); err != nil {
+			return fmt.Errorf("unable to decode PG previous AESKey '%s': %w", id, err)
+		}
+		if err = util.ValidateAESKey(pg.cfg.PreviousAESKeys[id]); err != nil {
+			return fmt.Errorf("unable to validate PG previous AESKey '%s'", id)
+		}
+	}
 	return nil
 }
 
+// keyring returns the keys used to encrypt and decrypt rows, as configured.
+func (pg *PGBackend) keyring() pgKeyring {
+	keys := make(map[string][]byte, len(pg.cfg.PreviousAESKeys)+1)
+	for id, key := range pg.cfg.PreviousAESKeys {
+		keys[id] = key
+	}
+	keys[pg.cfg.KeyID] = pg.cfg.AESKey
+	return pgKeyring{currentID: pg.cfg.KeyID, keys: keys}
+}
+
 // Insert takes the current keys and inserts them into the backend DB.
 func (pg *PGBackend) Insert() error {
 	if err := pg.sslKey.insertKeys(pg.db); err != nil {
@@ -175,7 +204,7 @@ func (pg *PGBackend) Fetch() error {
 
 // GetSSLKeys converts the backends internal key representation into the common representation (SSLKey).
 func (pg *PGBackend) GetSSLKeys() ([]SSLKey, error) {
-	if err := pg.sslKey.decrypt(pg.cfg.AESKey); err != nil {
+	if err := pg.sslKey.decrypt(pg.keyring()); err != nil {
 		return nil, err
 	}
 	return pg.sslKey.toGeneric(), nil
@@ -184,12 +213,12 @@ func (pg *PGBackend) GetSSLKeys() ([]SSLKey, error) {
 // SetSSLKeys takes in keys and converts & encrypts the data into the backends internal format.
 func (pg *PGBackend) SetSSLKeys(keys []SSLKey) error {
 	pg.sslKey.fromGeneric(keys)
-	return pg.sslKey.encrypt(pg.cfg.AESKey)
+	return pg.sslKey.encrypt(pg.keyring())
 }
 
 // GetDNSSecKeys converts the backends internal key representation into the common representation (DNSSecKey).
 func (pg *PGBackend) GetDNSSecKeys() ([]DNSSecKey, error) {
-	if err := pg.dnssec.decrypt(pg.cfg.AESKey); err != nil {
+	if err := pg.dnssec.decrypt(pg.keyring()); err != nil {
 		return nil, err
 	}
 	return pg.dnssec.toGeneric(), nil
@@ -198,12 +227,12 @@ func (pg *PGBackend) GetDNSSecKeys() ([]DNSSecKey, error) {
 // SetDNSSecKeys takes in keys and converts & encrypts the data into the backends internal format.
 func (pg *PGBackend) SetDNSSecKeys(keys []DNSSecKey) error {
 	pg.dnssec.fromGeneric(keys)
-	return pg.dnssec.encrypt(pg.cfg.AESKey)
+	return pg.dnssec.encrypt(pg.keyring())
 }
 
 // GetURISignKeys converts the pg internal key representation into the common representation (URISignKey).
 func (pg *PGBackend) GetURISignKeys() ([]URISignKey, error) {
-	if err := pg.uriSigningKeys.decrypt(pg.cfg.AESKey); err != nil {
+	if err := pg.uriSigningKeys.decrypt(pg.keyring()); err != nil {
 		return nil, err
 	}
 	return pg.uriSigningKeys.toGeneric(), nil
@@ -212,12 +241,12 @@ func (pg *PGBackend) GetURISignKeys() ([]URISignKey, error) {
 // SetURISignKeys takes in keys and converts & encrypts the data into the backends internal format.
 func (pg *PGBackend) SetURISignKeys(keys []URISignKey) error {
 	pg.uriSigningKeys.fromGeneric(keys)
-	return pg.uriSigningKeys.encrypt(pg.cfg.AESKey)
+	return pg.uriSigningKeys.encrypt(pg.keyring())
 }
 
 // GetURLSigKeys converts the backends internal key representation into the common representation (URLSigKey).
 func (pg *PGBackend) GetURLSigKeys() ([]URLSigKey, error) {
-	if err := pg.urlSigKeys.decrypt(pg.cfg.AESKey); err != nil {
+	if err := pg.urlSigKeys.decrypt(pg.keyring()); err != nil {
 		return nil, err
 	}
 	return pg.urlSigKeys.toGeneric(), nil
@@ -226,11 +255,13 @@ func (pg *PGBackend) GetURLSigKeys() ([]URLSigKey, error) {
 // SetURLSigKeys takes in keys and converts & encrypts the data into the backends internal format.
 func (pg *PGBackend) SetURLSigKeys(keys []URLSigKey) error {
 	pg.urlSigKeys.fromGeneric(keys)
-	return pg.urlSigKeys.encrypt(pg.cfg.AESKey)
+	return pg.urlSigKeys.encrypt(pg.keyring())
 }
 
 type pgCommonRecord struct {
 	DataEncrypted []byte
+	// KeyID is the ID of the key that encrypted DataEncrypted.
+	KeyID string
 }
 
 type pgDNSSecRecord struct {
@@ -249,7 +280,7 @@ func (tbl *pgDNSSecTable) gatherKeys(db *sql.DB) error {
 	}
 	tbl.Records = make([]pgDNSSecRecord, sz)
 
-	query := "SELECT cdn, data from dnssec"
+	query := "SELECT cdn, data, key_id from dnssec"
 	rows, err := db.Query(query)
 	if err != nil {
 		return fmt.Errorf("PGDNSSec gatherKeys: unable to run query '%s': %w", query, err)
@@ -260,32 +291,33 @@ func (tbl *pgDNSSecTable) gatherKeys(db *sql.DB) error {
 		if i > len(tbl.Records)-1 {
 			return fmt.Errorf("PGDNSSec gatherKeys got more results than expected %d", len(tbl.Records))
 		}
-		if err := rows.Scan(&tbl.Records[i].CDN, &tbl.Records[i].DataEncrypted); err != nil {
+		if err := rows.Scan(&tbl.Records[i].CDN, &tbl.Records[i].DataEncrypted, &tbl.Records[i].KeyID); err != nil {
 			return fmt.Errorf("PGDNSSec gatherKeys unable to scan row: %w", err)
 		}
 		i += 1
 	}
 	return nil
 }
-func (tbl *pgDNSSecTable) decrypt(aesKey []byte) error {
+func (tbl *pgDNSSecTable) decrypt(keys pgKeyring) error {
 	for i, _ := range tbl.Records {
-		if err := decryptInto(aesKey, tbl.Records[i].DataEncrypted, &tbl.Records[i].Key); err != nil {
+		if err := keys.decryptInto(tbl.Records[i].KeyID, tbl.Records[i].DataEncrypted, &tbl.Records[i].Key); err != nil {
 			return fmt.Errorf("unable to decrypt into keys: %w", err)
 		}
 	}
 	return nil
 }
-func (tbl *pgDNSSecTable) encrypt(aesKey []byte) error {
+func (tbl *pgDNSSecTable) encrypt(keys pgKeyring) error {
 	for i, dns := range tbl.Records {
 		data, err := json.Marshal(&dns.Key)
 		if err != nil {
 			return fmt.Errorf("encrypt issue marshalling keys: %w", err)
 		}
-		dat, err := encrypt(data, aesKey)
+		dat, err := encrypt(data, keys.keys[keys.currentID])
 		if err != nil {
 			return fmt.Errorf("encrypt error: %w", err)
 		}
 		tbl.Records[i].DataEncrypted = dat
+		tbl.Records[i].KeyID = keys.currentID
 	}
 	return nil
 }
@@ -323,13 +355,14 @@ func (tbl *pgDNSSecTable) validate() []string {
 	return nil
 }
 func (tbl *pgDNSSecTable) insertKeys(db *sql.DB) error {
-	queryBase := "INSERT INTO dnssec (cdn, data) VALUES %s ON CONFLICT (cdn) DO UPDATE SET data = EXCLUDED.data"
-	stride := 2
+	queryBase := "INSERT INTO dnssec (cdn, data, key_id) VALUES %s ON CONFLICT (cdn) DO UPDATE SET data = EXCLUDED.data, key_id = EXCLUDED.key_id"
+	stride := 3
 	queryArgs := make([]interface{}, len(tbl.Records)*stride)
 	for i, record := range tbl.Records {
 		j := i * stride
 		queryArgs[j] = record.CDN
 		queryArgs[j+1] = record.DataEncrypted
+		queryArgs[j+2] = record.KeyID
 	}
 	return insertIntoTable(db, queryBase, stride, queryArgs)
 }
@@ -348,8 +381,8 @@ type pgSSLKeyTable struct {
 }
 
 func (tbl *pgSSLKeyTable) insertKeys(db *sql.DB) error {
-	queryBase := "INSERT INTO sslkey (deliveryservice, data, cdn, version) VALUES %s ON CONFLICT (deliveryservice,cdn,version) DO UPDATE SET data = EXCLUDED.data"
-	stride := 4
+	queryBase := "INSERT INTO sslkey (deliveryservice, data, cdn, version, key_id) VALUES %s ON CONFLICT (deliveryservice,cdn,version) DO UPDATE SET data = EXCLUDED.data, key_id = EXCLUDED.key_id"
+	stride := 5
 	queryArgs := make([]interface{}, len(tbl.Records)*stride)
 	for i, record := range tbl.Records {
 		j := i * stride
@@ -358,8 +391,9 @@ func (tbl *pgSSLKeyTable) insertKeys(db *sql.DB) error {
 		queryArgs[j+1] = record.DataEncrypted
 		queryArgs[j+2] = record.CDN
 		queryArgs[j+3] = record.Version
+		queryArgs[j+4] = record.KeyID
 	}
-	return insertIntoTable(db, queryBase, 4, queryArgs)
+	return insertIntoTable(db, queryBase, stride, queryArgs)
 }
 func (tbl *pgSSLKeyTable) gatherKeys(db *sql.DB) error {
 	sz, err := getSize(db, "sslkey")
@@ -368,7 +402,7 @@ func (tbl *pgSSLKeyTable) gatherKeys(db *sql.DB) error {
 	}
 	tbl.Records = make([]pgSSLKeyRecord, sz)
 
-	query := "SELECT data, deliveryservice, cdn, version from sslkey"
+	query := "SELECT data, deliveryservice, cdn, version, key_id from sslkey"
 	rows, err := db.Query(query)
 	if err != nil {
 		return fmt.Errorf("PGSSLKey gatherKeys unable to run query '%s': %w", query, err)
@@ -379,32 +413,33 @@ func (tbl *pgSSLKeyTable) gatherKeys(db *sql.DB) error {
 		if i > len(tbl.Records)-1 {
 			return fmt.Errorf("PGSSLKey gatherKeys: got more results than expected")
 		}
-		if err := rows.Scan(&tbl.Records[i].DataEncrypted, &tbl.Records[i].DeliveryService, &tbl.Records[i].CDN, &tbl.Records[i].Version); err != nil {
+		if err := rows.Scan(&tbl.Records[i].DataEncrypted, &tbl.Records[i].DeliveryService, &tbl.Records[i].CDN, &tbl.Records[i].Version, &tbl.Records[i].KeyID); err != nil {
 			return fmt.Errorf("PGSSLKey gatherKeys unable to scan %d row: %w", i, err)
 		}
 		i += 1
 	}
 	return nil
 }
-func (tbl *pgSSLKeyTable) decrypt(aesKey []byte) error {
+func (tbl *pgSSLKeyTable) decrypt(keys pgKeyring) error {
 	for i, dns := range tbl.Records {
-		if err := decryptInto(aesKey, dns.DataEncrypted, &tbl.Records[i].Keys); err != nil {
+		if err := keys.decryptInto(dns.KeyID, dns.DataEncrypted, &tbl.Records[i].Keys); err != nil {
 			return fmt.Errorf("unable to decrypt into keys: %w", err)
 		}
 	}
 	return nil
 }
-func (tbl *pgSSLKeyTable) encrypt(aesKey []byte) error {
+func (tbl *pgSSLKeyTable) encrypt(keys pgKeyring) error {
 	for i, dns := range tbl.Records {
 		data, err := json.Marshal(dns.Keys)
 		if err != nil {
 			return fmt.Errorf("encrypt issue marshalling keys: %w", err)
 		}
-		dat, err := encrypt(data, aesKey)
+		dat, err := encrypt(data, keys.keys[keys.currentID])
 		if err != nil {
 			return fmt.Errorf("encrypt error: %w", err)
 		}
 		tbl.Records[i].DataEncrypted = dat
+		tbl.Records[i].KeyID = keys.currentID
 	}
 	return nil
 }
@@ -466,13 +501,14 @@ type pgURLSigKeyTable struct {
 }
 
 func (tbl *pgURLSigKeyTable) insertKeys(db *sql.DB) error {
-	queryBase := "INSERT INTO url_sig_key (deliveryservice, data) VALUES %s ON CONFLICT (deliveryservice) DO UPDATE set data = EXCLUDED.data"
-	stride := 2
+	queryBase := "INSERT INTO url_sig_key (deliveryservice, data, key_id) VALUES %s ON CONFLICT (deliveryservice) DO UPDATE set data = EXCLUDED.data, key_id = EXCLUDED.key_id"
+	stride := 3
 	queryArgs := make([]interface{}, len(tbl.Records)*stride)
 	for i, record := range tbl.Records {
 		j := i * stride
 		queryArgs[j] = record.DeliveryService
 		queryArgs[j+1] = record.DataEncrypted
+		queryArgs[j+2] = record.KeyID
 	}
 	return insertIntoTable(db, queryBase, stride, queryArgs)
 }
@@ -483,7 +519,7 @@ func (tbl *pgURLSigKeyTable) gatherKeys(db *sql.DB) error {
 	}
 	tbl.Records = make([]pgURLSigKeyRecord, sz)
 
-	query := "SELECT deliveryservice, data from url_sig_key"
+	query := "SELECT deliveryservice, data, key_id from url_sig_key"
 	rows, err := db.Query(query)
 	if err != nil {
 		return fmt.Errorf("PGURLSigKey gatherKeys error running query '%s': %w", query, err)
@@ -494,33 +530,34 @@ func (tbl *pgURLSigKeyTable) gatherKeys(db *sql.DB) error {
 		if i > len(tbl.Records)-1 {
 			return fmt.Errorf("PGURLSigKey gatherKeys: got more results than expected %d", len(tbl.Records))
 		}
-		if err := rows.Scan(&tbl.Records[i].DeliveryService, &tbl.Records[i].DataEncrypted); err != nil {
+		if err := rows.Scan(&tbl.Records[i].DeliveryService, &tbl.Records[i].DataEncrypted, &tbl.Records[i].KeyID); err != nil {
 			return fmt.Errorf("PGURLSigKey gatherKeys: unable to scan row: %w", err)
 		}
 		i += 1
 	}
 	return nil
 }
-func (tbl *pgURLSigKeyTable) decrypt(aesKey []byte) error {
+func (tbl *pgURLSigKeyTable) decrypt(keys pgKeyring) error {
 	for i, sig := range tbl.Records {
-		if err := decryptInto(aesKey, sig.DataEncrypted, &tbl.Records[i].Keys); err != nil {
+		if err := keys.decryptInto(sig.KeyID, sig.DataEncrypted, &tbl.Records[i].Keys); err != nil {
 			return fmt.Errorf("unable to decrypt into keys: %w", err)
 		}
 	}
 	return nil
 }
-func (tbl *pgURLSigKeyTable) encrypt(aesKey []byte) error {
+func (tbl *pgURLSigKeyTable) encrypt(keys pgKeyring) error {
 	for i, sig := range tbl.Records {
 		data, err := json.Marshal(&sig.Keys)
 		if err != nil {
 			return fmt.Errorf("encrypt issue marshalling keys: %w", err)
 		}
 
-		dat, err := encrypt(data, aesKey)
+		dat, err := encrypt(data, keys.keys[keys.currentID])
 		if err != nil {
 			return fmt.Errorf("encrypt error: %w", err)
 		}
 		tbl.Records[i].DataEncrypted = dat
+		tbl.Records[i].KeyID = keys.currentID
 	}
 	return nil
 }
@@ -567,13 +604,14 @@ type pgURISignKeyTable struct {
 }
 
 func (tbl *pgURISignKeyTable) insertKeys(db *sql.DB) error {
-	queryBase := "INSERT INTO uri_signing_key (deliveryservice, data) VALUES %s ON CONFLICT (deliveryservice) DO UPDATE SET data = EXCLUDED.data"
-	stride := 2
+	queryBase := "INSERT INTO uri_signing_key (deliveryservice, data, key_id) VALUES %s ON CONFLICT (deliveryservice) DO UPDATE SET data = EXCLUDED.data, key_id = EXCLUDED.key_id"
+	stride := 3
 	queryArgs := make([]interface{}, len(tbl.Records)*stride)
 	for i, record := range tbl.Records {
 		j := i * stride
 		queryArgs[j] = record.DeliveryService
 		queryArgs[j+1] = record.DataEncrypted
+		queryArgs[j+2] = record.KeyID
 	}
 	return insertIntoTable(db, queryBase, stride, queryArgs)
 }
@@ -584,7 +622,7 @@ func (tbl *pgURISignKeyTable) gatherKeys(db *sql.DB) error {
 	}
 	tbl.Records = make([]pgURISignKeyRecord, sz)
 
-	query := "SELECT deliveryservice, data from uri_signing_key"
+	query := "SELECT deliveryservice, data, key_id from uri_signing_key"
 	rows, err := db.Query(query)
 	if err != nil {
 		return fmt.Errorf("PGURISignKey gatherKeys error while running query '%s': %w", query, err)
@@ -595,33 +633,34 @@ func (tbl *pgURISignKeyTable) gatherKeys(db *sql.DB) error {
 		if i > len(tbl.Records)-1 {
 			return fmt.Errorf("PGURISignKey gatherKeys: got more results than expected %d", len(tbl.Records))
 		}
-		if err := rows.Scan(&tbl.Records[i].DeliveryService, &tbl.Records[i].DataEncrypted); err != nil {
+		if err := rows.Scan(&tbl.Records[i].DeliveryService, &tbl.Records[i].DataEncrypted, &tbl.Records[i].KeyID); err != nil {
 			return fmt.Errorf("PGURISignKey gatherKeys: unable to scan row: %w", err)
 		}
 		i += 1
 	}
 	return nil
 }
-func (tbl *pgURISignKeyTable) decrypt(aesKey []byte) error {
+func (tbl *pgURISignKeyTable) decrypt(keys pgKeyring) error {
 	for i, sign := range tbl.Records {
-		if err := decryptInto(aesKey, sign.DataEncrypted, &tbl.Records[i].Keys); err != nil {
+		if err := keys.decryptInto(sign.KeyID, sign.DataEncrypted, &tbl.Records[i].Keys); err != nil {
 			return fmt.Errorf("unable to decrypt into keys: %w", err)
 		}
 	}
 	return nil
 }
-func (tbl *pgURISignKeyTable) encrypt(aesKey []byte) error {
+func (tbl *pgURISignKeyTable) encrypt(keys pgKeyring) error {
 	for i, sign := range tbl.Records {
 		data, err := json.Marshal(sign.Keys)
 		if err != nil {
 			return fmt.Errorf("encrypt issue marshalling keys: %w", err)
 		}
 
-		dat, err := encrypt(data, aesKey)
+		dat, err := encrypt(data, keys.keys[keys.currentID])
 		if err != nil {
 			return fmt.Errorf("encrypt error: %w", err)
 		}
 		tbl.Records[i].DataEncrypted = dat
+		tbl.Records[i].KeyID = keys.currentID
 	}
 	return nil
 }
@@ -692,14 +731,47 @@ func decryptInto(aesKey []byte, encData []byte, value interface{}) error {
 	if err != nil {
 		return err
 	}
+	return unmarshalDecrypted(data, value)
+}
+func unmarshalDecrypted(data []byte, value interface{}) error {
 	if len(data) == 0 {
 		return errors.New("decrypted data is empty")
 	}
-	if err = json.Unmarshal(data, &value); err != nil {
+	if err := json.Unmarshal(data, &value); err != nil {
 		return err
 	}
 	return nil
 }
+
+// pgKeyring holds the keys of the Traffic Ops keyring: rows are encrypted with the current key and tagged with
+// its ID, and may be decrypted with any key.
+type pgKeyring struct {
+	currentID string
+	keys      map[string][]byte
+}
+
+// decrypt decrypts the given record, which was encrypted with the key with the given ID. If that key is not
+// known, every key is tried in turn.
+func (k pgKeyring) decrypt(record []byte, keyID string) ([]byte, error) {
+	if key, ok := k.keys[keyID]; ok {
+		return decrypt(record, key)
+	}
+	for _, key := range k.keys {
+		if unencrypted, err := decrypt(record, key); err == nil {
+			return unencrypted, nil
+		}
+	}
+	return nil, fmt.Errorf("unable to decrypt: no key could decrypt data encrypted with key ID '%s'", keyID)
+}
+
+// decryptInto decrypts the given record, which was encrypted with the key with the given ID, into value.
+func (k pgKeyring) decryptInto(keyID string, encData []byte, value interface{}) error {
+	data, err := k.decrypt(encData, keyID)
+	if err != nil {
+		return err
+	}
+	return unmarshalDecrypted(data, value)
+}
 func insertIntoTable(db *sql.DB, queryBase string, stride int, queryArgs []interface{}) error {
 	rows := len(queryArgs) / stride
 	workStr := ""
@@ -58,6 +58,7 @@ var (
 	}
 	riakBE RiakBackend = RiakBackend{}
 	pgBE   PGBackend   = PGBackend{}
+	fsBE   FSBackend   = FSBackend{}
 )
 
 func init() {
@@ -119,7 +120,7 @@ func init() {
 // supportBackends returns the backends available in this tool.
 func supportedBackends() []TVBackend {
 	return []TVBackend{
-		&riakBE, &pgBE,
+		&riakBE, &pgBE, &fsBE,
 	}
 }
 
@@ -20,6 +20,7 @@ package main
  */
 
 import (
+	"encoding/json"
 	"github.com/apache/trafficcontrol/lib/go-tc"
 	"github.com/apache/trafficcontrol/lib/go-util"
 	"github.com/lestrrat/go-jwx/jwk"
@@ -196,3 +197,95 @@ func TestPGBackend(t *testing.T) {
 	}
 	testBackend(t, &pg)
 }
+
+func TestPGBackendKeyring(t *testing.T) {
+	previousKey := []byte("0123456789abcdef0123456789abcdef")
+	currentKey := []byte("fedcba9876543210fedcba9876543210")
+	urlKeys := tc.URLSigKeys{"key0": "abc"}
+	data, err := json.Marshal(urlKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := util.AESEncrypt(data, previousKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pg := PGBackend{
+		cfg: PGConfig{
+			KeyID:           "current",
+			AESKey:          currentKey,
+			PreviousAESKeys: map[string][]byte{"previous": previousKey},
+		},
+	}
+	pg.urlSigKeys.Records = []pgURLSigKeyRecord{{DeliveryService: "ds1", pgCommonRecord: pgCommonRecord{DataEncrypted: encrypted, KeyID: "previous"}}}
+	keys, err := pg.GetURLSigKeys()
+	if err != nil {
+		t.Fatalf("expected a row encrypted with a previous key to be decrypted, got: %v", err)
+	}
+	if !reflect.DeepEqual(keys, []URLSigKey{{DeliveryService: "ds1", URLSigKeys: urlKeys}}) {
+		t.Fatalf("expected url sig keys %+v, got %+v", urlKeys, keys)
+	}
+
+	if err := pg.SetURLSigKeys(keys); err != nil {
+		t.Fatal(err)
+	}
+	record := pg.urlSigKeys.Records[0]
+	if record.KeyID != "current" {
+		t.Errorf("expected the row to be tagged with key ID 'current', got '%s'", record.KeyID)
+	}
+	if _, err := util.AESDecrypt(record.DataEncrypted, currentKey); err != nil {
+		t.Errorf("expected the row to be encrypted with the current key, got: %v", err)
+	}
+}
+
+func TestFSBackend(t *testing.T) {
+	cfg := FSConfig{
+		Directory: t.TempDir(),
+		MasterKey: []byte("0123456789abcdef0123456789abcdef"),
+	}
+	sslKey := SSLKey{
+		DeliveryServiceSSLKeys: tc.DeliveryServiceSSLKeys{CDN: "cdn1", DeliveryService: "ds1", Key: "ds1", Version: 1},
+		Version:                "1",
+	}
+	dnssec := DNSSecKey{CDN: "cdn1", DNSSECKeysTrafficVault: tc.DNSSECKeysTrafficVault{"cdn1": tc.DNSSECKeySetV11{}}}
+	url := URLSigKey{DeliveryService: "ds1", URLSigKeys: tc.URLSigKeys{"key0": "abc"}}
+
+	fs := FSBackend{cfg: cfg}
+	if err := fs.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetKeys(&fs, Secrets{sslkeys: []SSLKey{sslKey}, dnssecKeys: []DNSSecKey{dnssec}, urlKeys: []URLSigKey{url}}); err != nil {
+		t.Fatal(err)
+	}
+	if errs := fs.ValidateKey(); len(errs) > 0 {
+		t.Fatalf("expected no validation issues with filled struct got: %v\n", strings.Join(errs, ", "))
+	}
+	if err := fs.Insert(); err != nil {
+		t.Fatal(err)
+	}
+
+	fetched := FSBackend{cfg: cfg}
+	if err := fetched.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := fetched.Fetch(); err != nil {
+		t.Fatal(err)
+	}
+	secrets, err := GetKeys(&fetched)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(secrets.sslkeys, []SSLKey{sslKey}) {
+		t.Fatalf("expected ssl keys %+v, got %+v", []SSLKey{sslKey}, secrets.sslkeys)
+	}
+	if !reflect.DeepEqual(secrets.dnssecKeys, []DNSSecKey{dnssec}) {
+		t.Fatalf("expected dnssec keys %+v, got %+v", []DNSSecKey{dnssec}, secrets.dnssecKeys)
+	}
+	if !reflect.DeepEqual(secrets.urlKeys, []URLSigKey{url}) {
+		t.Fatalf("expected url keys %+v, got %+v", []URLSigKey{url}, secrets.urlKeys)
+	}
+	if len(secrets.uriKeys) != 0 {
+		t.Fatalf("expected no uri keys, got %d", len(secrets.uriKeys))
+	}
+}
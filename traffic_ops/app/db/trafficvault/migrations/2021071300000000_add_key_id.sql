@@ -0,0 +1,28 @@
+/*
+
+    Licensed under the Apache License, Version 2.0 (the "License");
+    you may not use this file except in compliance with the License.
+    You may obtain a copy of the License at
+
+        http://www.apache.org/licenses/LICENSE-2.0
+
+    Unless required by applicable law or agreed to in writing, software
+    distributed under the License is distributed on an "AS IS" BASIS,
+    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+    See the License for the specific language governing permissions and
+    limitations under the License.
+*/
+
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+ALTER TABLE dnssec ADD COLUMN IF NOT EXISTS key_id text NOT NULL DEFAULT '';
+ALTER TABLE sslkey ADD COLUMN IF NOT EXISTS key_id text NOT NULL DEFAULT '';
+ALTER TABLE uri_signing_key ADD COLUMN IF NOT EXISTS key_id text NOT NULL DEFAULT '';
+ALTER TABLE url_sig_key ADD COLUMN IF NOT EXISTS key_id text NOT NULL DEFAULT '';
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+ALTER TABLE dnssec DROP COLUMN IF EXISTS key_id;
+ALTER TABLE sslkey DROP COLUMN IF EXISTS key_id;
+ALTER TABLE uri_signing_key DROP COLUMN IF EXISTS key_id;
+ALTER TABLE url_sig_key DROP COLUMN IF EXISTS key_id;
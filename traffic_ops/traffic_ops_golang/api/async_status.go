@@ -23,8 +23,11 @@ import (
 	"database/sql"
 	"errors"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/apache/trafficcontrol/lib/go-tc"
+
 	"github.com/jmoiron/sqlx"
 )
 
@@ -32,6 +35,7 @@ const (
 	AsyncSucceeded = "SUCCEEDED"
 	AsyncFailed    = "FAILED"
 	AsyncPending   = "PENDING"
+	AsyncAborted   = "ABORTED"
 )
 
 const CurrentAsyncEndpoint = "/api/4.0/async_status/"
@@ -46,8 +50,11 @@ type AsyncStatus struct {
 
 const selectAsyncStatusQuery = `SELECT id, status, message, start_time, end_time from async_status WHERE id = $1`
 const insertAsyncStatusQuery = `INSERT INTO async_status (status, message) VALUES ($1, $2) RETURNING id`
-const updateAsyncStatusEndTimeQuery = `UPDATE async_status SET status = $1, message = $2, end_time = now() WHERE id = $3`
-const updateAsyncStatusQuery = `UPDATE async_status SET status = $1, message = $2 WHERE id = $3`
+const insertAbortableAsyncStatusQuery = `INSERT INTO async_status (status, message, abortable) VALUES ($1, $2, TRUE) RETURNING id`
+const abortAsyncStatusQuery = `UPDATE async_status SET status = $1, message = $2, end_time = now() WHERE id = $3 AND status = $4 AND abortable RETURNING id`
+const selectAsyncStatusStatusQuery = `SELECT status from async_status WHERE id = $1`
+const updateAsyncStatusEndTimeQuery = `UPDATE async_status SET status = $1, message = $2, end_time = now() WHERE id = $3 AND status <> '` + AsyncAborted + `'`
+const updateAsyncStatusQuery = `UPDATE async_status SET status = $1, message = $2 WHERE id = $3 AND status <> '` + AsyncAborted + `'`
 
 // GetAsyncStatus returns the status of an asynchronous job.
 func GetAsyncStatus(w http.ResponseWriter, r *http.Request) {
@@ -88,9 +95,27 @@ func GetAsyncStatus(w http.ResponseWriter, r *http.Request) {
 
 // InsertAsyncStatus inserts a new status for an asynchronous job.
 func InsertAsyncStatus(tx *sql.Tx, message string) (int, int, error, error) {
+	return insertAsyncStatus(tx, insertAsyncStatusQuery, message)
+}
+
+// InsertAbortableAsyncStatus inserts a new status for an asynchronous job which may be aborted through the async_status API.
+// The job must check IsAsyncStatusAborted, and stop if it has been aborted.
+func InsertAbortableAsyncStatus(tx *sql.Tx, message string) (int, int, error, error) {
+	return insertAsyncStatus(tx, insertAbortableAsyncStatusQuery, message)
+}
+
+// InsertAbortableAsyncStatusTx is InsertAbortableAsyncStatus, but doesn't commit the transaction, so the job can be stored in the same transaction as its status.
+func InsertAbortableAsyncStatusTx(tx *sql.Tx, message string) (int, int, error, error) {
+	return insertAsyncStatusTx(tx, insertAbortableAsyncStatusQuery, message)
+}
+
+func insertAsyncStatus(tx *sql.Tx, query string, message string) (int, int, error, error) {
 	defer tx.Commit()
+	return insertAsyncStatusTx(tx, query, message)
+}
 
-	resultRows, err := tx.Query(insertAsyncStatusQuery, AsyncPending, message)
+func insertAsyncStatusTx(tx *sql.Tx, query string, message string) (int, int, error, error) {
+	resultRows, err := tx.Query(query, AsyncPending, message)
 	if err != nil {
 		userErr, sysErr, errCode := ParseDBError(err)
 		return 0, errCode, userErr, sysErr
@@ -116,6 +141,7 @@ func InsertAsyncStatus(tx *sql.Tx, message string) (int, int, error, error) {
 }
 
 // UpdateAsyncStatus updates the status table for an asynchronous job.
+// Jobs which have been aborted are not updated.
 func UpdateAsyncStatus(db *sqlx.DB, newStatus string, newMessage string, asyncStatusId int, finished bool) error {
 	if asyncStatusId == 0 {
 		return nil
@@ -137,3 +163,45 @@ func UpdateAsyncStatus(db *sqlx.DB, newStatus string, newMessage string, asyncSt
 
 	return nil
 }
+
+// AbortAsyncStatus aborts a pending, abortable asynchronous job.
+func AbortAsyncStatus(w http.ResponseWriter, r *http.Request) {
+	inf, userErr, sysErr, errCode := NewInfo(r, []string{"id"}, []string{"id"})
+	if userErr != nil || sysErr != nil {
+		HandleErr(w, r, inf.Tx.Tx, errCode, userErr, sysErr)
+		return
+	}
+	defer inf.Close()
+
+	asyncStatusId := inf.IntParams["id"]
+
+	status := ""
+	if err := inf.Tx.Tx.QueryRow(selectAsyncStatusStatusQuery, asyncStatusId).Scan(&status); err == sql.ErrNoRows {
+		HandleErr(w, r, inf.Tx.Tx, http.StatusNotFound, errors.New("async status not found"), nil)
+		return
+	} else if err != nil {
+		HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("getting async status: "+err.Error()))
+		return
+	}
+
+	id := 0
+	if err := inf.Tx.Tx.QueryRow(abortAsyncStatusQuery, AsyncAborted, "Aborted by user "+inf.User.UserName+".", asyncStatusId, AsyncPending).Scan(&id); err == sql.ErrNoRows {
+		HandleErr(w, r, inf.Tx.Tx, http.StatusConflict, errors.New("async job is "+status+", and can only be aborted if it is "+AsyncPending+" and abortable"), nil)
+		return
+	} else if err != nil {
+		HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("aborting async status: "+err.Error()))
+		return
+	}
+
+	CreateChangeLogRawTx(ApiChange, "Aborted async job "+strconv.Itoa(asyncStatusId), inf.User, inf.Tx.Tx)
+	WriteRespAlert(w, r, tc.SuccessLevel, "Async job "+strconv.Itoa(asyncStatusId)+" aborted.")
+}
+
+// IsAsyncStatusAborted returns whether the asynchronous job has been aborted through the async_status API.
+func IsAsyncStatusAborted(db *sqlx.DB, asyncStatusId int) (bool, error) {
+	status := ""
+	if err := db.QueryRow(selectAsyncStatusStatusQuery, asyncStatusId).Scan(&status); err != nil {
+		return false, err
+	}
+	return status == AsyncAborted, nil
+}
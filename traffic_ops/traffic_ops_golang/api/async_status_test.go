@@ -112,3 +112,29 @@ func TestUpdateAsyncStatusFinished(t *testing.T) {
 		return
 	}
 }
+
+func TestIsAsyncStatusAborted(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		return
+	}
+	defer mockDB.Close()
+
+	db := sqlx.NewDb(mockDB, "sqlmock")
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT status").WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(AsyncAborted))
+	mock.ExpectQuery("SELECT status").WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(AsyncPending))
+
+	if aborted, err := IsAsyncStatusAborted(db, 1); err != nil {
+		t.Fatalf("IsAsyncStatusAborted expected nil error, actual: %v", err)
+	} else if !aborted {
+		t.Errorf("IsAsyncStatusAborted expected true for an %s job, actual: false", AsyncAborted)
+	}
+	if aborted, err := IsAsyncStatusAborted(db, 2); err != nil {
+		t.Fatalf("IsAsyncStatusAborted expected nil error, actual: %v", err)
+	} else if aborted {
+		t.Errorf("IsAsyncStatusAborted expected false for a %s job, actual: true", AsyncPending)
+	}
+}
@@ -20,15 +20,22 @@ package cdnnotification
  */
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/apache/trafficcontrol/lib/go-log"
 	"github.com/apache/trafficcontrol/lib/go-tc"
 	"github.com/apache/trafficcontrol/lib/go-util"
 	"github.com/apache/trafficcontrol/traffic_ops/traffic_ops_golang/api"
 	"github.com/apache/trafficcontrol/traffic_ops/traffic_ops_golang/dbhelpers"
+	"github.com/apache/trafficcontrol/traffic_ops/traffic_ops_golang/deliveryservice"
 )
 
 const readQuery = `
@@ -110,9 +117,128 @@ func Read(w http.ResponseWriter, r *http.Request) {
 		cdnNotifications = append(cdnNotifications, n)
 	}
 
+	if includeCertExpirationNotifications(inf) {
+		certNotifications, err := certExpirationNotifications(inf, r.Context())
+		if err != nil {
+			log.Errorln("getting certificate expiration notifications: " + err.Error())
+		} else {
+			cdnNotifications = append(cdnNotifications, certNotifications...)
+		}
+	}
+
 	api.WriteResp(w, r, cdnNotifications)
 }
 
+// includeCertExpirationNotifications returns whether notifications about expiring Delivery Service
+// certificates should be generated for the given GET request. They are only generated when enabled in the
+// configuration, and never when the request selects specific stored notifications or pages through them.
+func includeCertExpirationNotifications(inf *api.APIInfo) bool {
+	if !inf.Config.TrafficVaultEnabled || inf.Config.CertExpirationNotificationDays <= 0 {
+		return false
+	}
+	for _, param := range []string{"id", "user", "limit", "offset", "page"} {
+		if _, ok := inf.Params[param]; ok {
+			return false
+		}
+	}
+	return true
+}
+
+// certExpirationCacheTTL is how long the certificate expirations read for CDN notifications are reused. Reading
+// them reads every Delivery Service's certificate from Traffic Vault, which is too slow to do on every request.
+const certExpirationCacheTTL = 5 * time.Minute
+
+// certExpirationKey identifies the certificate expirations read for a tenant, CDN and number of days, because
+// the Delivery Services read depend on all three.
+type certExpirationKey struct {
+	tenantID int
+	cdn      string
+	days     int
+}
+
+// certExpirationEntry is the cached certificate expirations of a certExpirationKey. Its mutex is held while
+// they're read, so concurrent requests for the same key wait for one read instead of each reading them.
+type certExpirationEntry struct {
+	m           sync.Mutex
+	expirations []tc.SSLKeyExpirationInformation
+	read        time.Time
+}
+
+var certExpirationCache = struct {
+	m       sync.Mutex
+	entries map[certExpirationKey]*certExpirationEntry
+}{entries: map[certExpirationKey]*certExpirationEntry{}}
+
+// getCertExpirations returns the cached certificate expirations of the key, calling read to read them if they
+// weren't read within the certExpirationCacheTTL before now. Errors aren't cached.
+func getCertExpirations(key certExpirationKey, now time.Time, read func() ([]tc.SSLKeyExpirationInformation, error)) ([]tc.SSLKeyExpirationInformation, error) {
+	certExpirationCache.m.Lock()
+	entry, ok := certExpirationCache.entries[key]
+	if !ok {
+		for otherKey, other := range certExpirationCache.entries {
+			if now.Sub(other.read) > certExpirationCacheTTL {
+				delete(certExpirationCache.entries, otherKey)
+			}
+		}
+		entry = &certExpirationEntry{}
+		certExpirationCache.entries[key] = entry
+	}
+	certExpirationCache.m.Unlock()
+
+	entry.m.Lock()
+	defer entry.m.Unlock()
+	if !entry.read.IsZero() && now.Sub(entry.read) <= certExpirationCacheTTL {
+		return entry.expirations, nil
+	}
+	expirations, err := read()
+	if err != nil {
+		return nil, err
+	}
+	entry.expirations = expirations
+	entry.read = now
+	return expirations, nil
+}
+
+// certExpirationNotifications generates one notification per CDN listing the Delivery Services whose
+// certificates expire within the configured number of days. These notifications aren't stored, so they have
+// no ID or user, and disappear once the certificates are renewed, within the certExpirationCacheTTL.
+func certExpirationNotifications(inf *api.APIInfo, ctx context.Context) ([]tc.CDNNotification, error) {
+	days := inf.Config.CertExpirationNotificationDays
+	key := certExpirationKey{tenantID: inf.User.TenantID, cdn: inf.Params["cdn"], days: days}
+	expirations, err := getCertExpirations(key, time.Now(), func() ([]tc.SSLKeyExpirationInformation, error) {
+		expirations, _, err := deliveryservice.ReadSSLKeyExpirations(inf.Tx.Tx, inf.Vault, inf.Config, inf.User.TenantID, inf.Params["cdn"], &days, ctx)
+		return expirations, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cdns := []string{}
+	expiringByCDN := map[string][]string{}
+	now := time.Now()
+	for _, exp := range expirations {
+		if _, ok := expiringByCDN[exp.CDN]; !ok {
+			cdns = append(cdns, exp.CDN)
+		}
+		verb := "expires"
+		if exp.Expiration.Before(now) {
+			verb = "expired"
+		}
+		expiringByCDN[exp.CDN] = append(expiringByCDN[exp.CDN], fmt.Sprintf("%s (%s %s)", exp.DeliveryService, verb, exp.Expiration.UTC().Format("2006-01-02")))
+	}
+	sort.Strings(cdns)
+
+	notifications := make([]tc.CDNNotification, 0, len(cdns))
+	for _, cdn := range cdns {
+		notifications = append(notifications, tc.CDNNotification{
+			CDN:          cdn,
+			LastUpdated:  now,
+			Notification: fmt.Sprintf("Delivery Service certificates expiring within %d days: %s", days, strings.Join(expiringByCDN[cdn], ", ")),
+		})
+	}
+	return notifications, nil
+}
+
 // Create is the handler for POST requests to /cdn_notifications.
 func Create(w http.ResponseWriter, r *http.Request) {
 	inf, sysErr, userErr, errCode := api.NewInfo(r, nil, nil)
@@ -0,0 +1,73 @@
+package cdnnotification
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+)
+
+func TestGetCertExpirations(t *testing.T) {
+	reads := 0
+	read := func() ([]tc.SSLKeyExpirationInformation, error) {
+		reads++
+		return []tc.SSLKeyExpirationInformation{{DeliveryService: "ds1", CDN: "cdn1"}}, nil
+	}
+	key := certExpirationKey{tenantID: 1, cdn: "cdn1", days: 30}
+	now := time.Now()
+
+	for _, at := range []time.Time{now, now.Add(certExpirationCacheTTL)} {
+		expirations, err := getCertExpirations(key, at, read)
+		if err != nil {
+			t.Fatalf("getCertExpirations expected nil error, actual: %v", err)
+		}
+		if len(expirations) != 1 || expirations[0].DeliveryService != "ds1" {
+			t.Errorf("getCertExpirations expected the expiration of ds1, actual: %+v", expirations)
+		}
+	}
+	if reads != 1 {
+		t.Errorf("getCertExpirations within the cache TTL expected 1 read, actual: %d", reads)
+	}
+
+	if _, err := getCertExpirations(certExpirationKey{tenantID: 2, cdn: "cdn1", days: 30}, now, read); err != nil {
+		t.Fatalf("getCertExpirations expected nil error, actual: %v", err)
+	}
+	if reads != 2 {
+		t.Errorf("getCertExpirations of another tenant expected its own read, actual reads: %d", reads)
+	}
+
+	if _, err := getCertExpirations(key, now.Add(certExpirationCacheTTL+time.Second), read); err != nil {
+		t.Fatalf("getCertExpirations expected nil error, actual: %v", err)
+	}
+	if reads != 3 {
+		t.Errorf("getCertExpirations after the cache TTL expected another read, actual reads: %d", reads)
+	}
+
+	failKey := certExpirationKey{tenantID: 3, cdn: "cdn1", days: 30}
+	if _, err := getCertExpirations(failKey, now, func() ([]tc.SSLKeyExpirationInformation, error) { return nil, errors.New("vault down") }); err == nil {
+		t.Error("getCertExpirations with a failed read expected error, actual: nil")
+	}
+	if _, err := getCertExpirations(failKey, now, read); err != nil || reads != 4 {
+		t.Errorf("getCertExpirations after a failed read expected to read again, actual error: %v, reads: %d", err, reads)
+	}
+}
@@ -106,6 +106,12 @@ type ConfigTrafficOpsGolang struct {
 	TLSConfig            *tls.Config     `json:"tls_config"`
 	TrafficVaultBackend  string          `json:"traffic_vault_backend"`
 	TrafficVaultConfig   json.RawMessage `json:"traffic_vault_config"`
+	// TrafficVaultReEncryptIntervalSeconds is how often Traffic Vault data that is not encrypted with the
+	// current key is re-encrypted in the background. If 0, data is only re-encrypted on request.
+	TrafficVaultReEncryptIntervalSeconds int `json:"traffic_vault_reencrypt_interval_seconds"`
+	// CertExpirationNotificationDays is the number of days before their expiration that Delivery Service
+	// certificates are reported in CDN notifications. If 0, expiring certificates are not reported.
+	CertExpirationNotificationDays int `json:"cert_expiration_notification_days"`
 
 	// CRConfigUseRequestHost is whether to use the client request host header in the CRConfig. If false, uses the tm.url parameter.
 	// This defaults to false. Traffic Ops used to always use the host header, setting this true will resume that legacy behavior.
@@ -0,0 +1,165 @@
+package deliveryservice
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-log"
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_ops/traffic_ops_golang/api"
+	"github.com/apache/trafficcontrol/traffic_ops/traffic_ops_golang/config"
+	"github.com/apache/trafficcontrol/traffic_ops/traffic_ops_golang/tenant"
+	"github.com/apache/trafficcontrol/traffic_ops/traffic_ops_golang/trafficvault"
+
+	"github.com/lib/pq"
+)
+
+const sslKeyDeliveryServicesQuery = `
+SELECT ds.xml_id, cdn.name
+FROM deliveryservice AS ds
+JOIN cdn ON cdn.id = ds.cdn_id
+WHERE ds.ssl_key_version IS NOT NULL
+AND ds.ssl_key_version != 0
+AND ds.tenant_id = ANY($1)
+AND ($2 = '' OR cdn.name = $2)
+ORDER BY ds.xml_id
+`
+
+// GetSSLKeyExpirations is the handler for GET requests to /sslkey_expirations.
+func GetSSLKeyExpirations(w http.ResponseWriter, r *http.Request) {
+	inf, userErr, sysErr, errCode := api.NewInfo(r, nil, []string{"days"})
+	if userErr != nil || sysErr != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, errCode, userErr, sysErr)
+		return
+	}
+	defer inf.Close()
+
+	if !inf.Config.TrafficVaultEnabled {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, errors.New("the Traffic Vault service is unavailable"), errors.New("getting SSL key expirations: Traffic Vault is not configured"))
+		return
+	}
+
+	var days *int
+	if d, ok := inf.IntParams["days"]; ok {
+		if d < 0 {
+			api.HandleErr(w, r, inf.Tx.Tx, http.StatusBadRequest, errors.New("days must not be negative"), nil)
+			return
+		}
+		days = &d
+	}
+
+	expirations, expErrs, err := ReadSSLKeyExpirations(inf.Tx.Tx, inf.Vault, inf.Config, inf.User.TenantID, inf.Params["cdn"], days, r.Context())
+	if err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("getting SSL key expirations: "+err.Error()))
+		return
+	}
+	if len(expErrs) == 0 {
+		api.WriteResp(w, r, expirations)
+		return
+	}
+	alerts := tc.Alerts{}
+	for _, expErr := range expErrs {
+		alerts.AddNewAlert(tc.WarnLevel, expErr.Error())
+	}
+	api.WriteAlertsObj(w, r, http.StatusOK, alerts, expirations)
+}
+
+// ReadSSLKeyExpirations returns the expiration information of the latest SSL certificates of the Delivery
+// Services in the tenants accessible to the given tenant, sorted by expiration. If cdn is not empty, only
+// Delivery Services in that CDN are included. If days is not nil, only the certificates that expire within
+// that many days (including those that have already expired) are included.
+//
+// Certificates that cannot be read or parsed don't fail the whole operation; instead, they are logged and
+// returned as user-facing errors.
+func ReadSSLKeyExpirations(tx *sql.Tx, tv trafficvault.TrafficVault, cfg *config.Config, userTenantID int, cdn string, days *int, ctx context.Context) ([]tc.SSLKeyExpirationInformation, []error, error) {
+	tenantIDs, err := tenant.GetUserTenantIDListTx(tx, userTenantID)
+	if err != nil {
+		return nil, nil, errors.New("getting user tenant ID list: " + err.Error())
+	}
+	rows, err := tx.Query(sslKeyDeliveryServicesQuery, pq.Array(tenantIDs), cdn)
+	if err != nil {
+		return nil, nil, errors.New("querying delivery services with SSL keys: " + err.Error())
+	}
+	type dsCDN struct {
+		xmlID string
+		cdn   string
+	}
+	dses := []dsCDN{}
+	for rows.Next() {
+		ds := dsCDN{}
+		if err := rows.Scan(&ds.xmlID, &ds.cdn); err != nil {
+			rows.Close()
+			return nil, nil, errors.New("scanning delivery services with SSL keys: " + err.Error())
+		}
+		dses = append(dses, ds)
+	}
+	rows.Close()
+
+	expirations := []tc.SSLKeyExpirationInformation{}
+	errs := []error{}
+	for _, ds := range dses {
+		keys, ok, err := tv.GetDeliveryServiceSSLKeys(ds.xmlID, "", tx, ctx)
+		if err != nil {
+			return nil, nil, errors.New("getting SSL keys for delivery service '" + ds.xmlID + "' from Traffic Vault: " + err.Error())
+		}
+		if !ok {
+			log.Warnf("SSL key expirations: delivery service '%s' has an SSL key version but no SSL keys in Traffic Vault", ds.xmlID)
+			errs = append(errs, errors.New("no SSL keys found for delivery service '"+ds.xmlID+"'"))
+			continue
+		}
+		version, err := makeSSLKeysVersion(keys)
+		if err != nil {
+			log.Warnf("SSL key expirations: parsing the certificate of delivery service '%s': %s", ds.xmlID, err.Error())
+			errs = append(errs, errors.New("the certificate of delivery service '"+ds.xmlID+"' could not be parsed"))
+			continue
+		}
+		if days != nil && version.Expiration.After(time.Now().AddDate(0, 0, *days)) {
+			continue
+		}
+		expirations = append(expirations, tc.SSLKeyExpirationInformation{
+			DeliveryService: ds.xmlID,
+			CDN:             ds.cdn,
+			Version:         version.Version,
+			AuthType:        version.AuthType,
+			SANs:            version.SANs,
+			Issuer:          version.Issuer,
+			Expiration:      version.Expiration,
+			AutoRenewable:   isAutoRenewable(cfg, version.AuthType),
+		})
+	}
+	sort.SliceStable(expirations, func(i, j int) bool {
+		return expirations[i].Expiration.Before(expirations[j].Expiration)
+	})
+	return expirations, errs, nil
+}
+
+// isAutoRenewable returns whether certificates of the given auth type are renewed by RunAutorenewal.
+func isAutoRenewable(cfg *config.Config, authType string) bool {
+	if authType == tc.SelfSignedCertAuthType {
+		return cfg.ConfigLetsEncrypt.ConvertSelfSigned
+	}
+	return GetAcmeAccountConfig(cfg, authType) != nil
+}
@@ -0,0 +1,140 @@
+package deliveryservice
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"testing"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_ops/traffic_ops_golang/config"
+	"github.com/apache/trafficcontrol/traffic_ops/traffic_ops_golang/trafficvault"
+
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+// sslKeysVault is a Traffic Vault that only implements GetDeliveryServiceSSLKeys.
+type sslKeysVault struct {
+	trafficvault.TrafficVault
+	keys map[string]tc.DeliveryServiceSSLKeysV15
+}
+
+func (v sslKeysVault) GetDeliveryServiceSSLKeys(xmlID string, version string, tx *sql.Tx, ctx context.Context) (tc.DeliveryServiceSSLKeysV15, bool, error) {
+	keys, ok := v.keys[xmlID]
+	return keys, ok, nil
+}
+
+func makeTestSSLKeys(xmlID string, authType string, crt string) tc.DeliveryServiceSSLKeysV15 {
+	return tc.DeliveryServiceSSLKeysV15{
+		DeliveryServiceSSLKeys: tc.DeliveryServiceSSLKeys{
+			AuthType:        authType,
+			DeliveryService: xmlID,
+			Version:         1,
+			Certificate: tc.DeliveryServiceSSLKeysCertificate{
+				Crt: base64.StdEncoding.EncodeToString([]byte(crt)),
+			},
+		},
+	}
+}
+
+func TestReadSSLKeyExpirations(t *testing.T) {
+	tv := sslKeysVault{keys: map[string]tc.DeliveryServiceSSLKeysV15{
+		"ds-ecdsa": makeTestSSLKeys("ds-ecdsa", tc.SelfSignedCertAuthType, SelfSignedECDSACertificate),
+		"ds-rsa":   makeTestSSLKeys("ds-rsa", tc.LetsEncryptAuthType, SelfSignedRSACertificate),
+		"ds-bad":   makeTestSSLKeys("ds-bad", tc.SelfSignedCertAuthType, BadCertData),
+	}}
+	cfg := &config.Config{}
+
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+
+	expectQueries := func() {
+		mock.ExpectQuery("WITH RECURSIVE").WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		rows := sqlmock.NewRows([]string{"xml_id", "name"})
+		for _, xmlID := range []string{"ds-bad", "ds-ecdsa", "ds-missing", "ds-rsa"} {
+			rows = rows.AddRow(xmlID, "cdn1")
+		}
+		mock.ExpectQuery("SELECT ds.xml_id, cdn.name").WillReturnRows(rows)
+	}
+	mock.ExpectBegin()
+	expectQueries()
+	expectQueries()
+	tx, err := mockDB.Begin()
+	if err != nil {
+		t.Fatalf("beginning transaction: %s", err.Error())
+	}
+
+	expirations, errs, err := ReadSSLKeyExpirations(tx, tv, cfg, 1, "", nil, context.Background())
+	if err != nil {
+		t.Fatalf("reading SSL key expirations - expected: nil error, actual: %v", err)
+	}
+	if len(errs) != 2 {
+		t.Errorf("reading SSL key expirations - expected: 2 errors for the missing and bad certificates, actual: %v", errs)
+	}
+	if len(expirations) != 2 {
+		t.Fatalf("reading SSL key expirations - expected: 2 expirations, actual: %+v", expirations)
+	}
+	if expirations[0].DeliveryService != "ds-rsa" || expirations[1].DeliveryService != "ds-ecdsa" {
+		t.Errorf("reading SSL key expirations - expected: sorted by expiration, actual: %+v", expirations)
+	}
+	if !expirations[0].AutoRenewable || expirations[1].AutoRenewable {
+		t.Errorf("reading SSL key expirations - expected: only the Let's Encrypt certificate to be auto-renewable, actual: %+v", expirations)
+	}
+	if expirations[1].CDN != "cdn1" || expirations[1].Issuer == "" || len(expirations[1].SANs) == 0 {
+		t.Errorf("reading SSL key expirations - expected: certificate information, actual: %+v", expirations[1])
+	}
+
+	days := 30
+	expirations, _, err = ReadSSLKeyExpirations(tx, tv, cfg, 1, "", &days, context.Background())
+	if err != nil {
+		t.Fatalf("reading SSL key expirations within %d days - expected: nil error, actual: %v", days, err)
+	}
+	if len(expirations) != 0 {
+		t.Errorf("reading SSL key expirations within %d days - expected: none, actual: %+v", days, expirations)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("expectations were not met: %s", err.Error())
+	}
+}
+
+func TestIsAutoRenewable(t *testing.T) {
+	cfg := &config.Config{AcmeAccounts: []config.ConfigAcmeAccount{{AcmeProvider: "acme-provider"}}}
+	if !isAutoRenewable(cfg, tc.LetsEncryptAuthType) {
+		t.Error("expected Let's Encrypt certificates to be auto-renewable")
+	}
+	if !isAutoRenewable(cfg, "acme-provider") {
+		t.Error("expected certificates of a configured ACME provider to be auto-renewable")
+	}
+	if isAutoRenewable(cfg, tc.CertificateAuthorityCertAuthType) {
+		t.Error("expected certificates of an unconfigured provider not to be auto-renewable")
+	}
+	if isAutoRenewable(cfg, tc.SelfSignedCertAuthType) {
+		t.Error("expected self-signed certificates not to be auto-renewable unless they are converted")
+	}
+	cfg.ConfigLetsEncrypt.ConvertSelfSigned = true
+	if !isAutoRenewable(cfg, tc.SelfSignedCertAuthType) {
+		t.Error("expected self-signed certificates to be auto-renewable when they are converted")
+	}
+}
@@ -0,0 +1,343 @@
+package deliveryservice
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/lib/go-util"
+	"github.com/apache/trafficcontrol/traffic_ops/traffic_ops_golang/api"
+	"github.com/apache/trafficcontrol/traffic_ops/traffic_ops_golang/dbhelpers"
+	"github.com/apache/trafficcontrol/traffic_ops/traffic_ops_golang/tenant"
+)
+
+// GetSSLKeysVersions lists every version of a Delivery Service's SSL keys stored in Traffic Vault.
+func GetSSLKeysVersions(w http.ResponseWriter, r *http.Request) {
+	inf, userErr, sysErr, errCode := api.NewInfo(r, []string{"xmlid"}, nil)
+	if userErr != nil || sysErr != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, errCode, userErr, sysErr)
+		return
+	}
+	defer inf.Close()
+	if !inf.Config.TrafficVaultEnabled {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("getting SSL keys versions from Traffic Vault: Traffic Vault is not configured"))
+		return
+	}
+	xmlID := inf.Params["xmlid"]
+	if userErr, sysErr, errCode := checkSSLKeysVersionsDS(inf, xmlID); userErr != nil || sysErr != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, errCode, userErr, sysErr)
+		return
+	}
+
+	keys, err := inf.Vault.GetDeliveryServiceSSLKeysVersions(xmlID, inf.Tx.Tx, r.Context())
+	if err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("getting SSL keys versions for delivery service '"+xmlID+"': "+err.Error()))
+		return
+	}
+	latest, err := getLatestSSLKeysVersion(inf, xmlID, r.Context())
+	if err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, err)
+		return
+	}
+
+	alerts := tc.Alerts{}
+	versions := make([]tc.DeliveryServiceSSLKeysVersion, 0, len(keys))
+	for _, key := range keys {
+		version, err := makeSSLKeysVersion(key)
+		if err != nil {
+			alerts.AddNewAlert(tc.WarnLevel, "SSL keys version "+key.Version.String()+" of delivery service '"+xmlID+"' has an unreadable certificate: "+err.Error())
+		}
+		version.Latest = key.Version == latest
+		versions = append(versions, version)
+	}
+	if len(alerts.Alerts) == 0 {
+		api.WriteResp(w, r, versions)
+	} else {
+		api.WriteAlertsObj(w, r, http.StatusOK, alerts, versions)
+	}
+}
+
+// GetSSLKeysVersionsDiff describes the differences between two stored versions of a Delivery Service's SSL keys.
+func GetSSLKeysVersionsDiff(w http.ResponseWriter, r *http.Request) {
+	inf, userErr, sysErr, errCode := api.NewInfo(r, []string{"xmlid", "from", "to"}, []string{"from", "to"})
+	if userErr != nil || sysErr != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, errCode, userErr, sysErr)
+		return
+	}
+	defer inf.Close()
+	if !inf.Config.TrafficVaultEnabled {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("getting SSL keys versions from Traffic Vault: Traffic Vault is not configured"))
+		return
+	}
+	xmlID := inf.Params["xmlid"]
+	if userErr, sysErr, errCode := checkSSLKeysVersionsDS(inf, xmlID); userErr != nil || sysErr != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, errCode, userErr, sysErr)
+		return
+	}
+
+	keys := make([]tc.DeliveryServiceSSLKeysV15, 0, 2)
+	for _, param := range []string{"from", "to"} {
+		version := strconv.Itoa(inf.IntParams[param])
+		key, ok, err := inf.Vault.GetDeliveryServiceSSLKeys(xmlID, version, inf.Tx.Tx, r.Context())
+		if err != nil {
+			api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("getting SSL keys version "+version+" for delivery service '"+xmlID+"': "+err.Error()))
+			return
+		} else if !ok {
+			api.HandleErr(w, r, inf.Tx.Tx, http.StatusNotFound, errors.New("no SSL keys version "+version+" for delivery service "+xmlID), nil)
+			return
+		}
+		keys = append(keys, key)
+	}
+	latest, err := getLatestSSLKeysVersion(inf, xmlID, r.Context())
+	if err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, err)
+		return
+	}
+
+	alerts := tc.Alerts{}
+	diff := tc.DeliveryServiceSSLKeysVersionsDiff{PrivateKeyChanged: keys[0].Certificate.Key != keys[1].Certificate.Key}
+	for i, version := range []*tc.DeliveryServiceSSLKeysVersion{&diff.From, &diff.To} {
+		var err error
+		if *version, err = makeSSLKeysVersion(keys[i]); err != nil {
+			alerts.AddNewAlert(tc.WarnLevel, "SSL keys version "+keys[i].Version.String()+" of delivery service '"+xmlID+"' has an unreadable certificate: "+err.Error())
+		}
+		version.Latest = keys[i].Version == latest
+	}
+	diff.Changes = diffSSLKeysVersions(diff.From, diff.To)
+	if len(alerts.Alerts) == 0 {
+		api.WriteResp(w, r, diff)
+	} else {
+		api.WriteAlertsObj(w, r, http.StatusOK, alerts, diff)
+	}
+}
+
+// checkSSLKeysVersionsDS checks that the Delivery Service with the given XMLID exists and that the user has
+// access to its tenant.
+func checkSSLKeysVersionsDS(inf *api.APIInfo, xmlID string) (error, error, int) {
+	if _, ok, err := getDSIDFromName(inf.Tx.Tx, xmlID); err != nil {
+		return nil, errors.New("getting DS ID from name " + err.Error()), http.StatusInternalServerError
+	} else if !ok {
+		return errors.New("no DS with name " + xmlID), nil, http.StatusNotFound
+	}
+	return tenant.Check(inf.User, xmlID, inf.Tx.Tx)
+}
+
+// getLatestSSLKeysVersion returns the latest version of a Delivery Service's SSL keys, or -1 if it has none.
+func getLatestSSLKeysVersion(inf *api.APIInfo, xmlID string, ctx context.Context) (util.JSONIntStr, error) {
+	latest, ok, err := inf.Vault.GetDeliveryServiceSSLKeys(xmlID, "", inf.Tx.Tx, ctx)
+	if err != nil {
+		return 0, errors.New("getting latest SSL keys for delivery service '" + xmlID + "': " + err.Error())
+	}
+	if !ok {
+		return -1, nil
+	}
+	return latest.Version, nil
+}
+
+// diffSSLKeysVersions returns the fields of the given SSL keys version summaries that differ, in the order
+// in which they are documented.
+func diffSSLKeysVersions(from tc.DeliveryServiceSSLKeysVersion, to tc.DeliveryServiceSSLKeysVersion) []tc.DeliveryServiceSSLKeysVersionChange {
+	fields := []struct {
+		name     string
+		from, to string
+	}{
+		{"authType", from.AuthType, to.AuthType},
+		{"expiration", formatSSLKeysVersionTime(from.Expiration), formatSSLKeysVersionTime(to.Expiration)},
+		{"fingerprint", from.Fingerprint, to.Fingerprint},
+		{"hostname", from.Hostname, to.Hostname},
+		{"issuer", from.Issuer, to.Issuer},
+		{"notBefore", formatSSLKeysVersionTime(from.NotBefore), formatSSLKeysVersionTime(to.NotBefore)},
+		{"sans", strings.Join(from.SANs, ", "), strings.Join(to.SANs, ", ")},
+		{"serialNumber", from.SerialNumber, to.SerialNumber},
+		{"subject", from.Subject, to.Subject},
+	}
+	changes := []tc.DeliveryServiceSSLKeysVersionChange{}
+	for _, field := range fields {
+		if field.from != field.to {
+			changes = append(changes, tc.DeliveryServiceSSLKeysVersionChange{Field: field.name, From: field.from, To: field.to})
+		}
+	}
+	return changes
+}
+
+// formatSSLKeysVersionTime formats a time of an SSL keys version summary in RFC 3339 format, or as an empty
+// string if it is unknown.
+func formatSSLKeysVersionTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// RollbackSSLKeys restores a previously stored version of a Delivery Service's SSL keys as the latest
+// version.
+func RollbackSSLKeys(w http.ResponseWriter, r *http.Request) {
+	inf, userErr, sysErr, errCode := api.NewInfo(r, []string{"xmlid"}, nil)
+	if userErr != nil || sysErr != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, errCode, userErr, sysErr)
+		return
+	}
+	defer inf.Close()
+	if !inf.Config.TrafficVaultEnabled {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("rolling back SSL keys in Traffic Vault: Traffic Vault is not configured"))
+		return
+	}
+	xmlID := inf.Params["xmlid"]
+	req := tc.DeliveryServiceSSLKeysRollbackRequest{}
+	if err := api.Parse(r.Body, inf.Tx.Tx, &req); err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusBadRequest, errors.New("parsing request: "+err.Error()), nil)
+		return
+	}
+	dsID, ok, err := getDSIDFromName(inf.Tx.Tx, xmlID)
+	if err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("deliveryservice.RollbackSSLKeys: getting DS ID from name "+err.Error()))
+		return
+	} else if !ok {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusNotFound, errors.New("no DS with name "+xmlID), nil)
+		return
+	}
+	if userErr, sysErr, errCode := tenant.Check(inf.User, xmlID, inf.Tx.Tx); userErr != nil || sysErr != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, errCode, userErr, sysErr)
+		return
+	}
+	_, cdn, _, err := dbhelpers.GetDSNameAndCDNFromID(inf.Tx.Tx, dsID)
+	if err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("deliveryservice.RollbackSSLKeys: getting CDN from DS ID "+err.Error()))
+		return
+	}
+	userErr, sysErr, statusCode := dbhelpers.CheckIfCurrentUserCanModifyCDN(inf.Tx.Tx, string(cdn), inf.User.UserName)
+	if userErr != nil || sysErr != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, statusCode, userErr, sysErr)
+		return
+	}
+
+	// The change log entry is written first, so that a failure to audit the rollback prevents it entirely; if
+	// the rollback itself fails, the transaction (and with it the entry) is rolled back.
+	rollback := sslKeysRollback{xmlID: xmlID, dsID: dsID, version: req.Version.String()}
+	if err := api.CreateChangeLog(api.ApiChange, "Rolled back", &rollback, inf.User, inf.Tx.Tx); err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("creating change log for SSL keys rollback of delivery service '"+xmlID+"': "+err.Error()))
+		return
+	}
+	key, ok, err := inf.Vault.RollbackDeliveryServiceSSLKeys(xmlID, req.Version.String(), inf.Tx.Tx, r.Context())
+	if err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("rolling back SSL keys in Traffic Vault for delivery service '"+xmlID+"': "+err.Error()))
+		return
+	} else if !ok {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusNotFound, errors.New("no SSL keys version "+req.Version.String()+" for delivery service "+xmlID), nil)
+		return
+	}
+	if err := updateSSLKeyVersion(xmlID, key.Version.ToInt64(), inf.Tx.Tx); err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("updating SSL key version of delivery service '"+xmlID+"': "+err.Error()))
+		return
+	}
+
+	version, err := makeSSLKeysVersion(key)
+	version.Latest = true
+	alerts := tc.CreateAlerts(tc.SuccessLevel, "Rolled back SSL keys for "+xmlID+" to version "+req.Version.String()+", stored as version "+key.Version.String())
+	if err != nil {
+		alerts.AddNewAlert(tc.WarnLevel, "the restored certificate could not be read: "+err.Error())
+	}
+	api.WriteAlertsObj(w, r, http.StatusOK, alerts, version)
+}
+
+// makeSSLKeysVersion summarizes the given SSL keys. If the certificate cannot be parsed, an error is
+// returned along with a summary that only describes the fields stored alongside the certificate.
+func makeSSLKeysVersion(key tc.DeliveryServiceSSLKeysV15) (tc.DeliveryServiceSSLKeysVersion, error) {
+	version := tc.DeliveryServiceSSLKeysVersion{
+		Version:  key.Version,
+		AuthType: key.AuthType,
+		Hostname: key.Hostname,
+		SANs:     []string{},
+	}
+	cert := key.Certificate
+	if err := Base64DecodeCertificate(&cert); err != nil {
+		return version, err
+	}
+	block, _ := pem.Decode([]byte(cert.Crt))
+	if block == nil {
+		return version, errors.New("decoding certificate PEM")
+	}
+	x509cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return version, errors.New("parsing certificate: " + err.Error())
+	}
+
+	version.Subject = x509cert.Subject.String()
+	version.Issuer = x509cert.Issuer.String()
+	version.SANs = append(version.SANs, x509cert.DNSNames...)
+	version.SerialNumber = x509cert.SerialNumber.String()
+	version.Fingerprint = certFingerprint(x509cert.Raw)
+	version.NotBefore = x509cert.NotBefore
+	version.Expiration = x509cert.NotAfter
+	return version, nil
+}
+
+// certFingerprint returns the SHA-256 fingerprint of the given DER certificate, in the same
+// colon-separated upper-case hexadecimal format as 'openssl x509 -fingerprint -sha256'.
+func certFingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+	hexBytes := make([]string, len(sum))
+	for i, b := range sum {
+		hexBytes[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(hexBytes, ":")
+}
+
+// sslKeysRollback identifies a rollback of a Delivery Service's SSL keys, for the change log.
+type sslKeysRollback struct {
+	xmlID   string
+	dsID    int
+	version string
+}
+
+func (s *sslKeysRollback) SetKeys(keys map[string]interface{}) {
+	s.dsID, _ = keys["id"].(int)
+}
+
+func (s *sslKeysRollback) GetKeys() (map[string]interface{}, bool) {
+	return map[string]interface{}{"id": s.dsID}, true
+}
+
+func (s *sslKeysRollback) GetKeyFieldsInfo() []api.KeyFieldInfo {
+	return []api.KeyFieldInfo{{Field: "id", Func: api.GetIntKey}}
+}
+
+func (s *sslKeysRollback) GetType() string {
+	return "deliveryservice ssl keys"
+}
+
+func (s *sslKeysRollback) GetAuditName() string {
+	return s.xmlID
+}
+
+// ChangeLogMessage implements the api.ChangeLogger interface.
+func (s *sslKeysRollback) ChangeLogMessage(action string) (string, error) {
+	return "DS: " + s.xmlID + ", ID: " + strconv.Itoa(s.dsID) + ", ACTION: " + action + " SSL keys to version " + s.version, nil
+}
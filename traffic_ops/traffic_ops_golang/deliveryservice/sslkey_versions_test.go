@@ -0,0 +1,112 @@
+package deliveryservice
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"encoding/base64"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+)
+
+func TestMakeSSLKeysVersion(t *testing.T) {
+	key := tc.DeliveryServiceSSLKeysV15{
+		DeliveryServiceSSLKeys: tc.DeliveryServiceSSLKeys{
+			AuthType: tc.SelfSignedCertAuthType,
+			Hostname: "*.ds1.example.test",
+			Version:  3,
+			Certificate: tc.DeliveryServiceSSLKeysCertificate{
+				Crt: base64.StdEncoding.EncodeToString([]byte(SelfSignedECDSACertificate)),
+				Key: base64.StdEncoding.EncodeToString([]byte(SelfSignedECDSAPrivateKey)),
+			},
+		},
+	}
+	version, err := makeSSLKeysVersion(key)
+	if err != nil {
+		t.Fatalf("making SSL keys version - expected: nil error, actual: %v", err)
+	}
+	if version.Version != 3 || version.AuthType != tc.SelfSignedCertAuthType || version.Hostname != "*.ds1.example.test" {
+		t.Errorf("making SSL keys version - expected: stored fields copied, actual: %+v", version)
+	}
+	if version.Issuer == "" || version.SerialNumber == "" || version.Expiration.IsZero() || !version.NotBefore.Before(version.Expiration) {
+		t.Errorf("making SSL keys version - expected: certificate fields populated, actual: %+v", version)
+	}
+	if !regexp.MustCompile(`^([0-9A-F]{2}:){31}[0-9A-F]{2}$`).MatchString(version.Fingerprint) {
+		t.Errorf("making SSL keys version - expected: SHA-256 fingerprint, actual: '%s'", version.Fingerprint)
+	}
+
+	key.Certificate.Crt = base64.StdEncoding.EncodeToString([]byte(BadCertData))
+	version, err = makeSSLKeysVersion(key)
+	if err == nil {
+		t.Error("making SSL keys version from a bad certificate - expected: error, actual: nil")
+	}
+	if version.Version != 3 || version.SANs == nil {
+		t.Errorf("making SSL keys version from a bad certificate - expected: stored fields copied, actual: %+v", version)
+	}
+}
+
+func TestDiffSSLKeysVersions(t *testing.T) {
+	from := tc.DeliveryServiceSSLKeysVersion{
+		Version:      1,
+		AuthType:     tc.SelfSignedCertAuthType,
+		Hostname:     "*.ds1.example.test",
+		Subject:      "CN=*.ds1.example.test",
+		Issuer:       "CN=*.ds1.example.test",
+		SANs:         []string{"*.ds1.example.test"},
+		SerialNumber: "1",
+		Fingerprint:  "AA",
+		NotBefore:    time.Date(2021, 5, 18, 13, 53, 6, 0, time.UTC),
+		Expiration:   time.Date(2022, 5, 18, 13, 53, 6, 0, time.UTC),
+	}
+	to := from
+	to.Version = 2
+	to.Latest = true
+
+	if changes := diffSSLKeysVersions(from, to); len(changes) != 0 {
+		t.Errorf("diffing SSL keys versions with the same certificate - expected: no changes, actual: %+v", changes)
+	}
+
+	to.SANs = []string{"*.ds1.example.test", "ds1.example.test"}
+	to.SerialNumber = "2"
+	to.Expiration = time.Time{}
+	expected := []tc.DeliveryServiceSSLKeysVersionChange{
+		{Field: "expiration", From: "2022-05-18T13:53:06Z", To: ""},
+		{Field: "sans", From: "*.ds1.example.test", To: "*.ds1.example.test, ds1.example.test"},
+		{Field: "serialNumber", From: "1", To: "2"},
+	}
+	if changes := diffSSLKeysVersions(from, to); !reflect.DeepEqual(changes, expected) {
+		t.Errorf("diffing SSL keys versions - expected: %+v, actual: %+v", expected, changes)
+	}
+}
+
+func TestSSLKeysRollbackChangeLogMessage(t *testing.T) {
+	rollback := sslKeysRollback{xmlID: "ds1", dsID: 42, version: "2"}
+	msg, err := rollback.ChangeLogMessage("Rolled back")
+	if err != nil {
+		t.Fatalf("making change log message - expected: nil error, actual: %v", err)
+	}
+	expected := "DS: ds1, ID: 42, ACTION: Rolled back SSL keys to version 2"
+	if msg != expected {
+		t.Errorf("making change log message - expected: '%s', actual: '%s'", expected, msg)
+	}
+}
@@ -0,0 +1,667 @@
+package rollout
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-log"
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/lib/go-util"
+	"github.com/apache/trafficcontrol/traffic_ops/traffic_ops_golang/api"
+	"github.com/apache/trafficcontrol/traffic_ops/traffic_ops_golang/dbhelpers"
+	"github.com/apache/trafficcontrol/traffic_ops/traffic_ops_golang/util/monitorhlp"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// rolloutPollInterval is how often a rollout checks whether its canaries have applied their updates and are healthy, and whether it has been aborted.
+const rolloutPollInterval = 10 * time.Second
+
+// rolloutLeaseDuration is how long a Traffic Ops instance's claim on a rollout lasts without being renewed. Rollouts are renewed every poll, so a rollout whose claim has expired was being run by an instance which stopped, and is resumed by another instance.
+const rolloutLeaseDuration = 6 * rolloutPollInterval
+
+// instanceName identifies this Traffic Ops instance as the owner of the rollouts it runs.
+var instanceName = newInstanceName()
+
+func newInstanceName() string {
+	hostName, err := os.Hostname()
+	if err != nil {
+		hostName = "unknown"
+	}
+	return fmt.Sprintf("%s:%d:%d", hostName, os.Getpid(), time.Now().UnixNano())
+}
+
+// rollout is a rollout which is running, as stored in the rollout table so that it can be resumed if the Traffic Ops instance running it stops.
+type rollout struct {
+	AsyncStatusID  int
+	CDNName        tc.CDNName
+	Canaries       []rolloutServer
+	Rest           []rolloutServer
+	CanaryDeadline time.Time
+	Soak           time.Duration
+}
+
+// rolloutServer is a server targeted by a rollout.
+type rolloutServer struct {
+	ID       int
+	HostName string
+	Type     string
+	Status   string
+}
+
+// Create starts a staged queue-update rollout, as an async job.
+// Updates are queued on the canary servers, and on the rest of the targeted servers once the canaries have applied them and are healthy.
+// Progress can be followed, and the rollout aborted, through the async_status API.
+func Create(w http.ResponseWriter, r *http.Request) {
+	inf, userErr, sysErr, errCode := api.NewInfo(r, nil, nil)
+	if userErr != nil || sysErr != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, errCode, userErr, sysErr)
+		return
+	}
+	defer inf.Close()
+
+	req := tc.RolloutRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusBadRequest, errors.New("malformed JSON: "+err.Error()), nil)
+		return
+	}
+	cdnName, userErr, sysErr, errCode := validate(inf.Tx.Tx, req)
+	if userErr != nil || sysErr != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, errCode, userErr, sysErr)
+		return
+	}
+	userErr, sysErr, errCode = dbhelpers.CheckIfCurrentUserHasCdnLock(inf.Tx.Tx, string(cdnName), inf.User.UserName)
+	if userErr != nil || sysErr != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, errCode, userErr, sysErr)
+		return
+	}
+
+	servers, err := getRolloutServers(inf.Tx.Tx, req)
+	if err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("getting rollout servers: "+err.Error()))
+		return
+	}
+	canaries, rest, err := selectCanaries(servers, req.CanaryPercent, req.CanaryServers)
+	if err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusBadRequest, err, nil)
+		return
+	}
+
+	db, err := api.GetDB(r.Context())
+	if err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("starting rollout: getting db: "+err.Error()))
+		return
+	}
+
+	if err := queueServerUpdates(inf.Tx.Tx, canaries); err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("queueing canary updates: "+err.Error()))
+		return
+	}
+
+	msg := fmt.Sprintf("CDN: %s, ACTION: Rollout started, queued updates on %d canary servers (%s), %d servers remaining", cdnName, len(canaries), strings.Join(serverHostNames(canaries), ", "), len(rest))
+	api.CreateChangeLogRawTx(api.ApiChange, msg, inf.User, inf.Tx.Tx)
+
+	asyncStatusId, errCode, userErr, sysErr := api.InsertAbortableAsyncStatusTx(inf.Tx.Tx, fmt.Sprintf("Rollout has started: waiting for %d canary servers to apply updates.", len(canaries)))
+	if userErr != nil || sysErr != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, errCode, userErr, sysErr)
+		return
+	}
+
+	timeout := time.Duration(tc.RolloutDefaultTimeoutSeconds) * time.Second
+	if req.TimeoutSeconds != nil {
+		timeout = time.Duration(*req.TimeoutSeconds) * time.Second
+	}
+	soak := time.Duration(tc.RolloutDefaultSoakSeconds) * time.Second
+	if req.SoakSeconds != nil {
+		soak = time.Duration(*req.SoakSeconds) * time.Second
+	}
+	ro := rollout{AsyncStatusID: asyncStatusId, CDNName: cdnName, Canaries: canaries, Rest: rest, CanaryDeadline: time.Now().Add(timeout), Soak: soak}
+	if err := insertRollout(inf.Tx.Tx, ro, req.CDNID); err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("storing rollout: "+err.Error()))
+		return
+	}
+
+	// The rollout must be committed before it runs, because it's renewed and finished in other transactions.
+	if err := inf.Tx.Tx.Commit(); err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("committing rollout: "+err.Error()))
+		return
+	}
+	go runRollout(db, ro)
+
+	var alerts tc.Alerts
+	alerts.AddAlert(tc.Alert{
+		Text:  "Beginning async rollout. Status updates can be found here: " + api.CurrentAsyncEndpoint + strconv.Itoa(asyncStatusId),
+		Level: tc.SuccessLevel.String(),
+	})
+
+	w.Header().Add("Location", api.CurrentAsyncEndpoint+strconv.Itoa(asyncStatusId))
+	api.WriteAlerts(w, r, http.StatusAccepted, alerts)
+}
+
+// validate validates the rollout request, and returns the name of its CDN, any user error, any system error, and the HTTP status code of any error.
+func validate(tx *sql.Tx, req tc.RolloutRequest) (tc.CDNName, error, error, int) {
+	cdnName, ok, err := dbhelpers.GetCDNNameFromID(tx, int64(req.CDNID))
+	if err != nil {
+		return "", nil, errors.New("getting CDN name from ID '" + strconv.Itoa(req.CDNID) + "': " + err.Error()), http.StatusInternalServerError
+	} else if !ok {
+		return "", errors.New("no cdn exists with id " + strconv.Itoa(req.CDNID)), nil, http.StatusBadRequest
+	}
+
+	if req.CacheGroupID != nil && req.Topology != nil {
+		return "", errors.New("cachegroupId and topology cannot both be set"), nil, http.StatusBadRequest
+	}
+	if req.CacheGroupID != nil {
+		if _, ok, err := dbhelpers.GetCacheGroupNameFromID(tx, *req.CacheGroupID); err != nil {
+			return "", nil, errors.New("checking cachegroup existence: " + err.Error()), http.StatusInternalServerError
+		} else if !ok {
+			return "", errors.New("no cachegroup exists with id " + strconv.Itoa(*req.CacheGroupID)), nil, http.StatusBadRequest
+		}
+	}
+	if req.Topology != nil {
+		if ok, err := dbhelpers.TopologyExists(tx, *req.Topology); err != nil {
+			return "", nil, errors.New("checking topology existence: " + err.Error()), http.StatusInternalServerError
+		} else if !ok {
+			return "", errors.New("no topology exists by the name of " + *req.Topology), nil, http.StatusBadRequest
+		}
+	}
+
+	if req.CanaryPercent != nil && len(req.CanaryServers) > 0 {
+		return "", errors.New("canaryPercent and canaryServers cannot both be set"), nil, http.StatusBadRequest
+	}
+	if req.CanaryPercent != nil && (*req.CanaryPercent < 1 || *req.CanaryPercent > 99) {
+		return "", errors.New("canaryPercent must be between 1 and 99"), nil, http.StatusBadRequest
+	}
+	if req.TimeoutSeconds != nil && *req.TimeoutSeconds < 1 {
+		return "", errors.New("timeoutSeconds must be positive"), nil, http.StatusBadRequest
+	}
+	if req.SoakSeconds != nil && *req.SoakSeconds < 0 {
+		return "", errors.New("soakSeconds cannot be negative"), nil, http.StatusBadRequest
+	}
+	return cdnName, nil, nil, http.StatusOK
+}
+
+// getRolloutServers returns the servers targeted by the rollout request.
+func getRolloutServers(tx *sql.Tx, req tc.RolloutRequest) ([]rolloutServer, error) {
+	qry := `
+SELECT s.id, s.host_name, t.name, st.name
+FROM server s
+JOIN type t ON t.id = s.type
+JOIN status st ON st.id = s.status
+JOIN cachegroup c ON c.id = s.cachegroup
+WHERE s.cdn_id = $1
+AND ($2::bigint IS NULL OR s.cachegroup = $2)
+AND ($3::text IS NULL OR c.name IN (SELECT tc.cachegroup FROM topology_cachegroup tc WHERE tc.topology = $3))
+ORDER BY s.host_name
+`
+	rows, err := tx.Query(qry, req.CDNID, req.CacheGroupID, req.Topology)
+	if err != nil {
+		return nil, errors.New("querying: " + err.Error())
+	}
+	defer log.Close(rows, "closing rollout server rows")
+
+	servers := []rolloutServer{}
+	for rows.Next() {
+		sv := rolloutServer{}
+		if err := rows.Scan(&sv.ID, &sv.HostName, &sv.Type, &sv.Status); err != nil {
+			return nil, errors.New("scanning: " + err.Error())
+		}
+		servers = append(servers, sv)
+	}
+	return servers, rows.Err()
+}
+
+// selectCanaries returns the canary servers, and the rest of the servers.
+// If canaryServers is not empty, the canaries are the servers with those host names, which must all be cache servers in servers.
+// Otherwise, the canaries are the given percent of the cache servers, rounded up, or tc.RolloutDefaultCanaryPercent if canaryPercent is nil.
+// Servers which aren't caches are never canaries, because they aren't monitored by Traffic Monitor.
+func selectCanaries(servers []rolloutServer, canaryPercent *int, canaryServers []string) ([]rolloutServer, []rolloutServer, error) {
+	caches := []rolloutServer{}
+	for _, sv := range servers {
+		if isCache(sv) {
+			caches = append(caches, sv)
+		}
+	}
+	if len(caches) == 0 {
+		return nil, nil, errors.New("no cache servers are targeted by the rollout")
+	}
+
+	canaryNames := map[string]struct{}{}
+	if len(canaryServers) > 0 {
+		cacheNames := map[string]struct{}{}
+		for _, sv := range caches {
+			cacheNames[sv.HostName] = struct{}{}
+		}
+		for _, name := range canaryServers {
+			if _, ok := cacheNames[name]; !ok {
+				return nil, nil, errors.New("canary server '" + name + "' is not a cache server targeted by the rollout")
+			}
+			canaryNames[name] = struct{}{}
+		}
+	} else {
+		percent := tc.RolloutDefaultCanaryPercent
+		if canaryPercent != nil {
+			percent = *canaryPercent
+		}
+		numCanaries := int(math.Ceil(float64(len(caches)) * float64(percent) / 100))
+		sort.Slice(caches, func(i, j int) bool { return caches[i].HostName < caches[j].HostName })
+		for _, sv := range caches[:numCanaries] {
+			canaryNames[sv.HostName] = struct{}{}
+		}
+	}
+
+	canaries := []rolloutServer{}
+	rest := []rolloutServer{}
+	for _, sv := range servers {
+		if _, ok := canaryNames[sv.HostName]; ok && isCache(sv) {
+			canaries = append(canaries, sv)
+		} else {
+			rest = append(rest, sv)
+		}
+	}
+	return canaries, rest, nil
+}
+
+func isCache(sv rolloutServer) bool {
+	return strings.HasPrefix(sv.Type, tc.EdgeTypePrefix) || strings.HasPrefix(sv.Type, tc.MidTypePrefix)
+}
+
+func serverHostNames(servers []rolloutServer) []string {
+	names := []string{}
+	for _, sv := range servers {
+		names = append(names, sv.HostName)
+	}
+	return names
+}
+
+func serverIDs(servers []rolloutServer) []int64 {
+	ids := []int64{}
+	for _, sv := range servers {
+		ids = append(ids, int64(sv.ID))
+	}
+	return ids
+}
+
+func queueServerUpdates(tx *sql.Tx, servers []rolloutServer) error {
+	if _, err := tx.Exec(`UPDATE server SET upd_pending = TRUE WHERE id = ANY($1)`, pq.Array(serverIDs(servers))); err != nil {
+		return errors.New("queueing updates: " + err.Error())
+	}
+	return nil
+}
+
+// StartResumer starts a goroutine which resumes the rollouts whose Traffic Ops instance stopped before they finished, on startup and periodically afterward.
+// A rollout is claimed by one instance at a time, so it's safe for every Traffic Ops instance using the database to run a resumer.
+func StartResumer(db *sqlx.DB) {
+	go func() {
+		for {
+			if err := deleteFinishedRollouts(db); err != nil {
+				log.Errorf("rollout resumer: deleting finished rollouts: %v", err)
+			}
+			rollouts, err := claimExpiredRollouts(db)
+			if err != nil {
+				log.Errorf("rollout resumer: claiming rollouts: %v", err)
+			}
+			for _, ro := range rollouts {
+				log.Infof("rollout %v: resuming, with %d canary servers and %d remaining servers", ro.AsyncStatusID, len(ro.Canaries), len(ro.Rest))
+				go runRollout(db, ro)
+			}
+			time.Sleep(rolloutLeaseDuration / 2)
+		}
+	}()
+}
+
+// runRollout waits for the canaries to apply their updates, and to stay healthy for the soak period, and then queues updates on the rest of the servers, reporting its progress to the rollout's async status.
+// If the canaries don't apply their updates before the deadline, or are unhealthy at any time during the soak period, the rest of the servers are not queued, and the rollout fails.
+// If another Traffic Ops instance claims the rollout, because this instance failed to renew its claim in time, the rollout stops here without updating its status.
+// It is meant to be run in its own goroutine, by the instance which claimed the rollout.
+func runRollout(db *sqlx.DB, ro rollout) {
+	asyncStatusId := ro.AsyncStatusID
+	defer func() {
+		if err := recover(); err != nil {
+			updateRolloutStatus(db, asyncStatusId, api.AsyncFailed, "Rollout failed.", true)
+			log.Errorf("panic: (err: %v) stacktrace:\n%s\n", err, util.Stacktrace())
+		}
+		if err := deleteRollout(db, asyncStatusId); err != nil {
+			log.Errorf("rollout %v: deleting: %v", asyncStatusId, err)
+		}
+	}()
+
+	// The soak period isn't stored, so a resumed rollout's soak period starts over, because the health of its canaries while it wasn't running is unknown.
+	soakStart := time.Time{}
+	for {
+		time.Sleep(rolloutPollInterval)
+
+		if aborted, err := api.IsAsyncStatusAborted(db, asyncStatusId); err != nil {
+			log.Errorf("rollout %v: checking if aborted: %v", asyncStatusId, err)
+		} else if aborted {
+			log.Infof("rollout %v: aborted, not queueing updates on %d remaining servers", asyncStatusId, len(ro.Rest))
+			return
+		}
+
+		if owned, err := renewRollout(db, asyncStatusId); err != nil {
+			log.Errorf("rollout %v: renewing claim: %v", asyncStatusId, err)
+		} else if !owned {
+			log.Warnf("rollout %v: claimed by another Traffic Ops instance, no longer running it here", asyncStatusId)
+			return
+		}
+
+		if rolledBack, err := getRolledBackServers(db, ro.Canaries); err != nil {
+			log.Errorf("rollout %v: getting canary rollback status: %v", asyncStatusId, err)
+			continue
+		} else if len(rolledBack) > 0 {
+			updateRolloutStatus(db, asyncStatusId, api.AsyncFailed, fmt.Sprintf("Rollout halted: %d canary servers rolled back their updates: %s. Updates were not queued on the %d remaining servers.", len(rolledBack), strings.Join(rolledBack, ", "), len(ro.Rest)), true)
+			return
+		}
+
+		if soakStart.IsZero() {
+			pending, err := getPendingServers(db, ro.Canaries)
+			if err != nil {
+				log.Errorf("rollout %v: getting canary update status: %v", asyncStatusId, err)
+				continue
+			}
+			if len(pending) > 0 {
+				if time.Now().After(ro.CanaryDeadline) {
+					updateRolloutStatus(db, asyncStatusId, api.AsyncFailed, fmt.Sprintf("Rollout halted: %d canary servers did not apply updates by %v: %s. Updates were not queued on the %d remaining servers.", len(pending), ro.CanaryDeadline.Format(time.RFC3339), strings.Join(pending, ", "), len(ro.Rest)), true)
+					return
+				}
+				updateRolloutStatus(db, asyncStatusId, api.AsyncPending, fmt.Sprintf("Rollout in progress: %d of %d canary servers have applied updates.", len(ro.Canaries)-len(pending), len(ro.Canaries)), false)
+				continue
+			}
+		}
+
+		crStates, err := getCRStates(db, ro.CDNName)
+		if err != nil {
+			updateRolloutStatus(db, asyncStatusId, api.AsyncFailed, "Rollout halted: getting canary health from Traffic Monitor: "+err.Error()+". Updates were not queued on the "+strconv.Itoa(len(ro.Rest))+" remaining servers.", true)
+			return
+		}
+		if unhealthy := getUnhealthyServers(ro.Canaries, crStates); len(unhealthy) > 0 {
+			updateRolloutStatus(db, asyncStatusId, api.AsyncFailed, fmt.Sprintf("Rollout halted: %d canary servers are unhealthy in Traffic Monitor after applying updates: %s. Updates were not queued on the %d remaining servers.", len(unhealthy), strings.Join(unhealthy, ", "), len(ro.Rest)), true)
+			return
+		}
+
+		if soakStart.IsZero() {
+			soakStart = time.Now()
+		}
+		if soaked := time.Since(soakStart); soaked < ro.Soak {
+			updateRolloutStatus(db, asyncStatusId, api.AsyncPending, fmt.Sprintf("Rollout in progress: all %d canary servers have applied updates, and have been healthy for %v of the %v soak period.", len(ro.Canaries), soaked.Round(time.Second), ro.Soak), false)
+			continue
+		}
+		break
+	}
+
+	if err := queueRemainingUpdates(db, asyncStatusId, ro.Rest); err == errRolloutNotOwned {
+		log.Warnf("rollout %v: claimed by another Traffic Ops instance, no longer running it here", asyncStatusId)
+		return
+	} else if err != nil {
+		updateRolloutStatus(db, asyncStatusId, api.AsyncFailed, "Rollout failed: queueing updates on the remaining servers: "+err.Error(), true)
+		return
+	}
+	updateRolloutStatus(db, asyncStatusId, api.AsyncSucceeded, fmt.Sprintf("Rollout completed: %d canary servers applied updates and stayed healthy for the %v soak period, updates queued on the %d remaining servers.", len(ro.Canaries), ro.Soak, len(ro.Rest)), true)
+}
+
+// errRolloutNotOwned is returned when a rollout was claimed by another Traffic Ops instance.
+var errRolloutNotOwned = errors.New("rollout is owned by another Traffic Ops instance")
+
+// queueRemainingUpdates queues updates on the given servers, unless the rollout was aborted or claimed by another Traffic Ops instance, and deletes the stored rollout.
+// The checks and the queueing are in the same transaction as row locks on the async status and the rollout, so a rollout aborted or claimed at the same time either queues nothing or is already finished.
+func queueRemainingUpdates(db *sqlx.DB, asyncStatusId int, servers []rolloutServer) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return errors.New("beginning transaction: " + err.Error())
+	}
+	status := ""
+	if err := tx.QueryRow(`SELECT status FROM async_status WHERE id = $1 FOR UPDATE`, asyncStatusId).Scan(&status); err != nil {
+		tx.Rollback()
+		return errors.New("checking if aborted: " + err.Error())
+	}
+	if status == api.AsyncAborted {
+		tx.Rollback()
+		return errors.New("rollout was aborted")
+	}
+	id := 0
+	if err := tx.QueryRow(`DELETE FROM rollout WHERE async_status_id = $1 AND owner = $2 RETURNING async_status_id`, asyncStatusId, instanceName).Scan(&id); err == sql.ErrNoRows {
+		tx.Rollback()
+		return errRolloutNotOwned
+	} else if err != nil {
+		tx.Rollback()
+		return errors.New("deleting rollout: " + err.Error())
+	}
+	if err := queueServerUpdates(tx, servers); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// insertRollout stores the rollout, claimed by this Traffic Ops instance.
+func insertRollout(tx *sql.Tx, ro rollout, cdnID int) error {
+	qry := `
+INSERT INTO rollout (async_status_id, cdn_id, canary_servers, remaining_servers, canary_deadline, soak_seconds, owner, lease_expires)
+VALUES ($1, $2, $3, $4, $5, $6, $7, now() + $8 * interval '1 second')
+`
+	if _, err := tx.Exec(qry, ro.AsyncStatusID, cdnID, pq.Array(serverIDs(ro.Canaries)), pq.Array(serverIDs(ro.Rest)), ro.CanaryDeadline, int64(ro.Soak/time.Second), instanceName, int64(rolloutLeaseDuration/time.Second)); err != nil {
+		return errors.New("inserting: " + err.Error())
+	}
+	return nil
+}
+
+// renewRollout extends this Traffic Ops instance's claim on the rollout, and returns false if the rollout isn't claimed by this instance.
+func renewRollout(db *sqlx.DB, asyncStatusId int) (bool, error) {
+	qry := `
+UPDATE rollout SET lease_expires = now() + $3 * interval '1 second', last_updated = now()
+WHERE async_status_id = $1 AND owner = $2
+`
+	result, err := db.Exec(qry, asyncStatusId, instanceName, int64(rolloutLeaseDuration/time.Second))
+	if err != nil {
+		return false, errors.New("updating: " + err.Error())
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, errors.New("getting rows affected: " + err.Error())
+	}
+	return rowsAffected > 0, nil
+}
+
+// deleteRollout deletes the stored rollout, if it's claimed by this Traffic Ops instance.
+func deleteRollout(db *sqlx.DB, asyncStatusId int) error {
+	if _, err := db.Exec(`DELETE FROM rollout WHERE async_status_id = $1 AND owner = $2`, asyncStatusId, instanceName); err != nil {
+		return errors.New("deleting: " + err.Error())
+	}
+	return nil
+}
+
+// deleteFinishedRollouts deletes the stored rollouts which are no longer pending, e.g. which were aborted while no Traffic Ops instance was running them.
+func deleteFinishedRollouts(db *sqlx.DB) error {
+	if _, err := db.Exec(`DELETE FROM rollout r USING async_status a WHERE a.id = r.async_status_id AND a.status <> $1`, api.AsyncPending); err != nil {
+		return errors.New("deleting: " + err.Error())
+	}
+	return nil
+}
+
+// claimExpiredRollouts claims the pending rollouts whose claims have expired for this Traffic Ops instance, and returns them.
+// The claim is a single conditional update, so when several instances claim at once, each rollout is claimed by only one of them.
+func claimExpiredRollouts(db *sqlx.DB) ([]rollout, error) {
+	qry := `
+UPDATE rollout r SET owner = $1, lease_expires = now() + $2 * interval '1 second', last_updated = now()
+FROM async_status a, cdn c
+WHERE a.id = r.async_status_id
+AND c.id = r.cdn_id
+AND a.status = $3
+AND r.lease_expires < now()
+RETURNING r.async_status_id, c.name, r.canary_servers, r.remaining_servers, r.canary_deadline, r.soak_seconds
+`
+	rows, err := db.Query(qry, instanceName, int64(rolloutLeaseDuration/time.Second), api.AsyncPending)
+	if err != nil {
+		return nil, errors.New("querying: " + err.Error())
+	}
+	defer log.Close(rows, "closing claimed rollout rows")
+
+	type claimedRollout struct {
+		ro        rollout
+		canaryIDs []int64
+		restIDs   []int64
+	}
+	claimed := []claimedRollout{}
+	for rows.Next() {
+		cr := claimedRollout{}
+		soakSeconds := int64(0)
+		if err := rows.Scan(&cr.ro.AsyncStatusID, &cr.ro.CDNName, pq.Array(&cr.canaryIDs), pq.Array(&cr.restIDs), &cr.ro.CanaryDeadline, &soakSeconds); err != nil {
+			return nil, errors.New("scanning: " + err.Error())
+		}
+		cr.ro.Soak = time.Duration(soakSeconds) * time.Second
+		claimed = append(claimed, cr)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.New("iterating: " + err.Error())
+	}
+
+	rollouts := []rollout{}
+	for _, cr := range claimed {
+		if cr.ro.Canaries, err = getServersByID(db, cr.canaryIDs); err != nil {
+			return nil, errors.New("getting canary servers of rollout " + strconv.Itoa(cr.ro.AsyncStatusID) + ": " + err.Error())
+		}
+		if cr.ro.Rest, err = getServersByID(db, cr.restIDs); err != nil {
+			return nil, errors.New("getting remaining servers of rollout " + strconv.Itoa(cr.ro.AsyncStatusID) + ": " + err.Error())
+		}
+		rollouts = append(rollouts, cr.ro)
+	}
+	return rollouts, nil
+}
+
+// getServersByID returns the servers with the given IDs. Servers which have been deleted are omitted.
+func getServersByID(db *sqlx.DB, ids []int64) ([]rolloutServer, error) {
+	qry := `
+SELECT s.id, s.host_name, t.name, st.name
+FROM server s
+JOIN type t ON t.id = s.type
+JOIN status st ON st.id = s.status
+WHERE s.id = ANY($1)
+ORDER BY s.host_name
+`
+	rows, err := db.Query(qry, pq.Array(ids))
+	if err != nil {
+		return nil, errors.New("querying: " + err.Error())
+	}
+	defer log.Close(rows, "closing rollout server rows")
+
+	servers := []rolloutServer{}
+	for rows.Next() {
+		sv := rolloutServer{}
+		if err := rows.Scan(&sv.ID, &sv.HostName, &sv.Type, &sv.Status); err != nil {
+			return nil, errors.New("scanning: " + err.Error())
+		}
+		servers = append(servers, sv)
+	}
+	return servers, rows.Err()
+}
+
+// updateRolloutStatus updates the rollout's async status. Aborted rollouts are not updated.
+func updateRolloutStatus(db *sqlx.DB, asyncStatusId int, status string, msg string, finished bool) {
+	if asyncErr := api.UpdateAsyncStatus(db, status, msg, asyncStatusId, finished); asyncErr != nil {
+		log.Errorf("updating async status for id %v: %v", asyncStatusId, asyncErr)
+	}
+}
+
+// getPendingServers returns the host names of the given servers which still have updates pending.
+func getPendingServers(db *sqlx.DB, servers []rolloutServer) ([]string, error) {
+	rows, err := db.Query(`SELECT host_name FROM server WHERE id = ANY($1) AND upd_pending ORDER BY host_name`, pq.Array(serverIDs(servers)))
+	if err != nil {
+		return nil, errors.New("querying: " + err.Error())
+	}
+	defer log.Close(rows, "closing pending server rows")
+
+	pending := []string{}
+	for rows.Next() {
+		name := ""
+		if err := rows.Scan(&name); err != nil {
+			return nil, errors.New("scanning: " + err.Error())
+		}
+		pending = append(pending, name)
+	}
+	return pending, rows.Err()
+}
+
+// getRolledBackServers returns the host names of the given servers which rolled back the updates they applied.
+func getRolledBackServers(db *sqlx.DB, servers []rolloutServer) ([]string, error) {
+	rows, err := db.Query(`SELECT host_name FROM server WHERE id = ANY($1) AND config_rolled_back ORDER BY host_name`, pq.Array(serverIDs(servers)))
+	if err != nil {
+		return nil, errors.New("querying: " + err.Error())
+	}
+	defer log.Close(rows, "closing rolled back server rows")
+
+	rolledBack := []string{}
+	for rows.Next() {
+		name := ""
+		if err := rows.Scan(&name); err != nil {
+			return nil, errors.New("scanning: " + err.Error())
+		}
+		rolledBack = append(rolledBack, name)
+	}
+	return rolledBack, rows.Err()
+}
+
+// getCRStates returns the cache states of the CDN from one of its Traffic Monitors.
+func getCRStates(db *sqlx.DB, cdnName tc.CDNName) (tc.CRStates, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return tc.CRStates{}, errors.New("beginning transaction: " + err.Error())
+	}
+	defer tx.Commit()
+
+	monitors, err := monitorhlp.GetURLs(tx)
+	if err != nil {
+		return tc.CRStates{}, errors.New("getting monitors: " + err.Error())
+	}
+	monitorFQDN, ok := monitors[cdnName]
+	if !ok {
+		return tc.CRStates{}, errors.New("no online Traffic Monitor found for CDN " + string(cdnName))
+	}
+	client, err := monitorhlp.GetClient(tx)
+	if err != nil {
+		return tc.CRStates{}, errors.New("getting monitor client: " + err.Error())
+	}
+	return monitorhlp.GetCRStates(monitorFQDN, client)
+}
+
+// getUnhealthyServers returns the host names of the given servers which are unavailable in the given Traffic Monitor cache states.
+// Only REPORTED servers are checked, because Traffic Monitor doesn't use the health of servers with other statuses.
+func getUnhealthyServers(servers []rolloutServer, crStates tc.CRStates) []string {
+	unhealthy := []string{}
+	for _, sv := range servers {
+		if sv.Status != string(tc.CacheStatusReported) {
+			continue
+		}
+		if avail, ok := crStates.Caches[tc.CacheName(sv.HostName)]; !ok || !avail.IsAvailable {
+			unhealthy = append(unhealthy, sv.HostName)
+		}
+	}
+	return unhealthy
+}
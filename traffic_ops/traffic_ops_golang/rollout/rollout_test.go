@@ -0,0 +1,183 @@
+package rollout
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+
+	"github.com/jmoiron/sqlx"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func testRolloutServers() []rolloutServer {
+	return []rolloutServer{
+		{ID: 1, HostName: "edge-d", Type: "EDGE", Status: "REPORTED"},
+		{ID: 2, HostName: "edge-a", Type: "EDGE", Status: "REPORTED"},
+		{ID: 3, HostName: "mid-b", Type: "MID", Status: "REPORTED"},
+		{ID: 4, HostName: "edge-c", Type: "EDGE", Status: "ADMIN_DOWN"},
+		{ID: 5, HostName: "tm-a", Type: "RASCAL", Status: "ONLINE"},
+	}
+}
+
+func TestSelectCanariesPercent(t *testing.T) {
+	servers := testRolloutServers()
+	pct := 25
+	canaries, rest, err := selectCanaries(servers, &pct, nil)
+	if err != nil {
+		t.Fatalf("selectCanaries expected nil error, actual: %v", err)
+	}
+	if names := serverHostNames(canaries); !reflect.DeepEqual(names, []string{"edge-a"}) {
+		t.Errorf("selectCanaries 25%% expected canaries [edge-a], actual: %v", names)
+	}
+	if len(rest) != 4 {
+		t.Errorf("selectCanaries expected 4 remaining servers, actual: %v", serverHostNames(rest))
+	}
+
+	pct = 50
+	canaries, rest, err = selectCanaries(servers, &pct, nil)
+	if err != nil {
+		t.Fatalf("selectCanaries expected nil error, actual: %v", err)
+	}
+	if names := serverHostNames(canaries); !reflect.DeepEqual(names, []string{"edge-a", "edge-c"}) {
+		t.Errorf("selectCanaries 50%% expected canaries [edge-a edge-c], actual: %v", names)
+	}
+	if names := serverHostNames(rest); !reflect.DeepEqual(names, []string{"edge-d", "mid-b", "tm-a"}) {
+		t.Errorf("selectCanaries 50%% expected rest [edge-d mid-b tm-a], actual: %v", names)
+	}
+
+	// the default percent must still select at least one canary
+	canaries, _, err = selectCanaries(servers, nil, nil)
+	if err != nil {
+		t.Fatalf("selectCanaries expected nil error, actual: %v", err)
+	}
+	if len(canaries) != 1 {
+		t.Errorf("selectCanaries default percent expected 1 canary, actual: %v", serverHostNames(canaries))
+	}
+}
+
+func TestSelectCanariesNamed(t *testing.T) {
+	servers := testRolloutServers()
+	canaries, rest, err := selectCanaries(servers, nil, []string{"mid-b", "edge-d"})
+	if err != nil {
+		t.Fatalf("selectCanaries expected nil error, actual: %v", err)
+	}
+	if names := serverHostNames(canaries); !reflect.DeepEqual(names, []string{"edge-d", "mid-b"}) {
+		t.Errorf("selectCanaries expected canaries [edge-d mid-b], actual: %v", names)
+	}
+	if len(rest) != 3 {
+		t.Errorf("selectCanaries expected 3 remaining servers, actual: %v", serverHostNames(rest))
+	}
+
+	if _, _, err := selectCanaries(servers, nil, []string{"tm-a"}); err == nil {
+		t.Error("selectCanaries with a non-cache canary expected error, actual: nil")
+	}
+	if _, _, err := selectCanaries(servers, nil, []string{"nonexistent"}); err == nil {
+		t.Error("selectCanaries with a nonexistent canary expected error, actual: nil")
+	}
+	if _, _, err := selectCanaries([]rolloutServer{servers[4]}, nil, nil); err == nil {
+		t.Error("selectCanaries with no caches expected error, actual: nil")
+	}
+}
+
+func TestGetUnhealthyServers(t *testing.T) {
+	servers := testRolloutServers()[:4]
+	crStates := tc.CRStates{
+		Caches: map[tc.CacheName]tc.IsAvailable{
+			"edge-d": {IsAvailable: true},
+			"edge-a": {IsAvailable: false},
+		},
+	}
+	// mid-b is missing from the CRStates, and edge-c isn't REPORTED, so its health is ignored.
+	unhealthy := getUnhealthyServers(servers, crStates)
+	if !reflect.DeepEqual(unhealthy, []string{"edge-a", "mid-b"}) {
+		t.Errorf("getUnhealthyServers expected [edge-a mid-b], actual: %v", unhealthy)
+	}
+}
+
+func TestClaimExpiredRollouts(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+	defer db.Close()
+
+	deadline := time.Now().Add(time.Hour)
+	rows := sqlmock.NewRows([]string{"async_status_id", "name", "canary_servers", "remaining_servers", "canary_deadline", "soak_seconds"})
+	rows.AddRow(7, "cdn0", "{2}", "{1,5}", deadline, 120)
+	mock.ExpectQuery("UPDATE rollout").WithArgs(instanceName, int64(rolloutLeaseDuration/time.Second), "PENDING").WillReturnRows(rows)
+
+	canaryRows := sqlmock.NewRows([]string{"id", "host_name", "name", "name"})
+	canaryRows.AddRow(2, "edge-a", "EDGE", "REPORTED")
+	mock.ExpectQuery("SELECT").WillReturnRows(canaryRows)
+	restRows := sqlmock.NewRows([]string{"id", "host_name", "name", "name"})
+	restRows.AddRow(1, "edge-d", "EDGE", "REPORTED")
+	restRows.AddRow(5, "tm-a", "RASCAL", "ONLINE")
+	mock.ExpectQuery("SELECT").WillReturnRows(restRows)
+
+	rollouts, err := claimExpiredRollouts(db)
+	if err != nil {
+		t.Fatalf("claimExpiredRollouts expected nil error, actual: %v", err)
+	}
+	if len(rollouts) != 1 {
+		t.Fatalf("claimExpiredRollouts expected 1 rollout, actual: %+v", rollouts)
+	}
+	ro := rollouts[0]
+	if ro.AsyncStatusID != 7 || ro.CDNName != "cdn0" || !ro.CanaryDeadline.Equal(deadline) || ro.Soak != 2*time.Minute {
+		t.Errorf("claimExpiredRollouts expected rollout 7 of cdn0 with a 2m soak period, actual: %+v", ro)
+	}
+	if names := serverHostNames(ro.Canaries); !reflect.DeepEqual(names, []string{"edge-a"}) {
+		t.Errorf("claimExpiredRollouts expected canaries [edge-a], actual: %v", names)
+	}
+	if names := serverHostNames(ro.Rest); !reflect.DeepEqual(names, []string{"edge-d", "tm-a"}) {
+		t.Errorf("claimExpiredRollouts expected remaining servers [edge-d tm-a], actual: %v", names)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("expectations were not met: %v", err)
+	}
+}
+
+func TestRenewRollout(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+	defer db.Close()
+
+	mock.ExpectExec("UPDATE rollout").WithArgs(7, instanceName, int64(rolloutLeaseDuration/time.Second)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE rollout").WithArgs(7, instanceName, int64(rolloutLeaseDuration/time.Second)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if owned, err := renewRollout(db, 7); err != nil || !owned {
+		t.Errorf("renewRollout of an owned rollout expected true, nil; actual: %v, %v", owned, err)
+	}
+	if owned, err := renewRollout(db, 7); err != nil || owned {
+		t.Errorf("renewRollout of a rollout claimed by another instance expected false, nil; actual: %v, %v", owned, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("expectations were not met: %v", err)
+	}
+}
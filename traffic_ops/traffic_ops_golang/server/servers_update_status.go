@@ -123,7 +123,8 @@ SELECT
 		WHERE sta.base_server_id = s.id
 		AND sta.cdn_id = s.cdn_id
 		UNION SELECT COALESCE(BOOL_OR(ps.reval_pending), FALSE)
-	) AS parent_reval_pending
+	) AS parent_reval_pending,
+	s.config_rolled_back
 	FROM use_reval_pending,
 		 server s
 LEFT JOIN status ON s.status = status.id
@@ -132,7 +133,7 @@ LEFT JOIN type ON type.id = s.type
 LEFT JOIN parentservers ps ON ps.cachegroup = cg.parent_cachegroup_id
 	AND ps.cdn_id = s.cdn_id
 WHERE s.host_name = $5
-GROUP BY s.id, s.host_name, type.name, server_reval_pending, use_reval_pending.value, s.upd_pending, status.name
+GROUP BY s.id, s.host_name, type.name, server_reval_pending, use_reval_pending.value, s.upd_pending, status.name, s.config_rolled_back
 ORDER BY s.id
 `
 
@@ -148,7 +149,7 @@ ORDER BY s.id
 	for rows.Next() {
 		var us tc.ServerUpdateStatus
 		var serverType string
-		if err := rows.Scan(&us.HostId, &us.HostName, &serverType, &us.RevalPending, &us.UseRevalPending, &us.UpdatePending, &us.Status, &us.ParentPending, &us.ParentRevalPending); err != nil {
+		if err := rows.Scan(&us.HostId, &us.HostName, &serverType, &us.RevalPending, &us.UseRevalPending, &us.UpdatePending, &us.Status, &us.ParentPending, &us.ParentRevalPending, &us.ConfigRolledBack); err != nil {
 			log.Errorf("could not scan server update status: %s\n", err)
 			return nil, tc.DBError
 		}
@@ -87,12 +87,14 @@ func UpdateHandler(w http.ResponseWriter, r *http.Request) {
 
 	updated, hasUpdated := inf.Params["updated"]
 	revalUpdated, hasRevalUpdated := inf.Params["reval_updated"]
-	if !hasUpdated && !hasRevalUpdated {
-		api.HandleErr(w, r, inf.Tx.Tx, http.StatusBadRequest, errors.New("Must pass at least one query paramter of 'updated' or 'reval_updated'"), nil)
+	rolledBack, hasRolledBack := inf.Params["config_rolled_back"]
+	if !hasUpdated && !hasRevalUpdated && !hasRolledBack {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusBadRequest, errors.New("Must pass at least one query paramter of 'updated', 'reval_updated' or 'config_rolled_back'"), nil)
 		return
 	}
 	updated = strings.ToLower(updated)
 	revalUpdated = strings.ToLower(revalUpdated)
+	rolledBack = strings.ToLower(rolledBack)
 
 	if hasUpdated && updated != `t` && updated != `true` && updated != `f` && updated != `false` {
 		api.HandleErr(w, r, inf.Tx.Tx, http.StatusBadRequest, errors.New("query parameter 'updated' must be 'true' or 'false'"), nil)
@@ -103,6 +105,11 @@ func UpdateHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if hasRolledBack && rolledBack != `t` && rolledBack != `true` && rolledBack != `f` && rolledBack != `false` {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusBadRequest, errors.New("query parameter 'config_rolled_back' must be 'true' or 'false'"), nil)
+		return
+	}
+
 	strToBool := func(s string) bool {
 		return !strings.HasPrefix(strings.ToLower(s), "f")
 	}
@@ -118,9 +125,18 @@ func UpdateHandler(w http.ResponseWriter, r *http.Request) {
 		revalUpdatedPtr = &revalUpdatedBool
 	}
 
-	if err := setUpdateStatuses(inf.Tx.Tx, hostName, updatedPtr, revalUpdatedPtr); err != nil {
-		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("setting updated statuses: "+err.Error()))
-		return
+	if updatedPtr != nil || revalUpdatedPtr != nil {
+		if err := setUpdateStatuses(inf.Tx.Tx, hostName, updatedPtr, revalUpdatedPtr); err != nil {
+			api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("setting updated statuses: "+err.Error()))
+			return
+		}
+	}
+	// This must be set after the updated statuses, because setting them clears it.
+	if hasRolledBack {
+		if err := setConfigRolledBack(inf.Tx.Tx, hostName, strToBool(rolledBack)); err != nil {
+			api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("setting config rolled back: "+err.Error()))
+			return
+		}
 	}
 
 	respMsg := "successfully set server '" + hostName + "'"
@@ -130,6 +146,9 @@ func UpdateHandler(w http.ResponseWriter, r *http.Request) {
 	if hasRevalUpdated {
 		respMsg += " reval_updated=" + strconv.FormatBool(strToBool(revalUpdated))
 	}
+	if hasRolledBack {
+		respMsg += " config_rolled_back=" + strconv.FormatBool(strToBool(rolledBack))
+	}
 
 	api.WriteAlerts(w, r, http.StatusOK, tc.CreateAlerts(tc.SuccessLevel, respMsg))
 }
@@ -162,3 +181,12 @@ func setUpdateStatuses(tx *sql.Tx, hostName string, updatePending *bool, revalPe
 	}
 	return nil
 }
+
+// setConfigRolledBack sets the config_rolled_back column of a server.
+// Setting the upd_pending or reval_pending columns clears it, so it must be set after them.
+func setConfigRolledBack(tx *sql.Tx, hostName string, rolledBack bool) error {
+	if _, err := tx.Exec(`UPDATE server SET config_rolled_back = $1 WHERE host_name = $2`, rolledBack, hostName); err != nil {
+		return errors.New("executing: " + err.Error())
+	}
+	return nil
+}
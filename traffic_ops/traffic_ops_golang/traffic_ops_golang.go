@@ -39,11 +39,14 @@ import (
 	"github.com/apache/trafficcontrol/traffic_ops/traffic_ops_golang/auth"
 	"github.com/apache/trafficcontrol/traffic_ops/traffic_ops_golang/config"
 	"github.com/apache/trafficcontrol/traffic_ops/traffic_ops_golang/plugin"
+	"github.com/apache/trafficcontrol/traffic_ops/traffic_ops_golang/rollout"
 	"github.com/apache/trafficcontrol/traffic_ops/traffic_ops_golang/routing"
 	"github.com/apache/trafficcontrol/traffic_ops/traffic_ops_golang/trafficvault"
 	_ "github.com/apache/trafficcontrol/traffic_ops/traffic_ops_golang/trafficvault/backends" // init traffic vault backends
 	"github.com/apache/trafficcontrol/traffic_ops/traffic_ops_golang/trafficvault/backends/disabled"
+	"github.com/apache/trafficcontrol/traffic_ops/traffic_ops_golang/trafficvault/backends/postgres"
 	"github.com/apache/trafficcontrol/traffic_ops/traffic_ops_golang/trafficvault/backends/riaksvc"
+	"github.com/apache/trafficcontrol/traffic_ops/traffic_ops_golang/vault"
 
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
@@ -167,6 +170,12 @@ func main() {
 		os.Exit(1)
 	}
 
+	rollout.StartResumer(db)
+
+	if cfg.TrafficVaultBackend == postgres.PostgresBackendName && cfg.TrafficVaultReEncryptIntervalSeconds > 0 {
+		vault.StartReEncryptScheduler(trafficVault, db, time.Duration(cfg.TrafficVaultReEncryptIntervalSeconds)*time.Second)
+	}
+
 	plugins.OnStartup(plugin.StartupData{Data: plugin.Data{SharedCfg: cfg.PluginSharedConfig, AppCfg: cfg}})
 
 	log.Infof("Listening on " + cfg.Port)
@@ -111,7 +111,7 @@ func queryStatsSummary(tx *sqlx.Tx, q string, queryValues map[string]interface{}
 	return statsSummaries, nil
 }
 
-// CreateStatsSummary handler for creating stats summaries
+// CreateStatsSummary handler for creating stats summaries. A summary of the same stat of the same CDN and Delivery Service on the same date replaces the existing one, so summaries may be recomputed.
 func CreateStatsSummary(w http.ResponseWriter, r *http.Request) {
 	inf, userErr, sysErr, errCode := api.NewInfo(r, []string{}, []string{})
 	if userErr != nil || sysErr != nil {
@@ -183,6 +183,10 @@ VALUES (
 	:stat_name,
 	:stat_value,
 	:summary_time,
-	:stat_date) RETURNING id
+	:stat_date)
+ON CONFLICT (cdn_name, deliveryservice_name, stat_name, stat_date) DO UPDATE SET
+	stat_value = EXCLUDED.stat_value,
+	summary_time = EXCLUDED.summary_time
+RETURNING id
 `
 }
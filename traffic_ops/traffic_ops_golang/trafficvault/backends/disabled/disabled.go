@@ -53,6 +53,14 @@ func (d *Disabled) DeleteDeliveryServiceSSLKeys(xmlID string, version string, tx
 	return disabledErr
 }
 
+func (d *Disabled) GetDeliveryServiceSSLKeysVersions(xmlID string, tx *sql.Tx, ctx context.Context) ([]tc.DeliveryServiceSSLKeysV15, error) {
+	return nil, disabledErr
+}
+
+func (d *Disabled) RollbackDeliveryServiceSSLKeys(xmlID string, version string, tx *sql.Tx, ctx context.Context) (tc.DeliveryServiceSSLKeysV15, bool, error) {
+	return tc.DeliveryServiceSSLKeysV15{}, false, disabledErr
+}
+
 func (d *Disabled) DeleteOldDeliveryServiceSSLKeys(existingXMLIDs map[string]struct{}, cdnName string, tx *sql.Tx, ctx context.Context) error {
 	return disabledErr
 }
@@ -103,3 +111,7 @@ func (d *Disabled) Ping(tx *sql.Tx, ctx context.Context) (tc.TrafficVaultPing, e
 func (d *Disabled) GetBucketKey(bucket string, key string, tx *sql.Tx) ([]byte, bool, error) {
 	return nil, false, disabledErr
 }
+
+func (d *Disabled) ReEncrypt(progress func(done int, total int), ctx context.Context) error {
+	return disabledErr
+}
@@ -0,0 +1,134 @@
+package filesystem
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/apache/trafficcontrol/lib/go-util"
+)
+
+// dataKeyLen is the length, in bytes, of the generated data key (AES-256).
+const dataKeyLen = 32
+
+// readMasterKey reads the base64-encoded AES master key from the given file.
+func readMasterKey(location string) ([]byte, error) {
+	keyBase64, err := ioutil.ReadFile(location)
+	if err != nil {
+		return nil, errors.New("reading file '" + location + "': " + err.Error())
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(keyBase64)))
+	if err != nil {
+		return nil, errors.New("master key cannot be decoded from base64")
+	}
+	if err := util.ValidateAESKey(key); err != nil {
+		return nil, errors.New("validating master key: " + err.Error())
+	}
+	return key, nil
+}
+
+// loadDataKey returns the data key used to encrypt every file in the given directory. The data key is stored
+// on disk wrapped (encrypted) by the master key. If no data key exists yet, a new one is generated and stored.
+func loadDataKey(dir string, masterKey []byte) ([]byte, error) {
+	dataKey, found, err := readDataKey(dir, masterKey)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		return dataKey, nil
+	}
+
+	dataKey = make([]byte, dataKeyLen)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, errors.New("generating data key: " + err.Error())
+	}
+	wrapped, err := util.AESEncrypt(dataKey, masterKey)
+	if err != nil {
+		return nil, errors.New("wrapping data key with master key: " + err.Error())
+	}
+	if err := writeFileAtomic(filepath.Join(dir, dataKeyFile), wrapped); err != nil {
+		return nil, errors.New("writing data key: " + err.Error())
+	}
+	return dataKey, nil
+}
+
+// readDataKey reads the data key stored in the given directory and unwraps it with the master key.
+func readDataKey(dir string, masterKey []byte) ([]byte, bool, error) {
+	path := filepath.Join(dir, dataKeyFile)
+	wrapped, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, false, nil
+		}
+		return nil, false, errors.New("reading data key '" + path + "': " + err.Error())
+	}
+	dataKey, err := util.AESDecrypt(wrapped, masterKey)
+	if err != nil {
+		return nil, false, errors.New("unwrapping data key '" + path + "' with master key: " + err.Error())
+	}
+	if err := util.ValidateAESKey(dataKey); err != nil {
+		return nil, false, errors.New("validating data key '" + path + "': " + err.Error())
+	}
+	return dataKey, true, nil
+}
+
+// writeFileAtomic writes data to a temporary file in the same directory as path, and then renames it to path,
+// so readers never see a partially-written file.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, dirPerm); err != nil {
+		return errors.New("creating directory '" + dir + "': " + err.Error())
+	}
+	tmp, err := ioutil.TempFile(dir, "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return errors.New("creating temporary file in '" + dir + "': " + err.Error())
+	}
+	tmpName := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return errors.New("writing temporary file '" + tmpName + "': " + err.Error())
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return errors.New("syncing temporary file '" + tmpName + "': " + err.Error())
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return errors.New("closing temporary file '" + tmpName + "': " + err.Error())
+	}
+	if err := os.Chmod(tmpName, filePerm); err != nil {
+		os.Remove(tmpName)
+		return errors.New("setting permissions on '" + tmpName + "': " + err.Error())
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		os.Remove(tmpName)
+		return errors.New("renaming '" + tmpName + "' to '" + path + "': " + err.Error())
+	}
+	return nil
+}
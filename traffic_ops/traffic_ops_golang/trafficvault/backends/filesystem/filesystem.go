@@ -0,0 +1,674 @@
+// Package filesystem provides a TrafficVault implementation which stores all secrets as encrypted files
+// in a local directory tree.
+//
+// Every file is encrypted with a single data key, which is itself stored in the directory wrapped (encrypted)
+// by a master key that never touches the directory. Rotating the master key therefore only requires
+// re-wrapping the data key.
+package filesystem
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/apache/trafficcontrol/lib/go-log"
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/lib/go-tc/tovalidate"
+	"github.com/apache/trafficcontrol/lib/go-util"
+	"github.com/apache/trafficcontrol/traffic_ops/traffic_ops_golang/trafficvault"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+const (
+	fileSystemBackendName = "filesystem"
+
+	dataKeyFile          = "data.key"
+	sslKeysDir           = "ssl"
+	dnssecKeysDir        = "dnssec"
+	urlSigKeysDir        = "url_sig_keys"
+	uriSigningKeysDir    = "uri_signing_keys"
+	encryptedFileSuffix  = ".enc"
+	latestVersion        = "latest"
+	urlSigKeyPrefix      = "url_sig_"
+	urlSigKeySuffix      = ".config"
+	riakSSLBucket        = "ssl"
+	riakDNSSECBucket     = "dnssec"
+	riakURLSigBucket     = "url_sig_keys"
+	riakURISigningBucket = "cdn_uri_sig_keys"
+
+	dirPerm  = 0700
+	filePerm = 0600
+)
+
+type Config struct {
+	Directory         string `json:"directory"`
+	MasterKeyLocation string `json:"master_key_location"`
+}
+
+type FileSystem struct {
+	cfg     Config
+	dataKey []byte
+	// mutex guards the directory tree against concurrent writers within this Traffic Ops instance.
+	mutex sync.RWMutex
+}
+
+// GetDeliveryServiceSSLKeys retrieves the SSL keys of the given version for
+// the delivery service identified by the given xmlID. If version is empty,
+// the implementation should return the latest version.
+func (f *FileSystem) GetDeliveryServiceSSLKeys(xmlID string, version string, tx *sql.Tx, ctx context.Context) (tc.DeliveryServiceSSLKeysV15, bool, error) {
+	if version == "" {
+		version = latestVersion
+	}
+	path, err := f.sslKeyPath(xmlID, version)
+	if err != nil {
+		return tc.DeliveryServiceSSLKeysV15{}, false, err
+	}
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	sslKey := tc.DeliveryServiceSSLKeysV15{}
+	found, err := f.readObject(path, &sslKey)
+	if err != nil || !found {
+		return tc.DeliveryServiceSSLKeysV15{}, false, err
+	}
+	return sslKey, true, nil
+}
+
+// PutDeliveryServiceSSLKeys stores the given SSL keys for a delivery service.
+func (f *FileSystem) PutDeliveryServiceSSLKeys(key tc.DeliveryServiceSSLKeys, tx *sql.Tx, ctx context.Context) error {
+	versionPath, err := f.sslKeyPath(key.DeliveryService, strconv.FormatInt(int64(key.Version), 10))
+	if err != nil {
+		return err
+	}
+	latestPath, err := f.sslKeyPath(key.DeliveryService, latestVersion)
+	if err != nil {
+		return err
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.putSSLKeys(versionPath, latestPath, key)
+}
+
+// putSSLKeys writes the given SSL keys to both their version file and the latest file. Callers must hold
+// the mutex.
+func (f *FileSystem) putSSLKeys(versionPath string, latestPath string, key tc.DeliveryServiceSSLKeys) error {
+	if err := f.writeObject(versionPath, &key); err != nil {
+		return errors.New("writing SSL keys: " + err.Error())
+	}
+	if err := f.writeObject(latestPath, &key); err != nil {
+		return errors.New("writing latest SSL keys: " + err.Error())
+	}
+	return nil
+}
+
+// DeleteDeliveryServiceSSLKeys removes the SSL keys of the given version (or latest
+// if version is empty) for the delivery service identified by the given xmlID.
+func (f *FileSystem) DeleteDeliveryServiceSSLKeys(xmlID string, version string, tx *sql.Tx, ctx context.Context) error {
+	if version == "" {
+		version = latestVersion
+	}
+	path, err := f.sslKeyPath(xmlID, version)
+	if err != nil {
+		return err
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if err := removeFile(path); err != nil {
+		return errors.New("deleting SSL keys: " + err.Error())
+	}
+	removeDirIfEmpty(filepath.Dir(path))
+	return nil
+}
+
+// GetDeliveryServiceSSLKeysVersions retrieves every stored version of the SSL keys
+// for the delivery service identified by the given xmlID, ordered by ascending version.
+func (f *FileSystem) GetDeliveryServiceSSLKeysVersions(xmlID string, tx *sql.Tx, ctx context.Context) ([]tc.DeliveryServiceSSLKeysV15, error) {
+	if err := validateName(xmlID); err != nil {
+		return nil, errors.New("invalid Delivery Service xmlID: " + err.Error())
+	}
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	return f.getSSLKeysVersions(xmlID)
+}
+
+// RollbackDeliveryServiceSSLKeys stores a copy of the SSL keys of the given version
+// as a new latest version for the delivery service identified by the given xmlID.
+func (f *FileSystem) RollbackDeliveryServiceSSLKeys(xmlID string, version string, tx *sql.Tx, ctx context.Context) (tc.DeliveryServiceSSLKeysV15, bool, error) {
+	if err := validateName(xmlID); err != nil {
+		return tc.DeliveryServiceSSLKeysV15{}, false, errors.New("invalid Delivery Service xmlID: " + err.Error())
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	versions, err := f.getSSLKeysVersions(xmlID)
+	if err != nil {
+		return tc.DeliveryServiceSSLKeysV15{}, false, err
+	}
+	key, ok := trafficvault.GetSSLKeysRollback(versions, version)
+	if !ok {
+		return tc.DeliveryServiceSSLKeysV15{}, false, nil
+	}
+	key.DeliveryService = xmlID
+	versionPath, err := f.sslKeyPath(xmlID, strconv.FormatInt(int64(key.Version), 10))
+	if err != nil {
+		return tc.DeliveryServiceSSLKeysV15{}, false, err
+	}
+	latestPath, err := f.sslKeyPath(xmlID, latestVersion)
+	if err != nil {
+		return tc.DeliveryServiceSSLKeysV15{}, false, err
+	}
+	if err := f.putSSLKeys(versionPath, latestPath, key.DeliveryServiceSSLKeys); err != nil {
+		return tc.DeliveryServiceSSLKeysV15{}, false, err
+	}
+	return key, true, nil
+}
+
+// getSSLKeysVersions reads every numbered version of the given Delivery Service's SSL keys, ordered by
+// ascending version. Callers must hold the mutex.
+func (f *FileSystem) getSSLKeysVersions(xmlID string) ([]tc.DeliveryServiceSSLKeysV15, error) {
+	dir := filepath.Join(f.cfg.Directory, sslKeysDir, xmlID)
+	names, err := listDir(dir)
+	if err != nil {
+		return nil, errors.New("Traffic Vault file system: listing '" + dir + "': " + err.Error())
+	}
+	keys := []tc.DeliveryServiceSSLKeysV15{}
+	for _, name := range names {
+		version, err := strconv.ParseInt(strings.TrimSuffix(name, encryptedFileSuffix), 10, 64)
+		if err != nil || !strings.HasSuffix(name, encryptedFileSuffix) {
+			continue // the latest file
+		}
+		key := tc.DeliveryServiceSSLKeysV15{}
+		if _, err := f.readObject(filepath.Join(dir, name), &key); err != nil {
+			return nil, errors.New("reading SSL keys version " + strconv.FormatInt(version, 10) + ": " + err.Error())
+		}
+		key.Version = util.JSONIntStr(version)
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Version < keys[j].Version })
+	return keys, nil
+}
+
+// DeleteOldDeliveryServiceSSLKeys takes a set of existingXMLIDs as input and will remove
+// all SSL keys for delivery services in the CDN identified by the given cdnName that
+// do not contain an xmlID in the given set of existingXMLIDs. This method is called
+// during a snapshot operation in order to delete SSL keys for delivery services that
+// no longer exist.
+func (f *FileSystem) DeleteOldDeliveryServiceSSLKeys(existingXMLIDs map[string]struct{}, cdnName string, tx *sql.Tx, ctx context.Context) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	xmlIDs, err := listDir(filepath.Join(f.cfg.Directory, sslKeysDir))
+	if err != nil {
+		return errors.New("listing SSL keys: " + err.Error())
+	}
+	successes := []string{}
+	failures := []string{}
+	for _, xmlID := range xmlIDs {
+		if _, ok := existingXMLIDs[xmlID]; ok {
+			continue
+		}
+		dsDir := filepath.Join(f.cfg.Directory, sslKeysDir, xmlID)
+		versions, err := listDir(dsDir)
+		if err != nil {
+			log.Errorln("listing Traffic Vault SSL keys for Delivery Service '" + xmlID + "': " + err.Error())
+			failures = append(failures, xmlID)
+			continue
+		}
+		for _, versionFile := range versions {
+			path := filepath.Join(dsDir, versionFile)
+			key := tc.DeliveryServiceSSLKeys{}
+			if found, err := f.readObject(path, &key); err != nil || !found {
+				if err != nil {
+					log.Errorln("reading Traffic Vault SSL keys '" + path + "': " + err.Error())
+				}
+				continue
+			}
+			if key.CDN != cdnName {
+				continue
+			}
+			if err := removeFile(path); err != nil {
+				log.Errorln("deleting Traffic Vault SSL keys for Delivery Service '" + xmlID + "' file '" + path + "': " + err.Error())
+				failures = append(failures, xmlID)
+			} else {
+				log.Infoln("Deleted Traffic Vault SSL keys for delivery service which has been deleted in the database '" + xmlID + "' file '" + path + "'")
+				successes = append(successes, xmlID)
+			}
+		}
+		removeDirIfEmpty(dsDir)
+	}
+	if len(failures) > 0 {
+		return errors.New("successfully deleted Traffic Vault SSL keys for deleted dses [" + strings.Join(successes, ", ") + "], but failed to delete Traffic Vault SSL keys for [" + strings.Join(failures, ", ") + "]; see the error log for details")
+	}
+	return nil
+}
+
+// GetCDNSSLKeys retrieves all the SSL keys for delivery services in the CDN identified
+// by the given cdnName.
+func (f *FileSystem) GetCDNSSLKeys(cdnName string, tx *sql.Tx, ctx context.Context) ([]tc.CDNSSLKey, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	keys := []tc.CDNSSLKey{}
+	xmlIDs, err := listDir(filepath.Join(f.cfg.Directory, sslKeysDir))
+	if err != nil {
+		return keys, errors.New("listing SSL keys: " + err.Error())
+	}
+	for _, xmlID := range xmlIDs {
+		path := filepath.Join(f.cfg.Directory, sslKeysDir, xmlID, latestVersion+encryptedFileSuffix)
+		dsKey := tc.DeliveryServiceSSLKeys{}
+		found, err := f.readObject(path, &dsKey)
+		if err != nil {
+			log.Errorf("couldn't read SSL key '%s': %v", path, err)
+			continue
+		}
+		if !found || dsKey.CDN != cdnName {
+			continue
+		}
+		keys = append(keys, tc.CDNSSLKey{
+			DeliveryService: dsKey.DeliveryService,
+			HostName:        dsKey.Hostname,
+			Certificate: tc.CDNSSLKeyCert{
+				Crt: dsKey.Certificate.Crt,
+				Key: dsKey.Certificate.Key,
+			},
+		})
+	}
+	return keys, nil
+}
+
+// GetDNSSECKeys retrieves all the DNSSEC keys associated with the CDN identified by the
+// given cdnName.
+func (f *FileSystem) GetDNSSECKeys(cdnName string, tx *sql.Tx, ctx context.Context) (tc.DNSSECKeysTrafficVault, bool, error) {
+	path, err := f.objectPath(dnssecKeysDir, cdnName)
+	if err != nil {
+		return tc.DNSSECKeysTrafficVault{}, false, err
+	}
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	dnssecKeys := tc.DNSSECKeysTrafficVault{}
+	found, err := f.readObject(path, &dnssecKeys)
+	if err != nil || !found {
+		return tc.DNSSECKeysTrafficVault{}, false, err
+	}
+	return dnssecKeys, true, nil
+}
+
+// PutDNSSECKeys stores all the DNSSEC keys for the CDN identified by the given cdnName.
+func (f *FileSystem) PutDNSSECKeys(cdnName string, keys tc.DNSSECKeysTrafficVault, tx *sql.Tx, ctx context.Context) error {
+	path, err := f.objectPath(dnssecKeysDir, cdnName)
+	if err != nil {
+		return err
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if err := f.writeObject(path, &keys); err != nil {
+		return errors.New("writing DNSSEC keys: " + err.Error())
+	}
+	return nil
+}
+
+// DeleteDNSSECKeys removes all the DNSSEC keys for the CDN identified by the given cdnName.
+func (f *FileSystem) DeleteDNSSECKeys(cdnName string, tx *sql.Tx, ctx context.Context) error {
+	path, err := f.objectPath(dnssecKeysDir, cdnName)
+	if err != nil {
+		return err
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if err := removeFile(path); err != nil {
+		return errors.New("deleting DNSSEC keys: " + err.Error())
+	}
+	return nil
+}
+
+// GetURLSigKeys retrieves the URL sig keys for the delivery service identified by the
+// given xmlID.
+func (f *FileSystem) GetURLSigKeys(xmlID string, tx *sql.Tx, ctx context.Context) (tc.URLSigKeys, bool, error) {
+	path, err := f.objectPath(urlSigKeysDir, xmlID)
+	if err != nil {
+		return tc.URLSigKeys{}, false, err
+	}
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	urlSigKeys := tc.URLSigKeys{}
+	found, err := f.readObject(path, &urlSigKeys)
+	if err != nil || !found {
+		return tc.URLSigKeys{}, false, err
+	}
+	return urlSigKeys, true, nil
+}
+
+// PutURLSigKeys stores the given URL sig keys for the delivery service identified by
+// the given xmlID.
+func (f *FileSystem) PutURLSigKeys(xmlID string, keys tc.URLSigKeys, tx *sql.Tx, ctx context.Context) error {
+	path, err := f.objectPath(urlSigKeysDir, xmlID)
+	if err != nil {
+		return err
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if err := f.writeObject(path, &keys); err != nil {
+		return errors.New("writing URL Sig keys: " + err.Error())
+	}
+	return nil
+}
+
+// DeleteURLSigKeys deletes the URL sig keys for the delivery service identified
+// by the given xmlID.
+func (f *FileSystem) DeleteURLSigKeys(xmlID string, tx *sql.Tx, ctx context.Context) error {
+	path, err := f.objectPath(urlSigKeysDir, xmlID)
+	if err != nil {
+		return err
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if err := removeFile(path); err != nil {
+		return errors.New("deleting URL Sig keys: " + err.Error())
+	}
+	return nil
+}
+
+// GetURISigningKeys retrieves the URI signing keys (as raw JSON bytes) for the delivery
+// service identified by the given xmlID.
+func (f *FileSystem) GetURISigningKeys(xmlID string, tx *sql.Tx, ctx context.Context) ([]byte, bool, error) {
+	path, err := f.objectPath(uriSigningKeysDir, xmlID)
+	if err != nil {
+		return []byte{}, false, err
+	}
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	return f.readFile(path)
+}
+
+// PutURISigningKeys stores the given URI signing keys (as raw JSON bytes) for the delivery
+// service identified by the given xmlID.
+func (f *FileSystem) PutURISigningKeys(xmlID string, keysJson []byte, tx *sql.Tx, ctx context.Context) error {
+	path, err := f.objectPath(uriSigningKeysDir, xmlID)
+	if err != nil {
+		return err
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if err := f.writeFile(path, keysJson); err != nil {
+		return errors.New("writing URI signing keys: " + err.Error())
+	}
+	return nil
+}
+
+// DeleteURISigningKeys removes the URI signing keys for the delivery service identified by
+// the given xmlID.
+func (f *FileSystem) DeleteURISigningKeys(xmlID string, tx *sql.Tx, ctx context.Context) error {
+	path, err := f.objectPath(uriSigningKeysDir, xmlID)
+	if err != nil {
+		return err
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if err := removeFile(path); err != nil {
+		return errors.New("deleting URI signing keys: " + err.Error())
+	}
+	return nil
+}
+
+// Ping checks that the Traffic Vault directory is still accessible and that its data key can still be
+// unwrapped with the configured master key.
+func (f *FileSystem) Ping(tx *sql.Tx, ctx context.Context) (tc.TrafficVaultPing, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	fi, err := os.Stat(f.cfg.Directory)
+	if err != nil {
+		return tc.TrafficVaultPing{}, errors.New("Traffic Vault file system: stat directory: " + err.Error())
+	}
+	if !fi.IsDir() {
+		return tc.TrafficVaultPing{}, errors.New("Traffic Vault file system: '" + f.cfg.Directory + "' is not a directory")
+	}
+	masterKey, err := readMasterKey(f.cfg.MasterKeyLocation)
+	if err != nil {
+		return tc.TrafficVaultPing{}, errors.New("Traffic Vault file system: reading master key: " + err.Error())
+	}
+	dataKey, found, err := readDataKey(f.cfg.Directory, masterKey)
+	if err != nil {
+		return tc.TrafficVaultPing{}, errors.New("Traffic Vault file system: " + err.Error())
+	}
+	if !found || !bytes.Equal(dataKey, f.dataKey) {
+		return tc.TrafficVaultPing{}, errors.New("Traffic Vault file system: data key in '" + f.cfg.Directory + "' is missing or has changed since startup")
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "localhost"
+	}
+	return tc.TrafficVaultPing{Status: "OK", Server: hostname + ":" + f.cfg.Directory}, nil
+}
+
+// GetBucketKey returns the raw (decrypted) bytes stored under the given Riak-style bucket and key, in
+// order to keep the deprecated bucket/key API routes working with this backend.
+func (f *FileSystem) GetBucketKey(bucket string, key string, tx *sql.Tx) ([]byte, bool, error) {
+	var path string
+	var err error
+	switch bucket {
+	case riakSSLBucket:
+		i := strings.LastIndex(key, "-")
+		if i < 1 || i == len(key)-1 {
+			return nil, false, nil
+		}
+		path, err = f.sslKeyPath(key[:i], key[i+1:])
+	case riakDNSSECBucket:
+		path, err = f.objectPath(dnssecKeysDir, key)
+	case riakURLSigBucket:
+		if !strings.HasPrefix(key, urlSigKeyPrefix) || !strings.HasSuffix(key, urlSigKeySuffix) {
+			return nil, false, nil
+		}
+		path, err = f.objectPath(urlSigKeysDir, strings.TrimSuffix(strings.TrimPrefix(key, urlSigKeyPrefix), urlSigKeySuffix))
+	case riakURISigningBucket:
+		path, err = f.objectPath(uriSigningKeysDir, key)
+	default:
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	return f.readFile(path)
+}
+
+// ReEncrypt is not supported by the file system backend. Its files are encrypted with a data key which is
+// itself wrapped by the master key, so rotating the master key only requires re-wrapping the data key.
+func (f *FileSystem) ReEncrypt(progress func(done int, total int), ctx context.Context) error {
+	return errors.New("re-encryption is not supported by the filesystem Traffic Vault backend")
+}
+
+// sslKeyPath returns the path of the file holding the given version of a Delivery Service's SSL keys.
+func (f *FileSystem) sslKeyPath(xmlID string, version string) (string, error) {
+	if err := validateName(xmlID); err != nil {
+		return "", errors.New("invalid Delivery Service xmlID: " + err.Error())
+	}
+	if err := validateName(version); err != nil {
+		return "", errors.New("invalid SSL key version: " + err.Error())
+	}
+	return filepath.Join(f.cfg.Directory, sslKeysDir, xmlID, version+encryptedFileSuffix), nil
+}
+
+// objectPath returns the path of the file holding the object with the given name in the given directory.
+func (f *FileSystem) objectPath(dir string, name string) (string, error) {
+	if err := validateName(name); err != nil {
+		return "", errors.New("invalid name: " + err.Error())
+	}
+	return filepath.Join(f.cfg.Directory, dir, name+encryptedFileSuffix), nil
+}
+
+// readFile reads and decrypts the file at the given path. Callers must hold the mutex.
+func (f *FileSystem) readFile(path string) ([]byte, bool, error) {
+	encrypted, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, false, nil
+		}
+		return nil, false, errors.New("Traffic Vault file system: reading '" + path + "': " + err.Error())
+	}
+	data, err := util.AESDecrypt(encrypted, f.dataKey)
+	if err != nil {
+		return nil, false, errors.New("Traffic Vault file system: decrypting '" + path + "': " + err.Error())
+	}
+	return data, true, nil
+}
+
+// writeFile encrypts and writes the given data to the given path. Callers must hold the mutex.
+func (f *FileSystem) writeFile(path string, data []byte) error {
+	encrypted, err := util.AESEncrypt(data, f.dataKey)
+	if err != nil {
+		return errors.New("encrypting: " + err.Error())
+	}
+	return writeFileAtomic(path, encrypted)
+}
+
+// readObject reads, decrypts, and unmarshals the JSON object at the given path into obj. Callers must hold
+// the mutex.
+func (f *FileSystem) readObject(path string, obj interface{}) (bool, error) {
+	data, found, err := f.readFile(path)
+	if err != nil || !found {
+		return false, err
+	}
+	if err := json.Unmarshal(data, obj); err != nil {
+		return false, errors.New("unmarshalling '" + path + "': " + err.Error())
+	}
+	return true, nil
+}
+
+// writeObject marshals obj as JSON, then encrypts and writes it to the given path. Callers must hold the
+// mutex.
+func (f *FileSystem) writeObject(path string, obj interface{}) error {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return errors.New("marshalling: " + err.Error())
+	}
+	return f.writeFile(path, data)
+}
+
+// validateName ensures that name can be safely used as a single path component.
+func validateName(name string) error {
+	if name == "" {
+		return errors.New("name is empty")
+	}
+	if name == "." || name == ".." || strings.ContainsAny(name, "/\\\x00") {
+		return errors.New("name '" + name + "' is not a valid file name")
+	}
+	return nil
+}
+
+// listDir returns the names of the entries in dir, skipping temporary files. A nonexistent directory is
+// treated as empty.
+func listDir(dir string) ([]string, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	names := make([]string, 0, len(infos))
+	for _, info := range infos {
+		if strings.HasPrefix(info.Name(), ".") {
+			continue // skip temporary files
+		}
+		names = append(names, info.Name())
+	}
+	return names, nil
+}
+
+// removeFile removes the file at path. A nonexistent file is not an error.
+func removeFile(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// removeDirIfEmpty removes dir if it has no entries left, logging any unexpected error.
+func removeDirIfEmpty(dir string) {
+	names, err := ioutil.ReadDir(dir)
+	if err != nil || len(names) > 0 {
+		return
+	}
+	if err := os.Remove(dir); err != nil && !os.IsNotExist(err) {
+		log.Errorln("Traffic Vault file system: removing empty directory '" + dir + "': " + err.Error())
+	}
+}
+
+func init() {
+	trafficvault.AddBackend(fileSystemBackendName, fileSystemLoad)
+}
+
+func fileSystemLoad(b json.RawMessage) (trafficvault.TrafficVault, error) {
+	fsCfg := Config{}
+	if err := json.Unmarshal(b, &fsCfg); err != nil {
+		return nil, errors.New("unmarshalling file system config: " + err.Error())
+	}
+	if err := validateConfig(fsCfg); err != nil {
+		return nil, errors.New("validating file system config: " + err.Error())
+	}
+	if err := os.MkdirAll(fsCfg.Directory, dirPerm); err != nil {
+		return nil, errors.New("creating Traffic Vault directory '" + fsCfg.Directory + "': " + err.Error())
+	}
+	masterKey, err := readMasterKey(fsCfg.MasterKeyLocation)
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := loadDataKey(fsCfg.Directory, masterKey)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSystem{cfg: fsCfg, dataKey: dataKey}, nil
+}
+
+func validateConfig(cfg Config) error {
+	errs := tovalidate.ToErrors(validation.Errors{
+		"directory":           validation.Validate(cfg.Directory, validation.Required),
+		"master_key_location": validation.Validate(cfg.MasterKeyLocation, validation.Required),
+	})
+	if len(errs) == 0 {
+		return nil
+	}
+	return util.JoinErrs(errs)
+}
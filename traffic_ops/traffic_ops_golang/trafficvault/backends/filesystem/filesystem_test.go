@@ -0,0 +1,221 @@
+package filesystem
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/lib/go-util"
+	"github.com/apache/trafficcontrol/traffic_ops/traffic_ops_golang/trafficvault"
+)
+
+func loadTestBackend(t *testing.T, dir string) trafficvault.TrafficVault {
+	keyFile := filepath.Join(dir, "master.key")
+	if _, err := ioutil.ReadFile(keyFile); err != nil {
+		key := base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+		if err := ioutil.WriteFile(keyFile, []byte(key+"\n"), 0600); err != nil {
+			t.Fatalf("writing master key: %v", err)
+		}
+	}
+	cfg, err := json.Marshal(Config{Directory: filepath.Join(dir, "vault"), MasterKeyLocation: keyFile})
+	if err != nil {
+		t.Fatalf("marshalling config: %v", err)
+	}
+	tv, err := trafficvault.GetBackend(fileSystemBackendName, cfg)
+	if err != nil {
+		t.Fatalf("loading file system backend - expected: nil error, actual: %v", err)
+	}
+	return tv
+}
+
+func TestBadConfig(t *testing.T) {
+	testCases := map[string]string{
+		"missing directory":           `{"master_key_location": "/tmp/foo.key"}`,
+		"missing master_key_location": `{"directory": "/tmp/foo"}`,
+		"invalid JSON":                `asdf`,
+		"nonexistent master key":      `{"directory": "` + t.TempDir() + `", "master_key_location": "/nonexistent/master.key"}`,
+	}
+	for reason, cfg := range testCases {
+		if _, err := fileSystemLoad([]byte(cfg)); err == nil {
+			t.Errorf("loading bad file system config - expected error because %s, actual: no error", reason)
+		}
+	}
+}
+
+func TestSSLKeys(t *testing.T) {
+	dir := t.TempDir()
+	tv := loadTestBackend(t, dir)
+	ctx := context.Background()
+
+	key := tc.DeliveryServiceSSLKeys{
+		CDN:             "cdn1",
+		DeliveryService: "ds1",
+		Hostname:        "*.ds1.example.test",
+		Key:             "ds1",
+		Version:         2,
+		Certificate:     tc.DeliveryServiceSSLKeysCertificate{Crt: "crt", Key: "key", CSR: "csr"},
+	}
+	if err := tv.PutDeliveryServiceSSLKeys(key, nil, ctx); err != nil {
+		t.Fatalf("putting SSL keys: %v", err)
+	}
+
+	for _, version := range []string{"", "latest", "2"} {
+		got, ok, err := tv.GetDeliveryServiceSSLKeys("ds1", version, nil, ctx)
+		if err != nil || !ok {
+			t.Fatalf("getting SSL keys version '%s' - expected: found, nil error, actual: %t, %v", version, ok, err)
+		}
+		if got.Certificate.Crt != "crt" || got.CDN != "cdn1" {
+			t.Errorf("getting SSL keys version '%s' - expected: original keys, actual: %+v", version, got)
+		}
+	}
+	if _, ok, err := tv.GetDeliveryServiceSSLKeys("ds1", "1", nil, ctx); err != nil || ok {
+		t.Errorf("getting nonexistent SSL keys version - expected: not found, nil error, actual: %t, %v", ok, err)
+	}
+
+	// keys survive a restart with the same master key
+	tv = loadTestBackend(t, dir)
+	cdnKeys, err := tv.GetCDNSSLKeys("cdn1", nil, ctx)
+	if err != nil {
+		t.Fatalf("getting CDN SSL keys: %v", err)
+	}
+	expected := []tc.CDNSSLKey{{DeliveryService: "ds1", HostName: "*.ds1.example.test", Certificate: tc.CDNSSLKeyCert{Crt: "crt", Key: "key"}}}
+	if !reflect.DeepEqual(cdnKeys, expected) {
+		t.Errorf("getting CDN SSL keys - expected: %+v, actual: %+v", expected, cdnKeys)
+	}
+
+	raw, ok, err := tv.GetBucketKey("ssl", "ds1-latest", nil)
+	if err != nil || !ok || !bytes.Contains(raw, []byte(`"deliveryservice":"ds1"`)) {
+		t.Errorf("getting bucket key ssl/ds1-latest - expected: found JSON, actual: %t, %v, %s", ok, err, raw)
+	}
+
+	if err := tv.DeleteOldDeliveryServiceSSLKeys(map[string]struct{}{"ds1": {}}, "cdn1", nil, ctx); err != nil {
+		t.Fatalf("deleting old SSL keys: %v", err)
+	}
+	if _, ok, _ := tv.GetDeliveryServiceSSLKeys("ds1", "", nil, ctx); !ok {
+		t.Error("deleting old SSL keys - expected: existing Delivery Service keys kept, actual: deleted")
+	}
+	if err := tv.DeleteOldDeliveryServiceSSLKeys(map[string]struct{}{}, "cdn1", nil, ctx); err != nil {
+		t.Fatalf("deleting old SSL keys: %v", err)
+	}
+	if _, ok, _ := tv.GetDeliveryServiceSSLKeys("ds1", "2", nil, ctx); ok {
+		t.Error("deleting old SSL keys - expected: removed Delivery Service keys deleted, actual: found")
+	}
+}
+
+func TestSSLKeysVersions(t *testing.T) {
+	tv := loadTestBackend(t, t.TempDir())
+	ctx := context.Background()
+
+	for _, version := range []int{1, 2} {
+		key := tc.DeliveryServiceSSLKeys{
+			CDN:             "cdn1",
+			DeliveryService: "ds1",
+			Version:         util.JSONIntStr(version),
+			Certificate:     tc.DeliveryServiceSSLKeysCertificate{Crt: "crt" + strconv.Itoa(version)},
+		}
+		if err := tv.PutDeliveryServiceSSLKeys(key, nil, ctx); err != nil {
+			t.Fatalf("putting SSL keys version %d: %v", version, err)
+		}
+	}
+
+	versions, err := tv.GetDeliveryServiceSSLKeysVersions("ds1", nil, ctx)
+	if err != nil {
+		t.Fatalf("getting SSL keys versions: %v", err)
+	}
+	if len(versions) != 2 || versions[0].Version != 1 || versions[1].Version != 2 {
+		t.Fatalf("getting SSL keys versions - expected: versions 1 and 2, actual: %+v", versions)
+	}
+
+	if _, ok, err := tv.RollbackDeliveryServiceSSLKeys("ds1", "7", nil, ctx); err != nil || ok {
+		t.Errorf("rolling back to a nonexistent version - expected: not found, nil error, actual: %t, %v", ok, err)
+	}
+	rolledBack, ok, err := tv.RollbackDeliveryServiceSSLKeys("ds1", "1", nil, ctx)
+	if err != nil || !ok {
+		t.Fatalf("rolling back SSL keys - expected: found, nil error, actual: %t, %v", ok, err)
+	}
+	if rolledBack.Version != 3 || rolledBack.Certificate.Crt != "crt1" {
+		t.Errorf("rolling back SSL keys - expected: version 3 with version 1 certificate, actual: %+v", rolledBack)
+	}
+	latest, _, err := tv.GetDeliveryServiceSSLKeys("ds1", "", nil, ctx)
+	if err != nil || latest.Version != 3 || latest.Certificate.Crt != "crt1" {
+		t.Errorf("getting latest SSL keys after rollback - expected: version 3 with version 1 certificate, actual: %+v, %v", latest, err)
+	}
+	if versions, _ := tv.GetDeliveryServiceSSLKeysVersions("ds1", nil, ctx); len(versions) != 3 {
+		t.Errorf("getting SSL keys versions after rollback - expected: 3 versions, actual: %+v", versions)
+	}
+}
+
+func TestOtherKeys(t *testing.T) {
+	tv := loadTestBackend(t, t.TempDir())
+	ctx := context.Background()
+
+	urlKeys := tc.URLSigKeys{"key0": "abc"}
+	if err := tv.PutURLSigKeys("ds1", urlKeys, nil, ctx); err != nil {
+		t.Fatalf("putting URL sig keys: %v", err)
+	}
+	if got, ok, err := tv.GetURLSigKeys("ds1", nil, ctx); err != nil || !ok || !reflect.DeepEqual(got, urlKeys) {
+		t.Errorf("getting URL sig keys - expected: %v, actual: %v, %t, %v", urlKeys, got, ok, err)
+	}
+	if _, ok, err := tv.GetBucketKey("url_sig_keys", "url_sig_ds1.config", nil); err != nil || !ok {
+		t.Errorf("getting bucket key url_sig_keys/url_sig_ds1.config - expected: found, actual: %t, %v", ok, err)
+	}
+	if err := tv.DeleteURLSigKeys("ds1", nil, ctx); err != nil {
+		t.Fatalf("deleting URL sig keys: %v", err)
+	}
+	if _, ok, _ := tv.GetURLSigKeys("ds1", nil, ctx); ok {
+		t.Error("getting deleted URL sig keys - expected: not found, actual: found")
+	}
+
+	uriKeys := []byte(`{"ds1":{"renewal_kid":"a","keys":[]}}`)
+	if err := tv.PutURISigningKeys("ds1", uriKeys, nil, ctx); err != nil {
+		t.Fatalf("putting URI signing keys: %v", err)
+	}
+	if got, ok, err := tv.GetURISigningKeys("ds1", nil, ctx); err != nil || !ok || !bytes.Equal(got, uriKeys) {
+		t.Errorf("getting URI signing keys - expected: %s, actual: %s, %t, %v", uriKeys, got, ok, err)
+	}
+
+	dnssec := tc.DNSSECKeysTrafficVault{"cdn1": tc.DNSSECKeySetV11{ZSK: []tc.DNSSECKeyV11{{Name: "cdn1.", TTLSeconds: 60}}}}
+	if err := tv.PutDNSSECKeys("cdn1", dnssec, nil, ctx); err != nil {
+		t.Fatalf("putting DNSSEC keys: %v", err)
+	}
+	if got, ok, err := tv.GetDNSSECKeys("cdn1", nil, ctx); err != nil || !ok || !reflect.DeepEqual(got, dnssec) {
+		t.Errorf("getting DNSSEC keys - expected: %v, actual: %v, %t, %v", dnssec, got, ok, err)
+	}
+	if err := tv.DeleteDNSSECKeys("cdn1", nil, ctx); err != nil {
+		t.Fatalf("deleting DNSSEC keys: %v", err)
+	}
+
+	if err := tv.PutURLSigKeys("../escape", urlKeys, nil, ctx); err == nil {
+		t.Error("putting URL sig keys with a path in the xmlID - expected: error, actual: nil")
+	}
+
+	if ping, err := tv.Ping(nil, ctx); err != nil || ping.Status != "OK" {
+		t.Errorf("pinging - expected: OK, actual: %+v, %v", ping, err)
+	}
+}
@@ -26,9 +26,89 @@ import (
 	"io/ioutil"
 	"time"
 
+	"github.com/apache/trafficcontrol/lib/go-util"
 	"github.com/apache/trafficcontrol/traffic_ops/traffic_ops_golang/trafficvault/backends/postgres/hashicorpvault"
 )
 
+// keyring holds the AES keys used to encrypt and decrypt the data stored in Traffic Vault. New data is always
+// encrypted with the current key, and each stored row is tagged with the ID of the key that encrypted it. Rows
+// may be decrypted with any key in the keyring, which allows the current key to be rotated while rows encrypted
+// with previous keys are re-encrypted in the background.
+type keyring struct {
+	currentID string
+	keys      map[string][]byte
+	// ids holds the IDs of all keys, with the current key first, in the order they are tried when decrypting
+	// a row whose key ID is not in the keyring (e.g. rows stored before key IDs were recorded).
+	ids []string
+}
+
+// newKeyring creates a keyring from the given keys (by ID), using the key with the given ID to encrypt new data.
+func newKeyring(currentID string, keys map[string][]byte) (*keyring, error) {
+	if _, ok := keys[currentID]; !ok {
+		return nil, errors.New("current key ID '" + currentID + "' is not in the keyring")
+	}
+	k := &keyring{currentID: currentID, keys: keys, ids: []string{currentID}}
+	for id := range keys {
+		if id != currentID {
+			k.ids = append(k.ids, id)
+		}
+	}
+	return k, nil
+}
+
+// encrypt encrypts the given data with the current key, returning the encrypted data and the ID of the key.
+func (k *keyring) encrypt(data []byte) ([]byte, string, error) {
+	encrypted, err := util.AESEncrypt(data, k.keys[k.currentID])
+	if err != nil {
+		return nil, "", err
+	}
+	return encrypted, k.currentID, nil
+}
+
+// decrypt decrypts the given data, which was encrypted with the key with the given ID. If that key is not in
+// the keyring, every key is tried in turn.
+func (k *keyring) decrypt(data []byte, keyID string) ([]byte, error) {
+	if key, ok := k.keys[keyID]; ok {
+		return util.AESDecrypt(data, key)
+	}
+	for _, id := range k.ids {
+		if decrypted, err := util.AESDecrypt(data, k.keys[id]); err == nil {
+			return decrypted, nil
+		}
+	}
+	return nil, errors.New("no key in the keyring could decrypt data encrypted with key ID '" + keyID + "'")
+}
+
+// readKeyring reads the AES keys used for encryption/decryption, based on the given configuration. A single
+// key configured with aes_key_location or hashicorp_vault has the empty key ID.
+func readKeyring(cfg Config) (*keyring, error) {
+	if cfg.AesKeyring == nil {
+		key, err := readKey(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return newKeyring("", map[string][]byte{"": key})
+	}
+	keys := make(map[string][]byte, len(cfg.AesKeyring.Keys))
+	for _, ringKey := range cfg.AesKeyring.Keys {
+		key, err := readKeyFile(ringKey.Location)
+		if err != nil {
+			return nil, errors.New("reading keyring key '" + ringKey.ID + "': " + err.Error())
+		}
+		keys[ringKey.ID] = key
+	}
+	return newKeyring(cfg.AesKeyring.CurrentKeyID, keys)
+}
+
+// readKeyFile reads the base64-encoded AES key in the file at the given location.
+func readKeyFile(location string) ([]byte, error) {
+	keyBase64, err := ioutil.ReadFile(location)
+	if err != nil {
+		return nil, errors.New("reading file '" + location + "':" + err.Error())
+	}
+	return decodeKey(string(keyBase64))
+}
+
 // readKey reads the AES key (encoded in base64) used for encryption/decryption from either an on-disk file
 // or from HashiCorp Vault (based on the given configuration).
 func readKey(cfg Config) ([]byte, error) {
@@ -59,6 +139,11 @@ func readKey(cfg Config) ([]byte, error) {
 		keyBase64 = key
 	}
 
+	return decodeKey(keyBase64)
+}
+
+// decodeKey decodes the given base64-encoded AES key and verifies that it can be used as an AES key.
+func decodeKey(keyBase64 string) ([]byte, error) {
 	key, err := base64.StdEncoding.DecodeString(keyBase64)
 	if err != nil {
 		return []byte{}, errors.New("AES key cannot be decoded from base64")
@@ -0,0 +1,137 @@
+package postgres
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/apache/trafficcontrol/lib/go-util"
+)
+
+func makeTestKey(t *testing.T) []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatalf("generating AES key: %s", err.Error())
+	}
+	return key
+}
+
+func TestKeyring(t *testing.T) {
+	oldKey := makeTestKey(t)
+	newKey := makeTestKey(t)
+	if _, err := newKeyring("new", map[string][]byte{"old": oldKey}); err == nil {
+		t.Error("expected an error creating a keyring whose current key is missing, actual: nil")
+	}
+	ring, err := newKeyring("new", map[string][]byte{"old": oldKey, "new": newKey})
+	if err != nil {
+		t.Fatalf("creating keyring: %s", err.Error())
+	}
+
+	data := []byte("some Traffic Vault data")
+	encrypted, keyID, err := ring.encrypt(data)
+	if err != nil {
+		t.Fatalf("encrypting: %s", err.Error())
+	}
+	if keyID != "new" {
+		t.Errorf("expected data to be encrypted with key 'new', actual: '%s'", keyID)
+	}
+	if decrypted, err := ring.decrypt(encrypted, keyID); err != nil {
+		t.Errorf("decrypting with the current key: %s", err.Error())
+	} else if string(decrypted) != string(data) {
+		t.Errorf("expected decrypted data '%s', actual: '%s'", data, decrypted)
+	}
+
+	oldEncrypted, err := util.AESEncrypt(data, oldKey)
+	if err != nil {
+		t.Fatalf("encrypting with the old key: %s", err.Error())
+	}
+	if decrypted, err := ring.decrypt(oldEncrypted, "old"); err != nil {
+		t.Errorf("decrypting with a previous key: %s", err.Error())
+	} else if string(decrypted) != string(data) {
+		t.Errorf("expected decrypted data '%s', actual: '%s'", data, decrypted)
+	}
+	// rows stored before key IDs were recorded have an empty key ID
+	if decrypted, err := ring.decrypt(oldEncrypted, ""); err != nil {
+		t.Errorf("decrypting data with an unknown key ID: %s", err.Error())
+	} else if string(decrypted) != string(data) {
+		t.Errorf("expected decrypted data '%s', actual: '%s'", data, decrypted)
+	}
+
+	unknownEncrypted, err := util.AESEncrypt(data, makeTestKey(t))
+	if err != nil {
+		t.Fatalf("encrypting with an unknown key: %s", err.Error())
+	}
+	if _, err := ring.decrypt(unknownEncrypted, ""); err == nil {
+		t.Error("expected an error decrypting data encrypted with a key that is not in the keyring, actual: nil")
+	}
+}
+
+func TestValidateConfigKeyring(t *testing.T) {
+	validCfg := func() Config {
+		return Config{
+			DBName:   "traffic_vault",
+			Hostname: "localhost",
+			User:     "traffic_vault",
+			Password: "twelve",
+			Port:     5432,
+			AesKeyring: &AesKeyring{
+				CurrentKeyID: "2021-07",
+				Keys: []AesKeyringKey{
+					{ID: "2021-01", Location: "/opt/traffic_ops/app/conf/aes-2021-01.key"},
+					{ID: "2021-07", Location: "/opt/traffic_ops/app/conf/aes-2021-07.key"},
+				},
+			},
+		}
+	}
+	if err := validateConfig(validCfg()); err != nil {
+		t.Errorf("expected valid keyring config, actual error: %s", err.Error())
+	}
+
+	cfg := validCfg()
+	cfg.AesKeyLocation = "/opt/traffic_ops/app/conf/aes.key"
+	if err := validateConfig(cfg); err == nil {
+		t.Error("expected an error when both aes_key_location and aes_keyring are set, actual: nil")
+	}
+
+	cfg = validCfg()
+	cfg.AesKeyring.CurrentKeyID = "2022-01"
+	if err := validateConfig(cfg); err == nil {
+		t.Error("expected an error when the current key ID is not in the keyring, actual: nil")
+	}
+
+	cfg = validCfg()
+	cfg.AesKeyring.Keys[0].ID = "2021-07"
+	if err := validateConfig(cfg); err == nil {
+		t.Error("expected an error when key IDs are not unique, actual: nil")
+	}
+
+	cfg = validCfg()
+	cfg.AesKeyring.Keys[1].Location = ""
+	if err := validateConfig(cfg); err == nil {
+		t.Error("expected an error when a key has no location, actual: nil")
+	}
+
+	cfg = validCfg()
+	cfg.AesKeyring.Keys = nil
+	if err := validateConfig(cfg); err == nil {
+		t.Error("expected an error when the keyring has no keys, actual: nil")
+	}
+}
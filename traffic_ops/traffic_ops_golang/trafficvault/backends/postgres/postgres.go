@@ -50,7 +50,8 @@ func (e Error) Error() string {
 const (
 	notImplementedErr = Error("this Traffic Vault functionality is not implemented for the postgres backend")
 
-	postgresBackendName = "postgres"
+	// PostgresBackendName is the name of the PostgreSQL Traffic Vault backend.
+	PostgresBackendName = "postgres"
 
 	defaultMaxIdleConnections     = 10 // if this is higher than MaxDBConnections it will be automatically adjusted below it by the db/sql library
 	defaultConnMaxLifetimeSeconds = 60
@@ -74,9 +75,23 @@ type Config struct {
 	ConnMaxLifetimeSeconds int             `json:"conn_max_lifetime_seconds"`
 	QueryTimeoutSeconds    int             `json:"query_timeout_seconds"`
 	AesKeyLocation         string          `json:"aes_key_location"`
+	AesKeyring             *AesKeyring     `json:"aes_keyring"`
 	HashiCorpVault         *HashiCorpVault `json:"hashicorp_vault"`
 }
 
+// AesKeyring configures multiple AES keys, identified by IDs, so that the key used to encrypt Traffic Vault
+// data can be rotated without downtime. Data is encrypted with the key identified by CurrentKeyID and can be
+// decrypted with any key in Keys.
+type AesKeyring struct {
+	CurrentKeyID string          `json:"current_key_id"`
+	Keys         []AesKeyringKey `json:"keys"`
+}
+
+type AesKeyringKey struct {
+	ID       string `json:"id"`
+	Location string `json:"location"`
+}
+
 type HashiCorpVault struct {
 	Address    string `json:"address"`
 	RoleID     string `json:"role_id"`
@@ -88,9 +103,9 @@ type HashiCorpVault struct {
 }
 
 type Postgres struct {
-	cfg    Config
-	db     *sqlx.DB
-	aesKey []byte
+	cfg  Config
+	db   *sqlx.DB
+	keys *keyring
 }
 
 func checkErrWithContext(prefix string, err error, ctxErr error) error {
@@ -130,11 +145,12 @@ func (p *Postgres) GetDeliveryServiceSSLKeys(xmlID string, version string, tx *s
 	}
 	defer p.commitTransaction(tvTx, dbCtx, cancelFunc)
 	var encryptedSslKeys []byte
-	query := "SELECT data FROM sslkey WHERE deliveryservice=$1 AND version=$2"
+	var keyID string
+	query := "SELECT data, key_id FROM sslkey WHERE deliveryservice=$1 AND version=$2"
 	if version == "" {
 		version = "latest"
 	}
-	err = tvTx.QueryRow(query, xmlID, version).Scan(&encryptedSslKeys)
+	err = tvTx.QueryRow(query, xmlID, version).Scan(&encryptedSslKeys, &keyID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return tc.DeliveryServiceSSLKeysV15{}, false, nil
@@ -143,7 +159,7 @@ func (p *Postgres) GetDeliveryServiceSSLKeys(xmlID string, version string, tx *s
 		return tc.DeliveryServiceSSLKeysV15{}, false, e
 	}
 
-	jsonKeys, err := util.AESDecrypt(encryptedSslKeys, p.aesKey)
+	jsonKeys, err := p.keys.decrypt(encryptedSslKeys, keyID)
 	if err != nil {
 		return tc.DeliveryServiceSSLKeysV15{}, false, err
 	}
@@ -163,6 +179,12 @@ func (p *Postgres) PutDeliveryServiceSSLKeys(key tc.DeliveryServiceSSLKeys, tx *
 		return err
 	}
 	defer p.commitTransaction(tvTx, dbCtx, cancelFunc)
+	return p.putSSLKeys(tvTx, key, ctx)
+}
+
+// putSSLKeys stores the given SSL keys as both their own version and the latest
+// version, using the given Traffic Vault transaction.
+func (p *Postgres) putSSLKeys(tvTx *sqlx.Tx, key tc.DeliveryServiceSSLKeys, ctx context.Context) error {
 	keyJSON, err := json.Marshal(&key)
 	if err != nil {
 		return errors.New("marshalling keys: " + err.Error())
@@ -176,13 +198,13 @@ func (p *Postgres) PutDeliveryServiceSSLKeys(key tc.DeliveryServiceSSLKeys, tx *
 		return e
 	}
 
-	encryptedKey, err := util.AESEncrypt(keyJSON, p.aesKey)
+	encryptedKey, keyID, err := p.keys.encrypt(keyJSON)
 	if err != nil {
 		return errors.New("encrypting keys: " + err.Error())
 	}
 
 	// insert the new ssl keys now
-	res, err := tvTx.Exec("INSERT INTO sslkey (deliveryservice, data, cdn, version) VALUES ($1, $2, $3, $4), ($5, $6, $7, $8)", key.DeliveryService, encryptedKey, key.CDN, strconv.FormatInt(int64(key.Version), 10), key.DeliveryService, encryptedKey, key.CDN, latestVersion)
+	res, err := tvTx.Exec("INSERT INTO sslkey (deliveryservice, data, cdn, version, key_id) VALUES ($1, $2, $3, $4, $5), ($6, $7, $8, $9, $10)", key.DeliveryService, encryptedKey, key.CDN, strconv.FormatInt(int64(key.Version), 10), keyID, key.DeliveryService, encryptedKey, key.CDN, latestVersion, keyID)
 	if err != nil {
 		e := checkErrWithContext("Traffic Vault PostgreSQL: executing INSERT SSL Key query", err, ctx.Err())
 		return e
@@ -215,6 +237,40 @@ func (p *Postgres) DeleteDeliveryServiceSSLKeys(xmlID string, version string, tx
 	return nil
 }
 
+// GetDeliveryServiceSSLKeysVersions retrieves every stored version of the SSL keys
+// for the delivery service identified by the given xmlID, ordered by ascending version.
+func (p *Postgres) GetDeliveryServiceSSLKeysVersions(xmlID string, tx *sql.Tx, ctx context.Context) ([]tc.DeliveryServiceSSLKeysV15, error) {
+	tvTx, dbCtx, cancelFunc, err := p.beginTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer p.commitTransaction(tvTx, dbCtx, cancelFunc)
+	return getSSLKeysVersions(xmlID, tvTx, ctx, p.keys)
+}
+
+// RollbackDeliveryServiceSSLKeys stores a copy of the SSL keys of the given version
+// as a new latest version for the delivery service identified by the given xmlID.
+func (p *Postgres) RollbackDeliveryServiceSSLKeys(xmlID string, version string, tx *sql.Tx, ctx context.Context) (tc.DeliveryServiceSSLKeysV15, bool, error) {
+	tvTx, dbCtx, cancelFunc, err := p.beginTransaction(ctx)
+	if err != nil {
+		return tc.DeliveryServiceSSLKeysV15{}, false, err
+	}
+	defer p.commitTransaction(tvTx, dbCtx, cancelFunc)
+	versions, err := getSSLKeysVersions(xmlID, tvTx, ctx, p.keys)
+	if err != nil {
+		return tc.DeliveryServiceSSLKeysV15{}, false, err
+	}
+	key, ok := trafficvault.GetSSLKeysRollback(versions, version)
+	if !ok {
+		return tc.DeliveryServiceSSLKeysV15{}, false, nil
+	}
+	key.DeliveryService = xmlID
+	if err := p.putSSLKeys(tvTx, key.DeliveryServiceSSLKeys, ctx); err != nil {
+		return tc.DeliveryServiceSSLKeysV15{}, false, err
+	}
+	return key, true, nil
+}
+
 // DeleteOldDeliveryServiceSSLKeys takes a set of existingXMLIDs as input and will remove
 // all SSL keys for delivery services in the CDN identified by the given cdnName that
 // do not contain an xmlID in the given set of existingXMLIDs. This method is called
@@ -254,7 +310,7 @@ func (p *Postgres) GetCDNSSLKeys(cdnName string, tx *sql.Tx, ctx context.Context
 	}
 	defer p.commitTransaction(tvTx, dbCtx, cancelFunc)
 
-	rows, err := tvTx.Query("SELECT data from sslkey WHERE cdn=$1 AND version=$2", cdnName, latestVersion)
+	rows, err := tvTx.Query("SELECT data, key_id from sslkey WHERE cdn=$1 AND version=$2", cdnName, latestVersion)
 	if err != nil {
 		e := checkErrWithContext("Traffic Vault PostgreSQL: executing GET SSL Keys for CDN query", err, ctx.Err())
 		return keys, e
@@ -262,12 +318,13 @@ func (p *Postgres) GetCDNSSLKeys(cdnName string, tx *sql.Tx, ctx context.Context
 	defer rows.Close()
 	for rows.Next() {
 		encryptedSslKeys := []byte{}
-		if err := rows.Scan(&encryptedSslKeys); err != nil {
+		keyID := ""
+		if err := rows.Scan(&encryptedSslKeys, &keyID); err != nil {
 			e := checkErrWithContext("Traffic Vault PostgreSQL: scanning CDN SSL keys", err, ctx.Err())
 			return keys, e
 		}
 
-		jsonKey, err := util.AESDecrypt(encryptedSslKeys, p.aesKey)
+		jsonKey, err := p.keys.decrypt(encryptedSslKeys, keyID)
 		if err != nil {
 			log.Errorf("couldn't decrypt key: %v", err)
 			continue
@@ -290,7 +347,8 @@ func (p *Postgres) GetDNSSECKeys(cdnName string, tx *sql.Tx, ctx context.Context
 	}
 	defer p.commitTransaction(tvTx, dbCtx, cancelFunc)
 	var encryptedDnssecKey []byte
-	if err := tvTx.QueryRow("SELECT data FROM dnssec WHERE cdn = $1", cdnName).Scan(&encryptedDnssecKey); err != nil {
+	var keyID string
+	if err := tvTx.QueryRow("SELECT data, key_id FROM dnssec WHERE cdn = $1", cdnName).Scan(&encryptedDnssecKey, &keyID); err != nil {
 		if err == sql.ErrNoRows {
 			return tc.DNSSECKeysTrafficVault{}, false, nil
 		}
@@ -298,7 +356,7 @@ func (p *Postgres) GetDNSSECKeys(cdnName string, tx *sql.Tx, ctx context.Context
 		return tc.DNSSECKeysTrafficVault{}, false, e
 	}
 
-	dnssecJSON, err := util.AESDecrypt(encryptedDnssecKey, p.aesKey)
+	dnssecJSON, err := p.keys.decrypt(encryptedDnssecKey, keyID)
 	if err != nil {
 		return tc.DNSSECKeysTrafficVault{}, false, err
 	}
@@ -326,12 +384,12 @@ func (p *Postgres) PutDNSSECKeys(cdnName string, keys tc.DNSSECKeysTrafficVault,
 		return e
 	}
 
-	encryptedKey, err := util.AESEncrypt(dnssecJSON, p.aesKey)
+	encryptedKey, keyID, err := p.keys.encrypt(dnssecJSON)
 	if err != nil {
 		return errors.New("encrypting keys: " + err.Error())
 	}
 
-	res, err := tvTx.Exec("INSERT INTO dnssec (cdn, data) VALUES ($1, $2)", cdnName, encryptedKey)
+	res, err := tvTx.Exec("INSERT INTO dnssec (cdn, data, key_id) VALUES ($1, $2, $3)", cdnName, encryptedKey, keyID)
 	if err != nil {
 		e := checkErrWithContext("Traffic Vault PostgreSQL: executing INSERT DNSSEC keys query", err, ctx.Err())
 		return e
@@ -364,7 +422,7 @@ func (p *Postgres) GetURLSigKeys(xmlID string, tx *sql.Tx, ctx context.Context)
 		return tc.URLSigKeys{}, false, err
 	}
 	defer p.commitTransaction(tvTx, dbCtx, cancelFunc)
-	return getURLSigKeys(xmlID, tvTx, ctx, p.aesKey)
+	return getURLSigKeys(xmlID, tvTx, ctx, p.keys)
 }
 
 func (p *Postgres) PutURLSigKeys(xmlID string, keys tc.URLSigKeys, tx *sql.Tx, ctx context.Context) error {
@@ -374,7 +432,7 @@ func (p *Postgres) PutURLSigKeys(xmlID string, keys tc.URLSigKeys, tx *sql.Tx, c
 	}
 	defer p.commitTransaction(tvTx, dbCtx, cancelFunc)
 
-	return putURLSigKeys(xmlID, tvTx, keys, ctx, p.aesKey)
+	return putURLSigKeys(xmlID, tvTx, keys, ctx, p.keys)
 }
 
 func (p *Postgres) DeleteURLSigKeys(xmlID string, tx *sql.Tx, ctx context.Context) error {
@@ -393,7 +451,7 @@ func (p *Postgres) GetURISigningKeys(xmlID string, tx *sql.Tx, ctx context.Conte
 		return []byte{}, false, err
 	}
 	defer p.commitTransaction(tvTx, dbCtx, cancelFunc)
-	return getURISigningKeys(xmlID, tvTx, ctx, p.aesKey)
+	return getURISigningKeys(xmlID, tvTx, ctx, p.keys)
 }
 
 func (p *Postgres) PutURISigningKeys(xmlID string, keysJson []byte, tx *sql.Tx, ctx context.Context) error {
@@ -403,7 +461,7 @@ func (p *Postgres) PutURISigningKeys(xmlID string, keysJson []byte, tx *sql.Tx,
 	}
 	defer p.commitTransaction(tvTx, dbCtx, cancelFunc)
 
-	return putURISigningKeys(xmlID, tvTx, keysJson, ctx, p.aesKey)
+	return putURISigningKeys(xmlID, tvTx, keysJson, ctx, p.keys)
 }
 
 func (p *Postgres) DeleteURISigningKeys(xmlID string, tx *sql.Tx, ctx context.Context) error {
@@ -438,7 +496,7 @@ func (p *Postgres) GetBucketKey(bucket string, key string, tx *sql.Tx) ([]byte,
 }
 
 func init() {
-	trafficvault.AddBackend(postgresBackendName, postgresLoad)
+	trafficvault.AddBackend(PostgresBackendName, postgresLoad)
 }
 
 func postgresLoad(b json.RawMessage) (trafficvault.TrafficVault, error) {
@@ -488,12 +546,12 @@ func postgresLoad(b json.RawMessage) (trafficvault.TrafficVault, error) {
 		log.Infoln("successfully pinged the Traffic Vault database")
 	}
 
-	aesKey, err := readKey(pgCfg)
+	keys, err := readKeyring(pgCfg)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Postgres{cfg: pgCfg, db: db, aesKey: aesKey}, nil
+	return &Postgres{cfg: pgCfg, db: db, keys: keys}, nil
 }
 
 func validateConfig(cfg Config) error {
@@ -507,9 +565,16 @@ func validateConfig(cfg Config) error {
 		"query_timeout_seconds": validation.Validate(cfg.QueryTimeoutSeconds, validation.Min(0)),
 	})
 	aesKeyLocSet := cfg.AesKeyLocation != ""
+	aesKeyringSet := cfg.AesKeyring != nil
 	hashiCorpVaultSet := cfg.HashiCorpVault != nil && *cfg.HashiCorpVault != HashiCorpVault{}
-	if aesKeyLocSet && hashiCorpVaultSet {
-		errs = append(errs, errors.New("aes_key_location and hashicorp_vault cannot both be set"))
+	numKeySources := 0
+	for _, set := range []bool{aesKeyLocSet, aesKeyringSet, hashiCorpVaultSet} {
+		if set {
+			numKeySources++
+		}
+	}
+	if numKeySources > 1 {
+		errs = append(errs, errors.New("only one of aes_key_location, aes_keyring, or hashicorp_vault may be set"))
 	} else if hashiCorpVaultSet {
 		hashiErrs := tovalidate.ToErrors(validation.Errors{
 			"address":     validation.Validate(cfg.HashiCorpVault.Address, validation.Required, is.URL),
@@ -518,11 +583,36 @@ func validateConfig(cfg Config) error {
 			"secret_path": validation.Validate(cfg.HashiCorpVault.SecretPath, validation.Required),
 		})
 		errs = append(errs, hashiErrs...)
+	} else if aesKeyringSet {
+		errs = append(errs, validateKeyring(*cfg.AesKeyring)...)
 	} else if !aesKeyLocSet {
-		errs = append(errs, errors.New("one of either aes_key_location or hashicorp_vault is required"))
+		errs = append(errs, errors.New("one of either aes_key_location, aes_keyring, or hashicorp_vault is required"))
 	}
 	if len(errs) == 0 {
 		return nil
 	}
 	return util.JoinErrs(errs)
 }
+
+func validateKeyring(keyring AesKeyring) []error {
+	errs := tovalidate.ToErrors(validation.Errors{
+		"aes_keyring.current_key_id": validation.Validate(keyring.CurrentKeyID, validation.Required),
+		"aes_keyring.keys":           validation.Validate(keyring.Keys, validation.Required),
+	})
+	ids := make(map[string]struct{}, len(keyring.Keys))
+	for i, key := range keyring.Keys {
+		if key.ID == "" {
+			errs = append(errs, fmt.Errorf("aes_keyring.keys[%d].id is required", i))
+		} else if _, ok := ids[key.ID]; ok {
+			errs = append(errs, fmt.Errorf("aes_keyring.keys[%d].id '%s' is not unique", i, key.ID))
+		}
+		ids[key.ID] = struct{}{}
+		if key.Location == "" {
+			errs = append(errs, fmt.Errorf("aes_keyring.keys[%d].location is required", i))
+		}
+	}
+	if _, ok := ids[keyring.CurrentKeyID]; keyring.CurrentKeyID != "" && !ok {
+		errs = append(errs, errors.New("aes_keyring.current_key_id '"+keyring.CurrentKeyID+"' is not the id of any key in aes_keyring.keys"))
+	}
+	return errs
+}
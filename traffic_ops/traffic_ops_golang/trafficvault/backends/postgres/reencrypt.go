@@ -0,0 +1,153 @@
+package postgres
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// reEncryptBatchSize is the number of rows re-encrypted in each Traffic Vault transaction.
+const reEncryptBatchSize = 100
+
+// encryptedTable describes a Traffic Vault table whose rows hold encrypted data tagged with a key ID.
+type encryptedTable struct {
+	name string
+	// keyColumns are the (text) primary key columns of the table.
+	keyColumns []string
+}
+
+var encryptedTables = []encryptedTable{
+	{name: "sslkey", keyColumns: []string{"deliveryservice", "cdn", "version"}},
+	{name: "dnssec", keyColumns: []string{"cdn"}},
+	{name: "url_sig_key", keyColumns: []string{"deliveryservice"}},
+	{name: "uri_signing_key", keyColumns: []string{"deliveryservice"}},
+}
+
+// ReEncrypt re-encrypts every row that is not encrypted with the current key of the keyring. Rows are
+// re-encrypted in small batches, each in its own transaction, so that Traffic Vault remains available while
+// the re-encryption is in progress.
+func (p *Postgres) ReEncrypt(progress func(done int, total int), ctx context.Context) error {
+	total := 0
+	for _, table := range encryptedTables {
+		n, err := p.countStaleRows(table, ctx)
+		if err != nil {
+			return err
+		}
+		total += n
+	}
+	done := 0
+	progress(done, total)
+	for _, table := range encryptedTables {
+		for {
+			n, err := p.reEncryptBatch(table, ctx)
+			if err != nil {
+				return errors.New("re-encrypting " + table.name + ": " + err.Error())
+			}
+			if n == 0 {
+				break
+			}
+			done += n
+			progress(done, total)
+		}
+	}
+	return nil
+}
+
+// countStaleRows returns the number of rows in the given table that are not encrypted with the current key.
+func (p *Postgres) countStaleRows(table encryptedTable, ctx context.Context) (int, error) {
+	tvTx, dbCtx, cancelFunc, err := p.beginTransaction(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer p.commitTransaction(tvTx, dbCtx, cancelFunc)
+	n := 0
+	if err := tvTx.QueryRow("SELECT COUNT(*) FROM "+table.name+" WHERE key_id <> $1", p.keys.currentID).Scan(&n); err != nil {
+		return 0, checkErrWithContext("Traffic Vault PostgreSQL: counting "+table.name+" rows to re-encrypt", err, ctx.Err())
+	}
+	return n, nil
+}
+
+// reEncryptBatch re-encrypts up to reEncryptBatchSize rows of the given table with the current key, returning
+// the number of rows re-encrypted. Rows locked by concurrent writers are skipped, since those writers store
+// their rows encrypted with the current key anyway.
+func (p *Postgres) reEncryptBatch(table encryptedTable, ctx context.Context) (int, error) {
+	tvTx, dbCtx, cancelFunc, err := p.beginTransaction(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer p.commitTransaction(tvTx, dbCtx, cancelFunc)
+
+	type staleRow struct {
+		keyValues []interface{}
+		data      []byte
+		keyID     string
+	}
+	cols := strings.Join(table.keyColumns, ", ")
+	rows, err := tvTx.Query("SELECT "+cols+", data, key_id FROM "+table.name+" WHERE key_id <> $1 LIMIT $2 FOR UPDATE SKIP LOCKED", p.keys.currentID, reEncryptBatchSize)
+	if err != nil {
+		return 0, checkErrWithContext("Traffic Vault PostgreSQL: selecting rows to re-encrypt", err, ctx.Err())
+	}
+	staleRows := []staleRow{}
+	for rows.Next() {
+		keyValues := make([]string, len(table.keyColumns))
+		row := staleRow{}
+		dest := make([]interface{}, 0, len(keyValues)+2)
+		for i := range keyValues {
+			dest = append(dest, &keyValues[i])
+		}
+		dest = append(dest, &row.data, &row.keyID)
+		if err := rows.Scan(dest...); err != nil {
+			rows.Close()
+			return 0, checkErrWithContext("Traffic Vault PostgreSQL: scanning rows to re-encrypt", err, ctx.Err())
+		}
+		for _, v := range keyValues {
+			row.keyValues = append(row.keyValues, v)
+		}
+		staleRows = append(staleRows, row)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, checkErrWithContext("Traffic Vault PostgreSQL: iterating over rows to re-encrypt", err, ctx.Err())
+	}
+
+	where := make([]string, len(table.keyColumns))
+	for i, col := range table.keyColumns {
+		where[i] = fmt.Sprintf("%s = $%d", col, i+3)
+	}
+	update := "UPDATE " + table.name + " SET data = $1, key_id = $2 WHERE " + strings.Join(where, " AND ")
+	for _, row := range staleRows {
+		decrypted, err := p.keys.decrypt(row.data, row.keyID)
+		if err != nil {
+			return 0, fmt.Errorf("decrypting row %v: %s", row.keyValues, err.Error())
+		}
+		encrypted, keyID, err := p.keys.encrypt(decrypted)
+		if err != nil {
+			return 0, fmt.Errorf("encrypting row %v: %s", row.keyValues, err.Error())
+		}
+		args := append([]interface{}{encrypted, keyID}, row.keyValues...)
+		if _, err := tvTx.Exec(update, args...); err != nil {
+			return 0, checkErrWithContext(fmt.Sprintf("Traffic Vault PostgreSQL: updating re-encrypted row %v", row.keyValues), err, ctx.Err())
+		}
+	}
+	return len(staleRows), nil
+}
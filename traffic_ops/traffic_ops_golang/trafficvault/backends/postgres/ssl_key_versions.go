@@ -0,0 +1,73 @@
+package postgres
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+
+	"github.com/apache/trafficcontrol/lib/go-log"
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/lib/go-util"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// getSSLKeysVersions returns every numbered version of the SSL keys for the given
+// Delivery Service, ordered by ascending version.
+func getSSLKeysVersions(xmlID string, tvTx *sqlx.Tx, ctx context.Context, aesKeys *keyring) ([]tc.DeliveryServiceSSLKeysV15, error) {
+	rows, err := tvTx.Query("SELECT data, version, key_id FROM sslkey WHERE deliveryservice = $1 AND version <> $2", xmlID, latestVersion)
+	if err != nil {
+		return nil, checkErrWithContext("Traffic Vault PostgreSQL: executing SELECT SSL Keys versions query", err, ctx.Err())
+	}
+	defer log.Close(rows, "closing SSL Keys versions query")
+
+	keys := []tc.DeliveryServiceSSLKeysV15{}
+	for rows.Next() {
+		var encryptedSslKeys []byte
+		var version string
+		var keyID string
+		if err := rows.Scan(&encryptedSslKeys, &version, &keyID); err != nil {
+			return nil, checkErrWithContext("Traffic Vault PostgreSQL: scanning SSL Keys versions", err, ctx.Err())
+		}
+		jsonKeys, err := aesKeys.decrypt(encryptedSslKeys, keyID)
+		if err != nil {
+			return nil, errors.New("decrypting ssl keys version " + version + ": " + err.Error())
+		}
+		key := tc.DeliveryServiceSSLKeysV15{}
+		if err := json.Unmarshal(jsonKeys, &key); err != nil {
+			return nil, errors.New("unmarshalling ssl keys version " + version + ": " + err.Error())
+		}
+		versionNum, err := strconv.ParseInt(version, 10, 64)
+		if err != nil {
+			return nil, errors.New("parsing ssl keys version '" + version + "': " + err.Error())
+		}
+		key.Version = util.JSONIntStr(versionNum)
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, checkErrWithContext("Traffic Vault PostgreSQL: iterating over SSL Keys versions", err, ctx.Err())
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Version < keys[j].Version })
+	return keys, nil
+}
@@ -26,14 +26,14 @@ import (
 	"errors"
 
 	"github.com/apache/trafficcontrol/lib/go-tc"
-	"github.com/apache/trafficcontrol/lib/go-util"
 
 	"github.com/jmoiron/sqlx"
 )
 
-func getURLSigKeys(xmlID string, tvTx *sqlx.Tx, ctx context.Context, aesKey []byte) (tc.URLSigKeys, bool, error) {
+func getURLSigKeys(xmlID string, tvTx *sqlx.Tx, ctx context.Context, aesKeys *keyring) (tc.URLSigKeys, bool, error) {
 	var encryptedUrlSigKey []byte
-	if err := tvTx.QueryRow("SELECT data FROM url_sig_key WHERE deliveryservice = $1", xmlID).Scan(&encryptedUrlSigKey); err != nil {
+	var keyID string
+	if err := tvTx.QueryRow("SELECT data, key_id FROM url_sig_key WHERE deliveryservice = $1", xmlID).Scan(&encryptedUrlSigKey, &keyID); err != nil {
 		if err == sql.ErrNoRows {
 			return tc.URLSigKeys{}, false, nil
 		}
@@ -41,7 +41,7 @@ func getURLSigKeys(xmlID string, tvTx *sqlx.Tx, ctx context.Context, aesKey []by
 		return tc.URLSigKeys{}, false, e
 	}
 
-	jsonUrlKeys, err := util.AESDecrypt(encryptedUrlSigKey, aesKey)
+	jsonUrlKeys, err := aesKeys.decrypt(encryptedUrlSigKey, keyID)
 	if err != nil {
 		return tc.URLSigKeys{}, false, err
 	}
@@ -55,7 +55,7 @@ func getURLSigKeys(xmlID string, tvTx *sqlx.Tx, ctx context.Context, aesKey []by
 	return urlSignKey, true, nil
 }
 
-func putURLSigKeys(xmlID string, tvTx *sqlx.Tx, keys tc.URLSigKeys, ctx context.Context, aesKey []byte) error {
+func putURLSigKeys(xmlID string, tvTx *sqlx.Tx, keys tc.URLSigKeys, ctx context.Context, aesKeys *keyring) error {
 	keyJSON, err := json.Marshal(&keys)
 	if err != nil {
 		return errors.New("marshalling keys: " + err.Error())
@@ -66,12 +66,12 @@ func putURLSigKeys(xmlID string, tvTx *sqlx.Tx, keys tc.URLSigKeys, ctx context.
 		return err
 	}
 
-	encryptedKey, err := util.AESEncrypt(keyJSON, aesKey)
+	encryptedKey, keyID, err := aesKeys.encrypt(keyJSON)
 	if err != nil {
 		return errors.New("encrypting keys: " + err.Error())
 	}
 
-	res, err := tvTx.Exec("INSERT INTO url_sig_key (deliveryservice, data) VALUES ($1, $2)", xmlID, encryptedKey)
+	res, err := tvTx.Exec("INSERT INTO url_sig_key (deliveryservice, data, key_id) VALUES ($1, $2, $3)", xmlID, encryptedKey, keyID)
 	if err != nil {
 		e := checkErrWithContext("Traffic Vault PostgreSQL: executing INSERT URL Sig Keys query", err, ctx.Err())
 		return e
@@ -23,11 +23,14 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/apache/trafficcontrol/lib/go-log"
 	"github.com/apache/trafficcontrol/lib/go-rfc"
 	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/lib/go-util"
 
 	"github.com/basho/riak-go-client"
 )
@@ -387,6 +390,52 @@ func searchDocsToCDNSSLKeys(docs []*riak.SearchDoc) []tc.CDNSSLKey {
 	return keys
 }
 
+// getDeliveryServiceSSLKeysVersionsObj returns every numbered version of the SSL keys in Riak for the given delivery service, ordered by ascending version.
+func getDeliveryServiceSSLKeysVersionsObj(xmlID string, tx *sql.Tx, authOpts *riak.AuthOptions, riakPort *uint) ([]tc.DeliveryServiceSSLKeysV15, error) {
+	keys := []tc.DeliveryServiceSSLKeysV15{}
+	err := withCluster(tx, authOpts, riakPort, func(cluster StorageCluster) error {
+		query := `deliveryservice:` + xmlID
+		filterQuery := ""
+		fields := []string{"_yz_rk"} // '_yz_rk' is the magic Riak field that populates the key. Without this, doc.Key would be empty.
+		searchDocs, err := search(cluster, sslKeysIndex, query, filterQuery, cdnSSLKeysLimit, fields)
+		if err != nil {
+			return errors.New("riak search error: " + err.Error())
+		}
+		prefix := xmlID + "-"
+		for _, doc := range searchDocs {
+			if !strings.HasPrefix(doc.Key, prefix) {
+				continue
+			}
+			version, err := strconv.ParseInt(strings.TrimPrefix(doc.Key, prefix), 10, 64)
+			if err != nil {
+				continue // the "latest" key, or another delivery service whose xmlID starts with this one
+			}
+			ro, err := fetchObjectValues(doc.Key, deliveryServiceSSLKeysBucket, cluster)
+			if err != nil {
+				return err
+			}
+			if len(ro) == 0 {
+				continue // deleted since the search index was updated
+			}
+			key := tc.DeliveryServiceSSLKeysV15{}
+			if err := json.Unmarshal(ro[0].Value, &key); err != nil {
+				return errors.New("unmarshalling Riak result for key '" + doc.Key + "': " + err.Error())
+			}
+			if key.DeliveryService != xmlID {
+				continue
+			}
+			key.Version = util.JSONIntStr(version)
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.New("with cluster error: " + err.Error())
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Version < keys[j].Version })
+	return keys, nil
+}
+
 // deleteOldDeliveryServiceSSLKeys deletes all the SSL keys in Riak for delivery services in the given CDN that are not in the given existingXMLIDs.
 func deleteOldDeliveryServiceSSLKeys(tx *sql.Tx, authOpts *riak.AuthOptions, riakPort *uint, cdn tc.CDNName, existingXMLIDs map[string]struct{}) error {
 	dsVersions := map[string][]string{}
@@ -52,6 +52,25 @@ func (r *Riak) DeleteDeliveryServiceSSLKeys(xmlID string, version string, tx *sq
 	return deleteDSSSLKeys(tx, &r.cfg.AuthOptions, &r.cfg.Port, xmlID, version)
 }
 
+func (r *Riak) GetDeliveryServiceSSLKeysVersions(xmlID string, tx *sql.Tx, ctx context.Context) ([]tc.DeliveryServiceSSLKeysV15, error) {
+	return getDeliveryServiceSSLKeysVersionsObj(xmlID, tx, &r.cfg.AuthOptions, &r.cfg.Port)
+}
+
+func (r *Riak) RollbackDeliveryServiceSSLKeys(xmlID string, version string, tx *sql.Tx, ctx context.Context) (tc.DeliveryServiceSSLKeysV15, bool, error) {
+	versions, err := getDeliveryServiceSSLKeysVersionsObj(xmlID, tx, &r.cfg.AuthOptions, &r.cfg.Port)
+	if err != nil {
+		return tc.DeliveryServiceSSLKeysV15{}, false, err
+	}
+	key, ok := trafficvault.GetSSLKeysRollback(versions, version)
+	if !ok {
+		return tc.DeliveryServiceSSLKeysV15{}, false, nil
+	}
+	if err := putDeliveryServiceSSLKeysObj(key.DeliveryServiceSSLKeys, tx, &r.cfg.AuthOptions, &r.cfg.Port); err != nil {
+		return tc.DeliveryServiceSSLKeysV15{}, false, err
+	}
+	return key, true, nil
+}
+
 func (r *Riak) DeleteOldDeliveryServiceSSLKeys(existingXMLIDs map[string]struct{}, cdnName string, tx *sql.Tx, ctx context.Context) error {
 	return deleteOldDeliveryServiceSSLKeys(tx, &r.cfg.AuthOptions, &r.cfg.Port, tc.CDNName(cdnName), existingXMLIDs)
 }
@@ -106,6 +125,11 @@ func (r *Riak) GetBucketKey(bucket string, key string, tx *sql.Tx) ([]byte, bool
 	return getBucketKey(tx, &r.cfg.AuthOptions, &r.cfg.Port, bucket, key)
 }
 
+// ReEncrypt is not supported by the Riak backend, which does not encrypt data with Traffic Ops-managed keys.
+func (r *Riak) ReEncrypt(progress func(done int, total int), ctx context.Context) error {
+	return errors.New("re-encryption is not supported by the riak Traffic Vault backend")
+}
+
 func init() {
 	trafficvault.AddBackend(RiakBackendName, riakConfigLoad)
 }
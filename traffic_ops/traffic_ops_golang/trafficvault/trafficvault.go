@@ -26,6 +26,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strconv"
 
 	"github.com/apache/trafficcontrol/lib/go-tc"
 )
@@ -47,6 +48,16 @@ type TrafficVault interface {
 	// DeleteDeliveryServiceSSLKeys removes the SSL keys of the given version (or latest
 	// if version is empty) for the delivery service identified by the given xmlID.
 	DeleteDeliveryServiceSSLKeys(xmlID string, version string, tx *sql.Tx, ctx context.Context) error
+	// GetDeliveryServiceSSLKeysVersions retrieves every stored version of the SSL keys
+	// for the delivery service identified by the given xmlID, ordered by ascending
+	// version. The "latest" alias is not included as a separate version.
+	GetDeliveryServiceSSLKeysVersions(xmlID string, tx *sql.Tx, ctx context.Context) ([]tc.DeliveryServiceSSLKeysV15, error)
+	// RollbackDeliveryServiceSSLKeys restores the SSL keys of the given version for the
+	// delivery service identified by the given xmlID by storing a copy of them as a new
+	// version (one greater than the current latest version), which becomes the latest.
+	// Existing versions are left untouched. It returns the newly stored keys and false
+	// if the given version does not exist.
+	RollbackDeliveryServiceSSLKeys(xmlID string, version string, tx *sql.Tx, ctx context.Context) (tc.DeliveryServiceSSLKeysV15, bool, error)
 	// DeleteOldDeliveryServiceSSLKeys takes a set of existingXMLIDs as input and will remove
 	// all SSL keys for delivery services in the CDN identified by the given cdnName that
 	// do not contain an xmlID in the given set of existingXMLIDs. This method is called
@@ -88,6 +99,12 @@ type TrafficVault interface {
 	// apply to every Traffic Vault backend implementation.
 	// Deprecated: this method and associated API routes will be removed in the future.
 	GetBucketKey(bucket string, key string, tx *sql.Tx) ([]byte, bool, error)
+	// ReEncrypt re-encrypts all the data stored in Traffic Vault that is not encrypted with
+	// the current encryption key, so that previous keys may be retired. The given progress
+	// function is called periodically with the number of records re-encrypted so far and
+	// the total number of records to re-encrypt. This may not apply to every Traffic Vault
+	// backend implementation.
+	ReEncrypt(progress func(done int, total int), ctx context.Context) error
 }
 
 var backends = make(map[string]LoadFunc)
@@ -120,3 +137,19 @@ func GetBackend(name string, cfgJson json.RawMessage) (TrafficVault, error) {
 	}
 	return backend, nil
 }
+
+// GetSSLKeysRollback finds the given version in versions (which must be ordered by
+// ascending version, as returned by GetDeliveryServiceSSLKeysVersions) and returns a
+// copy of it renumbered as the next version after the greatest one in versions. This
+// is the SSL keys object that a backend's RollbackDeliveryServiceSSLKeys should store.
+// It returns false if the given version is not in versions.
+func GetSSLKeysRollback(versions []tc.DeliveryServiceSSLKeysV15, version string) (tc.DeliveryServiceSSLKeysV15, bool) {
+	for _, v := range versions {
+		if strconv.FormatInt(int64(v.Version), 10) != version {
+			continue
+		}
+		v.Version = versions[len(versions)-1].Version + 1
+		return v, true
+	}
+	return tc.DeliveryServiceSSLKeysV15{}, false
+}
@@ -0,0 +1,183 @@
+package vault
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-log"
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/lib/go-util"
+	"github.com/apache/trafficcontrol/traffic_ops/traffic_ops_golang/api"
+	"github.com/apache/trafficcontrol/traffic_ops/traffic_ops_golang/trafficvault"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ReEncrypt starts an async job that re-encrypts all the data stored in Traffic Vault with the current
+// encryption key. Progress can be followed through the async_status API.
+func ReEncrypt(w http.ResponseWriter, r *http.Request) {
+	inf, userErr, sysErr, errCode := api.NewInfo(r, nil, nil)
+	if userErr != nil || sysErr != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, errCode, userErr, sysErr)
+		return
+	}
+	defer inf.Close()
+
+	if !inf.Config.TrafficVaultEnabled {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("re-encrypting Traffic Vault: Traffic Vault is not configured"))
+		return
+	}
+
+	db, err := api.GetDB(r.Context())
+	if err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("re-encrypting Traffic Vault: getting db: "+err.Error()))
+		return
+	}
+
+	// InsertAsyncStatus commits the transaction, so the change log must be written first
+	api.CreateChangeLogRawTx(api.ApiChange, "Started Traffic Vault re-encryption", inf.User, inf.Tx.Tx)
+
+	asyncStatusId, errCode, userErr, sysErr := api.InsertAsyncStatus(inf.Tx.Tx, "Traffic Vault re-encryption async job has started.")
+	if userErr != nil || sysErr != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, errCode, userErr, sysErr)
+		return
+	}
+
+	go reEncrypt(inf.Vault, db, asyncStatusId)
+
+	var alerts tc.Alerts
+	alerts.AddAlert(tc.Alert{
+		Text:  "Beginning async Traffic Vault re-encryption. Status updates can be found here: " + api.CurrentAsyncEndpoint + strconv.Itoa(asyncStatusId),
+		Level: tc.SuccessLevel.String(),
+	})
+
+	w.Header().Add("Location", api.CurrentAsyncEndpoint+strconv.Itoa(asyncStatusId))
+	api.WriteAlerts(w, r, http.StatusAccepted, alerts)
+}
+
+// StartReEncryptScheduler re-encrypts the data stored in Traffic Vault that is not encrypted with the current
+// key at startup, and then every interval, so that data encrypted with a rotated key is re-encrypted without
+// being requested. An async job is only recorded when there is data to re-encrypt.
+func StartReEncryptScheduler(tv trafficvault.TrafficVault, db *sqlx.DB, interval time.Duration) {
+	go func() {
+		for {
+			reEncryptScheduled(tv, db)
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// reEncryptScheduled re-encrypts the given Traffic Vault, inserting an async status to report its progress
+// once it is known that there is data to re-encrypt.
+func reEncryptScheduled(tv trafficvault.TrafficVault, db *sqlx.DB) {
+	asyncStatusId := 0
+	defer func() {
+		if err := recover(); err != nil {
+			updateReEncryptStatus(db, api.AsyncFailed, "Traffic Vault re-encryption failed.", asyncStatusId, true)
+			log.Errorf("panic: (err: %v) stacktrace:\n%s\n", err, util.Stacktrace())
+		}
+	}()
+
+	progress := func(done int, total int) {
+		if asyncStatusId == 0 {
+			if total == 0 {
+				return
+			}
+			id, err := insertScheduledReEncryptStatus(db)
+			if err != nil {
+				log.Errorln("inserting async status for scheduled Traffic Vault re-encryption: " + err.Error())
+				return
+			}
+			log.Infof("scheduled Traffic Vault re-encryption of %d records started, async status id %d", total, id)
+			asyncStatusId = id
+		}
+		updateReEncryptProgress(db, done, total, asyncStatusId)
+	}
+
+	err := tv.ReEncrypt(progress, context.Background())
+	if asyncStatusId == 0 {
+		if err != nil {
+			log.Errorln("scheduled re-encryption of Traffic Vault: " + err.Error())
+		}
+		return
+	}
+	finishReEncrypt(db, err, asyncStatusId)
+}
+
+// insertScheduledReEncryptStatus inserts the async status of a scheduled re-encryption, returning its ID.
+func insertScheduledReEncryptStatus(db *sqlx.DB) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, errors.New("beginning transaction: " + err.Error())
+	}
+	asyncStatusId, _, userErr, sysErr := api.InsertAsyncStatus(tx, "Scheduled Traffic Vault re-encryption async job has started.")
+	if userErr != nil || sysErr != nil {
+		return 0, util.JoinErrs([]error{userErr, sysErr})
+	}
+	return asyncStatusId, nil
+}
+
+// reEncrypt re-encrypts the given Traffic Vault, reporting its progress to the async status identified by
+// the given asyncStatusId. It is meant to be run in its own goroutine, so it uses its own background context
+// which isn't cancelled when the original HTTP connection is closed.
+func reEncrypt(tv trafficvault.TrafficVault, db *sqlx.DB, asyncStatusId int) {
+	defer func() {
+		if err := recover(); err != nil {
+			updateReEncryptStatus(db, api.AsyncFailed, "Traffic Vault re-encryption failed.", asyncStatusId, true)
+			log.Errorf("panic: (err: %v) stacktrace:\n%s\n", err, util.Stacktrace())
+		}
+	}()
+
+	progress := func(done int, total int) {
+		updateReEncryptProgress(db, done, total, asyncStatusId)
+	}
+
+	finishReEncrypt(db, tv.ReEncrypt(progress, context.Background()), asyncStatusId)
+}
+
+// updateReEncryptProgress reports the number of records re-encrypted so far to the given async status.
+func updateReEncryptProgress(db *sqlx.DB, done int, total int, asyncStatusId int) {
+	msg := fmt.Sprintf("Traffic Vault re-encryption in progress: re-encrypted %d of %d records.", done, total)
+	updateReEncryptStatus(db, api.AsyncPending, msg, asyncStatusId, false)
+}
+
+// finishReEncrypt reports the outcome of a re-encryption, which failed if err is not nil, to the given async status.
+func finishReEncrypt(db *sqlx.DB, err error, asyncStatusId int) {
+	if err != nil {
+		log.Errorln("re-encrypting Traffic Vault: " + err.Error())
+		updateReEncryptStatus(db, api.AsyncFailed, "Traffic Vault re-encryption failed: "+err.Error(), asyncStatusId, true)
+		return
+	}
+
+	log.Infoln("Traffic Vault re-encryption completed")
+	updateReEncryptStatus(db, api.AsyncSucceeded, "Traffic Vault re-encryption completed.", asyncStatusId, true)
+}
+
+func updateReEncryptStatus(db *sqlx.DB, status string, msg string, asyncStatusId int, finished bool) {
+	if asyncErr := api.UpdateAsyncStatus(db, status, msg, asyncStatusId, finished); asyncErr != nil {
+		log.Errorf("updating async status for id %v: %v", asyncStatusId, asyncErr)
+	}
+}
@@ -0,0 +1,84 @@
+package vault
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"context"
+	"testing"
+
+	"github.com/apache/trafficcontrol/traffic_ops/traffic_ops_golang/trafficvault/backends/disabled"
+
+	"github.com/jmoiron/sqlx"
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+type reEncryptingVault struct {
+	disabled.Disabled
+	total int
+}
+
+func (v *reEncryptingVault) ReEncrypt(progress func(done int, total int), ctx context.Context) error {
+	progress(0, v.total)
+	if v.total > 0 {
+		progress(v.total, v.total)
+	}
+	return nil
+}
+
+func TestReEncryptScheduledNothingStale(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+	defer db.Close()
+
+	reEncryptScheduled(&reEncryptingVault{total: 0}, db)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("expected no async status when there is nothing to re-encrypt: %v", err)
+	}
+}
+
+func TestReEncryptScheduled(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO async_status").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectCommit()
+	for _, status := range []string{"PENDING", "PENDING", "SUCCEEDED"} {
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE async_status").WithArgs(status, sqlmock.AnyArg(), 7).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+	}
+
+	reEncryptScheduled(&reEncryptingVault{total: 3}, db)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("expected an async status reporting the scheduled re-encryption: %v", err)
+	}
+}
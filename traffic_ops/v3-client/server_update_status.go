@@ -71,3 +71,11 @@ func (to *Session) SetUpdateServerStatuses(serverName string, updateStatus *bool
 	reqInf, err := to.post(path, nil, nil, &alerts)
 	return reqInf, err
 }
+
+// SetServerConfigRolledBack sets whether the server rolled back the last update or revalidation it applied.
+func (to *Session) SetServerConfigRolledBack(serverName string, rolledBack bool) (toclientlib.ReqInf, error) {
+	path := `/servers/` + serverName + `/update?config_rolled_back=` + strconv.FormatBool(rolledBack)
+	alerts := tc.Alerts{}
+	reqInf, err := to.post(path, nil, nil, &alerts)
+	return reqInf, err
+}
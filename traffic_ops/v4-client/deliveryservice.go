@@ -24,6 +24,7 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"strconv"
 
 	"github.com/apache/trafficcontrol/lib/go-tc"
 	"github.com/apache/trafficcontrol/lib/go-util"
@@ -72,6 +73,28 @@ const (
 	// of the Delivery Service of interest).
 	apiAPIDeliveryServiceXMLIDSSLKeys = apiDeliveryServices + "/xmlId/%s/sslkeys"
 
+	// apiDeliveryServiceXMLIDSSLKeysVersions is the API path on which Traffic Ops lists the stored
+	// versions of the SSL keys used by a Delivery Service identified by its XMLID. It is intended to be
+	// used with fmt.Sprintf to insert its required path parameter (namely the XMLID of the Delivery
+	// Service of interest).
+	apiDeliveryServiceXMLIDSSLKeysVersions = apiAPIDeliveryServiceXMLIDSSLKeys + "/versions"
+
+	// apiDeliveryServiceXMLIDSSLKeysVersionsDiff is the API path on which Traffic Ops describes the
+	// differences between two stored versions of the SSL keys used by a Delivery Service identified by
+	// its XMLID. It is intended to be used with fmt.Sprintf to insert its required path parameter
+	// (namely the XMLID of the Delivery Service of interest).
+	apiDeliveryServiceXMLIDSSLKeysVersionsDiff = apiDeliveryServiceXMLIDSSLKeysVersions + "/diff"
+
+	// apiDeliveryServiceXMLIDSSLKeysRollback is the API path on which Traffic Ops will roll back the
+	// SSL keys used by a Delivery Service identified by its XMLID to a previously stored version. It
+	// is intended to be used with fmt.Sprintf to insert its required path parameter (namely the XMLID
+	// of the Delivery Service of interest).
+	apiDeliveryServiceXMLIDSSLKeysRollback = apiAPIDeliveryServiceXMLIDSSLKeys + "/rollback"
+
+	// apiSSLKeyExpirations is the API path on which Traffic Ops lists the expirations of the SSL
+	// certificates used by Delivery Services.
+	apiSSLKeyExpirations = "/sslkey_expirations"
+
 	// apiDeliveryServiceGenerateSSLKeys is the API path on which Traffic Ops will generate new SSL keys.
 	apiDeliveryServiceGenerateSSLKeys = apiDeliveryServices + "/sslkeys/generate"
 
@@ -280,6 +303,47 @@ func (to *Session) GetDeliveryServiceSSLKeys(xmlid string, opts RequestOptions)
 	return data, reqInf, err
 }
 
+// GetDeliveryServiceSSLKeysVersions retrieves summaries of every stored
+// version of the SSL keys of the Delivery Service with the given XMLID.
+func (to *Session) GetDeliveryServiceSSLKeysVersions(xmlid string, opts RequestOptions) (tc.DeliveryServiceSSLKeysVersionsResponse, toclientlib.ReqInf, error) {
+	var data tc.DeliveryServiceSSLKeysVersionsResponse
+	reqInf, err := to.get(fmt.Sprintf(apiDeliveryServiceXMLIDSSLKeysVersions, url.PathEscape(xmlid)), opts, &data)
+	return data, reqInf, err
+}
+
+// GetDeliveryServiceSSLKeysVersionsDiff retrieves the differences between the
+// given stored versions of the SSL keys of the Delivery Service with the given
+// XMLID.
+func (to *Session) GetDeliveryServiceSSLKeysVersionsDiff(xmlid string, from int, toVersion int, opts RequestOptions) (tc.DeliveryServiceSSLKeysVersionsDiffResponse, toclientlib.ReqInf, error) {
+	if opts.QueryParameters == nil {
+		opts.QueryParameters = url.Values{}
+	}
+	opts.QueryParameters.Set("from", strconv.Itoa(from))
+	opts.QueryParameters.Set("to", strconv.Itoa(toVersion))
+	var data tc.DeliveryServiceSSLKeysVersionsDiffResponse
+	reqInf, err := to.get(fmt.Sprintf(apiDeliveryServiceXMLIDSSLKeysVersionsDiff, url.PathEscape(xmlid)), opts, &data)
+	return data, reqInf, err
+}
+
+// RollbackDeliveryServiceSSLKeys restores the given stored version of the SSL
+// keys of the Delivery Service with the given XMLID as its latest version.
+func (to *Session) RollbackDeliveryServiceSSLKeys(xmlid string, version int, opts RequestOptions) (tc.DeliveryServiceSSLKeysRollbackResponse, toclientlib.ReqInf, error) {
+	v := util.JSONIntStr(version)
+	request := tc.DeliveryServiceSSLKeysRollbackRequest{Version: &v}
+	var resp tc.DeliveryServiceSSLKeysRollbackResponse
+	reqInf, err := to.post(fmt.Sprintf(apiDeliveryServiceXMLIDSSLKeysRollback, url.PathEscape(xmlid)), opts, request, &resp)
+	return resp, reqInf, err
+}
+
+// GetSSLKeyExpirations retrieves the expiration information of the latest SSL
+// certificates of all Delivery Services. Use the "days" query parameter in
+// opts to retrieve only the certificates that expire within that many days.
+func (to *Session) GetSSLKeyExpirations(opts RequestOptions) (tc.SSLKeyExpirationsResponse, toclientlib.ReqInf, error) {
+	var data tc.SSLKeyExpirationsResponse
+	reqInf, err := to.get(apiSSLKeyExpirations, opts, &data)
+	return data, reqInf, err
+}
+
 // GetDeliveryServicesEligible returns the servers eligible for assignment to the Delivery
 // Service identified by the integral, unique identifier 'dsID'.
 func (to *Session) GetDeliveryServicesEligible(dsID int, opts RequestOptions) (tc.DSServerResponseV4, toclientlib.ReqInf, error) {
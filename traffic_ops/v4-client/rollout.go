@@ -0,0 +1,45 @@
+package client
+
+/*
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+import (
+	"strconv"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_ops/toclientlib"
+)
+
+const (
+	// apiRollouts is the partial path (excluding the /api/<version> prefix) to the /rollouts API endpoint.
+	apiRollouts = "/rollouts"
+	// apiAsyncStatus is the partial path (excluding the /api/<version> prefix) to the /async_status/{{id}} API endpoint.
+	apiAsyncStatus = "/async_status/"
+)
+
+// StartRollout starts an asynchronous job that queues updates on canary servers, and then on the rest of the
+// targeted servers once the canaries have applied them and are healthy. The returned alerts include the location
+// of the job's async status.
+func (to *Session) StartRollout(req tc.RolloutRequest, opts RequestOptions) (tc.Alerts, toclientlib.ReqInf, error) {
+	var alerts tc.Alerts
+	reqInf, err := to.post(apiRollouts, opts, req, &alerts)
+	return alerts, reqInf, err
+}
+
+// AbortAsyncJob aborts the pending asynchronous job with the given ID, such as a rollout.
+func (to *Session) AbortAsyncJob(id int, opts RequestOptions) (tc.Alerts, toclientlib.ReqInf, error) {
+	var alerts tc.Alerts
+	reqInf, err := to.del(apiAsyncStatus+strconv.Itoa(id), opts, &alerts)
+	return alerts, reqInf, err
+}
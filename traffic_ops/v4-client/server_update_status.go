@@ -19,6 +19,7 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"strconv"
 
 	"github.com/apache/trafficcontrol/lib/go-tc"
 	"github.com/apache/trafficcontrol/traffic_ops/toclientlib"
@@ -81,3 +82,16 @@ func (to *Session) SetUpdateServerStatuses(serverName string, updateStatus *bool
 	reqInf, err := to.post(path, opts, nil, &alerts)
 	return alerts, reqInf, err
 }
+
+// SetServerConfigRolledBack sets whether the server identified by
+// 'serverName' rolled back the last update or revalidation it applied.
+func (to *Session) SetServerConfigRolledBack(serverName string, rolledBack bool, opts RequestOptions) (tc.Alerts, toclientlib.ReqInf, error) {
+	if opts.QueryParameters == nil {
+		opts.QueryParameters = url.Values{}
+	}
+	opts.QueryParameters.Set("config_rolled_back", strconv.FormatBool(rolledBack))
+	var alerts tc.Alerts
+	path := `/servers/` + url.PathEscape(serverName) + `/update`
+	reqInf, err := to.post(path, opts, nil, &alerts)
+	return alerts, reqInf, err
+}
@@ -22,6 +22,8 @@ import (
 const (
 	// apiVaultPing is the partial path (excluding the /api/<version> prefix) to the /vault/ping API endpoint.
 	apiVaultPing = "/vault/ping"
+	// apiVaultReEncrypt is the partial path (excluding the /api/<version> prefix) to the /vault/reencrypt API endpoint.
+	apiVaultReEncrypt = "/vault/reencrypt"
 )
 
 // TrafficVaultPing returns a response indicating whether or not Traffic Vault is responsive.
@@ -30,3 +32,11 @@ func (to *Session) TrafficVaultPing(opts RequestOptions) (tc.TrafficVaultPingRes
 	reqInf, err := to.get(apiVaultPing, opts, &data)
 	return data, reqInf, err
 }
+
+// TrafficVaultReEncrypt starts an asynchronous job that re-encrypts all the data in Traffic Vault with the
+// current encryption key. The returned alerts include the location of the job's async status.
+func (to *Session) TrafficVaultReEncrypt(opts RequestOptions) (tc.Alerts, toclientlib.ReqInf, error) {
+	var alerts tc.Alerts
+	reqInf, err := to.post(apiVaultReEncrypt, opts, nil, &alerts)
+	return alerts, reqInf, err
+}
@@ -0,0 +1,168 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	client "github.com/apache/trafficcontrol/traffic_ops/v2-client"
+
+	log "github.com/cihub/seelog"
+	influx "github.com/influxdata/influxdb/client/v2"
+)
+
+// backfillDateFormat is the format of the dates of the backfill flags.
+const backfillDateFormat = "2006-01-02"
+
+// runBackfill backfills the daily summaries of the days between the given dates, inclusive. If end is empty, only the start date is backfilled.
+func runBackfill(config StartupConfig, start string, end string) error {
+	startDate, err := time.Parse(backfillDateFormat, start)
+	if err != nil {
+		return fmt.Errorf("parsing start date: %v", err)
+	}
+	endDate := startDate
+	if end != "" {
+		if endDate, err = time.Parse(backfillDateFormat, end); err != nil {
+			return fmt.Errorf("parsing end date: %v", err)
+		}
+	}
+	if endDate.Before(startDate) {
+		return fmt.Errorf("end date %s is before start date %s", end, start)
+	}
+	if len(config.InfluxDBs) == 0 {
+		return errors.New("backfilling requires influxUrls, to query the bandwidth from")
+	}
+	return backfillSummaries(config, startDate, endDate)
+}
+
+// backfillSummaries recomputes the daily summaries of each day from start to end, inclusive, from the per-minute CDN bandwidth in InfluxDB, and rewrites them to the Traffic Ops stats_summary API and the sinks.
+//
+// This recovers summaries which were lost or wrong, e.g. because Traffic Stats wasn't running for part of a day. Traffic Ops replaces an existing summary of the same stat, CDN, and day, so backfilling a day again doesn't duplicate it. Points are written to the sinks directly, rather than spooled, so a running Traffic Stats' spools aren't disturbed.
+func backfillSummaries(config StartupConfig, start time.Time, end time.Time) error {
+	influxClient, err := influxConnect(config)
+	if err != nil {
+		return fmt.Errorf("connecting to InfluxDB: %v", err)
+	}
+	to, _, err := client.LoginWithAgent(config.ToURL, config.ToUser, config.ToPasswd, true, UserAgent, false, TrafficOpsRequestTimeout)
+	if err != nil {
+		return fmt.Errorf("logging in to %v: %v", config.ToURL, err)
+	}
+
+	failed := 0
+	for day := start; !day.After(end); day = day.Add(24 * time.Hour) {
+		summaries, err := queryCDNMinuteSummaries(influxClient, day, day.Add(24*time.Hour))
+		if err != nil {
+			return fmt.Errorf("querying bandwidth of %s: %v", day.Format(backfillDateFormat), err)
+		}
+
+		bp, _ := influx.NewBatchPoints(influx.BatchPointsConfig{
+			Database:        "daily_stats",
+			Precision:       "s",
+			RetentionPolicy: config.DailySummaryRetentionPolicy,
+		})
+		statsSummaries := append(calcDailyMaxGbps(summaries, bp), calcDailyBytesServed(summaries, bp, day)...)
+		for _, statsSummary := range statsSummaries {
+			if _, _, err := to.CreateSummaryStats(statsSummary); err != nil {
+				log.Errorf("could not create summary stats %s for %s on %s: %v", *statsSummary.StatName, *statsSummary.CDNName, day.Format(backfillDateFormat), err)
+				failed++
+			}
+		}
+		for _, sink := range config.MetricSinks {
+			if err := sink.Write(bp); err != nil {
+				log.Errorf("writing daily stats of %s to %s: %v", day.Format(backfillDateFormat), sink.Name(), err)
+				failed++
+			}
+		}
+		log.Infof("Backfilled %d daily stats for %s", len(statsSummaries), day.Format(backfillDateFormat))
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d daily stats could not be written", failed)
+	}
+	return nil
+}
+
+// queryCDNMinuteSummaries returns the mean bandwidth of each minute of each CDN from start to end, from the "bandwidth.cdn.1min" continuous query of InfluxDB.
+func queryCDNMinuteSummaries(influxClient influx.Client, start time.Time, end time.Time) (map[string]CDNMinuteSummary, error) {
+	queryString := fmt.Sprintf(`select mean(value) from "monthly"."bandwidth.cdn.1min" where time >= '%s' and time < '%s' group by time(1m), cdn`, start.Format(time.RFC3339), end.Format(time.RFC3339))
+	log.Infof("queryString = %v\n", queryString)
+	res, err := queryDB(influxClient, queryString, "cache_stats")
+	if err != nil {
+		return nil, err
+	}
+	return cdnMinuteSummariesFromResults(res), nil
+}
+
+func cdnMinuteSummariesFromResults(res []influx.Result) map[string]CDNMinuteSummary {
+	summaries := map[string]CDNMinuteSummary{}
+	if len(res) == 0 {
+		return summaries
+	}
+	for _, row := range res[0].Series {
+		cdn := row.Tags["cdn"]
+		for _, record := range row.Values {
+			if len(record) < 2 || record[1] == nil {
+				continue
+			}
+			t, ok := record[0].(string)
+			if !ok {
+				log.Errorf("Couldn't parse time from record %v\n", record)
+				continue
+			}
+			minute, err := time.Parse(time.RFC3339, t)
+			if err != nil {
+				log.Errorf("Couldn't parse time from record %v\n", record)
+				continue
+			}
+			value, ok := record[1].(json.Number)
+			if !ok {
+				log.Errorf("Couldn't parse value from record %v\n", record)
+				continue
+			}
+			kbps, err := value.Float64()
+			if err != nil {
+				log.Errorf("Couldn't parse value from record %v\n", record)
+				continue
+			}
+			if summaries[cdn] == nil {
+				summaries[cdn] = CDNMinuteSummary{}
+			}
+			summaries[cdn][minute.Unix()] = kbps
+		}
+	}
+	return summaries
+}
+
+func queryDB(con influx.Client, cmd string, database string) (res []influx.Result, err error) {
+	q := influx.Query{
+		Command:  cmd,
+		Database: database,
+	}
+	response, err := con.Query(q)
+	if err != nil {
+		return res, err
+	}
+	if response.Error() != nil {
+		return res, response.Error()
+	}
+	return response.Results, nil
+}
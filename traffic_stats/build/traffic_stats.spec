@@ -58,6 +58,7 @@ mkdir -p "${RPM_BUILD_ROOT}"/opt/traffic_stats/conf
 mkdir -p "${RPM_BUILD_ROOT}"/opt/traffic_stats/backup
 mkdir -p "${RPM_BUILD_ROOT}"/opt/traffic_stats/influxdb_tools
 mkdir -p "${RPM_BUILD_ROOT}"/opt/traffic_stats/var/run
+mkdir -p "${RPM_BUILD_ROOT}"/opt/traffic_stats/var/spool
 mkdir -p "${RPM_BUILD_ROOT}"/opt/traffic_stats/var/log/traffic_stats
 mkdir -p "${RPM_BUILD_ROOT}"/etc/init.d
 mkdir -p "${RPM_BUILD_ROOT}"/etc/logrotate.d
@@ -125,6 +126,7 @@ fi
 %dir /opt/traffic_stats/var
 %dir /opt/traffic_stats/var/log
 %dir /opt/traffic_stats/var/run
+%dir /opt/traffic_stats/var/spool
 %dir /opt/traffic_stats/var/log/traffic_stats
 %dir /usr/share/grafana/public/dashboards
 %dir /opt/traffic_stats/influxdb_tools
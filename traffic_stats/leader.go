@@ -0,0 +1,189 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+
+	log "github.com/cihub/seelog"
+	_ "github.com/lib/pq"
+)
+
+const (
+	defaultLeaderElectionInterval = 10
+	defaultLeaderElectionPort     = 5432
+	defaultLeaderElectionSSLMode  = "disable"
+	// defaultLeaderElectionLockID is the key of the advisory lock, "traf" in ASCII. It only needs to differ from the keys of other advisory locks in the same database.
+	defaultLeaderElectionLockID = 0x74726166
+)
+
+// LeaderElectionConfig is the configuration of the election of the leader of a group of Traffic Stats instances, only which polls Traffic Monitors and writes stats.
+type LeaderElectionConfig struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	DBName   string `json:"dbname"`
+	User     string `json:"user"`
+	Password string `json:"password"`
+	SSLMode  string `json:"sslmode"`
+	// LockID is the key of the Postgres advisory lock held by the leader.
+	LockID int64 `json:"lockId"`
+	// Interval is how often, in seconds, the leader checks it still holds the lock, and standbys try to take it.
+	Interval int `json:"interval"`
+}
+
+// LeaderElector elects the leader of a group of Traffic Stats instances, with a Postgres session-level advisory lock. The leader is the instance whose database session holds the lock, which Postgres releases as soon as the session ends, so when the leader fails a standby takes the lock on its next attempt.
+type LeaderElector struct {
+	config LeaderElectionConfig
+	db     *sql.DB
+	// conn is the session holding the lock, or nil if this instance isn't the leader, or has lost the session and is trying to take the lock again.
+	conn *sql.Conn
+	// confirmed is when this instance last confirmed it held the lock, or zero if it isn't the leader.
+	confirmed time.Time
+}
+
+// NewLeaderElector returns a leader elector for the given config, setting its defaults.
+func NewLeaderElector(config LeaderElectionConfig) (*LeaderElector, error) {
+	if config.Host == "" || config.DBName == "" {
+		return nil, errors.New("leader election requires a host and dbname")
+	}
+	if config.Port == 0 {
+		config.Port = defaultLeaderElectionPort
+	}
+	if config.SSLMode == "" {
+		config.SSLMode = defaultLeaderElectionSSLMode
+	}
+	if config.LockID == 0 {
+		config.LockID = defaultLeaderElectionLockID
+	}
+	if config.Interval == 0 {
+		config.Interval = defaultLeaderElectionInterval
+	}
+
+	dbURL := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(config.User, config.Password),
+		Host:     net.JoinHostPort(config.Host, strconv.Itoa(config.Port)),
+		Path:     config.DBName,
+		RawQuery: url.Values{"sslmode": {config.SSLMode}, "fallback_application_name": {UserAgent}}.Encode(),
+	}
+	db, err := sql.Open("postgres", dbURL.String())
+	if err != nil {
+		return nil, fmt.Errorf("opening leader election database: %v", err)
+	}
+	return &LeaderElector{config: config, db: db}, nil
+}
+
+// Interval returns how often the elector should elect.
+func (e *LeaderElector) Interval() time.Duration {
+	return time.Duration(e.config.Interval) * time.Second
+}
+
+// leaseWindow returns how long after the leader last confirmed it held the lock the database may still hold it for the leader's session, even though the leader can't reach the session: the keepalive idle time and one keepalive interval, after which the server ends a session it can't reach.
+func (e *LeaderElector) leaseWindow() time.Duration {
+	return 2 * e.Interval()
+}
+
+// Elect tries to become the leader, or to confirm this instance is still the leader, returning whether it is.
+// A leader which can't confirm it still holds the lock, because its session or the database can't be reached, remains the leader for the lease window after it last confirmed it, because no other instance can take the lock until the database ends its session. So a short database outage doesn't change the leader. If its session is known to be lost, it tries to take the lock again at once, and only steps down if another session holds it, or the lease window passes.
+func (e *LeaderElector) Elect(ctx context.Context) bool {
+	now := time.Now()
+	if e.conn != nil {
+		_, err := e.conn.ExecContext(ctx, "SELECT 1")
+		if err == nil {
+			e.confirmed = now
+			return true
+		}
+		if !errors.Is(err, driver.ErrBadConn) && !errors.Is(err, sql.ErrConnDone) && e.inLeaseWindow(now) {
+			log.Warnf("leader election: checking the leader's database session, remaining the leader until %v: %v", e.confirmed.Add(e.leaseWindow()), err)
+			return true
+		}
+		log.Errorf("leader election: lost the leader's database session: %v", err)
+		e.conn.Close()
+		e.conn = nil
+	}
+
+	conn, err := e.db.Conn(ctx)
+	if err != nil {
+		log.Errorf("leader election: connecting to the database: %v", err)
+		return e.keepLeading(now)
+	}
+	// Make the server notice a dead leader, and release the lock, within about two intervals, rather than the operating system's default of hours.
+	for _, setting := range []string{"tcp_keepalives_idle", "tcp_keepalives_interval"} {
+		if _, err := conn.ExecContext(ctx, fmt.Sprintf("SET %s = %d", setting, e.config.Interval)); err != nil {
+			log.Warnf("leader election: setting %s: %v", setting, err)
+		}
+	}
+	if _, err := conn.ExecContext(ctx, "SET tcp_keepalives_count = 1"); err != nil {
+		log.Warnf("leader election: setting tcp_keepalives_count: %v", err)
+	}
+
+	acquired := false
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", e.config.LockID).Scan(&acquired); err != nil {
+		log.Errorf("leader election: trying to take the lock: %v", err)
+		conn.Close()
+		return e.keepLeading(now)
+	}
+	if !acquired {
+		conn.Close()
+		e.confirmed = time.Time{}
+		return false
+	}
+	e.conn = conn
+	e.confirmed = now
+	return true
+}
+
+// inLeaseWindow returns whether this instance is the leader, and last confirmed it held the lock within the lease window before now.
+func (e *LeaderElector) inLeaseWindow(now time.Time) bool {
+	return !e.confirmed.IsZero() && now.Sub(e.confirmed) < e.leaseWindow()
+}
+
+// keepLeading returns whether this instance remains the leader although it couldn't take the lock because the database couldn't be reached, which it does within the lease window.
+func (e *LeaderElector) keepLeading(now time.Time) bool {
+	if e.inLeaseWindow(now) {
+		log.Warnf("leader election: database unreachable, remaining the leader until %v", e.confirmed.Add(e.leaseWindow()))
+		return true
+	}
+	e.confirmed = time.Time{}
+	return false
+}
+
+// runLeaderElection elects until the process exits, sending whether this instance is the leader on the given channel whenever it changes. The lock is released when the process exits, and with it its database session.
+func runLeaderElection(elector *LeaderElector, leaderChan chan<- bool) {
+	leader := false
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), elector.Interval())
+		elected := elector.Elect(ctx)
+		cancel()
+		if elected != leader {
+			leader = elected
+			leaderChan <- leader
+		}
+		time.Sleep(elector.Interval())
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNewLeaderElector(t *testing.T) {
+	if _, err := NewLeaderElector(LeaderElectionConfig{DBName: "traffic_stats"}); err == nil {
+		t.Error("expected an error without a host")
+	}
+
+	elector, err := NewLeaderElector(LeaderElectionConfig{Host: "localhost", DBName: "traffic_stats"})
+	if err != nil {
+		t.Fatalf("creating leader elector: %v", err)
+	}
+	if elector.config.Port != defaultLeaderElectionPort || elector.config.LockID != defaultLeaderElectionLockID || elector.Interval() != defaultLeaderElectionInterval*time.Second {
+		t.Errorf("expected defaults, actual %+v", elector.config)
+	}
+}
+
+func TestElectUnreachable(t *testing.T) {
+	elector, err := NewLeaderElector(LeaderElectionConfig{Host: "127.0.0.1", Port: 1, DBName: "traffic_stats", Interval: 1})
+	if err != nil {
+		t.Fatalf("creating leader elector: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), elector.Interval())
+	defer cancel()
+	if elector.Elect(ctx) {
+		t.Error("expected not to be elected leader without a database")
+	}
+}
+
+func TestElectUnreachableLeader(t *testing.T) {
+	elector, err := NewLeaderElector(LeaderElectionConfig{Host: "127.0.0.1", Port: 1, DBName: "traffic_stats", Interval: 1})
+	if err != nil {
+		t.Fatalf("creating leader elector: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), elector.Interval())
+	defer cancel()
+
+	elector.confirmed = time.Now()
+	if !elector.Elect(ctx) {
+		t.Error("expected a leader which can't reach the database to remain the leader within the lease window")
+	}
+
+	elector.confirmed = time.Now().Add(-elector.leaseWindow())
+	if elector.Elect(ctx) {
+		t.Error("expected a leader which can't reach the database to step down after the lease window")
+	}
+	if !elector.confirmed.IsZero() {
+		t.Errorf("expected a leader which stepped down to have no confirmed time, actual %v", elector.confirmed)
+	}
+}
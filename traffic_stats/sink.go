@@ -0,0 +1,174 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	influx "github.com/influxdata/influxdb/client/v2"
+)
+
+const (
+	// SinkTypeInfluxDB is the type of the InfluxDB sink, which is configured by influxUrls rather than sinks.
+	SinkTypeInfluxDB = "influxdb"
+	// SinkTypePrometheus is the type of Prometheus remote-write sinks.
+	SinkTypePrometheus = "prometheus"
+	// SinkTypeOTLP is the type of OpenTelemetry OTLP/HTTP metrics sinks.
+	SinkTypeOTLP = "otlp"
+
+	defaultSinkTimeout = 10
+)
+
+// Sink is a destination to which stats are written. Stats are given to every sink as InfluxDB batch points, whose database is cache_stats, deliveryservice_stats or daily_stats, and each of whose points has a single "value" field.
+type Sink interface {
+	// Name uniquely identifies the sink, in logs and for its spool.
+	Name() string
+	// Write writes the given points, returning an error if they weren't written and should be retried.
+	Write(bps influx.BatchPoints) error
+	// Close releases the sink's connections. It is called when the config is reloaded.
+	Close()
+}
+
+// SinkConfig is the configuration of a sink, other than InfluxDB.
+type SinkConfig struct {
+	// Type is the type of the sink, "prometheus" or "otlp".
+	Type string `json:"type"`
+	// URL is the URL to which stats are posted, e.g. the remote-write URL of Prometheus, or the OTLP/HTTP metrics URL of an OpenTelemetry collector.
+	URL      string            `json:"url"`
+	User     string            `json:"user"`
+	Password string            `json:"password"`
+	Headers  map[string]string `json:"headers"`
+	// Timeout is the timeout of requests to the sink, in seconds.
+	Timeout int `json:"timeout"`
+}
+
+// newSinks returns the configured sinks: InfluxDB, if influxUrls are configured, followed by the configured sinks.
+func newSinks(config StartupConfig) ([]Sink, error) {
+	sinks := []Sink{}
+	if len(config.InfluxDBs) > 0 {
+		sinks = append(sinks, &influxSink{config: config})
+	}
+	names := map[string]struct{}{}
+	for _, sinkConfig := range config.Sinks {
+		if sinkConfig.URL == "" {
+			return nil, fmt.Errorf("%s sink has no url", sinkConfig.Type)
+		}
+		if sinkConfig.Timeout == 0 {
+			sinkConfig.Timeout = defaultSinkTimeout
+		}
+		var sink Sink
+		switch sinkConfig.Type {
+		case SinkTypePrometheus:
+			sink = newPrometheusSink(sinkConfig)
+		case SinkTypeOTLP:
+			sink = newOTLPSink(sinkConfig)
+		default:
+			return nil, fmt.Errorf("unknown sink type '%s', must be '%s' or '%s'", sinkConfig.Type, SinkTypePrometheus, SinkTypeOTLP)
+		}
+		if _, ok := names[sink.Name()]; ok {
+			return nil, fmt.Errorf("duplicate sink %s", sink.Name())
+		}
+		names[sink.Name()] = struct{}{}
+		sinks = append(sinks, sink)
+	}
+	if len(sinks) == 0 {
+		return nil, errors.New("No sinks configured, please provide at least one valid InfluxDB URL in influxUrls, e.g. \"influxUrls\": [\"http://localhost:8086\"], or a sink in sinks")
+	}
+	return sinks, nil
+}
+
+// influxSink writes stats to one of the configured InfluxDB servers.
+type influxSink struct {
+	config StartupConfig
+}
+
+func (s *influxSink) Name() string { return SinkTypeInfluxDB }
+
+func (s *influxSink) Write(bps influx.BatchPoints) error {
+	influxClient, err := influxConnect(s.config)
+	if err != nil {
+		return fmt.Errorf("unable to get InfluxDB client: %v", err)
+	}
+	return influxClient.Write(bps)
+}
+
+func (s *influxSink) Close() {
+	for _, host := range s.config.InfluxDBs {
+		if host.InfluxClient != nil {
+			host.InfluxClient.Close()
+		}
+	}
+}
+
+// postSink posts the given body to a sink's URL, with its credentials and headers, returning an error if the response isn't successful.
+func postSink(client *http.Client, sinkConfig SinkConfig, contentType string, headers map[string]string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, sinkConfig.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request: %v", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", UserAgent)
+	for name, val := range headers {
+		req.Header.Set(name, val)
+	}
+	for name, val := range sinkConfig.Headers {
+		req.Header.Set(name, val)
+	}
+	if sinkConfig.User != "" {
+		req.SetBasicAuth(sinkConfig.User, sinkConfig.Password)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("posting: %v", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("bad response code %d: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+// pointValue returns the "value" field of the given point as a float, and false if it doesn't have one.
+func pointValue(pt *influx.Point) (float64, bool) {
+	fields, err := pt.Fields()
+	if err != nil {
+		return 0, false
+	}
+	switch v := fields["value"].(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+func newSinkHTTPClient(sinkConfig SinkConfig) *http.Client {
+	return &http.Client{Timeout: time.Duration(sinkConfig.Timeout) * time.Second}
+}
@@ -0,0 +1,144 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	influx "github.com/influxdata/influxdb/client/v2"
+)
+
+// otlpSink writes stats as OpenTelemetry metrics, with the JSON encoding of OTLP/HTTP.
+//
+// Each point becomes a gauge data point of the metric named by its database and measurement, e.g. cache_stats.bandwidth, with its tags as attributes.
+type otlpSink struct {
+	config SinkConfig
+	client *http.Client
+}
+
+func newOTLPSink(config SinkConfig) *otlpSink {
+	return &otlpSink{config: config, client: newSinkHTTPClient(config)}
+}
+
+func (s *otlpSink) Name() string { return SinkTypeOTLP + " " + s.config.URL }
+
+func (s *otlpSink) Write(bps influx.BatchPoints) error {
+	body, err := json.Marshal(newOTLPMetricsRequest(bps))
+	if err != nil {
+		return fmt.Errorf("encoding OTLP metrics: %v", err)
+	}
+	return postSink(s.client, s.config, "application/json", nil, body)
+}
+
+func (s *otlpSink) Close() {
+	s.client.CloseIdleConnections()
+}
+
+// These are the parts of the OTLP ExportMetricsServiceRequest used by Traffic Stats, in the OTLP JSON encoding, in which 64-bit integers are strings.
+
+type otlpMetricsRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource       `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope    `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpMetric struct {
+	Name  string    `json:"name"`
+	Gauge otlpGauge `json:"gauge"`
+}
+
+type otlpGauge struct {
+	DataPoints []otlpDataPoint `json:"dataPoints"`
+}
+
+type otlpDataPoint struct {
+	Attributes   []otlpAttribute `json:"attributes"`
+	TimeUnixNano string          `json:"timeUnixNano"`
+	AsDouble     float64         `json:"asDouble"`
+}
+
+type otlpAttribute struct {
+	Key   string             `json:"key"`
+	Value otlpAttributeValue `json:"value"`
+}
+
+type otlpAttributeValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+// newOTLPMetricsRequest returns the OTLP metrics of the given points, with a gauge metric for each measurement.
+func newOTLPMetricsRequest(bps influx.BatchPoints) otlpMetricsRequest {
+	metrics := map[string]*otlpMetric{}
+	names := []string{}
+	for _, pt := range bps.Points() {
+		value, ok := pointValue(pt)
+		if !ok {
+			continue
+		}
+		name := bps.Database() + "." + pt.Name()
+		metric, ok := metrics[name]
+		if !ok {
+			metric = &otlpMetric{Name: name}
+			metrics[name] = metric
+			names = append(names, name)
+		}
+		tags := pt.Tags()
+		attributes := make([]otlpAttribute, 0, len(tags))
+		for key, val := range tags {
+			attributes = append(attributes, otlpAttribute{Key: key, Value: otlpAttributeValue{StringValue: val}})
+		}
+		sort.Slice(attributes, func(i, j int) bool { return attributes[i].Key < attributes[j].Key })
+		metric.Gauge.DataPoints = append(metric.Gauge.DataPoints, otlpDataPoint{
+			Attributes:   attributes,
+			TimeUnixNano: strconv.FormatInt(pt.Time().UnixNano(), 10),
+			AsDouble:     value,
+		})
+	}
+	sort.Strings(names)
+
+	scopeMetrics := otlpScopeMetrics{Scope: otlpScope{Name: UserAgent}, Metrics: make([]otlpMetric, 0, len(names))}
+	for _, name := range names {
+		scopeMetrics.Metrics = append(scopeMetrics.Metrics, *metrics[name])
+	}
+	return otlpMetricsRequest{ResourceMetrics: []otlpResourceMetrics{{
+		Resource:     otlpResource{Attributes: []otlpAttribute{{Key: "service.name", Value: otlpAttributeValue{StringValue: UserAgent}}}},
+		ScopeMetrics: []otlpScopeMetrics{scopeMetrics},
+	}}}
+}
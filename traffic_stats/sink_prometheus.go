@@ -0,0 +1,117 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package main
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/golang/snappy"
+	influx "github.com/influxdata/influxdb/client/v2"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// prometheusSink writes stats with the Prometheus remote-write protocol.
+//
+// Each point becomes a sample of the metric named by its database and measurement, e.g. cache_stats_bandwidth, with its tags as labels.
+type prometheusSink struct {
+	config SinkConfig
+	client *http.Client
+}
+
+func newPrometheusSink(config SinkConfig) *prometheusSink {
+	return &prometheusSink{config: config, client: newSinkHTTPClient(config)}
+}
+
+func (s *prometheusSink) Name() string { return SinkTypePrometheus + " " + s.config.URL }
+
+func (s *prometheusSink) Write(bps influx.BatchPoints) error {
+	body := snappy.Encode(nil, encodePrometheusWriteRequest(bps))
+	headers := map[string]string{
+		"Content-Encoding":                  "snappy",
+		"X-Prometheus-Remote-Write-Version": "0.1.0",
+	}
+	return postSink(s.client, s.config, "application/x-protobuf", headers, body)
+}
+
+func (s *prometheusSink) Close() {
+	s.client.CloseIdleConnections()
+}
+
+// promLabel is a Prometheus label.
+type promLabel struct {
+	name  string
+	value string
+}
+
+// encodePrometheusWriteRequest encodes the given points as a remote-write WriteRequest protobuf message, with one time series per point.
+//
+// The message is encoded directly, rather than with generated code, as it's small and stable:
+//
+//	message WriteRequest { repeated TimeSeries timeseries = 1; }
+//	message TimeSeries { repeated Label labels = 1; repeated Sample samples = 2; }
+//	message Label { string name = 1; string value = 2; }
+//	message Sample { double value = 1; int64 timestamp = 2; }
+func encodePrometheusWriteRequest(bps influx.BatchPoints) []byte {
+	req := []byte{}
+	for _, pt := range bps.Points() {
+		value, ok := pointValue(pt)
+		if !ok {
+			continue
+		}
+		labels := []promLabel{{name: "__name__", value: prometheusName(bps.Database() + "_" + pt.Name())}}
+		for name, val := range pt.Tags() {
+			labels = append(labels, promLabel{name: prometheusName(name), value: val})
+		}
+		sort.Slice(labels, func(i, j int) bool { return labels[i].name < labels[j].name })
+
+		series := []byte{}
+		for _, label := range labels {
+			l := protowire.AppendTag(nil, 1, protowire.BytesType)
+			l = protowire.AppendString(l, label.name)
+			l = protowire.AppendTag(l, 2, protowire.BytesType)
+			l = protowire.AppendString(l, label.value)
+			series = protowire.AppendTag(series, 1, protowire.BytesType)
+			series = protowire.AppendBytes(series, l)
+		}
+		sample := protowire.AppendTag(nil, 1, protowire.Fixed64Type)
+		sample = protowire.AppendFixed64(sample, math.Float64bits(value))
+		sample = protowire.AppendTag(sample, 2, protowire.VarintType)
+		sample = protowire.AppendVarint(sample, uint64(pt.Time().UnixNano()/1000000))
+		series = protowire.AppendTag(series, 2, protowire.BytesType)
+		series = protowire.AppendBytes(series, sample)
+
+		req = protowire.AppendTag(req, 1, protowire.BytesType)
+		req = protowire.AppendBytes(req, series)
+	}
+	return req
+}
+
+// prometheusName returns the given name with every character not valid in Prometheus metric and label names replaced by an underscore.
+func prometheusName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+}
@@ -0,0 +1,182 @@
+package main
+
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	influx "github.com/influxdata/influxdb/client/v2"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func newTestSinkPoints(t *testing.T) influx.BatchPoints {
+	bps, err := influx.NewBatchPoints(influx.BatchPointsConfig{Database: "deliveryservice_stats", Precision: "ms"})
+	if err != nil {
+		t.Fatalf("creating batch points: %v", err)
+	}
+	pt, err := influx.NewPoint("tps.2xx", map[string]string{"deliveryservice": "ds0", "cdn": "cdn0"}, map[string]interface{}{"value": 42.5}, time.Unix(1600000000, 0))
+	if err != nil {
+		t.Fatalf("creating point: %v", err)
+	}
+	bps.AddPoint(pt)
+	return bps
+}
+
+// consumeField returns the number, type and value of the first field of a protobuf message, and the remainder of the message.
+func consumeField(t *testing.T, b []byte) (protowire.Number, protowire.Type, []byte, []byte) {
+	num, typ, n := protowire.ConsumeTag(b)
+	if n < 0 {
+		t.Fatalf("malformed tag: %v", protowire.ParseError(n))
+	}
+	b = b[n:]
+	m := protowire.ConsumeFieldValue(num, typ, b)
+	if m < 0 {
+		t.Fatalf("malformed field %d: %v", num, protowire.ParseError(m))
+	}
+	return num, typ, b[:m], b[m:]
+}
+
+func TestPrometheusSink(t *testing.T) {
+	var body []byte
+	header := http.Header{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		body, _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	sinks, err := newSinks(StartupConfig{Sinks: []SinkConfig{{Type: SinkTypePrometheus, URL: srv.URL, User: "user", Password: "pass", Headers: map[string]string{"X-Scope-OrgID": "tenant"}}}})
+	if err != nil {
+		t.Fatalf("creating sinks: %v", err)
+	}
+	if err := sinks[0].Write(newTestSinkPoints(t)); err != nil {
+		t.Fatalf("writing: %v", err)
+	}
+	if header.Get("Content-Encoding") != "snappy" || header.Get("X-Scope-OrgID") != "tenant" {
+		t.Errorf("expected snappy encoding and configured headers, actual %+v", header)
+	}
+	if user, pass, ok := (&http.Request{Header: header}).BasicAuth(); !ok || user != "user" || pass != "pass" {
+		t.Errorf("expected basic auth user:pass, actual %v %v:%v", ok, user, pass)
+	}
+
+	req, err := snappy.Decode(nil, body)
+	if err != nil {
+		t.Fatalf("decoding snappy: %v", err)
+	}
+	num, _, series, rest := consumeField(t, req)
+	if num != 1 || len(rest) != 0 {
+		t.Fatalf("expected a single time series, actual field %d with %d bytes remaining", num, len(rest))
+	}
+	series, _ = protowire.ConsumeBytes(series)
+
+	labels := []string{}
+	for len(series) > 0 {
+		var field []byte
+		num, _, field, series = consumeField(t, series)
+		field, _ = protowire.ConsumeBytes(field)
+		switch num {
+		case 1:
+			_, _, name, value := consumeField(t, field)
+			_, _, value, _ = consumeField(t, value)
+			nameStr, _ := protowire.ConsumeString(name)
+			valueStr, _ := protowire.ConsumeString(value)
+			labels = append(labels, nameStr+"="+valueStr)
+		case 2:
+			_, _, value, timestamp := consumeField(t, field)
+			_, _, timestamp, _ = consumeField(t, timestamp)
+			if v, _ := protowire.ConsumeFixed64(value); math.Float64frombits(v) != 42.5 {
+				t.Errorf("expected sample value 42.5, actual %v", math.Float64frombits(v))
+			}
+			if ms, _ := protowire.ConsumeVarint(timestamp); ms != 1600000000000 {
+				t.Errorf("expected sample timestamp 1600000000000, actual %d", ms)
+			}
+		}
+	}
+	if expected := "__name__=deliveryservice_stats_tps_2xx,cdn=cdn0,deliveryservice=ds0"; strings.Join(labels, ",") != expected {
+		t.Errorf("expected labels %s, actual %s", expected, strings.Join(labels, ","))
+	}
+}
+
+func TestOTLPSink(t *testing.T) {
+	req := otlpMetricsRequest{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	sinks, err := newSinks(StartupConfig{Sinks: []SinkConfig{{Type: SinkTypeOTLP, URL: srv.URL}}})
+	if err != nil {
+		t.Fatalf("creating sinks: %v", err)
+	}
+	if err := sinks[0].Write(newTestSinkPoints(t)); err != nil {
+		t.Fatalf("writing: %v", err)
+	}
+	if len(req.ResourceMetrics) != 1 || len(req.ResourceMetrics[0].ScopeMetrics) != 1 || len(req.ResourceMetrics[0].ScopeMetrics[0].Metrics) != 1 {
+		t.Fatalf("expected a single metric, actual %+v", req)
+	}
+	metric := req.ResourceMetrics[0].ScopeMetrics[0].Metrics[0]
+	if metric.Name != "deliveryservice_stats.tps.2xx" || len(metric.Gauge.DataPoints) != 1 {
+		t.Fatalf("expected one deliveryservice_stats.tps.2xx data point, actual %+v", metric)
+	}
+	dp := metric.Gauge.DataPoints[0]
+	if dp.AsDouble != 42.5 || dp.TimeUnixNano != "1600000000000000000" || len(dp.Attributes) != 2 || dp.Attributes[0].Key != "cdn" {
+		t.Errorf("expected data point 42.5 at 1600000000000000000 with cdn and deliveryservice attributes, actual %+v", dp)
+	}
+}
+
+func TestSinkError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	sinks, err := newSinks(StartupConfig{Sinks: []SinkConfig{{Type: SinkTypeOTLP, URL: srv.URL}}})
+	if err != nil {
+		t.Fatalf("creating sinks: %v", err)
+	}
+	if err := sinks[0].Write(newTestSinkPoints(t)); err == nil {
+		t.Error("expected an error writing to a failing sink")
+	}
+}
+
+func TestNewSinksInvalid(t *testing.T) {
+	invalid := map[string][]SinkConfig{
+		"none":      nil,
+		"no url":    {{Type: SinkTypePrometheus}},
+		"bad type":  {{Type: "graphite", URL: "http://localhost"}},
+		"duplicate": {{Type: SinkTypeOTLP, URL: "http://localhost"}, {Type: SinkTypeOTLP, URL: "http://localhost"}},
+	}
+	for name, sinkConfigs := range invalid {
+		if _, err := newSinks(StartupConfig{Sinks: sinkConfigs}); err == nil {
+			t.Errorf("expected an error for %s sinks", name)
+		}
+	}
+}
@@ -0,0 +1,373 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	log "github.com/cihub/seelog"
+	influx "github.com/influxdata/influxdb/client/v2"
+	"github.com/influxdata/influxdb/models"
+)
+
+const (
+	defaultMaxSpoolBytes = 100 * 1024 * 1024
+	spoolFileExt         = ".batch"
+)
+
+// Spool is a bounded first-in first-out queue of the batch points which couldn't be written to a sink, which are replayed in order once the sink recovers.
+//
+// If it has a directory, each batch is written to its own file, so unsent stats survive restarts. Otherwise, batches are only kept in memory. When the spool exceeds its maximum size, the oldest batches are dropped.
+//
+// Batches given to Enqueue are sent by a single writer goroutine per spool, so they're written in the order they were queued, however long the sink takes. If the queued batches exceed the spool's maximum size, e.g. because the sink is hanging, they're moved into the spool, so the queue is bounded too.
+type Spool struct {
+	m        *sync.Mutex
+	sink     string
+	dir      string
+	maxBytes int64
+	entries  []spoolEntry // oldest first
+	bytes    int64
+	nextSeq  uint64
+
+	// qm guards queue and queueBytes. When both are locked, m is locked first.
+	qm          *sync.Mutex
+	queue       []spoolSend // oldest first
+	queueBytes  int64
+	queued      chan struct{}
+	startWriter *sync.Once
+}
+
+// spoolSend is a send of batches to a sink, queued for the spool's writer.
+type spoolSend struct {
+	sink    Sink
+	batches []influx.BatchPoints
+	size    int64
+}
+
+type spoolEntry struct {
+	seq  uint64
+	size int64
+	data []byte // nil if the entry is on disk
+}
+
+// spoolHeader is the first line of a spooled batch, followed by its points in line protocol.
+type spoolHeader struct {
+	Database        string `json:"database"`
+	RetentionPolicy string `json:"retentionPolicy"`
+	Precision       string `json:"precision"`
+}
+
+// NewSpool returns the spool of the named sink, in a directory named after the sink in the given directory, loading any batches already spooled there. If dir is empty, the spool is kept in memory.
+func NewSpool(dir string, sink string, maxBytes int64) (*Spool, error) {
+	s := &Spool{m: &sync.Mutex{}, sink: sink, maxBytes: maxBytes, qm: &sync.Mutex{}, queued: make(chan struct{}, 1), startWriter: &sync.Once{}}
+	if dir == "" {
+		return s, nil
+	}
+	s.dir = filepath.Join(dir, url.PathEscape(sink))
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return nil, fmt.Errorf("creating spool directory: %v", err)
+	}
+	files, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading spool directory: %v", err)
+	}
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), spoolFileExt) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(file.Name(), spoolFileExt), 10, 64)
+		if err != nil {
+			log.Warnf("spool %s: ignoring unknown file %s", sink, file.Name())
+			continue
+		}
+		s.entries = append(s.entries, spoolEntry{seq: seq, size: file.Size()})
+		s.bytes += file.Size()
+		if seq >= s.nextSeq {
+			s.nextSeq = seq + 1
+		}
+	}
+	sort.Slice(s.entries, func(i, j int) bool { return s.entries[i].seq < s.entries[j].seq })
+	if len(s.entries) > 0 {
+		log.Infof("spool %s: loaded %d unsent batches (%d bytes)", sink, len(s.entries), s.bytes)
+	}
+	return s, nil
+}
+
+// Len returns the number of spooled batches.
+func (s *Spool) Len() int {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return len(s.entries)
+}
+
+// Enqueue queues the given batches to be sent to the sink by the spool's writer goroutine, after any batches queued before them. It doesn't wait for the sink, unless the queue has grown past the spool's maximum size, in which case it waits for the writer's current send, and spools the queue.
+func (s *Spool) Enqueue(sink Sink, batches []influx.BatchPoints) {
+	send := spoolSend{sink: sink, batches: batches}
+	for _, bps := range batches {
+		send.size += spoolBatchSize(bps)
+	}
+	s.qm.Lock()
+	s.queue = append(s.queue, send)
+	s.queueBytes += send.size
+	full := s.queueBytes > s.maxBytes
+	s.qm.Unlock()
+	if full {
+		s.spillQueue()
+	}
+	s.startWriter.Do(func() { go s.runWriter() })
+	select {
+	case s.queued <- struct{}{}:
+	default: // the writer is already signaled, and will find these batches
+	}
+}
+
+// SendQueued sends every batch queued by Enqueue, followed by the given batches, and returns once they've been written or spooled, e.g. before shutting down.
+func (s *Spool) SendQueued(sink Sink, batches []influx.BatchPoints) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	for _, queued := range s.takeQueue() {
+		if err := s.send(queued.sink, queued.batches); err != nil {
+			// the sink is down, so spool everything after the failed batch instead of waiting for it to fail again
+			log.Errorf("sending metrics to %s: error writing batch points, spooled %d batches: %v", queued.sink.Name(), len(s.entries), err)
+			s.spillLocked()
+			s.push(batches)
+			return err
+		}
+	}
+	return s.send(sink, batches)
+}
+
+// spillQueue moves every queued batch into the spool, after any batches already spooled, so they're replayed in order by the next send.
+func (s *Spool) spillQueue() {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.spillLocked()
+}
+
+// spillLocked is spillQueue, with the spool locked.
+func (s *Spool) spillLocked() {
+	queue := s.takeQueue()
+	if len(queue) == 0 {
+		return
+	}
+	log.Warnf("spool %s: %d sends are queued behind the sink, spooling them", s.sink, len(queue))
+	for _, queued := range queue {
+		s.push(queued.batches)
+	}
+}
+
+// takeQueue removes and returns every queued send.
+func (s *Spool) takeQueue() []spoolSend {
+	s.qm.Lock()
+	defer s.qm.Unlock()
+	queue := s.queue
+	s.queue = nil
+	s.queueBytes = 0
+	return queue
+}
+
+// runWriter sends the queued batches, oldest first, whenever batches are queued.
+func (s *Spool) runWriter() {
+	for range s.queued {
+		for s.sendNextQueued() {
+		}
+	}
+}
+
+// sendNextQueued sends the oldest queued batches, and returns false if there were none.
+func (s *Spool) sendNextQueued() bool {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.qm.Lock()
+	if len(s.queue) == 0 {
+		s.qm.Unlock()
+		return false
+	}
+	next := s.queue[0]
+	s.queue = s.queue[1:]
+	s.queueBytes -= next.size
+	s.qm.Unlock()
+	if err := s.send(next.sink, next.batches); err != nil {
+		log.Errorf("sending metrics to %s: error writing batch points, spooled %d batches: %v", next.sink.Name(), len(s.entries), err)
+		// the sink is down, so the batches queued while waiting for it would fail too
+		s.spillLocked()
+	}
+	return true
+}
+
+// Send writes the given batches to the sink, after replaying any already spooled. If the sink fails, the failed batch and every batch after it are spooled, so batches are always written in the order they were sent.
+func (s *Spool) Send(sink Sink, batches []influx.BatchPoints) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.send(sink, batches)
+}
+
+// send is Send, with the spool locked.
+func (s *Spool) send(sink Sink, batches []influx.BatchPoints) error {
+	if len(s.entries) == 0 {
+		for i, bps := range batches {
+			if err := sink.Write(bps); err != nil {
+				s.push(batches[i:])
+				return err
+			}
+			log.Infof("Sent %v stats for %v to %v", len(bps.Points()), bps.Database(), sink.Name())
+		}
+		return nil
+	}
+
+	s.push(batches)
+	return s.replay(sink)
+}
+
+// replay writes spooled batches to the sink, oldest first, until they're all written or the sink fails.
+func (s *Spool) replay(sink Sink) error {
+	replayed := 0
+	for len(s.entries) > 0 {
+		entry := s.entries[0]
+		bps, err := s.read(entry)
+		if err != nil {
+			log.Errorf("spool %s: dropping unreadable batch %d: %v", s.sink, entry.seq, err)
+			s.pop()
+			continue
+		}
+		if err := sink.Write(bps); err != nil {
+			return err
+		}
+		s.pop()
+		replayed++
+	}
+	log.Infof("spool %s: replayed %d batches", s.sink, replayed)
+	return nil
+}
+
+// push adds the given batches to the end of the spool, dropping the oldest batches if the spool becomes too large.
+func (s *Spool) push(batches []influx.BatchPoints) {
+	for _, bps := range batches {
+		data := encodeSpoolBatch(bps)
+		entry := spoolEntry{seq: s.nextSeq, size: int64(len(data))}
+		if s.dir == "" {
+			entry.data = data
+		} else if err := s.write(entry.seq, data); err != nil {
+			log.Errorf("spool %s: dropping %d stats for %s: %v", s.sink, len(bps.Points()), bps.Database(), err)
+			continue
+		}
+		s.nextSeq++
+		s.entries = append(s.entries, entry)
+		s.bytes += entry.size
+	}
+	for s.bytes > s.maxBytes && len(s.entries) > 0 {
+		log.Warnf("spool %s: exceeded %d bytes, dropping oldest batch", s.sink, s.maxBytes)
+		s.pop()
+	}
+}
+
+// pop removes the oldest batch from the spool.
+func (s *Spool) pop() {
+	entry := s.entries[0]
+	if s.dir != "" {
+		if err := os.Remove(s.path(entry.seq)); err != nil && !os.IsNotExist(err) {
+			log.Errorf("spool %s: removing batch %d: %v", s.sink, entry.seq, err)
+		}
+	}
+	s.entries = s.entries[1:]
+	s.bytes -= entry.size
+}
+
+// write atomically writes a batch to its file, by writing a temporary file and renaming it.
+func (s *Spool) write(seq uint64, data []byte) error {
+	tmpPath := s.path(seq) + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("writing spool file: %v", err)
+	}
+	if err := os.Rename(tmpPath, s.path(seq)); err != nil {
+		return fmt.Errorf("renaming spool file: %v", err)
+	}
+	return nil
+}
+
+func (s *Spool) read(entry spoolEntry) (influx.BatchPoints, error) {
+	if entry.data != nil {
+		return decodeSpoolBatch(entry.data)
+	}
+	data, err := ioutil.ReadFile(s.path(entry.seq))
+	if err != nil {
+		return nil, fmt.Errorf("reading spool file: %v", err)
+	}
+	return decodeSpoolBatch(data)
+}
+
+func (s *Spool) path(seq uint64) string {
+	// zero-padded, so files sort in order
+	return filepath.Join(s.dir, fmt.Sprintf("%020d%s", seq, spoolFileExt))
+}
+
+// spoolBatchSize returns the number of bytes the given batch takes in the spool.
+func spoolBatchSize(bps influx.BatchPoints) int64 {
+	return int64(len(encodeSpoolBatch(bps)))
+}
+
+// encodeSpoolBatch encodes the given batch as a JSON header line, followed by its points in nanosecond line protocol, which preserves their field types.
+func encodeSpoolBatch(bps influx.BatchPoints) []byte {
+	buf := &bytes.Buffer{}
+	header, _ := json.Marshal(spoolHeader{Database: bps.Database(), RetentionPolicy: bps.RetentionPolicy(), Precision: bps.Precision()})
+	buf.Write(header)
+	buf.WriteByte('\n')
+	for _, pt := range bps.Points() {
+		buf.WriteString(pt.String())
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+func decodeSpoolBatch(data []byte) (influx.BatchPoints, error) {
+	reader := bufio.NewReader(bytes.NewReader(data))
+	headerLine, err := reader.ReadBytes('\n')
+	if err != nil {
+		return nil, errors.New("missing header")
+	}
+	header := spoolHeader{}
+	if err := json.Unmarshal(headerLine, &header); err != nil {
+		return nil, fmt.Errorf("decoding header: %v", err)
+	}
+	bps, err := influx.NewBatchPoints(influx.BatchPointsConfig{Database: header.Database, RetentionPolicy: header.RetentionPolicy, Precision: header.Precision})
+	if err != nil {
+		return nil, fmt.Errorf("creating batch points: %v", err)
+	}
+	pts, err := models.ParsePoints(data[len(headerLine):])
+	if err != nil {
+		return nil, fmt.Errorf("parsing points: %v", err)
+	}
+	for _, pt := range pts {
+		bps.AddPoint(influx.NewPointFrom(pt))
+	}
+	return bps, nil
+}
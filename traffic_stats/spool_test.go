@@ -0,0 +1,248 @@
+package main
+
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	influx "github.com/influxdata/influxdb/client/v2"
+)
+
+// testSink is a sink which records the databases of the batches written to it, and fails while down.
+type testSink struct {
+	down    bool
+	written []string
+}
+
+func (s *testSink) Name() string { return "test" }
+
+func (s *testSink) Write(bps influx.BatchPoints) error {
+	if s.down {
+		return errors.New("down")
+	}
+	s.written = append(s.written, bps.Database())
+	return nil
+}
+
+func (s *testSink) Close() {}
+
+func newTestSpoolBatch(t *testing.T, database string) influx.BatchPoints {
+	bps, err := influx.NewBatchPoints(influx.BatchPointsConfig{Database: database, Precision: "ms", RetentionPolicy: "daily"})
+	if err != nil {
+		t.Fatalf("creating batch points: %v", err)
+	}
+	pt, err := influx.NewPoint("bandwidth", map[string]string{"cdn": "cdn0"}, map[string]interface{}{"value": int64(42)}, time.Unix(1600000000, 0))
+	if err != nil {
+		t.Fatalf("creating point: %v", err)
+	}
+	bps.AddPoint(pt)
+	return bps
+}
+
+func TestSpoolReplayInOrder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "traffic_stats_spool")
+	if err != nil {
+		t.Fatalf("creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	spool, err := NewSpool(dir, "test sink", defaultMaxSpoolBytes)
+	if err != nil {
+		t.Fatalf("creating spool: %v", err)
+	}
+	sink := &testSink{down: true}
+	if err := spool.Send(sink, []influx.BatchPoints{newTestSpoolBatch(t, "a"), newTestSpoolBatch(t, "b")}); err == nil {
+		t.Error("expected an error sending to a down sink")
+	}
+	sink.down = false
+	sink.written = nil
+	if err := spool.Send(sink, []influx.BatchPoints{newTestSpoolBatch(t, "c")}); err != nil {
+		t.Fatalf("sending: %v", err)
+	}
+	if len(sink.written) != 3 || sink.written[0] != "a" || sink.written[1] != "b" || sink.written[2] != "c" {
+		t.Errorf("expected spooled batches replayed before new batches, actual %v", sink.written)
+	}
+	if spool.Len() != 0 {
+		t.Errorf("expected empty spool after replay, actual %d batches", spool.Len())
+	}
+}
+
+func TestSpoolPersists(t *testing.T) {
+	dir, err := ioutil.TempDir("", "traffic_stats_spool")
+	if err != nil {
+		t.Fatalf("creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	spool, err := NewSpool(dir, "test sink", defaultMaxSpoolBytes)
+	if err != nil {
+		t.Fatalf("creating spool: %v", err)
+	}
+	spool.push([]influx.BatchPoints{newTestSpoolBatch(t, "a"), newTestSpoolBatch(t, "b")})
+
+	// as if Traffic Stats restarted
+	spool, err = NewSpool(dir, "test sink", defaultMaxSpoolBytes)
+	if err != nil {
+		t.Fatalf("reloading spool: %v", err)
+	}
+	if spool.Len() != 2 {
+		t.Fatalf("expected 2 spooled batches after reloading, actual %d", spool.Len())
+	}
+	bps, err := spool.read(spool.entries[0])
+	if err != nil {
+		t.Fatalf("reading spooled batch: %v", err)
+	}
+	if bps.Database() != "a" || bps.RetentionPolicy() != "daily" || bps.Precision() != "ms" || len(bps.Points()) != 1 {
+		t.Fatalf("expected batch a with retention policy daily and precision ms and 1 point, actual %v %v %v %v", bps.Database(), bps.RetentionPolicy(), bps.Precision(), len(bps.Points()))
+	}
+	pt := bps.Points()[0]
+	fields, _ := pt.Fields()
+	if pt.Name() != "bandwidth" || pt.Tags()["cdn"] != "cdn0" || fields["value"] != int64(42) || !pt.Time().Equal(time.Unix(1600000000, 0)) {
+		t.Errorf("expected spooled point to be unchanged, actual %v", pt)
+	}
+
+	sink := &testSink{}
+	if err := spool.Send(sink, nil); err != nil {
+		t.Fatalf("sending: %v", err)
+	}
+	if len(sink.written) != 2 {
+		t.Errorf("expected 2 replayed batches, actual %v", sink.written)
+	}
+	if files, _ := ioutil.ReadDir(spool.dir); len(files) != 0 {
+		t.Errorf("expected replayed batches to be removed, actual %d files", len(files))
+	}
+}
+
+func TestSpoolBounded(t *testing.T) {
+	size := int64(len(encodeSpoolBatch(newTestSpoolBatch(t, "a"))))
+	spool, err := NewSpool("", "test sink", 2*size)
+	if err != nil {
+		t.Fatalf("creating spool: %v", err)
+	}
+	spool.push([]influx.BatchPoints{newTestSpoolBatch(t, "a"), newTestSpoolBatch(t, "b"), newTestSpoolBatch(t, "c")})
+	if spool.Len() != 2 {
+		t.Fatalf("expected the spool to be bounded to 2 batches, actual %d", spool.Len())
+	}
+	sink := &testSink{}
+	if err := spool.Send(sink, nil); err != nil {
+		t.Fatalf("sending: %v", err)
+	}
+	if len(sink.written) != 2 || sink.written[0] != "b" || sink.written[1] != "c" {
+		t.Errorf("expected the oldest batch to be dropped, actual %v", sink.written)
+	}
+}
+
+// slowSink is a testSink whose first write is slow.
+type slowSink struct {
+	testSink
+	slow bool
+}
+
+func (s *slowSink) Write(bps influx.BatchPoints) error {
+	if !s.slow {
+		s.slow = true
+		time.Sleep(50 * time.Millisecond)
+	}
+	return s.testSink.Write(bps)
+}
+
+func TestSpoolEnqueueInOrder(t *testing.T) {
+	spool, err := NewSpool("", "test sink", defaultMaxSpoolBytes)
+	if err != nil {
+		t.Fatalf("creating spool: %v", err)
+	}
+	sink := &slowSink{}
+	for _, db := range []string{"a", "b", "c"} {
+		spool.Enqueue(sink, []influx.BatchPoints{newTestSpoolBatch(t, db)})
+	}
+	if err := spool.SendQueued(sink, []influx.BatchPoints{newTestSpoolBatch(t, "d")}); err != nil {
+		t.Fatalf("sending queued batches: %v", err)
+	}
+	if expected := []string{"a", "b", "c", "d"}; !reflect.DeepEqual(sink.written, expected) {
+		t.Errorf("expected batches written in the order %v, actual %v", expected, sink.written)
+	}
+}
+
+// blockingSink is a testSink whose first write waits until it's released.
+type blockingSink struct {
+	testSink
+	writing  chan struct{}
+	released chan struct{}
+	blocked  bool
+}
+
+func (s *blockingSink) Write(bps influx.BatchPoints) error {
+	if !s.blocked {
+		s.blocked = true
+		close(s.writing)
+		<-s.released
+	}
+	return s.testSink.Write(bps)
+}
+
+func TestSpoolEnqueueBounded(t *testing.T) {
+	size := spoolBatchSize(newTestSpoolBatch(t, "a"))
+	spool, err := NewSpool("", "test sink", 2*size)
+	if err != nil {
+		t.Fatalf("creating spool: %v", err)
+	}
+	sink := &blockingSink{writing: make(chan struct{}), released: make(chan struct{})}
+	spool.Enqueue(sink, []influx.BatchPoints{newTestSpoolBatch(t, "a")})
+	<-sink.writing
+	spool.Enqueue(sink, []influx.BatchPoints{newTestSpoolBatch(t, "b")})
+	spool.Enqueue(sink, []influx.BatchPoints{newTestSpoolBatch(t, "c")})
+
+	// the queue is now past the spool's maximum size, so this waits for the hanging write, then spools the queue
+	spilled := make(chan struct{})
+	go func() {
+		spool.Enqueue(sink, []influx.BatchPoints{newTestSpoolBatch(t, "d")})
+		close(spilled)
+	}()
+	for {
+		spool.qm.Lock()
+		full := spool.queueBytes > spool.maxBytes
+		spool.qm.Unlock()
+		if full {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(sink.released)
+	<-spilled
+
+	spool.qm.Lock()
+	queued := len(spool.queue)
+	spool.qm.Unlock()
+	if queued != 0 {
+		t.Fatalf("expected the queue to be spooled, actual %d queued sends", queued)
+	}
+	if err := spool.SendQueued(sink, []influx.BatchPoints{newTestSpoolBatch(t, "e")}); err != nil {
+		t.Fatalf("sending queued batches: %v", err)
+	}
+	// the writer may send b before the queue is spooled, otherwise b is the oldest spooled batch, and is dropped
+	if expected, alt := []string{"a", "b", "c", "d", "e"}, []string{"a", "c", "d", "e"}; !reflect.DeepEqual(sink.written, expected) && !reflect.DeepEqual(sink.written, alt) {
+		t.Errorf("expected batches written in the order %v or %v, actual %v", expected, alt, sink.written)
+	}
+}
@@ -0,0 +1,181 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package main
+
+import (
+	"sync"
+	"time"
+
+	influx "github.com/influxdata/influxdb/client/v2"
+)
+
+// summaryResampleWindow is how long after a minute ends that cache bandwidth stats of that minute are still accepted, like the RESAMPLE FOR of the bandwidth_cdn_1min continuous query.
+const summaryResampleWindow = 5 * time.Minute
+
+// CDNMinuteSummary is the total bandwidth of a CDN, in kilobits per second, in each minute, by the minute's Unix time.
+type CDNMinuteSummary map[int64]float64
+
+// meanKbps is the running mean of the bandwidth stats of a cache in a minute.
+type meanKbps struct {
+	sum   float64
+	count int
+}
+
+// DailySummary accumulates the total bandwidth of each CDN in each minute, from the stream of cache stats, from which the daily summaries are computed. It's the equivalent of the bandwidth.cdn.1min series of InfluxDB, so daily summaries don't depend on any sink.
+//
+// Summaries only cover the time during which Traffic Stats was running, so days not covered since collection last started aren't complete, and shouldn't be posted.
+type DailySummary struct {
+	m *sync.Mutex
+	// since is when stats collection last started, or the zero time if it hasn't.
+	since time.Time
+	// open is the mean bandwidth of each cache of each CDN in each minute which may still receive stats.
+	open map[string]map[int64]map[string]*meanKbps
+	// closed is the total bandwidth of each CDN in each minute which may no longer receive stats.
+	closed map[string]CDNMinuteSummary
+	// lastDay is the start of the last day which was summarized.
+	lastDay time.Time
+}
+
+// NewDailySummary returns a new, empty DailySummary.
+func NewDailySummary() *DailySummary {
+	return &DailySummary{
+		m:      &sync.Mutex{},
+		open:   map[string]map[int64]map[string]*meanKbps{},
+		closed: map[string]CDNMinuteSummary{},
+	}
+}
+
+// Start records that stats collection started at the given time, e.g. when Traffic Stats started or was elected leader. Days which began before then aren't covered.
+func (s *DailySummary) Start(now time.Time) {
+	if s == nil {
+		return
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.since = now
+}
+
+// Covers returns whether stats were collected throughout the day starting at the given time, because collection started no later than it began.
+func (s *DailySummary) Covers(startTime time.Time) bool {
+	if s == nil {
+		return false
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+	return !s.since.IsZero() && !s.since.After(startTime)
+}
+
+// Add adds the bandwidth stats of the given cache stats points, and totals the minutes which may no longer receive stats. Other points are ignored.
+func (s *DailySummary) Add(bps influx.BatchPoints, now time.Time) {
+	if s == nil || bps.Database() != "cache_stats" {
+		return
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+	oldest := now.Add(-summaryResampleWindow).Truncate(time.Minute).Unix()
+	for _, pt := range bps.Points() {
+		if pt.Name() != "bandwidth" {
+			continue
+		}
+		value, ok := pointValue(pt)
+		if !ok {
+			continue
+		}
+		minute := pt.Time().Truncate(time.Minute).Unix()
+		if minute < oldest {
+			continue
+		}
+		tags := pt.Tags()
+		cdn := tags["cdn"]
+		if s.open[cdn] == nil {
+			s.open[cdn] = map[int64]map[string]*meanKbps{}
+		}
+		if s.open[cdn][minute] == nil {
+			s.open[cdn][minute] = map[string]*meanKbps{}
+		}
+		mean := s.open[cdn][minute][tags["hostname"]]
+		if mean == nil {
+			mean = &meanKbps{}
+			s.open[cdn][minute][tags["hostname"]] = mean
+		}
+		mean.sum += value
+		mean.count++
+	}
+	s.close(oldest)
+}
+
+// close totals the minutes before the given Unix time. It must be called with the lock held.
+func (s *DailySummary) close(before int64) {
+	for cdn, minutes := range s.open {
+		for minute, caches := range minutes {
+			if minute >= before {
+				continue
+			}
+			total := 0.0
+			for _, mean := range caches {
+				total += mean.sum / float64(mean.count)
+			}
+			if s.closed[cdn] == nil {
+				s.closed[cdn] = CDNMinuteSummary{}
+			}
+			s.closed[cdn][minute] = total
+			delete(minutes, minute)
+		}
+		if len(minutes) == 0 {
+			delete(s.open, cdn)
+		}
+	}
+}
+
+// Summarize returns the total bandwidth of each CDN in each minute of the day starting at the given time, and forgets that day and every day before it. It returns false if that day, or a later one, was already summarized.
+func (s *DailySummary) Summarize(startTime time.Time) (map[string]CDNMinuteSummary, bool) {
+	if s == nil {
+		return nil, false
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+	if !s.lastDay.IsZero() && !startTime.After(s.lastDay) {
+		return nil, false
+	}
+	s.lastDay = startTime
+	start := startTime.Unix()
+	end := startTime.Add(24 * time.Hour).Unix()
+	s.close(end)
+
+	summaries := map[string]CDNMinuteSummary{}
+	for cdn, minutes := range s.closed {
+		for minute, kbps := range minutes {
+			if minute >= end {
+				continue
+			}
+			if minute >= start {
+				if summaries[cdn] == nil {
+					summaries[cdn] = CDNMinuteSummary{}
+				}
+				summaries[cdn][minute] = kbps
+			}
+			delete(minutes, minute)
+		}
+		if len(minutes) == 0 {
+			delete(s.closed, cdn)
+		}
+	}
+	return summaries, true
+}
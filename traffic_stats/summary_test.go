@@ -0,0 +1,147 @@
+package main
+
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	influx "github.com/influxdata/influxdb/client/v2"
+	"github.com/influxdata/influxdb/models"
+)
+
+func newBandwidthPoints(t *testing.T, points map[string]float64, cdn string, ptTime time.Time) influx.BatchPoints {
+	bps, err := influx.NewBatchPoints(influx.BatchPointsConfig{Database: "cache_stats", Precision: "ms"})
+	if err != nil {
+		t.Fatalf("creating batch points: %v", err)
+	}
+	for hostname, kbps := range points {
+		pt, err := influx.NewPoint("bandwidth", map[string]string{"cdn": cdn, "hostname": hostname}, map[string]interface{}{"value": kbps}, ptTime)
+		if err != nil {
+			t.Fatalf("creating point: %v", err)
+		}
+		bps.AddPoint(pt)
+	}
+	return bps
+}
+
+func TestDailySummary(t *testing.T) {
+	day := time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC)
+	summary := NewDailySummary()
+
+	// two polls of two caches in the first minute, averaged per cache, and summed per CDN
+	summary.Add(newBandwidthPoints(t, map[string]float64{"edge0": 1000, "edge1": 2000}, "cdn0", day.Add(10*time.Second)), day.Add(10*time.Second))
+	summary.Add(newBandwidthPoints(t, map[string]float64{"edge0": 3000, "edge1": 2000}, "cdn0", day.Add(20*time.Second)), day.Add(20*time.Second))
+	summary.Add(newBandwidthPoints(t, map[string]float64{"edge0": 6000}, "cdn0", day.Add(time.Hour)), day.Add(time.Hour))
+	// too late for its minute
+	summary.Add(newBandwidthPoints(t, map[string]float64{"edge1": 1000000}, "cdn0", day.Add(10*time.Second)), day.Add(time.Hour))
+	// the next day isn't summarized
+	nextDay := day.Add(24 * time.Hour)
+	summary.Add(newBandwidthPoints(t, map[string]float64{"edge0": 9000}, "cdn0", nextDay), nextDay)
+
+	summaries, ok := summary.Summarize(day)
+	if !ok {
+		t.Fatal("expected day to be summarized")
+	}
+	minutes := summaries["cdn0"]
+	if len(minutes) != 2 || minutes[day.Unix()] != 4000 || minutes[day.Add(time.Hour).Unix()] != 6000 {
+		t.Errorf("expected cdn0 minutes 4000 and 6000 kbps, actual %+v", minutes)
+	}
+	if _, ok := summary.Summarize(day); ok {
+		t.Error("expected summarizing the same day again to not summarize")
+	}
+
+	summaries, ok = summary.Summarize(nextDay)
+	if !ok || summaries["cdn0"][nextDay.Unix()] != 9000 {
+		t.Errorf("expected next day summarized with 9000 kbps, actual %v %+v", ok, summaries)
+	}
+}
+
+func TestDailySummaryCovers(t *testing.T) {
+	day := time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC)
+	summary := NewDailySummary()
+	if summary.Covers(day) {
+		t.Error("expected a day not covered before collection started")
+	}
+
+	summary.Start(day.Add(-time.Minute))
+	if !summary.Covers(day) {
+		t.Error("expected a day covered when collection started before it")
+	}
+
+	// e.g. restarted, or re-elected leader, during the day
+	summary.Start(day.Add(time.Hour))
+	if summary.Covers(day) {
+		t.Error("expected a day not covered when collection restarted during it")
+	}
+	if !summary.Covers(day.Add(24 * time.Hour)) {
+		t.Error("expected the next day covered")
+	}
+}
+
+func TestCalcDailyMaxGbpsAndBytesServed(t *testing.T) {
+	day := time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC)
+	summaries := map[string]CDNMinuteSummary{
+		"cdn0": {day.Unix(): 8000000, day.Add(time.Minute).Unix(): 16000000},
+	}
+	bp, err := influx.NewBatchPoints(influx.BatchPointsConfig{Database: "daily_stats", Precision: "s"})
+	if err != nil {
+		t.Fatalf("creating batch points: %v", err)
+	}
+	statsSummaries := append(calcDailyMaxGbps(summaries, bp), calcDailyBytesServed(summaries, bp, day)...)
+	if len(statsSummaries) != 2 || *statsSummaries[0].StatName != "daily_maxgbps" || *statsSummaries[0].StatValue != 16 || *statsSummaries[1].StatName != "daily_bytesserved" || !statsSummaries[1].StatDate.Equal(day) {
+		t.Errorf("expected daily_maxgbps and daily_bytesserved stats summaries, actual %+v", statsSummaries)
+	}
+
+	values := map[string]float64{}
+	for _, pt := range bp.Points() {
+		value, _ := pointValue(pt)
+		values[pt.Name()] = value
+		if pt.Name() == "daily_maxgbps" && !pt.Time().Equal(day.Add(time.Minute)) {
+			t.Errorf("expected max gbps at the minute of the maximum, actual %v", pt.Time())
+		}
+	}
+	if values["daily_maxgbps"] != 16 {
+		t.Errorf("expected daily max gbps 16, actual %v", values["daily_maxgbps"])
+	}
+	// (8000000 + 16000000) kbps * 60 s / 8 = 180000000 kB = 0.18 TB
+	if bytesServed := values["daily_bytesserved"]; bytesServed < 0.1799999 || bytesServed > 0.1800001 {
+		t.Errorf("expected daily bytes served 0.18, actual %v", bytesServed)
+	}
+}
+
+func TestCDNMinuteSummariesFromResults(t *testing.T) {
+	res := []influx.Result{{Series: []models.Row{{
+		Tags:    map[string]string{"cdn": "cdn0"},
+		Columns: []string{"time", "mean"},
+		Values: [][]interface{}{
+			{"2021-03-04T00:00:00Z", json.Number("4000")},
+			{"2021-03-04T00:01:00Z", nil},
+			{"2021-03-04T00:02:00Z", json.Number("6000")},
+		},
+	}}}}
+	summaries := cdnMinuteSummariesFromResults(res)
+	day := time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC)
+	minutes := summaries["cdn0"]
+	if len(minutes) != 2 || minutes[day.Unix()] != 4000 || minutes[day.Add(2*time.Minute).Unix()] != 6000 {
+		t.Errorf("expected cdn0 minutes 4000 and 6000 kbps, actual %+v", summaries)
+	}
+}
@@ -30,6 +30,7 @@ import (
 	"net/url"
 	"os"
 	"os/signal"
+	"sort"
 	"strconv"
 	"strings"
 	"syscall"
@@ -56,24 +57,33 @@ const (
 
 // StartupConfig contains all fields necessary to create a traffic stats session.
 type StartupConfig struct {
-	ToUser                      string   `json:"toUser"`
-	ToPasswd                    string   `json:"toPasswd"`
-	ToURL                       string   `json:"toUrl"`
-	InfluxUser                  string   `json:"influxUser"`
-	InfluxPassword              string   `json:"influxPassword"`
-	InfluxURLs                  []string `json:"influxUrls"`
-	PollingInterval             int      `json:"pollingInterval"`
-	DailySummaryPollingInterval int      `json:"dailySummaryPollingInterval"`
-	PublishingInterval          int      `json:"publishingInterval"`
-	ConfigInterval              int      `json:"configInterval"`
-	MaxPublishSize              int      `json:"maxPublishSize"`
-	StatusToMon                 string   `json:"statusToMon"`
-	SeelogConfig                string   `json:"seelogConfig"`
-	CacheRetentionPolicy        string   `json:"cacheRetentionPolicy"`
-	DsRetentionPolicy           string   `json:"dsRetentionPolicy"`
-	DailySummaryRetentionPolicy string   `json:"dailySummaryRetentionPolicy"`
+	ToUser                      string       `json:"toUser"`
+	ToPasswd                    string       `json:"toPasswd"`
+	ToURL                       string       `json:"toUrl"`
+	InfluxUser                  string       `json:"influxUser"`
+	InfluxPassword              string       `json:"influxPassword"`
+	InfluxURLs                  []string     `json:"influxUrls"`
+	PollingInterval             int          `json:"pollingInterval"`
+	DailySummaryPollingInterval int          `json:"dailySummaryPollingInterval"`
+	PublishingInterval          int          `json:"publishingInterval"`
+	ConfigInterval              int          `json:"configInterval"`
+	MaxPublishSize              int          `json:"maxPublishSize"`
+	StatusToMon                 string       `json:"statusToMon"`
+	SeelogConfig                string       `json:"seelogConfig"`
+	CacheRetentionPolicy        string       `json:"cacheRetentionPolicy"`
+	DsRetentionPolicy           string       `json:"dsRetentionPolicy"`
+	DailySummaryRetentionPolicy string       `json:"dailySummaryRetentionPolicy"`
+	Sinks                       []SinkConfig `json:"sinks"`
+	SpoolDir                    string       `json:"spoolDir"`
+	MaxSpoolBytes               int64        `json:"maxSpoolBytes"`
 	BpsChan                     chan influx.BatchPoints
 	InfluxDBs                   []*InfluxDBProps
+	MetricSinks                 []Sink
+	Spools                      map[string]*Spool
+	Summary                     *DailySummary
+
+	// LeaderElection is the configuration of leader election. If it's nil, this instance is always the leader.
+	LeaderElection *LeaderElectionConfig `json:"leaderElection"`
 }
 
 // RunningConfig is used to store runtime configuration for Traffic Stats.  This includes information
@@ -105,6 +115,8 @@ func main() {
 	var tickers Timers
 
 	configFile := flag.String("cfg", "", "The config file")
+	backfillStart := flag.String("backfill-start", "", "Recompute and rewrite the daily summaries of the days from this date (YYYY-MM-DD) from InfluxDB, then exit")
+	backfillEnd := flag.String("backfill-end", "", "The last date (YYYY-MM-DD) of daily summaries to recompute, defaulting to -backfill-start")
 	flag.Parse()
 	if *configFile == "" {
 		flag.Usage()
@@ -119,16 +131,52 @@ func main() {
 		panic(err)
 	}
 
+	if *backfillStart != "" {
+		if err := runBackfill(config, *backfillStart, *backfillEnd); err != nil {
+			log.Errorf("backfilling daily summaries: %v", err)
+			log.Flush()
+			fmt.Fprintf(os.Stderr, "backfilling daily summaries: %v\n", err)
+			os.Exit(1)
+		}
+		log.Flush()
+		os.Exit(0)
+	}
+
 	Bps = make(map[string]influx.BatchPoints)
 	config.BpsChan = make(chan influx.BatchPoints)
+	config.Summary = NewDailySummary()
 
 	defer log.Flush()
 
+	// Only the leader polls Traffic Monitors and writes daily summaries. The leader election config is only read at startup.
+	isLeader := true
+	// missedPoll is whether Traffic Monitors weren't polled at a poll tick because this instance wasn't the leader, so the daily summary must start over when it's elected.
+	missedPoll := false
+	var leaderChan chan bool
+	if config.LeaderElection != nil {
+		elector, err := NewLeaderElector(*config.LeaderElection)
+		if err != nil {
+			err = fmt.Errorf("could not start leader election: %v", err)
+			log.Error(err)
+			panic(err)
+		}
+		isLeader = false
+		missedPoll = true
+		leaderChan = make(chan bool)
+		go runLeaderElection(elector, leaderChan)
+		log.Info("Standing by until elected leader")
+	}
+
 	configChan := make(chan RunningConfig)
 	go getToData(config, true, configChan)
 	runningConfig := <-configChan
 
 	tickers = setTimers(config)
+	if isLeader {
+		now := time.Now()
+		config.Summary.Start(now)
+		warnSummarySkipped("Started", now)
+	}
 
 	termChan := make(chan os.Signal, 1)
 	signal.Notify(termChan, syscall.SIGKILL, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
@@ -149,19 +197,41 @@ func main() {
 			}
 		case <-termChan:
 			log.Info("Shutdown Request Received - Sending stored metrics then quitting")
-			for _, val := range Bps {
-				sendMetrics(config, val, false)
+			batches := batchPointsList(Bps)
+			for _, sink := range config.MetricSinks {
+				if err := config.Spools[sink.Name()].SendQueued(sink, chunkMetrics(config, batches)); err != nil {
+					log.Errorf("sending metrics to %s: error writing batch points, spooled %d batches: %v", sink.Name(), config.Spools[sink.Name()].Len(), err)
+				}
 			}
 			os.Exit(0)
 		case <-tickers.Publish:
-			for key, val := range Bps {
-				go sendMetrics(config, val, true)
+			batches := batchPointsList(Bps)
+			for _, sink := range config.MetricSinks {
+				sendMetrics(config, sink, batches)
+			}
+			for key := range Bps {
 				delete(Bps, key)
 			}
+		case isLeader = <-leaderChan:
+			if isLeader && !missedPoll {
+				log.Warn("Re-elected leader before missing a poll - polling Traffic Monitors")
+			} else if isLeader {
+				now := time.Now()
+				config.Summary.Start(now)
+				missedPoll = false
+				log.Warn("Elected leader - polling Traffic Monitors")
+				warnSummarySkipped("Elected leader", now)
+			} else {
+				log.Warn("No longer the leader - standing by")
+			}
 		case runningConfig = <-configChan:
 		case <-tickers.Config:
 			go getToData(config, false, configChan)
 		case <-tickers.Poll:
+			if !isLeader {
+				missedPoll = true
+				break
+			}
 			for cdnName, urls := range runningConfig.HealthUrls {
 				for _, u := range urls {
 					log.Debug(cdnName, " -> ", u)
@@ -169,22 +239,44 @@ func main() {
 				}
 			}
 		case now := <-tickers.DailySummary:
-			go calcDailySummary(now, config, runningConfig)
+			if isLeader {
+				go calcDailySummary(now, config, runningConfig)
+			}
 		case batchPoints := <-config.BpsChan:
 			log.Debug("Received ", len(batchPoints.Points()), " stats")
-			key := fmt.Sprintf("%s%s", batchPoints.Database(), batchPoints.RetentionPolicy())
-			bp, ok := Bps[key]
-			if ok {
-				for _, p := range batchPoints.Points() {
-					bp.AddPoint(p)
-				}
-				log.Debug("Aggregating ", len(bp.Points()), " stats to ", key)
-			} else {
-				Bps[key] = batchPoints
-				log.Debug("Created ", key)
-			}
+			config.Summary.Add(batchPoints, time.Now())
+			aggregateBatchPoints(Bps, batchPoints)
+		}
+	}
+}
+
+// aggregateBatchPoints adds the given batch points to those of the same database and retention policy in the given map.
+func aggregateBatchPoints(bpsMap map[string]influx.BatchPoints, batchPoints influx.BatchPoints) {
+	key := fmt.Sprintf("%s%s", batchPoints.Database(), batchPoints.RetentionPolicy())
+	bp, ok := bpsMap[key]
+	if ok {
+		for _, p := range batchPoints.Points() {
+			bp.AddPoint(p)
 		}
+		log.Debug("Aggregating ", len(bp.Points()), " stats to ", key)
+	} else {
+		bpsMap[key] = batchPoints
+		log.Debug("Created ", key)
+	}
+}
+
+// batchPointsList returns the batch points in the given map, in a consistent order.
+func batchPointsList(bpsMap map[string]influx.BatchPoints) []influx.BatchPoints {
+	keys := make([]string, 0, len(bpsMap))
+	for key := range bpsMap {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	batches := make([]influx.BatchPoints, 0, len(keys))
+	for _, key := range keys {
+		batches = append(batches, bpsMap[key])
 	}
+	return batches
 }
 
 func setTimers(config StartupConfig) Timers {
@@ -217,6 +309,7 @@ func loadStartupConfig(configFile string, oldConfig StartupConfig) (StartupConfi
 	}
 
 	config.BpsChan = oldConfig.BpsChan
+	config.Summary = oldConfig.Summary
 
 	if config.PollingInterval == 0 {
 		config.PollingInterval = defaultPollingInterval
@@ -233,6 +326,9 @@ func loadStartupConfig(configFile string, oldConfig StartupConfig) (StartupConfi
 	if config.MaxPublishSize == 0 {
 		config.MaxPublishSize = defaultMaxPublishSize
 	}
+	if config.MaxSpoolBytes == 0 {
+		config.MaxSpoolBytes = defaultMaxSpoolBytes
+	}
 
 	logger, err := log.LoggerFromConfigAsFile(config.SeelogConfig)
 	if err != nil {
@@ -241,9 +337,6 @@ func loadStartupConfig(configFile string, oldConfig StartupConfig) (StartupConfi
 	log.ReplaceLogger(logger)
 	log.Info("Replaced logger, see log file according to", config.SeelogConfig)
 
-	if len(config.InfluxURLs) == 0 {
-		return config, fmt.Errorf("No InfluxDB urls provided in influxUrls, please provide at least one valid URL.  e.g. \"influxUrls\": [\"http://localhost:8086\"]")
-	}
 	for _, u := range config.InfluxURLs {
 		influxDBProps := InfluxDBProps{
 			URL: u,
@@ -251,29 +344,56 @@ func loadStartupConfig(configFile string, oldConfig StartupConfig) (StartupConfi
 		config.InfluxDBs = append(config.InfluxDBs, &influxDBProps)
 	}
 
-	//Close old connections explicitly
-	for _, host := range oldConfig.InfluxDBs {
-		if host.InfluxClient != nil {
-			host.InfluxClient.Close()
+	config.MetricSinks, err = newSinks(config)
+	if err != nil {
+		return config, err
+	}
+
+	// spools are kept across reloads, so unsent stats aren't lost
+	config.Spools = make(map[string]*Spool, len(config.MetricSinks))
+	for _, sink := range config.MetricSinks {
+		if spool, ok := oldConfig.Spools[sink.Name()]; ok {
+			config.Spools[sink.Name()] = spool
+			continue
 		}
+		config.Spools[sink.Name()], err = NewSpool(config.SpoolDir, sink.Name(), config.MaxSpoolBytes)
+		if err != nil {
+			return config, fmt.Errorf("creating spool for %s: %v", sink.Name(), err)
+		}
+	}
+
+	//Close old connections explicitly
+	for _, sink := range oldConfig.MetricSinks {
+		sink.Close()
 	}
 
 	return config, nil
 }
 
+// warnSummarySkipped logs that the daily summaries of the day in progress won't be written, because stats collection started during it, e.g. when Traffic Stats started or was elected leader.
+func warnSummarySkipped(event string, now time.Time) {
+	startTime := now.UTC().Truncate(24 * time.Hour)
+	if now.Equal(startTime) {
+		return
+	}
+	day := startTime.Format("2006-01-02")
+	log.Warnf("%s during %s (UTC) - that day's daily summaries won't be written, because they would only cover the stats polled from now on. Recompute them with -backfill-start %s once the day is over", event, day, day)
+}
+
 func calcDailySummary(now time.Time, config StartupConfig, runningConfig RunningConfig) {
 	log.Infof("lastSummaryTime is %v", runningConfig.LastSummaryTime)
 	if runningConfig.LastSummaryTime.Day() != now.Day() {
 		startTime := now.Truncate(24 * time.Hour).Add(-24 * time.Hour)
 		endTime := startTime.Add(24 * time.Hour)
-		log.Info("Summarizing from ", startTime, " (", startTime.Unix(), ") to ", endTime, " (", endTime.Unix(), ")")
-
-		// influx connection
-		influxClient, err := influxConnect(config)
-		if err != nil {
-			log.Errorf("could not connect to InfluxDb to get daily summary stats: %v", err)
+		summaries, ok := config.Summary.Summarize(startTime)
+		if !ok {
+			return
+		}
+		if !config.Summary.Covers(startTime) {
+			log.Warnf("Not writing daily summaries of %s: stats weren't collected for the whole day. Recompute them with -backfill-start %s", startTime.Format("2006-01-02"), startTime.Format("2006-01-02"))
 			return
 		}
+		log.Info("Summarizing from ", startTime, " (", startTime.Unix(), ") to ", endTime, " (", endTime.Unix(), ")")
 
 		bp, _ := influx.NewBatchPoints(influx.BatchPointsConfig{
 			Database:        "daily_stats",
@@ -281,136 +401,101 @@ func calcDailySummary(now time.Time, config StartupConfig, runningConfig Running
 			RetentionPolicy: config.DailySummaryRetentionPolicy,
 		})
 
-		calcDailyMaxGbps(influxClient, bp, startTime, endTime, config)
-		calcDailyBytesServed(influxClient, bp, startTime, endTime, config)
+		statsSummaries := append(calcDailyMaxGbps(summaries, bp), calcDailyBytesServed(summaries, bp, startTime)...)
+		for _, statsSummary := range statsSummaries {
+			go writeSummaryStats(config, statsSummary)
+		}
+		config.BpsChan <- bp
 		log.Info("Collected daily stats @ ", now)
 	}
 }
 
-func calcDailyMaxGbps(client influx.Client, bp influx.BatchPoints, startTime time.Time, endTime time.Time, config StartupConfig) {
+// calcDailyMaxGbps adds the maximum bandwidth of each CDN in the given summaries, in gigabits per second, to the batch points, and returns it as stats summaries to write to Traffic Ops.
+func calcDailyMaxGbps(summaries map[string]CDNMinuteSummary, bp influx.BatchPoints) []tc.StatsSummary {
 	kilobitsToGigabits := 1000000.00
-	queryString := fmt.Sprintf(`select time, cdn, max(value) from "monthly"."bandwidth.cdn.1min" where time > '%s' and time < '%s' group by cdn`, startTime.Format(time.RFC3339), endTime.Format(time.RFC3339))
-	log.Infof("queryString = %v\n", queryString)
-	res, err := queryDB(client, queryString, "cache_stats")
-	if err != nil {
-		log.Errorf("An error occured getting max bandwidth! %v\n", err)
-		return
-	}
-	if res != nil && len(res[0].Series) > 0 {
-		for _, row := range res[0].Series {
-			for _, record := range row.Values {
-				t := record[0].(string)
-				if record[1] != nil {
-					cdn := record[1].(string)
-					value, err := record[2].(json.Number).Float64()
-					if err != nil {
-						log.Errorf("Couldn't parse value from record %v\n", record)
-						continue
-					}
-					value = value / kilobitsToGigabits
-					statTime, _ := time.Parse(time.RFC3339, t)
-					log.Infof("max gbps for cdn %v = %v", cdn, value)
-					var statsSummary tc.StatsSummary
-					statsSummary.CDNName = util.StrPtr(cdn)
-					statsSummary.DeliveryService = util.StrPtr("all")
-					statsSummary.StatName = util.StrPtr("daily_maxgbps")
-					statsSummary.StatValue = util.FloatPtr(value)
-					statsSummary.SummaryTime = time.Now()
-					statsSummary.StatDate = &statTime
-					go writeSummaryStats(config, statsSummary)
-
-					//write to influxdb
-					tags := map[string]string{"cdn": cdn, "deliveryservice": "all"}
-					fields := map[string]interface{}{
-						"value": value,
-					}
-					pt, err := influx.NewPoint(
-						"daily_maxgbps",
-						tags,
-						fields,
-						statTime,
-					)
-					if err != nil {
-						log.Errorf("error adding data point for max Gbps...%v\n", err)
-						continue
-					}
-					bp.AddPoint(pt)
-				}
+	statsSummaries := []tc.StatsSummary{}
+	for cdn, minutes := range summaries {
+		maxMinute, maxKbps := int64(0), -1.0
+		for minute, kbps := range minutes {
+			if kbps > maxKbps || (kbps == maxKbps && minute < maxMinute) {
+				maxMinute, maxKbps = minute, kbps
 			}
 		}
+		if maxKbps < 0 {
+			continue
+		}
+		value := maxKbps / kilobitsToGigabits
+		statTime := time.Unix(maxMinute, 0).UTC()
+		log.Infof("max gbps for cdn %v = %v", cdn, value)
+		var statsSummary tc.StatsSummary
+		statsSummary.CDNName = util.StrPtr(cdn)
+		statsSummary.DeliveryService = util.StrPtr("all")
+		statsSummary.StatName = util.StrPtr("daily_maxgbps")
+		statsSummary.StatValue = util.FloatPtr(value)
+		statsSummary.SummaryTime = time.Now()
+		statsSummary.StatDate = &statTime
+		statsSummaries = append(statsSummaries, statsSummary)
+
+		//write to the sinks
+		tags := map[string]string{"cdn": cdn, "deliveryservice": "all"}
+		fields := map[string]interface{}{
+			"value": value,
+		}
+		pt, err := influx.NewPoint(
+			"daily_maxgbps",
+			tags,
+			fields,
+			statTime,
+		)
+		if err != nil {
+			log.Errorf("error adding data point for max Gbps...%v\n", err)
+			continue
+		}
+		bp.AddPoint(pt)
 	}
-	config.BpsChan <- bp
+	return statsSummaries
 }
 
-func calcDailyBytesServed(client influx.Client, bp influx.BatchPoints, startTime time.Time, endTime time.Time, config StartupConfig) {
+// calcDailyBytesServed adds the bytes served by each CDN in the given summaries, in terabytes, to the batch points, and returns it as stats summaries to write to Traffic Ops.
+func calcDailyBytesServed(summaries map[string]CDNMinuteSummary, bp influx.BatchPoints, startTime time.Time) []tc.StatsSummary {
 	bytesToTerabytes := 1000000000.00
+	statsSummaries := []tc.StatsSummary{}
 	sampleTimeSecs := 60.00
 	bitsTobytes := 8.00
-	queryString := fmt.Sprintf(`select mean(value) from "monthly"."bandwidth.cdn.1min" where time > '%s' and time < '%s' group by time(1m), cdn`, startTime.Format(time.RFC3339), endTime.Format(time.RFC3339))
-	log.Infof("queryString = %v\n", queryString)
-	res, err := queryDB(client, queryString, "cache_stats")
-	if err != nil {
-		log.Error("An error occured getting max bandwidth!\n")
-		return
-	}
-	if res != nil && len(res[0].Series) > 0 {
-		for _, row := range res[0].Series {
-			bytesServed := float64(0)
-			cdn := row.Tags["cdn"]
-			for _, record := range row.Values {
-				if record[1] != nil {
-					value, err := record[1].(json.Number).Float64()
-					if err != nil {
-						log.Errorf("Couldn't parse value from record %v\n", record)
-						continue
-					}
-					bytesServed += value * sampleTimeSecs / bitsTobytes
-				}
-			}
-			bytesServedTB := bytesServed / bytesToTerabytes
-			log.Infof("TBytes served for cdn %v = %v", cdn, bytesServedTB)
-			//write to Traffic Ops
-			var statsSummary tc.StatsSummary
-			statsSummary.CDNName = util.StrPtr(cdn)
-			statsSummary.DeliveryService = util.StrPtr("all")
-			statsSummary.StatName = util.StrPtr("daily_bytesserved")
-			statsSummary.StatValue = util.FloatPtr(bytesServedTB)
-			statsSummary.SummaryTime = time.Now()
-			statsSummary.StatDate = &startTime
-			go writeSummaryStats(config, statsSummary)
-			//write to Influxdb
-			tags := map[string]string{"cdn": cdn, "deliveryservice": "all"}
-			fields := map[string]interface{}{
-				"value": bytesServedTB, //converted to TB
-			}
-			pt, err := influx.NewPoint(
-				"daily_bytesserved",
-				tags,
-				fields,
-				startTime,
-			)
-			if err != nil {
-				log.Errorf("error adding creating data point for max Gbps...%v\n", err)
-				continue
-			}
-			bp.AddPoint(pt)
+	for cdn, minutes := range summaries {
+		bytesServed := float64(0)
+		for _, kbps := range minutes {
+			bytesServed += kbps * sampleTimeSecs / bitsTobytes
 		}
-		config.BpsChan <- bp
-	}
-}
-
-func queryDB(con influx.Client, cmd string, database string) (res []influx.Result, err error) {
-	q := influx.Query{
-		Command:  cmd,
-		Database: database,
-	}
-	if response, err := con.Query(q); err == nil {
-		if response.Error() != nil {
-			return res, response.Error()
+		bytesServedTB := bytesServed / bytesToTerabytes
+		log.Infof("TBytes served for cdn %v = %v", cdn, bytesServedTB)
+		//write to Traffic Ops
+		var statsSummary tc.StatsSummary
+		statsSummary.CDNName = util.StrPtr(cdn)
+		statsSummary.DeliveryService = util.StrPtr("all")
+		statsSummary.StatName = util.StrPtr("daily_bytesserved")
+		statsSummary.StatValue = util.FloatPtr(bytesServedTB)
+		statsSummary.SummaryTime = time.Now()
+		statsSummary.StatDate = &startTime
+		statsSummaries = append(statsSummaries, statsSummary)
+		//write to the sinks
+		tags := map[string]string{"cdn": cdn, "deliveryservice": "all"}
+		fields := map[string]interface{}{
+			"value": bytesServedTB, //converted to TB
+		}
+		pt, err := influx.NewPoint(
+			"daily_bytesserved",
+			tags,
+			fields,
+			startTime,
+		)
+		if err != nil {
+			log.Errorf("error adding creating data point for max Gbps...%v\n", err)
+			continue
 		}
-		res = response.Results
+		bp.AddPoint(pt)
 	}
-	return
+	return statsSummaries
 }
 
 func writeSummaryStats(config StartupConfig, statsSummary tc.StatsSummary) {
@@ -772,44 +857,34 @@ func influxConnect(config StartupConfig) (influx.Client, error) {
 	return nil, err
 }
 
-func sendMetrics(config StartupConfig, bps influx.BatchPoints, retry bool) {
-	influxClient, err := influxConnect(config)
-	if err != nil {
-		if retry {
-			config.BpsChan <- bps
-		}
-		log.Errorf("sending metrics to InfluxDB: unable to get InfluxDB client: %v", err)
-		return
-	}
+// sendMetrics queues the given batch points to be sent to the sink, in chunks of at most maxPublishSize points, by the writer of the sink's spool, so that they're written in order, and chunks which can't be written are spooled and replayed in order.
+func sendMetrics(config StartupConfig, sink Sink, batches []influx.BatchPoints) {
+	config.Spools[sink.Name()].Enqueue(sink, chunkMetrics(config, batches))
+}
 
-	pts := bps.Points()
-	for len(pts) > 0 {
-		chunkBps, err := influx.NewBatchPoints(influx.BatchPointsConfig{
-			Database:        bps.Database(),
-			Precision:       bps.Precision(),
-			RetentionPolicy: bps.RetentionPolicy(),
-		})
-		if err != nil {
-			if retry {
-				config.BpsChan <- chunkBps
+// chunkMetrics splits the given batch points into chunks of at most maxPublishSize points.
+func chunkMetrics(config StartupConfig, batches []influx.BatchPoints) []influx.BatchPoints {
+	chunks := []influx.BatchPoints{}
+	for _, bps := range batches {
+		pts := bps.Points()
+		for len(pts) > 0 {
+			chunkBps, err := influx.NewBatchPoints(influx.BatchPointsConfig{
+				Database:        bps.Database(),
+				Precision:       bps.Precision(),
+				RetentionPolicy: bps.RetentionPolicy(),
+			})
+			if err != nil {
+				log.Errorf("sending metrics: error creating new batch points: %v", err)
+				return chunks
 			}
-			log.Errorf("sending metrics to InfluxDB: error creating new batch points: %v", err)
-		}
-		for _, p := range pts[:intMin(config.MaxPublishSize, len(pts))] {
-			chunkBps.AddPoint(p)
-		}
-		pts = pts[intMin(config.MaxPublishSize, len(pts)):]
-
-		err = influxClient.Write(chunkBps)
-		if err != nil {
-			if retry {
-				config.BpsChan <- chunkBps
+			for _, p := range pts[:intMin(config.MaxPublishSize, len(pts))] {
+				chunkBps.AddPoint(p)
 			}
-			log.Errorf("sending metrics to InfluxDB: error writing batch points: %v", err)
-		} else {
-			log.Info(fmt.Sprintf("Sent %v stats for %v", len(chunkBps.Points()), chunkBps.Database()))
+			pts = pts[intMin(config.MaxPublishSize, len(pts)):]
+			chunks = append(chunks, chunkBps)
 		}
 	}
+	return chunks
 }
 
 func intMin(a, b int) int {